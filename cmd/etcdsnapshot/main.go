@@ -20,6 +20,7 @@ func main() {
 			etcdsnapshot.List,
 			etcdsnapshot.Prune,
 			etcdsnapshot.Save,
+			etcdsnapshot.Verify,
 		),
 	}
 
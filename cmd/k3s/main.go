@@ -37,16 +37,26 @@ func main() {
 		return
 	}
 
+	serverCommand := internalCLIAction(version.Program+"-server", dataDir, os.Args)
 	tokenCommand := internalCLIAction(version.Program+"-"+cmds.TokenCommand, dataDir, os.Args)
 	etcdsnapshotCommand := internalCLIAction(version.Program+"-"+cmds.EtcdSnapshotCommand, dataDir, os.Args)
+	etcdCommand := internalCLIAction(version.Program+"-"+cmds.EtcdCommand, dataDir, os.Args)
 	secretsencryptCommand := internalCLIAction(version.Program+"-"+cmds.SecretsEncryptCommand, dataDir, os.Args)
 	certCommand := internalCLIAction(version.Program+"-"+cmds.CertCommand, dataDir, os.Args)
+	statusCommand := internalCLIAction(version.Program+"-"+cmds.StatusCommand, dataDir, os.Args)
+	logsCommand := internalCLIAction(version.Program+"-"+cmds.LogsCommand, dataDir, os.Args)
+	checkCommand := internalCLIAction(version.Program+"-"+cmds.CheckCommand, dataDir, os.Args)
+	dataDirCommand := internalCLIAction(version.Program+"-"+cmds.DataDirCommand, dataDir, os.Args)
+	keystoreCommand := internalCLIAction(version.Program+"-"+cmds.KeystoreCommand, dataDir, os.Args)
+	imagesCommand := internalCLIAction(version.Program+"-"+cmds.ImagesCommand, dataDir, os.Args)
+	kubeconfigCommand := internalCLIAction(version.Program+"-"+cmds.KubeconfigCommand, dataDir, os.Args)
+	nodeCommand := internalCLIAction(version.Program+"-"+cmds.NodeCommand, dataDir, os.Args)
 
 	// Handle subcommand invocation (k3s server, k3s crictl, etc)
 	app := cmds.NewApp()
 	app.EnableBashCompletion = true
 	app.Commands = []cli.Command{
-		cmds.NewServerCommand(internalCLIAction(version.Program+"-server", dataDir, os.Args)),
+		cmds.NewServerCommand(serverCommand, serverCommand),
 		cmds.NewAgentCommand(internalCLIAction(version.Program+"-agent", dataDir, os.Args)),
 		cmds.NewKubectlCommand(externalCLIAction("kubectl", dataDir)),
 		cmds.NewCRICTL(externalCLIAction("crictl", dataDir)),
@@ -63,6 +73,14 @@ func main() {
 			etcdsnapshotCommand,
 			etcdsnapshotCommand,
 			etcdsnapshotCommand,
+			etcdsnapshotCommand,
+		),
+		cmds.NewEtcdCommand(
+			cmds.NewEtcdSubcommands(
+				etcdCommand,
+				etcdCommand,
+				etcdCommand,
+			),
 		),
 		cmds.NewSecretsEncryptCommands(
 			secretsencryptCommand,
@@ -78,6 +96,44 @@ func main() {
 				certCommand,
 			),
 		),
+		cmds.NewStatusCommand(statusCommand),
+		cmds.NewLogsCommand(logsCommand),
+		cmds.NewCheckCommand(
+			cmds.NewCheckSubcommands(
+				checkCommand,
+			),
+		),
+		cmds.NewDataDirCommand(
+			cmds.NewDataDirSubcommands(
+				dataDirCommand,
+			),
+		),
+		cmds.NewKeystoreCommand(
+			cmds.NewKeystoreSubcommands(
+				keystoreCommand,
+				keystoreCommand,
+			),
+		),
+		cmds.NewImagesCommand(
+			cmds.NewImagesSubcommands(
+				imagesCommand,
+				imagesCommand,
+			),
+		),
+		cmds.NewKubeconfigCommand(
+			cmds.NewKubeconfigSubcommands(
+				kubeconfigCommand,
+				kubeconfigCommand,
+				kubeconfigCommand,
+			),
+		),
+		cmds.NewNodeCommand(
+			cmds.NewNodeSubcommands(
+				nodeCommand,
+				nodeCommand,
+			),
+		),
+		cmds.NewVersionCommand(internalCLIAction(version.Program+"-"+cmds.VersionCommand, dataDir, os.Args)),
 		cmds.NewCompletionCommand(internalCLIAction(version.Program+"-completion", dataDir, os.Args)),
 	}
 
@@ -150,9 +206,10 @@ func externalCLIAction(cmd, dataDir string) func(cli *cli.Context) error {
 }
 
 // externalCLI calls an external binary, fixing up argv[0] to the correct name.
-// crictl needs extra help to find its config file so we do that here too.
+// crictl needs extra help to find its config file so we do that here too; ctr
+// also uses it to find the containerd socket.
 func externalCLI(cli, dataDir string, args []string) error {
-	if cli == "crictl" {
+	if cli == "crictl" || cli == "ctr" {
 		if os.Getenv("CRI_CONFIG_FILE") == "" {
 			os.Setenv("CRI_CONFIG_FILE", findCriConfig(dataDir))
 		}
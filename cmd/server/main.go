@@ -7,22 +7,34 @@ import (
 	"path/filepath"
 
 	"github.com/docker/docker/pkg/reexec"
+	"github.com/k3s-io/k3s/pkg/agent/registryauth"
 	"github.com/k3s-io/k3s/pkg/cli/agent"
 	"github.com/k3s-io/k3s/pkg/cli/cert"
+	"github.com/k3s-io/k3s/pkg/cli/check"
 	"github.com/k3s-io/k3s/pkg/cli/cmds"
 	"github.com/k3s-io/k3s/pkg/cli/completion"
 	"github.com/k3s-io/k3s/pkg/cli/crictl"
 	"github.com/k3s-io/k3s/pkg/cli/ctr"
+	"github.com/k3s-io/k3s/pkg/cli/datadir"
+	"github.com/k3s-io/k3s/pkg/cli/etcdmember"
 	"github.com/k3s-io/k3s/pkg/cli/etcdsnapshot"
+	"github.com/k3s-io/k3s/pkg/cli/images"
+	"github.com/k3s-io/k3s/pkg/cli/keystore"
+	"github.com/k3s-io/k3s/pkg/cli/kubeconfig"
 	"github.com/k3s-io/k3s/pkg/cli/kubectl"
+	"github.com/k3s-io/k3s/pkg/cli/logs"
+	"github.com/k3s-io/k3s/pkg/cli/node"
 	"github.com/k3s-io/k3s/pkg/cli/secretsencrypt"
 	"github.com/k3s-io/k3s/pkg/cli/server"
+	"github.com/k3s-io/k3s/pkg/cli/status"
 	"github.com/k3s-io/k3s/pkg/cli/token"
+	"github.com/k3s-io/k3s/pkg/cli/version"
 	"github.com/k3s-io/k3s/pkg/configfilearg"
 	"github.com/k3s-io/k3s/pkg/containerd"
+	crictl2 "github.com/k3s-io/k3s/pkg/crictl"
 	ctr2 "github.com/k3s-io/k3s/pkg/ctr"
+	"github.com/k3s-io/k3s/pkg/kine"
 	kubectl2 "github.com/k3s-io/k3s/pkg/kubectl"
-	crictl2 "github.com/kubernetes-sigs/cri-tools/cmd/crictl"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
@@ -32,6 +44,8 @@ func init() {
 	reexec.Register("kubectl", kubectl2.Main)
 	reexec.Register("crictl", crictl2.Main)
 	reexec.Register("ctr", ctr2.Main)
+	reexec.Register(kine.Command, kine.Main)
+	reexec.Register(registryauth.Command, registryauth.Main)
 }
 
 func main() {
@@ -44,7 +58,7 @@ func main() {
 
 	app := cmds.NewApp()
 	app.Commands = []cli.Command{
-		cmds.NewServerCommand(server.Run),
+		cmds.NewServerCommand(server.Run, server.RenderManifests),
 		cmds.NewAgentCommand(agent.Run),
 		cmds.NewKubectlCommand(kubectl.Run),
 		cmds.NewCRICTL(crictl.Run),
@@ -60,6 +74,14 @@ func main() {
 			etcdsnapshot.List,
 			etcdsnapshot.Prune,
 			etcdsnapshot.Save,
+			etcdsnapshot.Verify,
+		),
+		cmds.NewEtcdCommand(
+			cmds.NewEtcdSubcommands(
+				etcdmember.List,
+				etcdmember.Promote,
+				etcdmember.Remove,
+			),
 		),
 		cmds.NewSecretsEncryptCommands(
 			secretsencrypt.Status,
@@ -75,6 +97,44 @@ func main() {
 				cert.RotateCA,
 			),
 		),
+		cmds.NewStatusCommand(status.Run),
+		cmds.NewLogsCommand(logs.Run),
+		cmds.NewCheckCommand(
+			cmds.NewCheckSubcommands(
+				check.Cluster,
+			),
+		),
+		cmds.NewDataDirCommand(
+			cmds.NewDataDirSubcommands(
+				datadir.Migrate,
+			),
+		),
+		cmds.NewKeystoreCommand(
+			cmds.NewKeystoreSubcommands(
+				keystore.Export,
+				keystore.Import,
+			),
+		),
+		cmds.NewImagesCommand(
+			cmds.NewImagesSubcommands(
+				images.Export,
+				images.Import,
+			),
+		),
+		cmds.NewKubeconfigCommand(
+			cmds.NewKubeconfigSubcommands(
+				kubeconfig.Add,
+				kubeconfig.Remove,
+				kubeconfig.List,
+			),
+		),
+		cmds.NewNodeCommand(
+			cmds.NewNodeSubcommands(
+				node.Approve,
+				node.Deny,
+			),
+		),
+		cmds.NewVersionCommand(version.Run),
 		cmds.NewCompletionCommand(completion.Run),
 	}
 
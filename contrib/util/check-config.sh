@@ -20,10 +20,46 @@ possibleConfigs="
 binDir=$(dirname "$0")
 configFormat=gz
 isError=0
-
-if [ $# -gt 0 ]; then
-  CONFIG="$1"
-fi
+outputFormat=text
+section=
+resultsFile=
+
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --json)
+      outputFormat=json
+      ;;
+    --output=*|-o=*)
+      outputFormat="${1#*=}"
+      ;;
+    --output|-o)
+      if [ $# -lt 2 ]; then
+        echo >&2 "error: $1 requires an argument"
+        exit 1
+      fi
+      shift
+      outputFormat="$1"
+      ;;
+    *)
+      CONFIG="$1"
+      ;;
+  esac
+  shift
+done
+
+case "$outputFormat" in
+  text) ;;
+  json)
+    # Human-readable output is discarded; results are collected and written to the original stdout as JSON
+    resultsFile=$(mktemp)
+    trap 'rm -f "$resultsFile"' EXIT
+    exec 3>&1 >/dev/null
+    ;;
+  *)
+    echo >&2 "error: unsupported output format $outputFormat, must be one of: text, json"
+    exit 1
+    ;;
+esac
 
 if ! command -v zgrep >/dev/null 2>&1; then
   zgrep() {
@@ -90,15 +126,83 @@ wrap_color() {
 }
 
 wrap_good() {
+  record_result pass "$1" "$2"
   echo "$(wrap_color "$1" white): $(wrap_color "$2" green)"
 }
 wrap_bad() {
+  record_result fail "$1" "$2"
   echo "$(wrap_color "$1" bold): $(wrap_color "$2 (fail)" bold red)"
   EXITCODE=$(($EXITCODE+1))
 }
 wrap_warn() {
+  record_result warn "$1" "$2"
   echo "$(wrap_color "$1" bold): $(wrap_color "$2" bold yellow)"
 }
+
+# record_result saves the result of a check for JSON output. A file is used, as some checks are run in a
+# subshell.
+record_result() {
+  [ -n "$resultsFile" ] || return 0
+  name=$(echo "$2" | sed -e 's/^[- ]*//')
+  printf '%s\t%s\t%s\t%s\n' "$1" "$section" "$name" "$3" >>"$resultsFile"
+}
+
+# remediation prints a hint on how to fix a check that did not pass.
+remediation() {
+  case "$1" in
+    CONFIG_*)
+      echo "enable $1 in the kernel configuration, or load the module that provides it"
+      ;;
+    sha256sum|links)
+      echo "reinstall to restore the bundled binaries"
+      ;;
+    swap)
+      echo "disable swap, for example run: swapoff -a"
+      ;;
+    routes)
+      echo "set cluster-cidr and service-cidr to ranges that are not already routed"
+      ;;
+    /proc/sys/kernel/keys/root_maxkeys)
+      echo "increase the limit, for example run: sysctl -w kernel/keys/root_maxkeys=1000000"
+      ;;
+    apparmor)
+      echo "install the apparmor_parser package for your distribution"
+      ;;
+    'cgroup hierarchy')
+      echo "mount the cgroup hierarchy and enable the required controllers"
+      ;;
+    *iptables*)
+      echo "use an iptables version older than v1.8.0, newer than v1.8.3, or in legacy mode"
+      ;;
+    '(RHEL7/CentOS7')
+      echo "add user_namespace.enable=1 to the kernel command line"
+      ;;
+  esac
+}
+
+json_escape() {
+  printf '%s' "$1" | sed -e 's/\\/\\\\/g' -e 's/"/\\"/g' | tr -d '\000-\037'
+}
+
+# emit_json writes the collected results as a JSON document to the original stdout.
+emit_json() {
+  {
+    status=pass
+    [ $EXITCODE -eq 0 ] || status=fail
+    printf '{\n  "status": "%s",\n  "failures": %d,\n  "checks": [' "$status" "$EXITCODE"
+    sep=
+    tab=$(printf '\t')
+    while IFS="$tab" read -r checkStatus checkSection checkName checkMessage; do
+      hint=
+      [ "$checkStatus" = pass ] || hint=$(remediation "$checkName")
+      printf '%s\n    {"name": "%s", "section": "%s", "status": "%s", "message": "%s", "remediation": "%s"}' \
+        "$sep" "$(json_escape "$checkName")" "$(json_escape "$checkSection")" "$checkStatus" \
+        "$(json_escape "$checkMessage")" "$(json_escape "$hint")"
+      sep=,
+    done <"$resultsFile"
+    printf '\n  ]\n}\n'
+  } >&3
+}
 warning() {
   wrap_color >&2 "$*" yellow
 }
@@ -156,6 +260,7 @@ check_distro_userns() {
 
 echo
 
+section=binaries
 {
   cd $binDir
   echo "Verifying binaries in $binDir:"
@@ -220,6 +325,7 @@ echo
     )
   }
 
+  section=system
   echo "System:"
 
   iptablesCmd=$(which_iptables)
@@ -270,6 +376,7 @@ echo
     fi
   }
 
+  section=limits
   echo 'Limits:'
   check_limit_over /proc/sys/kernel/keys/root_maxkeys 10000
 }
@@ -301,6 +408,11 @@ if [ ! -e "$CONFIG" ]; then
   esac
   warning "  try running this script again, specifying the kernel config:"
   warning "  set CONFIG=/path/to/kernel/.config or add argument /path/to/kernel/.config"
+  if [ "$outputFormat" = json ]; then
+    section=kernel
+    wrap_bad 'kernel config' 'not found'
+    emit_json
+  fi
   exit 1
 fi
 
@@ -309,6 +421,7 @@ zcat $CONFIG >/dev/null 2>&1 || configFormat=
 
 echo
 
+section=generally-necessary
 echo 'Generally Necessary:'
 
 cgroupV2FsType='63677270'
@@ -397,6 +510,7 @@ fi
 
 echo
 
+section=optional-features
 echo 'Optional Features:'
 {
   check_flags USER_NS
@@ -450,7 +564,7 @@ fi
 flags="
   BLK_CGROUP BLK_DEV_THROTTLING
   CGROUP_PERF
-  CGROUP_HUGETLB
+  CGROUP_HUGETLB HUGETLBFS HUGETLB_PAGE
   NET_CLS_CGROUP $netprio
   CFS_BANDWIDTH FAIR_GROUP_SCHED RT_GROUP_SCHED
   IP_NF_TARGET_REDIRECT
@@ -499,7 +613,9 @@ check_flags OVERLAY_FS | sed 's/^/    /'
 # ---
 
 echo
-if [ $EXITCODE -eq 0 ]; then
+if [ "$outputFormat" = json ]; then
+  emit_json
+elif [ $EXITCODE -eq 0 ]; then
   wrap_good 'STATUS' 'pass'
 else
   wrap_bad 'STATUS' $EXITCODE
@@ -0,0 +1,316 @@
+package bwlimit
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// maxChunk is the largest number of bytes read from an upstream connection at a time, and the minimum
+// burst allowed by a limiter.
+const maxChunk = 32 * 1024
+
+// Limits are the bandwidth limits applied to image pulls, in bytes per second. A limit of 0 is unlimited.
+type Limits struct {
+	// Default is the limit shared by all registries that do not have their own limit.
+	Default int64
+	// Registries are the limits of individual registries, keyed by host name, optionally with a port.
+	Registries map[string]int64
+}
+
+// Enabled returns true if any limits are set.
+func (l Limits) Enabled() bool {
+	if l.Default > 0 {
+		return true
+	}
+	for _, limit := range l.Registries {
+		if limit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLimit parses a bandwidth limit in bytes per second, as a Kubernetes quantity such as 10Mi or
+// 500k. An empty string is unlimited.
+func ParseLimit(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid bandwidth limit %q", s)
+	}
+	if q.Sign() < 0 {
+		return 0, errors.Errorf("invalid bandwidth limit %q: must not be negative", s)
+	}
+	return q.Value(), nil
+}
+
+// registriesFile is the subset of registries.yaml that sets per-registry bandwidth limits, for example:
+//
+//	configs:
+//	  "registry.example.com":
+//	    bandwidth_limit: 5Mi
+type registriesFile struct {
+	Configs map[string]struct {
+		BandwidthLimit string `yaml:"bandwidth_limit"`
+	} `yaml:"configs"`
+}
+
+// Load returns the limits with the given default limit, and the per-registry limits set in the
+// registries.yaml file, if it exists.
+func Load(defaultLimit string, registriesYAML string) (Limits, error) {
+	limits := Limits{Registries: map[string]int64{}}
+	var err error
+	if limits.Default, err = ParseLimit(defaultLimit); err != nil {
+		return limits, err
+	}
+
+	b, err := os.ReadFile(registriesYAML)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return limits, nil
+		}
+		return limits, err
+	}
+	file := registriesFile{}
+	if err := yaml.Unmarshal(b, &file); err != nil {
+		return limits, errors.Wrapf(err, "failed to parse %s", registriesYAML)
+	}
+	for host, config := range file.Configs {
+		if config.BandwidthLimit == "" {
+			continue
+		}
+		limit, err := ParseLimit(config.BandwidthLimit)
+		if err != nil {
+			return limits, errors.Wrapf(err, "registry %s", host)
+		}
+		// Images from docker.io are pulled from registry-1.docker.io
+		if host == "docker.io" {
+			host = "registry-1.docker.io"
+		}
+		limits.Registries[host] = limit
+	}
+	return limits, nil
+}
+
+// Proxy is an HTTP proxy that limits the bandwidth of responses from upstream servers. HTTPS requests are
+// tunnelled with CONNECT, so the limits are applied without terminating TLS. Limits are applied to the
+// total bandwidth of all connections to a host, not to each connection: connections to registries that
+// do not have their own limit share the default limit.
+type Proxy struct {
+	limits Limits
+
+	mu          sync.Mutex
+	limiters    map[string]*rate.Limiter
+	defaultRate *rate.Limiter
+	dialer      net.Dialer
+	transport   *http.Transport
+}
+
+// NewProxy creates a proxy that applies the given limits.
+func NewProxy(limits Limits) *Proxy {
+	p := &Proxy{
+		limits:      limits,
+		limiters:    map[string]*rate.Limiter{},
+		defaultRate: newLimiter(limits.Default),
+		dialer:      net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+	}
+	p.transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := p.dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			// Connections are reused across requests, so reads must not be bound to the context of the dial
+			return &limitedConn{Conn: conn, ctx: context.Background(), limiter: p.limiter(addr)}, nil
+		},
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return p
+}
+
+// ListenAndServe serves the proxy on the address until the context is cancelled. The address that the
+// proxy is listening on is returned once it is ready to accept connections.
+func (p *Proxy) ListenAndServe(ctx context.Context, address string) (string, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return "", err
+	}
+	server := &http.Server{
+		Handler:           p,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Errorf("Image pull bandwidth limiting proxy failed: %v", err)
+		}
+	}()
+	return listener.Addr().String(), nil
+}
+
+// ServeHTTP tunnels CONNECT requests, and forwards other requests to the upstream server.
+func (p *Proxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodConnect {
+		p.connect(rw, req)
+		return
+	}
+	if req.URL.Host == "" {
+		http.Error(rw, "proxy requests must use an absolute URL", http.StatusBadRequest)
+		return
+	}
+	req = req.Clone(req.Context())
+	req.RequestURI = ""
+	req.Header.Del("Proxy-Connection")
+	req.Header.Del("Proxy-Authorization")
+	resp, err := p.transport.RoundTrip(req)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for k, v := range resp.Header {
+		rw.Header()[k] = v
+	}
+	rw.WriteHeader(resp.StatusCode)
+	io.Copy(rw, resp.Body)
+}
+
+func (p *Proxy) connect(rw http.ResponseWriter, req *http.Request) {
+	hijacker, ok := rw.(http.Hijacker)
+	if !ok {
+		http.Error(rw, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	upstream, err := p.dialer.DialContext(req.Context(), "tcp", req.Host)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadGateway)
+		return
+	}
+	conn, bufrw, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		upstream.Close()
+		conn.Close()
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	limited := &limitedConn{Conn: upstream, ctx: ctx, limiter: p.limiter(req.Host)}
+	go func() {
+		defer cancel()
+		defer upstream.Close()
+		defer conn.Close()
+		// Any data buffered by the server after the request headers must be sent before the rest of the connection
+		if n := bufrw.Reader.Buffered(); n > 0 {
+			buffered, _ := bufrw.Reader.Peek(n)
+			if _, err := upstream.Write(buffered); err != nil {
+				return
+			}
+		}
+		go func() {
+			io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		io.Copy(conn, limited)
+	}()
+}
+
+// limiter returns the limiter for the host, or nil if connections to the host are unlimited.
+func (p *Proxy) limiter(address string) *rate.Limiter {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	limit, ok := p.limits.Registries[address]
+	if !ok {
+		limit, ok = p.limits.Registries[host]
+	}
+	if !ok {
+		return p.defaultRate
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	limiter, ok := p.limiters[address]
+	if !ok {
+		limiter = newLimiter(limit)
+		p.limiters[address] = limiter
+	}
+	return limiter
+}
+
+func newLimiter(limit int64) *rate.Limiter {
+	if limit <= 0 {
+		return nil
+	}
+	burst := int(limit)
+	if burst < maxChunk {
+		burst = maxChunk
+	}
+	return rate.NewLimiter(rate.Limit(limit), burst)
+}
+
+// limitedConn is a connection whose reads are limited by a rate limiter.
+type limitedConn struct {
+	net.Conn
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+func (c *limitedConn) Read(b []byte) (int, error) {
+	if c.limiter == nil {
+		return c.Conn.Read(b)
+	}
+	if len(b) > maxChunk {
+		b = b[:maxChunk]
+	}
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		if werr := c.limiter.WaitN(c.ctx, n); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
+
+// ProxyEnv returns the proxy environment variables that send HTTP and HTTPS requests through the proxy
+// at the address.
+func ProxyEnv(address string) []string {
+	proxyURL := "http://" + address
+	return []string{"HTTP_PROXY=" + proxyURL, "HTTPS_PROXY=" + proxyURL}
+}
+
+// ProxyConfigured returns true if any of the environment variables already set a proxy.
+func ProxyConfigured(env []string) bool {
+	for _, e := range env {
+		name, value, _ := strings.Cut(e, "=")
+		switch strings.ToUpper(name) {
+		case "HTTP_PROXY", "HTTPS_PROXY":
+			if value != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package bwlimit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_UnitParseLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   string
+		want    int64
+		wantErr bool
+	}{
+		{
+			name: "unlimited",
+			want: 0,
+		},
+		{
+			name:  "binary suffix",
+			limit: "10Mi",
+			want:  10 * 1024 * 1024,
+		},
+		{
+			name:  "decimal suffix",
+			limit: "500k",
+			want:  500 * 1000,
+		},
+		{
+			name:    "negative",
+			limit:   "-1Mi",
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			limit:   "fast",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLimit(tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitLoad(t *testing.T) {
+	registriesYAML := filepath.Join(t.TempDir(), "registries.yaml")
+	err := os.WriteFile(registriesYAML, []byte(`
+mirrors:
+  docker.io:
+    endpoint:
+      - "https://mirror.example.com"
+configs:
+  "mirror.example.com":
+    bandwidth_limit: 5Mi
+    tls:
+      insecure_skip_verify: true
+  "docker.io":
+    bandwidth_limit: 1Mi
+  "registry.example.com:5000":
+    auth:
+      username: user
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limits, err := Load("10Mi", registriesYAML)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := Limits{
+		Default: 10 * 1024 * 1024,
+		Registries: map[string]int64{
+			"mirror.example.com":   5 * 1024 * 1024,
+			"registry-1.docker.io": 1024 * 1024,
+		},
+	}
+	if !reflect.DeepEqual(limits, want) {
+		t.Errorf("Load() = %+v, want %+v", limits, want)
+	}
+
+	limits, err = Load("", filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("Load() with missing registries.yaml error = %v", err)
+	}
+	if limits.Enabled() {
+		t.Errorf("Load() with no limits is enabled")
+	}
+}
+
+func Test_UnitProxyLimitsBandwidth(t *testing.T) {
+	const size = 96 * 1024
+	content := strings.Repeat("x", size)
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		io.WriteString(rw, content)
+	})
+	httpUpstream := httptest.NewServer(handler)
+	defer httpUpstream.Close()
+	httpsUpstream := httptest.NewTLSServer(handler)
+	defer httpsUpstream.Close()
+
+	tests := []struct {
+		name    string
+		https   bool
+		limits  Limits
+		minTime time.Duration
+	}{
+		{
+			name:   "unlimited",
+			limits: Limits{},
+		},
+		{
+			name:    "default limit",
+			limits:  Limits{Default: 64 * 1024},
+			minTime: 400 * time.Millisecond,
+		},
+		{
+			name:    "registry limit",
+			limits:  Limits{Registries: map[string]int64{"127.0.0.1": 64 * 1024}},
+			minTime: 400 * time.Millisecond,
+		},
+		{
+			name:    "https registry limit",
+			https:   true,
+			limits:  Limits{Registries: map[string]int64{"127.0.0.1": 64 * 1024}},
+			minTime: 400 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httpUpstream
+			if tt.https {
+				upstream = httpsUpstream
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			address, err := NewProxy(tt.limits).ListenAndServe(ctx, "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			proxyURL, _ := url.Parse("http://" + address)
+			transport := upstream.Client().Transport.(*http.Transport).Clone()
+			transport.Proxy = http.ProxyURL(proxyURL)
+			client := &http.Client{Transport: transport}
+
+			start := time.Now()
+			resp, err := client.Get(upstream.URL)
+			if err != nil {
+				t.Fatalf("request through proxy failed: %v", err)
+			}
+			b, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("failed to read response: %v", err)
+			}
+			if len(b) != size {
+				t.Errorf("response size = %d, want %d", len(b), size)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minTime {
+				t.Errorf("response took %s, want at least %s", elapsed, tt.minTime)
+			}
+		})
+	}
+}
+
+func Test_UnitProxyConfigured(t *testing.T) {
+	tests := []struct {
+		name string
+		env  []string
+		want bool
+	}{
+		{
+			name: "no proxy",
+			env:  []string{"PATH=/usr/bin", "NO_PROXY=10.0.0.0/8"},
+			want: false,
+		},
+		{
+			name: "https proxy",
+			env:  []string{"HTTPS_PROXY=http://proxy.example.com:3128"},
+			want: true,
+		},
+		{
+			name: "lowercase http proxy",
+			env:  []string{"http_proxy=http://proxy.example.com:3128"},
+			want: true,
+		},
+		{
+			name: "empty proxy",
+			env:  []string{"HTTP_PROXY="},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProxyConfigured(tt.env); got != tt.want {
+				t.Errorf("ProxyConfigured() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package certmonitor
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	// ConditionType is the type of the node condition that is set to true when any certificate managed by
+	// k3s on the node is within the expiry window.
+	ConditionType v1.NodeConditionType = "CertificatesExpiring"
+
+	// checkInterval is the interval at which certificates are inspected. Certificates are renewed when
+	// k3s is restarted, so they only change on disk between checks when rotated by the certificate command.
+	checkInterval = time.Hour
+)
+
+var certExpirationGauge = metrics.NewGaugeVec(&metrics.GaugeOpts{
+	Name:           version.Program + "_certificate_expiration_timestamp_seconds",
+	StabilityLevel: metrics.ALPHA,
+	Help:           "Expiration time of the certificates managed by " + version.Program + " on this node, in seconds since the Unix epoch. 'file' is the path of the certificate relative to the data directory, and 'subject' is its common name.",
+}, []string{"file", "subject"})
+
+func init() {
+	legacyregistry.MustRegister(certExpirationGauge)
+}
+
+// Certificate is a certificate file found in the data directory. If the file contains a bundle, the first
+// certificate in the bundle is described.
+type Certificate struct {
+	File     string
+	Subject  string
+	NotAfter time.Time
+}
+
+// Scan returns the certificates in the agent and server certificate directories under the data directory,
+// sorted by file name. The server directory is skipped if this node is not a server. Subdirectories of the
+// agent directory hold containerd state and images rather than certificates, and are not scanned.
+func Scan(dataDir string) ([]Certificate, error) {
+	certs := []Certificate{}
+	dirs := map[string]bool{
+		filepath.Join(dataDir, "agent"):         false,
+		filepath.Join(dataDir, "server", "tls"): true,
+	}
+	for dir, recursive := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == dir {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if d.IsDir() {
+				if path != dir && !recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(path, ".crt") {
+				return nil
+			}
+			parsed, err := certutil.CertsFromFile(path)
+			if err != nil {
+				logrus.Debugf("Skipping certificate %s: %v", path, err)
+				return nil
+			}
+			rel, err := filepath.Rel(dataDir, path)
+			if err != nil {
+				return err
+			}
+			certs = append(certs, Certificate{
+				File:     filepath.ToSlash(rel),
+				Subject:  parsed[0].Subject.CommonName,
+				NotAfter: parsed[0].NotAfter,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(certs, func(i, j int) bool { return certs[i].File < certs[j].File })
+	return certs, nil
+}
+
+// Expiring returns the certificates that expire within the window.
+func Expiring(certs []Certificate, now time.Time, window time.Duration) []Certificate {
+	expiring := []Certificate{}
+	deadline := now.Add(window)
+	for _, cert := range certs {
+		if cert.NotAfter.Before(deadline) {
+			expiring = append(expiring, cert)
+		}
+	}
+	return expiring
+}
+
+// Run inspects the certificates in the data directory at startup and then periodically until the context is
+// cancelled, reporting their expiration time as metrics. If the window is not zero, the node condition is
+// also updated to show whether any certificate expires within the window.
+func Run(ctx context.Context, dataDir string, window time.Duration, nodeName string, nodes typedcorev1.NodeInterface) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		if err := check(ctx, dataDir, window, nodeName, nodes); err != nil {
+			logrus.Errorf("Failed to check certificate expiration: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func check(ctx context.Context, dataDir string, window time.Duration, nodeName string, nodes typedcorev1.NodeInterface) error {
+	certs, err := Scan(dataDir)
+	if err != nil {
+		return err
+	}
+	certExpirationGauge.Reset()
+	for _, cert := range certs {
+		certExpirationGauge.WithLabelValues(cert.File, cert.Subject).Set(float64(cert.NotAfter.Unix()))
+	}
+	if window == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	expiring := Expiring(certs, now, window)
+	for _, cert := range expiring {
+		logrus.Warnf("Certificate %s expires at %s; restart %s or run '%s certificate rotate' to renew it", cert.File, cert.NotAfter.Format(time.RFC3339), version.Program, version.Program)
+	}
+	condition := expiryCondition(expiring, window, metav1.NewTime(now))
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := nodes.Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if !setCondition(&node.Status, condition) {
+			return nil
+		}
+		_, err = nodes.UpdateStatus(ctx, node, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// expiryCondition returns the node condition for the expiring certificates.
+func expiryCondition(expiring []Certificate, window time.Duration, now metav1.Time) v1.NodeCondition {
+	condition := v1.NodeCondition{
+		Type:               ConditionType,
+		Status:             v1.ConditionFalse,
+		Reason:             "CertificatesValid",
+		Message:            fmt.Sprintf("No certificates expire within %s", window),
+		LastHeartbeatTime:  now,
+		LastTransitionTime: now,
+	}
+	if len(expiring) > 0 {
+		files := make([]string, len(expiring))
+		for i, cert := range expiring {
+			files[i] = cert.File
+		}
+		condition.Status = v1.ConditionTrue
+		condition.Reason = "CertificatesExpiring"
+		condition.Message = fmt.Sprintf("Certificates expire within %s: %s", window, strings.Join(files, ", "))
+	}
+	return condition
+}
+
+// setCondition sets the condition in the node status, and returns true if the status was changed. The
+// transition time of an existing condition is retained unless its status has changed.
+func setCondition(status *v1.NodeStatus, condition v1.NodeCondition) bool {
+	for i, existing := range status.Conditions {
+		if existing.Type != condition.Type {
+			continue
+		}
+		if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+			return false
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		status.Conditions[i] = condition
+		return true
+	}
+	status.Conditions = append(status.Conditions, condition)
+	return true
+}
@@ -0,0 +1,134 @@
+package certmonitor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func Test_UnitScan(t *testing.T) {
+	dataDir := t.TempDir()
+	certPEM, _, err := certutil.GenerateSelfSignedCertKey("k3s-test", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"agent/client-kubelet.crt":                 certPEM,
+		"agent/client-kubelet.key":                 []byte("not a certificate"),
+		"agent/containerd/io.containerd/image.crt": certPEM,
+		"server/tls/server-ca.crt":                 certPEM,
+		"server/tls/etcd/peer-server-client.crt":   certPEM,
+		"server/tls/invalid.crt":                   []byte("not a certificate"),
+	}
+	for file, b := range files {
+		path := filepath.Join(dataDir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	certs, err := Scan(dataDir)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	got := []string{}
+	for _, cert := range certs {
+		got = append(got, cert.File)
+		if cert.NotAfter.IsZero() {
+			t.Errorf("Scan() certificate %s has no expiration time", cert.File)
+		}
+	}
+	want := []string{"agent/client-kubelet.crt", "server/tls/etcd/peer-server-client.crt", "server/tls/server-ca.crt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan() = %v, want %v", got, want)
+	}
+
+	if certs, err := Scan(filepath.Join(dataDir, "missing")); err != nil || len(certs) != 0 {
+		t.Errorf("Scan() of missing data dir = %v, %v, want no certificates", certs, err)
+	}
+}
+
+func Test_UnitSetCondition(t *testing.T) {
+	window := 30 * 24 * time.Hour
+	earlier := metav1.NewTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	certs := []Certificate{
+		{File: "agent/client-kubelet.crt", NotAfter: now.Add(10 * 24 * time.Hour)},
+		{File: "server/tls/server-ca.crt", NotAfter: now.Add(3650 * 24 * time.Hour)},
+	}
+	expiring := expiryCondition(Expiring(certs, now.Time, window), window, now)
+	valid := expiryCondition(Expiring(certs[1:], now.Time, window), window, now)
+
+	tests := []struct {
+		name           string
+		existing       []v1.NodeCondition
+		condition      v1.NodeCondition
+		wantChanged    bool
+		wantStatus     v1.ConditionStatus
+		wantTransition metav1.Time
+	}{
+		{
+			name:           "added",
+			existing:       []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			condition:      expiring,
+			wantChanged:    true,
+			wantStatus:     v1.ConditionTrue,
+			wantTransition: now,
+		},
+		{
+			name:        "unchanged",
+			existing:    []v1.NodeCondition{{Type: ConditionType, Status: v1.ConditionFalse, Reason: valid.Reason, Message: valid.Message, LastTransitionTime: earlier}},
+			condition:   valid,
+			wantChanged: false,
+			wantStatus:  v1.ConditionFalse,
+			// the existing condition is not modified
+			wantTransition: earlier,
+		},
+		{
+			name:           "transitioned",
+			existing:       []v1.NodeCondition{{Type: ConditionType, Status: v1.ConditionFalse, Reason: valid.Reason, Message: valid.Message, LastTransitionTime: earlier}},
+			condition:      expiring,
+			wantChanged:    true,
+			wantStatus:     v1.ConditionTrue,
+			wantTransition: now,
+		},
+		{
+			name:           "message changed",
+			existing:       []v1.NodeCondition{{Type: ConditionType, Status: v1.ConditionTrue, Reason: expiring.Reason, Message: "old", LastTransitionTime: earlier}},
+			condition:      expiring,
+			wantChanged:    true,
+			wantStatus:     v1.ConditionTrue,
+			wantTransition: earlier,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &v1.NodeStatus{Conditions: tt.existing}
+			if changed := setCondition(status, tt.condition); changed != tt.wantChanged {
+				t.Errorf("setCondition() = %v, want %v", changed, tt.wantChanged)
+			}
+			for _, condition := range status.Conditions {
+				if condition.Type != ConditionType {
+					continue
+				}
+				if condition.Status != tt.wantStatus {
+					t.Errorf("setCondition() status = %s, want %s", condition.Status, tt.wantStatus)
+				}
+				if !condition.LastTransitionTime.Equal(&tt.wantTransition) {
+					t.Errorf("setCondition() transition time = %s, want %s", condition.LastTransitionTime, tt.wantTransition)
+				}
+				return
+			}
+			t.Errorf("setCondition() did not set condition %s", ConditionType)
+		})
+	}
+}
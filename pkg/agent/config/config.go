@@ -9,6 +9,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"io"
+	"math/rand"
 	"net"
 	"net/http"
 	"net/url"
@@ -16,12 +17,17 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 	"time"
 
+	"github.com/k3s-io/k3s/pkg/agent/bwlimit"
 	"github.com/k3s-io/k3s/pkg/agent/containerd"
+	"github.com/k3s-io/k3s/pkg/agent/externalip"
+	"github.com/k3s-io/k3s/pkg/agent/flannel"
 	"github.com/k3s-io/k3s/pkg/agent/proxy"
 	agentutil "github.com/k3s-io/k3s/pkg/agent/util"
+	"github.com/k3s-io/k3s/pkg/cgroups"
 	"github.com/k3s-io/k3s/pkg/cli/cmds"
 	"github.com/k3s-io/k3s/pkg/clientaccess"
 	"github.com/k3s-io/k3s/pkg/daemons/config"
@@ -31,11 +37,22 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rancher/wrangler/pkg/slice"
 	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/wait"
+	pluginapi "k8s.io/kubelet/pkg/apis/deviceplugin/v1beta1"
+	kubeletconfig "k8s.io/kubernetes/pkg/kubelet/apis/config"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+	"k8s.io/kubernetes/pkg/kubelet/sysctl"
+	utilflag "k8s.io/kubernetes/pkg/util/flag"
 )
 
 const (
 	DefaultPodManifestPath = "pod-manifests"
+	// ResolvConfAuto detects the host's DNS configuration, and keeps the kubelet's resolv.conf up to date.
+	ResolvConfAuto = "auto"
 )
 
 // Get returns a pointer to a completed Node configuration struct,
@@ -132,6 +149,7 @@ func getNodeNamedCrt(nodeName string, nodeIPs []net.IP, nodePasswordFile string)
 		}
 		req.Header.Set(version.Program+"-Node-Password", nodePassword)
 		req.Header.Set(version.Program+"-Node-IP", util.JoinIPs(nodeIPs))
+		req.Header.Set(version.Program+"-Node-Time", time.Now().UTC().Format(time.RFC3339Nano))
 
 		resp, err := client.Do(req)
 		if err != nil {
@@ -143,6 +161,15 @@ func getNodeNamedCrt(nodeName string, nodeIPs []net.IP, nodePasswordFile string)
 			return nil, fmt.Errorf("Node password rejected, duplicate hostname or contents of '%s' may not match server node-passwd entry, try enabling a unique node name with the --with-node-id flag", nodePasswordFile)
 		}
 
+		if resp.StatusCode == http.StatusPreconditionFailed {
+			message, _ := io.ReadAll(resp.Body)
+			return nil, fmt.Errorf("Node rejected by server due to clock skew, ensure that time is synchronized with the server: %s", strings.TrimSpace(string(message)))
+		}
+
+		if resp.StatusCode == http.StatusAccepted {
+			return nil, fmt.Errorf("Node join request is pending approval, approve it with '%s node approve %s' on a server", version.Program, nodeName)
+		}
+
 		if resp.StatusCode != http.StatusOK {
 			return nil, fmt.Errorf("%s: %s", u, resp.Status)
 		}
@@ -257,6 +284,12 @@ func splitCertKeyPEM(bytes []byte) (certPem []byte, keyPem []byte) {
 	return
 }
 
+// GetNodeNamedHostFile requests a certificate and key issued to the named node from the server, and writes them
+// to disk. The node password is read from the password file, or generated and written to it if it does not exist.
+func GetNodeNamedHostFile(filename, keyFile, nodeName string, nodeIPs []net.IP, nodePasswordFile string, info *clientaccess.Info) error {
+	return getNodeNamedHostFile(filename, keyFile, nodeName, nodeIPs, nodePasswordFile, info)
+}
+
 func getNodeNamedHostFile(filename, keyFile, nodeName string, nodeIPs []net.IP, nodePasswordFile string, info *clientaccess.Info) error {
 	basename := filepath.Base(filename)
 	fileBytes, err := Request("/v1-"+version.Program+"/"+basename, info, getNodeNamedCrt(nodeName, nodeIPs, nodePasswordFile))
@@ -274,6 +307,21 @@ func getNodeNamedHostFile(filename, keyFile, nodeName string, nodeIPs []net.IP,
 	return nil
 }
 
+// devicePluginPath is the directory that device plugins register in. It is a var so that it can be overridden by tests.
+var devicePluginPath = pluginapi.DevicePluginPath
+
+// resolvConfSources are the locations checked for a resolv.conf that does not use a local stub resolver.
+// systemd-resolved and NetworkManager both write the upstream nameservers, including any pushed by VPN
+// connections, to a separate file when /etc/resolv.conf points at a stub listener on the loopback address.
+var resolvConfSources = []string{
+	"/etc/resolv.conf",
+	"/run/systemd/resolve/resolv.conf",
+	"/run/NetworkManager/no-stub-resolv.conf",
+	"/run/NetworkManager/resolv.conf",
+}
+
+// isValidResolvConf returns true if the file contains at least one nameserver, and all nameservers
+// are global unicast addresses that will be reachable from within pods.
 func isValidResolvConf(resolvConfFile string) bool {
 	file, err := os.Open(resolvConfFile)
 	if err != nil {
@@ -283,6 +331,7 @@ func isValidResolvConf(resolvConfFile string) bool {
 
 	nameserver := regexp.MustCompile(`^nameserver\s+([^\s]*)`)
 	scanner := bufio.NewScanner(file)
+	found := false
 	for scanner.Scan() {
 		ipMatch := nameserver.FindStringSubmatch(scanner.Text())
 		if len(ipMatch) == 2 {
@@ -290,34 +339,91 @@ func isValidResolvConf(resolvConfFile string) bool {
 			if ip == nil || !ip.IsGlobalUnicast() {
 				return false
 			}
+			found = true
 		}
 	}
 	if err := scanner.Err(); err != nil {
 		return false
 	}
-	return true
+	return found
 }
 
-func locateOrGenerateResolvConf(envInfo *cmds.Agent) string {
-	if envInfo.ResolvConf != "" {
-		return envInfo.ResolvConf
-	}
-	resolvConfs := []string{"/etc/resolv.conf", "/run/systemd/resolve/resolv.conf"}
-	for _, conf := range resolvConfs {
+// generateResolvConf returns the contents of the first valid host resolv.conf, along with its path.
+// If none are valid, the search and options from /etc/resolv.conf are combined with a public nameserver,
+// and an empty path is returned.
+func generateResolvConf() (string, string) {
+	for _, conf := range resolvConfSources {
 		if isValidResolvConf(conf) {
-			return conf
+			if b, err := os.ReadFile(conf); err == nil {
+				return string(b), conf
+			}
+		}
+	}
+
+	var sb strings.Builder
+	if file, err := os.Open(resolvConfSources[0]); err == nil {
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "search") || strings.HasPrefix(line, "domain") || strings.HasPrefix(line, "options") {
+				sb.WriteString(line + "\n")
+			}
 		}
 	}
+	sb.WriteString("nameserver 8.8.8.8\n")
+	return sb.String(), ""
+}
+
+// locateOrGenerateResolvConf returns the resolv.conf to be used by the kubelet. If a path is set, it is
+// used as-is. Otherwise, the host's DNS configuration is detected and copied into the agent's data dir,
+// so that it can be updated by WatchResolvConf if the host's DNS configuration changes.
+func locateOrGenerateResolvConf(envInfo *cmds.Agent) string {
+	if envInfo.ResolvConf != "" && envInfo.ResolvConf != ResolvConfAuto {
+		return envInfo.ResolvConf
+	}
 
 	resolvConf := filepath.Join(envInfo.DataDir, "agent", "etc", "resolv.conf")
-	if err := agentutil.WriteFile(resolvConf, "nameserver 8.8.8.8\n"); err != nil {
+	contents, source := generateResolvConf()
+	if err := agentutil.WriteFile(resolvConf, contents); err != nil {
 		logrus.Errorf("Failed to write %s: %v", resolvConf, err)
 		return ""
 	}
-	logrus.Warnf("Host resolv.conf includes loopback or multicast nameservers - kubelet will use autogenerated resolv.conf with nameserver 8.8.8.8")
+	if source == "" {
+		logrus.Warnf("Host resolv.conf includes loopback or multicast nameservers - kubelet will use autogenerated resolv.conf with nameserver 8.8.8.8")
+	} else {
+		logrus.Infof("Using host DNS configuration from %s", source)
+	}
 	return resolvConf
 }
 
+// WatchResolvConf periodically re-detects the host's DNS configuration, and updates the kubelet's
+// resolv.conf if it has changed. The kubelet reads this file when creating pod sandboxes, so changes
+// are picked up by new pods without restarting the kubelet.
+func WatchResolvConf(ctx context.Context, resolvConf string) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			contents, source := generateResolvConf()
+			if current, err := os.ReadFile(resolvConf); err == nil && string(current) == contents {
+				continue
+			}
+			if err := agentutil.WriteFile(resolvConf, contents); err != nil {
+				logrus.Errorf("Failed to update %s: %v", resolvConf, err)
+				continue
+			}
+			if source == "" {
+				source = "autogenerated configuration"
+			}
+			logrus.Infof("Host DNS configuration changed; updated %s from %s", resolvConf, source)
+		}
+	}
+}
+
 func get(ctx context.Context, envInfo *cmds.Agent, proxy proxy.Proxy) (*config.Node, error) {
 	if envInfo.Debug {
 		logrus.SetLevel(logrus.DebugLevel)
@@ -377,7 +483,23 @@ func get(ctx context.Context, envInfo *cmds.Agent, proxy proxy.Proxy) (*config.N
 	newNodePasswordFile := filepath.Join(nodeConfigPath, "password")
 	upgradeOldNodePasswordPath(oldNodePasswordFile, newNodePasswordFile)
 
-	nodeName, nodeIPs, err := util.GetHostnameAndIPs(envInfo.NodeName, envInfo.NodeIP)
+	// Discovery only applies if no node IPs are set; if no address is discovered, the address of the interface
+	// with the default route is used.
+	nodeIPFlag := envInfo.NodeIP
+	if len(nodeIPFlag) == 0 && len(envInfo.NodeIPDiscovery) > 0 {
+		nodeIPDiscovery := util.SplitStringSlice(envInfo.NodeIPDiscovery)
+		if err := externalip.Validate(nodeIPDiscovery, externalip.InternalIP); err != nil {
+			return nil, err
+		}
+		if ip, err := externalip.DiscoverInternal(ctx, nodeIPDiscovery); err != nil {
+			logrus.Warnf("Unable to discover node IP: %v", err)
+		} else {
+			logrus.Infof("Discovered node IP %s", ip)
+			nodeIPFlag = cli.StringSlice{ip.String()}
+		}
+	}
+
+	nodeName, nodeIPs, err := util.GetHostnameAndIPs(envInfo.NodeName, nodeIPFlag)
 	if err != nil {
 		return nil, err
 	}
@@ -387,6 +509,22 @@ func get(ctx context.Context, envInfo *cmds.Agent, proxy proxy.Proxy) (*config.N
 		return nil, fmt.Errorf("invalid node-external-ip: %w", err)
 	}
 
+	// Discovery only applies if no external IPs are set; failure to discover an address is not fatal, as the
+	// node functions without one, and discovery is retried periodically.
+	var externalIPDiscovery []string
+	if len(nodeExternalIPs) == 0 && len(envInfo.NodeExternalIPDiscovery) > 0 {
+		externalIPDiscovery = util.SplitStringSlice(envInfo.NodeExternalIPDiscovery)
+		if err := externalip.Validate(externalIPDiscovery, externalip.ExternalIP); err != nil {
+			return nil, err
+		}
+		if ip, err := externalip.Discover(ctx, externalIPDiscovery, envInfo.NodeExternalIPSTUNServer); err != nil {
+			logrus.Warnf("Unable to discover node external IP: %v", err)
+		} else {
+			logrus.Infof("Discovered node external IP %s", ip)
+			nodeExternalIPs = []net.IP{ip}
+		}
+	}
+
 	if envInfo.WithNodeID {
 		nodeID, err := ensureNodeID(filepath.Join(nodeConfigPath, "id"))
 		if err != nil {
@@ -442,6 +580,7 @@ func get(ctx context.Context, envInfo *cmds.Agent, proxy proxy.Proxy) (*config.N
 		FlannelBackend:           controlConfig.FlannelBackend,
 		FlannelIPv6Masq:          controlConfig.FlannelIPv6Masq,
 		FlannelExternalIP:        controlConfig.FlannelExternalIP,
+		FlannelKeyRotation:       controlConfig.FlannelKeyRotation,
 		EgressSelectorMode:       controlConfig.EgressSelectorMode,
 		ServerHTTPSPort:          controlConfig.HTTPSPort,
 		Token:                    info.String(),
@@ -461,12 +600,13 @@ func get(ctx context.Context, envInfo *cmds.Agent, proxy proxy.Proxy) (*config.N
 	nodeConfig.AgentConfig.KubeConfigKubelet = kubeconfigKubelet
 	nodeConfig.AgentConfig.KubeConfigKubeProxy = kubeconfigKubeproxy
 	nodeConfig.AgentConfig.KubeConfigK3sController = kubeconfigK3sController
-	if envInfo.Rootless {
-		nodeConfig.AgentConfig.RootDir = filepath.Join(envInfo.DataDir, "agent", "kubelet")
+	if err := setKubeletRootDir(nodeConfig, envInfo); err != nil {
+		return nil, err
 	}
 	nodeConfig.AgentConfig.Snapshotter = envInfo.Snapshotter
 	nodeConfig.AgentConfig.IPSECPSK = controlConfig.IPSECPSK
 	nodeConfig.Containerd.Config = filepath.Join(envInfo.DataDir, "agent", "etc", "containerd", "config.toml")
+	nodeConfig.Containerd.Registry = filepath.Join(envInfo.DataDir, "agent", "etc", "containerd", "certs.d")
 	nodeConfig.Containerd.Root = filepath.Join(envInfo.DataDir, "agent", "containerd")
 	nodeConfig.CRIDockerd.Root = filepath.Join(envInfo.DataDir, "agent", "cri-dockerd")
 	if !nodeConfig.Docker && nodeConfig.ContainerRuntimeEndpoint == "" {
@@ -505,6 +645,32 @@ func get(ctx context.Context, envInfo *cmds.Agent, proxy proxy.Proxy) (*config.N
 	nodeConfig.AgentConfig.NodeIP = nodeIP.String()
 	nodeConfig.AgentConfig.ListenAddress = listenAddress
 	nodeConfig.AgentConfig.NodeExternalIPs = nodeExternalIPs
+	nodeConfig.AgentConfig.NodeExternalIPDiscovery = externalIPDiscovery
+	nodeConfig.AgentConfig.NodeExternalIPSTUNServer = envInfo.NodeExternalIPSTUNServer
+
+	localRegistryName := envInfo.LocalRegistryName
+	if envInfo.LocalRegistryListen != "" {
+		if nodeConfig.Docker || nodeConfig.ContainerRuntimeEndpoint != "" {
+			return nil, errors.New("local-registry-listen requires the embedded containerd")
+		}
+		if envInfo.LocalRegistryAuth == "" {
+			return nil, errors.New("local-registry-auth is required when local-registry-listen is set")
+		}
+		if localRegistryName == "" {
+			_, port, err := net.SplitHostPort(envInfo.LocalRegistryListen)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid local-registry-listen")
+			}
+			localRegistryName = net.JoinHostPort("localhost", port)
+		}
+	}
+	nodeConfig.AgentConfig.LocalRegistryListen = envInfo.LocalRegistryListen
+	nodeConfig.AgentConfig.LocalRegistryAuth = envInfo.LocalRegistryAuth
+	nodeConfig.AgentConfig.LocalRegistryName = localRegistryName
+	nodeConfig.AgentConfig.RegistryHealthInterval = envInfo.RegistryHealthInterval
+	nodeConfig.AgentConfig.RegistryHealthTimeout = envInfo.RegistryHealthTimeout
+	nodeConfig.AgentConfig.RegistryAuthSecret = envInfo.RegistryAuthSecret
+	nodeConfig.AgentConfig.CertificateExpiryWindow = envInfo.CertificateExpiryWindow
 
 	// if configured, set NodeExternalIP to the first IPv4 address, for legacy clients
 	// unless only IPv6 address given
@@ -532,6 +698,11 @@ func get(ctx context.Context, envInfo *cmds.Agent, proxy proxy.Proxy) (*config.N
 		nodeConfig.AgentConfig.CNIBinDir = filepath.Dir(hostLocal)
 		nodeConfig.AgentConfig.CNIConfDir = filepath.Join(envInfo.DataDir, "agent", "etc", "cni", "net.d")
 		nodeConfig.AgentConfig.FlannelCniConfFile = envInfo.FlannelCniConfFile
+		nodeConfig.AgentConfig.NetworkName = flannel.NetworkName(nodeConfig.FlannelBackend)
+	} else if controlConfig.CNI == config.CNICalico || controlConfig.CNI == config.CNICilium {
+		// the packaged CNI charts install their plugins and configuration to the default CNI paths
+		nodeConfig.AgentConfig.CNIBinDir = "/opt/cni/bin"
+		nodeConfig.AgentConfig.CNIConfDir = "/etc/cni/net.d"
 	}
 
 	if nodeConfig.Docker {
@@ -571,6 +742,13 @@ func get(ctx context.Context, envInfo *cmds.Agent, proxy proxy.Proxy) (*config.N
 		nodeConfig.AgentConfig.ClusterDNSs = controlConfig.ClusterDNSs
 	}
 
+	// Pods use the node-local DNS cache, and fall back to the cluster DNS service if it is not running.
+	// The cache is not deployed to Windows nodes.
+	if controlConfig.NodeLocalDNS && runtime.GOOS == "linux" {
+		nodeConfig.AgentConfig.NodeLocalDNS = net.ParseIP(config.NodeLocalDNSAddress)
+		nodeConfig.AgentConfig.ClusterDNSs = append([]net.IP{nodeConfig.AgentConfig.NodeLocalDNS}, nodeConfig.AgentConfig.ClusterDNSs...)
+	}
+
 	nodeConfig.AgentConfig.PauseImage = envInfo.PauseImage
 	nodeConfig.AgentConfig.AirgapExtraRegistry = envInfo.AirgapExtraRegistry
 	nodeConfig.AgentConfig.SystemDefaultRegistry = controlConfig.SystemDefaultRegistry
@@ -592,17 +770,66 @@ func get(ctx context.Context, envInfo *cmds.Agent, proxy proxy.Proxy) (*config.N
 	nodeConfig.AgentConfig.ImageCredProvBinDir = envInfo.ImageCredProvBinDir
 	nodeConfig.AgentConfig.ImageCredProvConfig = envInfo.ImageCredProvConfig
 	nodeConfig.AgentConfig.PrivateRegistry = envInfo.PrivateRegistry
+	nodeConfig.AgentConfig.ImagePullBandwidth, err = bwlimit.Load(envInfo.ImagePullBandwidthLimit, envInfo.PrivateRegistry)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load image pull bandwidth limits")
+	}
 	nodeConfig.AgentConfig.DisableCCM = controlConfig.DisableCCM
 	nodeConfig.AgentConfig.DisableNPC = controlConfig.DisableNPC
+	nodeConfig.AgentConfig.NetworkPolicyLogging = envInfo.NetworkPolicyLogging
 	nodeConfig.AgentConfig.Rootless = envInfo.Rootless
 	nodeConfig.AgentConfig.PodManifests = filepath.Join(envInfo.DataDir, "agent", DefaultPodManifestPath)
+	if envInfo.StaticPodDir != "" {
+		nodeConfig.AgentConfig.PodManifests = envInfo.StaticPodDir
+	}
 	nodeConfig.AgentConfig.ProtectKernelDefaults = envInfo.ProtectKernelDefaults
+	nodeConfig.AgentConfig.FailSwapOn = envInfo.FailSwapOn
+	nodeConfig.AgentConfig.SwapBehavior = envInfo.SwapBehavior
+	nodeConfig.AgentConfig.ReserveResources = envInfo.ReserveResources
+	nodeConfig.AgentConfig.KubeReserved = envInfo.KubeReserved
+	nodeConfig.AgentConfig.SystemReserved = envInfo.SystemReserved
+	nodeConfig.AgentConfig.CPUManagerPolicy = envInfo.CPUManagerPolicy
+	nodeConfig.AgentConfig.ReservedCPUs = envInfo.ReservedCPUs
+	nodeConfig.AgentConfig.MemoryManagerPolicy = envInfo.MemoryManagerPolicy
+	nodeConfig.AgentConfig.ReservedMemory = envInfo.ReservedMemory
+	nodeConfig.AgentConfig.TopologyManagerPolicy = envInfo.TopologyManagerPolicy
+	nodeConfig.AgentConfig.TopologyManagerScope = envInfo.TopologyManagerScope
+	nodeConfig.AgentConfig.TuningProfile = envInfo.TuningProfile
+	nodeConfig.AgentConfig.HugePages = util.SplitStringSlice(envInfo.HugePages)
+	nodeConfig.AgentConfig.KubeletSettings = getKubeletSettings(info, envInfo)
+	for _, name := range util.SplitStringSlice(envInfo.AllowedUnsafeSysctls) {
+		if name = strings.TrimSpace(name); name != "" {
+			nodeConfig.AgentConfig.AllowedUnsafeSysctls = append(nodeConfig.AgentConfig.AllowedUnsafeSysctls, name)
+		}
+	}
+	nodeConfig.AgentConfig.ContainerLogMaxSize = envInfo.ContainerLogMaxSize
+	nodeConfig.AgentConfig.ContainerLogMaxFiles = envInfo.ContainerLogMaxFiles
+	nodeConfig.AgentConfig.ImageGCHighThreshold = envInfo.ImageGCHighThreshold
+	nodeConfig.AgentConfig.ImageGCLowThreshold = envInfo.ImageGCLowThreshold
+	nodeConfig.AgentConfig.PinPreloadedImages = envInfo.PinPreloadedImages
+	nodeConfig.AgentConfig.KubeletConfig = filepath.Join(envInfo.DataDir, "agent", "etc", "kubelet-config.yaml")
 	nodeConfig.AgentConfig.DisableServiceLB = envInfo.DisableServiceLB
 
 	if err := validateNetworkConfig(nodeConfig); err != nil {
 		return nil, err
 	}
 
+	if err := validateResourceManagers(nodeConfig); err != nil {
+		return nil, err
+	}
+
+	if err := validateAllowedUnsafeSysctls(nodeConfig.AgentConfig.AllowedUnsafeSysctls); err != nil {
+		return nil, err
+	}
+
+	if err := validateImageGC(nodeConfig); err != nil {
+		return nil, err
+	}
+
+	if err := validateLogRotation(nodeConfig, envInfo); err != nil {
+		return nil, err
+	}
+
 	return nodeConfig, nil
 }
 
@@ -660,12 +887,304 @@ func getConfig(info *clientaccess.Info) (*config.Control, error) {
 	return controlControl, json.Unmarshal(data, controlControl)
 }
 
+// kubeletSettingsFile returns the path of the file used to cache kubelet settings retrieved from the server.
+func kubeletSettingsFile(envInfo *cmds.Agent) string {
+	return filepath.Join(envInfo.DataDir, "agent", "etc", "kubelet-settings.json")
+}
+
+// fetchKubeletSettings retrieves the kubelet settings distributed by the supervisor.
+func fetchKubeletSettings(info *clientaccess.Info) (map[string]string, error) {
+	data, err := info.Get("/v1-" + version.Program + "/kubelet-settings")
+	if err != nil {
+		return nil, err
+	}
+	settings := map[string]string{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	return settings, config.ValidateKubeletSettings(settings)
+}
+
+// getKubeletSettings retrieves the kubelet settings distributed by the supervisor, and caches them on disk.
+// If the server cannot provide the settings, the cached settings are used instead so that the agent does
+// not start with different settings than it was last running with, only to be restarted once the server
+// becomes available.
+func getKubeletSettings(info *clientaccess.Info, envInfo *cmds.Agent) map[string]string {
+	settingsFile := kubeletSettingsFile(envInfo)
+	settings, err := fetchKubeletSettings(info)
+	if err != nil {
+		logrus.Warnf("Failed to retrieve kubelet settings from server; using cached settings: %v", err)
+		settings = map[string]string{}
+		if data, err := os.ReadFile(settingsFile); err == nil {
+			if err := json.Unmarshal(data, &settings); err != nil {
+				logrus.Warnf("Failed to read cached kubelet settings: %v", err)
+			}
+		}
+		return settings
+	}
+	if err := writeKubeletSettings(settingsFile, settings); err != nil {
+		logrus.Warnf("Failed to cache kubelet settings: %v", err)
+	}
+	return settings
+}
+
+func writeKubeletSettings(settingsFile string, settings map[string]string) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return agentutil.WriteFile(settingsFile, string(data))
+}
+
+// kubeletSettingsInterval is the interval at which agents check for changes to the kubelet settings, and
+// kubeletSettingsRestartSpread is the period over which agents restart to apply changed settings, so that
+// the nodes of a cluster do not all restart at the same time.
+const (
+	kubeletSettingsInterval      = time.Minute
+	kubeletSettingsRestartSpread = 10 * time.Minute
+)
+
+// WatchKubeletSettings periodically retrieves the kubelet settings distributed by the supervisor. If the
+// settings differ from those the kubelet was started with, the new settings are cached, and after a random
+// delay the agent exits so that it can be restarted by the service manager and start the kubelet with the new
+// settings. Servers never exit, as restarting all servers at once could lose etcd quorum; the new settings are
+// applied the next time each server is restarted.
+func WatchKubeletSettings(ctx context.Context, nodeConfig *config.Node, envInfo *cmds.Agent, proxy proxy.Proxy) {
+	withCert := clientaccess.WithClientCertificate(nodeConfig.AgentConfig.ClientKubeletCert, nodeConfig.AgentConfig.ClientKubeletKey)
+	var restart <-chan time.Time
+	var warned map[string]string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-restart:
+			logrus.Fatalf("Kubelet settings changed for node %s; exiting so that %s can be restarted with new settings", nodeConfig.AgentConfig.NodeName, version.Program)
+		case <-time.After(wait.Jitter(kubeletSettingsInterval, 0.5)):
+		}
+		info, err := clientaccess.ParseAndValidateToken(proxy.SupervisorURL(), nodeConfig.Token, withCert)
+		if err != nil {
+			logrus.Debugf("Failed to validate server token while checking kubelet settings: %v", err)
+			continue
+		}
+		settings, err := fetchKubeletSettings(info)
+		if err != nil {
+			logrus.Debugf("Failed to retrieve kubelet settings from server: %v", err)
+			continue
+		}
+		if kubeletSettingsEqual(settings, nodeConfig.AgentConfig.KubeletSettings) {
+			if restart != nil {
+				logrus.Infof("Kubelet settings for node %s reverted to current settings; not restarting", nodeConfig.AgentConfig.NodeName)
+				restart = nil
+			}
+			continue
+		}
+		if envInfo.Server {
+			if !kubeletSettingsEqual(settings, warned) {
+				logrus.Warnf("Kubelet settings changed; restart %s on server %s to apply them", version.Program, nodeConfig.AgentConfig.NodeName)
+				warned = settings
+			}
+			continue
+		}
+		if err := writeKubeletSettings(kubeletSettingsFile(envInfo), settings); err != nil {
+			logrus.Errorf("Failed to cache kubelet settings: %v", err)
+			continue
+		}
+		if restart == nil {
+			delay := time.Duration(rand.Int63n(int64(kubeletSettingsRestartSpread)))
+			logrus.Infof("Kubelet settings changed for node %s; restarting %s in %s to apply them", nodeConfig.AgentConfig.NodeName, version.Program, delay.Round(time.Second))
+			restart = time.After(delay)
+		}
+	}
+}
+
+// kubeletSettingsEqual returns true if the kubelet settings are the same, treating nil and empty settings as equal.
+func kubeletSettingsEqual(a, b map[string]string) bool {
+	return (len(a) == 0 && len(b) == 0) || equality.Semantic.DeepEqual(a, b)
+}
+
 // getReadyz returns nil if the server is ready, or an error if not.
 func getReadyz(info *clientaccess.Info) error {
 	_, err := info.Get("/v1-" + version.Program + "/readyz")
 	return err
 }
 
+// validateResourceManagers ensures that the kubelet CPU, memory, and topology manager policies are valid,
+// and that the reservations required by the static policies are present. Policies that are not set are
+// defaulted according to the tuning profile.
+func validateResourceManagers(nodeConfig *config.Node) error {
+	agentConfig := &nodeConfig.AgentConfig
+
+	switch agentConfig.TuningProfile {
+	case "", "none":
+	case config.TuningProfileLowLatency:
+		// low-latency workloads need exclusive CPUs that are aligned with the devices they use
+		if agentConfig.ReservedCPUs == "" {
+			return fmt.Errorf("tuning-profile %s requires reserved-cpus to be set", agentConfig.TuningProfile)
+		}
+		if agentConfig.CPUManagerPolicy == config.CPUManagerPolicyNone {
+			return fmt.Errorf("tuning-profile %s cannot be used with cpu-manager-policy %s", agentConfig.TuningProfile, agentConfig.CPUManagerPolicy)
+		}
+		agentConfig.CPUManagerPolicy = config.CPUManagerPolicyStatic
+		if agentConfig.TopologyManagerPolicy == "" {
+			agentConfig.TopologyManagerPolicy = "restricted"
+		}
+	default:
+		return fmt.Errorf("invalid tuning-profile %s; valid values are 'none' and '%s'", agentConfig.TuningProfile, config.TuningProfileLowLatency)
+	}
+
+	switch agentConfig.CPUManagerPolicy {
+	case "", config.CPUManagerPolicyNone:
+	case config.CPUManagerPolicyStatic:
+		if agentConfig.ReservedCPUs == "" && agentConfig.ReserveResources == "" && agentConfig.KubeReserved == "" && agentConfig.SystemReserved == "" &&
+			!hasKubeletArg(agentConfig.ExtraKubeletArgs, "kube-reserved") && !hasKubeletArg(agentConfig.ExtraKubeletArgs, "system-reserved") {
+			return errors.New("cpu-manager-policy static requires reserved-cpus, reserve-resources, kube-reserved or system-reserved to be set")
+		}
+	default:
+		return fmt.Errorf("invalid cpu-manager-policy %s; valid values are '%s' and '%s'", agentConfig.CPUManagerPolicy, config.CPUManagerPolicyNone, config.CPUManagerPolicyStatic)
+	}
+
+	if agentConfig.ReservedCPUs != "" {
+		if _, err := cpuset.Parse(agentConfig.ReservedCPUs); err != nil {
+			return errors.Wrap(err, "invalid reserved-cpus")
+		}
+	}
+
+	if agentConfig.CPUManagerPolicy == config.CPUManagerPolicyStatic {
+		if err := cgroups.ValidateCPUManager(agentConfig.ReservedCPUs); err != nil {
+			return errors.Wrap(err, "cpu-manager-policy static is not supported by the cgroup configuration of this node")
+		}
+	}
+
+	switch agentConfig.MemoryManagerPolicy {
+	case "", config.MemoryManagerPolicyNone:
+	case config.MemoryManagerPolicyStatic:
+		if agentConfig.ReservedMemory == "" {
+			return errors.New("memory-manager-policy Static requires reserved-memory to be set")
+		}
+	default:
+		return fmt.Errorf("invalid memory-manager-policy %s; valid values are '%s' and '%s'", agentConfig.MemoryManagerPolicy, config.MemoryManagerPolicyNone, config.MemoryManagerPolicyStatic)
+	}
+
+	if agentConfig.ReservedMemory != "" {
+		reservations := []kubeletconfig.MemoryReservation{}
+		if err := (&utilflag.ReservedMemoryVar{Value: &reservations}).Set(agentConfig.ReservedMemory); err != nil {
+			return errors.Wrap(err, "invalid reserved-memory")
+		}
+		if agentConfig.MemoryManagerPolicy == config.MemoryManagerPolicyStatic {
+			nodes := []int{}
+			for _, reservation := range reservations {
+				nodes = append(nodes, int(reservation.NumaNode))
+			}
+			if err := cgroups.ValidateMemoryManager(nodes); err != nil {
+				return errors.Wrap(err, "memory-manager-policy Static is not supported by the cgroup configuration of this node")
+			}
+		}
+	}
+
+	switch agentConfig.TopologyManagerPolicy {
+	case "", "none", "best-effort", "restricted", "single-numa-node":
+	default:
+		return fmt.Errorf("invalid topology-manager-policy %s; valid values are 'none', 'best-effort', 'restricted', and 'single-numa-node'", agentConfig.TopologyManagerPolicy)
+	}
+	if err := cgroups.ValidateTopologyManager(agentConfig.TopologyManagerPolicy); err != nil {
+		return errors.Wrap(err, "failed to validate topology-manager-policy")
+	}
+
+	switch agentConfig.TopologyManagerScope {
+	case "", "container", "pod":
+	default:
+		return fmt.Errorf("invalid topology-manager-scope %s; valid values are 'container' and 'pod'", agentConfig.TopologyManagerScope)
+	}
+
+	return nil
+}
+
+// validateAllowedUnsafeSysctls ensures that the allowed unsafe sysctls are valid patterns for namespaced
+// sysctls, as the kubelet will refuse to start otherwise. Sysctls that are not available on this node are
+// only warned about, as they may be provided by kernel modules that have not yet been loaded.
+func validateAllowedUnsafeSysctls(sysctls []string) error {
+	if len(sysctls) == 0 {
+		return nil
+	}
+	if _, err := sysctl.NewAllowlist(sysctls); err != nil {
+		return errors.Wrap(err, "invalid allowed-unsafe-sysctls")
+	}
+	checkSysctlsExist(sysctls)
+	return nil
+}
+
+// validateImageGC ensures that the image garbage collection thresholds are valid percentages, and that the
+// low threshold is less than the high threshold if both are set.
+func validateImageGC(nodeConfig *config.Node) error {
+	high, low := nodeConfig.AgentConfig.ImageGCHighThreshold, nodeConfig.AgentConfig.ImageGCLowThreshold
+	if high < 0 || high > 100 {
+		return fmt.Errorf("invalid image-gc-high-threshold %d; must be between 0 and 100", high)
+	}
+	if low < 0 || low > 100 {
+		return fmt.Errorf("invalid image-gc-low-threshold %d; must be between 0 and 100", low)
+	}
+	if high != 0 && low != 0 && low >= high {
+		return fmt.Errorf("invalid image-gc-low-threshold %d; must be less than image-gc-high-threshold %d", low, high)
+	}
+	return nil
+}
+
+// setKubeletRootDir sets the kubelet root dir, which contains the plugin, plugin registration, and pod-resources
+// directories. The device plugin registration dir is fixed by the kubelet and does not move with the root dir, so
+// startup fails if it cannot be created, instead of the kubelet failing to start its device manager later.
+func setKubeletRootDir(nodeConfig *config.Node, envInfo *cmds.Agent) error {
+	switch {
+	case envInfo.KubeletRootDir != "":
+		nodeConfig.AgentConfig.RootDir = envInfo.KubeletRootDir
+		if err := os.MkdirAll(devicePluginPath, 0750); err != nil {
+			return errors.Wrapf(err, "kubelet-root-dir is set to %s, but device plugins must still register at %s, which cannot be created", envInfo.KubeletRootDir, devicePluginPath)
+		}
+	case envInfo.Rootless:
+		nodeConfig.AgentConfig.RootDir = filepath.Join(envInfo.DataDir, "agent", "kubelet")
+	}
+	return nil
+}
+
+// validateLogRotation ensures that the container and containerd log rotation settings are valid, and
+// converts the containerd log size to the whole number of megabytes used by the log writer.
+func validateLogRotation(nodeConfig *config.Node, envInfo *cmds.Agent) error {
+	if size := nodeConfig.AgentConfig.ContainerLogMaxSize; size != "" {
+		if q, err := resource.ParseQuantity(size); err != nil || q.Sign() <= 0 {
+			return fmt.Errorf("invalid container-log-max-size %s; must be a positive quantity such as 10Mi", size)
+		}
+	}
+	if files := nodeConfig.AgentConfig.ContainerLogMaxFiles; files != 0 && files < 2 {
+		return fmt.Errorf("invalid container-log-max-files %d; must be at least 2", files)
+	}
+
+	if size := envInfo.ContainerdLogMaxSize; size != "" {
+		q, err := resource.ParseQuantity(size)
+		if err != nil || q.Sign() <= 0 {
+			return fmt.Errorf("invalid containerd-log-max-size %s; must be a positive quantity such as 10Mi", size)
+		}
+		megabytes := (q.Value() + 1024*1024 - 1) / (1024 * 1024)
+		nodeConfig.Containerd.LogMaxSize = int(megabytes)
+	}
+	// The log writer retains all rotated files if the number of backups is 0.
+	if envInfo.ContainerdLogMaxBackups < 1 {
+		return fmt.Errorf("invalid containerd-log-max-backups %d; must be at least 1", envInfo.ContainerdLogMaxBackups)
+	}
+	nodeConfig.Containerd.LogMaxBackups = envInfo.ContainerdLogMaxBackups
+	nodeConfig.Containerd.RuntimeSearchPaths = util.SplitStringSlice(envInfo.RuntimeSearchPath)
+	return nil
+}
+
+// hasKubeletArg returns true if the named arg is set in the list of extra kubelet args.
+func hasKubeletArg(args []string, name string) bool {
+	for _, arg := range args {
+		arg = strings.TrimLeft(arg, "-")
+		if arg == name || strings.HasPrefix(arg, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
 // validateNetworkConfig ensures that the network configuration values provided by the server make sense.
 func validateNetworkConfig(nodeConfig *config.Node) error {
 	// Old versions of the server do not send enough information to correctly start the NPC. Users
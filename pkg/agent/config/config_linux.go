@@ -5,8 +5,10 @@ package config
 
 import (
 	"path/filepath"
+	"strings"
 
 	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/sirupsen/logrus"
 )
 
 func applyContainerdStateAndAddress(nodeConfig *config.Node) {
@@ -17,3 +19,18 @@ func applyContainerdStateAndAddress(nodeConfig *config.Node) {
 func applyCRIDockerdAddress(nodeConfig *config.Node) {
 	nodeConfig.CRIDockerd.Address = "unix:///run/k3s/cri-dockerd/cri-dockerd.sock"
 }
+
+// checkSysctlsExist warns about any sysctls, or sysctl patterns, that do not match anything under /proc/sys.
+func checkSysctlsExist(sysctls []string) {
+	for _, name := range sysctls {
+		// Sysctls may use either dots or slashes as separators; if slashes are used, dots are literal.
+		path := name
+		if !strings.Contains(path, "/") {
+			path = strings.ReplaceAll(path, ".", "/")
+		}
+		path = filepath.Join("/proc/sys", path)
+		if matches, err := filepath.Glob(path); err != nil || len(matches) == 0 {
+			logrus.Warnf("Allowed unsafe sysctl %s is not available on this node", name)
+		}
+	}
+}
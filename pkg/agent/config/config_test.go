@@ -0,0 +1,218 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+)
+
+func Test_UnitValidateLogRotation(t *testing.T) {
+	tests := []struct {
+		name              string
+		envInfo           cmds.Agent
+		wantLogMaxSize    int
+		wantLogMaxBackups int
+		wantErr           bool
+	}{
+		{
+			name: "defaults",
+			envInfo: cmds.Agent{
+				ContainerLogMaxSize:     "10Mi",
+				ContainerLogMaxFiles:    5,
+				ContainerdLogMaxSize:    "10Mi",
+				ContainerdLogMaxBackups: 3,
+			},
+			wantLogMaxSize:    10,
+			wantLogMaxBackups: 3,
+		},
+		{
+			name: "containerd log size rounded up to whole megabytes",
+			envInfo: cmds.Agent{
+				ContainerdLogMaxSize:    "1500Ki",
+				ContainerdLogMaxBackups: 1,
+			},
+			wantLogMaxSize:    2,
+			wantLogMaxBackups: 1,
+		},
+		{
+			name: "invalid container log size",
+			envInfo: cmds.Agent{
+				ContainerLogMaxSize:     "ten megabytes",
+				ContainerdLogMaxBackups: 3,
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero container log size",
+			envInfo: cmds.Agent{
+				ContainerLogMaxSize:     "0",
+				ContainerdLogMaxBackups: 3,
+			},
+			wantErr: true,
+		},
+		{
+			name: "single container log file",
+			envInfo: cmds.Agent{
+				ContainerLogMaxFiles:    1,
+				ContainerdLogMaxBackups: 3,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative containerd log size",
+			envInfo: cmds.Agent{
+				ContainerdLogMaxSize:    "-10Mi",
+				ContainerdLogMaxBackups: 3,
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero containerd log backups",
+			envInfo: cmds.Agent{
+				ContainerdLogMaxSize:    "10Mi",
+				ContainerdLogMaxBackups: 0,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative containerd log backups",
+			envInfo: cmds.Agent{
+				ContainerdLogMaxSize:    "10Mi",
+				ContainerdLogMaxBackups: -1,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeConfig := &config.Node{}
+			nodeConfig.AgentConfig.ContainerLogMaxSize = tt.envInfo.ContainerLogMaxSize
+			nodeConfig.AgentConfig.ContainerLogMaxFiles = tt.envInfo.ContainerLogMaxFiles
+			err := validateLogRotation(nodeConfig, &tt.envInfo)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateLogRotation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if nodeConfig.Containerd.LogMaxSize != tt.wantLogMaxSize {
+				t.Errorf("validateLogRotation() LogMaxSize = %d, want %d", nodeConfig.Containerd.LogMaxSize, tt.wantLogMaxSize)
+			}
+			if nodeConfig.Containerd.LogMaxBackups != tt.wantLogMaxBackups {
+				t.Errorf("validateLogRotation() LogMaxBackups = %d, want %d", nodeConfig.Containerd.LogMaxBackups, tt.wantLogMaxBackups)
+			}
+		})
+	}
+}
+
+func Test_UnitSetKubeletRootDir(t *testing.T) {
+	tempDir := t.TempDir()
+	notADir := filepath.Join(tempDir, "file")
+	if err := os.WriteFile(notADir, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer func(path string) { devicePluginPath = path }(devicePluginPath)
+
+	tests := []struct {
+		name             string
+		envInfo          cmds.Agent
+		devicePluginPath string
+		wantRootDir      string
+		wantErr          bool
+	}{
+		{
+			name:             "default",
+			envInfo:          cmds.Agent{DataDir: "/var/lib/rancher/k3s"},
+			devicePluginPath: filepath.Join(tempDir, "default", "device-plugins"),
+		},
+		{
+			name:             "rootless",
+			envInfo:          cmds.Agent{DataDir: "/home/k3s/.rancher/k3s", Rootless: true},
+			devicePluginPath: filepath.Join(tempDir, "rootless", "device-plugins"),
+			wantRootDir:      "/home/k3s/.rancher/k3s/agent/kubelet",
+		},
+		{
+			name:             "kubelet root dir",
+			envInfo:          cmds.Agent{DataDir: "/var/lib/rancher/k3s", KubeletRootDir: "/data/kubelet"},
+			devicePluginPath: filepath.Join(tempDir, "kubelet", "device-plugins"),
+			wantRootDir:      "/data/kubelet",
+		},
+		{
+			name:             "kubelet root dir overrides rootless",
+			envInfo:          cmds.Agent{DataDir: "/home/k3s/.rancher/k3s", Rootless: true, KubeletRootDir: "/data/kubelet"},
+			devicePluginPath: filepath.Join(tempDir, "both", "device-plugins"),
+			wantRootDir:      "/data/kubelet",
+		},
+		{
+			name:             "device plugin dir cannot be created",
+			envInfo:          cmds.Agent{DataDir: "/var/lib/rancher/k3s", KubeletRootDir: "/data/kubelet"},
+			devicePluginPath: filepath.Join(notADir, "device-plugins"),
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devicePluginPath = tt.devicePluginPath
+			nodeConfig := &config.Node{}
+			err := setKubeletRootDir(nodeConfig, &tt.envInfo)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setKubeletRootDir() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if nodeConfig.AgentConfig.RootDir != tt.wantRootDir {
+				t.Errorf("setKubeletRootDir() RootDir = %s, want %s", nodeConfig.AgentConfig.RootDir, tt.wantRootDir)
+			}
+			if tt.envInfo.KubeletRootDir != "" {
+				if _, err := os.Stat(tt.devicePluginPath); err != nil {
+					t.Errorf("setKubeletRootDir() did not create device plugin dir: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func Test_UnitValidateAllowedUnsafeSysctls(t *testing.T) {
+	tests := []struct {
+		name    string
+		sysctls []string
+		wantErr bool
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:    "namespaced sysctls",
+			sysctls: []string{"net.core.somaxconn", "kernel.msgmax"},
+		},
+		{
+			name:    "namespaced pattern",
+			sysctls: []string{"net.ipv4.tcp_*"},
+		},
+		{
+			name:    "slash separators",
+			sysctls: []string{"net/ipv4/conf/eth0.100/forwarding"},
+		},
+		{
+			name:    "not namespaced",
+			sysctls: []string{"vm.swappiness"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid pattern",
+			sysctls: []string{"net.*.somaxconn"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateAllowedUnsafeSysctls(tt.sysctls); (err != nil) != tt.wantErr {
+				t.Errorf("validateAllowedUnsafeSysctls() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/sirupsen/logrus"
 )
 
 func applyContainerdStateAndAddress(nodeConfig *config.Node) {
@@ -17,3 +18,8 @@ func applyContainerdStateAndAddress(nodeConfig *config.Node) {
 func applyCRIDockerdAddress(nodeConfig *config.Node) {
 	nodeConfig.CRIDockerd.Address = "npipe:////.pipe/cri-dockerd"
 }
+
+// checkSysctlsExist warns that sysctls are not supported, as Windows does not have them.
+func checkSysctlsExist(sysctls []string) {
+	logrus.Warnf("Allowed unsafe sysctls are not supported on Windows and will be ignored: %v", sysctls)
+}
@@ -68,9 +68,17 @@ func setupContainerdConfig(ctx context.Context, cfg *config.Node) error {
 		IsRunningInUserNS:     isRunningInUserNS,
 		EnableUnprivileged:    kernel.CheckKernelVersion(4, 11, 0),
 		PrivateRegistryConfig: privRegistries.Registry,
-		ExtraRuntimes:         findNvidiaContainerRuntimes(os.DirFS(string(os.PathSeparator))),
+		ExtraRuntimes:         findContainerRuntimes(os.DirFS(string(os.PathSeparator)), cfg.Containerd.RuntimeSearchPaths),
 		Program:               version.Program,
 	}
+	cfg.Containerd.Runtimes = runtimeNames(containerdConfig.ExtraRuntimes)
+
+	if cfg.AgentConfig.RegistryHealthInterval > 0 && len(privRegistries.Registry.Mirrors) > 0 {
+		if err := setupRegistryHosts(ctx, cfg, privRegistries.Registry); err != nil {
+			return errors.Wrap(err, "failed to set up registry hosts config")
+		}
+		containerdConfig.RegistryConfigPath = cfg.Containerd.Registry
+	}
 
 	selEnabled, selConfigured, err := selinuxStatus()
 	if err != nil {
@@ -86,6 +94,9 @@ func setupContainerdConfig(ctx context.Context, cfg *config.Node) error {
 	containerdTemplateBytes, err := os.ReadFile(cfg.Containerd.Template)
 	if err == nil {
 		logrus.Infof("Using containerd template at %s", cfg.Containerd.Template)
+		if containerdConfig.RegistryConfigPath != "" {
+			logrus.Warnf("Registry mirror health checks require the containerd template to set the CRI registry config_path to %s", containerdConfig.RegistryConfigPath)
+		}
 		containerdTemplate = string(containerdTemplateBytes)
 	} else if os.IsNotExist(err) {
 		containerdTemplate = templates.ContainerdConfigTemplate
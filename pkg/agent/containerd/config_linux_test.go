@@ -0,0 +1,96 @@
+//go:build linux
+// +build linux
+
+package containerd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/pkg/cri/config"
+	"github.com/containerd/containerd/plugin"
+	srvconfig "github.com/containerd/containerd/services/server/config"
+	"github.com/k3s-io/k3s/pkg/agent/templates"
+	daemonconfig "github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/rancher/wharfie/pkg/registries"
+)
+
+// loadCRIConfig loads the CRI plugin config from the rendered containerd config, and validates it as containerd
+// does at startup.
+func loadCRIConfig(t *testing.T, containerdConfig string) (*config.PluginConfig, error) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(containerdConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	srvConfig := &srvconfig.Config{}
+	if err := srvconfig.LoadConfig(path, srvConfig); err != nil {
+		return nil, err
+	}
+	pluginConfig := config.DefaultConfig()
+	if _, err := srvConfig.Decode(&plugin.Registration{Type: plugin.GRPCPlugin, ID: "cri", Config: &pluginConfig}); err != nil {
+		return nil, err
+	}
+	return &pluginConfig, config.ValidatePluginConfig(context.Background(), &pluginConfig)
+}
+
+func Test_UnitContainerdConfigTemplateRegistry(t *testing.T) {
+	registry := &registries.Registry{
+		Mirrors: map[string]registries.Mirror{
+			"docker.io": {Endpoints: []string{"https://mirror.example.com"}},
+		},
+		Configs: map[string]registries.RegistryConfig{
+			"mirror.example.com": {
+				Auth: &registries.AuthConfig{Username: "user", Password: "pass"},
+				TLS:  &registries.TLSConfig{CAFile: "/etc/ssl/mirror-ca.crt"},
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		configPath string
+		wantMirror bool
+		wantTLS    bool
+	}{
+		{
+			name:       "Registry config in CRI plugin",
+			wantMirror: true,
+			wantTLS:    true,
+		},
+		{
+			name:       "Registry hosts config path",
+			configPath: "/var/lib/rancher/k3s/agent/etc/containerd/certs.d",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := templates.ParseTemplateFromConfig(templates.ContainerdConfigTemplate, templates.ContainerdConfig{
+				NodeConfig:            &daemonconfig.Node{},
+				PrivateRegistryConfig: registry,
+				RegistryConfigPath:    tt.configPath,
+				Program:               "k3s",
+			})
+			if err != nil {
+				t.Fatalf("ParseTemplateFromConfig() error = %v", err)
+			}
+			pluginConfig, err := loadCRIConfig(t, rendered)
+			if err != nil {
+				t.Fatalf("containerd rejected rendered config: %v\n%s", err, rendered)
+			}
+			if pluginConfig.Registry.ConfigPath != tt.configPath {
+				t.Errorf("config_path = %q, want %q", pluginConfig.Registry.ConfigPath, tt.configPath)
+			}
+			if _, ok := pluginConfig.Registry.Mirrors["docker.io"]; ok != tt.wantMirror {
+				t.Errorf("mirrors set = %v, want %v", ok, tt.wantMirror)
+			}
+			registryConfig := pluginConfig.Registry.Configs["mirror.example.com"]
+			if (registryConfig.TLS != nil) != tt.wantTLS {
+				t.Errorf("configs.tls set = %v, want %v", registryConfig.TLS != nil, tt.wantTLS)
+			}
+			if registryConfig.Auth == nil || registryConfig.Auth.Username != "user" {
+				t.Errorf("configs.auth = %+v, want username user", registryConfig.Auth)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	util2 "github.com/k3s-io/k3s/pkg/agent/util"
 	"github.com/k3s-io/k3s/pkg/daemons/config"
 	util3 "github.com/k3s-io/k3s/pkg/util"
+	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/pkg/errors"
 	"github.com/rancher/wharfie/pkg/registries"
 	"github.com/sirupsen/logrus"
@@ -38,6 +39,14 @@ func setupContainerdConfig(ctx context.Context, cfg *config.Node) error {
 		logrus.Warn("SELinux isn't supported on windows")
 	}
 
+	if cfg.AgentConfig.RegistryHealthInterval > 0 {
+		logrus.Warn("Registry mirror health checks aren't supported on windows")
+	}
+
+	if cfg.AgentConfig.RegistryAuthSecret {
+		logrus.Warn("Registry credentials from a Secret aren't supported on windows")
+	}
+
 	var containerdTemplate string
 
 	containerdConfig := templates.ContainerdConfig{
@@ -85,3 +94,8 @@ func FuseoverlayfsSupported(root string) error {
 func StargzSupported(root string) error {
 	return errors.Wrapf(util3.ErrUnsupportedPlatform, "stargz is not supported")
 }
+
+func ReloadRegistries(cfg *config.Node) error {
+	logrus.Infof("Registry hosts config is not supported on windows; %s must be restarted to apply changes to %s", version.Program, cfg.AgentConfig.PrivateRegistry)
+	return nil
+}
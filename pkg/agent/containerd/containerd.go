@@ -18,9 +18,11 @@ import (
 	"github.com/containerd/containerd/namespaces"
 	"github.com/containerd/containerd/pkg/cri/constants"
 	"github.com/containerd/containerd/reference/docker"
+	"github.com/k3s-io/k3s/pkg/agent/bwlimit"
 	"github.com/k3s-io/k3s/pkg/agent/cri"
 	util2 "github.com/k3s-io/k3s/pkg/agent/util"
 	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/util"
 	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/natefinch/lumberjack"
 	"github.com/pkg/errors"
@@ -31,6 +33,18 @@ import (
 	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
 )
 
+const (
+	// PinnedImagesFile is the name of the file in the agent images directory that lists images that are pinned,
+	// so that they are not removed by kubelet image garbage collection. Listed images that are not present are
+	// pulled, as with other image lists in the images directory.
+	PinnedImagesFile = "pinned.txt"
+	// pinnedLabel is the containerd image label that marks an image as pinned. The CRI plugin reports images
+	// with this label to the kubelet as pinned, and the kubelet does not garbage collect pinned images. Images
+	// may also be pinned by setting the label directly, for example with 'ctr images label'.
+	pinnedLabel      = "io.cri-containerd.pinned"
+	pinnedLabelValue = "pinned"
+)
+
 // Run configures and starts containerd as a child process. Once it is up, images are preloaded
 // or pulled from files found in the agent images directory.
 func Run(ctx context.Context, cfg *config.Node) error {
@@ -46,8 +60,8 @@ func Run(ctx context.Context, cfg *config.Node) error {
 		logrus.Infof("Logging containerd to %s", cfg.Containerd.Log)
 		fileOut := &lumberjack.Logger{
 			Filename:   cfg.Containerd.Log,
-			MaxSize:    50,
-			MaxBackups: 3,
+			MaxSize:    cfg.Containerd.LogMaxSize,
+			MaxBackups: cfg.Containerd.LogMaxBackups,
 			MaxAge:     28,
 			Compress:   true,
 		}
@@ -62,6 +76,15 @@ func Run(ctx context.Context, cfg *config.Node) error {
 		}
 	}
 
+	pullProxy := ""
+	if cfg.AgentConfig.ImagePullBandwidth.Enabled() {
+		address, err := bwlimit.NewProxy(cfg.AgentConfig.ImagePullBandwidth).ListenAndServe(ctx, "127.0.0.1:0")
+		if err != nil {
+			return errors.Wrap(err, "failed to start image pull bandwidth limiting proxy")
+		}
+		pullProxy = address
+	}
+
 	go func() {
 		env := []string{}
 		cenv := []string{}
@@ -74,6 +97,9 @@ func Run(ctx context.Context, cfg *config.Node) error {
 			case pair[0] == "CONTAINERD_LOG_LEVEL":
 				// Turn CONTAINERD_LOG_LEVEL variable into log-level flag
 				args = append(args, "--log-level", pair[1])
+			case strings.EqualFold(pair[0], "CONTAINERD_NO_PROXY"):
+				// Add CONTAINERD_NO_PROXY entries to the global NO_PROXY, so that automatically computed entries are retained
+				cenv = append(cenv, "NO_PROXY="+util.AppendNoProxy(os.Getenv("NO_PROXY"), pair[1]))
 			case strings.HasPrefix(pair[0], "CONTAINERD_"):
 				// Strip variables with CONTAINERD_ prefix before passing through
 				// This allows doing things like setting a proxy for image pulls by setting
@@ -85,6 +111,15 @@ func Run(ctx context.Context, cfg *config.Node) error {
 			}
 		}
 
+		if pullProxy != "" {
+			if bwlimit.ProxyConfigured(append(env, cenv...)) {
+				logrus.Warn("Image pull bandwidth limits are not applied, as a proxy is configured for containerd")
+			} else {
+				logrus.Infof("Limiting image pull bandwidth through proxy at %s", pullProxy)
+				cenv = append(cenv, bwlimit.ProxyEnv(pullProxy)...)
+			}
+		}
+
 		logrus.Infof("Running containerd %s", config.ArgString(args[1:]))
 		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
 		cmd.Stdout = stdOut
@@ -102,6 +137,12 @@ func Run(ctx context.Context, cfg *config.Node) error {
 		return err
 	}
 
+	if cfg.AgentConfig.LocalRegistryListen != "" {
+		if err := startLocalRegistry(ctx, cfg); err != nil {
+			return errors.Wrap(err, "failed to start local registry")
+		}
+	}
+
 	return preloadImages(ctx, cfg)
 }
 
@@ -179,6 +220,12 @@ func preloadImages(ctx context.Context, cfg *config.Node) error {
 			continue
 		}
 		logrus.Infof("Imported images from %s in %s", filePath, time.Since(start))
+
+		if fileInfo.Name() == PinnedImagesFile {
+			if err := pinImageList(ctx, client, filePath); err != nil {
+				logrus.Errorf("Error encountered while pinning images listed in %s: %v", filePath, err)
+			}
+		}
 	}
 	return nil
 }
@@ -197,25 +244,130 @@ func preloadFile(ctx context.Context, cfg *config.Node, client *containerd.Clien
 		return prePullImages(ctx, criConn, file)
 	}
 
-	opener, err := tarfile.GetOpener(filePath)
+	images, err := importFile(ctx, client, filePath)
 	if err != nil {
 		return err
 	}
 
-	imageReader, err := opener()
+	// Images are pinned before they are retagged, so that the retagged images inherit the pinned label.
+	if cfg.AgentConfig.PinPreloadedImages {
+		for i := range images {
+			if err := pinImage(ctx, client, &images[i]); err != nil {
+				return errors.Wrapf(err, "failed to pin image %s", images[i].Name)
+			}
+		}
+	}
+
+	return retagImages(ctx, client, images, cfg.AgentConfig.AirgapExtraRegistry)
+}
+
+// pinImageList pins the images listed in a file. Images that are not present, for example because they failed
+// to pull, are skipped.
+func pinImageList(ctx context.Context, client *containerd.Client, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	refs, err := imageListRefs(file)
 	if err != nil {
 		return err
 	}
-	defer imageReader.Close()
 
-	logrus.Infof("Importing images from %s", filePath)
+	var errs []error
+	imageService := client.ImageService()
+	for _, ref := range refs {
+		image, err := imageService.Get(ctx, ref)
+		if errdefs.IsNotFound(err) {
+			logrus.Warnf("Unable to pin image %s: image not found", ref)
+			continue
+		} else if err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to get image %s", ref))
+			continue
+		}
+		if err := pinImage(ctx, client, &image); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to pin image %s", ref))
+		}
+	}
+	return merr.NewErrors(errs...)
+}
 
-	images, err := client.Import(ctx, imageReader, containerd.WithAllPlatforms(true))
-	if err != nil {
+// pinImage sets the pinned label on an image, unless it is already set.
+func pinImage(ctx context.Context, client *containerd.Client, image *images.Image) error {
+	if image.Labels[pinnedLabel] == pinnedLabelValue {
+		return nil
+	}
+	if image.Labels == nil {
+		image.Labels = map[string]string{}
+	}
+	image.Labels[pinnedLabel] = pinnedLabelValue
+	if _, err := client.ImageService().Update(ctx, *image, "labels."+pinnedLabel); err != nil {
 		return err
 	}
+	logrus.Infof("Pinned %s", image.Name)
+	return nil
+}
 
-	return retagImages(ctx, client, images, cfg.AgentConfig.AirgapExtraRegistry)
+// imageListRefs returns the normalized references of the images in an image list. Blank lines and lines
+// starting with # are ignored.
+func imageListRefs(r io.Reader) ([]string, error) {
+	refs := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ref, err := docker.ParseDockerRef(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid image reference %s", line)
+		}
+		refs = append(refs, ref.String())
+	}
+	return refs, scanner.Err()
+}
+
+// Import imports the images from an image tarball into a running containerd. The images are held by the
+// same lease as the images preloaded when the agent starts, so that they are not garbage collected.
+func Import(ctx context.Context, address, filePath string) ([]images.Image, error) {
+	client, err := Client(address)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx = namespaces.WithNamespace(ctx, constants.K8sContainerdNamespace)
+
+	ls := client.LeasesService()
+	existingLeases, err := ls.List(ctx, "id=="+version.Program)
+	if err != nil {
+		return nil, err
+	}
+	if len(existingLeases) == 0 {
+		if _, err := ls.Create(ctx, leases.WithID(version.Program)); err != nil {
+			return nil, err
+		}
+	}
+	ctx = leases.WithLease(ctx, version.Program)
+
+	return importFile(ctx, client, filePath)
+}
+
+func importFile(ctx context.Context, client *containerd.Client, filePath string) ([]images.Image, error) {
+	opener, err := tarfile.GetOpener(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	imageReader, err := opener()
+	if err != nil {
+		return nil, err
+	}
+	defer imageReader.Close()
+
+	logrus.Infof("Importing images from %s", filePath)
+
+	return client.Import(ctx, imageReader, containerd.WithAllPlatforms(true))
 }
 
 // retagImages retags all listed images as having been pulled from the given remote registries.
@@ -275,6 +427,9 @@ func prePullImages(ctx context.Context, conn *grpc.ClientConn, images io.Reader)
 	scanner := bufio.NewScanner(images)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
 		resp, err := imageClient.ImageStatus(ctx, &runtimeapi.ImageStatusRequest{
 			Image: &runtimeapi.ImageSpec{
 				Image: line,
@@ -0,0 +1,47 @@
+package containerd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_UnitImageListRefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "Empty list",
+			list: "",
+			want: []string{},
+		},
+		{
+			name: "Normalized references",
+			list: "nginx\n\n# comment\n  docker.io/rancher/mirrored-pause:3.6  \nregistry.example.com/app@sha256:0000000000000000000000000000000000000000000000000000000000000000\n",
+			want: []string{
+				"docker.io/library/nginx:latest",
+				"docker.io/rancher/mirrored-pause:3.6",
+				"registry.example.com/app@sha256:0000000000000000000000000000000000000000000000000000000000000000",
+			},
+		},
+		{
+			name:    "Invalid reference",
+			list:    "Invalid Image\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := imageListRefs(strings.NewReader(tt.list))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("imageListRefs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("imageListRefs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+//go:build linux
+// +build linux
+
+package containerd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/k3s-io/k3s/pkg/agent/mirrorhealth"
+	"github.com/k3s-io/k3s/pkg/agent/templates"
+	util2 "github.com/k3s-io/k3s/pkg/agent/util"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/rancher/wharfie/pkg/registries"
+	"github.com/sirupsen/logrus"
+)
+
+// registryHosts holds the registry configuration used to write the hosts.toml files, so that they can be
+// written again when the health of a mirror endpoint changes.
+var registryHosts struct {
+	sync.Mutex
+	cfg      *config.Node
+	registry *registries.Registry
+	checker  *mirrorhealth.Checker
+}
+
+// setupRegistryHosts writes a hosts.toml file for each registry. If health checks are enabled, the configured
+// registry mirror endpoints are probed, and ordered so that unhealthy endpoints are tried last. containerd reads
+// the hosts.toml files when resolving each image, so the endpoints are reordered without a restart as their
+// health changes.
+func setupRegistryHosts(ctx context.Context, cfg *config.Node, registry *registries.Registry) error {
+	if err := os.RemoveAll(cfg.Containerd.Registry); err != nil {
+		return errors.Wrap(err, "failed to remove registry hosts config")
+	}
+
+	var checker *mirrorhealth.Checker
+	endpoints := mirrorEndpoints(registry)
+	if cfg.AgentConfig.RegistryHealthInterval > 0 && len(endpoints) > 0 {
+		checker = mirrorhealth.New(cfg.AgentConfig.RegistryHealthInterval, cfg.AgentConfig.RegistryHealthTimeout, func(host string) (*tls.Config, error) {
+			return registryTLSConfig(registry, host)
+		})
+		checker.Probe(ctx, endpoints)
+	}
+
+	registryHosts.Lock()
+	registryHosts.cfg = cfg
+	registryHosts.registry = registry
+	registryHosts.checker = checker
+	err := writeHostsConfig(cfg, registry, checker)
+	registryHosts.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if checker != nil {
+		logrus.Infof("Probing %d registry mirror endpoints every %s", len(endpoints), checker.Interval)
+		go checker.Run(ctx, endpoints, func() {
+			if err := updateRegistryHosts(); err != nil {
+				logrus.Errorf("Failed to update registry hosts config: %v", err)
+			}
+		})
+	}
+	return nil
+}
+
+// updateRegistryHosts writes the hosts.toml files again, with the endpoints in their current order.
+func updateRegistryHosts() error {
+	registryHosts.Lock()
+	defer registryHosts.Unlock()
+	if registryHosts.cfg == nil {
+		return errors.New("registry hosts config is not in use")
+	}
+	return writeHostsConfig(registryHosts.cfg, registryHosts.registry, registryHosts.checker)
+}
+
+// mirrorEndpoints returns the unique endpoints of all configured mirrors.
+func mirrorEndpoints(registry *registries.Registry) []string {
+	seen := map[string]bool{}
+	endpoints := []string{}
+	for _, mirror := range registry.Mirrors {
+		for _, endpoint := range mirror.Endpoints {
+			if !seen[endpoint] {
+				seen[endpoint] = true
+				endpoints = append(endpoints, endpoint)
+			}
+		}
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
+
+// registryTLSConfig returns the TLS config used by containerd to connect to the registry host, with any
+// configured CA, client certificate and key loaded.
+func registryTLSConfig(registry *registries.Registry, host string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	registryConfig, ok := registry.Configs[host]
+	if !ok || registryConfig.TLS == nil {
+		return tlsConfig, nil
+	}
+	if registryConfig.TLS.CertFile != "" || registryConfig.TLS.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(registryConfig.TLS.CertFile, registryConfig.TLS.KeyFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load client certificate for %s", host)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if registryConfig.TLS.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get system cert pool")
+		}
+		ca, err := os.ReadFile(registryConfig.TLS.CAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load CA file for %s", host)
+		}
+		pool.AppendCertsFromPEM(ca)
+		tlsConfig.RootCAs = pool
+	}
+	tlsConfig.InsecureSkipVerify = registryConfig.TLS.InsecureSkipVerify
+	return tlsConfig, nil
+}
+
+// writeHostsConfig writes a hosts.toml file for each registry that has mirrors or TLS settings configured. When
+// the hosts config directory is used, containerd ignores the TLS settings in the CRI plugin config, so registries
+// that have TLS settings but no mirrors must also have a hosts.toml file. The files of registries that are no
+// longer configured are removed.
+func writeHostsConfig(cfg *config.Node, registry *registries.Registry, checker *mirrorhealth.Checker) error {
+	names := map[string]bool{}
+	for name := range registry.Mirrors {
+		names[name] = true
+	}
+	for name, registryConfig := range registry.Configs {
+		if registryConfig.TLS != nil {
+			names[name] = true
+		}
+	}
+
+	dirs := map[string]bool{}
+	for name := range names {
+		hosts := templates.HostsConfig{Program: version.Program}
+		dir := name
+		switch name {
+		case "*":
+			dir = "_default"
+		case "docker.io":
+			hosts.Server = "https://registry-1.docker.io"
+		default:
+			hosts.Server = "https://" + name
+		}
+		dirs[dir] = true
+		if registryConfig, ok := registry.Configs[name]; ok {
+			hosts.ServerTLS = registryConfig.TLS
+		}
+
+		mirror := registry.Mirrors[name]
+		endpoints := mirror.Endpoints
+		if checker != nil {
+			endpoints = checker.Order(endpoints)
+		}
+		for _, endpoint := range endpoints {
+			host := templates.HostConfig{URL: endpoint, Rewrites: mirror.Rewrites}
+			if u, err := url.Parse(endpoint); err == nil {
+				if registryConfig, ok := registry.Configs[u.Host]; ok {
+					host.TLS = registryConfig.TLS
+				}
+			}
+			hosts.Hosts = append(hosts.Hosts, host)
+		}
+
+		hostsToml, err := templates.ParseTemplateFromConfig(templates.HostsTomlTemplate, hosts)
+		if err != nil {
+			return err
+		}
+		if err := util2.WriteFile(filepath.Join(cfg.Containerd.Registry, dir, "hosts.toml"), hostsToml); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(cfg.Containerd.Registry)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, entry := range entries {
+		if !dirs[entry.Name()] {
+			if err := os.RemoveAll(filepath.Join(cfg.Containerd.Registry, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReloadRegistries reads the private registry configuration file again, and writes the hosts.toml files with
+// the new configuration, so that changes to registry mirrors take effect without restarting containerd. The
+// mirror health checker continues to probe the endpoints that were configured at startup. If the hosts config
+// directory is not in use, the registry configuration is part of the containerd config, and containerd must be
+// restarted to apply changes.
+func ReloadRegistries(cfg *config.Node) error {
+	privRegistries, err := registries.GetPrivateRegistries(cfg.AgentConfig.PrivateRegistry)
+	if err != nil {
+		return err
+	}
+	registryHosts.Lock()
+	defer registryHosts.Unlock()
+	if registryHosts.cfg == nil {
+		logrus.Infof("Registry hosts config is not in use; %s must be restarted to apply changes to %s", version.Program, cfg.AgentConfig.PrivateRegistry)
+		return nil
+	}
+	registryHosts.registry = privRegistries.Registry
+	if err := writeHostsConfig(registryHosts.cfg, registryHosts.registry, registryHosts.checker); err != nil {
+		return err
+	}
+	logrus.Infof("Reloaded registry configuration from %s", cfg.AgentConfig.PrivateRegistry)
+	return nil
+}
@@ -0,0 +1,44 @@
+package containerd
+
+import (
+	"context"
+	"net"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/images"
+	"github.com/k3s-io/k3s/pkg/agent/registry"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/sirupsen/logrus"
+)
+
+// startLocalRegistry serves a writable registry backed by the containerd content and image stores. Images pushed to
+// it are unpacked into the configured snapshotter, so that pods can use them without pulling.
+func startLocalRegistry(ctx context.Context, cfg *config.Node) error {
+	client, err := Client(cfg.Containerd.Address)
+	if err != nil {
+		return err
+	}
+	unpack := func(ctx context.Context, image images.Image) error {
+		return containerd.NewImage(client, image).Unpack(ctx, cfg.AgentConfig.Snapshotter)
+	}
+	r, err := registry.New(client.ContentStore(), client.ImageService(), client.LeasesService(), unpack, cfg.AgentConfig.LocalRegistryName, cfg.AgentConfig.LocalRegistryAuth)
+	if err != nil {
+		client.Close()
+		return err
+	}
+
+	logrus.Infof("Storing images pushed to the local registry as %s", cfg.AgentConfig.LocalRegistryName)
+	if host, _, err := net.SplitHostPort(cfg.AgentConfig.LocalRegistryListen); err == nil {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+			logrus.Warnf("Local registry is listening on %s without TLS; credentials and images are sent in cleartext", cfg.AgentConfig.LocalRegistryListen)
+		}
+	}
+
+	go func() {
+		defer client.Close()
+		if err := r.ListenAndServe(ctx, cfg.AgentConfig.LocalRegistryListen); err != nil {
+			logrus.Errorf("Local registry failed: %v", err)
+		}
+	}()
+	return nil
+}
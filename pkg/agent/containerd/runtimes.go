@@ -0,0 +1,101 @@
+//go:build linux
+// +build linux
+
+package containerd
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/k3s-io/k3s/pkg/agent/templates"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultRuntimeSearchPaths are the directories searched for additional container runtimes if no search paths
+// are configured. Kata Containers release tarballs are installed to /opt/kata.
+var defaultRuntimeSearchPaths = []string{"/usr/local/bin", "/usr/bin", "/opt/kata/bin"}
+
+// runtimeDefinition describes an additional container runtime, and the binary that indicates it is installed.
+type runtimeDefinition struct {
+	binary      string
+	runtimeType string
+	// shim is true if the binary is a containerd shim, rather than an OCI runtime used by the runc shim.
+	shim bool
+}
+
+// potentialRuntimes are the additional container runtimes that are added to the containerd config if found.
+// The names are used as the runtime handler names, and match the RuntimeClasses deployed by the server.
+var potentialRuntimes = map[string]runtimeDefinition{
+	"kata": {
+		binary:      "containerd-shim-kata-v2",
+		runtimeType: "io.containerd.kata.v2",
+		shim:        true,
+	},
+	"runsc": {
+		binary:      "containerd-shim-runsc-v1",
+		runtimeType: "io.containerd.runsc.v1",
+		shim:        true,
+	},
+	"firecracker": {
+		binary:      "containerd-shim-aws-firecracker",
+		runtimeType: "aws.firecracker",
+		shim:        true,
+	},
+	"crun": {
+		binary:      "crun",
+		runtimeType: "io.containerd.runc.v2",
+	},
+}
+
+// findContainerRuntimes returns the nvidia container runtimes, and the other additional container runtimes
+// found in the search paths. If a runtime is found in more than one search path, the first takes precedence.
+// The given fs.FS should represent the filesystem root directory to search in.
+func findContainerRuntimes(root fs.FS, searchPaths []string) map[string]templates.ContainerdRuntimeConfig {
+	if len(searchPaths) == 0 {
+		searchPaths = defaultRuntimeSearchPaths
+	}
+
+	foundRuntimes := findNvidiaContainerRuntimes(root)
+RUNTIME:
+	for runtimeName, definition := range potentialRuntimes {
+		for _, searchPath := range searchPaths {
+			binaryPath := filepath.Join(strings.TrimPrefix(filepath.Clean(searchPath), "/"), definition.binary)
+			logrus.Debugf("Searching for %s container runtime at /%s", runtimeName, binaryPath)
+			info, err := fs.Stat(root, binaryPath)
+			if err != nil {
+				if !errors.Is(err, fs.ErrNotExist) {
+					logrus.Errorf("Error searching for %s container runtime at /%s: %v", runtimeName, binaryPath, err)
+				}
+				continue
+			}
+			if info.IsDir() {
+				logrus.Debugf("Found %s container runtime at /%s, but it is a directory. Skipping.", runtimeName, binaryPath)
+				continue
+			}
+
+			runtimeConfig := templates.ContainerdRuntimeConfig{RuntimeType: definition.runtimeType}
+			if definition.shim {
+				runtimeConfig.RuntimePath = filepath.Join("/", binaryPath)
+			} else {
+				runtimeConfig.BinaryName = filepath.Join("/", binaryPath)
+			}
+			logrus.Infof("Found %s container runtime at /%s", runtimeName, binaryPath)
+			foundRuntimes[runtimeName] = runtimeConfig
+			continue RUNTIME
+		}
+	}
+	return foundRuntimes
+}
+
+// runtimeNames returns the sorted names of the runtimes.
+func runtimeNames(runtimes map[string]templates.ContainerdRuntimeConfig) []string {
+	names := make([]string, 0, len(runtimes))
+	for name := range runtimes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
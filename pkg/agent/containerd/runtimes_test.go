@@ -0,0 +1,100 @@
+//go:build linux
+// +build linux
+
+package containerd
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/k3s-io/k3s/pkg/agent/templates"
+)
+
+func Test_UnitFindContainerRuntimes(t *testing.T) {
+	executable := &fstest.MapFile{Mode: 0755}
+	type args struct {
+		root        fs.FS
+		searchPaths []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want map[string]templates.ContainerdRuntimeConfig
+	}{
+		{
+			name: "No runtimes",
+			args: args{
+				root: fstest.MapFS{},
+			},
+			want: map[string]templates.ContainerdRuntimeConfig{},
+		},
+		{
+			name: "Shims and crun in default search paths",
+			args: args{
+				root: fstest.MapFS{
+					"opt/kata/bin/containerd-shim-kata-v2":   executable,
+					"usr/local/bin/containerd-shim-runsc-v1": executable,
+					"usr/bin/crun":                           executable,
+					"usr/bin/nvidia-container-runtime":       executable,
+				},
+			},
+			want: map[string]templates.ContainerdRuntimeConfig{
+				"kata": {
+					RuntimeType: "io.containerd.kata.v2",
+					RuntimePath: "/opt/kata/bin/containerd-shim-kata-v2",
+				},
+				"runsc": {
+					RuntimeType: "io.containerd.runsc.v1",
+					RuntimePath: "/usr/local/bin/containerd-shim-runsc-v1",
+				},
+				"crun": {
+					RuntimeType: "io.containerd.runc.v2",
+					BinaryName:  "/usr/bin/crun",
+				},
+				"nvidia": {
+					RuntimeType: "io.containerd.runc.v2",
+					BinaryName:  "/usr/bin/nvidia-container-runtime",
+				},
+			},
+		},
+		{
+			name: "Configured search paths take precedence in order",
+			args: args{
+				root: fstest.MapFS{
+					"srv/runtimes/containerd-shim-aws-firecracker":  executable,
+					"usr/local/bin/containerd-shim-aws-firecracker": executable,
+					"usr/local/bin/crun":                            executable,
+				},
+				searchPaths: []string{"/srv/runtimes/", "/usr/local/bin"},
+			},
+			want: map[string]templates.ContainerdRuntimeConfig{
+				"firecracker": {
+					RuntimeType: "aws.firecracker",
+					RuntimePath: "/srv/runtimes/containerd-shim-aws-firecracker",
+				},
+				"crun": {
+					RuntimeType: "io.containerd.runc.v2",
+					BinaryName:  "/usr/local/bin/crun",
+				},
+			},
+		},
+		{
+			name: "Directory with runtime name",
+			args: args{
+				root: fstest.MapFS{
+					"usr/bin/crun": &fstest.MapFile{Mode: fs.ModeDir},
+				},
+			},
+			want: map[string]templates.ContainerdRuntimeConfig{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findContainerRuntimes(tt.args.root, tt.args.searchPaths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findContainerRuntimes() = %+v\nWant = %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package drain
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DrainedAnnotation is set on a node that was cordoned when the agent shut down, so that it can be
+// uncordoned when the agent starts again. Nodes that were cordoned by an administrator are left as they are.
+var DrainedAnnotation = version.Program + ".io/shutdown-drained"
+
+// pollInterval is the interval at which evictions are retried, and evicted pods are checked for.
+var pollInterval = 2 * time.Second
+
+// Drainer cordons and drains the node when the agent shuts down, so that its pods are rescheduled to
+// other nodes rather than being killed along with the agent.
+type Drainer struct {
+	// Timeout is the maximum time to wait for pods to be evicted.
+	Timeout time.Duration
+	// GracePeriodSeconds is the termination grace period of evicted pods, or -1 to use the grace period
+	// set on each pod.
+	GracePeriodSeconds int
+
+	mu       sync.Mutex
+	client   kubernetes.Interface
+	nodeName string
+}
+
+// New creates a drainer with the given eviction timeout and pod grace period.
+func New(timeout time.Duration, gracePeriodSeconds int) *Drainer {
+	return &Drainer{
+		Timeout:            timeout,
+		GracePeriodSeconds: gracePeriodSeconds,
+	}
+}
+
+// Context returns a context that is cancelled once the parent context is done, and the node has been
+// drained. The agent's components should be run with this context, so that they continue to run while
+// the node is drained.
+func (d *Drainer) Context(parent context.Context) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer cancel()
+		<-parent.Done()
+		if err := d.Drain(ctx); err != nil {
+			logrus.Errorf("Failed to drain node on shutdown: %v", err)
+		}
+	}()
+	return ctx
+}
+
+// Register sets the client and node name used to drain the node. Until this is called, the node is not
+// drained on shutdown.
+func (d *Drainer) Register(client kubernetes.Interface, nodeName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.client = client
+	d.nodeName = nodeName
+}
+
+// Drain cordons the node and evicts its pods, waiting up to the timeout for the pods to terminate.
+// DaemonSet pods and static pods are not evicted, as they would not be rescheduled elsewhere.
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.mu.Lock()
+	client, nodeName := d.client, d.nodeName
+	d.mu.Unlock()
+	if client == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout)
+	defer cancel()
+
+	logrus.Infof("Draining node %s before shutdown", nodeName)
+	if err := cordon(ctx, client, nodeName); err != nil {
+		return errors.Wrapf(err, "failed to cordon node %s", nodeName)
+	}
+	pods, err := drainablePods(ctx, client, nodeName)
+	if err != nil {
+		return err
+	}
+	remaining := map[types.UID]corev1.Pod{}
+	for _, pod := range pods {
+		remaining[pod.UID] = pod
+	}
+	evicted := map[types.UID]bool{}
+
+	err = wait.PollImmediateUntilWithContext(ctx, pollInterval, func(ctx context.Context) (bool, error) {
+		for uid, pod := range remaining {
+			if evicted[uid] {
+				continue
+			}
+			if err := d.evict(ctx, client, pod); err != nil {
+				logrus.Infof("Waiting to evict pod %s/%s: %v", pod.Namespace, pod.Name, err)
+				continue
+			}
+			evicted[uid] = true
+		}
+		current, err := drainablePods(ctx, client, nodeName)
+		if err != nil {
+			logrus.Warnf("Failed to list pods on node %s: %v", nodeName, err)
+			return false, nil
+		}
+		running := map[types.UID]bool{}
+		for _, pod := range current {
+			running[pod.UID] = true
+		}
+		for uid := range remaining {
+			if !running[uid] {
+				delete(remaining, uid)
+			}
+		}
+		return len(remaining) == 0, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "timed out waiting for %d pods to be evicted from node %s", len(remaining), nodeName)
+	}
+	logrus.Infof("Drained %d pods from node %s", len(pods), nodeName)
+	return nil
+}
+
+func (d *Drainer) evict(ctx context.Context, client kubernetes.Interface, pod corev1.Pod) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
+	if d.GracePeriodSeconds >= 0 {
+		gracePeriod := int64(d.GracePeriodSeconds)
+		eviction.DeleteOptions = &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod}
+	}
+	err := client.CoreV1().Pods(pod.Namespace).EvictV1(ctx, eviction)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// drainablePods returns the pods on the node that should be evicted.
+func drainablePods(ctx context.Context, client kubernetes.Interface, nodeName string) ([]corev1.Pod, error) {
+	podList, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	pods := []corev1.Pod{}
+	for _, pod := range podList.Items {
+		if pod.Spec.NodeName != nodeName || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+			continue
+		}
+		if controller := metav1.GetControllerOf(&pod); controller != nil && controller.Kind == "DaemonSet" {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// cordon marks the node unschedulable, unless it already is.
+func cordon(ctx context.Context, client kubernetes.Interface, nodeName string) error {
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if node.Spec.Unschedulable {
+		return nil
+	}
+	return patchNode(ctx, client, nodeName, true, "true")
+}
+
+// Uncordon marks the node schedulable, if it was cordoned when the agent last shut down.
+func Uncordon(ctx context.Context, client kubernetes.Interface, nodeName string) error {
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if _, ok := node.Annotations[DrainedAnnotation]; !ok {
+		return nil
+	}
+	logrus.Infof("Uncordoning node %s after it was drained on shutdown", nodeName)
+	return patchNode(ctx, client, nodeName, false, nil)
+}
+
+// patchNode sets the node's unschedulable field and drained annotation. A nil annotation value removes
+// the annotation.
+func patchNode(ctx context.Context, client kubernetes.Interface, nodeName string, unschedulable bool, annotation interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{DrainedAnnotation: annotation},
+		},
+		"spec": map[string]interface{}{
+			"unschedulable": unschedulable,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.CoreV1().Nodes().Patch(ctx, nodeName, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,137 @@
+package drain
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testPod(name, nodeName string, mutate func(*corev1.Pod)) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+			UID:       types.UID("uid-" + name),
+		},
+		Spec:   corev1.PodSpec{NodeName: nodeName},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if mutate != nil {
+		mutate(pod)
+	}
+	return pod
+}
+
+func Test_UnitDrainerDrain(t *testing.T) {
+	isController := true
+	tests := []struct {
+		name          string
+		unschedulable bool
+		pods          []runtime.Object
+		wantEvicted   []string
+		wantDrained   bool
+	}{
+		{
+			name: "evicts pods on the node",
+			pods: []runtime.Object{
+				testPod("web", "node1", nil),
+				testPod("worker", "node1", nil),
+				testPod("other", "node2", nil),
+			},
+			wantEvicted: []string{"web", "worker"},
+			wantDrained: true,
+		},
+		{
+			name: "skips daemonset, static and completed pods",
+			pods: []runtime.Object{
+				testPod("web", "node1", nil),
+				testPod("ds", "node1", func(pod *corev1.Pod) {
+					pod.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ds", Controller: &isController}}
+				}),
+				testPod("static", "node1", func(pod *corev1.Pod) {
+					pod.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "hash"}
+				}),
+				testPod("job", "node1", func(pod *corev1.Pod) {
+					pod.Status.Phase = corev1.PodSucceeded
+				}),
+			},
+			wantEvicted: []string{"web"},
+			wantDrained: true,
+		},
+		{
+			name:          "node already cordoned",
+			unschedulable: true,
+			pods:          []runtime.Object{testPod("web", "node1", nil)},
+			wantEvicted:   []string{"web"},
+			wantDrained:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+				Spec:       corev1.NodeSpec{Unschedulable: tt.unschedulable},
+			}
+			client := fake.NewSimpleClientset(append(tt.pods, node)...)
+			evicted := []string{}
+			client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "eviction" {
+					return false, nil, nil
+				}
+				eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction)
+				evicted = append(evicted, eviction.Name)
+				return true, nil, client.Tracker().Delete(corev1.SchemeGroupVersion.WithResource("pods"), eviction.Namespace, eviction.Name)
+			})
+
+			pollInterval = 10 * time.Millisecond
+			ctx := context.Background()
+			d := New(5*time.Second, -1)
+			d.Register(client, "node1")
+			if err := d.Drain(ctx); err != nil {
+				t.Fatalf("Drain() error = %v", err)
+			}
+
+			sort.Strings(evicted)
+			if len(evicted) != len(tt.wantEvicted) {
+				t.Fatalf("evicted = %v, want %v", evicted, tt.wantEvicted)
+			}
+			for i := range evicted {
+				if evicted[i] != tt.wantEvicted[i] {
+					t.Errorf("evicted = %v, want %v", evicted, tt.wantEvicted)
+				}
+			}
+
+			node, err := client.CoreV1().Nodes().Get(ctx, "node1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get node: %v", err)
+			}
+			if !node.Spec.Unschedulable {
+				t.Errorf("node is not cordoned")
+			}
+			if _, drained := node.Annotations[DrainedAnnotation]; drained != tt.wantDrained {
+				t.Errorf("node drained annotation = %v, want %v", drained, tt.wantDrained)
+			}
+
+			// The node is uncordoned when the agent starts again, only if it was cordoned by the drainer.
+			if err := Uncordon(ctx, client, "node1"); err != nil {
+				t.Fatalf("Uncordon() error = %v", err)
+			}
+			node, err = client.CoreV1().Nodes().Get(ctx, "node1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get node: %v", err)
+			}
+			if node.Spec.Unschedulable != tt.unschedulable {
+				t.Errorf("node unschedulable after restart = %v, want %v", node.Spec.Unschedulable, tt.unschedulable)
+			}
+		})
+	}
+}
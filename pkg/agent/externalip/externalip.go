@@ -0,0 +1,138 @@
+package externalip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// MethodMetadata discovers the address from the instance metadata service of the cloud provider.
+	MethodMetadata = "metadata"
+	// MethodSTUN discovers the external IP by sending a binding request to a STUN server.
+	MethodSTUN = "stun"
+	// MethodInterface discovers the address of the first network interface with a name matching a glob, for
+	// example interface:eth*
+	MethodInterface = "interface"
+	// MethodScript discovers the address by running a script, which must print the address on the first line
+	// of its output, for example script:/usr/local/bin/node-ip
+	MethodScript = "script"
+
+	// DefaultSTUNServer is the STUN server used if none is configured.
+	DefaultSTUNServer = "stun.l.google.com:19302"
+
+	// watchInterval is the interval at which the external IP is rediscovered to detect changes.
+	watchInterval = 5 * time.Minute
+)
+
+// AddressType is the type of node address to discover.
+type AddressType string
+
+const (
+	InternalIP AddressType = "InternalIP"
+	ExternalIP AddressType = "ExternalIP"
+)
+
+// parseMethod splits a discovery method into its name and argument.
+func parseMethod(method string) (string, string) {
+	name, arg, _ := strings.Cut(method, ":")
+	return name, arg
+}
+
+// Validate returns an error if any of the discovery methods are not supported for the address type.
+func Validate(methods []string, addressType AddressType) error {
+	for _, method := range methods {
+		name, arg := parseMethod(method)
+		switch name {
+		case MethodMetadata:
+			continue
+		case MethodSTUN:
+			if addressType == ExternalIP {
+				continue
+			}
+		case MethodInterface:
+			if arg == "" {
+				return fmt.Errorf("%s discovery method must be specified as %s:<glob>", name, name)
+			}
+			if _, err := filepath.Match(arg, ""); err != nil {
+				return fmt.Errorf("invalid interface glob %q", arg)
+			}
+			continue
+		case MethodScript:
+			if arg == "" {
+				return fmt.Errorf("%s discovery method must be specified as %s:<path>", name, name)
+			}
+			continue
+		}
+		if addressType == ExternalIP {
+			return fmt.Errorf("unsupported node-external-ip-discovery method %q: must be one of %s, %s, %s:<glob>, %s:<path>", method, MethodMetadata, MethodSTUN, MethodInterface, MethodScript)
+		}
+		return fmt.Errorf("unsupported node-ip-discovery method %q: must be one of %s, %s:<glob>, %s:<path>", method, MethodMetadata, MethodInterface, MethodScript)
+	}
+	return nil
+}
+
+// Discover returns the external IP of this node, using the discovery methods in order until one succeeds.
+func Discover(ctx context.Context, methods []string, stunServer string) (net.IP, error) {
+	return discover(ctx, methods, stunServer, ExternalIP)
+}
+
+// DiscoverInternal returns the internal IP of this node, using the discovery methods in order until one succeeds.
+func DiscoverInternal(ctx context.Context, methods []string) (net.IP, error) {
+	return discover(ctx, methods, "", InternalIP)
+}
+
+func discover(ctx context.Context, methods []string, stunServer string, addressType AddressType) (net.IP, error) {
+	var errs []string
+	for _, method := range methods {
+		var ip net.IP
+		var err error
+		name, arg := parseMethod(method)
+		switch {
+		case name == MethodMetadata:
+			ip, err = discoverMetadata(ctx, addressType)
+		case name == MethodSTUN && addressType == ExternalIP:
+			ip, err = discoverSTUN(ctx, stunServer)
+		case name == MethodInterface:
+			ip, err = discoverInterface(arg)
+		case name == MethodScript:
+			ip, err = discoverScript(ctx, arg, addressType)
+		default:
+			err = errors.New("unsupported method")
+		}
+		if err == nil {
+			logrus.Debugf("Discovered %s %s using %s", addressType, ip, method)
+			return ip, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", method, err))
+	}
+	return nil, fmt.Errorf("failed to discover %s: %s", addressType, strings.Join(errs, "; "))
+}
+
+// Watch periodically rediscovers the external IP of this node, and calls the change function with the new address
+// when it differs from the current address. Discovery failures are logged, and the current address is retained.
+func Watch(ctx context.Context, methods []string, stunServer string, current net.IP, changed func(net.IP) error) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		ip, err := Discover(ctx, methods, stunServer)
+		if err != nil {
+			logrus.Warnf("Failed to rediscover node external IP: %v", err)
+			return
+		}
+		if ip.Equal(current) {
+			return
+		}
+		logrus.Infof("Node external IP changed from %s to %s", current, ip)
+		if err := changed(ip); err != nil {
+			logrus.Warnf("Failed to update node external IP: %v", err)
+			return
+		}
+		current = ip
+	}, watchInterval)
+}
@@ -0,0 +1,119 @@
+package externalip
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_UnitValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		methods     []string
+		addressType AddressType
+		wantErr     bool
+	}{
+		{name: "external methods", methods: []string{"metadata", "stun", "interface:eth*", "script:/usr/local/bin/node-ip"}, addressType: ExternalIP},
+		{name: "internal methods", methods: []string{"metadata", "interface:ens[0-9]", "script:/usr/local/bin/node-ip"}, addressType: InternalIP},
+		{name: "stun for internal IP", methods: []string{"stun"}, addressType: InternalIP, wantErr: true},
+		{name: "interface without glob", methods: []string{"interface"}, addressType: InternalIP, wantErr: true},
+		{name: "invalid glob", methods: []string{"interface:eth[0"}, addressType: ExternalIP, wantErr: true},
+		{name: "script without path", methods: []string{"script:"}, addressType: ExternalIP, wantErr: true},
+		{name: "unknown method", methods: []string{"dns"}, addressType: ExternalIP, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.methods, tt.addressType); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_UnitMatchInterface(t *testing.T) {
+	ifaces := []net.Interface{
+		{Index: 1, Name: "lo", Flags: net.FlagUp | net.FlagLoopback},
+		{Index: 2, Name: "eth0", Flags: 0},
+		{Index: 3, Name: "eth1", Flags: net.FlagUp},
+		{Index: 4, Name: "wg0", Flags: net.FlagUp},
+	}
+	tests := []struct {
+		glob     string
+		wantName string
+	}{
+		{glob: "eth*", wantName: "eth1"},
+		{glob: "wg?", wantName: "wg0"},
+		{glob: "ens*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob, func(t *testing.T) {
+			iface, err := matchInterface(ifaces, tt.glob)
+			if tt.wantName == "" {
+				if err == nil {
+					t.Errorf("matchInterface() = %s, want error", iface.Name)
+				}
+				return
+			}
+			if err != nil || iface.Name != tt.wantName {
+				t.Errorf("matchInterface() = %v, %v, want %s", iface, err, tt.wantName)
+			}
+		})
+	}
+}
+
+func Test_UnitSelectAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		ips  []string
+		want string
+	}{
+		{name: "prefers IPv4", ips: []string{"fe80::1", "2001:db8::10", "10.0.0.5"}, want: "10.0.0.5"},
+		{name: "global IPv6", ips: []string{"fe80::1", "2001:db8::10"}, want: "2001:db8::10"},
+		{name: "no global address", ips: []string{"127.0.0.1", "fe80::1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ips []net.IP
+			for _, ip := range tt.ips {
+				ips = append(ips, net.ParseIP(ip))
+			}
+			got := selectAddress(ips)
+			if (tt.want == "" && got != nil) || (tt.want != "" && !got.Equal(net.ParseIP(tt.want))) {
+				t.Errorf("selectAddress() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitDiscoverScript(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "node-ip")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nif [ \"$ADDRESS_TYPE\" = ExternalIP ]; then echo 203.0.113.7; else echo 10.0.0.5; fi\necho ignored\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid")
+	if err := os.WriteFile(invalid, []byte("#!/bin/sh\necho not-an-address\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	ip, err := discoverScript(context.Background(), script, ExternalIP)
+	if err != nil || !ip.Equal(net.ParseIP("203.0.113.7")) {
+		t.Errorf("discoverScript() = %v, %v, want 203.0.113.7", ip, err)
+	}
+	ip, err = discoverScript(context.Background(), script, InternalIP)
+	if err != nil || !ip.Equal(net.ParseIP("10.0.0.5")) {
+		t.Errorf("discoverScript() = %v, %v, want 10.0.0.5", ip, err)
+	}
+	if ip, err := discoverScript(context.Background(), invalid, InternalIP); err == nil {
+		t.Errorf("discoverScript() = %v, want error", ip)
+	}
+}
+
+func Test_UnitParseHetznerPrivateNetworks(t *testing.T) {
+	body := "- ip: 10.0.0.2\n  alias_ips: []\n  interface_num: 1\n  mac_address: 86:00:00:2a:7d:e0\n  network_id: 1234\n"
+	if got := parseHetznerPrivateNetworks(body); got != "10.0.0.2" {
+		t.Errorf("parseHetznerPrivateNetworks() = %q, want 10.0.0.2", got)
+	}
+}
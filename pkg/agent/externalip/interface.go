@@ -0,0 +1,98 @@
+package externalip
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const scriptTimeout = 10 * time.Second
+
+// discoverInterface returns the first global unicast address of the first network interface, in index order,
+// whose name matches the glob. IPv4 addresses are preferred over IPv6 addresses.
+func discoverInterface(glob string) (net.IP, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	iface, err := matchInterface(ifaces, glob)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			ips = append(ips, ipNet.IP)
+		}
+	}
+	if ip := selectAddress(ips); ip != nil {
+		return ip, nil
+	}
+	return nil, fmt.Errorf("interface %s has no global unicast address", iface.Name)
+}
+
+// matchInterface returns the first interface that is up and whose name matches the glob.
+func matchInterface(ifaces []net.Interface, glob string) (*net.Interface, error) {
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagUp == 0 {
+			continue
+		}
+		if ok, _ := filepath.Match(glob, ifaces[i].Name); ok {
+			return &ifaces[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no interface that is up matches %q", glob)
+}
+
+// selectAddress returns the first global unicast IPv4 address, or the first global unicast IPv6 address if there
+// are no IPv4 addresses.
+func selectAddress(ips []net.IP) net.IP {
+	var ipv6 net.IP
+	for _, ip := range ips {
+		if !ip.IsGlobalUnicast() {
+			continue
+		}
+		if ip.To4() != nil {
+			return ip
+		}
+		if ipv6 == nil {
+			ipv6 = ip
+		}
+	}
+	return ipv6
+}
+
+// discoverScript runs a script to discover the address. The address type is passed to the script in the
+// ADDRESS_TYPE environment variable, and the address is read from the first line of its output.
+func discoverScript(ctx context.Context, path string, addressType AddressType) (net.IP, error) {
+	ctx, cancel := context.WithTimeout(ctx, scriptTimeout)
+	defer cancel()
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Env = append(os.Environ(), "ADDRESS_TYPE="+string(addressType))
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s failed: %s", path, strings.TrimSpace(stderr.String()))
+	}
+	line, _, _ := bufio.NewReader(bytes.NewReader(out)).ReadLine()
+	ip := net.ParseIP(strings.TrimSpace(string(line)))
+	if ip == nil {
+		return nil, fmt.Errorf("%s returned invalid address %q", path, line)
+	}
+	return ip, nil
+}
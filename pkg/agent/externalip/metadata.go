@@ -0,0 +1,148 @@
+package externalip
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const metadataTimeout = 2 * time.Second
+
+// metadataProvider describes how to retrieve the public and private IPv4 addresses of an instance from a cloud
+// provider's metadata service.
+type metadataProvider struct {
+	name       string
+	url        string
+	privateURL string
+	headers    map[string]string
+	// parsePrivate, if set, extracts the private address from the response to the private address request.
+	parsePrivate func(string) string
+	// tokenURL, if set, is used to retrieve a session token that is sent in the tokenHeader on the address request.
+	tokenURL       string
+	tokenTTLHeader string
+	tokenHeader    string
+}
+
+var metadataProviders = []metadataProvider{
+	{
+		name:           "aws",
+		url:            "http://169.254.169.254/latest/meta-data/public-ipv4",
+		privateURL:     "http://169.254.169.254/latest/meta-data/local-ipv4",
+		tokenURL:       "http://169.254.169.254/latest/api/token",
+		tokenTTLHeader: "X-aws-ec2-metadata-token-ttl-seconds",
+		tokenHeader:    "X-aws-ec2-metadata-token",
+	},
+	{
+		name:       "gce",
+		url:        "http://169.254.169.254/computeMetadata/v1/instance/network-interfaces/0/access-configs/0/external-ip",
+		privateURL: "http://169.254.169.254/computeMetadata/v1/instance/network-interfaces/0/ip",
+		headers:    map[string]string{"Metadata-Flavor": "Google"},
+	},
+	{
+		name:       "azure",
+		url:        "http://169.254.169.254/metadata/instance/network/interface/0/ipv4/ipAddress/0/publicIpAddress?api-version=2021-02-01&format=text",
+		privateURL: "http://169.254.169.254/metadata/instance/network/interface/0/ipv4/ipAddress/0/privateIpAddress?api-version=2021-02-01&format=text",
+		headers:    map[string]string{"Metadata": "true"},
+	},
+	{
+		name:       "digitalocean",
+		url:        "http://169.254.169.254/metadata/v1/interfaces/public/0/ipv4/address",
+		privateURL: "http://169.254.169.254/metadata/v1/interfaces/private/0/ipv4/address",
+	},
+	{
+		name:         "hetzner",
+		url:          "http://169.254.169.254/hetzner/v1/metadata/public-ipv4",
+		privateURL:   "http://169.254.169.254/hetzner/v1/metadata/private-networks",
+		parsePrivate: parseHetznerPrivateNetworks,
+	},
+}
+
+// discoverMetadata queries the metadata service of each supported cloud provider in turn, and returns the first
+// public or private address found.
+func discoverMetadata(ctx context.Context, addressType AddressType) (net.IP, error) {
+	client := &http.Client{
+		Timeout: metadataTimeout,
+		// Metadata requests must not be sent through a proxy.
+		Transport: &http.Transport{Proxy: nil},
+	}
+	for _, p := range metadataProviders {
+		if ip, err := p.get(ctx, client, addressType); err == nil {
+			return ip, nil
+		}
+	}
+	if addressType == InternalIP {
+		return nil, errors.New("no cloud provider metadata service returned a private address")
+	}
+	return nil, errors.New("no cloud provider metadata service returned a public address")
+}
+
+func (p *metadataProvider) get(ctx context.Context, client *http.Client, addressType AddressType) (net.IP, error) {
+	url := p.url
+	if addressType == InternalIP {
+		url = p.privateURL
+	}
+	headers := map[string]string{}
+	for k, v := range p.headers {
+		headers[k] = v
+	}
+	if p.tokenURL != "" {
+		token, err := request(ctx, client, http.MethodPut, p.tokenURL, map[string]string{p.tokenTTLHeader: "60"})
+		if err != nil {
+			return nil, err
+		}
+		headers[p.tokenHeader] = token
+	}
+	body, err := request(ctx, client, http.MethodGet, url, headers)
+	if err != nil {
+		return nil, err
+	}
+	if addressType == InternalIP && p.parsePrivate != nil {
+		body = p.parsePrivate(body)
+	}
+	ip := net.ParseIP(body)
+	if ip == nil {
+		return nil, fmt.Errorf("%s metadata service returned invalid address %q", p.name, body)
+	}
+	return ip, nil
+}
+
+func request(ctx context.Context, client *http.Client, method, url string, headers map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s %s: %s", method, url, resp.Status)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// parseHetznerPrivateNetworks returns the address of the first private network from the YAML list of private
+// networks returned by the Hetzner metadata service.
+func parseHetznerPrivateNetworks(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "- "))
+		if value, ok := strings.CutPrefix(line, "ip:"); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package externalip
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// STUN message constants, as defined in RFC 5389.
+const (
+	stunBindingRequest       = 0x0001
+	stunBindingSuccess       = 0x0101
+	stunMagicCookie          = 0x2112A442
+	stunHeaderSize           = 20
+	stunAttrMappedAddress    = 0x0001
+	stunAttrXORMappedAddress = 0x0020
+	stunFamilyIPv4           = 0x01
+	stunFamilyIPv6           = 0x02
+
+	stunTimeout = 3 * time.Second
+)
+
+// discoverSTUN sends a binding request to the STUN server, and returns the address that the request was received from.
+// This is the public address of the node, as seen from outside any NAT.
+func discoverSTUN(ctx context.Context, server string) (net.IP, error) {
+	if server == "" {
+		server = DefaultSTUNServer
+	}
+	ctx, cancel := context.WithTimeout(ctx, stunTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := make([]byte, stunHeaderSize)
+	binary.BigEndian.PutUint16(req[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint32(req[4:8], stunMagicCookie)
+	if _, err := rand.Read(req[8:20]); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	resp := make([]byte, 1500)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	return parseSTUNResponse(resp[:n], req[8:20])
+}
+
+// parseSTUNResponse returns the mapped address from a STUN binding success response with the given transaction ID.
+func parseSTUNResponse(resp, transactionID []byte) (net.IP, error) {
+	if len(resp) < stunHeaderSize {
+		return nil, errors.New("STUN response too short")
+	}
+	if binary.BigEndian.Uint16(resp[0:2]) != stunBindingSuccess {
+		return nil, errors.New("STUN response is not a binding success response")
+	}
+	if binary.BigEndian.Uint32(resp[4:8]) != stunMagicCookie || string(resp[8:20]) != string(transactionID) {
+		return nil, errors.New("STUN response does not match request")
+	}
+	length := int(binary.BigEndian.Uint16(resp[2:4]))
+	if len(resp) < stunHeaderSize+length {
+		return nil, errors.New("STUN response truncated")
+	}
+
+	var mapped net.IP
+	attrs := resp[stunHeaderSize : stunHeaderSize+length]
+	for len(attrs) >= 4 {
+		attrType := binary.BigEndian.Uint16(attrs[0:2])
+		attrLen := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if len(attrs) < 4+attrLen {
+			return nil, errors.New("STUN attribute truncated")
+		}
+		value := attrs[4 : 4+attrLen]
+		switch attrType {
+		case stunAttrXORMappedAddress:
+			// The XOR-MAPPED-ADDRESS attribute is preferred, as it is not modified by NAT devices that rewrite addresses in payloads.
+			if ip := parseSTUNAddress(value, resp[4:20]); ip != nil {
+				return ip, nil
+			}
+		case stunAttrMappedAddress:
+			if ip := parseSTUNAddress(value, nil); ip != nil {
+				mapped = ip
+			}
+		}
+		// Attributes are padded to a multiple of 4 bytes
+		attrLen = (attrLen + 3) &^ 3
+		if len(attrs) < 4+attrLen {
+			break
+		}
+		attrs = attrs[4+attrLen:]
+	}
+	if mapped == nil {
+		return nil, errors.New("STUN response does not contain a mapped address")
+	}
+	return mapped, nil
+}
+
+// parseSTUNAddress parses the value of a MAPPED-ADDRESS attribute, or a XOR-MAPPED-ADDRESS
+// attribute if the key containing the magic cookie and transaction ID is provided.
+func parseSTUNAddress(value, key []byte) net.IP {
+	if len(value) < 4 {
+		return nil
+	}
+	var size int
+	switch value[1] {
+	case stunFamilyIPv4:
+		size = net.IPv4len
+	case stunFamilyIPv6:
+		size = net.IPv6len
+	default:
+		return nil
+	}
+	if len(value) < 4+size {
+		return nil
+	}
+	ip := make(net.IP, size)
+	copy(ip, value[4:4+size])
+	if key != nil {
+		for i := range ip {
+			ip[i] ^= key[i]
+		}
+	}
+	return ip
+}
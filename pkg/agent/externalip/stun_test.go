@@ -0,0 +1,101 @@
+package externalip
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+func stunResponse(transactionID []byte, attrs ...[]byte) []byte {
+	resp := make([]byte, stunHeaderSize)
+	binary.BigEndian.PutUint16(resp[0:2], stunBindingSuccess)
+	binary.BigEndian.PutUint32(resp[4:8], stunMagicCookie)
+	copy(resp[8:20], transactionID)
+	for _, attr := range attrs {
+		resp = append(resp, attr...)
+	}
+	binary.BigEndian.PutUint16(resp[2:4], uint16(len(resp)-stunHeaderSize))
+	return resp
+}
+
+func stunAttr(attrType uint16, value []byte) []byte {
+	attr := make([]byte, 4, 4+len(value)+3)
+	binary.BigEndian.PutUint16(attr[0:2], attrType)
+	binary.BigEndian.PutUint16(attr[2:4], uint16(len(value)))
+	attr = append(attr, value...)
+	for len(attr)%4 != 0 {
+		attr = append(attr, 0)
+	}
+	return attr
+}
+
+func Test_UnitParseSTUNResponse(t *testing.T) {
+	transactionID := []byte("0123456789ab")
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint32(key[0:4], stunMagicCookie)
+	copy(key[4:], transactionID)
+
+	mappedIPv4 := []byte{0, stunFamilyIPv4, 0, 0, 198, 51, 100, 1}
+	xorIPv4 := []byte{0, stunFamilyIPv4, 0, 0}
+	for i, b := range net.ParseIP("203.0.113.7").To4() {
+		xorIPv4 = append(xorIPv4, b^key[i])
+	}
+	xorIPv6 := []byte{0, stunFamilyIPv6, 0, 0}
+	for i, b := range net.ParseIP("2001:db8::1") {
+		xorIPv6 = append(xorIPv6, b^key[i])
+	}
+
+	tests := []struct {
+		name    string
+		resp    []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "XOR-MAPPED-ADDRESS IPv4",
+			resp: stunResponse(transactionID, stunAttr(stunAttrXORMappedAddress, xorIPv4)),
+			want: "203.0.113.7",
+		},
+		{
+			name: "XOR-MAPPED-ADDRESS IPv6",
+			resp: stunResponse(transactionID, stunAttr(stunAttrXORMappedAddress, xorIPv6)),
+			want: "2001:db8::1",
+		},
+		{
+			name: "MAPPED-ADDRESS",
+			resp: stunResponse(transactionID, stunAttr(0x8022, []byte("software")), stunAttr(stunAttrMappedAddress, mappedIPv4)),
+			want: "198.51.100.1",
+		},
+		{
+			name: "XOR-MAPPED-ADDRESS preferred",
+			resp: stunResponse(transactionID, stunAttr(stunAttrMappedAddress, mappedIPv4), stunAttr(stunAttrXORMappedAddress, xorIPv4)),
+			want: "203.0.113.7",
+		},
+		{
+			name:    "Wrong transaction ID",
+			resp:    stunResponse([]byte("ba9876543210"), stunAttr(stunAttrXORMappedAddress, xorIPv4)),
+			wantErr: true,
+		},
+		{
+			name:    "No address",
+			resp:    stunResponse(transactionID),
+			wantErr: true,
+		},
+		{
+			name:    "Too short",
+			resp:    []byte{1, 1, 0},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSTUNResponse(tt.resp, transactionID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSTUNResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(net.ParseIP(tt.want)) {
+				t.Errorf("parseSTUNResponse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
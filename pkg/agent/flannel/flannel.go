@@ -131,7 +131,7 @@ func flannel(ctx context.Context, flannelIface *net.Interface, flannelConf, kube
 		logrus.Infof("Wrote flannel subnet file to %s", subnetFile)
 	}
 
-	// Start "Running" the backend network. This will block until the context is done so run in another goroutine.
+	// Start "Running" the backend network. This will block until the context is done.
 	logrus.Info("Running flannel backend.")
 	bn.Run(ctx)
 	return nil
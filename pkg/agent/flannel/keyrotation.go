@@ -0,0 +1,163 @@
+package flannel
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.zx2c4.com/wireguard/wgctrl"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+var (
+	// KeyRotatedAnnotation records the time at which the node last rotated its wireguard key.
+	KeyRotatedAnnotation = version.Program + ".io/flannel-key-rotated"
+
+	flannelBackendDataAnnotation   = FlannelBaseAnnotation + "/backend-data"
+	flannelBackendV6DataAnnotation = FlannelBaseAnnotation + "/backend-v6-data"
+
+	// wireguardDevices are the interfaces created by the flannel wireguard backend. Both use the same key.
+	wireguardDevices = []string{"flannel-wg", "flannel-wg-v6"}
+
+	// newWireguardClient returns a client used to configure the wireguard devices; it is replaced in tests.
+	newWireguardClient = func() (wireguardClient, error) { return wgctrl.New() }
+)
+
+// wireguardClient is the subset of the wgctrl client used to rotate keys.
+type wireguardClient interface {
+	Device(name string) (*wgtypes.Device, error)
+	ConfigureDevice(name string, cfg wgtypes.Config) error
+	Close() error
+}
+
+// wireguardLeaseAttrs matches the flannel wireguard backend data stored in the node annotations.
+type wireguardLeaseAttrs struct {
+	PublicKey string
+}
+
+// wireguardKeyFile returns the path of the private key used by the wireguard backend.
+func wireguardKeyFile() string {
+	if keyFile, ok := os.LookupEnv("WIREGUARD_KEY_FILE"); ok {
+		return keyFile
+	}
+	return "/run/flannel/wgkey"
+}
+
+// rotateWireguardKey replaces the wireguard private key once it is older than the rotation interval. The new key is
+// set on the existing wireguard devices, without recreating them, and the public key is updated in the flannel
+// backend-data annotations on the node, which other nodes watch in order to update their peers.
+func rotateWireguardKey(ctx context.Context, nodeName string, nodes typedcorev1.NodeInterface, interval time.Duration) {
+	keyFile := wireguardKeyFile()
+	for {
+		// The key is created by flannel when the network is first registered, or after the node is rebooted.
+		wait := interval
+		if info, err := os.Stat(keyFile); err == nil {
+			wait = time.Until(info.ModTime().Add(interval))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		logrus.Infof("Rotating flannel wireguard key for node %s", nodeName)
+		if err := rotateKey(ctx, nodeName, nodes, keyFile); err != nil {
+			logrus.Errorf("Failed to rotate flannel wireguard key: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Minute):
+			}
+		}
+	}
+}
+
+// rotateKey generates a new private key, saves it to the key file so that it is used if flannel is restarted,
+// configures it on the wireguard devices, and publishes the public key.
+func rotateKey(ctx context.Context, nodeName string, nodes typedcorev1.NodeInterface, keyFile string) error {
+	client, err := newWireguardClient()
+	if err != nil {
+		return errors.Wrap(err, "failed to create wireguard client")
+	}
+	defer client.Close()
+
+	var devices []string
+	for _, name := range wireguardDevices {
+		if _, err := client.Device(name); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to get wireguard device %s", name)
+		}
+		devices = append(devices, name)
+	}
+	if len(devices) == 0 {
+		return errors.New("no flannel wireguard devices found")
+	}
+
+	privateKey, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		return errors.Wrap(err, "failed to generate private key")
+	}
+	if err := writeWireguardKey(keyFile, privateKey); err != nil {
+		return err
+	}
+	for _, name := range devices {
+		if err := client.ConfigureDevice(name, wgtypes.Config{PrivateKey: &privateKey}); err != nil {
+			return errors.Wrapf(err, "failed to configure wireguard device %s", name)
+		}
+	}
+	return publishPublicKey(ctx, nodeName, nodes, privateKey.PublicKey())
+}
+
+// writeWireguardKey replaces the key file with the private key.
+func writeWireguardKey(keyFile string, privateKey wgtypes.Key) error {
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0755); err != nil {
+		return err
+	}
+	tmpFile := keyFile + ".tmp"
+	os.Remove(tmpFile)
+	if err := os.WriteFile(tmpFile, []byte(privateKey.String()), 0400); err != nil {
+		return errors.Wrap(err, "failed to write key file")
+	}
+	return os.Rename(tmpFile, keyFile)
+}
+
+// publishPublicKey updates the public key in the flannel backend-data annotations that are set on the node,
+// and records the time of the rotation.
+func publishPublicKey(ctx context.Context, nodeName string, nodes typedcorev1.NodeInterface, publicKey wgtypes.Key) error {
+	node, err := nodes.Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to get node")
+	}
+	backendData, err := json.Marshal(&wireguardLeaseAttrs{PublicKey: publicKey.String()})
+	if err != nil {
+		return err
+	}
+	annotations := map[string]interface{}{KeyRotatedAnnotation: time.Now().UTC().Format(time.RFC3339)}
+	for _, annotation := range []string{flannelBackendDataAnnotation, flannelBackendV6DataAnnotation} {
+		if _, ok := node.Annotations[annotation]; ok {
+			annotations[annotation] = string(backendData)
+		}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := nodes.Patch(ctx, nodeName, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return errors.Wrap(err, "failed to update node annotations")
+	}
+	return nil
+}
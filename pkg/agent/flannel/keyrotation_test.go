@@ -0,0 +1,157 @@
+package flannel
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeWireguardClient records the keys configured on a set of existing devices.
+type fakeWireguardClient struct {
+	keys         map[string]*wgtypes.Key
+	configureErr error
+}
+
+func (f *fakeWireguardClient) Device(name string) (*wgtypes.Device, error) {
+	if _, ok := f.keys[name]; !ok {
+		return nil, os.ErrNotExist
+	}
+	return &wgtypes.Device{Name: name}, nil
+}
+
+func (f *fakeWireguardClient) ConfigureDevice(name string, cfg wgtypes.Config) error {
+	if f.configureErr != nil {
+		return f.configureErr
+	}
+	if cfg.ReplacePeers || cfg.Peers != nil {
+		return errors.New("peers must not be changed")
+	}
+	f.keys[name] = cfg.PrivateKey
+	return nil
+}
+
+func (f *fakeWireguardClient) Close() error {
+	return nil
+}
+
+func Test_UnitRotateKey(t *testing.T) {
+	oldKey, err := wgtypes.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldData, _ := json.Marshal(&wireguardLeaseAttrs{PublicKey: oldKey.PublicKey().String()})
+
+	tests := []struct {
+		name            string
+		devices         []string
+		annotations     map[string]string
+		configureErr    error
+		wantAnnotations []string
+		wantErr         bool
+	}{
+		{
+			name:            "ipv4",
+			devices:         []string{"flannel-wg"},
+			annotations:     map[string]string{flannelBackendDataAnnotation: string(oldData)},
+			wantAnnotations: []string{flannelBackendDataAnnotation},
+		},
+		{
+			name:            "dual-stack single device",
+			devices:         []string{"flannel-wg"},
+			annotations:     map[string]string{flannelBackendDataAnnotation: string(oldData), flannelBackendV6DataAnnotation: string(oldData)},
+			wantAnnotations: []string{flannelBackendDataAnnotation, flannelBackendV6DataAnnotation},
+		},
+		{
+			name:            "dual-stack separate devices",
+			devices:         []string{"flannel-wg", "flannel-wg-v6"},
+			annotations:     map[string]string{flannelBackendDataAnnotation: string(oldData), flannelBackendV6DataAnnotation: string(oldData)},
+			wantAnnotations: []string{flannelBackendDataAnnotation, flannelBackendV6DataAnnotation},
+		},
+		{
+			name:            "ipv6 separate device",
+			devices:         []string{"flannel-wg-v6"},
+			annotations:     map[string]string{flannelBackendV6DataAnnotation: string(oldData)},
+			wantAnnotations: []string{flannelBackendV6DataAnnotation},
+		},
+		{
+			name:        "no devices",
+			annotations: map[string]string{flannelBackendDataAnnotation: string(oldData)},
+			wantErr:     true,
+		},
+		{
+			name:         "configure error",
+			devices:      []string{"flannel-wg"},
+			annotations:  map[string]string{flannelBackendDataAnnotation: string(oldData)},
+			configureErr: errors.New("operation not permitted"),
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := &fakeWireguardClient{keys: map[string]*wgtypes.Key{}, configureErr: tt.configureErr}
+			for _, name := range tt.devices {
+				client.keys[name] = &oldKey
+			}
+			defer func(f func() (wireguardClient, error)) { newWireguardClient = f }(newWireguardClient)
+			newWireguardClient = func() (wireguardClient, error) { return client, nil }
+
+			k8s := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: tt.annotations}})
+			nodes := k8s.CoreV1().Nodes()
+			keyFile := filepath.Join(t.TempDir(), "wgkey")
+
+			err := rotateKey(ctx, "node1", nodes, keyFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rotateKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			node, err := nodes.Get(ctx, "node1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr {
+				if _, ok := node.Annotations[KeyRotatedAnnotation]; ok {
+					t.Errorf("rotateKey() recorded a failed rotation")
+				}
+				return
+			}
+
+			b, err := os.ReadFile(keyFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			newKey, err := wgtypes.ParseKey(string(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if newKey == oldKey {
+				t.Errorf("rotateKey() did not change the key")
+			}
+			for _, name := range tt.devices {
+				if key := client.keys[name]; key == nil || *key != newKey {
+					t.Errorf("rotateKey() did not configure the new key on device %s", name)
+				}
+			}
+
+			newData, _ := json.Marshal(&wireguardLeaseAttrs{PublicKey: newKey.PublicKey().String()})
+			for _, annotation := range tt.wantAnnotations {
+				if got := node.Annotations[annotation]; got != string(newData) {
+					t.Errorf("rotateKey() annotation %s = %s, want %s", annotation, got, newData)
+				}
+			}
+			if _, ok := node.Annotations[flannelBackendV6DataAnnotation]; ok != (tt.annotations[flannelBackendV6DataAnnotation] != "") {
+				t.Errorf("rotateKey() changed the set of backend data annotations")
+			}
+			if _, ok := node.Annotations[KeyRotatedAnnotation]; !ok {
+				t.Errorf("rotateKey() did not set %s annotation", KeyRotatedAnnotation)
+			}
+		})
+	}
+}
@@ -23,34 +23,6 @@ import (
 )
 
 const (
-	cniConf = `{
-  "name":"cbr0",
-  "cniVersion":"1.0.0",
-  "plugins":[
-    {
-      "type":"flannel",
-      "delegate":{
-        "hairpinMode":true,
-        "forceAddress":true,
-        "isDefaultGateway":true
-      }
-    },
-    {
-      "type":"portmap",
-      "capabilities":{
-        "portMappings":true
-      }
-    },
-    {
-      "type":"bandwidth",
-      "capabilities":{
-        "bandwidth":true
-      }
-    }
-  ]
-}
-`
-
 	flannelConf = `{
 	"Network": "%CIDR%",
 	"EnableIPv6": %IPV6_ENABLED%,
@@ -60,10 +32,6 @@ const (
 }
 `
 
-	vxlanBackend = `{
-	"Type": "vxlan"
-}`
-
 	hostGWBackend = `{
 	"Type": "host-gw"
 }`
@@ -104,6 +72,10 @@ func Run(ctx context.Context, nodeConfig *config.Node, nodes typedcorev1.NodeInt
 	if err != nil {
 		return errors.Wrap(err, "failed to check netMode for flannel")
 	}
+
+	if nodeConfig.FlannelBackend == config.FlannelBackendWireguardNative && nodeConfig.FlannelKeyRotation > 0 {
+		go rotateWireguardKey(ctx, nodeConfig.AgentConfig.NodeName, nodes, nodeConfig.FlannelKeyRotation)
+	}
 	go func() {
 		err := flannel(ctx, nodeConfig.FlannelIface, nodeConfig.FlannelConfFile, nodeConfig.AgentConfig.KubeConfigKubelet, nodeConfig.FlannelIPv6Masq, nodeConfig.MultiClusterCIDR, netMode)
 		if err != nil && !errors.Is(err, context.Canceled) {
@@ -153,6 +125,10 @@ func createCNIConf(dir string, nodeConfig *config.Node) error {
 		logrus.Debugf("Using %s as the flannel CNI conf", nodeConfig.AgentConfig.FlannelCniConfFile)
 		return util.CopyFile(nodeConfig.AgentConfig.FlannelCniConfFile, p)
 	}
+	cniConf, err := cniConfig(nodeConfig)
+	if err != nil {
+		return err
+	}
 	return util.WriteFile(p, cniConf)
 }
 
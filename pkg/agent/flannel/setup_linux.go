@@ -0,0 +1,52 @@
+//go:build linux
+// +build linux
+
+package flannel
+
+import (
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+)
+
+const (
+	cniConf = `{
+  "name":"cbr0",
+  "cniVersion":"1.0.0",
+  "plugins":[
+    {
+      "type":"flannel",
+      "delegate":{
+        "hairpinMode":true,
+        "forceAddress":true,
+        "isDefaultGateway":true
+      }
+    },
+    {
+      "type":"portmap",
+      "capabilities":{
+        "portMappings":true
+      }
+    },
+    {
+      "type":"bandwidth",
+      "capabilities":{
+        "bandwidth":true
+      }
+    }
+  ]
+}
+`
+
+	vxlanBackend = `{
+	"Type": "vxlan"
+}`
+)
+
+// cniConfig returns the flannel CNI config, which delegates to the bridge plugin for all backends.
+func cniConfig(nodeConfig *config.Node) (string, error) {
+	return cniConf, nil
+}
+
+// NetworkName returns the name of the network created by flannel. It is only used by kube-proxy on Windows.
+func NetworkName(backend string) string {
+	return ""
+}
@@ -0,0 +1,149 @@
+//go:build windows
+// +build windows
+
+package flannel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	utilsnet "k8s.io/utils/net"
+)
+
+const (
+	// The HNS network created by the vxlan backend is named after its VNI, which must be 4096 or greater on Windows.
+	vxlanNetworkName  = "flannel.4096"
+	hostGWNetworkName = "cbr0"
+
+	overlayCNIConf = `{
+  "name":"flannel.4096",
+  "cniVersion":"1.0.0",
+  "plugins":[
+    {
+      "type":"flannel",
+      "capabilities":{
+        "portMappings":true,
+        "dns":true
+      },
+      "delegate":{
+        "type":"win-overlay",
+        "apiVersion":2,
+        "Policies":[
+          {
+            "Name":"EndpointPolicy",
+            "Value":{
+              "Type":"OutBoundNAT",
+              "Settings":{
+                "Exceptions":["%CLUSTER_CIDR%","%SERVICE_CIDR%"]
+              }
+            }
+          },
+          {
+            "Name":"EndpointPolicy",
+            "Value":{
+              "Type":"SDNRoute",
+              "Settings":{
+                "DestinationPrefix":"%SERVICE_CIDR%",
+                "NeedEncap":true
+              }
+            }
+          },
+          {
+            "Name":"EndpointPolicy",
+            "Value":{
+              "Type":"ProviderAddress",
+              "Settings":{
+                "ProviderAddress":"%IPV4_ADDRESS%"
+              }
+            }
+          }
+        ]
+      }
+    }
+  ]
+}
+`
+
+	bridgeCNIConf = `{
+  "name":"cbr0",
+  "cniVersion":"1.0.0",
+  "plugins":[
+    {
+      "type":"flannel",
+      "capabilities":{
+        "portMappings":true,
+        "dns":true
+      },
+      "delegate":{
+        "type":"win-bridge",
+        "apiVersion":2,
+        "Policies":[
+          {
+            "Name":"EndpointPolicy",
+            "Value":{
+              "Type":"OutBoundNAT",
+              "Settings":{
+                "Exceptions":["%CLUSTER_CIDR%","%SERVICE_CIDR%"]
+              }
+            }
+          },
+          {
+            "Name":"EndpointPolicy",
+            "Value":{
+              "Type":"SDNRoute",
+              "Settings":{
+                "DestinationPrefix":"%SERVICE_CIDR%",
+                "NeedEncap":true
+              }
+            }
+          }
+        ]
+      }
+    }
+  ]
+}
+`
+
+	vxlanBackend = `{
+	"Type": "vxlan",
+	"VNI": 4096,
+	"Port": 4789
+}`
+)
+
+// cniConfig returns the flannel CNI config for the backend. Flannel delegates to the win-overlay plugin for
+// the vxlan backend, and to the win-bridge plugin for the host-gw backend; other backends are not supported
+// on Windows. Traffic to the cluster and service CIDRs is excluded from outbound NAT.
+func cniConfig(nodeConfig *config.Node) (string, error) {
+	var conf string
+	switch nodeConfig.FlannelBackend {
+	case config.FlannelBackendVXLAN:
+		conf = overlayCNIConf
+	case config.FlannelBackendHostGW:
+		conf = bridgeCNIConf
+	default:
+		return "", fmt.Errorf("flannel backend %s is not supported on Windows; use %s or %s", nodeConfig.FlannelBackend, config.FlannelBackendVXLAN, config.FlannelBackendHostGW)
+	}
+
+	agentConfig := nodeConfig.AgentConfig
+	if agentConfig.ClusterCIDR == nil || !utilsnet.IsIPv4CIDR(agentConfig.ClusterCIDR) || agentConfig.ServiceCIDR == nil {
+		return "", fmt.Errorf("flannel on Windows requires an IPv4 cluster CIDR and service CIDR")
+	}
+	conf = strings.ReplaceAll(conf, "%CLUSTER_CIDR%", agentConfig.ClusterCIDR.String())
+	conf = strings.ReplaceAll(conf, "%SERVICE_CIDR%", agentConfig.ServiceCIDR.String())
+	conf = strings.ReplaceAll(conf, "%IPV4_ADDRESS%", agentConfig.NodeIP)
+	return conf, nil
+}
+
+// NetworkName returns the name of the HNS network created by flannel for the backend, which kube-proxy
+// programs load balancers on.
+func NetworkName(backend string) string {
+	switch backend {
+	case config.FlannelBackendVXLAN:
+		return vxlanNetworkName
+	case config.FlannelBackendHostGW:
+		return hostGWNetworkName
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+//go:build windows
+// +build windows
+
+package flannel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+)
+
+func Test_UnitCNIConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		backend  string
+		cidrs    string
+		wantConf []string
+		wantErr  bool
+	}{
+		{
+			name:     "vxlan",
+			backend:  config.FlannelBackendVXLAN,
+			cidrs:    "10.42.0.0/16",
+			wantConf: []string{`"name":"flannel.4096"`, `"type":"win-overlay"`, `"Exceptions":["10.42.0.0/16","10.43.0.0/16"]`, `"ProviderAddress":"192.168.1.10"`},
+		},
+		{
+			name:     "host-gw",
+			backend:  config.FlannelBackendHostGW,
+			cidrs:    "10.42.0.0/16",
+			wantConf: []string{`"name":"cbr0"`, `"type":"win-bridge"`, `"DestinationPrefix":"10.43.0.0/16"`},
+		},
+		{
+			name:    "wireguard",
+			backend: config.FlannelBackendWireguardNative,
+			cidrs:   "10.42.0.0/16",
+			wantErr: true,
+		},
+		{
+			name:    "ipv6 only",
+			backend: config.FlannelBackendVXLAN,
+			cidrs:   "2001:cafe:42:0::/56",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeConfig := &config.Node{FlannelBackend: tt.backend}
+			nodeConfig.AgentConfig.ClusterCIDR = stringToCIDR(tt.cidrs)[0]
+			nodeConfig.AgentConfig.ServiceCIDR = stringToCIDR("10.43.0.0/16")[0]
+			nodeConfig.AgentConfig.NodeIP = "192.168.1.10"
+			got, err := cniConfig(nodeConfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cniConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, want := range tt.wantConf {
+				if !strings.Contains(got, want) {
+					t.Errorf("cniConfig() does not contain %s", want)
+				}
+			}
+		})
+	}
+}
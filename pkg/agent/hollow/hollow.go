@@ -0,0 +1,336 @@
+package hollow
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	agentconfig "github.com/k3s-io/k3s/pkg/agent/config"
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/clientaccess"
+	"github.com/k3s-io/k3s/pkg/util"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
+)
+
+var (
+	// HollowNodeLabel is set on all hollow nodes, so that they can be found and removed after testing.
+	HollowNodeLabel = version.Program + ".io/hollow-node"
+	// HollowNodeTaint is set on all hollow nodes, so that only pods that tolerate it are scheduled to them.
+	HollowNodeTaint = corev1.Taint{Key: HollowNodeLabel, Value: "true", Effect: corev1.TaintEffectNoSchedule}
+
+	leaseDuration  = 40 * time.Second
+	renewInterval  = 10 * time.Second
+	statusInterval = time.Minute
+)
+
+// Run registers the configured number of hollow nodes with the server, and runs them until the context is
+// cancelled. Hollow nodes do not run containers; they maintain their node lease and status, and report pods
+// that are scheduled to them as running, so that the datastore, apiserver, scheduler and controllers can be
+// tested at scale without real nodes.
+func Run(ctx context.Context, cfg cmds.Agent) error {
+	info, err := clientaccess.ParseAndValidateToken(cfg.ServerURL, cfg.Token)
+	if err != nil {
+		return err
+	}
+	nodeIP := net.ParseIP("127.0.0.1")
+	if len(cfg.NodeIP) > 0 {
+		if nodeIP = util.ParseIP(cfg.NodeIP[0]); nodeIP == nil {
+			return fmt.Errorf("invalid node-ip %s", cfg.NodeIP[0])
+		}
+	}
+	prefix := cfg.NodeName
+	if prefix == "" {
+		if prefix, err = os.Hostname(); err != nil {
+			return err
+		}
+	}
+	dir := filepath.Join(cfg.DataDir, "agent", "hollow")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	logrus.Infof("Starting %d hollow nodes with prefix %s-hollow", cfg.Simulate, prefix)
+	wg := sync.WaitGroup{}
+	for i := 0; i < cfg.Simulate; i++ {
+		name := fmt.Sprintf("%s-hollow-%d", prefix, i)
+		client, err := getClient(dir, name, nodeIP, info)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get credentials for hollow node %s", name)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			newNode(name, nodeIP, client).run(ctx)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// getClient returns a client that authenticates to the server as the named node.
+func getClient(dir, name string, nodeIP net.IP, info *clientaccess.Info) (kubernetes.Interface, error) {
+	// the certificate is requested from the server by file name, so each node's files are kept in their own directory
+	dir = filepath.Join(dir, name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	certFile := filepath.Join(dir, "client-kubelet.crt")
+	keyFile := filepath.Join(dir, "client-kubelet.key")
+	passwordFile := filepath.Join(dir, "password")
+	if err := agentconfig.GetNodeNamedHostFile(certFile, keyFile, name, []net.IP{nodeIP}, passwordFile, info); err != nil {
+		return nil, err
+	}
+	restConfig := &rest.Config{
+		Host:      info.BaseURL,
+		UserAgent: version.Program + "-hollow-node",
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData:   info.CACerts,
+			CertFile: certFile,
+			KeyFile:  keyFile,
+		},
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+type node struct {
+	name   string
+	ip     net.IP
+	client kubernetes.Interface
+
+	mu      sync.Mutex
+	podCIDR *net.IPNet
+	nextIP  int
+}
+
+func newNode(name string, ip net.IP, client kubernetes.Interface) *node {
+	return &node{name: name, ip: ip, client: client}
+}
+
+func (n *node) run(ctx context.Context) {
+	if err := wait.PollImmediateUntilWithContext(ctx, 5*time.Second, func(ctx context.Context) (bool, error) {
+		if err := n.register(ctx); err != nil {
+			logrus.Warnf("Failed to register hollow node %s: %v", n.name, err)
+			return false, nil
+		}
+		return true, nil
+	}); err != nil {
+		return
+	}
+	go wait.UntilWithContext(ctx, n.renewLease, renewInterval)
+	go wait.UntilWithContext(ctx, n.updateStatus, statusInterval)
+	n.watchPods(ctx)
+}
+
+// register creates the node, if it does not already exist.
+func (n *node) register(ctx context.Context) error {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: n.name,
+			Labels: map[string]string{
+				corev1.LabelHostname:   n.name,
+				corev1.LabelOSStable:   "linux",
+				corev1.LabelArchStable: "amd64",
+				HollowNodeLabel:        "true",
+			},
+		},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{HollowNodeTaint},
+		},
+	}
+	if _, err := n.client.CoreV1().Nodes().Create(ctx, node, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	logrus.Infof("Registered hollow node %s", n.name)
+	n.updateStatus(ctx)
+	return nil
+}
+
+// updateStatus reports the node as ready, with fixed capacity.
+func (n *node) updateStatus(ctx context.Context) {
+	node, err := n.client.CoreV1().Nodes().Get(ctx, n.name, metav1.GetOptions{})
+	if err != nil {
+		logrus.Warnf("Failed to get hollow node %s: %v", n.name, err)
+		return
+	}
+	n.setPodCIDR(node.Spec.PodCIDR)
+
+	now := metav1.Now()
+	capacity := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("16Gi"),
+		corev1.ResourcePods:   resource.MustParse("110"),
+	}
+	node.Status = corev1.NodeStatus{
+		Capacity:    capacity,
+		Allocatable: capacity,
+		Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue, Reason: "KubeletReady", Message: "hollow node is posting ready status", LastHeartbeatTime: now, LastTransitionTime: now},
+			{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse, Reason: "KubeletHasSufficientMemory", LastHeartbeatTime: now, LastTransitionTime: now},
+			{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse, Reason: "KubeletHasNoDiskPressure", LastHeartbeatTime: now, LastTransitionTime: now},
+			{Type: corev1.NodePIDPressure, Status: corev1.ConditionFalse, Reason: "KubeletHasSufficientPID", LastHeartbeatTime: now, LastTransitionTime: now},
+		},
+		Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: n.ip.String()},
+			{Type: corev1.NodeHostName, Address: n.name},
+		},
+		NodeInfo: corev1.NodeSystemInfo{
+			KubeletVersion:          version.Version,
+			KubeProxyVersion:        version.Version,
+			OperatingSystem:         "linux",
+			Architecture:            "amd64",
+			ContainerRuntimeVersion: "hollow://" + version.Version,
+		},
+	}
+	if _, err := n.client.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{}); err != nil {
+		logrus.Warnf("Failed to update status of hollow node %s: %v", n.name, err)
+	}
+}
+
+// renewLease creates or renews the node lease, which the node lifecycle controller uses as the node heartbeat.
+func (n *node) renewLease(ctx context.Context) {
+	leases := n.client.CoordinationV1().Leases(corev1.NamespaceNodeLease)
+	now := metav1.NewMicroTime(time.Now())
+	lease, err := leases.Get(ctx, n.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: n.name, Namespace: corev1.NamespaceNodeLease},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       pointer.String(n.name),
+				LeaseDurationSeconds: pointer.Int32(int32(leaseDuration / time.Second)),
+				RenewTime:            &now,
+			},
+		}
+		_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+	} else if err == nil {
+		lease.Spec.RenewTime = &now
+		_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		logrus.Warnf("Failed to renew lease for hollow node %s: %v", n.name, err)
+	}
+}
+
+// watchPods reports pods scheduled to the node as running, and completes the deletion of pods that are deleted.
+func (n *node) watchPods(ctx context.Context) {
+	lw := cache.NewListWatchFromClient(n.client.CoreV1().RESTClient(), "pods", metav1.NamespaceAll, fields.OneTermEqualSelector("spec.nodeName", n.name))
+	_, controller := cache.NewInformer(lw, &corev1.Pod{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			n.syncPod(ctx, obj.(*corev1.Pod))
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			n.syncPod(ctx, obj.(*corev1.Pod))
+		},
+	})
+	controller.Run(ctx.Done())
+}
+
+func (n *node) syncPod(ctx context.Context, pod *corev1.Pod) {
+	if pod.DeletionTimestamp != nil {
+		err := n.client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{
+			GracePeriodSeconds: pointer.Int64(0),
+			Preconditions:      metav1.NewUIDPreconditions(string(pod.UID)),
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			logrus.Warnf("Failed to delete pod %s/%s from hollow node %s: %v", pod.Namespace, pod.Name, n.name, err)
+		}
+		return
+	}
+	if pod.Status.Phase != corev1.PodPending && pod.Status.Phase != "" {
+		return
+	}
+
+	pod = pod.DeepCopy()
+	pod.Status = n.runningStatus(pod)
+	if _, err := n.client.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+		logrus.Warnf("Failed to update status of pod %s/%s on hollow node %s: %v", pod.Namespace, pod.Name, n.name, err)
+	}
+}
+
+// runningStatus returns the status of the pod, with all containers running and ready.
+func (n *node) runningStatus(pod *corev1.Pod) corev1.PodStatus {
+	now := metav1.Now()
+	status := corev1.PodStatus{
+		Phase:     corev1.PodRunning,
+		HostIP:    n.ip.String(),
+		StartTime: &now,
+		QOSClass:  pod.Status.QOSClass,
+		Conditions: []corev1.PodCondition{
+			{Type: corev1.PodInitialized, Status: corev1.ConditionTrue, LastTransitionTime: now},
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue, LastTransitionTime: now},
+			{Type: corev1.ContainersReady, Status: corev1.ConditionTrue, LastTransitionTime: now},
+			{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: now},
+		},
+	}
+	if pod.Spec.HostNetwork {
+		status.PodIP = n.ip.String()
+	} else {
+		status.PodIP = n.allocateIP()
+	}
+	if status.PodIP != "" {
+		status.PodIPs = []corev1.PodIP{{IP: status.PodIP}}
+	}
+	for _, container := range pod.Spec.Containers {
+		status.ContainerStatuses = append(status.ContainerStatuses, corev1.ContainerStatus{
+			Name:        container.Name,
+			Image:       container.Image,
+			ImageID:     container.Image,
+			ContainerID: "hollow://" + string(pod.UID) + "/" + container.Name,
+			Ready:       true,
+			Started:     pointer.Bool(true),
+			State:       corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: now}},
+		})
+	}
+	return status
+}
+
+func (n *node) setPodCIDR(cidr string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.podCIDR != nil || cidr == "" {
+		return
+	}
+	if _, podCIDR, err := net.ParseCIDR(cidr); err == nil {
+		n.podCIDR = podCIDR
+	}
+}
+
+// allocateIP returns the next address in the node's pod CIDR. Addresses are not tracked or reused; the
+// pod IPs of hollow nodes only need to be plausible, as no traffic is sent to them.
+func (n *node) allocateIP() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.podCIDR == nil {
+		return ""
+	}
+	ones, bits := n.podCIDR.Mask.Size()
+	size := 1 << (bits - ones)
+	if bits-ones >= 31 {
+		size = 1 << 30
+	}
+	n.nextIP = n.nextIP%(size-2) + 1
+	ip := make(net.IP, len(n.podCIDR.IP))
+	copy(ip, n.podCIDR.IP)
+	for i, carry := len(ip)-1, n.nextIP; i >= 0 && carry > 0; i-- {
+		sum := int(ip[i]) + carry
+		ip[i] = byte(sum)
+		carry = sum >> 8
+	}
+	return ip.String()
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/k3s-io/k3s/pkg/util"
 	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/sirupsen/logrus"
 	"inet.af/tcpproxy"
@@ -66,7 +67,16 @@ func New(ctx context.Context, dataDir, serviceName, serverURL string, lbServerPo
 	} else {
 		localAddress = fmt.Sprintf("127.0.0.1:%d", lbServerPort)
 	}
-	listener, err := config.Listen(ctx, "tcp", localAddress)
+	// use the listener passed by systemd socket activation if there is one, so that connections made while
+	// the agent is starting or restarting are queued instead of refused.
+	var listener net.Listener
+	var err error
+	if lbServerPort != RandomPort {
+		listener = util.ActivatedListener(lbServerPort)
+	}
+	if listener == nil {
+		listener, err = config.Listen(ctx, "tcp", localAddress)
+	}
 	defer func() {
 		if _err != nil {
 			logrus.Warnf("Error starting load balancer: %s", _err)
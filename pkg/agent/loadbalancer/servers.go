@@ -10,7 +10,18 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
-var defaultDialer = &net.Dialer{}
+// Dialer dials connections to servers.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+var defaultDialer Dialer = &net.Dialer{}
+
+// SetDialer sets the dialer used by all load balancers to connect to servers.
+// This must be called before any load balancers are created.
+func SetDialer(dialer Dialer) {
+	defaultDialer = dialer
+}
 
 func (lb *LoadBalancer) setServers(serverAddresses []string) bool {
 	serverAddresses, hasOriginalServer := sortServers(serverAddresses, lb.defaultServerAddress)
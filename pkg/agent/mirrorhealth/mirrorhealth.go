@@ -0,0 +1,179 @@
+package mirrorhealth
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Checker probes registry mirror endpoints, and orders them so that endpoints that are not responding
+// are tried only after those that are. containerd tries mirror endpoints in the configured order, waiting
+// for each to time out before moving on to the next, so a mirror that is down would otherwise stall
+// every image pull.
+type Checker struct {
+	Interval time.Duration
+	Timeout  time.Duration
+
+	mu        sync.Mutex
+	unhealthy map[string]bool
+	probe     func(ctx context.Context, endpoint string) error
+}
+
+// New creates a checker that probes endpoints at the given interval, considering endpoints that do not
+// respond within the timeout to be unhealthy. Endpoints are verified using the TLS config returned for
+// their host, so that an endpoint whose certificate would be rejected by containerd is not considered
+// healthy. If tlsConfig is nil, the default TLS config is used for all endpoints.
+func New(interval, timeout time.Duration, tlsConfig func(host string) (*tls.Config, error)) *Checker {
+	c := &Checker{
+		Interval:  interval,
+		Timeout:   timeout,
+		unhealthy: map[string]bool{},
+	}
+	clients := map[string]*http.Client{}
+	clientsMu := sync.Mutex{}
+	c.probe = func(ctx context.Context, endpoint string) error {
+		u, err := endpointURL(endpoint)
+		if err != nil {
+			return err
+		}
+		clientsMu.Lock()
+		client, ok := clients[u.Host]
+		if !ok {
+			transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+			if tlsConfig != nil {
+				if transport.TLSClientConfig, err = tlsConfig(u.Host); err != nil {
+					clientsMu.Unlock()
+					return err
+				}
+			}
+			client = &http.Client{
+				Transport: transport,
+				CheckRedirect: func(*http.Request, []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			}
+			clients[u.Host] = client
+		}
+		clientsMu.Unlock()
+		return probeEndpoint(ctx, client, endpoint)
+	}
+	return c
+}
+
+// Probe probes each of the endpoints concurrently, and returns true if the health of any endpoint has
+// changed since it was last probed. Endpoints that have not been probed before are considered healthy.
+func (c *Checker) Probe(ctx context.Context, endpoints []string) bool {
+	results := make([]error, len(endpoints))
+	wg := sync.WaitGroup{}
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+			defer cancel()
+			results[i] = c.probe(ctx, endpoint)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := false
+	for i, endpoint := range endpoints {
+		unhealthy := results[i] != nil
+		if unhealthy == c.unhealthy[endpoint] {
+			continue
+		}
+		changed = true
+		if unhealthy {
+			logrus.Warnf("Registry mirror endpoint %s is unhealthy and will be tried last: %v", endpoint, results[i])
+			c.unhealthy[endpoint] = true
+		} else {
+			logrus.Infof("Registry mirror endpoint %s is healthy", endpoint)
+			delete(c.unhealthy, endpoint)
+		}
+	}
+	return changed
+}
+
+// Order returns the endpoints with the healthy endpoints first, followed by the unhealthy endpoints.
+// The relative order of the configured endpoints is otherwise preserved.
+func (c *Checker) Order(endpoints []string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	healthy := make([]string, 0, len(endpoints))
+	unhealthy := []string{}
+	for _, endpoint := range endpoints {
+		if c.unhealthy[endpoint] {
+			unhealthy = append(unhealthy, endpoint)
+		} else {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// Run probes the endpoints at the configured interval until the context is cancelled, calling onChange
+// whenever the health of an endpoint changes.
+func (c *Checker) Run(ctx context.Context, endpoints []string, onChange func()) {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if c.Probe(ctx, endpoints) && ctx.Err() == nil {
+				onChange()
+			}
+		}
+	}
+}
+
+// probeEndpoint checks that the endpoint responds to a request for the base of the registry API. Any
+// response other than a server error is considered healthy, as registries are expected to require
+// authentication.
+func probeEndpoint(ctx context.Context, client *http.Client, endpoint string) error {
+	u, err := endpointURL(endpoint)
+	if err != nil {
+		return err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	if !strings.HasSuffix(u.Path, "/v2") {
+		u.Path += "/v2"
+	}
+	u.Path += "/"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// endpointURL parses the endpoint, which defaults to https if no scheme is given.
+func endpointURL(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" {
+		return url.Parse("https://" + endpoint)
+	}
+	return u, nil
+}
@@ -0,0 +1,176 @@
+package mirrorhealth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_UnitCheckerOrder(t *testing.T) {
+	endpoints := []string{"https://a.example.com", "https://b.example.com", "https://c.example.com"}
+	tests := []struct {
+		name        string
+		down        []map[string]bool
+		wantChanged bool
+		want        []string
+	}{
+		{
+			name:        "all healthy",
+			down:        []map[string]bool{{}},
+			wantChanged: false,
+			want:        endpoints,
+		},
+		{
+			name:        "first endpoint down",
+			down:        []map[string]bool{{"https://a.example.com": true}},
+			wantChanged: true,
+			want:        []string{"https://b.example.com", "https://c.example.com", "https://a.example.com"},
+		},
+		{
+			name:        "multiple endpoints down",
+			down:        []map[string]bool{{"https://a.example.com": true, "https://b.example.com": true}},
+			wantChanged: true,
+			want:        []string{"https://c.example.com", "https://a.example.com", "https://b.example.com"},
+		},
+		{
+			name:        "endpoint still down",
+			down:        []map[string]bool{{"https://a.example.com": true}, {"https://a.example.com": true}},
+			wantChanged: false,
+			want:        []string{"https://b.example.com", "https://c.example.com", "https://a.example.com"},
+		},
+		{
+			name:        "endpoint recovers",
+			down:        []map[string]bool{{"https://a.example.com": true}, {}},
+			wantChanged: true,
+			want:        endpoints,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(time.Minute, time.Second, nil)
+			var down map[string]bool
+			c.probe = func(ctx context.Context, endpoint string) error {
+				if down[endpoint] {
+					return errors.New("connection refused")
+				}
+				return nil
+			}
+			var changed bool
+			for _, down = range tt.down {
+				changed = c.Probe(context.Background(), endpoints)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("Probe() = %v, want %v", changed, tt.wantChanged)
+			}
+			if got := c.Order(endpoints); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Order() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitProbeEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		path    string
+		closed  bool
+		wantErr bool
+	}{
+		{
+			name:   "ok",
+			status: http.StatusOK,
+		},
+		{
+			name:   "authentication required",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "endpoint with api path",
+			status: http.StatusOK,
+			path:   "/v2",
+		},
+		{
+			name:    "server error",
+			status:  http.StatusServiceUnavailable,
+			wantErr: true,
+		},
+		{
+			name:    "not listening",
+			closed:  true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/v2/" {
+					rw.WriteHeader(http.StatusNotFound)
+					return
+				}
+				rw.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			if tt.closed {
+				server.Close()
+			}
+
+			err := probeEndpoint(context.Background(), server.Client(), server.URL+tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("probeEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_UnitProbeTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	serverTLS := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	tests := []struct {
+		name          string
+		tlsConfig     func(host string) (*tls.Config, error)
+		wantUnhealthy bool
+	}{
+		{
+			name:          "untrusted certificate",
+			wantUnhealthy: true,
+		},
+		{
+			name: "registry CA",
+			tlsConfig: func(host string) (*tls.Config, error) {
+				return &tls.Config{RootCAs: serverTLS.RootCAs}, nil
+			},
+		},
+		{
+			name: "insecure skip verify",
+			tlsConfig: func(host string) (*tls.Config, error) {
+				return &tls.Config{InsecureSkipVerify: true}, nil
+			},
+		},
+		{
+			name: "invalid TLS config",
+			tlsConfig: func(host string) (*tls.Config, error) {
+				return nil, errors.New("failed to load CA file")
+			},
+			wantUnhealthy: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New(time.Minute, time.Second, tt.tlsConfig)
+			c.Probe(context.Background(), []string{server.URL})
+			if got := c.Order([]string{server.URL, "https://other"})[0] != server.URL; got != tt.wantUnhealthy {
+				t.Errorf("Probe() unhealthy = %v, want %v", got, tt.wantUnhealthy)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+//go:build !windows
+// +build !windows
+
+package netpol
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+	"golang.org/x/time/rate"
+	v1core "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// nflogGroup is the netfilter log group that the network policy controller logs rejected packets to. The
+// controller limits logging to 10 packets per minute for each pod.
+const nflogGroup = 100
+
+// Constants from linux/netfilter/nfnetlink_log.h, which are not defined by x/sys/unix.
+const (
+	nfulnlMsgPacket  = 0
+	nfulnlMsgConfig  = 1
+	nfulaPayload     = 9
+	nfulaCfgCmd      = 1
+	nfulaCfgMode     = 2
+	nfulnlCfgCmdBind = 1
+	nfulnlCopyPacket = 2
+
+	// nflogCopyRange is the number of bytes of each packet that is copied; enough for the IP and transport
+	// headers.
+	nflogCopyRange = 128
+)
+
+// deniedConnection is a packet that was rejected by the network policy controller.
+type deniedConnection struct {
+	protocol string
+	src      net.IP
+	dst      net.IP
+	srcPort  uint16
+	dstPort  uint16
+}
+
+// logDeniedConnections logs packets that are rejected by the network policy controller, with the pods that they
+// were sent from and to. In addition to the rate limit for each pod applied by the controller, no more than 10
+// packets are logged per second; the number of packets that were not logged is included in the next message.
+func logDeniedConnections(ctx context.Context, pods cache.Indexer) {
+	fd, err := bindNFLog(nflogGroup)
+	if err != nil {
+		logrus.Errorf("Failed to enable network policy logging; is another process bound to netfilter log group %d? %v", nflogGroup, err)
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		unix.Close(fd)
+	}()
+
+	logrus.Infof("Logging connections denied by network policies")
+	limiter := rate.NewLimiter(rate.Limit(10), 10)
+	suppressed := 0
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, unix.ENOBUFS) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+			logrus.Errorf("Failed to read denied connections from netfilter log: %v", err)
+			return
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			logrus.Debugf("Failed to parse netfilter log message: %v", err)
+			continue
+		}
+		for _, msg := range msgs {
+			if msg.Header.Type != unix.NFNL_SUBSYS_ULOG<<8|nfulnlMsgPacket {
+				continue
+			}
+			conn, err := parseNFLogPacket(msg.Data)
+			if err != nil {
+				logrus.Debugf("Failed to parse packet from netfilter log: %v", err)
+				continue
+			}
+			if !limiter.Allow() {
+				suppressed++
+				continue
+			}
+			message := fmt.Sprintf("Network policy denied %s connection from %s to %s",
+				conn.protocol, endpoint(pods, conn.src, conn.srcPort), endpoint(pods, conn.dst, conn.dstPort))
+			if suppressed > 0 {
+				message += fmt.Sprintf(" (%d more denied connections not logged)", suppressed)
+				suppressed = 0
+			}
+			logrus.Info(message)
+		}
+	}
+}
+
+// bindNFLog opens a netlink socket that receives packets logged to the netfilter log group.
+func bindNFLog(group uint16) (int, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_NETFILTER)
+	if err != nil {
+		return -1, err
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		unix.Close(fd)
+		return -1, err
+	}
+	// Copy only the start of each packet. The mode is a packed struct of the big-endian copy range, the copy
+	// mode, and padding.
+	mode := make([]byte, 6)
+	binary.BigEndian.PutUint32(mode, nflogCopyRange)
+	mode[4] = nfulnlCopyPacket
+	for seq, attr := range [][]byte{
+		netlinkAttr(nfulaCfgCmd, []byte{nfulnlCfgCmdBind}),
+		netlinkAttr(nfulaCfgMode, mode),
+	} {
+		if err := sendNFLogConfig(fd, group, uint32(seq+1), attr); err != nil {
+			unix.Close(fd)
+			return -1, err
+		}
+	}
+	return fd, nil
+}
+
+// sendNFLogConfig sends a config message for the netfilter log group, and waits for it to be acknowledged.
+func sendNFLogConfig(fd int, group uint16, seq uint32, attr []byte) error {
+	msg := make([]byte, unix.SizeofNlMsghdr+nl.SizeofNfgenmsg, unix.SizeofNlMsghdr+nl.SizeofNfgenmsg+len(attr))
+	msg = append(msg, attr...)
+	nl.NativeEndian().PutUint32(msg[0:4], uint32(len(msg)))
+	nl.NativeEndian().PutUint16(msg[4:6], unix.NFNL_SUBSYS_ULOG<<8|nfulnlMsgConfig)
+	nl.NativeEndian().PutUint16(msg[6:8], unix.NLM_F_REQUEST|unix.NLM_F_ACK)
+	nl.NativeEndian().PutUint32(msg[8:12], seq)
+	// The netfilter header holds the address family, version, and the big-endian group number.
+	msg[unix.SizeofNlMsghdr] = unix.AF_UNSPEC
+	msg[unix.SizeofNlMsghdr+1] = unix.NFNETLINK_V0
+	binary.BigEndian.PutUint16(msg[unix.SizeofNlMsghdr+2:], group)
+	if err := unix.Sendto(fd, msg, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != seq || m.Header.Type != unix.NLMSG_ERROR {
+				continue
+			}
+			if len(m.Data) < 4 {
+				return errors.New("short netlink error message")
+			}
+			if errno := int32(nl.NativeEndian().Uint32(m.Data[0:4])); errno != 0 {
+				return syscall.Errno(-errno)
+			}
+			return nil
+		}
+	}
+}
+
+// netlinkAttr encodes a netlink attribute, padded to a multiple of 4 bytes.
+func netlinkAttr(attrType uint16, value []byte) []byte {
+	length := unix.SizeofNlAttr + len(value)
+	attr := make([]byte, (length+unix.NLA_ALIGNTO-1) & ^(unix.NLA_ALIGNTO-1))
+	nl.NativeEndian().PutUint16(attr[0:2], uint16(length))
+	nl.NativeEndian().PutUint16(attr[2:4], attrType)
+	copy(attr[unix.SizeofNlAttr:], value)
+	return attr
+}
+
+// parseNFLogPacket parses the addresses, protocol and ports of the packet in a netfilter log message.
+func parseNFLogPacket(data []byte) (*deniedConnection, error) {
+	if len(data) < nl.SizeofNfgenmsg {
+		return nil, errors.New("short netfilter log message")
+	}
+	attrs := data[nl.SizeofNfgenmsg:]
+	for len(attrs) >= unix.SizeofNlAttr {
+		length := int(nl.NativeEndian().Uint16(attrs[0:2]))
+		attrType := nl.NativeEndian().Uint16(attrs[2:4]) & nl.NLA_TYPE_MASK
+		if length < unix.SizeofNlAttr || length > len(attrs) {
+			return nil, errors.New("invalid netfilter log attribute")
+		}
+		if attrType == nfulaPayload {
+			return parsePacket(attrs[unix.SizeofNlAttr:length])
+		}
+		aligned := (length + unix.NLA_ALIGNTO - 1) & ^(unix.NLA_ALIGNTO - 1)
+		if aligned > len(attrs) {
+			break
+		}
+		attrs = attrs[aligned:]
+	}
+	return nil, errors.New("netfilter log message does not include packet")
+}
+
+// parsePacket parses the IP and transport headers of a packet. IPv6 extension headers are not parsed, so the
+// protocol of packets with extension headers is reported by its number, without ports.
+func parsePacket(packet []byte) (*deniedConnection, error) {
+	if len(packet) < 1 {
+		return nil, errors.New("empty packet")
+	}
+	conn := &deniedConnection{}
+	var protocol uint8
+	var transport []byte
+	switch packet[0] >> 4 {
+	case 4:
+		headerLen := int(packet[0]&0x0f) * 4
+		if len(packet) < 20 || headerLen < 20 || len(packet) < headerLen {
+			return nil, errors.New("short IPv4 packet")
+		}
+		protocol = packet[9]
+		conn.src, conn.dst = net.IP(packet[12:16]), net.IP(packet[16:20])
+		transport = packet[headerLen:]
+	case 6:
+		if len(packet) < 40 {
+			return nil, errors.New("short IPv6 packet")
+		}
+		protocol = packet[6]
+		conn.src, conn.dst = net.IP(packet[8:24]), net.IP(packet[24:40])
+		transport = packet[40:]
+	default:
+		return nil, fmt.Errorf("unknown IP version %d", packet[0]>>4)
+	}
+
+	switch protocol {
+	case unix.IPPROTO_TCP:
+		conn.protocol = "tcp"
+	case unix.IPPROTO_UDP:
+		conn.protocol = "udp"
+	case unix.IPPROTO_SCTP:
+		conn.protocol = "sctp"
+	case unix.IPPROTO_ICMP, unix.IPPROTO_ICMPV6:
+		conn.protocol = "icmp"
+		return conn, nil
+	default:
+		conn.protocol = fmt.Sprintf("protocol %d", protocol)
+		return conn, nil
+	}
+	// TCP, UDP and SCTP all start with the source and destination ports.
+	if len(transport) >= 4 {
+		conn.srcPort = binary.BigEndian.Uint16(transport[0:2])
+		conn.dstPort = binary.BigEndian.Uint16(transport[2:4])
+	}
+	return conn, nil
+}
+
+// endpoint formats the address and port, with the name of the pod that has the address if there is one.
+// Pods on the host network are not included, as they share their address with the node.
+func endpoint(pods cache.Indexer, ip net.IP, port uint16) string {
+	address := ip.String()
+	if port != 0 {
+		address = net.JoinHostPort(address, fmt.Sprint(port))
+	}
+	for _, obj := range pods.List() {
+		pod, ok := obj.(*v1core.Pod)
+		if !ok || pod.Spec.HostNetwork {
+			continue
+		}
+		for _, podIP := range pod.Status.PodIPs {
+			if ip.Equal(net.ParseIP(podIP.IP)) {
+				return fmt.Sprintf("%s (pod %s/%s)", address, pod.Namespace, pod.Name)
+			}
+		}
+	}
+	return address
+}
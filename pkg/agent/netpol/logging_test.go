@@ -0,0 +1,256 @@
+//go:build !windows
+// +build !windows
+
+package netpol
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+	v1core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// nfulaPrefix is the attribute type of the log prefix; it is included to check that other attributes are skipped.
+const nfulaPrefix = 10
+
+func ipv4Packet(protocol uint8, src, dst string, transport []byte) []byte {
+	packet := make([]byte, 20)
+	packet[0] = 0x45
+	packet[9] = protocol
+	copy(packet[12:16], net.ParseIP(src).To4())
+	copy(packet[16:20], net.ParseIP(dst).To4())
+	return append(packet, transport...)
+}
+
+func ipv6Packet(protocol uint8, src, dst string, transport []byte) []byte {
+	packet := make([]byte, 40)
+	packet[0] = 0x60
+	packet[6] = protocol
+	copy(packet[8:24], net.ParseIP(src).To16())
+	copy(packet[24:40], net.ParseIP(dst).To16())
+	return append(packet, transport...)
+}
+
+func ports(src, dst uint16) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint16(b[0:2], src)
+	binary.BigEndian.PutUint16(b[2:4], dst)
+	return b
+}
+
+func nflogMessage(attrs ...[]byte) []byte {
+	msg := []byte{unix.AF_INET, unix.NFNETLINK_V0, 0, nflogGroup}
+	for _, attr := range attrs {
+		msg = append(msg, attr...)
+	}
+	return msg
+}
+
+func Test_UnitNetlinkAttr(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrType uint16
+		value    []byte
+		wantLen  int
+	}{
+		{
+			name:     "aligned",
+			attrType: nfulaCfgMode,
+			value:    []byte{0, 0, 0, 128},
+			wantLen:  8,
+		},
+		{
+			name:     "padded",
+			attrType: nfulaCfgCmd,
+			value:    []byte{nfulnlCfgCmdBind},
+			wantLen:  8,
+		},
+		{
+			name:     "mode",
+			attrType: nfulaCfgMode,
+			value:    []byte{0, 0, 0, 128, nfulnlCopyPacket, 0},
+			wantLen:  12,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attr := netlinkAttr(tt.attrType, tt.value)
+			if len(attr) != tt.wantLen {
+				t.Fatalf("netlinkAttr() length = %d, want %d", len(attr), tt.wantLen)
+			}
+			if length := int(nl.NativeEndian().Uint16(attr[0:2])); length != unix.SizeofNlAttr+len(tt.value) {
+				t.Errorf("netlinkAttr() attribute length = %d, want %d", length, unix.SizeofNlAttr+len(tt.value))
+			}
+			if attrType := nl.NativeEndian().Uint16(attr[2:4]); attrType != tt.attrType {
+				t.Errorf("netlinkAttr() attribute type = %d, want %d", attrType, tt.attrType)
+			}
+			if value := attr[unix.SizeofNlAttr : unix.SizeofNlAttr+len(tt.value)]; !reflect.DeepEqual(value, tt.value) {
+				t.Errorf("netlinkAttr() value = %v, want %v", value, tt.value)
+			}
+		})
+	}
+}
+
+func Test_UnitParseNFLogPacket(t *testing.T) {
+	tcpPacket := ipv4Packet(unix.IPPROTO_TCP, "10.42.0.5", "10.42.1.7", ports(34567, 80))
+	tests := []struct {
+		name    string
+		data    []byte
+		want    *deniedConnection
+		wantErr bool
+	}{
+		{
+			name: "ipv4 tcp",
+			data: nflogMessage(netlinkAttr(nfulaPayload, tcpPacket)),
+			want: &deniedConnection{protocol: "tcp", src: net.ParseIP("10.42.0.5").To4(), dst: net.ParseIP("10.42.1.7").To4(), srcPort: 34567, dstPort: 80},
+		},
+		{
+			name: "payload after other attributes",
+			data: nflogMessage(netlinkAttr(nfulaPrefix, []byte("NWPLCY\x00")), netlinkAttr(nfulaPayload, tcpPacket)),
+			want: &deniedConnection{protocol: "tcp", src: net.ParseIP("10.42.0.5").To4(), dst: net.ParseIP("10.42.1.7").To4(), srcPort: 34567, dstPort: 80},
+		},
+		{
+			name: "ipv6 udp",
+			data: nflogMessage(netlinkAttr(nfulaPayload, ipv6Packet(unix.IPPROTO_UDP, "2001:cafe:42::5", "2001:cafe:42:1::7", ports(5353, 53)))),
+			want: &deniedConnection{protocol: "udp", src: net.ParseIP("2001:cafe:42::5"), dst: net.ParseIP("2001:cafe:42:1::7"), srcPort: 5353, dstPort: 53},
+		},
+		{
+			name: "ipv4 sctp",
+			data: nflogMessage(netlinkAttr(nfulaPayload, ipv4Packet(unix.IPPROTO_SCTP, "10.42.0.5", "10.42.1.7", ports(1000, 2000)))),
+			want: &deniedConnection{protocol: "sctp", src: net.ParseIP("10.42.0.5").To4(), dst: net.ParseIP("10.42.1.7").To4(), srcPort: 1000, dstPort: 2000},
+		},
+		{
+			name: "icmp",
+			data: nflogMessage(netlinkAttr(nfulaPayload, ipv4Packet(unix.IPPROTO_ICMP, "10.42.0.5", "10.42.1.7", []byte{8, 0, 0, 0}))),
+			want: &deniedConnection{protocol: "icmp", src: net.ParseIP("10.42.0.5").To4(), dst: net.ParseIP("10.42.1.7").To4()},
+		},
+		{
+			name: "other protocol",
+			data: nflogMessage(netlinkAttr(nfulaPayload, ipv4Packet(unix.IPPROTO_GRE, "10.42.0.5", "10.42.1.7", nil))),
+			want: &deniedConnection{protocol: "protocol 47", src: net.ParseIP("10.42.0.5").To4(), dst: net.ParseIP("10.42.1.7").To4()},
+		},
+		{
+			name: "truncated transport header",
+			data: nflogMessage(netlinkAttr(nfulaPayload, ipv4Packet(unix.IPPROTO_TCP, "10.42.0.5", "10.42.1.7", []byte{0x87}))),
+			want: &deniedConnection{protocol: "tcp", src: net.ParseIP("10.42.0.5").To4(), dst: net.ParseIP("10.42.1.7").To4()},
+		},
+		{
+			name: "ipv4 options",
+			data: nflogMessage(netlinkAttr(nfulaPayload, func() []byte {
+				packet := ipv4Packet(unix.IPPROTO_TCP, "10.42.0.5", "10.42.1.7", append([]byte{1, 1, 1, 0}, ports(34567, 443)...))
+				packet[0] = 0x46
+				return packet
+			}())),
+			want: &deniedConnection{protocol: "tcp", src: net.ParseIP("10.42.0.5").To4(), dst: net.ParseIP("10.42.1.7").To4(), srcPort: 34567, dstPort: 443},
+		},
+		{
+			name:    "short message",
+			data:    []byte{unix.AF_INET, unix.NFNETLINK_V0},
+			wantErr: true,
+		},
+		{
+			name:    "no payload",
+			data:    nflogMessage(netlinkAttr(nfulaPrefix, []byte("NWPLCY\x00"))),
+			wantErr: true,
+		},
+		{
+			name:    "invalid attribute length",
+			data:    append(nflogMessage(), 0xff, 0x00, nfulaPayload, 0x00),
+			wantErr: true,
+		},
+		{
+			name:    "short ipv4 packet",
+			data:    nflogMessage(netlinkAttr(nfulaPayload, tcpPacket[:16])),
+			wantErr: true,
+		},
+		{
+			name:    "short ipv6 packet",
+			data:    nflogMessage(netlinkAttr(nfulaPayload, ipv6Packet(unix.IPPROTO_TCP, "2001:cafe:42::5", "2001:cafe:42:1::7", nil)[:32])),
+			wantErr: true,
+		},
+		{
+			name:    "unknown ip version",
+			data:    nflogMessage(netlinkAttr(nfulaPayload, []byte{0x50, 0, 0, 0})),
+			wantErr: true,
+		},
+		{
+			name:    "empty packet",
+			data:    nflogMessage(netlinkAttr(nfulaPayload, nil)),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNFLogPacket(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNFLogPacket() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNFLogPacket() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitEndpoint(t *testing.T) {
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	pods.Add(&v1core.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status:     v1core.PodStatus{PodIPs: []v1core.PodIP{{IP: "10.42.0.5"}, {IP: "2001:cafe:42::5"}}},
+	})
+	pods.Add(&v1core.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "proxy", Namespace: "kube-system"},
+		Spec:       v1core.PodSpec{HostNetwork: true},
+		Status:     v1core.PodStatus{PodIPs: []v1core.PodIP{{IP: "192.168.1.10"}}},
+	})
+
+	tests := []struct {
+		name string
+		ip   net.IP
+		port uint16
+		want string
+	}{
+		{
+			name: "pod",
+			ip:   net.ParseIP("10.42.0.5").To4(),
+			port: 80,
+			want: "10.42.0.5:80 (pod default/web)",
+		},
+		{
+			name: "pod ipv6",
+			ip:   net.ParseIP("2001:cafe:42::5"),
+			port: 443,
+			want: "[2001:cafe:42::5]:443 (pod default/web)",
+		},
+		{
+			name: "no port",
+			ip:   net.ParseIP("10.42.0.5"),
+			want: "10.42.0.5 (pod default/web)",
+		},
+		{
+			name: "host network pod",
+			ip:   net.ParseIP("192.168.1.10"),
+			port: 10250,
+			want: "192.168.1.10:10250",
+		},
+		{
+			name: "unknown address",
+			ip:   net.ParseIP("10.42.3.9"),
+			port: 8080,
+			want: "10.42.3.9:8080",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := endpoint(pods, tt.ip, tt.port); got != tt.want {
+				t.Errorf("endpoint() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
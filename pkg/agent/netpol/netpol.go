@@ -83,6 +83,10 @@ func Run(ctx context.Context, nodeConfig *config.Node) error {
 	informerFactory.Start(stopCh)
 	informerFactory.WaitForCacheSync(stopCh)
 
+	if nodeConfig.AgentConfig.NetworkPolicyLogging {
+		go logDeniedConnections(ctx, podInformer.GetIndexer())
+	}
+
 	iptablesCmdHandlers := make(map[v1core.IPFamily]utils.IPTablesHandler, 2)
 	ipSetHandlers := make(map[v1core.IPFamily]utils.IPSetHandler, 2)
 
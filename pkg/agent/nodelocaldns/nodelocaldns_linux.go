@@ -0,0 +1,65 @@
+//go:build linux
+// +build linux
+
+// Package nodelocaldns manages the iptables rules required by the node-local DNS cache.
+package nodelocaldns
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/coreos/go-iptables/iptables"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ensureInterval is the interval at which the rules are checked, and restored if they have been flushed.
+const ensureInterval = time.Minute
+
+type rule struct {
+	table, chain string
+	spec         []string
+}
+
+// rules returns the rules that exempt DNS traffic to and from the node-local DNS cache from connection tracking,
+// so that queries are not dropped when the conntrack table is full or entries race, and accept the traffic
+// regardless of the host firewall policy.
+func rules(address string) []rule {
+	comment := []string{"-m", "comment", "--comment", version.Program + " node-local DNS cache"}
+	var rs []rule
+	for _, proto := range []string{"udp", "tcp"} {
+		rs = append(rs,
+			rule{"raw", "PREROUTING", append([]string{"-d", address, "-p", proto, "--dport", "53", "-j", "NOTRACK"}, comment...)},
+			rule{"raw", "OUTPUT", append([]string{"-d", address, "-p", proto, "--dport", "53", "-j", "NOTRACK"}, comment...)},
+			rule{"raw", "OUTPUT", append([]string{"-s", address, "-p", proto, "--sport", "53", "-j", "NOTRACK"}, comment...)},
+			rule{"filter", "INPUT", append([]string{"-d", address, "-p", proto, "--dport", "53", "-j", "ACCEPT"}, comment...)},
+			rule{"filter", "OUTPUT", append([]string{"-s", address, "-p", proto, "--sport", "53", "-j", "ACCEPT"}, comment...)},
+		)
+	}
+	return rs
+}
+
+// Run ensures that the iptables rules for the node-local DNS cache exist until the context is cancelled. The
+// iptables command is used, so the rules are created with the nftables backend if iptables-nft is in use.
+func Run(ctx context.Context, address net.IP) {
+	ipt, err := iptables.NewWithProtocol(iptables.ProtocolIPv4)
+	if err != nil {
+		logrus.Errorf("Failed to set up node-local DNS cache iptables rules: %v", err)
+		return
+	}
+	rs := rules(address.String())
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		for _, r := range rs {
+			// Rules are inserted at the top of the chain, so that they take effect before any reject rules.
+			exists, err := ipt.Exists(r.table, r.chain, r.spec...)
+			if err == nil && !exists {
+				err = ipt.Insert(r.table, r.chain, 1, r.spec...)
+			}
+			if err != nil {
+				logrus.Errorf("Failed to ensure node-local DNS cache iptables rule in %s/%s: %v", r.table, r.chain, err)
+			}
+		}
+	}, ensureInterval)
+}
@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package nodelocaldns
+
+import (
+	"context"
+	"net"
+)
+
+// Run does nothing, as the node-local DNS cache is not deployed to Windows nodes.
+func Run(ctx context.Context, address net.IP) {}
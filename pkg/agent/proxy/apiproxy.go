@@ -16,9 +16,11 @@ type Proxy interface {
 	Update(addresses []string)
 	SetAPIServerPort(ctx context.Context, port int, isIPv6 bool) error
 	SetSupervisorDefault(address string)
+	SetSupervisorAdvertiseAddresses(addresses map[string]string)
 	IsSupervisorLBEnabled() bool
 	SupervisorURL() string
 	SupervisorAddresses() []string
+	APIServerAddresses() []string
 	APIServerURL() string
 	IsAPIServerLBEnabled() bool
 }
@@ -67,11 +69,14 @@ type proxy struct {
 	apiServerEnabled bool
 
 	apiServerURL              string
+	apiServerPort             string
+	apiServerAddresses        []string
 	supervisorURL             string
 	supervisorPort            string
 	initialSupervisorURL      string
 	fallbackSupervisorAddress string
 	supervisorAddresses       []string
+	supervisorAdvertised      map[string]string
 
 	apiServerLB  *loadbalancer.LoadBalancer
 	supervisorLB *loadbalancer.LoadBalancer
@@ -91,6 +96,7 @@ func (p *proxy) Update(addresses []string) {
 		p.supervisorLB.Update(supervisorAddresses)
 	}
 	p.supervisorAddresses = supervisorAddresses
+	p.apiServerAddresses = apiServerAddresses
 }
 
 func (p *proxy) setSupervisorPort(addresses []string) []string {
@@ -101,6 +107,10 @@ func (p *proxy) setSupervisorPort(addresses []string) []string {
 			logrus.Errorf("Failed to parse address %s, dropping: %v", address, err)
 			continue
 		}
+		if advertised, ok := p.supervisorAdvertised[h]; ok {
+			newAddresses = append(newAddresses, advertised)
+			continue
+		}
 		newAddresses = append(newAddresses, sysnet.JoinHostPort(h, p.supervisorPort))
 	}
 	return newAddresses
@@ -119,6 +129,7 @@ func (p *proxy) SetAPIServerPort(ctx context.Context, port int, isIPv6 bool) err
 	u.Host = sysnet.JoinHostPort(u.Hostname(), strconv.Itoa(port))
 
 	p.apiServerURL = u.String()
+	p.apiServerPort = u.Port()
 	p.apiServerEnabled = true
 
 	if p.lbEnabled && p.apiServerLB == nil {
@@ -159,6 +170,13 @@ func (p *proxy) SetSupervisorDefault(address string) {
 	}
 }
 
+// SetSupervisorAdvertiseAddresses sets the supervisor addresses advertised by servers, keyed by the host of
+// their apiserver address. These are used instead of the apiserver host and supervisor port, when the
+// supervisor addresses are next updated.
+func (p *proxy) SetSupervisorAdvertiseAddresses(addresses map[string]string) {
+	p.supervisorAdvertised = addresses
+}
+
 func (p *proxy) IsSupervisorLBEnabled() bool {
 	return p.supervisorLB != nil
 }
@@ -174,6 +192,24 @@ func (p *proxy) SupervisorAddresses() []string {
 	return []string{p.fallbackSupervisorAddress}
 }
 
+// APIServerAddresses returns the addresses of the apiservers, which are the supervisor addresses unless the
+// apiserver port has been set.
+func (p *proxy) APIServerAddresses() []string {
+	if !p.apiServerEnabled {
+		return p.SupervisorAddresses()
+	}
+	if len(p.apiServerAddresses) > 0 {
+		return p.apiServerAddresses
+	}
+	var addresses []string
+	for _, address := range p.SupervisorAddresses() {
+		if h, _, err := sysnet.SplitHostPort(address); err == nil {
+			addresses = append(addresses, sysnet.JoinHostPort(h, p.apiServerPort))
+		}
+	}
+	return addresses
+}
+
 func (p *proxy) APIServerURL() string {
 	return p.apiServerURL
 }
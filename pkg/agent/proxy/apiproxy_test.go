@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func Test_UnitSupervisorAddresses(t *testing.T) {
+	tests := []struct {
+		name          string
+		apiServerPort int
+		advertised    map[string]string
+		addresses     []string
+		want          []string
+	}{
+		{
+			name:      "shared port",
+			addresses: []string{"10.0.0.1:6443", "10.0.0.2:6443"},
+			want:      []string{"10.0.0.1:6443", "10.0.0.2:6443"},
+		},
+		{
+			name:          "separate port",
+			apiServerPort: 6443,
+			addresses:     []string{"10.0.0.1:6443", "10.0.0.2:6443"},
+			want:          []string{"10.0.0.1:9345", "10.0.0.2:9345"},
+		},
+		{
+			name:          "advertised supervisor address",
+			apiServerPort: 6443,
+			advertised:    map[string]string{"10.0.0.2": "192.168.1.20:9345"},
+			addresses:     []string{"10.0.0.1:6443", "10.0.0.2:6443"},
+			want:          []string{"10.0.0.1:9345", "192.168.1.20:9345"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			p, err := NewSupervisorProxy(ctx, false, t.TempDir(), "https://10.0.0.1:9345", 0, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.apiServerPort != 0 {
+				if err := p.SetAPIServerPort(ctx, tt.apiServerPort, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p.SetSupervisorAdvertiseAddresses(tt.advertised)
+			p.Update(tt.addresses)
+			if got := p.SupervisorAddresses(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SupervisorAddresses() = %v, want %v", got, tt.want)
+			}
+			if got := p.APIServerAddresses(); !reflect.DeepEqual(got, tt.addresses) {
+				t.Errorf("APIServerAddresses() = %v, want %v", got, tt.addresses)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package reboot
+
+import (
+	"context"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationclient "k8s.io/client-go/kubernetes/typed/coordination/v1"
+)
+
+// LeaseName is the name of the lease in the kube-system namespace that is held by the node that is being
+// drained and rebooted.
+var LeaseName = version.Program + "-reboot"
+
+// LeasePath is the supervisor path that nodes acquire the reboot lease from with POST, and release it with
+// DELETE. Nodes are only allowed to access their own lease in the kube-node-lease namespace, so the reboot
+// lease is managed by the server on their behalf.
+var LeasePath = "/v1-" + version.Program + "/reboot-lease"
+
+// LeaseDuration is the time after which the reboot lease may be taken over by another node, if the node
+// holding it does not come back up and release it.
+var LeaseDuration = 30 * time.Minute
+
+// AcquireLease acquires or renews the reboot lease for the node, unless it is held by another node and has not
+// expired. The holder of the lease is returned; the lease was acquired if this is the given node.
+func AcquireLease(ctx context.Context, leases coordinationclient.LeaseInterface, nodeName string, now time.Time) (string, error) {
+	lease, err := leases.Get(ctx, LeaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      LeaseName,
+				Namespace: metav1.NamespaceSystem,
+			},
+		}
+		setHolder(lease, nodeName, now)
+		if _, err := leases.Create(ctx, lease, metav1.CreateOptions{}); err != nil {
+			return "", err
+		}
+		return nodeName, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if holder := leaseHolder(lease); holder != "" && holder != nodeName && !leaseExpired(lease, now) {
+		return holder, nil
+	}
+	lease = lease.DeepCopy()
+	setHolder(lease, nodeName, now)
+	if _, err := leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return "", err
+	}
+	return nodeName, nil
+}
+
+// ReleaseLease releases the reboot lease, if it is held by the node.
+func ReleaseLease(ctx context.Context, leases coordinationclient.LeaseInterface, nodeName string) error {
+	lease, err := leases.Get(ctx, LeaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if leaseHolder(lease) != nodeName {
+		return nil
+	}
+	lease = lease.DeepCopy()
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.AcquireTime = nil
+	lease.Spec.RenewTime = nil
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+func leaseHolder(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second).Before(now)
+}
+
+// setHolder sets the node as the holder of the lease, keeping the acquire time if it already held it.
+func setHolder(lease *coordinationv1.Lease, nodeName string, now time.Time) {
+	renewTime := metav1.NewMicroTime(now)
+	duration := int32(LeaseDuration / time.Second)
+	if leaseHolder(lease) != nodeName || lease.Spec.AcquireTime == nil {
+		lease.Spec.AcquireTime = &renewTime
+	}
+	lease.Spec.HolderIdentity = &nodeName
+	lease.Spec.RenewTime = &renewTime
+	lease.Spec.LeaseDurationSeconds = &duration
+}
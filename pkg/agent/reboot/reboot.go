@@ -0,0 +1,210 @@
+package reboot
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/agent/drain"
+	"github.com/k3s-io/k3s/pkg/agent/proxy"
+	"github.com/k3s-io/k3s/pkg/clientaccess"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// readyPollInterval is the interval at which the node is checked for the Ready condition, before the reboot
+// lease is released.
+var readyPollInterval = 5 * time.Second
+
+// Lease is the lock that ensures that only one node in the cluster is drained and rebooted at a time.
+type Lease interface {
+	// Acquire acquires or renews the lease, returning an error if it is held by another node.
+	Acquire() error
+	// Release releases the lease, if it is held by this node.
+	Release() error
+}
+
+// Coordinator reboots the node when a sentinel file indicates that a reboot is required, for example after OS
+// packages have been upgraded. The node is cordoned and drained before it is rebooted, and is uncordoned when
+// the agent starts again.
+type Coordinator struct {
+	// Sentinel is the path of the file that indicates that the node requires a reboot.
+	Sentinel string
+	// Command is the command that is run to reboot the node.
+	Command []string
+	// Period is the interval at which the sentinel file is checked for.
+	Period time.Duration
+
+	drainer   *drain.Drainer
+	reboot    func() error
+	rebooting bool
+	startTime time.Time
+	// renewInterval is the interval at which the lease is renewed while the node is draining, so that it does
+	// not expire if the drain takes longer than the lease duration.
+	renewInterval time.Duration
+}
+
+// New creates a coordinator that checks for the sentinel file at the given interval, and drains the node for up
+// to the drain timeout before rebooting it with the command.
+func New(sentinel string, command []string, period, drainTimeout time.Duration) *Coordinator {
+	c := &Coordinator{
+		Sentinel:  sentinel,
+		Command:   command,
+		Period:    period,
+		drainer:   drain.New(drainTimeout, -1),
+		startTime: time.Now().Truncate(time.Second),
+	}
+	c.renewInterval = LeaseDuration / 3
+	c.reboot = c.runCommand
+	return c
+}
+
+// Run checks for the sentinel file until the context is cancelled. The lease is released once the node reports
+// Ready after the agent starts, as the node holding it is running again, either after a reboot or after the agent
+// was restarted while draining.
+func (c *Coordinator) Run(ctx context.Context, lease Lease, client kubernetes.Interface, nodeName string) {
+	c.drainer.Register(client, nodeName)
+	if err := c.waitForReady(ctx, client, nodeName); err != nil {
+		return
+	}
+	if err := lease.Release(); err != nil {
+		logrus.Warnf("Failed to release reboot lease: %v", err)
+	}
+
+	ticker := time.NewTicker(c.Period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := c.check(ctx, lease, client, nodeName); err != nil {
+			logrus.Errorf("Failed to reboot node: %v", err)
+		}
+	}
+}
+
+// check drains and reboots the node if the sentinel file exists and the lease can be acquired. If the node
+// cannot be drained or rebooted, it is uncordoned and the lease is released, so that other nodes can proceed.
+func (c *Coordinator) check(ctx context.Context, lease Lease, client kubernetes.Interface, nodeName string) error {
+	if c.rebooting {
+		return nil
+	}
+	if _, err := os.Stat(c.Sentinel); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := lease.Acquire(); err != nil {
+		logrus.Infof("Node requires a reboot, waiting to acquire the reboot lease: %v", err)
+		return nil
+	}
+
+	renewCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go c.renewLease(renewCtx, lease)
+
+	logrus.Infof("Node requires a reboot as %s exists; draining node before rebooting", c.Sentinel)
+	err := c.drainer.Drain(ctx)
+	if err == nil {
+		logrus.Infof("Rebooting node with '%s'", strings.Join(c.Command, " "))
+		if err = c.reboot(); err == nil {
+			c.rebooting = true
+			return nil
+		}
+		err = errors.Wrap(err, "reboot command failed")
+	}
+	if uncordonErr := drain.Uncordon(ctx, client, nodeName); uncordonErr != nil {
+		logrus.Warnf("Failed to uncordon node: %v", uncordonErr)
+	}
+	cancel()
+	if releaseErr := lease.Release(); releaseErr != nil {
+		logrus.Warnf("Failed to release reboot lease: %v", releaseErr)
+	}
+	return err
+}
+
+// renewLease renews the reboot lease until the context is cancelled.
+func (c *Coordinator) renewLease(ctx context.Context, lease Lease) {
+	ticker := time.NewTicker(c.renewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := lease.Acquire(); err != nil {
+			logrus.Warnf("Failed to renew reboot lease: %v", err)
+		}
+	}
+}
+
+// waitForReady waits until the node reports Ready with a heartbeat that is more recent than the coordinator
+// start time, as the condition may still be set from before the node was rebooted.
+func (c *Coordinator) waitForReady(ctx context.Context, client kubernetes.Interface, nodeName string) error {
+	return wait.PollImmediateUntilWithContext(ctx, readyPollInterval, func(ctx context.Context) (bool, error) {
+		node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			logrus.Debugf("Waiting for node to be Ready before releasing reboot lease: %v", err)
+			return false, nil
+		}
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue && !cond.LastHeartbeatTime.Time.Before(c.startTime) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func (c *Coordinator) runCommand() error {
+	if len(c.Command) == 0 {
+		return errors.New("no reboot command configured")
+	}
+	if output, err := exec.Command(c.Command[0], c.Command[1:]...).CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "%s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// supervisorLease acquires and releases the reboot lease through the supervisor.
+type supervisorLease struct {
+	nodeConfig *config.Node
+	proxy      proxy.Proxy
+}
+
+// NewSupervisorLease returns a lease that is acquired and released through the supervisor.
+func NewSupervisorLease(nodeConfig *config.Node, proxy proxy.Proxy) Lease {
+	return &supervisorLease{nodeConfig: nodeConfig, proxy: proxy}
+}
+
+func (l *supervisorLease) info() (*clientaccess.Info, error) {
+	withCert := clientaccess.WithClientCertificate(l.nodeConfig.AgentConfig.ClientKubeletCert, l.nodeConfig.AgentConfig.ClientKubeletKey)
+	return clientaccess.ParseAndValidateToken(l.proxy.SupervisorURL(), l.nodeConfig.Token, withCert)
+}
+
+func (l *supervisorLease) Acquire() error {
+	info, err := l.info()
+	if err != nil {
+		return err
+	}
+	return info.Post(LeasePath, nil)
+}
+
+func (l *supervisorLease) Release() error {
+	info, err := l.info()
+	if err != nil {
+		return err
+	}
+	return info.Delete(LeasePath)
+}
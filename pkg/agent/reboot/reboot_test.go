@@ -0,0 +1,282 @@
+package reboot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/agent/drain"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testLease(holder string, renewTime time.Time) *coordinationv1.Lease {
+	duration := int32(LeaseDuration / time.Second)
+	renew := metav1.NewMicroTime(renewTime)
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LeaseName,
+			Namespace: metav1.NamespaceSystem,
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			AcquireTime:          &renew,
+			RenewTime:            &renew,
+			LeaseDurationSeconds: &duration,
+		},
+	}
+}
+
+func Test_UnitAcquireLease(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name       string
+		lease      *coordinationv1.Lease
+		wantHolder string
+	}{
+		{
+			name:       "no lease",
+			wantHolder: "node1",
+		},
+		{
+			name:       "released lease",
+			lease:      testLease("", now.Add(-time.Minute)),
+			wantHolder: "node1",
+		},
+		{
+			name:       "held by this node",
+			lease:      testLease("node1", now.Add(-time.Minute)),
+			wantHolder: "node1",
+		},
+		{
+			name:       "held by another node",
+			lease:      testLease("node2", now.Add(-time.Minute)),
+			wantHolder: "node2",
+		},
+		{
+			name:       "expired lease held by another node",
+			lease:      testLease("node2", now.Add(-LeaseDuration-time.Minute)),
+			wantHolder: "node1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []runtime.Object{}
+			if tt.lease != nil {
+				objects = append(objects, tt.lease)
+			}
+			ctx := context.Background()
+			leases := fake.NewSimpleClientset(objects...).CoordinationV1().Leases(metav1.NamespaceSystem)
+
+			holder, err := AcquireLease(ctx, leases, "node1", now)
+			if err != nil {
+				t.Fatalf("AcquireLease() error = %v", err)
+			}
+			if holder != tt.wantHolder {
+				t.Errorf("AcquireLease() holder = %s, want %s", holder, tt.wantHolder)
+			}
+
+			// The lease is only released by the node holding it.
+			if err := ReleaseLease(ctx, leases, "node1"); err != nil {
+				t.Fatalf("ReleaseLease() error = %v", err)
+			}
+			lease, err := leases.Get(ctx, LeaseName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get lease: %v", err)
+			}
+			wantHolder := ""
+			if tt.wantHolder != "node1" {
+				wantHolder = tt.wantHolder
+			}
+			if got := leaseHolder(lease); got != wantHolder {
+				t.Errorf("lease holder after release = %s, want %s", got, wantHolder)
+			}
+		})
+	}
+}
+
+type testLeaseLock struct {
+	mu         sync.Mutex
+	acquireErr error
+	acquires   int
+	released   bool
+}
+
+func (l *testLeaseLock) Acquire() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.acquireErr != nil {
+		return l.acquireErr
+	}
+	l.acquires++
+	return nil
+}
+
+func (l *testLeaseLock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.released = true
+	return nil
+}
+
+func Test_UnitCoordinatorCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		sentinel      bool
+		acquireErr    error
+		rebootErr     error
+		wantErr       bool
+		wantReboot    bool
+		wantCordoned  bool
+		wantReleased  bool
+		wantRebooting bool
+		wantRenewed   bool
+		slowReboot    bool
+	}{
+		{
+			name: "no reboot required",
+		},
+		{
+			name:       "lease held by another node",
+			sentinel:   true,
+			acquireErr: errors.New("reboot lease is held by node node2"),
+		},
+		{
+			name:          "reboot required",
+			sentinel:      true,
+			wantReboot:    true,
+			wantCordoned:  true,
+			wantRebooting: true,
+		},
+		{
+			name:          "lease renewed while draining",
+			sentinel:      true,
+			slowReboot:    true,
+			wantReboot:    true,
+			wantCordoned:  true,
+			wantRebooting: true,
+			wantRenewed:   true,
+		},
+		{
+			name:         "reboot command fails",
+			sentinel:     true,
+			rebootErr:    errors.New("reboot failed"),
+			wantErr:      true,
+			wantReboot:   true,
+			wantReleased: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentinel := filepath.Join(t.TempDir(), "reboot-required")
+			if tt.sentinel {
+				if err := os.WriteFile(sentinel, nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			client := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
+			lease := &testLeaseLock{acquireErr: tt.acquireErr}
+
+			rebooted := false
+			c := New(sentinel, []string{"reboot"}, time.Minute, 5*time.Second)
+			c.renewInterval = 10 * time.Millisecond
+			c.reboot = func() error {
+				rebooted = true
+				if tt.slowReboot {
+					time.Sleep(100 * time.Millisecond)
+				}
+				return tt.rebootErr
+			}
+			c.drainer.Register(client, "node1")
+
+			ctx := context.Background()
+			if err := c.check(ctx, lease, client, "node1"); (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if rebooted != tt.wantReboot {
+				t.Errorf("rebooted = %v, want %v", rebooted, tt.wantReboot)
+			}
+			if lease.released != tt.wantReleased {
+				t.Errorf("lease released = %v, want %v", lease.released, tt.wantReleased)
+			}
+			lease.mu.Lock()
+			renewed := lease.acquires > 1
+			lease.mu.Unlock()
+			if renewed != tt.wantRenewed {
+				t.Errorf("lease renewed = %v, want %v", renewed, tt.wantRenewed)
+			}
+			if c.rebooting != tt.wantRebooting {
+				t.Errorf("rebooting = %v, want %v", c.rebooting, tt.wantRebooting)
+			}
+
+			node, err := client.CoreV1().Nodes().Get(ctx, "node1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get node: %v", err)
+			}
+			if node.Spec.Unschedulable != tt.wantCordoned {
+				t.Errorf("node unschedulable = %v, want %v", node.Spec.Unschedulable, tt.wantCordoned)
+			}
+			if _, drained := node.Annotations[drain.DrainedAnnotation]; drained != tt.wantCordoned {
+				t.Errorf("node drained annotation = %v, want %v", drained, tt.wantCordoned)
+			}
+		})
+	}
+}
+
+func Test_UnitCoordinatorWaitForReady(t *testing.T) {
+	readyPollInterval = 10 * time.Millisecond
+	startTime := time.Now().Truncate(time.Second)
+	tests := []struct {
+		name      string
+		status    corev1.ConditionStatus
+		heartbeat time.Time
+		wantErr   bool
+	}{
+		{
+			name:      "ready after start",
+			status:    corev1.ConditionTrue,
+			heartbeat: startTime.Add(time.Second),
+		},
+		{
+			name:      "ready before reboot",
+			status:    corev1.ConditionTrue,
+			heartbeat: startTime.Add(-time.Minute),
+			wantErr:   true,
+		},
+		{
+			name:      "not ready",
+			status:    corev1.ConditionFalse,
+			heartbeat: startTime.Add(time.Second),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{{
+						Type:              corev1.NodeReady,
+						Status:            tt.status,
+						LastHeartbeatTime: metav1.NewTime(tt.heartbeat),
+					}},
+				},
+			}
+			c := New("", nil, time.Minute, time.Second)
+			c.startTime = startTime
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			if err := c.waitForReady(ctx, fake.NewSimpleClientset(node), "node1"); (err != nil) != tt.wantErr {
+				t.Errorf("waitForReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
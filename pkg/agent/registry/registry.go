@@ -0,0 +1,579 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/leases"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/pkg/cri/constants"
+	"github.com/containerd/containerd/reference/docker"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// uploadTimeout is the time after which incomplete blob uploads are aborted, and after which the
+	// leases protecting uploaded content that is not referenced by an image expire.
+	uploadTimeout = time.Hour
+
+	maxManifestSize = 4 << 20
+)
+
+// UnpackFunc unpacks an image into the snapshotter, so that containers can be created from it.
+type UnpackFunc func(ctx context.Context, image images.Image) error
+
+// Registry serves the OCI distribution API, storing pushed content in the containerd content store and creating
+// images in the containerd image store. Pushed images can be used by pods as soon as the push completes, without
+// being pulled from an external registry.
+type Registry struct {
+	content content.Store
+	images  images.Store
+	leases  leases.Manager
+	unpack  UnpackFunc
+	name    string
+	auth    string
+
+	mu      sync.Mutex
+	uploads map[string]*upload
+}
+
+type upload struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	ref     string
+	writer  content.Writer
+	offset  int64
+	updated time.Time
+}
+
+type errorResponse struct {
+	Errors []errorDetail `json:"errors"`
+}
+
+type errorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// New returns a Registry backed by the content and image stores. Images are stored under the registry name, which
+// is the address that pods use to reference pushed images, for example localhost:5000. Clients must authenticate
+// with credentials in username:password format for all requests, including pushes. If a lease manager is provided,
+// uploaded content is protected from garbage collection until it is referenced by an image. If an unpack function
+// is provided, it is called for each tagged image.
+func New(cs content.Store, is images.Store, lm leases.Manager, unpack UnpackFunc, name, credentials string) (*Registry, error) {
+	username, password, ok := strings.Cut(credentials, ":")
+	if !ok || username == "" || password == "" {
+		return nil, errors.New("registry credentials must be in username:password format")
+	}
+	if named, err := docker.ParseNormalizedNamed(name + "/image"); err != nil || docker.Domain(named) != name {
+		return nil, fmt.Errorf("invalid registry name %q", name)
+	}
+	return &Registry{
+		content: cs,
+		images:  is,
+		leases:  lm,
+		unpack:  unpack,
+		name:    name,
+		auth:    "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials)),
+		uploads: map[string]*upload{},
+	}, nil
+}
+
+// ListenAndServe accepts registry connections on the address until the context is cancelled.
+func (r *Registry) ListenAndServe(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		ticker := time.NewTicker(uploadTimeout / 6)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				server.Close()
+				return
+			case <-ticker.C:
+				r.expireUploads(time.Now().Add(-uploadTimeout))
+			}
+		}
+	}()
+	logrus.Infof("Serving local registry on %s", listener.Addr())
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (r *Registry) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	resp.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+	if subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte(r.auth)) != 1 {
+		resp.Header().Set("WWW-Authenticate", `Basic realm="`+version.Program+` registry"`)
+		writeError(resp, http.StatusUnauthorized, "UNAUTHORIZED", "authentication required")
+		return
+	}
+
+	ctx := namespaces.WithNamespace(req.Context(), constants.K8sContainerdNamespace)
+	path := req.URL.Path
+	if path == "/v2" || path == "/v2/" {
+		resp.WriteHeader(http.StatusOK)
+		return
+	}
+	if !strings.HasPrefix(path, "/v2/") {
+		writeError(resp, http.StatusNotFound, "NOT_FOUND", "not found")
+		return
+	}
+	path = strings.TrimPrefix(path, "/v2/")
+
+	if name, ok := strings.CutSuffix(path, "/tags/list"); ok {
+		r.tags(ctx, resp, req, name)
+	} else if i := strings.LastIndex(path, "/blobs/uploads/"); i > 0 {
+		r.blobUpload(resp, req, path[:i], path[i+len("/blobs/uploads/"):])
+	} else if i := strings.LastIndex(path, "/blobs/"); i > 0 {
+		r.blob(ctx, resp, req, path[i+len("/blobs/"):])
+	} else if i := strings.LastIndex(path, "/manifests/"); i > 0 {
+		r.manifest(ctx, resp, req, path[:i], path[i+len("/manifests/"):])
+	} else {
+		writeError(resp, http.StatusNotFound, "NOT_FOUND", "not found")
+	}
+}
+
+// imageName returns the name of the image in the image store for a repository. Images are named after the
+// configured registry name, and not the address used by the client, so that clients cannot create or replace
+// images of other registries.
+func (r *Registry) imageName(repository string) (string, error) {
+	name := r.name + "/" + repository
+	if _, err := docker.ParseNormalizedNamed(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (r *Registry) tags(ctx context.Context, resp http.ResponseWriter, req *http.Request, repository string) {
+	if req.Method != http.MethodGet {
+		writeError(resp, http.StatusMethodNotAllowed, "UNSUPPORTED", "unsupported method")
+		return
+	}
+	name, err := r.imageName(repository)
+	if err != nil {
+		writeError(resp, http.StatusBadRequest, "NAME_INVALID", err.Error())
+		return
+	}
+	imageList, err := r.images.List(ctx)
+	if err != nil {
+		writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	tags := []string{}
+	for _, image := range imageList {
+		if tag, ok := strings.CutPrefix(image.Name, name+":"); ok {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	resp.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(resp).Encode(map[string]interface{}{"name": repository, "tags": tags})
+}
+
+func (r *Registry) blob(ctx context.Context, resp http.ResponseWriter, req *http.Request, ref string) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		writeError(resp, http.StatusMethodNotAllowed, "UNSUPPORTED", "unsupported method")
+		return
+	}
+	dgst, err := digest.Parse(ref)
+	if err != nil {
+		writeError(resp, http.StatusBadRequest, "DIGEST_INVALID", err.Error())
+		return
+	}
+	ra, err := r.content.ReaderAt(ctx, ocispec.Descriptor{Digest: dgst})
+	if errdefs.IsNotFound(err) {
+		writeError(resp, http.StatusNotFound, "BLOB_UNKNOWN", "blob unknown to registry")
+		return
+	} else if err != nil {
+		writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	defer ra.Close()
+	resp.Header().Set("Content-Type", "application/octet-stream")
+	resp.Header().Set("Docker-Content-Digest", dgst.String())
+	resp.Header().Set("ETag", `"`+dgst.String()+`"`)
+	http.ServeContent(resp, req, "", time.Time{}, io.NewSectionReader(ra, 0, ra.Size()))
+}
+
+func (r *Registry) blobUpload(resp http.ResponseWriter, req *http.Request, repository, id string) {
+	if _, err := r.imageName(repository); err != nil {
+		writeError(resp, http.StatusBadRequest, "NAME_INVALID", err.Error())
+		return
+	}
+	if id == "" {
+		if req.Method != http.MethodPost {
+			writeError(resp, http.StatusMethodNotAllowed, "UNSUPPORTED", "unsupported method")
+			return
+		}
+		r.startUpload(resp, req, repository)
+		return
+	}
+
+	r.mu.Lock()
+	u := r.uploads[id]
+	r.mu.Unlock()
+	if u == nil {
+		writeError(resp, http.StatusNotFound, "BLOB_UPLOAD_UNKNOWN", "blob upload unknown to registry")
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.updated = time.Now()
+
+	switch req.Method {
+	case http.MethodGet:
+		writeUploadStatus(resp, http.StatusNoContent, repository, id, u.offset)
+	case http.MethodPatch:
+		if err := u.write(req.Body); err != nil {
+			writeError(resp, http.StatusInternalServerError, "BLOB_UPLOAD_INVALID", err.Error())
+			return
+		}
+		writeUploadStatus(resp, http.StatusAccepted, repository, id, u.offset)
+	case http.MethodPut:
+		dgst, err := digest.Parse(req.URL.Query().Get("digest"))
+		if err != nil {
+			writeError(resp, http.StatusBadRequest, "DIGEST_INVALID", err.Error())
+			return
+		}
+		if err := u.write(req.Body); err != nil {
+			writeError(resp, http.StatusInternalServerError, "BLOB_UPLOAD_INVALID", err.Error())
+			return
+		}
+		r.removeUpload(id)
+		if err := u.commit(dgst); err != nil {
+			writeError(resp, http.StatusBadRequest, "DIGEST_INVALID", err.Error())
+			return
+		}
+		writeBlobCreated(resp, repository, dgst)
+	case http.MethodDelete:
+		r.removeUpload(id)
+		u.abort(r.content)
+		resp.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(resp, http.StatusMethodNotAllowed, "UNSUPPORTED", "unsupported method")
+	}
+}
+
+// startUpload starts a blob upload. Cross-repository mounts of existing blobs succeed immediately, as content is
+// shared between all repositories. If a digest is provided, the request body is the complete blob.
+func (r *Registry) startUpload(resp http.ResponseWriter, req *http.Request, repository string) {
+	query := req.URL.Query()
+	if mount := query.Get("mount"); mount != "" {
+		if dgst, err := digest.Parse(mount); err == nil {
+			ctx := namespaces.WithNamespace(req.Context(), constants.K8sContainerdNamespace)
+			if _, err := r.content.Info(ctx, dgst); err == nil {
+				writeBlobCreated(resp, repository, dgst)
+				return
+			}
+		}
+	}
+
+	ctx, err := r.withLease(namespaces.WithNamespace(context.Background(), constants.K8sContainerdNamespace))
+	if err != nil {
+		writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	id, err := randomID()
+	if err != nil {
+		writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	ref := version.Program + "-registry-" + id
+	writer, err := content.OpenWriter(ctx, r.content, content.WithRef(ref))
+	if err != nil {
+		writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	u := &upload{ctx: ctx, ref: ref, writer: writer, updated: time.Now()}
+
+	if d := query.Get("digest"); d != "" {
+		dgst, err := digest.Parse(d)
+		if err != nil {
+			u.abort(r.content)
+			writeError(resp, http.StatusBadRequest, "DIGEST_INVALID", err.Error())
+			return
+		}
+		if err := u.write(req.Body); err != nil {
+			u.abort(r.content)
+			writeError(resp, http.StatusInternalServerError, "BLOB_UPLOAD_INVALID", err.Error())
+			return
+		}
+		if err := u.commit(dgst); err != nil {
+			writeError(resp, http.StatusBadRequest, "DIGEST_INVALID", err.Error())
+			return
+		}
+		writeBlobCreated(resp, repository, dgst)
+		return
+	}
+
+	r.mu.Lock()
+	r.uploads[id] = u
+	r.mu.Unlock()
+	writeUploadStatus(resp, http.StatusAccepted, repository, id, 0)
+}
+
+func (r *Registry) removeUpload(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.uploads, id)
+}
+
+// expireUploads aborts uploads that have not been updated since the given time.
+func (r *Registry) expireUploads(before time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, u := range r.uploads {
+		if u.mu.TryLock() {
+			if u.updated.Before(before) {
+				logrus.Debugf("Aborting expired local registry upload %s", id)
+				delete(r.uploads, id)
+				u.abort(r.content)
+			}
+			u.mu.Unlock()
+		}
+	}
+}
+
+func (u *upload) write(body io.Reader) error {
+	n, err := io.Copy(u.writer, body)
+	u.offset += n
+	return err
+}
+
+// commit commits the uploaded content, verifying that it matches the digest. Content that already exists
+// is not an error, as the upload is then redundant.
+func (u *upload) commit(dgst digest.Digest) error {
+	if err := u.writer.Commit(u.ctx, 0, dgst); err != nil && !errdefs.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (u *upload) abort(cs content.Store) {
+	u.writer.Close()
+	if err := cs.Abort(u.ctx, u.ref); err != nil && !errdefs.IsNotFound(err) {
+		logrus.Debugf("Failed to abort local registry upload %s: %v", u.ref, err)
+	}
+}
+
+func (r *Registry) manifest(ctx context.Context, resp http.ResponseWriter, req *http.Request, repository, ref string) {
+	name, err := r.imageName(repository)
+	if err != nil {
+		writeError(resp, http.StatusBadRequest, "NAME_INVALID", err.Error())
+		return
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		r.getManifest(ctx, resp, req, name, ref)
+	case http.MethodPut:
+		r.putManifest(ctx, resp, req, name, repository, ref)
+	default:
+		writeError(resp, http.StatusMethodNotAllowed, "UNSUPPORTED", "unsupported method")
+	}
+}
+
+func (r *Registry) getManifest(ctx context.Context, resp http.ResponseWriter, req *http.Request, name, ref string) {
+	var desc ocispec.Descriptor
+	if dgst, err := digest.Parse(ref); err == nil {
+		info, err := r.content.Info(ctx, dgst)
+		if errdefs.IsNotFound(err) {
+			writeError(resp, http.StatusNotFound, "MANIFEST_UNKNOWN", "manifest unknown to registry")
+			return
+		} else if err != nil {
+			writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+			return
+		}
+		desc = ocispec.Descriptor{Digest: dgst, Size: info.Size}
+	} else {
+		image, err := r.images.Get(ctx, name+":"+ref)
+		if errdefs.IsNotFound(err) {
+			writeError(resp, http.StatusNotFound, "MANIFEST_UNKNOWN", "manifest unknown to registry")
+			return
+		} else if err != nil {
+			writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+			return
+		}
+		desc = image.Target
+	}
+
+	b, err := content.ReadBlob(ctx, r.content, desc)
+	if err != nil {
+		writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	if desc.MediaType == "" {
+		desc.MediaType = manifestMediaType(b)
+	}
+	resp.Header().Set("Content-Type", desc.MediaType)
+	resp.Header().Set("Content-Length", fmt.Sprint(len(b)))
+	resp.Header().Set("Docker-Content-Digest", desc.Digest.String())
+	resp.Header().Set("ETag", `"`+desc.Digest.String()+`"`)
+	if req.Method == http.MethodGet {
+		resp.Write(b)
+	}
+}
+
+// putManifest stores a manifest, after checking that the content it references has been pushed. If the
+// reference is a tag, an image is created in the image store.
+func (r *Registry) putManifest(ctx context.Context, resp http.ResponseWriter, req *http.Request, name, repository, ref string) {
+	b, err := io.ReadAll(io.LimitReader(req.Body, maxManifestSize+1))
+	if err != nil {
+		writeError(resp, http.StatusBadRequest, "MANIFEST_INVALID", err.Error())
+		return
+	}
+	if len(b) > maxManifestSize {
+		writeError(resp, http.StatusRequestEntityTooLarge, "SIZE_INVALID", "manifest too large")
+		return
+	}
+	mediaType := req.Header.Get("Content-Type")
+	if mediaType == "" {
+		mediaType = manifestMediaType(b)
+	}
+	if !images.IsManifestType(mediaType) && !images.IsIndexType(mediaType) {
+		writeError(resp, http.StatusBadRequest, "MANIFEST_INVALID", "unsupported manifest media type "+mediaType)
+		return
+	}
+	desc := ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(b), Size: int64(len(b))}
+
+	dgst, err := digest.Parse(ref)
+	isDigest := err == nil
+	if isDigest && dgst != desc.Digest {
+		writeError(resp, http.StatusBadRequest, "DIGEST_INVALID", "manifest digest does not match reference")
+		return
+	}
+
+	ctx, err = r.withLease(ctx)
+	if err != nil {
+		writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	if err := content.WriteBlob(ctx, r.content, version.Program+"-registry-"+desc.Digest.String(), bytes.NewReader(b), desc); err != nil {
+		writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+	children, err := images.Children(ctx, r.content, desc)
+	if err != nil {
+		writeError(resp, http.StatusBadRequest, "MANIFEST_INVALID", err.Error())
+		return
+	}
+	for _, child := range children {
+		if _, err := r.content.Info(ctx, child.Digest); err != nil {
+			writeError(resp, http.StatusBadRequest, "MANIFEST_BLOB_UNKNOWN", fmt.Sprintf("blob %s unknown to registry", child.Digest))
+			return
+		}
+	}
+	// Label the manifest with references to its children, so that they are retained as long as it is.
+	if err := images.Walk(ctx, images.SetChildrenLabels(r.content, images.ChildrenHandler(r.content)), desc); err != nil {
+		writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+		return
+	}
+
+	if !isDigest {
+		image := images.Image{Name: name + ":" + ref, Target: desc}
+		if _, err := r.images.Create(ctx, image); errdefs.IsAlreadyExists(err) {
+			_, err = r.images.Update(ctx, image, "target")
+			if err != nil {
+				writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+				return
+			}
+		} else if err != nil {
+			writeError(resp, http.StatusInternalServerError, "UNKNOWN", err.Error())
+			return
+		}
+		logrus.Infof("Pushed %s to local registry", image.Name)
+		if r.unpack != nil {
+			if err := r.unpack(ctx, image); err != nil {
+				logrus.Warnf("Failed to unpack %s: %v", image.Name, err)
+			}
+		}
+	}
+
+	resp.Header().Set("Location", "/v2/"+repository+"/manifests/"+desc.Digest.String())
+	resp.Header().Set("Docker-Content-Digest", desc.Digest.String())
+	resp.WriteHeader(http.StatusCreated)
+}
+
+// withLease returns a context with a lease that protects the content written with it from garbage collection
+// until the lease expires.
+func (r *Registry) withLease(ctx context.Context) (context.Context, error) {
+	if r.leases == nil {
+		return ctx, nil
+	}
+	lease, err := r.leases.Create(ctx, leases.WithRandomID(), leases.WithExpiration(uploadTimeout))
+	if err != nil {
+		return nil, err
+	}
+	return leases.WithLease(ctx, lease.ID), nil
+}
+
+// manifestMediaType returns the media type declared in a manifest, defaulting to an OCI image manifest.
+func manifestMediaType(b []byte) string {
+	var manifest struct {
+		MediaType string `json:"mediaType"`
+	}
+	if err := json.Unmarshal(b, &manifest); err == nil && manifest.MediaType != "" {
+		return manifest.MediaType
+	}
+	return ocispec.MediaTypeImageManifest
+}
+
+func writeUploadStatus(resp http.ResponseWriter, status int, repository, id string, offset int64) {
+	resp.Header().Set("Location", "/v2/"+repository+"/blobs/uploads/"+id)
+	resp.Header().Set("Docker-Upload-UUID", id)
+	resp.Header().Set("Range", fmt.Sprintf("0-%d", offset-1))
+	if offset == 0 {
+		resp.Header().Set("Range", "0-0")
+	}
+	resp.Header().Set("Content-Length", "0")
+	resp.WriteHeader(status)
+}
+
+func writeBlobCreated(resp http.ResponseWriter, repository string, dgst digest.Digest) {
+	resp.Header().Set("Location", "/v2/"+repository+"/blobs/"+dgst.String())
+	resp.Header().Set("Docker-Content-Digest", dgst.String())
+	resp.Header().Set("Content-Length", "0")
+	resp.WriteHeader(http.StatusCreated)
+}
+
+func writeError(resp http.ResponseWriter, status int, code, message string) {
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(status)
+	json.NewEncoder(resp).Encode(errorResponse{Errors: []errorDetail{{Code: code, Message: message}}})
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
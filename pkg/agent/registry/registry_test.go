@@ -0,0 +1,337 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/containerd/containerd/content/local"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// imageStore is an in-memory images.Store.
+type imageStore struct {
+	mu     sync.Mutex
+	images map[string]images.Image
+}
+
+func (s *imageStore) Get(ctx context.Context, name string) (images.Image, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	image, ok := s.images[name]
+	if !ok {
+		return images.Image{}, errdefs.ErrNotFound
+	}
+	return image, nil
+}
+
+func (s *imageStore) List(ctx context.Context, filters ...string) ([]images.Image, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := []images.Image{}
+	for _, image := range s.images {
+		result = append(result, image)
+	}
+	return result, nil
+}
+
+func (s *imageStore) Create(ctx context.Context, image images.Image) (images.Image, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.images[image.Name]; ok {
+		return images.Image{}, errdefs.ErrAlreadyExists
+	}
+	s.images[image.Name] = image
+	return image, nil
+}
+
+func (s *imageStore) Update(ctx context.Context, image images.Image, fieldpaths ...string) (images.Image, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.images[image.Name] = image
+	return image, nil
+}
+
+func (s *imageStore) Delete(ctx context.Context, name string, opts ...images.DeleteOpt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.images, name)
+	return nil
+}
+
+// labelStore is an in-memory local.LabelStore.
+type labelStore struct {
+	mu     sync.Mutex
+	labels map[digest.Digest]map[string]string
+}
+
+func (s *labelStore) Get(dgst digest.Digest) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.labels[dgst], nil
+}
+
+func (s *labelStore) Set(dgst digest.Digest, labels map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.labels[dgst] = labels
+	return nil
+}
+
+func (s *labelStore) Update(dgst digest.Digest, update map[string]string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	labels := s.labels[dgst]
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range update {
+		if v == "" {
+			delete(labels, k)
+		} else {
+			labels[k] = v
+		}
+	}
+	s.labels[dgst] = labels
+	return labels, nil
+}
+
+const (
+	credentials  = "user:secret"
+	registryName = "localhost:5000"
+)
+
+func do(t *testing.T, method, url string, body []byte, header http.Header) *http.Response {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	user, password, _ := strings.Cut(credentials, ":")
+	req.SetBasicAuth(user, password)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func Test_UnitNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		registry    string
+		credentials string
+		wantErr     bool
+	}{
+		{name: "localhost with port", registry: "localhost:5000", credentials: credentials},
+		{name: "hostname", registry: "registry.example.com", credentials: credentials},
+		{name: "name without domain", registry: "registry", credentials: credentials, wantErr: true},
+		{name: "name with path", registry: "localhost:5000/app", credentials: credentials, wantErr: true},
+		{name: "empty name", registry: "", credentials: credentials, wantErr: true},
+		{name: "credentials without password", registry: "localhost:5000", credentials: "user", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(nil, nil, nil, nil, tt.registry, tt.credentials)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_UnitRegistry(t *testing.T) {
+	cs, err := local.NewLabeledStore(t.TempDir(), &labelStore{labels: map[digest.Digest]map[string]string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	is := &imageStore{images: map[string]images.Image{}}
+	unpacked := []string{}
+	r, err := New(cs, is, nil, func(ctx context.Context, image images.Image) error {
+		unpacked = append(unpacked, image.Name)
+		return nil
+	}, registryName, credentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	layer := []byte("layer data")
+	config := []byte(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":[]}}`)
+	manifest, _ := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.Descriptor{MediaType: ocispec.MediaTypeImageConfig, Digest: digest.FromBytes(config), Size: int64(len(config))},
+		Layers:    []ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageLayer, Digest: digest.FromBytes(layer), Size: int64(len(layer))}},
+	})
+	manifestDigest := digest.FromBytes(manifest).String()
+	manifestHeader := http.Header{"Content-Type": []string{ocispec.MediaTypeImageManifest}}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       []byte
+		header     http.Header
+		noAuth     bool
+		wantStatus int
+	}{
+		{
+			name:       "Unauthenticated",
+			method:     http.MethodGet,
+			path:       "/v2/",
+			noAuth:     true,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Authenticated",
+			method:     http.MethodGet,
+			path:       "/v2/",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Unauthenticated push",
+			method:     http.MethodPut,
+			path:       "/v2/app/manifests/v1",
+			body:       manifest,
+			header:     manifestHeader,
+			noAuth:     true,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Unknown blob",
+			method:     http.MethodHead,
+			path:       "/v2/app/blobs/" + digest.FromBytes(layer).String(),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Manifest with missing blobs",
+			method:     http.MethodPut,
+			path:       "/v2/app/manifests/v1",
+			body:       manifest,
+			header:     manifestHeader,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Monolithic blob upload",
+			method:     http.MethodPost,
+			path:       "/v2/app/blobs/uploads/?digest=" + digest.FromBytes(config).String(),
+			body:       config,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Monolithic blob upload with wrong digest",
+			method:     http.MethodPost,
+			path:       "/v2/app/blobs/uploads/?digest=" + digest.FromBytes([]byte("other")).String(),
+			body:       layer,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown upload",
+			method:     http.MethodPatch,
+			path:       "/v2/app/blobs/uploads/unknown",
+			body:       layer,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.noAuth {
+				req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(tt.body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp, err = http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer resp.Body.Close()
+			} else {
+				resp = do(t, tt.method, server.URL+tt.path, tt.body, tt.header)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+
+	// Chunked upload of the layer, as performed by docker push
+	resp := do(t, http.MethodPost, server.URL+"/v2/app/blobs/uploads/", nil, nil)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("start upload status = %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	location := resp.Header.Get("Location")
+	resp = do(t, http.MethodPatch, server.URL+location, layer[:5], nil)
+	if resp.StatusCode != http.StatusAccepted || resp.Header.Get("Range") != "0-4" {
+		t.Fatalf("patch upload status = %d range = %q, want %d 0-4", resp.StatusCode, resp.Header.Get("Range"), http.StatusAccepted)
+	}
+	resp = do(t, http.MethodPut, server.URL+location+"?digest="+digest.FromBytes(layer).String(), layer[5:], nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("complete upload status = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+
+	resp = do(t, http.MethodPut, server.URL+"/v2/app/manifests/v1", manifest, manifestHeader)
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Docker-Content-Digest") != manifestDigest {
+		t.Fatalf("put manifest status = %d digest = %q, want %d %s", resp.StatusCode, resp.Header.Get("Docker-Content-Digest"), http.StatusCreated, manifestDigest)
+	}
+	if image, err := is.Get(context.Background(), registryName+"/app:v1"); err != nil || image.Target.Digest.String() != manifestDigest {
+		t.Errorf("image = %v, error = %v, want target %s", image, err, manifestDigest)
+	}
+	if len(unpacked) != 1 || unpacked[0] != registryName+"/app:v1" {
+		t.Errorf("unpacked = %v, want [%s/app:v1]", unpacked, registryName)
+	}
+
+	// The image name must not depend on the address used by the client
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/v2/app/manifests/v2", bytes.NewReader(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Host = "registry.k8s.io"
+	req.Header.Set("Content-Type", ocispec.MediaTypeImageManifest)
+	user, password, _ := strings.Cut(credentials, ":")
+	req.SetBasicAuth(user, password)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("put manifest with other host status = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	if _, err := is.Get(context.Background(), "registry.k8s.io/app:v2"); err == nil {
+		t.Errorf("image was created with the registry name from the Host header")
+	}
+	if _, err := is.Get(context.Background(), registryName+"/app:v2"); err != nil {
+		t.Errorf("image %s/app:v2 not found: %v", registryName, err)
+	}
+
+	pulls := []struct {
+		path string
+		want []byte
+	}{
+		{path: "/v2/app/manifests/v1", want: manifest},
+		{path: "/v2/app/manifests/" + manifestDigest, want: manifest},
+		{path: "/v2/app/blobs/" + digest.FromBytes(layer).String(), want: layer},
+		{path: "/v2/app/tags/list", want: []byte(`{"name":"app","tags":["v1","v2"]}` + "\n")},
+	}
+	for _, pull := range pulls {
+		resp := do(t, http.MethodGet, server.URL+pull.path, nil, nil)
+		got, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || !bytes.Equal(got, pull.want) {
+			t.Errorf("GET %s = %d %q, want %d %q", pull.path, resp.StatusCode, got, http.StatusOK, pull.want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package registryauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/reference/docker"
+	"github.com/k3s-io/k3s/pkg/daemons/agent"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	credentialproviderv1 "k8s.io/kubelet/pkg/apis/credentialprovider/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Command is the name under which the credential provider plugin is registered for reexec, and the name of
+	// the provider in the kubelet credential provider config.
+	Command = "registry-auth-credential-provider"
+
+	// cacheDuration is how long the kubelet caches credentials returned by the plugin, and so the longest time
+	// after which rotated credentials are used.
+	cacheDuration = time.Minute
+)
+
+// matchImages are the image patterns that the kubelet runs the plugin for. Patterns match each part of the
+// registry host separately, so there is a pattern for each number of parts. Registries on a non-default port
+// only match patterns that include the port, so patterns for these are added when the plugin is configured.
+var matchImages = []string{"*", "*.*", "*.*.*", "*.*.*.*", "*.*.*.*.*", "*.*.*.*.*.*"}
+
+// Setup configures the kubelet to run the credential provider plugin, which returns the credentials read from
+// the auth file. Any credential provider plugins that are already configured are also included in the
+// generated config and bin dir.
+func Setup(agentConfig *config.Agent, dataDir, authFile string) error {
+	providerDir := filepath.Join(dataDir, "agent", "credential-provider")
+	binDir := filepath.Join(providerDir, "bin")
+	if err := os.RemoveAll(binDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(binDir, 0700); err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := os.Symlink(exe, filepath.Join(binDir, Command)); err != nil {
+		return err
+	}
+
+	var providerConfig map[string]interface{}
+	if agent.ImageCredProvAvailable(agentConfig) {
+		b, err := os.ReadFile(agentConfig.ImageCredProvConfig)
+		if err != nil {
+			return err
+		}
+		if err := yaml.Unmarshal(b, &providerConfig); err != nil {
+			return errors.Wrapf(err, "failed to parse credential provider config %s", agentConfig.ImageCredProvConfig)
+		}
+		providers, _ := providerConfig["providers"].([]interface{})
+		for _, p := range providers {
+			provider, _ := p.(map[string]interface{})
+			name, _ := provider["name"].(string)
+			if name == "" || name == Command || strings.ContainsAny(name, `/\`) {
+				return fmt.Errorf("invalid credential provider name %q in %s", name, agentConfig.ImageCredProvConfig)
+			}
+			if err := os.Symlink(filepath.Join(agentConfig.ImageCredProvBinDir, name), filepath.Join(binDir, name)); err != nil {
+				return err
+			}
+		}
+	} else {
+		providerConfig = map[string]interface{}{
+			"apiVersion": "kubelet.config.k8s.io/v1",
+			"kind":       "CredentialProviderConfig",
+		}
+	}
+
+	images, err := providerMatchImages(authFile)
+	if err != nil {
+		return err
+	}
+	providers, _ := providerConfig["providers"].([]interface{})
+	providerConfig["providers"] = append(providers, map[string]interface{}{
+		"name":                 Command,
+		"apiVersion":           credentialproviderv1.SchemeGroupVersion.String(),
+		"matchImages":          images,
+		"defaultCacheDuration": cacheDuration.String(),
+		"args":                 []string{authFile},
+	})
+	b, err := yaml.Marshal(providerConfig)
+	if err != nil {
+		return err
+	}
+	configFile := filepath.Join(providerDir, "config.yaml")
+	if err := os.WriteFile(configFile, b, 0600); err != nil {
+		return err
+	}
+
+	logrus.Infof("Using registry credentials from Secret %s for image pulls", SecretName)
+	agentConfig.ImageCredProvBinDir = binDir
+	agentConfig.ImageCredProvConfig = configFile
+	return nil
+}
+
+// providerMatchImages returns the image patterns for the plugin, including patterns for the registries in the
+// auth file that use a non-default port.
+func providerMatchImages(authFile string) ([]string, error) {
+	images := append([]string{}, matchImages...)
+	creds, err := readAuthFile(authFile)
+	if err != nil {
+		return nil, err
+	}
+	for host := range creds {
+		if strings.Contains(host, ":") {
+			images = append(images, host)
+		}
+	}
+	return images, nil
+}
+
+// readAuthFile reads the registry credentials from the auth file. No credentials are returned if the file does
+// not exist, as the Secret has not yet been read.
+func readAuthFile(authFile string) (map[string]credentials, error) {
+	creds := map[string]credentials{}
+	b, err := os.ReadFile(authFile)
+	if os.IsNotExist(err) {
+		return creds, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &creds); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", authFile)
+	}
+	return creds, nil
+}
+
+// Main is the entrypoint of the credential provider plugin. The kubelet runs the plugin with the path of the
+// auth file as its only argument, and writes the request for an image to stdin.
+func Main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s <auth file>\n", os.Args[0])
+		os.Exit(1)
+	}
+	if err := provide(os.Stdin, os.Stdout, os.Args[1]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", Command, err)
+		os.Exit(1)
+	}
+}
+
+// provide reads a credential provider request, and writes a response with the credentials for the registry of
+// the requested image, if there are any.
+func provide(in io.Reader, out io.Writer, authFile string) error {
+	request := &credentialproviderv1.CredentialProviderRequest{}
+	if err := json.NewDecoder(in).Decode(request); err != nil {
+		return errors.Wrap(err, "failed to decode request")
+	}
+	if request.APIVersion != credentialproviderv1.SchemeGroupVersion.String() {
+		return fmt.Errorf("unsupported request apiVersion %q", request.APIVersion)
+	}
+	named, err := docker.ParseNormalizedNamed(request.Image)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse image %s", request.Image)
+	}
+	creds, err := readAuthFile(authFile)
+	if err != nil {
+		return err
+	}
+
+	response := &credentialproviderv1.CredentialProviderResponse{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: credentialproviderv1.SchemeGroupVersion.String(),
+			Kind:       "CredentialProviderResponse",
+		},
+		CacheKeyType: credentialproviderv1.RegistryPluginCacheKeyType,
+		Auth:         map[string]credentialproviderv1.AuthConfig{},
+	}
+	host := docker.Domain(named)
+	if c, ok := creds[host]; ok {
+		response.Auth[host] = credentialproviderv1.AuthConfig{Username: c.Username, Password: c.Password}
+	}
+	return json.NewEncoder(out).Encode(response)
+}
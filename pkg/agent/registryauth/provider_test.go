@@ -0,0 +1,203 @@
+package registryauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/rancher/wharfie/pkg/registries"
+	credentialproviderv1 "k8s.io/kubelet/pkg/apis/credentialprovider/v1"
+	"k8s.io/kubernetes/pkg/credentialprovider"
+	"k8s.io/kubernetes/pkg/credentialprovider/plugin"
+)
+
+func init() {
+	reexec.Register(Command, Main)
+}
+
+// TestMain runs the credential provider plugin when the test binary is executed by the kubelet credential
+// provider code through the symlink created by Setup.
+func TestMain(m *testing.M) {
+	cmd := os.Args[0]
+	os.Args[0] = filepath.Base(os.Args[0])
+	if reexec.Init() {
+		return
+	}
+	os.Args[0] = cmd
+	os.Exit(m.Run())
+}
+
+func Test_UnitWriteAuthFile(t *testing.T) {
+	authFile := filepath.Join(t.TempDir(), "etc", "registry-auth.json")
+	auths := map[string]registries.AuthConfig{
+		"registry.example.com": {Username: "user", Password: "pass"},
+		"docker.io":            {Auth: "dXNlcjpwYXNzOndvcmQ="},
+	}
+	for i := 0; i < 2; i++ {
+		if err := writeAuthFile(authFile, auths); err != nil {
+			t.Fatalf("writeAuthFile() error = %v", err)
+		}
+	}
+	info, err := os.Stat(authFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("auth file mode = %v, want 0600", mode)
+	}
+	got, err := readAuthFile(authFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]credentials{
+		"registry.example.com": {Username: "user", Password: "pass"},
+		"docker.io":            {Username: "user", Password: "pass:word"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readAuthFile() = %+v, want %+v", got, want)
+	}
+
+	if err := writeAuthFile(authFile, map[string]registries.AuthConfig{"docker.io": {Auth: "!"}}); err == nil {
+		t.Errorf("writeAuthFile() with invalid auth did not return an error")
+	}
+}
+
+func Test_UnitProvide(t *testing.T) {
+	authFile := filepath.Join(t.TempDir(), "registry-auth.json")
+	if err := writeAuthFile(authFile, map[string]registries.AuthConfig{
+		"docker.io":                 {Username: "hub", Password: "hubpass"},
+		"registry.example.com:5000": {Username: "user", Password: "pass"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	apiVersion := credentialproviderv1.SchemeGroupVersion.String()
+
+	tests := []struct {
+		name       string
+		apiVersion string
+		image      string
+		want       map[string]credentialproviderv1.AuthConfig
+		wantErr    bool
+	}{
+		{
+			name:       "docker hub image",
+			apiVersion: apiVersion,
+			image:      "library/busybox:latest",
+			want: map[string]credentialproviderv1.AuthConfig{
+				"docker.io": {Username: "hub", Password: "hubpass"},
+			},
+		},
+		{
+			name:       "registry with port",
+			apiVersion: apiVersion,
+			image:      "registry.example.com:5000/app/server:v1",
+			want: map[string]credentialproviderv1.AuthConfig{
+				"registry.example.com:5000": {Username: "user", Password: "pass"},
+			},
+		},
+		{
+			name:       "registry without credentials",
+			apiVersion: apiVersion,
+			image:      "registry.example.com/app/server:v1",
+		},
+		{
+			name:       "unsupported apiVersion",
+			apiVersion: "credentialprovider.kubelet.k8s.io/v1alpha1",
+			image:      "library/busybox:latest",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid image",
+			apiVersion: apiVersion,
+			image:      "Invalid Image",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &credentialproviderv1.CredentialProviderRequest{Image: tt.image}
+			request.APIVersion = tt.apiVersion
+			in, err := json.Marshal(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := &bytes.Buffer{}
+			err = provide(bytes.NewReader(in), out, authFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("provide() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			response := &credentialproviderv1.CredentialProviderResponse{}
+			if err := json.Unmarshal(out.Bytes(), response); err != nil {
+				t.Fatal(err)
+			}
+			if response.CacheKeyType != credentialproviderv1.RegistryPluginCacheKeyType {
+				t.Errorf("provide() cacheKeyType = %s, want %s", response.CacheKeyType, credentialproviderv1.RegistryPluginCacheKeyType)
+			}
+			if !reflect.DeepEqual(response.Auth, tt.want) {
+				t.Errorf("provide() auth = %+v, want %+v", response.Auth, tt.want)
+			}
+		})
+	}
+}
+
+// Test_UnitSetup checks that the generated config, merged with an existing config, is accepted by the kubelet,
+// and that the kubelet keyring returns the credentials from the auth file. The kubelet registers the plugins
+// globally, so this can only be done once per test binary.
+func Test_UnitSetup(t *testing.T) {
+	dataDir := t.TempDir()
+	userBinDir := filepath.Join(dataDir, "user", "bin")
+	if err := os.MkdirAll(userBinDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(userBinDir, "ecr-credential-provider"), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	userConfig := filepath.Join(dataDir, "user", "config.yaml")
+	if err := os.WriteFile(userConfig, []byte(`apiVersion: kubelet.config.k8s.io/v1
+kind: CredentialProviderConfig
+providers:
+- name: ecr-credential-provider
+  apiVersion: credentialprovider.kubelet.k8s.io/v1
+  matchImages:
+  - "*.dkr.ecr.*.amazonaws.com"
+  defaultCacheDuration: 12h
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	authFile := filepath.Join(dataDir, "agent", "etc", "registry-auth.json")
+	if err := writeAuthFile(authFile, map[string]registries.AuthConfig{
+		"registry.example.com:5000": {Username: "user", Password: "pass"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	agentConfig := &config.Agent{ImageCredProvBinDir: userBinDir, ImageCredProvConfig: userConfig}
+	if err := Setup(agentConfig, dataDir, authFile); err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	for _, name := range []string{Command, "ecr-credential-provider"} {
+		if _, err := os.Stat(filepath.Join(agentConfig.ImageCredProvBinDir, name)); err != nil {
+			t.Errorf("credential provider %s not found in bin dir: %v", name, err)
+		}
+	}
+	if err := plugin.RegisterCredentialProviderPlugins(agentConfig.ImageCredProvConfig, agentConfig.ImageCredProvBinDir); err != nil {
+		t.Fatalf("generated credential provider config is not valid: %v", err)
+	}
+
+	creds, ok := credentialprovider.NewDockerKeyring().Lookup("registry.example.com:5000/app/server:v1")
+	if !ok || len(creds) == 0 {
+		t.Fatalf("no credentials returned for registry.example.com:5000")
+	}
+	if creds[0].Username != "user" || creds[0].Password != "pass" {
+		t.Errorf("credentials = %s:%s, want user:pass", creds[0].Username, creds[0].Password)
+	}
+}
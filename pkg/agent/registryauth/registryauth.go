@@ -0,0 +1,158 @@
+// Package registryauth loads registry credentials from a Secret, and provides them to the kubelet through an
+// image credential provider plugin. The kubelet passes the credentials to the container runtime with each image
+// pull request, so that they are also used to request tokens from registries that use token authentication.
+package registryauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/rancher/wharfie/pkg/registries"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	toolswatch "k8s.io/client-go/tools/watch"
+)
+
+// SecretName is the name of the Secret in the kube-system namespace that registry credentials are loaded from.
+var SecretName = version.Program + "-registry-auth"
+
+// dockerConfigJSON is the content of a kubernetes.io/dockerconfigjson Secret.
+type dockerConfigJSON struct {
+	Auths map[string]struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	} `json:"auths"`
+}
+
+// credentials are the username and password for a registry, as stored in the auth file read by the
+// credential provider plugin.
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Watch watches the registry auth Secret, and writes its credentials to the auth file each time it changes, so
+// that registry credentials can be rotated without restarting the kubelet or container runtime.
+func Watch(ctx context.Context, secrets typedcorev1.SecretInterface, authFile string) {
+	fieldSelector := fields.Set{metav1.ObjectNameField: SecretName}.String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (object runtime.Object, e error) {
+			options.FieldSelector = fieldSelector
+			return secrets.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (i watch.Interface, e error) {
+			options.FieldSelector = fieldSelector
+			return secrets.Watch(ctx, options)
+		},
+	}
+	condition := func(ev watch.Event) (bool, error) {
+		secret, ok := ev.Object.(*v1.Secret)
+		if !ok {
+			return false, errors.New("event object not of type v1.Secret")
+		}
+		auths := map[string]registries.AuthConfig{}
+		if ev.Type != watch.Deleted {
+			var err error
+			if auths, err = parseRegistryAuth(secret); err != nil {
+				logrus.Errorf("Failed to load registry credentials from Secret %s: %v", SecretName, err)
+				return false, nil
+			}
+		}
+		if err := writeAuthFile(authFile, auths); err != nil {
+			logrus.Errorf("Failed to write registry credentials from Secret %s: %v", SecretName, err)
+			return false, nil
+		}
+		logrus.Infof("Updated registry credentials for %d registries from Secret %s", len(auths), SecretName)
+		return false, nil
+	}
+
+	logrus.Infof("Watching Secret %s for registry credentials", SecretName)
+	if _, err := toolswatch.UntilWithSync(ctx, lw, &v1.Secret{}, nil, condition); err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, toolswatch.ErrWatchClosed) {
+		logrus.Errorf("Failed to watch Secret %s for registry credentials: %v", SecretName, err)
+	}
+}
+
+// parseRegistryAuth returns the registry credentials in a dockerconfigjson Secret, by registry host.
+func parseRegistryAuth(secret *v1.Secret) (map[string]registries.AuthConfig, error) {
+	if secret.Type != v1.SecretTypeDockerConfigJson {
+		return nil, errors.Errorf("unsupported Secret type %s; must be %s", secret.Type, v1.SecretTypeDockerConfigJson)
+	}
+	config := &dockerConfigJSON{}
+	if err := json.Unmarshal(secret.Data[v1.DockerConfigJsonKey], config); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", v1.DockerConfigJsonKey)
+	}
+	auths := map[string]registries.AuthConfig{}
+	for server, auth := range config.Auths {
+		if auth.Auth == "" && auth.Username == "" {
+			logrus.Warnf("Ignoring credentials for registry %s without a username or auth", server)
+			continue
+		}
+		auths[registryHost(server)] = registries.AuthConfig{
+			Username: auth.Username,
+			Password: auth.Password,
+			Auth:     auth.Auth,
+		}
+	}
+	return auths, nil
+}
+
+// registryHost returns the host of a registry server in a docker config file, which may be a URL.
+func registryHost(server string) string {
+	host := server
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	host = strings.TrimSuffix(host, "/")
+	switch host {
+	case "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	}
+	return host
+}
+
+// writeAuthFile writes the registry credentials to the auth file, replacing it atomically so that the credential
+// provider plugin never reads a partially written file. The file is only readable by root.
+func writeAuthFile(authFile string, auths map[string]registries.AuthConfig) error {
+	creds := map[string]credentials{}
+	for host, auth := range auths {
+		c := credentials{Username: auth.Username, Password: auth.Password}
+		if auth.Auth != "" {
+			b, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return errors.Wrapf(err, "invalid auth for registry %s", host)
+			}
+			c.Username, c.Password, _ = strings.Cut(string(b), ":")
+		}
+		creds[host] = c
+	}
+	b, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(authFile), 0700); err != nil {
+		return err
+	}
+	// The temporary file is removed first, as WriteFile does not change the mode of an existing file.
+	tmp := authFile + ".tmp"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, authFile)
+}
@@ -0,0 +1,82 @@
+package registryauth
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rancher/wharfie/pkg/registries"
+	v1 "k8s.io/api/core/v1"
+)
+
+func Test_UnitParseRegistryAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  *v1.Secret
+		want    map[string]registries.AuthConfig
+		wantErr bool
+	}{
+		{
+			name: "username and password",
+			secret: &v1.Secret{
+				Type: v1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{
+					v1.DockerConfigJsonKey: []byte(`{"auths":{"registry.example.com":{"username":"user","password":"pass"}}}`),
+				},
+			},
+			want: map[string]registries.AuthConfig{
+				"registry.example.com": {Username: "user", Password: "pass"},
+			},
+		},
+		{
+			name: "docker hub url and auth",
+			secret: &v1.Secret{
+				Type: v1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{
+					v1.DockerConfigJsonKey: []byte(`{"auths":{"https://index.docker.io/v1/":{"auth":"dXNlcjpwYXNz"},"https://registry.example.com:5000":{"username":"user","password":"pass"}}}`),
+				},
+			},
+			want: map[string]registries.AuthConfig{
+				"docker.io":                 {Auth: "dXNlcjpwYXNz"},
+				"registry.example.com:5000": {Username: "user", Password: "pass"},
+			},
+		},
+		{
+			name: "no credentials",
+			secret: &v1.Secret{
+				Type: v1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{
+					v1.DockerConfigJsonKey: []byte(`{"auths":{"registry.example.com":{}}}`),
+				},
+			},
+			want: map[string]registries.AuthConfig{},
+		},
+		{
+			name: "wrong type",
+			secret: &v1.Secret{
+				Type: v1.SecretTypeOpaque,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid json",
+			secret: &v1.Secret{
+				Type: v1.SecretTypeDockerConfigJson,
+				Data: map[string][]byte{
+					v1.DockerConfigJsonKey: []byte(`{"auths":`),
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRegistryAuth(tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRegistryAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRegistryAuth() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package relay
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/clientaccess"
+	"github.com/pkg/errors"
+)
+
+const (
+	// relayUser is the username used to authenticate to relay agents.
+	relayUser = "node"
+
+	dialTimeout = 30 * time.Second
+)
+
+// Dialer dials connections through a HTTP CONNECT relay, which may be another agent, or a HTTP or HTTPS proxy.
+type Dialer struct {
+	relayURL *url.URL
+	auth     string
+}
+
+// NewDialer returns a Dialer that connects through the relay at the given URL. Credentials included in the URL are
+// sent to the relay; if there are none, credentials derived from the token are sent, as expected by relay agents.
+func NewDialer(relayURL, token string) (*Dialer, error) {
+	u, err := url.Parse(relayURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid relay-url")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid relay-url %s: scheme must be http or https", relayURL)
+	}
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	d := &Dialer{relayURL: u}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		d.auth = basicAuth(u.User.Username(), password)
+	} else if token != "" {
+		password, err := Password(token)
+		if err != nil {
+			return nil, err
+		}
+		d.auth = basicAuth(relayUser, password)
+	}
+	return d, nil
+}
+
+// Password returns the password used to authenticate to relay agents for the given token, which may be a server or
+// agent token, or a bootstrap token. The password is derived from the secret part of the token, so that the token
+// itself is not exposed to the relay; agents that use a relay agent must therefore use the same token as it.
+func Password(token string) (string, error) {
+	secret, ok := clientaccess.ParseTokenSecret(token)
+	if !ok {
+		return "", errors.New("failed to parse token for relay authentication")
+	}
+	digest := sha256.Sum256([]byte("relay:" + secret))
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// DialContext connects to the address through the relay.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if network != "tcp" && network != "tcp4" && network != "tcp6" {
+		return nil, fmt.Errorf("relay does not support network %s", network)
+	}
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", d.relayURL.Host)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to relay")
+	}
+	if d.relayURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: d.relayURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, errors.Wrap(err, "failed to connect to relay")
+		}
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if d.auth != "" {
+		req.Header.Set("Proxy-Authorization", d.auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "failed to send relay request")
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "failed to read relay response")
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("relay refused connection to %s: %s", address, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+
+	// Data may have been sent by the server immediately after the relay response.
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: br}, nil
+	}
+	return conn, nil
+}
+
+func basicAuth(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+// bufferedConn is a net.Conn that reads any data buffered while reading the relay response before reading
+// from the underlying connection.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
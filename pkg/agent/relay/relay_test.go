@@ -0,0 +1,112 @@
+package relay
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+// echoServer starts a TCP server that echoes back everything it receives, and returns its address.
+func echoServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func Test_UnitRelay(t *testing.T) {
+	target := echoServer(t)
+
+	tests := []struct {
+		name        string
+		serverToken string
+		clientToken string
+		allowed     bool
+		wantErr     bool
+	}{
+		{
+			name:        "Relayed",
+			serverToken: "K10aabbccddeeff00112233445566778899aabbccddeeff00112233445566778899::node:secret",
+			clientToken: "secret",
+			allowed:     true,
+		},
+		{
+			name:        "Relayed with bootstrap token",
+			serverToken: "K10aabbccddeeff00112233445566778899aabbccddeeff00112233445566778899::abcdef.0123456789abcdef",
+			clientToken: "abcdef.0123456789abcdef",
+			allowed:     true,
+		},
+		{
+			name:        "Wrong bootstrap token",
+			serverToken: "abcdef.0123456789abcdef",
+			clientToken: "abcdef.fedcba9876543210",
+			allowed:     true,
+			wantErr:     true,
+		},
+		{
+			name:        "Wrong token",
+			serverToken: "secret",
+			clientToken: "other",
+			allowed:     true,
+			wantErr:     true,
+		},
+		{
+			name:        "Destination not allowed",
+			serverToken: "secret",
+			clientToken: "secret",
+			allowed:     false,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewServer(tt.serverToken, func(address string) bool { return tt.allowed && address == target })
+			if err != nil {
+				t.Fatal(err)
+			}
+			relay := httptest.NewServer(s)
+			defer relay.Close()
+
+			d, err := NewDialer(relay.URL, tt.clientToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn, err := d.DialContext(context.Background(), "tcp", target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DialContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			want := "hello through the relay"
+			if _, err := conn.Write([]byte(want)); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]byte, len(want))
+			if _, err := io.ReadFull(conn, got); err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != want {
+				t.Errorf("read %q, want %q", got, want)
+			}
+		})
+	}
+}
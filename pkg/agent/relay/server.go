@@ -0,0 +1,117 @@
+package relay
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Server accepts HTTP CONNECT requests from other agents, and relays their connections to servers in the cluster.
+// This allows agents that cannot reach the servers directly to join the cluster through an agent that can.
+type Server struct {
+	auth    string
+	allowed func(address string) bool
+}
+
+// NewServer returns a relay Server that authenticates clients using credentials derived from the token, and only
+// relays connections to host:port addresses for which the allowed function returns true.
+func NewServer(token string, allowed func(address string) bool) (*Server, error) {
+	password, err := Password(token)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{auth: basicAuth(relayUser, password), allowed: allowed}, nil
+}
+
+// ListenAndServe accepts relay connections on the address until the context is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	logrus.Infof("Relaying server connections for other agents on %s", listener.Addr())
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodConnect {
+		http.Error(resp, "only CONNECT requests are supported", http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(req.Header.Get("Proxy-Authorization")), []byte(s.auth)) != 1 {
+		logrus.Warnf("Rejected unauthenticated relay request from %s", req.RemoteAddr)
+		resp.Header().Set("Proxy-Authenticate", `Basic realm="relay"`)
+		resp.WriteHeader(http.StatusProxyAuthRequired)
+		return
+	}
+	if _, _, err := net.SplitHostPort(req.Host); err != nil || !s.allowed(req.Host) {
+		logrus.Warnf("Rejected relay request from %s to %s: not a server address", req.RemoteAddr, req.Host)
+		http.Error(resp, "destination is not a server in the cluster", http.StatusForbidden)
+		return
+	}
+
+	target, err := (&net.Dialer{Timeout: dialTimeout}).DialContext(req.Context(), "tcp", req.Host)
+	if err != nil {
+		logrus.Debugf("Failed to relay connection from %s to %s: %v", req.RemoteAddr, req.Host, err)
+		http.Error(resp, err.Error(), http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := resp.(http.Hijacker)
+	if !ok {
+		target.Close()
+		http.Error(resp, "connection cannot be relayed", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		target.Close()
+		return
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		conn.Close()
+		target.Close()
+		return
+	}
+	// Forward any data sent by the client along with the request.
+	if rw.Reader.Buffered() > 0 {
+		if _, err := io.CopyN(target, rw.Reader, int64(rw.Reader.Buffered())); err != nil {
+			conn.Close()
+			target.Close()
+			return
+		}
+	}
+	logrus.Debugf("Relaying connection from %s to %s", req.RemoteAddr, req.Host)
+	pipe(conn, target)
+}
+
+// pipe copies data in both directions between the connections until either is closed.
+func pipe(a, b net.Conn) {
+	var once sync.Once
+	closeBoth := func() {
+		a.Close()
+		b.Close()
+	}
+	go func() {
+		io.Copy(a, b)
+		once.Do(closeBoth)
+	}()
+	io.Copy(b, a)
+	once.Do(closeBoth)
+}
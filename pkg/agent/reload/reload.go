@@ -0,0 +1,200 @@
+package reload
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/k3s-io/k3s/pkg/agent/containerd"
+	"github.com/k3s-io/k3s/pkg/agent/proxy"
+	"github.com/k3s-io/k3s/pkg/clientaccess"
+	"github.com/k3s-io/k3s/pkg/configfilearg"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/kubernetes/pkg/util/taints"
+)
+
+// NodeTaintsPath is the supervisor path that nodes send their configured taints to. Nodes are not allowed to
+// modify their own taints, so taints added to the configuration are applied by the server.
+var NodeTaintsPath = "/v1-" + version.Program + "/node-taints"
+
+var trigger = make(chan struct{}, 1)
+
+// Trigger requests that the agent configuration is reloaded. It does not wait for the reload to complete.
+func Trigger() {
+	select {
+	case trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run reloads the agent configuration each time SIGHUP is received or a reload is triggered, and applies
+// the subset of agent configuration that can be changed without restarting the kubelet: node labels, node
+// taints, and registry mirrors and credentials. Changes to other flags are not applied until restart.
+func Run(ctx context.Context, nodeConfig *config.Node, proxy proxy.Proxy, nodes typedcorev1.NodeInterface) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+		case <-trigger:
+		}
+		logrus.Infof("Reloading agent configuration")
+		if err := reload(ctx, nodeConfig, proxy, nodes); err != nil {
+			logrus.Errorf("Failed to reload agent configuration: %v", err)
+			continue
+		}
+		logrus.Infof("Reloaded agent configuration")
+	}
+}
+
+// reload reads the command line and config file again, and applies the reloadable configuration.
+func reload(ctx context.Context, nodeConfig *config.Node, proxy proxy.Proxy, nodes typedcorev1.NodeInterface) error {
+	args, err := configfilearg.DefaultParser.Parse(os.Args)
+	if err != nil {
+		return errors.Wrap(err, "failed to read config file")
+	}
+	agentConfig := &nodeConfig.AgentConfig
+
+	var errs []error
+	nodeLabels := flagValues(args, "node-label")
+	if err := updateLabels(ctx, nodes, agentConfig.NodeName, agentConfig.NodeLabels, nodeLabels); err != nil {
+		errs = append(errs, errors.Wrap(err, "failed to update node labels"))
+	} else {
+		agentConfig.NodeLabels = nodeLabels
+	}
+
+	nodeTaints := flagValues(args, "node-taint")
+	if err := updateTaints(nodeConfig, proxy, agentConfig.NodeTaints, nodeTaints); err != nil {
+		errs = append(errs, errors.Wrap(err, "failed to update node taints"))
+	} else {
+		agentConfig.NodeTaints = nodeTaints
+	}
+
+	if kubeletArgs := flagValues(args, "kubelet-arg"); !equality.Semantic.DeepEqual(kubeletArgs, agentConfig.ExtraKubeletArgs) && (len(kubeletArgs) > 0 || len(agentConfig.ExtraKubeletArgs) > 0) {
+		logrus.Warnf("kubelet-arg has changed; the kubelet cannot be reconfigured while it is running, so %s must be restarted to apply the new kubelet args", version.Program)
+	}
+
+	if !nodeConfig.Docker && nodeConfig.ContainerRuntimeEndpoint == "" {
+		if err := containerd.ReloadRegistries(nodeConfig); err != nil {
+			errs = append(errs, errors.Wrap(err, "failed to reload registries"))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// flagValues returns the values of a flag in an os.Args style slice, in the order given. Values from the
+// config file are passed as --name=value, but values from the command line may also be passed as --name value.
+func flagValues(args []string, name string) []string {
+	values := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		arg = strings.TrimLeft(arg, "-")
+		if v, ok := strings.CutPrefix(arg, name+"="); ok {
+			values = append(values, v)
+		} else if arg == name && i+1 < len(args) {
+			values = append(values, args[i+1])
+			i++
+		}
+	}
+	return values
+}
+
+// parseLabels parses node labels in key=value form.
+func parseLabels(nodeLabels []string) map[string]string {
+	result := map[string]string{}
+	for _, label := range nodeLabels {
+		k, v, _ := strings.Cut(label, "=")
+		result[k] = v
+	}
+	return result
+}
+
+// updateLabels sets the configured labels on the node, and removes labels that were previously configured but
+// have since been removed from the configuration. Other labels on the node are not modified.
+func updateLabels(ctx context.Context, nodes typedcorev1.NodeInterface, nodeName string, oldLabels, newLabels []string) error {
+	oldSet, newSet := parseLabels(oldLabels), parseLabels(newLabels)
+	if equality.Semantic.DeepEqual(oldSet, newSet) {
+		return nil
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := nodes.Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if node.Labels == nil {
+			node.Labels = map[string]string{}
+		}
+		for k := range oldSet {
+			if _, ok := newSet[k]; !ok {
+				delete(node.Labels, k)
+			}
+		}
+		for k, v := range newSet {
+			node.Labels[k] = v
+		}
+		if _, err := nodes.Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		logrus.Infof("Updated labels on node %s", nodeName)
+		return nil
+	})
+}
+
+// updateTaints sends the configured taints to the supervisor, which adds any that are not already present on
+// the node. Taints removed from the configuration are not removed from the node, as nodes are not allowed to
+// remove their own taints; they must be removed with kubectl.
+func updateTaints(nodeConfig *config.Node, proxy proxy.Proxy, oldTaints, newTaints []string) error {
+	if equality.Semantic.DeepEqual(oldTaints, newTaints) {
+		return nil
+	}
+	toAdd, toRemove, err := taints.ParseTaints(newTaints)
+	if err != nil {
+		return err
+	}
+	if len(toRemove) > 0 {
+		return errors.New("taints cannot be removed by node-taint")
+	}
+	if old, _, err := taints.ParseTaints(oldTaints); err == nil {
+		for _, taint := range old {
+			if !taints.TaintExists(toAdd, &taint) {
+				logrus.Warnf("Taint %s has been removed from node-taint; nodes cannot remove their own taints, so it must be removed from node %s with kubectl", taint.ToString(), nodeConfig.AgentConfig.NodeName)
+			}
+		}
+	}
+	if len(toAdd) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(toAdd)
+	if err != nil {
+		return err
+	}
+	withCert := clientaccess.WithClientCertificate(nodeConfig.AgentConfig.ClientKubeletCert, nodeConfig.AgentConfig.ClientKubeletKey)
+	info, err := clientaccess.ParseAndValidateToken(proxy.SupervisorURL(), nodeConfig.Token, withCert)
+	if err != nil {
+		return err
+	}
+	return info.Put(NodeTaintsPath, body)
+}
@@ -0,0 +1,55 @@
+package reload
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_UnitFlagValues(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		flag string
+		want []string
+	}{
+		{
+			name: "No values",
+			args: []string{"k3s", "agent", "--server=https://10.0.0.1:6443"},
+			flag: "node-label",
+			want: []string{},
+		},
+		{
+			name: "Config file and command line values",
+			args: []string{"k3s", "agent", "--node-label=foo=bar", "--node-label=baz=", "--node-label", "qux=quux"},
+			flag: "node-label",
+			want: []string{"foo=bar", "baz=", "qux=quux"},
+		},
+		{
+			name: "Flag with common prefix",
+			args: []string{"k3s", "server", "--node-taint=a=b:NoSchedule", "--node-taints-extra=c", "-node-taint=d:NoExecute"},
+			flag: "node-taint",
+			want: []string{"a=b:NoSchedule", "d:NoExecute"},
+		},
+		{
+			name: "Values after terminator",
+			args: []string{"k3s", "agent", "--kubelet-arg=max-pods=150", "--", "--kubelet-arg=v=2"},
+			flag: "kubelet-arg",
+			want: []string{"max-pods=150"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flagValues(tt.args, tt.flag); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("flagValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitParseLabels(t *testing.T) {
+	got := parseLabels([]string{"foo=bar", "empty=", "novalue", "foo=baz"})
+	want := map[string]string{"foo": "baz", "empty": "", "novalue": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLabels() = %v, want %v", got, want)
+	}
+}
@@ -4,19 +4,30 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	goruntime "runtime"
 	"strconv"
 	"strings"
 	"time"
 
 	systemd "github.com/coreos/go-systemd/daemon"
+	"github.com/k3s-io/k3s/pkg/agent/certmonitor"
 	"github.com/k3s-io/k3s/pkg/agent/config"
 	"github.com/k3s-io/k3s/pkg/agent/containerd"
 	"github.com/k3s-io/k3s/pkg/agent/cridockerd"
+	"github.com/k3s-io/k3s/pkg/agent/drain"
+	"github.com/k3s-io/k3s/pkg/agent/externalip"
 	"github.com/k3s-io/k3s/pkg/agent/flannel"
+	"github.com/k3s-io/k3s/pkg/agent/loadbalancer"
 	"github.com/k3s-io/k3s/pkg/agent/netpol"
+	"github.com/k3s-io/k3s/pkg/agent/nodelocaldns"
 	"github.com/k3s-io/k3s/pkg/agent/proxy"
+	"github.com/k3s-io/k3s/pkg/agent/reboot"
+	"github.com/k3s-io/k3s/pkg/agent/registryauth"
+	"github.com/k3s-io/k3s/pkg/agent/relay"
+	"github.com/k3s-io/k3s/pkg/agent/reload"
 	"github.com/k3s-io/k3s/pkg/agent/syssetup"
 	"github.com/k3s-io/k3s/pkg/agent/tunnel"
 	"github.com/k3s-io/k3s/pkg/cgroups"
@@ -29,6 +40,7 @@ import (
 	"github.com/k3s-io/k3s/pkg/daemons/executor"
 	"github.com/k3s-io/k3s/pkg/nodeconfig"
 	"github.com/k3s-io/k3s/pkg/rootless"
+	"github.com/k3s-io/k3s/pkg/rootlessports"
 	"github.com/k3s-io/k3s/pkg/util"
 	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/pkg/errors"
@@ -43,15 +55,20 @@ import (
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 	toolswatch "k8s.io/client-go/tools/watch"
+	"k8s.io/client-go/util/retry"
 	app2 "k8s.io/kubernetes/cmd/kube-proxy/app"
 	kubeproxyconfig "k8s.io/kubernetes/pkg/proxy/apis/config"
 	utilsnet "k8s.io/utils/net"
 	utilpointer "k8s.io/utils/pointer"
 )
 
-func run(ctx context.Context, cfg cmds.Agent, proxy proxy.Proxy) error {
+func run(ctx context.Context, cfg cmds.Agent, proxy proxy.Proxy, drainer *drain.Drainer) error {
 	nodeConfig := config.Get(ctx, cfg, proxy)
 
+	if (cfg.ResolvConf == "" || cfg.ResolvConf == config.ResolvConfAuto) && nodeConfig.AgentConfig.ResolvConf != "" {
+		go config.WatchResolvConf(ctx, nodeConfig.AgentConfig.ResolvConf)
+	}
+
 	dualCluster, err := utilsnet.IsDualStackCIDRs(nodeConfig.AgentConfig.ClusterCIDRs)
 	if err != nil {
 		return errors.Wrap(err, "failed to validate cluster-cidr")
@@ -84,13 +101,30 @@ func run(ctx context.Context, cfg cmds.Agent, proxy proxy.Proxy) error {
 		return errors.Wrap(err, "failed to validate kube-proxy conntrack configuration")
 	}
 	syssetup.Configure(enableIPv6, conntrackConfig)
+	if err := syssetup.ConfigureHugePages(nodeConfig.AgentConfig.HugePages); err != nil {
+		return errors.Wrap(err, "failed to configure hugepages")
+	}
+	if err := syssetup.ConfigureTuningProfile(nodeConfig.AgentConfig.TuningProfile, nodeConfig.AgentConfig.ReservedCPUs); err != nil {
+		return errors.Wrap(err, "failed to apply tuning profile")
+	}
 	nodeConfig.AgentConfig.EnableIPv4 = enableIPv4
 	nodeConfig.AgentConfig.EnableIPv6 = enableIPv6
 
+	if util.IsProxyConfigured() {
+		setNoProxyEnv(nodeConfig)
+	}
+
 	if err := setupCriCtlConfig(cfg, nodeConfig); err != nil {
 		return err
 	}
 
+	registryAuthFile := filepath.Join(cfg.DataDir, "agent", "etc", "registry-auth.json")
+	if nodeConfig.AgentConfig.RegistryAuthSecret && goruntime.GOOS != "windows" {
+		if err := registryauth.Setup(&nodeConfig.AgentConfig, cfg.DataDir, registryAuthFile); err != nil {
+			return errors.Wrap(err, "failed to configure registry credential provider")
+		}
+	}
+
 	if err := executor.Bootstrap(ctx, nodeConfig, cfg); err != nil {
 		return err
 	}
@@ -144,6 +178,40 @@ func run(ctx context.Context, cfg cmds.Agent, proxy proxy.Proxy) error {
 		return err
 	}
 
+	if err := drain.Uncordon(ctx, coreClient, nodeConfig.AgentConfig.NodeName); err != nil {
+		logrus.Warnf("Failed to uncordon node after it was drained on shutdown: %v", err)
+	}
+	if drainer != nil {
+		drainer.Register(coreClient, nodeConfig.AgentConfig.NodeName)
+	}
+	if cfg.RebootSentinel != "" {
+		coordinator := reboot.New(cfg.RebootSentinel, strings.Fields(cfg.RebootCommand), cfg.RebootCheckPeriod, cfg.RebootDrainTimeout)
+		go coordinator.Run(ctx, reboot.NewSupervisorLease(nodeConfig, proxy), coreClient, nodeConfig.AgentConfig.NodeName)
+	}
+
+	go watchCertRotation(ctx, nodeConfig, coreClient.CoreV1().Nodes(), filepath.Join(cfg.DataDir, "agent", "cert-rotation"))
+	if nodeConfig.AgentConfig.NodeLocalDNS != nil {
+		go nodelocaldns.Run(ctx, nodeConfig.AgentConfig.NodeLocalDNS)
+	}
+	if nodeConfig.AgentConfig.RegistryAuthSecret && goruntime.GOOS != "windows" {
+		go registryauth.Watch(ctx, coreClient.CoreV1().Secrets(metav1.NamespaceSystem), registryAuthFile)
+	}
+	go certmonitor.Run(ctx, cfg.DataDir, nodeConfig.AgentConfig.CertificateExpiryWindow, nodeConfig.AgentConfig.NodeName, coreClient.CoreV1().Nodes())
+	if len(nodeConfig.AgentConfig.NodeExternalIPDiscovery) > 0 && !nodeConfig.AgentConfig.DisableCCM {
+		go watchExternalIP(ctx, nodeConfig, coreClient.CoreV1().Nodes())
+	}
+	go config.WatchKubeletSettings(ctx, nodeConfig, &cfg, proxy)
+	go reload.Run(ctx, nodeConfig, proxy, coreClient.CoreV1().Nodes())
+	if cfg.Rootless && !cfg.RootlessAlreadyUnshared {
+		flannelBackend := nodeConfig.FlannelBackend
+		if nodeConfig.NoFlannel {
+			flannelBackend = types.FlannelBackendNone
+		}
+		if err := rootlessports.RegisterAgent(ctx, coreClient, flannelBackend); err != nil {
+			return err
+		}
+	}
+
 	if !nodeConfig.NoFlannel {
 		if err := flannel.Run(ctx, nodeConfig, coreClient.CoreV1().Nodes()); err != nil {
 			return err
@@ -246,6 +314,37 @@ func Run(ctx context.Context, cfg cmds.Agent) error {
 		return err
 	}
 
+	// When draining on shutdown, the agent's components are kept running until the node has been drained.
+	var drainer *drain.Drainer
+	if cfg.GracefulShutdownDrain {
+		drainer = drain.New(cfg.DrainTimeout, cfg.DrainGracePeriod)
+		ctx = drainer.Context(ctx)
+	}
+
+	if err := validateSwapConfig(cfg); err != nil {
+		return err
+	}
+
+	if cfg.RebootSentinel != "" && (cfg.RebootCheckPeriod <= 0 || len(strings.Fields(cfg.RebootCommand)) == 0) {
+		return errors.New("invalid flag use; --reboot-sentinel requires a positive --reboot-check-period and a --reboot-command")
+	}
+
+	if cfg.ReserveResources != "" {
+		if err := agent.ValidateReserveResources(cfg.ReserveResources); err != nil {
+			return err
+		}
+	}
+	if cfg.KubeReserved != "" {
+		if err := agent.ValidateReservation(cfg.KubeReserved); err != nil {
+			return errors.Wrap(err, "invalid kube-reserved")
+		}
+	}
+	if cfg.SystemReserved != "" {
+		if err := agent.ValidateReservation(cfg.SystemReserved); err != nil {
+			return errors.Wrap(err, "invalid system-reserved")
+		}
+	}
+
 	if cfg.Rootless && !cfg.RootlessAlreadyUnshared {
 		dualNode, err := utilsnet.IsDualStackIPStrings(cfg.NodeIP)
 		if err != nil {
@@ -261,7 +360,63 @@ func Run(ctx context.Context, cfg cmds.Agent) error {
 		return err
 	}
 
-	return run(ctx, cfg, proxy)
+	if cfg.RelayListen != "" {
+		if err := startRelay(ctx, cfg, proxy); err != nil {
+			return err
+		}
+	}
+
+	return run(ctx, cfg, proxy, drainer)
+}
+
+// startRelay starts relaying connections to servers for other agents. Only connections to the
+// supervisor and apiserver ports of servers currently known to this agent are relayed.
+func startRelay(ctx context.Context, cfg cmds.Agent, proxy proxy.Proxy) error {
+	initialAddress := ""
+	if u, err := url.Parse(cfg.ServerURL); err == nil {
+		initialAddress = u.Host
+		if u.Port() == "" {
+			initialAddress = net.JoinHostPort(u.Hostname(), "443")
+		}
+	}
+	server, err := relay.NewServer(cfg.Token, func(address string) bool {
+		if address == initialAddress {
+			return true
+		}
+		for _, addresses := range [][]string{proxy.SupervisorAddresses(), proxy.APIServerAddresses()} {
+			for _, a := range addresses {
+				if a == address {
+					return true
+				}
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := server.ListenAndServe(ctx, cfg.RelayListen); err != nil {
+			logrus.Errorf("Relay failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// validateSwapConfig checks the kubelet swap configuration, and ensures that
+// the node supports swap if workloads are allowed to use it.
+func validateSwapConfig(cfg cmds.Agent) error {
+	switch cfg.SwapBehavior {
+	case "":
+		return nil
+	case daemonconfig.SwapBehaviorLimited, daemonconfig.SwapBehaviorUnlimited:
+	default:
+		return fmt.Errorf("invalid swap-behavior %s; valid values are '%s' and '%s'", cfg.SwapBehavior, daemonconfig.SwapBehaviorLimited, daemonconfig.SwapBehaviorUnlimited)
+	}
+	if cfg.FailSwapOn {
+		return errors.New("swap-behavior cannot be used with fail-swap-on")
+	}
+	return errors.Wrap(cgroups.ValidateSwap(), "failed to validate swap support")
 }
 
 func createProxyAndValidateToken(ctx context.Context, cfg *cmds.Agent) (proxy.Proxy, error) {
@@ -274,6 +429,21 @@ func createProxyAndValidateToken(ctx context.Context, cfg *cmds.Agent) (proxy.Pr
 	}
 	_, isIPv6, _ := util.GetFirstString([]string{cfg.NodeIP.String()})
 
+	// All connections to servers are made through the load balancers and the tunnel, so setting their
+	// dialer is sufficient to route everything through the relay.
+	if cfg.RelayURL != "" {
+		if cfg.DisableLoadBalancer {
+			return nil, errors.New("relay-url cannot be used with the load balancer disabled")
+		}
+		dialer, err := relay.NewDialer(cfg.RelayURL, cfg.Token)
+		if err != nil {
+			return nil, err
+		}
+		loadbalancer.SetDialer(dialer)
+		tunnel.SetDialer(dialer)
+		logrus.Infof("Connecting to servers through relay %s", cfg.RelayURL)
+	}
+
 	proxy, err := proxy.NewSupervisorProxy(ctx, !cfg.DisableLoadBalancer, agentDir, cfg.ServerURL, cfg.LBServerPort, isIPv6)
 	if err != nil {
 		return nil, err
@@ -371,6 +541,99 @@ func configureNode(ctx context.Context, nodeConfig *daemonconfig.Node, nodes typ
 	return nil
 }
 
+// watchExternalIP periodically rediscovers the node's external IP, and updates the node's address annotations
+// when it changes, so that the cloud controller updates the node's addresses. The flannel public IP is only
+// read when flannel starts, so changes to the flannel external IP take effect when the agent is restarted.
+func watchExternalIP(ctx context.Context, nodeConfig *daemonconfig.Node, nodes typedcorev1.NodeInterface) {
+	agentConfig := nodeConfig.AgentConfig
+	var current net.IP
+	if len(agentConfig.NodeExternalIPs) > 0 {
+		current = agentConfig.NodeExternalIPs[0]
+	}
+	externalip.Watch(ctx, agentConfig.NodeExternalIPDiscovery, agentConfig.NodeExternalIPSTUNServer, current, func(ip net.IP) error {
+		updatedConfig := *nodeConfig
+		updatedConfig.AgentConfig.NodeExternalIP = ip.String()
+		updatedConfig.AgentConfig.NodeExternalIPs = []net.IP{ip}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			node, err := nodes.Get(ctx, agentConfig.NodeName, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			annotations, changed := updateAddressAnnotations(&updatedConfig, node.Annotations)
+			if !changed {
+				return nil
+			}
+			node.Annotations = annotations
+			if labels, changed := updateLegacyAddressLabels(&updatedConfig.AgentConfig, node.Labels); changed {
+				node.Labels = labels
+			}
+			_, err = nodes.Update(ctx, node, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if nodeConfig.FlannelExternalIP {
+			logrus.Warnf("Node external IP changed; restart %s to update the flannel public IP", version.Program)
+		}
+		return nil
+	})
+}
+
+// watchCertRotation watches the node object for a certificate rotation request, as set by the
+// supervisor node management API. Agent certificates are requested from the supervisor on every
+// startup, so rotation is handled by exiting and allowing the service manager to restart us.
+// The most recently handled request is recorded to the state file to avoid restart loops in case
+// of clock skew between the server and this node.
+func watchCertRotation(ctx context.Context, nodeConfig *daemonconfig.Node, nodes typedcorev1.NodeInterface, stateFile string) {
+	startTime := time.Now()
+	fieldSelector := fields.Set{metav1.ObjectNameField: nodeConfig.AgentConfig.NodeName}.String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (object runtime.Object, e error) {
+			options.FieldSelector = fieldSelector
+			return nodes.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (i watch.Interface, e error) {
+			options.FieldSelector = fieldSelector
+			return nodes.Watch(ctx, options)
+		},
+	}
+
+	condition := func(ev watch.Event) (bool, error) {
+		node, ok := ev.Object.(*v1.Node)
+		if !ok {
+			return false, nil
+		}
+		requested, ok := node.Annotations[nodeconfig.CertRotationAnnotation]
+		if !ok {
+			return false, nil
+		}
+		if handled, err := os.ReadFile(stateFile); err == nil && strings.TrimSpace(string(handled)) == requested {
+			return false, nil
+		}
+		requestedTime, err := time.Parse(time.RFC3339, requested)
+		if err != nil {
+			logrus.Warnf("Ignoring invalid certificate rotation request %q on node %s: %v", requested, node.Name, err)
+			return false, nil
+		}
+		if requestedTime.Before(startTime) {
+			return false, nil
+		}
+		if err := os.WriteFile(stateFile, []byte(requested+"\n"), 0600); err != nil {
+			logrus.Warnf("Failed to record certificate rotation request: %v", err)
+		}
+		return true, nil
+	}
+
+	if _, err := toolswatch.UntilWithSync(ctx, lw, &v1.Node{}, nil, condition); err != nil {
+		if ctx.Err() == nil {
+			logrus.Errorf("Failed to watch for certificate rotation requests: %v", err)
+		}
+		return
+	}
+	logrus.Fatalf("Certificate rotation requested for node %s; exiting so that %s can be restarted with new certificates", nodeConfig.AgentConfig.NodeName, version.Program)
+}
+
 func updateMutableLabels(agentConfig *daemonconfig.Agent, nodeLabels map[string]string) (map[string]string, bool) {
 	result := map[string]string{}
 
@@ -407,6 +670,27 @@ func updateLegacyAddressLabels(agentConfig *daemonconfig.Agent, nodeLabels map[s
 	return nil, false
 }
 
+// setNoProxyEnv adds the cluster and service CIDRs, cluster domain, and node addresses to the NO_PROXY
+// environment variable, so that connections within the cluster made by the agent and containerd are not
+// sent through the proxy.
+func setNoProxyEnv(nodeConfig *daemonconfig.Node) {
+	agentConfig := &nodeConfig.AgentConfig
+	noProxy := util.AppendNoProxy(os.Getenv("NO_PROXY"), os.Getenv("no_proxy"),
+		"127.0.0.1",
+		"::1",
+		"localhost",
+		".svc",
+		"."+agentConfig.ClusterDomain,
+		util.JoinIPNets(agentConfig.ClusterCIDRs),
+		util.JoinIPNets(agentConfig.ServiceCIDRs),
+		util.JoinIPs(agentConfig.NodeIPs),
+		util.JoinIPs(agentConfig.NodeExternalIPs),
+	)
+	os.Unsetenv("no_proxy")
+	os.Setenv("NO_PROXY", noProxy)
+	logrus.Debugf("Set NO_PROXY to %s", noProxy)
+}
+
 // updateAddressAnnotations updates the node annotations with important information about IP addresses of the node
 func updateAddressAnnotations(nodeConfig *daemonconfig.Node, nodeAnnotations map[string]string) (map[string]string, bool) {
 	agentConfig := &nodeConfig.AgentConfig
@@ -6,6 +6,7 @@ package agent
 import (
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/k3s-io/k3s/pkg/cli/cmds"
 	"github.com/k3s-io/k3s/pkg/daemons/config"
@@ -17,9 +18,11 @@ const (
 )
 
 // setupCriCtlConfig creates the crictl config file and populates it
-// with the given data from config.
+// with the given data from config. The runtime and image endpoints are
+// the same sockets used by the kubelet, so that crictl and ctr always
+// talk to the runtime that is actually in use.
 func setupCriCtlConfig(cfg cmds.Agent, nodeConfig *config.Node) error {
-	cre := nodeConfig.ContainerRuntimeEndpoint
+	cre := nodeConfig.AgentConfig.RuntimeSocket
 	if cre == "" {
 		switch {
 		case cfg.Docker:
@@ -28,6 +31,11 @@ func setupCriCtlConfig(cfg cmds.Agent, nodeConfig *config.Node) error {
 			cre = containerdSock
 		}
 	}
+	cre = socketURL(cre)
+	ise := cre
+	if nodeConfig.AgentConfig.ImageServiceSocket != "" {
+		ise = socketURL(nodeConfig.AgentConfig.ImageServiceSocket)
+	}
 
 	agentConfDir := filepath.Join(cfg.DataDir, "agent", "etc")
 	if _, err := os.Stat(agentConfDir); os.IsNotExist(err) {
@@ -36,6 +44,14 @@ func setupCriCtlConfig(cfg cmds.Agent, nodeConfig *config.Node) error {
 		}
 	}
 
-	crp := "runtime-endpoint: " + cre + "\n"
+	crp := "runtime-endpoint: " + cre + "\n" + "image-endpoint: " + ise + "\n"
 	return os.WriteFile(agentConfDir+"/crictl.yaml", []byte(crp), 0600)
 }
+
+// socketURL adds the unix scheme to bare socket paths.
+func socketURL(socket string) string {
+	if strings.HasPrefix(socket, "/") {
+		return "unix://" + socket
+	}
+	return socket
+}
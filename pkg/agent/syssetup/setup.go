@@ -3,14 +3,20 @@
 package syssetup
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/cadvisor/machine"
 	"github.com/google/cadvisor/utils/sysfs"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/component-helpers/node/util/sysctl"
 	kubeproxyconfig "k8s.io/kubernetes/pkg/proxy/apis/config"
 )
@@ -76,6 +82,54 @@ func Configure(enableIPv6 bool, config *kubeproxyconfig.KubeProxyConntrackConfig
 	}
 }
 
+const hugePagesDir = "/sys/kernel/mm/hugepages"
+
+// ConfigureHugePages allocates the requested number of hugepages of each size, specified as size=count.
+// Pages are allocated before the kubelet starts, so that they are discovered and made available to pods.
+// The kernel may not be able to allocate all of the requested pages if memory is fragmented; in this
+// case a warning is logged, as the pages will need to be allocated at boot instead.
+func ConfigureHugePages(pages []string) error {
+	for _, page := range pages {
+		parts := strings.SplitN(page, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid hugepages %s, expected size=count", page)
+		}
+		size, err := resource.ParseQuantity(parts[0])
+		if err != nil {
+			return errors.Wrapf(err, "invalid hugepage size %s", parts[0])
+		}
+		count, err := strconv.Atoi(parts[1])
+		if err != nil || count < 0 {
+			return fmt.Errorf("invalid hugepage count %s", parts[1])
+		}
+
+		dir := filepath.Join(hugePagesDir, fmt.Sprintf("hugepages-%dkB", size.Value()/1024))
+		if _, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("kernel does not support hugepages of size %s", parts[0])
+		}
+		file := filepath.Join(dir, "nr_hugepages")
+		if current, _ := readInt(file); current == count {
+			continue
+		}
+		logrus.Infof("Allocating %d hugepages of size %s", count, parts[0])
+		if err := os.WriteFile(file, []byte(strconv.Itoa(count)), 0644); err != nil {
+			return errors.Wrapf(err, "failed to allocate hugepages of size %s", parts[0])
+		}
+		if allocated, err := readInt(file); err != nil || allocated < count {
+			logrus.Warnf("Only %d of %d hugepages of size %s could be allocated; consider allocating them at boot using the hugepagesz and hugepages kernel parameters", allocated, count, parts[0])
+		}
+	}
+	return nil
+}
+
+func readInt(file string) (int, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
 // getConntrackMax is cribbed from kube-proxy, as recent kernels no longer allow non-init namespaces
 // to set conntrack-related sysctls.
 // ref: https://github.com/kubernetes/kubernetes/blob/v1.21.1/cmd/kube-proxy/app/server.go#L780
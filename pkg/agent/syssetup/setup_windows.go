@@ -1,7 +1,18 @@
 package syssetup
 
-import kubeproxyconfig "k8s.io/kubernetes/pkg/proxy/apis/config"
+import (
+	"errors"
+
+	kubeproxyconfig "k8s.io/kubernetes/pkg/proxy/apis/config"
+)
 
 func Configure(enableIPv6 bool, config *kubeproxyconfig.KubeProxyConntrackConfiguration) {
 
 }
+
+func ConfigureHugePages(pages []string) error {
+	if len(pages) > 0 {
+		return errors.New("hugepages are not supported on windows")
+	}
+	return nil
+}
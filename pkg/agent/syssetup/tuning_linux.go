@@ -0,0 +1,174 @@
+//go:build linux
+
+package syssetup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+)
+
+// ConfigureTuningProfile applies the host tuning for the given profile. The low-latency profile moves
+// interrupt handling and the agent's own threads onto the reserved CPUs, leaving the remaining CPUs for
+// pods with exclusive CPU assignments, and checks that the kernel is configured for realtime workloads.
+// Kernel settings that can only be configured at boot are validated and warned about, but not changed.
+func ConfigureTuningProfile(profile, reservedCPUs string) error {
+	switch profile {
+	case "", "none":
+		return nil
+	case config.TuningProfileLowLatency:
+	default:
+		return fmt.Errorf("invalid tuning-profile %s; valid values are 'none' and '%s'", profile, config.TuningProfileLowLatency)
+	}
+
+	reserved, err := cpuset.Parse(reservedCPUs)
+	if err != nil || reserved.IsEmpty() {
+		return fmt.Errorf("tuning-profile %s requires reserved-cpus to be set", profile)
+	}
+	online, err := readCPUList("/sys/devices/system/cpu/online")
+	if err != nil {
+		return errors.Wrap(err, "failed to read online CPUs")
+	}
+	if !reserved.IsSubsetOf(online) {
+		return fmt.Errorf("reserved-cpus %s are not all online; online CPUs are %s", reserved, online)
+	}
+	isolated := online.Difference(reserved)
+	logrus.Infof("Applying %s tuning profile: housekeeping CPUs %s, isolated CPUs %s", profile, reserved, isolated)
+
+	setIRQAffinity(reserved)
+	if err := setProcessAffinity(reserved); err != nil {
+		logrus.Warnf("Failed to pin %s threads to reserved CPUs: %v", filepath.Base(os.Args[0]), err)
+	}
+	validateRealtimeKernel(isolated)
+	return nil
+}
+
+// setIRQAffinity sets the default and current affinity of all interrupts to the reserved CPUs. Managed
+// interrupts, such as per-queue NVMe interrupts, cannot be moved and are skipped.
+func setIRQAffinity(reserved cpuset.CPUSet) {
+	if err := os.WriteFile("/proc/irq/default_smp_affinity", []byte(cpuMask(reserved)), 0644); err != nil {
+		logrus.Warnf("Failed to set default IRQ affinity: %v", err)
+	}
+	files, _ := filepath.Glob("/proc/irq/*/smp_affinity_list")
+	skipped := 0
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte(reserved.String()), 0644); err != nil {
+			logrus.Debugf("Failed to set IRQ affinity in %s: %v", file, err)
+			skipped++
+		}
+	}
+	logrus.Infof("Set affinity of %d interrupts to CPUs %s; %d could not be moved", len(files)-skipped, reserved, skipped)
+
+	if processRunning("irqbalance") {
+		logrus.Warnf("irqbalance is running and may move interrupts onto isolated CPUs; set IRQBALANCE_BANNED_CPULIST to exclude them, or disable irqbalance")
+	}
+}
+
+// setProcessAffinity pins all threads of the current process to the reserved CPUs. Threads and child
+// processes created later inherit the affinity; container processes are moved to the CPUs assigned to
+// them by the kubelet CPU manager when they are placed in their cgroup.
+func setProcessAffinity(reserved cpuset.CPUSet) error {
+	set := &unix.CPUSet{}
+	for _, cpu := range reserved.List() {
+		set.Set(cpu)
+	}
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		if err := unix.SchedSetaffinity(tid, set); err != nil && err != unix.ESRCH {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRealtimeKernel warns about kernel settings that are recommended for low-latency workloads
+// on isolated CPUs, but can only be set when the kernel is built or booted.
+func validateRealtimeKernel(isolated cpuset.CPUSet) {
+	if b, err := os.ReadFile("/sys/kernel/realtime"); err != nil || strings.TrimSpace(string(b)) != "1" {
+		logrus.Warn("Kernel is not a PREEMPT_RT realtime kernel; scheduling latency may not be bounded")
+	}
+
+	cmdline, _ := os.ReadFile("/proc/cmdline")
+	params := map[string]string{}
+	for _, field := range strings.Fields(string(cmdline)) {
+		k, v, _ := strings.Cut(field, "=")
+		params[k] = v
+	}
+	for _, param := range []string{"isolcpus", "nohz_full", "rcu_nocbs"} {
+		value, ok := params[param]
+		if !ok {
+			logrus.Warnf("Kernel parameter %s is not set; consider booting with %s=%s", param, param, isolated)
+			continue
+		}
+		// isolcpus may be prefixed with flags, as in isolcpus=managed_irq,domain,2-7
+		if param == "isolcpus" {
+			parts := strings.Split(value, ",")
+			for len(parts) > 1 && strings.IndexAny(parts[0], "0123456789") != 0 {
+				parts = parts[1:]
+			}
+			value = strings.Join(parts, ",")
+		}
+		if set, err := cpuset.Parse(value); err != nil || !set.Equals(isolated) {
+			logrus.Warnf("Kernel parameter %s=%s does not match the isolated CPUs %s", param, value, isolated)
+		}
+	}
+
+	if b, err := os.ReadFile("/proc/sys/kernel/sched_rt_runtime_us"); err == nil && strings.TrimSpace(string(b)) != "-1" {
+		logrus.Infof("Realtime throttling is enabled (kernel.sched_rt_runtime_us=%s); realtime tasks may be preempted to run other tasks", strings.TrimSpace(string(b)))
+	}
+
+	for _, cpu := range isolated.List() {
+		file := fmt.Sprintf("/sys/devices/system/cpu/cpu%d/cpufreq/scaling_governor", cpu)
+		if b, err := os.ReadFile(file); err == nil && strings.TrimSpace(string(b)) != "performance" {
+			logrus.Warnf("CPU frequency governor of isolated CPU %d is %s; consider using the performance governor", cpu, strings.TrimSpace(string(b)))
+		}
+	}
+}
+
+// cpuMask returns the CPU set as a comma-separated list of 32-bit hex words, most significant first,
+// as used by /proc/irq/*/smp_affinity.
+func cpuMask(set cpuset.CPUSet) string {
+	cpus := set.List()
+	words := make([]uint32, cpus[len(cpus)-1]/32+1)
+	for _, cpu := range cpus {
+		words[cpu/32] |= 1 << (cpu % 32)
+	}
+	parts := make([]string, len(words))
+	for i, word := range words {
+		parts[len(words)-1-i] = fmt.Sprintf("%08x", word)
+	}
+	return strings.Join(parts, ",")
+}
+
+func readCPUList(file string) (cpuset.CPUSet, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return cpuset.New(), err
+	}
+	return cpuset.Parse(strings.TrimSpace(string(b)))
+}
+
+func processRunning(name string) bool {
+	comms, _ := filepath.Glob("/proc/[0-9]*/comm")
+	for _, comm := range comms {
+		if b, err := os.ReadFile(comm); err == nil && strings.TrimSpace(string(b)) == name {
+			return true
+		}
+	}
+	return false
+}
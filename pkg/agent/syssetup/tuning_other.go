@@ -0,0 +1,14 @@
+//go:build !linux
+
+package syssetup
+
+import (
+	"errors"
+)
+
+func ConfigureTuningProfile(profile, reservedCPUs string) error {
+	if profile != "" && profile != "none" {
+		return errors.New("tuning profiles are only supported on linux")
+	}
+	return nil
+}
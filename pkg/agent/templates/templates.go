@@ -9,6 +9,9 @@ import (
 type ContainerdRuntimeConfig struct {
 	RuntimeType string
 	BinaryName  string
+	// RuntimePath is the path to the shim binary, if the shim is not named after the runtime type or is
+	// not installed on the PATH.
+	RuntimePath string
 }
 
 type ContainerdConfig struct {
@@ -20,4 +23,23 @@ type ContainerdConfig struct {
 	PrivateRegistryConfig *registries.Registry
 	ExtraRuntimes         map[string]ContainerdRuntimeConfig
 	Program               string
+	// RegistryConfigPath is the directory containing the generated hosts.toml files for registry mirrors,
+	// if mirror endpoints are configured by host rather than in the CRI plugin config. containerd rejects
+	// registry TLS settings in the CRI plugin config when this is set, so they are only written to hosts.toml.
+	RegistryConfigPath string
+}
+
+// HostsConfig is the configuration rendered into the hosts.toml file for a registry.
+type HostsConfig struct {
+	Program   string
+	Server    string
+	ServerTLS *registries.TLSConfig
+	Hosts     []HostConfig
+}
+
+// HostConfig is the configuration of a single registry mirror endpoint in a hosts.toml file.
+type HostConfig struct {
+	URL      string
+	TLS      *registries.TLSConfig
+	Rewrites map[string]string
 }
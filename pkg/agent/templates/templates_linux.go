@@ -76,7 +76,7 @@ enable_keychain = true
 {{end}}
 {{end}}
 
-{{- if not .NodeConfig.NoFlannel }}
+{{- if .NodeConfig.AgentConfig.CNIBinDir }}
 [plugins."io.containerd.grpc.v1.cri".cni]
   bin_dir = "{{ .NodeConfig.AgentConfig.CNIBinDir }}"
   conf_dir = "{{ .NodeConfig.AgentConfig.CNIConfDir }}"
@@ -89,6 +89,10 @@ enable_keychain = true
   SystemdCgroup = {{ .SystemdCgroup }}
 
 {{ if .PrivateRegistryConfig }}
+{{ if .RegistryConfigPath }}
+[plugins."io.containerd.grpc.v1.cri".registry]
+  config_path = "{{ .RegistryConfigPath }}"
+{{ else }}
 {{ if .PrivateRegistryConfig.Mirrors }}
 [plugins."io.containerd.grpc.v1.cri".registry.mirrors]{{end}}
 {{range $k, $v := .PrivateRegistryConfig.Mirrors }}
@@ -101,6 +105,7 @@ enable_keychain = true
 {{end}}
 {{end}}
 {{end}}
+{{end}}
 
 {{range $k, $v := .PrivateRegistryConfig.Configs }}
 {{ if $v.Auth }}
@@ -110,7 +115,7 @@ enable_keychain = true
   {{ if $v.Auth.Auth }}auth = {{ printf "%q" $v.Auth.Auth }}{{end}}
   {{ if $v.Auth.IdentityToken }}identitytoken = {{ printf "%q" $v.Auth.IdentityToken }}{{end}}
 {{end}}
-{{ if $v.TLS }}
+{{ if and $v.TLS (not $.RegistryConfigPath) }}
 [plugins."io.containerd.grpc.v1.cri".registry.configs."{{$k}}".tls]
   {{ if $v.TLS.CAFile }}ca_file = "{{ $v.TLS.CAFile }}"{{end}}
   {{ if $v.TLS.CertFile }}cert_file = "{{ $v.TLS.CertFile }}"{{end}}
@@ -123,11 +128,55 @@ enable_keychain = true
 {{range $k, $v := .ExtraRuntimes}}
 [plugins."io.containerd.grpc.v1.cri".containerd.runtimes."{{$k}}"]
   runtime_type = "{{$v.RuntimeType}}"
+{{- if $v.RuntimePath }}
+  runtime_path = "{{$v.RuntimePath}}"
+{{- end }}
+{{- if $v.BinaryName }}
 [plugins."io.containerd.grpc.v1.cri".containerd.runtimes."{{$k}}".options]
   BinaryName = "{{$v.BinaryName}}"
+{{- end }}
 {{end}}
 `
 
+// HostsTomlTemplate is the template for the hosts.toml file of a registry, listing its mirror endpoints in
+// the order in which containerd should try them.
+const HostsTomlTemplate = `
+# File generated by {{ .Program }}. DO NOT EDIT.
+{{ if .Server }}server = "{{ .Server }}"{{ end }}
+{{- with .ServerTLS }}
+{{- if .CAFile }}
+ca = [{{ printf "%q" .CAFile }}]
+{{- end }}
+{{- if and .CertFile .KeyFile }}
+client = [[{{ printf "%q" .CertFile }}, {{ printf "%q" .KeyFile }}]]
+{{- end }}
+{{- if .InsecureSkipVerify }}
+skip_verify = true
+{{- end }}
+{{- end }}
+{{ range .Hosts }}
+[host."{{ .URL }}"]
+  capabilities = ["pull", "resolve"]
+{{- with .TLS }}
+{{- if .CAFile }}
+  ca = [{{ printf "%q" .CAFile }}]
+{{- end }}
+{{- if and .CertFile .KeyFile }}
+  client = [[{{ printf "%q" .CertFile }}, {{ printf "%q" .KeyFile }}]]
+{{- end }}
+{{- if .InsecureSkipVerify }}
+  skip_verify = true
+{{- end }}
+{{- end }}
+{{- if .Rewrites }}
+  [host."{{ .URL }}".rewrite]
+{{- range $pattern, $replace := .Rewrites }}
+    "{{ $pattern }}" = "{{ $replace }}"
+{{- end }}
+{{- end }}
+{{ end }}
+`
+
 func ParseTemplateFromConfig(templateBuffer string, config interface{}) (string, error) {
 	out := new(bytes.Buffer)
 	t := template.Must(template.New("compiled_template").Parse(templateBuffer))
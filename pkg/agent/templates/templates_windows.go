@@ -106,6 +106,14 @@ oom_score = 0
           runtime_root = ""
           privileged_without_host_devices = false
           base_runtime_spec = ""
+        [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runhcs-wcow-hypervisor]
+          runtime_type = "io.containerd.runhcs.v1"
+          runtime_engine = ""
+          runtime_root = ""
+          privileged_without_host_devices = false
+          base_runtime_spec = ""
+          [plugins."io.containerd.grpc.v1.cri".containerd.runtimes.runhcs-wcow-hypervisor.options]
+            SandboxIsolation = 1
     [plugins."io.containerd.grpc.v1.cri".cni]
       bin_dir = "{{ replace .NodeConfig.AgentConfig.CNIBinDir }}"
       conf_dir = "{{ replace .NodeConfig.AgentConfig.CNIConfDir }}"
@@ -0,0 +1,84 @@
+package tunnel
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+
+	daemonconfig "github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/logs"
+	"github.com/sirupsen/logrus"
+)
+
+// serveLogs serves the logs of the embedded components on the logs listener, until the context is cancelled.
+func (a *agentTunnel) serveLogs(ctx context.Context, config *daemonconfig.Node) {
+	mux := http.NewServeMux()
+	mux.Handle("/logs", logs.Handler(config.Containerd.Log))
+	server := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	if err := server.Serve(a.logs); err != nil && ctx.Err() == nil {
+		logrus.Errorf("Tunnel logs server failed: %v", err)
+	}
+}
+
+// explicit interface check
+var _ net.Listener = &pipeListener{}
+
+// pipeListener is a listener for in-process connections, so that a server can be reached through the tunnel
+// without listening on a port on the node.
+type pipeListener struct {
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+}
+
+// dial returns a connection to the listener, once it has been accepted.
+func (l *pipeListener) dial(ctx context.Context) (net.Conn, error) {
+	var err error
+	client, server := net.Pipe()
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.done:
+		err = net.ErrClosed
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	client.Close()
+	server.Close()
+	return nil, err
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "pipe" }
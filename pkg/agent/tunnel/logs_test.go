@@ -0,0 +1,66 @@
+package tunnel
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	daemonconfig "github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/yl2chen/cidranger"
+)
+
+func Test_UnitLogsListener(t *testing.T) {
+	a := &agentTunnel{cidrs: cidranger.NewPCTrieRanger(), logs: newPipeListener()}
+	server := &http.Server{Handler: http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.Write([]byte("logs\n"))
+	})}
+	go server.Serve(a.logs)
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		address    string
+		authorized bool
+	}{
+		{
+			name:       "ipv4 loopback",
+			address:    net.JoinHostPort("127.0.0.1", daemonconfig.LogsServerPort),
+			authorized: true,
+		},
+		{
+			name:       "ipv6 loopback",
+			address:    net.JoinHostPort("::1", daemonconfig.LogsServerPort),
+			authorized: true,
+		},
+		{
+			name:    "node address",
+			address: net.JoinHostPort("10.0.0.1", daemonconfig.LogsServerPort),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if got := a.authorized(ctx, "tcp", tt.address); got != tt.authorized {
+				t.Fatalf("authorized() = %v, want %v", got, tt.authorized)
+			}
+			if !tt.authorized {
+				return
+			}
+			client := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					return a.dialContext(ctx, network, tt.address)
+				},
+			}}
+			resp, err := client.Get("http://" + tt.address + "/logs")
+			if err != nil {
+				t.Fatalf("failed to get logs: %v", err)
+			}
+			defer resp.Body.Close()
+			if body, _ := io.ReadAll(resp.Body); string(body) != "logs\n" {
+				t.Errorf("logs = %q, want %q", body, "logs\n")
+			}
+		})
+	}
+}
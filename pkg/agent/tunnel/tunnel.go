@@ -3,6 +3,7 @@ package tunnel
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/gorilla/websocket"
 	agentconfig "github.com/k3s-io/k3s/pkg/agent/config"
+	"github.com/k3s-io/k3s/pkg/agent/loadbalancer"
 	"github.com/k3s-io/k3s/pkg/agent/proxy"
 	daemonconfig "github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/util"
@@ -37,8 +39,17 @@ import (
 
 var (
 	endpointDebounceDelay = time.Second
+
+	// dialer, if set, is used to connect the tunnel to servers, instead of connecting directly.
+	dialer loadbalancer.Dialer
 )
 
+// SetDialer sets the dialer used to connect the tunnel to servers.
+// This must be called before the tunnel is set up.
+func SetDialer(d loadbalancer.Dialer) {
+	dialer = d
+}
+
 type agentTunnel struct {
 	client      kubernetes.Interface
 	cidrs       cidranger.Ranger
@@ -46,6 +57,8 @@ type agentTunnel struct {
 	mode        string
 	kubeletPort string
 	startTime   time.Time
+	// logs accepts connections to the logs server, which is only reachable through the tunnel.
+	logs *pipeListener
 }
 
 // explicit interface check
@@ -107,6 +120,10 @@ func Setup(ctx context.Context, config *daemonconfig.Node, proxy proxy.Proxy) er
 		// Allow the kubelet port, as published via our node object.
 		go tunnel.setKubeletPort(ctx, apiServerReady)
 
+		// Serve the logs of the embedded components to servers, through the tunnel.
+		tunnel.logs = newPipeListener()
+		go tunnel.serveLogs(ctx, config)
+
 		switch tunnel.mode {
 		case daemonconfig.EgressSelectorModeCluster:
 			// In Cluster mode, we allow the cluster CIDRs, and any connections to the node's IPs for pods using host network.
@@ -132,6 +149,7 @@ func Setup(ctx context.Context, config *daemonconfig.Node, proxy proxy.Proxy) er
 		} else {
 			if endpoint, _ := client.CoreV1().Endpoints(metav1.NamespaceDefault).Get(ctx, "kubernetes", metav1.GetOptions{}); endpoint != nil {
 				if addresses := util.GetAddresses(endpoint); len(addresses) > 0 {
+					proxy.SetSupervisorAdvertiseAddresses(util.GetSupervisorAddresses(endpoint))
 					proxy.Update(addresses)
 				}
 			}
@@ -350,11 +368,14 @@ func (a *agentTunnel) watchEndpoints(ctx context.Context, apiServerReady <-chan
 					return
 				}
 
-				newAddresses := util.GetAddresses(endpoint)
-				if reflect.DeepEqual(newAddresses, proxy.SupervisorAddresses()) {
+				// Servers may advertise a supervisor address that differs from their apiserver
+				// address, so the supervisor addresses are compared after updating the proxy.
+				oldAddresses := proxy.SupervisorAddresses()
+				proxy.SetSupervisorAdvertiseAddresses(util.GetSupervisorAddresses(endpoint))
+				proxy.Update(util.GetAddresses(endpoint))
+				if reflect.DeepEqual(oldAddresses, proxy.SupervisorAddresses()) {
 					return
 				}
-				proxy.Update(newAddresses)
 
 				validEndpoint := map[string]bool{}
 
@@ -385,7 +406,7 @@ func (a *agentTunnel) authorized(ctx context.Context, proto, address string) boo
 	logrus.Debugf("Tunnel authorizer checking dial request for %s", address)
 	host, port, err := net.SplitHostPort(address)
 	if err == nil {
-		if a.isKubeletPort(proto, host, port) {
+		if a.isKubeletPort(proto, host, port) || a.isLogsPort(proto, host, port) {
 			return true
 		}
 		if ip := net.ParseIP(host); ip != nil {
@@ -410,6 +431,9 @@ func (a *agentTunnel) connect(rootCtx context.Context, waitGroup *sync.WaitGroup
 	ws := &websocket.Dialer{
 		TLSClientConfig: tlsConfig,
 	}
+	if dialer != nil {
+		ws.NetDialContext = dialer.DialContext
+	}
 
 	once := sync.Once{}
 	if waitGroup != nil {
@@ -420,14 +444,14 @@ func (a *agentTunnel) connect(rootCtx context.Context, waitGroup *sync.WaitGroup
 
 	go func() {
 		for {
-			remotedialer.ClientConnect(ctx, wsURL, nil, ws, func(proto, address string) bool {
-				return a.authorized(rootCtx, proto, address)
-			}, func(_ context.Context, _ *remotedialer.Session) error {
+			if err := a.connectToProxy(ctx, wsURL, ws, func() {
 				if waitGroup != nil {
 					once.Do(waitGroup.Done)
 				}
-				return nil
-			})
+			}); err != nil && !errors.Is(err, context.Canceled) {
+				logrus.WithError(err).Error("Remotedialer proxy error")
+				time.Sleep(5 * time.Second)
+			}
 
 			if ctx.Err() != nil {
 				if waitGroup != nil {
@@ -441,6 +465,59 @@ func (a *agentTunnel) connect(rootCtx context.Context, waitGroup *sync.WaitGroup
 	return cancel
 }
 
+// connectToProxy connects to the remotedialer server and serves dial requests until the connection fails or the
+// context is cancelled. This is the same as remotedialer.ConnectToProxy, except that dial requests are handled by
+// dialContext, so that connections to servers that do not listen on a port can be served through the tunnel.
+func (a *agentTunnel) connectToProxy(rootCtx context.Context, wsURL string, ws *websocket.Dialer, onConnect func()) error {
+	logrus.WithField("url", wsURL).Info("Connecting to proxy")
+	conn, resp, err := ws.DialContext(rootCtx, wsURL, nil)
+	if err != nil {
+		if resp != nil {
+			logrus.WithError(err).Errorf("Failed to connect to proxy. Response status: %v - %v", resp.StatusCode, resp.Status)
+		}
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(rootCtx)
+	defer cancel()
+
+	session := remotedialer.NewClientSessionWithDialer(func(proto, address string) bool {
+		return a.authorized(rootCtx, proto, address)
+	}, conn, a.dialContext)
+	defer session.Close()
+	onConnect()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := session.Serve(ctx)
+		result <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		logrus.WithField("url", wsURL).WithField("err", ctx.Err()).Info("Proxy done")
+		return nil
+	case err := <-result:
+		return err
+	}
+}
+
+// dialContext fulfills an authorized dial request from the server. Connections to the logs server are accepted
+// by the in-process logs listener; all other connections are dialed directly.
+func (a *agentTunnel) dialContext(ctx context.Context, proto, address string) (net.Conn, error) {
+	if host, port, err := net.SplitHostPort(address); err == nil && a.isLogsPort(proto, host, port) {
+		return a.logs.dial(ctx)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, proto, address)
+}
+
+// isLogsPort returns true if the connection is to the logs server port on a loopback address.
+func (a *agentTunnel) isLogsPort(proto, host, port string) bool {
+	return a.logs != nil && proto == "tcp" && (host == "127.0.0.1" || host == "::1") && port == daemonconfig.LogsServerPort
+}
+
 // isKubeletPort returns true if the connection is to a reserved TCP port on a loopback address.
 func (a *agentTunnel) isKubeletPort(proto, host, port string) bool {
 	return proto == "tcp" && (host == "127.0.0.1" || host == "::1") && (port == a.kubeletPort || port == daemonconfig.StreamServerPort)
@@ -0,0 +1,147 @@
+package airgap
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/k3s-io/k3s/pkg/agent/util"
+	"github.com/k3s-io/k3s/pkg/inventory"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/rancher/wharfie/pkg/registries"
+	"github.com/sirupsen/logrus"
+)
+
+// Fetcher returns the image for a reference.
+type Fetcher func(ref name.Reference) (v1.Image, error)
+
+// RegistryFetcher returns a Fetcher that pulls images for the given platform, using the mirrors, rewrites and
+// credentials from the private registry configuration file. Images are pulled from their upstream registry
+// if the configuration file does not exist.
+func RegistryFetcher(ctx context.Context, registriesFile string, platform v1.Platform) (Fetcher, error) {
+	registry, err := registries.GetPrivateRegistries(registriesFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load private registry configuration")
+	}
+	return func(ref name.Reference) (v1.Image, error) {
+		return registry.Image(ref, remote.WithPlatform(platform), remote.WithContext(ctx))
+	}, nil
+}
+
+// Images returns the references of the images deployed by the packaged manifests or used by the embedded
+// controllers of this version, followed by any extra images, sorted and without duplicates.
+func Images(extra []string) []string {
+	seen := map[string]bool{}
+	refs := []string{}
+	for _, image := range inventory.Images() {
+		ref := image.Source + ":" + image.Version
+		if image.Digest != "" {
+			ref += "@" + image.Digest
+		}
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	for _, ref := range extra {
+		if ref != "" && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// Export fetches the images and writes them to an image tarball at output, which is compressed according to
+// its file extension. The tarball is written to a temporary file and renamed once complete, so that a partial
+// tarball is never left in the agent images directory.
+func Export(refs []string, output string, fetch Fetcher) error {
+	images := map[name.Tag]v1.Image{}
+	for _, ref := range refs {
+		tag, pullRef, err := parseReference(ref)
+		if err != nil {
+			return errors.Wrapf(err, "invalid image reference %s", ref)
+		}
+		logrus.Infof("Pulling image %s", pullRef)
+		image, err := fetch(pullRef)
+		if err != nil {
+			return errors.Wrapf(err, "failed to pull image %s", pullRef)
+		}
+		images[tag] = image
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(output), "."+filepath.Base(output)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w, err := compressWriter(tmp, output)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Writing %d images to %s", len(images), output)
+	if err := tarball.MultiWrite(images, w); err != nil {
+		return errors.Wrap(err, "failed to write image tarball")
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), output)
+}
+
+// parseReference returns the tag that an image is stored under in the tarball, and the reference that it is
+// pulled by. Images pinned to a digest are pulled by digest, but keep their tag so that they can be found by
+// the packaged manifests after import.
+func parseReference(ref string) (name.Tag, name.Reference, error) {
+	tagRef, digest, _ := strings.Cut(ref, "@")
+	tag, err := name.NewTag(tagRef)
+	if err != nil {
+		return name.Tag{}, nil, err
+	}
+	if digest == "" {
+		return tag, tag, nil
+	}
+	digestRef, err := name.NewDigest(strings.TrimSuffix(tagRef, ":"+tag.TagStr()) + "@" + digest)
+	if err != nil {
+		return name.Tag{}, nil, err
+	}
+	return tag, digestRef, nil
+}
+
+// compressWriter returns a writer that compresses the image tarball according to the extension of the file
+// name. Only the extensions that are supported when importing images on agents are accepted.
+func compressWriter(w io.Writer, fileName string) (io.WriteCloser, error) {
+	switch {
+	case util.HasSuffixI(fileName, ".tar.zst", ".tzst"):
+		return zstd.NewWriter(w)
+	case util.HasSuffixI(fileName, ".tar.gz", ".tgz"):
+		return gzip.NewWriter(w), nil
+	case util.HasSuffixI(fileName, ".tar"):
+		return nopWriteCloser{w}, nil
+	}
+	return nil, fmt.Errorf("unsupported image tarball file name %s; supported extensions: .tar .tar.gz .tgz .tar.zst .tzst", fileName)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
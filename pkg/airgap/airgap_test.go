@@ -0,0 +1,127 @@
+package airgap
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/rancher/wharfie/pkg/tarfile"
+)
+
+func Test_UnitParseReference(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		wantTag  string
+		wantPull string
+		wantErr  bool
+	}{
+		{
+			name:     "tag",
+			ref:      "docker.io/rancher/mirrored-pause:3.6",
+			wantTag:  "docker.io/rancher/mirrored-pause:3.6",
+			wantPull: "docker.io/rancher/mirrored-pause:3.6",
+		},
+		{
+			name:     "tag and digest",
+			ref:      "docker.io/rancher/klipper-lb:v0.4.4@sha256:0b7e7ffd3e5c7bf3d0a6e5e5b22b9a3e4c4fd2c04cf3c7c3a0c8b6df2f9e6a11",
+			wantTag:  "docker.io/rancher/klipper-lb:v0.4.4",
+			wantPull: "docker.io/rancher/klipper-lb@sha256:0b7e7ffd3e5c7bf3d0a6e5e5b22b9a3e4c4fd2c04cf3c7c3a0c8b6df2f9e6a11",
+		},
+		{
+			name:    "invalid",
+			ref:     "docker.io/rancher/Invalid:tag",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, pull, err := parseReference(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tag.String() != tt.wantTag {
+				t.Errorf("parseReference() tag = %s, want %s", tag, tt.wantTag)
+			}
+			if pull.String() != tt.wantPull {
+				t.Errorf("parseReference() pull = %s, want %s", pull, tt.wantPull)
+			}
+		})
+	}
+}
+
+func Test_UnitExport(t *testing.T) {
+	refs := []string{"docker.io/rancher/mirrored-pause:3.6", "docker.io/rancher/klipper-helm:v0.8.0"}
+	images := map[string]v1.Image{}
+	for _, ref := range refs {
+		image, err := random.Image(256, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		images[ref] = image
+	}
+	fetch := func(ref name.Reference) (v1.Image, error) {
+		return images[ref.String()], nil
+	}
+
+	tests := []struct {
+		name     string
+		fileName string
+		wantErr  bool
+	}{
+		{
+			name:     "uncompressed",
+			fileName: "images.tar",
+		},
+		{
+			name:     "gzip",
+			fileName: "images.tar.gz",
+		},
+		{
+			name:     "zstd",
+			fileName: "k3s-airgap-images-amd64.tar.zst",
+		},
+		{
+			name:     "unsupported extension",
+			fileName: "images.zip",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), tt.fileName)
+			err := Export(refs, output, fetch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Export() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			opener, err := tarfile.GetOpener(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ref := range refs {
+				tag, err := name.NewTag(ref)
+				if err != nil {
+					t.Fatal(err)
+				}
+				image, err := tarball.Image(opener, &tag)
+				if err != nil {
+					t.Fatalf("image %s not found in tarball: %v", ref, err)
+				}
+				got, _ := image.Digest()
+				want, _ := images[ref].Digest()
+				if got != want {
+					t.Errorf("image %s digest = %s, want %s", ref, got, want)
+				}
+			}
+		})
+	}
+}
@@ -18,3 +18,25 @@ type AddonSpec struct {
 	Source   string `json:"source,omitempty"`
 	Checksum string `json:"checksum,omitempty"`
 }
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeJoinRequest is created by the server when a new node requests kubelet certificates while manual node
+// join approval is enabled. Certificates are not issued to the node until the request is approved.
+type NodeJoinRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NodeJoinRequestSpec `json:"spec,omitempty"`
+}
+
+type NodeJoinRequestSpec struct {
+	NodeName      string `json:"nodeName,omitempty"`
+	RemoteAddress string `json:"remoteAddress,omitempty"`
+	Approved      bool   `json:"approved,omitempty"`
+	Denied        bool   `json:"denied,omitempty"`
+	// PasswordHash is the hash of the password of the node that created the request. It is cleared once the node
+	// password secret has been created, after the request is approved.
+	PasswordHash string `json:"passwordHash,omitempty"`
+}
@@ -100,3 +100,79 @@ func (in *AddonSpec) DeepCopy() *AddonSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeJoinRequest) DeepCopyInto(out *NodeJoinRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeJoinRequest.
+func (in *NodeJoinRequest) DeepCopy() *NodeJoinRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeJoinRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeJoinRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeJoinRequestList) DeepCopyInto(out *NodeJoinRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NodeJoinRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeJoinRequestList.
+func (in *NodeJoinRequestList) DeepCopy() *NodeJoinRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeJoinRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NodeJoinRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeJoinRequestSpec) DeepCopyInto(out *NodeJoinRequestSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeJoinRequestSpec.
+func (in *NodeJoinRequestSpec) DeepCopy() *NodeJoinRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeJoinRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
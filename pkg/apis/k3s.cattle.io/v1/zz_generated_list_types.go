@@ -40,3 +40,20 @@ func NewAddon(namespace, name string, obj Addon) *Addon {
 	obj.Namespace = namespace
 	return &obj
 }
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeJoinRequestList is a list of NodeJoinRequest resources
+type NodeJoinRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []NodeJoinRequest `json:"items"`
+}
+
+func NewNodeJoinRequest(namespace, name string, obj NodeJoinRequest) *NodeJoinRequest {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("NodeJoinRequest").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
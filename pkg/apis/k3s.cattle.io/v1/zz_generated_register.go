@@ -28,7 +28,8 @@ import (
 )
 
 var (
-	AddonResourceName = "addons"
+	AddonResourceName           = "addons"
+	NodeJoinRequestResourceName = "nodejoinrequests"
 )
 
 // SchemeGroupVersion is group version used to register these objects
@@ -54,6 +55,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&Addon{},
 		&AddonList{},
+		&NodeJoinRequest{},
+		&NodeJoinRequestList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
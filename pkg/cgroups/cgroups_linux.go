@@ -15,6 +15,20 @@ import (
 	cgroupsv2 "github.com/containerd/cgroups/v2"
 	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/sirupsen/logrus"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+)
+
+var (
+	// sysRoot is the mount point of sysfs, which the cpuset controller files and online CPUs and NUMA nodes
+	// are read from.
+	sysRoot = "/sys"
+	// unified returns true if the unified (v2) cgroup hierarchy is in use.
+	unified = func() bool { return cgroups.Mode() == cgroups.Unified }
+	// enabledControllers returns the cgroup controllers that are available to the kubelet.
+	enabledControllers = func() map[string]bool {
+		_, _, controllers := CheckCgroups()
+		return controllers
+	}
 )
 
 func Validate() error {
@@ -24,6 +38,118 @@ func Validate() error {
 	return validateCgroupsV1()
 }
 
+// ValidateSwap checks that the node can support the kubelet NodeSwap feature, which requires
+// cgroup v2. A warning is logged if swap is not currently enabled on the node.
+func ValidateSwap() error {
+	if cgroups.Mode() != cgroups.Unified {
+		return errors.New("swap support requires cgroup v2")
+	}
+
+	swaps, err := os.ReadFile("/proc/swaps")
+	if err != nil {
+		return err
+	}
+	// The first line of /proc/swaps is a header; any additional lines are active swap devices or files.
+	if lines := strings.Split(strings.TrimSpace(string(swaps)), "\n"); len(lines) < 2 {
+		logrus.Warn("Swap behavior is configured, but swap is not enabled on this node")
+	}
+	return nil
+}
+
+// ValidateCPUManager checks that the node can support the kubelet static CPU manager policy, which assigns
+// exclusive CPUs to containers using the cpuset controller. The reserved CPUs must be available to the cgroup
+// that pods are created in, and must leave at least one CPU for pods.
+func ValidateCPUManager(reservedCPUs string) error {
+	if !enabledControllers()["cpuset"] {
+		return errors.New("cpu-manager-policy static requires the cpuset cgroup controller")
+	}
+	if reservedCPUs == "" {
+		return nil
+	}
+
+	reserved, err := cpuset.Parse(reservedCPUs)
+	if err != nil {
+		return err
+	}
+	available, err := availableCpuset("cpus", filepath.Join(sysRoot, "devices/system/cpu/online"))
+	if err != nil {
+		return err
+	}
+	if !reserved.IsSubsetOf(available) {
+		return fmt.Errorf("reserved-cpus %s are not available in cgroup cpuset %s", reserved.Difference(available), available)
+	}
+	if available.Difference(reserved).IsEmpty() {
+		return fmt.Errorf("reserved-cpus %s does not leave any CPUs for pods", reserved)
+	}
+	return nil
+}
+
+// ValidateMemoryManager checks that the NUMA nodes that memory is reserved on for the kubelet static memory
+// manager policy are available to the cgroup that pods are created in.
+func ValidateMemoryManager(reservedNodes []int) error {
+	available, err := numaNodes()
+	if err != nil {
+		return err
+	}
+	if reserved := cpuset.New(reservedNodes...); !reserved.IsSubsetOf(available) {
+		return fmt.Errorf("reserved-memory NUMA nodes %s are not available in cgroup cpuset %s", reserved.Difference(available), available)
+	}
+	return nil
+}
+
+// ValidateTopologyManager logs a warning if the topology manager policy aligns resources to NUMA nodes, but only
+// a single NUMA node is available to pods.
+func ValidateTopologyManager(policy string) error {
+	if policy != "restricted" && policy != "single-numa-node" {
+		return nil
+	}
+	available, err := numaNodes()
+	if err != nil {
+		return err
+	}
+	if available.Size() < 2 {
+		logrus.Warnf("Topology manager policy %s has no effect, as only NUMA node %s is available", policy, available)
+	}
+	return nil
+}
+
+// numaNodes returns the NUMA nodes that are available to pods. Kernels built without NUMA support have a
+// single node.
+func numaNodes() (cpuset.CPUSet, error) {
+	nodes, err := availableCpuset("mems", filepath.Join(sysRoot, "devices/system/node/online"))
+	if errors.Is(err, os.ErrNotExist) {
+		return cpuset.New(0), nil
+	}
+	return nodes, err
+}
+
+// availableCpuset returns the CPUs or memory nodes that are available to pods, from the cpuset controller of
+// the cgroup hierarchy root, or from the fallback path if the root cgroup does not restrict them.
+func availableCpuset(resource, fallback string) (cpuset.CPUSet, error) {
+	cgroupRoot := filepath.Join(sysRoot, "fs/cgroup")
+	paths := []string{filepath.Join(cgroupRoot, "cpuset."+resource+".effective")}
+	if !unified() {
+		paths = []string{filepath.Join(cgroupRoot, "cpuset/cpuset.effective_"+resource), filepath.Join(cgroupRoot, "cpuset/cpuset."+resource)}
+	}
+	for _, path := range append(paths, fallback) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && path != fallback {
+				continue
+			}
+			return cpuset.CPUSet{}, err
+		}
+		set, err := cpuset.Parse(strings.TrimSpace(string(b)))
+		if err != nil {
+			return cpuset.CPUSet{}, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if !set.IsEmpty() {
+			return set, nil
+		}
+	}
+	return cpuset.CPUSet{}, fmt.Errorf("no %s are available in %s", resource, fallback)
+}
+
 func validateCgroupsV1() error {
 	cgroups, err := os.ReadFile("/proc/self/cgroup")
 	if err != nil {
@@ -0,0 +1,325 @@
+//go:build linux
+// +build linux
+
+package cgroups
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupSysfs creates a sysfs tree with the given files under a temp dir, and points the package at it for the
+// duration of the test.
+func setupSysfs(t *testing.T, v2 bool, files map[string]string) {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldRoot, oldUnified := sysRoot, unified
+	sysRoot = root
+	unified = func() bool { return v2 }
+	t.Cleanup(func() {
+		sysRoot, unified = oldRoot, oldUnified
+	})
+}
+
+func Test_UnitAvailableCpuset(t *testing.T) {
+	tests := []struct {
+		name     string
+		v2       bool
+		resource string
+		files    map[string]string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "v2 effective cpus",
+			v2:       true,
+			resource: "cpus",
+			files: map[string]string{
+				"fs/cgroup/cpuset.cpus.effective": "0-3\n",
+				"devices/system/cpu/online":       "0-7\n",
+			},
+			want: "0-3",
+		},
+		{
+			name:     "v2 without cpuset controller",
+			v2:       true,
+			resource: "cpus",
+			files: map[string]string{
+				"devices/system/cpu/online": "0-7\n",
+			},
+			want: "0-7",
+		},
+		{
+			name:     "v2 empty effective cpus",
+			v2:       true,
+			resource: "cpus",
+			files: map[string]string{
+				"fs/cgroup/cpuset.cpus.effective": "\n",
+				"devices/system/cpu/online":       "0-7\n",
+			},
+			want: "0-7",
+		},
+		{
+			name:     "v1 effective cpus",
+			resource: "cpus",
+			files: map[string]string{
+				"fs/cgroup/cpuset/cpuset.effective_cpus": "2-5\n",
+				"fs/cgroup/cpuset/cpuset.cpus":           "0-7\n",
+				"devices/system/cpu/online":              "0-7\n",
+			},
+			want: "2-5",
+		},
+		{
+			name:     "v1 without effective cpus",
+			resource: "cpus",
+			files: map[string]string{
+				"fs/cgroup/cpuset/cpuset.cpus": "0,2,4\n",
+				"devices/system/cpu/online":    "0-7\n",
+			},
+			want: "0,2,4",
+		},
+		{
+			name:     "v1 ignores v2 files",
+			resource: "cpus",
+			files: map[string]string{
+				"fs/cgroup/cpuset.cpus.effective": "0\n",
+				"devices/system/cpu/online":       "0-7\n",
+			},
+			want: "0-7",
+		},
+		{
+			name:     "v2 effective mems",
+			v2:       true,
+			resource: "mems",
+			files: map[string]string{
+				"fs/cgroup/cpuset.mems.effective": "1\n",
+				"devices/system/node/online":      "0-1\n",
+			},
+			want: "1",
+		},
+		{
+			name:     "missing fallback",
+			v2:       true,
+			resource: "mems",
+			wantErr:  true,
+		},
+		{
+			name:     "empty fallback",
+			v2:       true,
+			resource: "cpus",
+			files: map[string]string{
+				"devices/system/cpu/online": "\n",
+			},
+			wantErr: true,
+		},
+		{
+			name:     "invalid cpuset",
+			v2:       true,
+			resource: "cpus",
+			files: map[string]string{
+				"fs/cgroup/cpuset.cpus.effective": "a-b\n",
+				"devices/system/cpu/online":       "0-7\n",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupSysfs(t, tt.v2, tt.files)
+			fallback := filepath.Join(sysRoot, "devices/system/cpu/online")
+			if tt.resource == "mems" {
+				fallback = filepath.Join(sysRoot, "devices/system/node/online")
+			}
+			got, err := availableCpuset(tt.resource, fallback)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("availableCpuset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("availableCpuset() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitValidateCPUManager(t *testing.T) {
+	tests := []struct {
+		name         string
+		v2           bool
+		noCpuset     bool
+		files        map[string]string
+		reservedCPUs string
+		wantErr      bool
+	}{
+		{
+			name:     "no cpuset controller",
+			v2:       true,
+			noCpuset: true,
+			files: map[string]string{
+				"devices/system/cpu/online": "0-7\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "no reserved cpus",
+			v2:   true,
+		},
+		{
+			name: "v2 reserved cpus available",
+			v2:   true,
+			files: map[string]string{
+				"fs/cgroup/cpuset.cpus.effective": "0-3\n",
+				"devices/system/cpu/online":       "0-7\n",
+			},
+			reservedCPUs: "0-1",
+		},
+		{
+			name: "v2 reserved cpus outside cgroup",
+			v2:   true,
+			files: map[string]string{
+				"fs/cgroup/cpuset.cpus.effective": "0-3\n",
+				"devices/system/cpu/online":       "0-7\n",
+			},
+			reservedCPUs: "3-4",
+			wantErr:      true,
+		},
+		{
+			name: "v2 reserved cpus leave none for pods",
+			v2:   true,
+			files: map[string]string{
+				"fs/cgroup/cpuset.cpus.effective": "0-3\n",
+				"devices/system/cpu/online":       "0-7\n",
+			},
+			reservedCPUs: "0-3",
+			wantErr:      true,
+		},
+		{
+			name: "v1 reserved cpus available",
+			files: map[string]string{
+				"fs/cgroup/cpuset/cpuset.effective_cpus": "0-3\n",
+				"devices/system/cpu/online":              "0-7\n",
+			},
+			reservedCPUs: "3",
+		},
+		{
+			name: "v1 reserved cpus outside cgroup",
+			files: map[string]string{
+				"fs/cgroup/cpuset/cpuset.cpus": "0-3\n",
+				"devices/system/cpu/online":    "0-7\n",
+			},
+			reservedCPUs: "6",
+			wantErr:      true,
+		},
+		{
+			name: "reserved cpus from online cpus",
+			v2:   true,
+			files: map[string]string{
+				"devices/system/cpu/online": "0-7\n",
+			},
+			reservedCPUs: "4-6",
+		},
+		{
+			name: "invalid reserved cpus",
+			v2:   true,
+			files: map[string]string{
+				"devices/system/cpu/online": "0-7\n",
+			},
+			reservedCPUs: "0-",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupSysfs(t, tt.v2, tt.files)
+			oldControllers := enabledControllers
+			enabledControllers = func() map[string]bool {
+				return map[string]bool{"cpu": true, "cpuset": !tt.noCpuset}
+			}
+			defer func() { enabledControllers = oldControllers }()
+
+			if err := ValidateCPUManager(tt.reservedCPUs); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCPUManager() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_UnitValidateMemoryManager(t *testing.T) {
+	tests := []struct {
+		name          string
+		v2            bool
+		files         map[string]string
+		reservedNodes []int
+		wantErr       bool
+	}{
+		{
+			name: "v2 reserved nodes available",
+			v2:   true,
+			files: map[string]string{
+				"fs/cgroup/cpuset.mems.effective": "0-1\n",
+				"devices/system/node/online":      "0-1\n",
+			},
+			reservedNodes: []int{0, 1},
+		},
+		{
+			name: "v2 reserved nodes outside cgroup",
+			v2:   true,
+			files: map[string]string{
+				"fs/cgroup/cpuset.mems.effective": "0\n",
+				"devices/system/node/online":      "0-1\n",
+			},
+			reservedNodes: []int{1},
+			wantErr:       true,
+		},
+		{
+			name: "v1 reserved nodes available",
+			files: map[string]string{
+				"fs/cgroup/cpuset/cpuset.effective_mems": "1\n",
+				"devices/system/node/online":             "0-1\n",
+			},
+			reservedNodes: []int{1},
+		},
+		{
+			name: "reserved nodes from online nodes",
+			v2:   true,
+			files: map[string]string{
+				"devices/system/node/online": "0-3\n",
+			},
+			reservedNodes: []int{2, 3},
+		},
+		{
+			name:          "kernel without numa support",
+			v2:            true,
+			reservedNodes: []int{0},
+		},
+		{
+			name:          "kernel without numa support reserved nodes unavailable",
+			v2:            true,
+			reservedNodes: []int{1},
+			wantErr:       true,
+		},
+		{
+			name: "no reserved nodes",
+			v2:   true,
+			files: map[string]string{
+				"devices/system/node/online": "0\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupSysfs(t, tt.v2, tt.files)
+			if err := ValidateMemoryManager(tt.reservedNodes); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateMemoryManager() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,10 +3,28 @@
 
 package cgroups
 
+import "errors"
+
 func Validate() error {
 	return nil
 }
 
+func ValidateSwap() error {
+	return errors.New("swap is not supported on windows")
+}
+
+func ValidateCPUManager(reservedCPUs string) error {
+	return errors.New("cpu-manager-policy static is not supported on windows")
+}
+
+func ValidateMemoryManager(reservedNodes []int) error {
+	return errors.New("memory-manager-policy Static is not supported on windows")
+}
+
+func ValidateTopologyManager(policy string) error {
+	return nil
+}
+
 func CheckCgroups() (kubeletRoot, runtimeRoot string, controllers map[string]bool) {
 	return
 }
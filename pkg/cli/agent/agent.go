@@ -9,8 +9,10 @@ import (
 
 	"github.com/erikdubbelboer/gspt"
 	"github.com/k3s-io/k3s/pkg/agent"
+	"github.com/k3s-io/k3s/pkg/agent/hollow"
 	"github.com/k3s-io/k3s/pkg/cli/cmds"
 	"github.com/k3s-io/k3s/pkg/datadir"
+	"github.com/k3s-io/k3s/pkg/preflight"
 	"github.com/k3s-io/k3s/pkg/token"
 	"github.com/k3s-io/k3s/pkg/util"
 	"github.com/k3s-io/k3s/pkg/version"
@@ -35,7 +37,7 @@ func Run(ctx *cli.Context) error {
 		return err
 	}
 
-	if runtime.GOOS != "windows" && os.Getuid() != 0 && !cmds.AgentConfig.Rootless {
+	if runtime.GOOS != "windows" && os.Getuid() != 0 && !cmds.AgentConfig.Rootless && cmds.AgentConfig.Simulate == 0 {
 		return fmt.Errorf("agent must be run as root, or with --rootless")
 	}
 
@@ -74,6 +76,20 @@ func Run(ctx *cli.Context) error {
 	cfg.Debug = ctx.GlobalBool("debug")
 	cfg.DataDir = dataDir
 
+	if cfg.Simulate > 0 {
+		return hollow.Run(signals.SetupSignalContext(), cfg)
+	}
+
+	if err := preflight.Run(cfg.Preflight, &preflight.Config{
+		DataDir:         dataDir,
+		Ports:           []int{10250},
+		Agent:           true,
+		Rootless:        cfg.Rootless,
+		ExternalRuntime: cfg.Docker || cfg.ContainerRuntimeEndpoint != "",
+	}); err != nil {
+		return err
+	}
+
 	contextCtx := signals.SetupSignalContext()
 
 	return agent.Run(contextCtx, cfg)
@@ -0,0 +1,403 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/util"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	resultPass = "pass"
+	resultFail = "fail"
+	resultSkip = "skip"
+
+	httpPort = 8080
+)
+
+// result is the outcome of a single check.
+type result struct {
+	Name     string        `json:"name" yaml:"name"`
+	Result   string        `json:"result" yaml:"result"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+	Message  string        `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// checker runs checks against the cluster, creating resources in its namespace.
+type checker struct {
+	client kubernetes.Interface
+	cfg    *cmds.Check
+}
+
+// checkFunc runs a check, and returns a message describing the result.
+type checkFunc func(ctx context.Context, c *checker) (string, error)
+
+var checks = []struct {
+	name string
+	run  checkFunc
+}{
+	{name: "pod", run: checkPod},
+	{name: "dns", run: checkDNS},
+	{name: "registry", run: checkRegistry},
+	{name: "loadbalancer", run: checkLoadBalancer},
+	{name: "pvc", run: checkPVC},
+}
+
+func Cluster(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return cluster(app, &cmds.CheckConfig)
+}
+
+func cluster(app *cli.Context, cfg *cmds.Check) error {
+	cfg.Kubeconfig = util.GetKubeConfigPath(cfg.Kubeconfig)
+	client, err := util.GetClientSet(cfg.Kubeconfig)
+	if err != nil {
+		return err
+	}
+	skip := map[string]bool{}
+	for _, name := range cfg.Skip {
+		for _, name := range strings.Split(name, ",") {
+			skip[strings.TrimSpace(name)] = true
+		}
+	}
+	for name := range skip {
+		if !validCheck(name) {
+			return fmt.Errorf("invalid check %s in --skip", name)
+		}
+	}
+
+	ctx := context.Background()
+	c := &checker{client: client, cfg: cfg}
+	if err := c.createNamespace(ctx); err != nil {
+		return err
+	}
+	if !cfg.Keep {
+		defer c.deleteNamespace(ctx)
+	}
+
+	results := make([]result, 0, len(checks))
+	ran, failed := 0, 0
+	for _, check := range checks {
+		r := result{Name: check.name, Result: resultSkip}
+		if !skip[check.name] {
+			logrus.Infof("Running %s check", check.name)
+			checkCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+			start := time.Now()
+			message, err := check.run(checkCtx, c)
+			cancel()
+			ran++
+			r.Duration = time.Since(start).Round(time.Millisecond)
+			if err != nil {
+				r.Result, r.Message = resultFail, err.Error()
+				failed++
+			} else {
+				r.Result, r.Message = resultPass, message
+			}
+		}
+		results = append(results, r)
+	}
+
+	if err := printResults(cfg.Output, results); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, ran)
+	}
+	return nil
+}
+
+func validCheck(name string) bool {
+	for _, check := range checks {
+		if check.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func printResults(output string, results []result) error {
+	switch output {
+	case "json":
+		return json.NewEncoder(os.Stdout).Encode(results)
+	case "yaml":
+		return yaml.NewEncoder(os.Stdout).Encode(results)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", "CHECK", "RESULT", "DURATION", "MESSAGE")
+		for _, r := range results {
+			duration := "-"
+			if r.Result != resultSkip {
+				duration = r.Duration.String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, strings.ToUpper(r.Result), duration, r.Message)
+		}
+	}
+	return nil
+}
+
+func (c *checker) createNamespace(ctx context.Context) error {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   c.cfg.Namespace,
+			Labels: map[string]string{"app.kubernetes.io/managed-by": version.Program + "-check"},
+		},
+	}
+	if _, err := c.client.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("namespace %s already exists; delete it or use --namespace to select another namespace", c.cfg.Namespace)
+		}
+		return errors.Wrapf(err, "failed to create namespace %s", c.cfg.Namespace)
+	}
+	return nil
+}
+
+func (c *checker) deleteNamespace(ctx context.Context) {
+	if err := c.client.CoreV1().Namespaces().Delete(ctx, c.cfg.Namespace, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		logrus.Warnf("Failed to delete namespace %s: %v", c.cfg.Namespace, err)
+	}
+}
+
+// newPod returns a pod that runs the given shell command in the check image.
+func (c *checker) newPod(name, command string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.cfg.Namespace,
+			Labels:    map[string]string{"app": name},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			TerminationGracePeriodSeconds: pointer.Int64(0),
+			Containers: []corev1.Container{{
+				Name:            name,
+				Image:           c.cfg.Image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"sh", "-c", command},
+			}},
+		},
+	}
+}
+
+// runPod creates the pod, and waits for it to reach the given phase. If the pod does not reach the
+// phase, the error includes the reason that its container is waiting or terminated, if any.
+func (c *checker) runPod(ctx context.Context, pod *corev1.Pod, phase corev1.PodPhase) (*corev1.Pod, error) {
+	name := pod.Name
+	pods := c.client.CoreV1().Pods(c.cfg.Namespace)
+	pod, err := pods.Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create pod %s", name)
+	}
+	err = wait.PollImmediateUntilWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
+		current, err := pods.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		pod = current
+		if pod.Status.Phase == phase {
+			return true, nil
+		}
+		if pod.Status.Phase == corev1.PodFailed {
+			return false, fmt.Errorf("pod %s failed%s", name, podReason(pod))
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout || errors.Is(err, context.DeadlineExceeded) {
+		return pod, fmt.Errorf("timed out waiting for pod %s to be %s; pod is %s%s", name, phase, pod.Status.Phase, podReason(pod))
+	}
+	return pod, err
+}
+
+// podReason returns a description of why the pod's container is not running, or why the pod is not scheduled.
+func podReason(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if state := status.State.Waiting; state != nil && state.Reason != "" {
+			return fmt.Sprintf(": %s: %s", state.Reason, state.Message)
+		}
+		if state := status.State.Terminated; state != nil && state.ExitCode != 0 {
+			return fmt.Sprintf(": container exited with code %d: %s", state.ExitCode, strings.TrimSpace(state.Message))
+		}
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			return fmt.Sprintf(": %s: %s", condition.Reason, condition.Message)
+		}
+	}
+	return ""
+}
+
+// checkPod checks that a pod can be scheduled, and that its container runs.
+func checkPod(ctx context.Context, c *checker) (string, error) {
+	pod, err := c.runPod(ctx, c.newPod("check-pod", "true"), corev1.PodSucceeded)
+	if err != nil {
+		return "", err
+	}
+	return "pod ran on node " + pod.Spec.NodeName, nil
+}
+
+// checkDNS checks that the kubernetes service name can be resolved from a pod.
+func checkDNS(ctx context.Context, c *checker) (string, error) {
+	host := "kubernetes.default.svc." + c.cfg.ClusterDomain
+	if _, err := c.runPod(ctx, c.newPod("check-dns", "nslookup "+host), corev1.PodSucceeded); err != nil {
+		return "", err
+	}
+	return "resolved " + host, nil
+}
+
+// checkRegistry checks that the image can be pulled, through any registry mirrors configured on the node.
+func checkRegistry(ctx context.Context, c *checker) (string, error) {
+	pod := c.newPod("check-registry", "true")
+	pod.Spec.Containers[0].ImagePullPolicy = corev1.PullAlways
+	pod, err := c.runPod(ctx, pod, corev1.PodSucceeded)
+	if err != nil {
+		return "", err
+	}
+	image := c.cfg.Image
+	if len(pod.Status.ContainerStatuses) > 0 && pod.Status.ContainerStatuses[0].ImageID != "" {
+		image = pod.Status.ContainerStatuses[0].ImageID
+	}
+	return fmt.Sprintf("pulled %s on node %s", image, pod.Spec.NodeName), nil
+}
+
+// checkLoadBalancer checks that a LoadBalancer service is assigned an address, and that a request to
+// the address reaches the pod behind the service.
+func checkLoadBalancer(ctx context.Context, c *checker) (string, error) {
+	name := "check-loadbalancer"
+	pod := c.newPod(name, fmt.Sprintf("mkdir -p /www && echo %s > /www/index.html && httpd -f -p %d -h /www", name, httpPort))
+	pod.Spec.Containers[0].Ports = []corev1.ContainerPort{{ContainerPort: httpPort}}
+	if _, err := c.runPod(ctx, pod, corev1.PodRunning); err != nil {
+		return "", err
+	}
+
+	services := c.client.CoreV1().Services(c.cfg.Namespace)
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: c.cfg.Namespace},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeLoadBalancer,
+			Selector: pod.Labels,
+			Ports: []corev1.ServicePort{{
+				Name:       "http",
+				Port:       httpPort,
+				TargetPort: intstr.FromInt(httpPort),
+			}},
+		},
+	}
+	if _, err := services.Create(ctx, svc, metav1.CreateOptions{}); err != nil {
+		return "", errors.Wrapf(err, "failed to create service %s", name)
+	}
+
+	var url string
+	err := wait.PollImmediateUntilWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
+		svc, err := services.Get(ctx, name, metav1.GetOptions{})
+		if err != nil || len(svc.Status.LoadBalancer.Ingress) == 0 {
+			return false, nil
+		}
+		ingress := svc.Status.LoadBalancer.Ingress[0]
+		host := ingress.IP
+		if host == "" {
+			host = ingress.Hostname
+		}
+		url = "http://" + net.JoinHostPort(host, strconv.Itoa(httpPort)) + "/"
+		return true, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("timed out waiting for service %s to be assigned a load balancer address", name)
+	}
+
+	var lastErr error
+	err = wait.PollImmediateUntilWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
+		lastErr = httpGet(ctx, url, name)
+		return lastErr == nil, nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(lastErr, "timed out waiting for a response from %s", url)
+	}
+	return "received response from " + url, nil
+}
+
+func httpGet(ctx context.Context, url, expected string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != expected {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return nil
+}
+
+// checkPVC checks that a PVC using the default storage class is bound, and that a pod can write to it.
+func checkPVC(ctx context.Context, c *checker) (string, error) {
+	name := "check-pvc"
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: c.cfg.Namespace},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("64Mi")},
+			},
+		},
+	}
+	pvcs := c.client.CoreV1().PersistentVolumeClaims(c.cfg.Namespace)
+	if _, err := pvcs.Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		return "", errors.Wrapf(err, "failed to create persistent volume claim %s", name)
+	}
+
+	pod := c.newPod(name, "echo "+name+" > /data/check && grep -q "+name+" /data/check")
+	pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "data", MountPath: "/data"}}
+	pod.Spec.Volumes = []corev1.Volume{{
+		Name: "data",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name},
+		},
+	}}
+	if _, err := c.runPod(ctx, pod, corev1.PodSucceeded); err != nil {
+		return "", err
+	}
+
+	pvc, err := pvcs.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	storageClass := "<default>"
+	if pvc.Spec.StorageClassName != nil {
+		storageClass = *pvc.Spec.StorageClassName
+	}
+	return fmt.Sprintf("wrote to volume %s from storage class %s", pvc.Spec.VolumeName, storageClass), nil
+}
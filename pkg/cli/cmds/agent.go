@@ -3,6 +3,7 @@ package cmds
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/urfave/cli"
@@ -17,17 +18,34 @@ type Agent struct {
 	DisableLoadBalancer      bool
 	DisableServiceLB         bool
 	ETCDAgent                bool
+	Server                   bool
 	LBServerPort             int
 	ResolvConf               string
 	DataDir                  string
 	NodeIP                   cli.StringSlice
+	NodeIPDiscovery          cli.StringSlice
 	NodeExternalIP           cli.StringSlice
+	NodeExternalIPDiscovery  cli.StringSlice
+	NodeExternalIPSTUNServer string
+	RelayURL                 string
+	RelayListen              string
+	LocalRegistryListen      string
+	LocalRegistryAuth        string
+	LocalRegistryName        string
+	RegistryHealthInterval   time.Duration
+	RegistryHealthTimeout    time.Duration
+	RegistryAuthSecret       bool
+	ImagePullBandwidthLimit  string
+	ContainerdLogMaxSize     string
+	ContainerdLogMaxBackups  int
+	RuntimeSearchPath        cli.StringSlice
 	NodeName                 string
 	PauseImage               string
 	Snapshotter              string
 	Docker                   bool
 	ContainerRuntimeEndpoint string
 	FlannelIface             string
+	NetworkPolicyLogging     bool
 	FlannelConf              string
 	FlannelCniConfFile       string
 	Debug                    bool
@@ -36,6 +54,29 @@ type Agent struct {
 	WithNodeID               bool
 	EnableSELinux            bool
 	ProtectKernelDefaults    bool
+	FailSwapOn               bool
+	SwapBehavior             string
+	ReserveResources         string
+	KubeReserved             string
+	SystemReserved           string
+	CPUManagerPolicy         string
+	ReservedCPUs             string
+	MemoryManagerPolicy      string
+	ReservedMemory           string
+	TopologyManagerPolicy    string
+	TopologyManagerScope     string
+	TuningProfile            string
+	HugePages                cli.StringSlice
+	KubeletRootDir           string
+	AllowedUnsafeSysctls     cli.StringSlice
+	ContainerLogMaxSize      string
+	ContainerLogMaxFiles     int
+	ImageGCHighThreshold     int
+	ImageGCLowThreshold      int
+	PinPreloadedImages       bool
+	StaticPodDir             string
+	CertificateExpiryWindow  time.Duration
+	Preflight                string
 	ClusterReset             bool
 	PrivateRegistry          string
 	SystemDefaultRegistry    string
@@ -46,6 +87,14 @@ type Agent struct {
 	Taints                   cli.StringSlice
 	ImageCredProvBinDir      string
 	ImageCredProvConfig      string
+	Simulate                 int
+	GracefulShutdownDrain    bool
+	DrainTimeout             time.Duration
+	DrainGracePeriod         int
+	RebootSentinel           string
+	RebootCommand            string
+	RebootCheckPeriod        time.Duration
+	RebootDrainTimeout       time.Duration
 	AgentReady               chan<- struct{}
 	AgentShared
 }
@@ -68,11 +117,131 @@ var (
 		Usage: "(agent/networking) IPv4/IPv6 addresses to advertise for node",
 		Value: &AgentConfig.NodeIP,
 	}
+	NodeIPDiscoveryFlag = &cli.StringSliceFlag{
+		Name:  "node-ip-discovery",
+		Usage: "(agent/networking) Methods to use, in order, to discover the IP address to advertise for node if node-ip is not set: metadata, interface:<glob>, script:<path>",
+		Value: &AgentConfig.NodeIPDiscovery,
+	}
 	NodeExternalIPFlag = &cli.StringSliceFlag{
 		Name:  "node-external-ip",
 		Usage: "(agent/networking) IPv4/IPv6 external IP addresses to advertise for node",
 		Value: &AgentConfig.NodeExternalIP,
 	}
+	NodeExternalIPDiscoveryFlag = &cli.StringSliceFlag{
+		Name:  "node-external-ip-discovery",
+		Usage: "(agent/networking) Methods to use, in order, to discover the external IP address to advertise for node if node-external-ip is not set: metadata, stun, interface:<glob>, script:<path>",
+		Value: &AgentConfig.NodeExternalIPDiscovery,
+	}
+	NodeExternalIPSTUNServerFlag = &cli.StringFlag{
+		Name:        "node-external-ip-stun-server",
+		Usage:       "(agent/networking) STUN server address used to discover the external IP address for node",
+		Destination: &AgentConfig.NodeExternalIPSTUNServer,
+		Value:       "stun.l.google.com:19302",
+	}
+	RelayURLFlag = &cli.StringFlag{
+		Name:        "relay-url",
+		Usage:       "(agent/networking) URL of a relay agent or HTTP CONNECT proxy used to connect to servers that cannot be reached directly; proxy credentials may be included in the URL",
+		Destination: &AgentConfig.RelayURL,
+	}
+	RelayListenFlag = &cli.StringFlag{
+		Name:        "relay-listen",
+		Usage:       "(agent/networking) Address on which to relay connections to servers for other agents that set relay-url and use the same token, for example 0.0.0.0:6445. Only the supervisor and apiserver ports of servers are relayed",
+		Destination: &AgentConfig.RelayListen,
+	}
+	LocalRegistryListenFlag = &cli.StringFlag{
+		Name:        "local-registry-listen",
+		Usage:       "(agent/runtime) Address on which to serve a writable registry backed by the embedded containerd image store, for example 127.0.0.1:5000",
+		Destination: &AgentConfig.LocalRegistryListen,
+	}
+	LocalRegistryAuthFlag = &cli.StringFlag{
+		Name:        "local-registry-auth",
+		Usage:       "(agent/runtime) Credentials in username:password format required to push to and pull from the local registry",
+		EnvVar:      version.ProgramUpper + "_LOCAL_REGISTRY_AUTH",
+		Destination: &AgentConfig.LocalRegistryAuth,
+	}
+	LocalRegistryNameFlag = &cli.StringFlag{
+		Name:        "local-registry-name",
+		Usage:       "(agent/runtime) Registry name under which images pushed to the local registry are stored, and with which pods reference them (default: localhost and the local-registry-listen port)",
+		Destination: &AgentConfig.LocalRegistryName,
+	}
+	RegistryHealthIntervalFlag = &cli.DurationFlag{
+		Name:        "registry-health-check-interval",
+		Usage:       "(agent/runtime) Interval at which registry mirror endpoints are probed, so that unhealthy endpoints are tried last (0 to disable)",
+		Destination: &AgentConfig.RegistryHealthInterval,
+	}
+	RegistryHealthTimeoutFlag = &cli.DurationFlag{
+		Name:        "registry-health-check-timeout",
+		Usage:       "(agent/runtime) Timeout after which a registry mirror endpoint that has not responded to a probe is considered unhealthy",
+		Destination: &AgentConfig.RegistryHealthTimeout,
+		Value:       5 * time.Second,
+	}
+	RegistryAuthSecretFlag = &cli.BoolFlag{
+		Name:        "registry-auth-secret",
+		Usage:       "(agent/runtime) Pull images with registry credentials from the " + version.Program + "-registry-auth dockerconfigjson Secret in the kube-system namespace, using a kubelet image credential provider; changes to the Secret are used within a minute",
+		Destination: &AgentConfig.RegistryAuthSecret,
+	}
+	GracefulShutdownDrainFlag = &cli.BoolFlag{
+		Name:        "graceful-shutdown-drain",
+		Usage:       "(agent/node) Cordon and drain the node when the agent is stopped, so that its pods are rescheduled to other nodes",
+		Destination: &AgentConfig.GracefulShutdownDrain,
+	}
+	DrainTimeoutFlag = &cli.DurationFlag{
+		Name:        "graceful-shutdown-drain-timeout",
+		Usage:       "(agent/node) Maximum time to wait for pods to be evicted when draining the node on shutdown",
+		Destination: &AgentConfig.DrainTimeout,
+		Value:       60 * time.Second,
+	}
+	DrainGracePeriodFlag = &cli.IntFlag{
+		Name:        "graceful-shutdown-drain-grace-period",
+		Usage:       "(agent/node) Termination grace period in seconds for pods evicted when draining the node on shutdown (-1 to use the pod's grace period)",
+		Destination: &AgentConfig.DrainGracePeriod,
+		Value:       -1,
+	}
+	RebootSentinelFlag = &cli.StringFlag{
+		Name:        "reboot-sentinel",
+		Usage:       "(agent/node) Path of a file that indicates the node requires a reboot, for example /var/run/reboot-required. If set, the node is cordoned, drained and rebooted when the file exists, one node in the cluster at a time",
+		Destination: &AgentConfig.RebootSentinel,
+	}
+	RebootCommandFlag = &cli.StringFlag{
+		Name:        "reboot-command",
+		Usage:       "(agent/node) Command run to reboot the node when the reboot sentinel file exists",
+		Destination: &AgentConfig.RebootCommand,
+		Value:       "/bin/systemctl reboot",
+	}
+	RebootCheckPeriodFlag = &cli.DurationFlag{
+		Name:        "reboot-check-period",
+		Usage:       "(agent/node) Interval at which the reboot sentinel file is checked for",
+		Destination: &AgentConfig.RebootCheckPeriod,
+		Value:       5 * time.Minute,
+	}
+	RebootDrainTimeoutFlag = &cli.DurationFlag{
+		Name:        "reboot-drain-timeout",
+		Usage:       "(agent/node) Maximum time to wait for pods to be evicted when draining the node before a reboot. The node is not rebooted if it cannot be drained",
+		Destination: &AgentConfig.RebootDrainTimeout,
+		Value:       5 * time.Minute,
+	}
+	ImagePullBandwidthLimitFlag = &cli.StringFlag{
+		Name:        "image-pull-bandwidth-limit",
+		Usage:       "(agent/runtime) Maximum bandwidth in bytes per second used by image pulls, for example 10Mi; may be set for individual registries with bandwidth_limit in the private registry configuration",
+		Destination: &AgentConfig.ImagePullBandwidthLimit,
+	}
+	ContainerdLogMaxSizeFlag = &cli.StringFlag{
+		Name:        "containerd-log-max-size",
+		Usage:       "(agent/runtime) Maximum size of the containerd log file before it is rotated",
+		Destination: &AgentConfig.ContainerdLogMaxSize,
+		Value:       "10Mi",
+	}
+	ContainerdLogMaxBackupsFlag = &cli.IntFlag{
+		Name:        "containerd-log-max-backups",
+		Usage:       "(agent/runtime) Maximum number of rotated containerd log files to retain. Must be at least 1",
+		Destination: &AgentConfig.ContainerdLogMaxBackups,
+		Value:       3,
+	}
+	RuntimeSearchPathFlag = &cli.StringSliceFlag{
+		Name:  "container-runtime-search-path",
+		Usage: "(agent/runtime) Directory searched for kata, runsc, firecracker and crun container runtimes, which are added to the containerd config as runtime handlers of the same name (default: /usr/local/bin, /usr/bin, /opt/kata/bin)",
+		Value: &AgentConfig.RuntimeSearchPath,
+	}
 	NodeNameFlag = &cli.StringFlag{
 		Name:        "node-name",
 		Usage:       "(agent/node) Node name",
@@ -89,6 +258,125 @@ var (
 		Usage:       "(agent/node) Kernel tuning behavior. If set, error if kernel tunables are different than kubelet defaults.",
 		Destination: &AgentConfig.ProtectKernelDefaults,
 	}
+	FailSwapOnFlag = &cli.BoolFlag{
+		Name:        "fail-swap-on",
+		Usage:       "(agent/node) Kubelet swap behavior. If set, the kubelet will fail to start if swap is enabled on the node.",
+		Destination: &AgentConfig.FailSwapOn,
+	}
+	SwapBehaviorFlag = &cli.StringFlag{
+		Name:        "swap-behavior",
+		Usage:       "(agent/node) Kubelet swap behavior. Allow workloads to use swap, with the given behavior (valid values: 'LimitedSwap', 'UnlimitedSwap'). Requires cgroup v2.",
+		Destination: &AgentConfig.SwapBehavior,
+	}
+	ReserveResourcesFlag = &cli.StringFlag{
+		Name:        "reserve-resources",
+		Usage:       "(agent/node) Reserve CPU and memory for system and " + version.Program + " processes, and set a memory eviction threshold, calculated from the CPUs and memory detected when the agent starts (valid values: 'auto', 'minimal', 'standard', 'generous')",
+		Destination: &AgentConfig.ReserveResources,
+	}
+	KubeReservedFlag = &cli.StringFlag{
+		Name:        "kube-reserved",
+		Usage:       "(agent/node) Resources reserved for " + version.Program + " processes, as resource=quantity pairs (e.g. 'cpu=100m,memory=300Mi'). Overrides the corresponding resources calculated by reserve-resources",
+		Destination: &AgentConfig.KubeReserved,
+	}
+	SystemReservedFlag = &cli.StringFlag{
+		Name:        "system-reserved",
+		Usage:       "(agent/node) Resources reserved for system processes, as resource=quantity pairs (e.g. 'cpu=50m,memory=150Mi'). Overrides the corresponding resources calculated by reserve-resources",
+		Destination: &AgentConfig.SystemReserved,
+	}
+	ContainerLogMaxSizeFlag = &cli.StringFlag{
+		Name:        "container-log-max-size",
+		Usage:       "(agent/node) Maximum size of a container log file before it is rotated by the kubelet",
+		Destination: &AgentConfig.ContainerLogMaxSize,
+		Value:       "10Mi",
+	}
+	ContainerLogMaxFilesFlag = &cli.IntFlag{
+		Name:        "container-log-max-files",
+		Usage:       "(agent/node) Maximum number of log files, including the current log file, retained for each container. Must be at least 2",
+		Destination: &AgentConfig.ContainerLogMaxFiles,
+		Value:       5,
+	}
+	ImageGCHighThresholdFlag = &cli.IntFlag{
+		Name:        "image-gc-high-threshold",
+		Usage:       "(agent/runtime) Percent of disk usage after which the kubelet removes unused images. Takes precedence over the imageGCHighThresholdPercent kubelet setting distributed by the server (default: 85)",
+		Destination: &AgentConfig.ImageGCHighThreshold,
+	}
+	ImageGCLowThresholdFlag = &cli.IntFlag{
+		Name:        "image-gc-low-threshold",
+		Usage:       "(agent/runtime) Percent of disk usage that the kubelet removes unused images down to. Must be less than image-gc-high-threshold. Takes precedence over the imageGCLowThresholdPercent kubelet setting distributed by the server (default: 80)",
+		Destination: &AgentConfig.ImageGCLowThreshold,
+	}
+	PinPreloadedImagesFlag = &cli.BoolFlag{
+		Name:        "pin-preloaded-images",
+		Usage:       "(agent/runtime) Pin images imported from the agent images directory, so that they are not removed by kubelet image garbage collection. Images listed in pinned.txt in the agent images directory are always pinned",
+		Destination: &AgentConfig.PinPreloadedImages,
+	}
+	StaticPodDirFlag = &cli.StringFlag{
+		Name:        "static-pod-dir",
+		Usage:       "(agent/node) Directory of static pod manifests that the kubelet runs on this node, even while the server is unreachable (default: ${data-dir}/agent/pod-manifests)",
+		Destination: &AgentConfig.StaticPodDir,
+	}
+	CertificateExpiryWindowFlag = &cli.DurationFlag{
+		Name:        "certificate-expiry-window",
+		Usage:       "(agent/node) Set the CertificatesExpiring node condition when a certificate managed by " + version.Program + " on this node expires within this window (0 to disable)",
+		Destination: &AgentConfig.CertificateExpiryWindow,
+		Value:       30 * 24 * time.Hour,
+	}
+	CPUManagerPolicyFlag = &cli.StringFlag{
+		Name:        "cpu-manager-policy",
+		Usage:       "(agent/node) Kubelet CPU manager policy (valid values: 'none', 'static'). The static policy requires reserved-cpus or reserve-resources to be set",
+		Destination: &AgentConfig.CPUManagerPolicy,
+	}
+	ReservedCPUsFlag = &cli.StringFlag{
+		Name:        "reserved-cpus",
+		Usage:       "(agent/node) Set of CPUs reserved for system and kubernetes processes, as a cpuset list (example: '0-1,4')",
+		Destination: &AgentConfig.ReservedCPUs,
+	}
+	MemoryManagerPolicyFlag = &cli.StringFlag{
+		Name:        "memory-manager-policy",
+		Usage:       "(agent/node) Kubelet memory manager policy (valid values: 'None', 'Static'). The Static policy requires reserved-memory to be set",
+		Destination: &AgentConfig.MemoryManagerPolicy,
+	}
+	ReservedMemoryFlag = &cli.StringFlag{
+		Name:        "reserved-memory",
+		Usage:       "(agent/node) Memory reserved on each NUMA node, as a semicolon-separated list (example: '0:memory=1Gi,hugepages-1Gi=2Gi;1:memory=2Gi')",
+		Destination: &AgentConfig.ReservedMemory,
+	}
+	TopologyManagerPolicyFlag = &cli.StringFlag{
+		Name:        "topology-manager-policy",
+		Usage:       "(agent/node) Kubelet topology manager policy (valid values: 'none', 'best-effort', 'restricted', 'single-numa-node')",
+		Destination: &AgentConfig.TopologyManagerPolicy,
+	}
+	TopologyManagerScopeFlag = &cli.StringFlag{
+		Name:        "topology-manager-scope",
+		Usage:       "(agent/node) Scope at which topology manager policy is applied (valid values: 'container', 'pod')",
+		Destination: &AgentConfig.TopologyManagerScope,
+	}
+	TuningProfileFlag = &cli.StringFlag{
+		Name:        "tuning-profile",
+		Usage:       "(agent/node) Host and kubelet tuning profile (valid values: 'none', 'low-latency'). The low-latency profile requires reserved-cpus, moves interrupts and " + version.Program + " threads onto the reserved CPUs, enables the static CPU manager policy, and validates realtime kernel settings",
+		Destination: &AgentConfig.TuningProfile,
+	}
+	HugePagesFlag = &cli.StringSliceFlag{
+		Name:  "hugepages",
+		Usage: "(agent/node) Hugepages to allocate at startup, as size=count (example: '2Mi=1024'). Large page sizes may need to be allocated at boot using kernel parameters instead",
+		Value: &AgentConfig.HugePages,
+	}
+	KubeletRootDirFlag = &cli.StringFlag{
+		Name:        "kubelet-root-dir",
+		Usage:       "(agent/node) Kubelet state directory, containing the plugin, plugin registration, and pod-resources directories. Device plugins must still register in /var/lib/kubelet/device-plugins, which must be writable. Set to a path within the data dir on systems where /var/lib/kubelet cannot be created (default: /var/lib/kubelet)",
+		Destination: &AgentConfig.KubeletRootDir,
+	}
+	AllowedUnsafeSysctlsFlag = &cli.StringSliceFlag{
+		Name:  "allowed-unsafe-sysctls",
+		Usage: "(agent/node) Unsafe sysctls or sysctl patterns (ending in '*') that pods on this node may set (example: 'net.core.somaxconn,net.ipv4.tcp_*'). Only namespaced sysctls are allowed",
+		Value: &AgentConfig.AllowedUnsafeSysctls,
+	}
+	PreflightFlag = &cli.StringFlag{
+		Name:        "preflight",
+		Usage:       "(agent/node) Preflight checks for ports, kernel modules, cgroups, time sync, disk space, and conflicting container runtimes (valid values: 'warn', 'strict', 'disabled'). The strict mode refuses to start if any check fails",
+		Destination: &AgentConfig.Preflight,
+		Value:       "warn",
+	}
 	SELinuxFlag = &cli.BoolFlag{
 		Name:        "selinux",
 		Usage:       "(agent/node) Enable SELinux in containerd",
@@ -136,6 +424,11 @@ var (
 		Destination: &AgentConfig.Snapshotter,
 		Value:       DefaultSnapshotter,
 	}
+	NetworkPolicyLoggingFlag = &cli.BoolFlag{
+		Name:        "network-policy-logging",
+		Usage:       "(agent/networking) Log connections to and from pods on this node that are denied by network policies, at most 10 per minute for each pod",
+		Destination: &AgentConfig.NetworkPolicyLogging,
+	}
 	FlannelIfaceFlag = &cli.StringFlag{
 		Name:        "flannel-iface",
 		Usage:       "(agent/networking) Override default flannel interface",
@@ -153,7 +446,7 @@ var (
 	}
 	ResolvConfFlag = &cli.StringFlag{
 		Name:        "resolv-conf",
-		Usage:       "(agent/networking) Kubelet resolv.conf file",
+		Usage:       "(agent/networking) Kubelet resolv.conf file, or 'auto' to detect the host's DNS configuration and update it when it changes (default: auto)",
 		EnvVar:      version.ProgramUpper + "_RESOLV_CONF",
 		Destination: &AgentConfig.ResolvConf,
 	}
@@ -227,20 +520,66 @@ func NewAgentCommand(action func(ctx *cli.Context) error) cli.Command {
 			WithNodeIDFlag,
 			NodeLabels,
 			NodeTaints,
+			GracefulShutdownDrainFlag,
+			DrainTimeoutFlag,
+			DrainGracePeriodFlag,
+			RebootSentinelFlag,
+			RebootCommandFlag,
+			RebootCheckPeriodFlag,
+			RebootDrainTimeoutFlag,
 			ImageCredProvBinDirFlag,
 			ImageCredProvConfigFlag,
 			SELinuxFlag,
 			LBServerPortFlag,
 			ProtectKernelDefaultsFlag,
+			FailSwapOnFlag,
+			SwapBehaviorFlag,
+			ReserveResourcesFlag,
+			KubeReservedFlag,
+			SystemReservedFlag,
+			ContainerLogMaxSizeFlag,
+			ContainerLogMaxFilesFlag,
+			ImageGCHighThresholdFlag,
+			ImageGCLowThresholdFlag,
+			PinPreloadedImagesFlag,
+			StaticPodDirFlag,
+			CertificateExpiryWindowFlag,
+			CPUManagerPolicyFlag,
+			ReservedCPUsFlag,
+			MemoryManagerPolicyFlag,
+			ReservedMemoryFlag,
+			TopologyManagerPolicyFlag,
+			TopologyManagerScopeFlag,
+			TuningProfileFlag,
+			HugePagesFlag,
+			KubeletRootDirFlag,
+			AllowedUnsafeSysctlsFlag,
+			PreflightFlag,
 			CRIEndpointFlag,
 			PauseImageFlag,
 			SnapshotterFlag,
 			PrivateRegistryFlag,
 			AirgapExtraRegistryFlag,
+			LocalRegistryListenFlag,
+			LocalRegistryAuthFlag,
+			LocalRegistryNameFlag,
+			RegistryHealthIntervalFlag,
+			RegistryHealthTimeoutFlag,
+			RegistryAuthSecretFlag,
+			ImagePullBandwidthLimitFlag,
+			ContainerdLogMaxSizeFlag,
+			ContainerdLogMaxBackupsFlag,
+			RuntimeSearchPathFlag,
 			NodeIPFlag,
+			NodeIPDiscoveryFlag,
 			NodeExternalIPFlag,
+			NodeExternalIPDiscoveryFlag,
+			NodeExternalIPSTUNServerFlag,
+			RelayURLFlag,
+			RelayListenFlag,
 			ResolvConfFlag,
 			FlannelIfaceFlag,
+			NetworkPolicyLoggingFlag,
 			FlannelConfFlag,
 			FlannelCniConfFileFlag,
 			ExtraKubeletArgs,
@@ -251,6 +590,11 @@ func NewAgentCommand(action func(ctx *cli.Context) error) cli.Command {
 				Usage:       "(experimental) Run rootless",
 				Destination: &AgentConfig.Rootless,
 			},
+			&cli.IntFlag{
+				Name:        "simulate",
+				Usage:       "(experimental) Register the given number of simulated hollow nodes for scale testing, instead of running a kubelet and container runtime",
+				Destination: &AgentConfig.Simulate,
+			},
 			PreferBundledBin,
 			// Deprecated/hidden below
 			DockerFlag,
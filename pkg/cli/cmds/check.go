@@ -0,0 +1,99 @@
+package cmds
+
+import (
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/urfave/cli"
+)
+
+const CheckCommand = "check"
+
+// Check holds CLI values for the check cluster command
+type Check struct {
+	Kubeconfig    string
+	Namespace     string
+	Image         string
+	ClusterDomain string
+	Timeout       time.Duration
+	Skip          cli.StringSlice
+	Keep          bool
+	Output        string
+}
+
+var (
+	CheckConfig       Check
+	CheckClusterFlags = []cli.Flag{
+		DebugFlag,
+		LogFile,
+		AlsoLogToStderr,
+		cli.StringFlag{
+			Name:        "kubeconfig",
+			Usage:       "(cluster) Server to connect to",
+			EnvVar:      "KUBECONFIG",
+			Destination: &CheckConfig.Kubeconfig,
+		},
+		cli.StringFlag{
+			Name:        "namespace,n",
+			Usage:       "Namespace to create for the check resources; it is deleted when the checks complete",
+			Value:       version.Program + "-check",
+			Destination: &CheckConfig.Namespace,
+		},
+		cli.StringFlag{
+			Name:        "image",
+			Usage:       "Image to run in the check pods; the registry check always pulls it, to verify pulls through configured registries and mirrors",
+			Value:       "docker.io/rancher/mirrored-library-busybox:1.34.1",
+			Destination: &CheckConfig.Image,
+		},
+		cli.StringFlag{
+			Name:        "cluster-domain",
+			Usage:       "(networking) Cluster domain used by the DNS check",
+			Value:       "cluster.local",
+			Destination: &CheckConfig.ClusterDomain,
+		},
+		cli.DurationFlag{
+			Name:        "timeout",
+			Usage:       "Time to wait for each check to complete",
+			Value:       2 * time.Minute,
+			Destination: &CheckConfig.Timeout,
+		},
+		cli.StringSliceFlag{
+			Name:  "skip",
+			Usage: "Checks to skip (valid items: pod, dns, registry, loadbalancer, pvc)",
+			Value: &CheckConfig.Skip,
+		},
+		cli.BoolFlag{
+			Name:        "keep",
+			Usage:       "Do not delete the namespace and check resources when the checks complete",
+			Destination: &CheckConfig.Keep,
+		},
+		cli.StringFlag{
+			Name:        "output,o",
+			Usage:       "Output format. Default: table. Optional: json, yaml",
+			Destination: &CheckConfig.Output,
+		},
+	}
+)
+
+func NewCheckCommand(subcommands []cli.Command) cli.Command {
+	return cli.Command{
+		Name:            CheckCommand,
+		Usage:           "Verify that a " + version.Program + " cluster is working",
+		SkipFlagParsing: false,
+		SkipArgReorder:  true,
+		Subcommands:     subcommands,
+	}
+}
+
+func NewCheckSubcommands(cluster func(ctx *cli.Context) error) []cli.Command {
+	return []cli.Command{
+		{
+			Name:            "cluster",
+			Usage:           "Run end-to-end checks of pod scheduling, DNS, image pulls, LoadBalancer services and persistent volumes, and print a report",
+			SkipFlagParsing: false,
+			SkipArgReorder:  true,
+			Action:          cluster,
+			Flags:           CheckClusterFlags,
+		},
+	}
+}
@@ -3,6 +3,7 @@
 package cmds
 
 const (
-	DefaultPauseImage  = "rancher/mirrored-pause:3.6"
-	DefaultSnapshotter = "overlayfs"
+	DefaultPauseImage        = "rancher/mirrored-pause:3.6"
+	DefaultSnapshotter       = "overlayfs"
+	DefaultContainerdAddress = "/run/k3s/containerd/containerd.sock"
 )
@@ -4,6 +4,7 @@
 package cmds
 
 const (
-	DefaultPauseImage  = "mcr.microsoft.com/oss/kubernetes/pause:1.4.0"
-	DefaultSnapshotter = "native"
+	DefaultPauseImage        = "mcr.microsoft.com/oss/kubernetes/pause:1.4.0"
+	DefaultSnapshotter       = "native"
+	DefaultContainerdAddress = "npipe:////./pipe/containerd-containerd"
 )
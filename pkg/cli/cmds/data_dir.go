@@ -0,0 +1,71 @@
+package cmds
+
+import (
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/urfave/cli"
+)
+
+const DataDirCommand = "data-dir"
+
+// DataDirMigrate holds CLI values for the data-dir migrate command
+type DataDirMigrate struct {
+	To          string
+	Service     string
+	SkipRestart bool
+	NoSymlink   bool
+}
+
+var (
+	DataDirMigrateConfig       DataDirMigrate
+	DataDirMigrateCommandFlags = []cli.Flag{
+		DebugFlag,
+		ConfigFlag,
+		LogFile,
+		AlsoLogToStderr,
+		DataDirFlag,
+		cli.StringFlag{
+			Name:        "to",
+			Usage:       "Path to move the data directory to",
+			Destination: &DataDirMigrateConfig.To,
+			Required:    true,
+		},
+		cli.StringFlag{
+			Name:        "service",
+			Usage:       "Name of the service to stop before and restart after migration (default: " + version.Program + " or " + version.Program + "-agent, whichever is installed)",
+			Destination: &DataDirMigrateConfig.Service,
+		},
+		cli.BoolFlag{
+			Name:        "skip-restart",
+			Usage:       "Do not restart the service after migration",
+			Destination: &DataDirMigrateConfig.SkipRestart,
+		},
+		cli.BoolFlag{
+			Name:        "no-symlink",
+			Usage:       "Do not leave a symlink to the new location at the original data directory path",
+			Destination: &DataDirMigrateConfig.NoSymlink,
+		},
+	}
+)
+
+func NewDataDirCommand(subcommands []cli.Command) cli.Command {
+	return cli.Command{
+		Name:            DataDirCommand,
+		Usage:           "Manage the " + version.Program + " data directory",
+		SkipFlagParsing: false,
+		SkipArgReorder:  true,
+		Subcommands:     subcommands,
+	}
+}
+
+func NewDataDirSubcommands(migrate func(ctx *cli.Context) error) []cli.Command {
+	return []cli.Command{
+		{
+			Name:            "migrate",
+			Usage:           "Stop " + version.Program + ", move the data directory to a new path, update the configuration to use it, and restart " + version.Program,
+			SkipFlagParsing: false,
+			SkipArgReorder:  true,
+			Action:          migrate,
+			Flags:           DataDirMigrateCommandFlags,
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package cmds
+
+import (
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/urfave/cli"
+)
+
+const EtcdCommand = "etcd"
+
+// Etcd holds CLI values for the etcd member management commands
+type Etcd struct {
+	Output string
+}
+
+var (
+	EtcdConfig      Etcd
+	EtcdCommonFlags = []cli.Flag{
+		DebugFlag,
+		ConfigFlag,
+		LogFile,
+		AlsoLogToStderr,
+		DataDirFlag,
+		ServerToken,
+		&cli.StringFlag{
+			Name:        "server, s",
+			Usage:       "(cluster) Server to connect to",
+			EnvVar:      version.ProgramUpper + "_URL",
+			Value:       "https://127.0.0.1:6443",
+			Destination: &ServerConfig.ServerURL,
+		},
+	}
+)
+
+func NewEtcdCommand(subcommands []cli.Command) cli.Command {
+	return cli.Command{
+		Name:            EtcdCommand,
+		Usage:           "Manage the embedded etcd cluster",
+		SkipFlagParsing: false,
+		SkipArgReorder:  true,
+		Subcommands:     subcommands,
+	}
+}
+
+func NewEtcdSubcommands(memberList, memberPromote, memberRemove func(ctx *cli.Context) error) []cli.Command {
+	return []cli.Command{
+		{
+			Name:           "member",
+			Usage:          "List, promote, and remove etcd cluster members",
+			SkipArgReorder: true,
+			Subcommands: []cli.Command{
+				{
+					Name:           "list",
+					Aliases:        []string{"ls"},
+					Usage:          "List etcd cluster members, and the sync progress of learners",
+					SkipArgReorder: true,
+					Action:         memberList,
+					Flags: append(EtcdCommonFlags, &cli.StringFlag{
+						Name:        "output,o",
+						Usage:       "Output format. Default: table. Optional: json, yaml",
+						Destination: &EtcdConfig.Output,
+					}),
+				},
+				{
+					Name:           "promote",
+					Usage:          "Promote a learner to a voting member. The learner must be in sync with the leader",
+					ArgsUsage:      "NAME|ID",
+					SkipArgReorder: true,
+					Action:         memberPromote,
+					Flags:          EtcdCommonFlags,
+				},
+				{
+					Name:           "remove",
+					Usage:          "Remove a member from the etcd cluster",
+					ArgsUsage:      "NAME|ID",
+					SkipArgReorder: true,
+					Action:         memberRemove,
+					Flags:          EtcdCommonFlags,
+				},
+			},
+		},
+	}
+}
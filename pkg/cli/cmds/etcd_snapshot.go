@@ -37,6 +37,11 @@ var EtcdSnapshotFlags = []cli.Flag{
 		Usage:       "(db) Compress etcd snapshot",
 		Destination: &ServerConfig.EtcdSnapshotCompress,
 	},
+	&cli.StringFlag{
+		Name:        "signing-key,etcd-snapshot-signing-key",
+		Usage:       "(db) Path to a PEM-encoded ed25519 private key used to sign the manifest written alongside the snapshot",
+		Destination: &ServerConfig.EtcdSnapshotSigningKey,
+	},
 	&cli.BoolFlag{
 		Name:        "s3,etcd-s3",
 		Usage:       "(db) Enable backup to S3",
@@ -91,6 +96,11 @@ var EtcdSnapshotFlags = []cli.Flag{
 		Usage:       "(db) Disables S3 over HTTPS",
 		Destination: &ServerConfig.EtcdS3Insecure,
 	},
+	&cli.BoolFlag{
+		Name:        "s3-incremental,etcd-s3-incremental",
+		Usage:       "(db) Upload snapshots to S3 as content-addressed chunks, uploading only the chunks that were not uploaded with a previous snapshot",
+		Destination: &ServerConfig.EtcdS3Incremental,
+	},
 	&cli.DurationFlag{
 		Name:        "s3-timeout,etcd-s3-timeout",
 		Usage:       "(db) S3 timeout",
@@ -99,7 +109,7 @@ var EtcdSnapshotFlags = []cli.Flag{
 	},
 }
 
-func NewEtcdSnapshotCommands(delete, list, prune, save func(ctx *cli.Context) error) cli.Command {
+func NewEtcdSnapshotCommands(delete, list, prune, save, verify func(ctx *cli.Context) error) cli.Command {
 	return cli.Command{
 		Name:            EtcdSnapshotCommand,
 		SkipFlagParsing: false,
@@ -111,7 +121,11 @@ func NewEtcdSnapshotCommands(delete, list, prune, save func(ctx *cli.Context) er
 				SkipFlagParsing: false,
 				SkipArgReorder:  true,
 				Action:          save,
-				Flags:           EtcdSnapshotFlags,
+				Flags: append(EtcdSnapshotFlags, ServerToken, &cli.StringFlag{
+					Name:        "server, s",
+					Usage:       "(cluster) Request the snapshot from a running server via the supervisor API, using the server's snapshot configuration",
+					Destination: &ServerConfig.ServerURL,
+				}),
 			},
 			{
 				Name:            "delete",
@@ -136,7 +150,7 @@ func NewEtcdSnapshotCommands(delete, list, prune, save func(ctx *cli.Context) er
 			},
 			{
 				Name:            "prune",
-				Usage:           "Remove snapshots that match the name prefix that exceed the configured retention count",
+				Usage:           "Remove snapshots that match the name prefix that exceed the configured retention count or policy",
 				SkipFlagParsing: false,
 				SkipArgReorder:  true,
 				Action:          prune,
@@ -145,6 +159,22 @@ func NewEtcdSnapshotCommands(delete, list, prune, save func(ctx *cli.Context) er
 					Usage:       "(db) Number of snapshots to retain.",
 					Destination: &ServerConfig.EtcdSnapshotRetention,
 					Value:       defaultSnapshotRentention,
+				}, &cli.StringFlag{
+					Name:        "snapshot-retention-policy",
+					Usage:       "(db) Grandfather-father-son retention policy applied instead of the retention count, for example 'hourly=24,daily=7,weekly=4,monthly=12'",
+					Destination: &ServerConfig.EtcdSnapshotRetentionPolicy,
+				}),
+			},
+			{
+				Name:            "verify",
+				Usage:           "Verify given snapshot(s) against the checksum and signature in their manifests",
+				SkipFlagParsing: false,
+				SkipArgReorder:  true,
+				Action:          verify,
+				Flags: append(EtcdSnapshotFlags, &cli.StringFlag{
+					Name:        "verification-key,etcd-snapshot-verification-key",
+					Usage:       "(db) Path to a PEM-encoded ed25519 public key; each snapshot's manifest must be signed by the matching private key",
+					Destination: &ServerConfig.EtcdSnapshotVerificationKey,
 				}),
 			},
 		},
@@ -0,0 +1,93 @@
+package cmds
+
+import (
+	"runtime"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/urfave/cli"
+)
+
+const ImagesCommand = "images"
+
+// Images holds CLI values for the air-gap image export and import commands
+type Images struct {
+	Output          string
+	Arch            string
+	PrivateRegistry string
+	ContainerdAddr  string
+	ExtraImages     cli.StringSlice
+}
+
+var (
+	ImagesConfig      Images
+	ImagesCommonFlags = []cli.Flag{
+		DebugFlag,
+		ConfigFlag,
+		LogFile,
+		AlsoLogToStderr,
+	}
+)
+
+func NewImagesCommand(subcommands []cli.Command) cli.Command {
+	return cli.Command{
+		Name:            ImagesCommand,
+		Usage:           "Export and import the images used by " + version.Program + ", for air-gapped installs",
+		SkipFlagParsing: false,
+		SkipArgReorder:  true,
+		Subcommands:     subcommands,
+	}
+}
+
+func NewImagesSubcommands(export, importImages func(ctx *cli.Context) error) []cli.Command {
+	return []cli.Command{
+		{
+			Name:            "export",
+			Usage:           "Pull the images referenced by the packaged manifests and embedded components of this version, and write them to an image tarball",
+			SkipFlagParsing: false,
+			SkipArgReorder:  true,
+			Action:          export,
+			Flags: append(ImagesCommonFlags,
+				&cli.StringFlag{
+					Name:        "output,o",
+					Usage:       "Path to write the image tarball to. The tarball is compressed if the file name ends in .tar.zst, .tar.gz or .tgz",
+					Value:       version.Program + "-airgap-images-" + runtime.GOARCH + ".tar.zst",
+					Destination: &ImagesConfig.Output,
+				},
+				&cli.StringFlag{
+					Name:        "arch",
+					Usage:       "CPU architecture of the images to export",
+					Value:       runtime.GOARCH,
+					Destination: &ImagesConfig.Arch,
+				},
+				&cli.StringFlag{
+					Name:        "private-registry",
+					Usage:       "Private registry configuration file used to pull the images",
+					Value:       "/etc/rancher/" + version.Program + "/registries.yaml",
+					Destination: &ImagesConfig.PrivateRegistry,
+				},
+				&cli.StringSliceFlag{
+					Name:  "image",
+					Usage: "Additional image to export",
+					Value: &ImagesConfig.ExtraImages,
+				},
+			),
+		},
+		{
+			Name:            "import",
+			Usage:           "Copy image tarballs to the agent images directory so that they are imported on every start, and import them into containerd if it is running",
+			ArgsUsage:       "FILE...",
+			SkipFlagParsing: false,
+			SkipArgReorder:  true,
+			Action:          importImages,
+			Flags: append(ImagesCommonFlags,
+				DataDirFlag,
+				&cli.StringFlag{
+					Name:        "containerd-address",
+					Usage:       "(agent/runtime) Address of the embedded containerd socket",
+					Value:       DefaultContainerdAddress,
+					Destination: &ImagesConfig.ContainerdAddr,
+				},
+			),
+		},
+	}
+}
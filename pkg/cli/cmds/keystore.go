@@ -0,0 +1,81 @@
+package cmds
+
+import (
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/urfave/cli"
+)
+
+const KeystoreCommand = "keystore"
+
+// Keystore holds CLI values for the keystore export and import commands
+type Keystore struct {
+	File         string
+	EtcdSnapshot string
+	Force        bool
+}
+
+var (
+	KeystoreConfig      Keystore
+	KeystoreCommonFlags = []cli.Flag{
+		DebugFlag,
+		ConfigFlag,
+		LogFile,
+		AlsoLogToStderr,
+		DataDirFlag,
+		ServerToken,
+	}
+)
+
+func NewKeystoreCommand(subcommands []cli.Command) cli.Command {
+	return cli.Command{
+		Name:            KeystoreCommand,
+		Usage:           "Export and import the encrypted keystore of " + version.Program + " cluster keys and tokens",
+		SkipFlagParsing: false,
+		SkipArgReorder:  true,
+		Subcommands:     subcommands,
+	}
+}
+
+func NewKeystoreSubcommands(export, importKeystore func(ctx *cli.Context) error) []cli.Command {
+	return []cli.Command{
+		{
+			Name:            "export",
+			Usage:           "Write the cluster keys and tokens from the data directory, or from an etcd snapshot, to an encrypted keystore file",
+			SkipFlagParsing: false,
+			SkipArgReorder:  true,
+			Action:          export,
+			Flags: append(KeystoreCommonFlags,
+				&cli.StringFlag{
+					Name:        "output,o",
+					Usage:       "Path to write the keystore to (default: stdout)",
+					Destination: &KeystoreConfig.File,
+				},
+				&cli.StringFlag{
+					Name:        "etcd-snapshot",
+					Usage:       "Path to an etcd snapshot to extract the keystore from, instead of reading keys from the data directory",
+					Destination: &KeystoreConfig.EtcdSnapshot,
+				},
+			),
+		},
+		{
+			Name:            "import",
+			Usage:           "Write the cluster keys and tokens from an encrypted keystore file to the data directory, before starting a server to restore or rebuild the cluster",
+			SkipFlagParsing: false,
+			SkipArgReorder:  true,
+			Action:          importKeystore,
+			Flags: append(KeystoreCommonFlags,
+				&cli.StringFlag{
+					Name:        "input,i",
+					Usage:       "Path to the keystore to import",
+					Destination: &KeystoreConfig.File,
+					Required:    true,
+				},
+				&cli.BoolFlag{
+					Name:        "force",
+					Usage:       "Replace existing keys in the data directory that differ from those in the keystore",
+					Destination: &KeystoreConfig.Force,
+				},
+			),
+		},
+	}
+}
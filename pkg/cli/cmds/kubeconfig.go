@@ -0,0 +1,88 @@
+package cmds
+
+import (
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/urfave/cli"
+)
+
+const KubeconfigCommand = "kubeconfig"
+
+// Kubeconfig holds CLI values for the kubeconfig subcommands
+type Kubeconfig struct {
+	ContextDir string
+	Context    string
+	Server     string
+}
+
+var (
+	KubeconfigConfig Kubeconfig
+	ContextDirFlag   = &cli.StringFlag{
+		Name:        "context-dir",
+		Usage:       "Directory containing kubeconfig files that are merged with the local kubeconfig by " + version.Program + " kubectl (default: /etc/rancher/" + version.Program + "/contexts or ${HOME}/.kube/" + version.Program + "-contexts if not root)",
+		EnvVar:      version.ProgramUpper + "_CONTEXT_DIR",
+		Destination: &KubeconfigConfig.ContextDir,
+	}
+	KubeconfigAddCommandFlags = []cli.Flag{
+		DebugFlag,
+		LogFile,
+		AlsoLogToStderr,
+		ContextDirFlag,
+		&cli.StringFlag{
+			Name:        "context",
+			Usage:       "Context to copy from the kubeconfig file (default: the current context)",
+			Destination: &KubeconfigConfig.Context,
+		},
+		&cli.StringFlag{
+			Name:        "server,s",
+			Usage:       "Server address to set for the context, replacing the address in the kubeconfig file",
+			Destination: &KubeconfigConfig.Server,
+		},
+	}
+	KubeconfigCommandFlags = []cli.Flag{
+		DebugFlag,
+		LogFile,
+		AlsoLogToStderr,
+		ContextDirFlag,
+	}
+)
+
+func NewKubeconfigCommand(subcommands []cli.Command) cli.Command {
+	return cli.Command{
+		Name:            KubeconfigCommand,
+		Usage:           "Manage kubeconfig contexts for other clusters, for use with " + version.Program + " kubectl",
+		SkipFlagParsing: false,
+		SkipArgReorder:  true,
+		Subcommands:     subcommands,
+	}
+}
+
+func NewKubeconfigSubcommands(add, remove, list func(ctx *cli.Context) error) []cli.Command {
+	return []cli.Command{
+		{
+			Name:            "add",
+			Usage:           "Copy a context from a kubeconfig file to the contexts directory",
+			ArgsUsage:       "NAME FILE",
+			SkipFlagParsing: false,
+			SkipArgReorder:  true,
+			Action:          add,
+			Flags:           KubeconfigAddCommandFlags,
+		},
+		{
+			Name:            "remove",
+			Usage:           "Remove contexts from the contexts directory",
+			ArgsUsage:       "NAME...",
+			SkipFlagParsing: false,
+			SkipArgReorder:  true,
+			Action:          remove,
+			Flags:           KubeconfigCommandFlags,
+		},
+		{
+			Name:            "list",
+			Usage:           "List contexts in the contexts directory",
+			SkipFlagParsing: false,
+			SkipArgReorder:  true,
+			Action:          list,
+			Flags:           KubeconfigCommandFlags,
+		},
+	}
+}
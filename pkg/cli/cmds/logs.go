@@ -0,0 +1,62 @@
+package cmds
+
+import (
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/urfave/cli"
+)
+
+const LogsCommand = "logs"
+
+// Logs holds CLI values for the logs command
+type Logs struct {
+	Component string
+	Node      string
+	Since     string
+}
+
+var (
+	LogsConfig = Logs{}
+	LogsFlags  = []cli.Flag{
+		DebugFlag,
+		ConfigFlag,
+		LogFile,
+		AlsoLogToStderr,
+		DataDirFlag,
+		ServerToken,
+		&cli.StringFlag{
+			Name:        "server, s",
+			Usage:       "(cluster) Server to connect to",
+			EnvVar:      version.ProgramUpper + "_URL",
+			Value:       "https://127.0.0.1:6443",
+			Destination: &ServerConfig.ServerURL,
+		},
+		&cli.StringFlag{
+			Name:        "component",
+			Usage:       "Component to retrieve logs for. One of: containerd, etcd, kubelet",
+			Value:       "kubelet",
+			Destination: &LogsConfig.Component,
+		},
+		&cli.StringFlag{
+			Name:        "node",
+			Usage:       "Node to retrieve logs from, through its tunnel to the server. Defaults to the server itself",
+			Destination: &LogsConfig.Node,
+		},
+		&cli.StringFlag{
+			Name:        "since",
+			Usage:       "Retrieve logs written after this time, either a duration such as 10m, or an RFC3339 timestamp",
+			Value:       "1h",
+			Destination: &LogsConfig.Since,
+		},
+	}
+)
+
+func NewLogsCommand(action func(*cli.Context) error) cli.Command {
+	return cli.Command{
+		Name:            LogsCommand,
+		Usage:           "Retrieve recent logs of the embedded components from a node",
+		SkipFlagParsing: false,
+		SkipArgReorder:  true,
+		Action:          action,
+		Flags:           LogsFlags,
+	}
+}
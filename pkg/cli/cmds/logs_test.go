@@ -0,0 +1,66 @@
+package cmds
+
+import (
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func Test_UnitNewLogsCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantComponent string
+		wantNode      string
+		wantSince     string
+	}{
+		{
+			name:          "Defaults",
+			args:          []string{"logs"},
+			wantComponent: "kubelet",
+			wantSince:     "1h",
+		},
+		{
+			name:          "Component and since",
+			args:          []string{"logs", "--component", "etcd", "--since", "10m"},
+			wantComponent: "etcd",
+			wantSince:     "10m",
+		},
+		{
+			name:          "Node",
+			args:          []string{"logs", "--node", "agent1"},
+			wantComponent: "kubelet",
+			wantNode:      "agent1",
+			wantSince:     "1h",
+		},
+		{
+			name:          "Config file",
+			args:          []string{"logs", "-c", "/dev/null", "--component=containerd"},
+			wantComponent: "containerd",
+			wantSince:     "1h",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			LogsConfig = Logs{}
+			called := false
+			app := cli.NewApp()
+			app.Commands = []cli.Command{
+				NewLogsCommand(func(*cli.Context) error {
+					called = true
+					return nil
+				}),
+			}
+			if err := app.Run(append([]string{"k3s"}, tt.args...)); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !called {
+				t.Fatalf("Run() did not call the logs action")
+			}
+			if LogsConfig.Component != tt.wantComponent || LogsConfig.Node != tt.wantNode || LogsConfig.Since != tt.wantSince {
+				t.Errorf("Run() parsed component=%s node=%s since=%s, want component=%s node=%s since=%s",
+					LogsConfig.Component, LogsConfig.Node, LogsConfig.Since, tt.wantComponent, tt.wantNode, tt.wantSince)
+			}
+		})
+	}
+}
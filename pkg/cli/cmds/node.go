@@ -0,0 +1,55 @@
+package cmds
+
+import (
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/urfave/cli"
+)
+
+const NodeCommand = "node"
+
+var NodeCommonFlags = []cli.Flag{
+	DebugFlag,
+	ConfigFlag,
+	LogFile,
+	AlsoLogToStderr,
+	DataDirFlag,
+	ServerToken,
+	&cli.StringFlag{
+		Name:        "server, s",
+		Usage:       "(cluster) Server to connect to",
+		EnvVar:      version.ProgramUpper + "_URL",
+		Value:       "https://127.0.0.1:6443",
+		Destination: &ServerConfig.ServerURL,
+	},
+}
+
+func NewNodeCommand(subcommands []cli.Command) cli.Command {
+	return cli.Command{
+		Name:            NodeCommand,
+		Usage:           "Manage cluster nodes",
+		SkipFlagParsing: false,
+		SkipArgReorder:  true,
+		Subcommands:     subcommands,
+	}
+}
+
+func NewNodeSubcommands(approve, deny func(ctx *cli.Context) error) []cli.Command {
+	return []cli.Command{
+		{
+			Name:           "approve",
+			Usage:          "Approve the join request for a node, allowing it to be issued kubelet certificates",
+			ArgsUsage:      "NAME",
+			SkipArgReorder: true,
+			Action:         approve,
+			Flags:          NodeCommonFlags,
+		},
+		{
+			Name:           "deny",
+			Usage:          "Deny the join request for a node",
+			ArgsUsage:      "NAME",
+			SkipArgReorder: true,
+			Action:         deny,
+			Flags:          NodeCommonFlags,
+		},
+	}
+}
@@ -7,4 +7,6 @@ const (
 	// The coredns and servicelb controllers can still be disabled, even if their manifests
 	// are missing. Same with CloudController/ccm.
 	DisableItems = "coredns, servicelb"
+	// There are no optional components without bundled manifests.
+	EnableItems = ""
 )
@@ -31,6 +31,7 @@ type Server struct {
 	TokenFile            string
 	ClusterSecret        string
 	ServiceCIDR          cli.StringSlice
+	DualStack            bool
 	ServiceNodePortRange string
 	ClusterDNS           cli.StringSlice
 	ClusterDomain        string
@@ -39,71 +40,129 @@ type Server struct {
 	// The port which custom k3s API runs on
 	SupervisorPort int
 	// The port which kube-apiserver runs on
-	APIServerPort            int
-	APIServerBindAddress     string
-	DataDir                  string
-	DisableAgent             bool
-	KubeConfigOutput         string
-	KubeConfigMode           string
-	TLSSan                   cli.StringSlice
-	BindAddress              string
-	EnablePProf              bool
-	ExtraAPIArgs             cli.StringSlice
-	ExtraEtcdArgs            cli.StringSlice
-	ExtraSchedulerArgs       cli.StringSlice
-	ExtraControllerArgs      cli.StringSlice
-	ExtraCloudControllerArgs cli.StringSlice
-	Rootless                 bool
-	DatastoreEndpoint        string
-	DatastoreCAFile          string
-	DatastoreCertFile        string
-	DatastoreKeyFile         string
-	AdvertiseIP              string
-	AdvertisePort            int
-	DisableScheduler         bool
-	ServerURL                string
-	MultiClusterCIDR         bool
-	FlannelBackend           string
-	FlannelIPv6Masq          bool
-	FlannelExternalIP        bool
-	EgressSelectorMode       string
-	DefaultLocalStoragePath  string
-	DisableCCM               bool
-	DisableNPC               bool
-	DisableHelmController    bool
-	DisableKubeProxy         bool
-	DisableAPIServer         bool
-	DisableControllerManager bool
-	DisableETCD              bool
-	ClusterInit              bool
-	ClusterReset             bool
-	ClusterResetRestorePath  string
-	EncryptSecrets           bool
-	EncryptForce             bool
-	EncryptOutput            string
-	EncryptSkip              bool
-	SystemDefaultRegistry    string
-	StartupHooks             []StartupHook
-	EtcdSnapshotName         string
-	EtcdDisableSnapshots     bool
-	EtcdExposeMetrics        bool
-	EtcdSnapshotDir          string
-	EtcdSnapshotCron         string
-	EtcdSnapshotRetention    int
-	EtcdSnapshotCompress     bool
-	EtcdListFormat           string
-	EtcdS3                   bool
-	EtcdS3Endpoint           string
-	EtcdS3EndpointCA         string
-	EtcdS3SkipSSLVerify      bool
-	EtcdS3AccessKey          string
-	EtcdS3SecretKey          string
-	EtcdS3BucketName         string
-	EtcdS3Region             string
-	EtcdS3Folder             string
-	EtcdS3Timeout            time.Duration
-	EtcdS3Insecure           bool
-	ServiceLBNamespace       string
+	APIServerPort               int
+	APIServerBindAddress        string
+	SupervisorBindAddress       string
+	SupervisorAdvertiseAddress  string
+	SupervisorTLSSan            cli.StringSlice
+	DataDir                     string
+	DisableAgent                bool
+	KubeConfigOutput            string
+	KubeConfigMode              string
+	TLSSan                      cli.StringSlice
+	BindAddress                 string
+	EnablePProf                 bool
+	ExtraAPIArgs                cli.StringSlice
+	ExtraEtcdArgs               cli.StringSlice
+	ExtraSchedulerArgs          cli.StringSlice
+	ExtraControllerArgs         cli.StringSlice
+	ExtraCloudControllerArgs    cli.StringSlice
+	Rootless                    bool
+	DatastoreEndpoint           string
+	DatastoreCAFile             string
+	DatastoreCertFile           string
+	DatastoreKeyFile            string
+	KineStandalone              bool
+	KineCPULimit                string
+	KineMemoryLimit             string
+	DatastoreSlowQueryThreshold time.Duration
+	AdvertiseIP                 string
+	AdvertisePort               int
+	DisableScheduler            bool
+	ServerURL                   string
+	MultiClusterCIDR            bool
+	FlannelBackend              string
+	CNI                         string
+	FlannelIPv6Masq             bool
+	FlannelExternalIP           bool
+	FlannelKeyRotation          time.Duration
+	EgressSelectorMode          string
+	AuditLogMode                string
+	PodSecurityProfile          string
+	PodSecurityExemptNamespaces cli.StringSlice
+	AuthOIDCIssuerURL           string
+	AuthOIDCClientID            string
+	AuthOIDCCAFile              string
+	AuthOIDCUsernameClaim       string
+	AuthOIDCUsernamePrefix      string
+	AuthOIDCGroupsClaim         string
+	AuthOIDCGroupsPrefix        string
+	AuthOIDCRequiredClaims      cli.StringSlice
+	AuthWebhookConfig           string
+	AuthWebhookCacheTTL         time.Duration
+	DefaultLocalStoragePath     string
+	DisableCCM                  bool
+	DisableNPC                  bool
+	DisableHelmController       bool
+	DisableKubeProxy            bool
+	DisableAPIServer            bool
+	DisableControllerManager    bool
+	DisableETCD                 bool
+	DisableComponents           cli.StringSlice
+	ClusterInit                 bool
+	LeaderElectionPriority      string
+	ClusterReset                bool
+	ClusterResetRestorePath     string
+	ClusterResetRestoreTime     string
+	EncryptSecrets              bool
+	EncryptResources            cli.StringSlice
+	KMSProviderConfig           string
+	EncryptForce                bool
+	EncryptOutput               string
+	EncryptSkip                 bool
+	SystemDefaultRegistry       string
+	StartupHooks                []StartupHook
+	EtcdSnapshotName            string
+	EtcdDisableSnapshots        bool
+	EtcdExposeMetrics           bool
+	EtcdSnapshotDir             string
+	EtcdSnapshotCron            string
+	EtcdSnapshotRetention       int
+	EtcdSnapshotRetentionPolicy string
+	EtcdSnapshotCompress        bool
+	EtcdSnapshotMinFree         int
+	EtcdSnapshotPruneFirst      bool
+	EtcdSnapshotSigningKey      string
+	EtcdSnapshotVerificationKey string
+	EtcdSnapshotVerify          bool
+	EtcdDefragCron              string
+	EtcdDefragThreshold         int
+	EtcdListFormat              string
+	EtcdS3                      bool
+	EtcdS3Endpoint              string
+	EtcdS3EndpointCA            string
+	EtcdS3SkipSSLVerify         bool
+	EtcdS3AccessKey             string
+	EtcdS3SecretKey             string
+	EtcdS3BucketName            string
+	EtcdS3Region                string
+	EtcdS3Folder                string
+	EtcdS3Timeout               time.Duration
+	EtcdS3Insecure              bool
+	EtcdS3Incremental           bool
+	EtcdS3WALArchive            bool
+	EtcdS3WALArchiveInterval    time.Duration
+	ServiceLBNamespace          string
+	EnableGatewayAPI            bool
+	NodeWebhookURLs             cli.StringSlice
+	NodeWebhookNotReady         time.Duration
+	StaleNodeCleanupDays        int
+	ClockSkewThreshold          time.Duration
+	ClockSkewReject             bool
+	NodeJoinApproval            string
+	RegistryPolicyMode          string
+	RegistryPolicyAllow         cli.StringSlice
+	RegistryPolicyDeny          cli.StringSlice
+	RegistryPolicyExempt        cli.StringSlice
+	NamespaceDefaultsConfig     string
+	NvidiaMIGStrategy           string
+	NvidiaMIGProfiles           cli.StringSlice
+	NvidiaTimeSlicing           cli.StringSlice
+	ShutdownDrainTimeout        time.Duration
+	SupervisorRateLimit         float64
+	SupervisorRateBurst         int
+	AuthFailureLimit            int
+	AuthLockoutDuration         time.Duration
 }
 
 var (
@@ -129,6 +188,11 @@ var (
 		Usage: "(networking) IPv4/IPv6 network CIDRs to use for service IPs (default: 10.43.0.0/16)",
 		Value: &ServerConfig.ServiceCIDR,
 	}
+	DualStack = &cli.BoolFlag{
+		Name:        "dual-stack",
+		Usage:       "(networking) Enable dual-stack operation, adding IPv6 unique local address ranges to the cluster-cidr and service-cidr if only IPv4 ranges are set",
+		Destination: &ServerConfig.DualStack,
+	}
 	ServiceNodePortRange = &cli.StringFlag{
 		Name:        "service-node-port-range",
 		Usage:       "(networking) Port range to reserve for services with NodePort visibility",
@@ -201,12 +265,68 @@ var ServerFlags = []cli.Flag{
 		Usage: "(listener) Add additional hostnames or IPv4/IPv6 addresses as Subject Alternative Names on the server TLS cert",
 		Value: &ServerConfig.TLSSan,
 	},
+	&cli.IntFlag{
+		Name:        "supervisor-port",
+		Usage:       "(listener) Port that the " + version.Program + " supervisor listens on for node registration. If different from https-listen-port, the apiserver listens on https-listen-port directly (default: https-listen-port)",
+		Destination: &ServerConfig.SupervisorPort,
+	},
+	&cli.StringFlag{
+		Name:        "supervisor-bind-address",
+		Usage:       "(listener) " + version.Program + " supervisor bind address, if different from bind-address. Requires supervisor-port to be set to a port other than https-listen-port",
+		Destination: &ServerConfig.SupervisorBindAddress,
+	},
+	&cli.StringFlag{
+		Name:        "supervisor-advertise-address",
+		Usage:       "(listener) IPv4/IPv6 address or hostname that nodes use to reach the " + version.Program + " supervisor, if different from the supervisor bind address. Requires supervisor-port to be set to a port other than https-listen-port",
+		Destination: &ServerConfig.SupervisorAdvertiseAddress,
+	},
+	&cli.StringSliceFlag{
+		Name:  "supervisor-tls-san",
+		Usage: "(listener) Add additional hostnames or IPv4/IPv6 addresses as Subject Alternative Names on the supervisor TLS cert. If set, tls-san values are not added to the supervisor cert. Requires supervisor-port to be set to a port other than https-listen-port",
+		Value: &ServerConfig.SupervisorTLSSan,
+	},
+	&cli.DurationFlag{
+		Name:        "shutdown-drain-timeout",
+		Usage:       "(listener) Time to wait for in-flight requests to the supervisor to complete when stopping. Use with a systemd socket unit for the supervisor port, so that connections made while restarting are queued instead of refused (0 to disable)",
+		Destination: &ServerConfig.ShutdownDrainTimeout,
+		Value:       30 * time.Second,
+	},
+	&cli.Float64Flag{
+		Name:        "supervisor-rate-limit",
+		Usage:       "(listener) Maximum rate of requests per second from each source address to the supervisor registration and bootstrap endpoints (0 to disable)",
+		Destination: &ServerConfig.SupervisorRateLimit,
+		Value:       20,
+	},
+	&cli.IntFlag{
+		Name:        "supervisor-rate-burst",
+		Usage:       "(listener) Maximum burst of requests from each source address to the supervisor registration and bootstrap endpoints",
+		Destination: &ServerConfig.SupervisorRateBurst,
+		Value:       100,
+	},
+	&cli.IntFlag{
+		Name:        "supervisor-auth-failure-limit",
+		Usage:       "(listener) Number of consecutive authentication failures from a source address after which it is locked out of the supervisor registration and bootstrap endpoints (0 to disable)",
+		Destination: &ServerConfig.AuthFailureLimit,
+	},
+	&cli.DurationFlag{
+		Name:        "supervisor-auth-lockout",
+		Usage:       "(listener) Time that a source address is locked out for after repeated authentication failures",
+		Destination: &ServerConfig.AuthLockoutDuration,
+		Value:       5 * time.Minute,
+	},
 	DataDirFlag,
 	ClusterCIDR,
 	ServiceCIDR,
+	DualStack,
 	ServiceNodePortRange,
 	ClusterDNS,
 	ClusterDomain,
+	&cli.StringFlag{
+		Name:        "cni",
+		Usage:       "(networking) CNI to deploy (valid values: 'flannel', 'calico', 'cilium', 'none'); 'calico' and 'cilium' deploy a packaged chart and disable flannel and the network policy controller",
+		Destination: &ServerConfig.CNI,
+		Value:       "flannel",
+	},
 	&cli.StringFlag{
 		Name:        "flannel-backend",
 		Usage:       "(networking) Backend (valid values: 'none', 'vxlan', 'ipsec' (deprecated), 'host-gw', 'wireguard-native'",
@@ -228,12 +348,86 @@ var ServerFlags = []cli.Flag{
 		Usage:       "(networking) Use node external IP addresses for Flannel traffic",
 		Destination: &ServerConfig.FlannelExternalIP,
 	},
+	&cli.DurationFlag{
+		Name:        "flannel-key-rotation-interval",
+		Usage:       "(networking) Interval at which nodes rotate their tunnel keys when using the wireguard-native flannel backend (0 to disable)",
+		Destination: &ServerConfig.FlannelKeyRotation,
+		Value:       30 * 24 * time.Hour,
+	},
 	&cli.StringFlag{
 		Name:        "egress-selector-mode",
-		Usage:       "(networking) One of 'agent', 'cluster', 'pod', 'disabled'",
+		Usage:       "(networking) One of 'agent' (tunnel apiserver connections to kubelets), 'cluster' (also tunnel connections to addresses in the cluster CIDR), 'pod' (also tunnel connections to pods, as tracked by the agent), 'disabled' (connect directly to kubelets and pods)",
 		Destination: &ServerConfig.EgressSelectorMode,
 		Value:       "agent",
 	},
+	&cli.StringFlag{
+		Name:        "audit-log-mode",
+		Usage:       "(security) Write the apiserver audit log to ${data-dir}/server/logs/audit, with a generated policy. One of 'none', 'metadata' (log request metadata), 'request' (also log the body of write requests), 'full' (also log the body of responses to write requests)",
+		Destination: &ServerConfig.AuditLogMode,
+		Value:       "none",
+	},
+	&cli.StringFlag{
+		Name:        "pod-security-profile",
+		Usage:       "(security) Pod security admission profile enforced for all namespaces that are not exempt, with a generated admission configuration. One of 'privileged', 'baseline', 'restricted' (default: no admission configuration)",
+		Destination: &ServerConfig.PodSecurityProfile,
+	},
+	&cli.StringSliceFlag{
+		Name:  "pod-security-exempt-namespace",
+		Usage: "(security) Namespace exempt from the pod security profile. kube-system and the servicelb namespace are always exempt",
+		Value: &ServerConfig.PodSecurityExemptNamespaces,
+	},
+	&cli.StringFlag{
+		Name:        "auth-oidc-issuer-url",
+		Usage:       "(security) Authenticate users with ID tokens issued by the OpenID Connect provider at this HTTPS URL",
+		Destination: &ServerConfig.AuthOIDCIssuerURL,
+	},
+	&cli.StringFlag{
+		Name:        "auth-oidc-client-id",
+		Usage:       "(security) Client ID that ID tokens must be issued for. Required with auth-oidc-issuer-url",
+		Destination: &ServerConfig.AuthOIDCClientID,
+	},
+	&cli.StringFlag{
+		Name:        "auth-oidc-ca-file",
+		Usage:       "(security) CA bundle used to verify the OpenID Connect provider, if not signed by a system CA. When the file changes, " + version.Program + " restarts after a random delay of up to 5 minutes to apply it",
+		Destination: &ServerConfig.AuthOIDCCAFile,
+	},
+	&cli.StringFlag{
+		Name:        "auth-oidc-username-claim",
+		Usage:       "(security) ID token claim used as the user name",
+		Destination: &ServerConfig.AuthOIDCUsernameClaim,
+		Value:       "sub",
+	},
+	&cli.StringFlag{
+		Name:        "auth-oidc-username-prefix",
+		Usage:       "(security) Prefix added to user names from ID tokens, to prevent clashes with other authenticators. Use '-' for no prefix (default: issuer URL followed by '#', unless the username claim is 'email')",
+		Destination: &ServerConfig.AuthOIDCUsernamePrefix,
+	},
+	&cli.StringFlag{
+		Name:        "auth-oidc-groups-claim",
+		Usage:       "(security) ID token claim used as the user's groups (default: no groups)",
+		Destination: &ServerConfig.AuthOIDCGroupsClaim,
+	},
+	&cli.StringFlag{
+		Name:        "auth-oidc-groups-prefix",
+		Usage:       "(security) Prefix added to groups from ID tokens, to prevent clashes with other authenticators",
+		Destination: &ServerConfig.AuthOIDCGroupsPrefix,
+	},
+	&cli.StringSliceFlag{
+		Name:  "auth-oidc-required-claim",
+		Usage: "(security) Claim that ID tokens must contain with the given value, as 'claim=value'",
+		Value: &ServerConfig.AuthOIDCRequiredClaims,
+	},
+	&cli.StringFlag{
+		Name:        "auth-webhook-config",
+		Usage:       "(security) Kubeconfig file for a webhook that authenticates bearer tokens. Files referenced by the kubeconfig are inlined when " + version.Program + " starts. When the kubeconfig or its files change, " + version.Program + " restarts after a random delay of up to 5 minutes to apply them",
+		Destination: &ServerConfig.AuthWebhookConfig,
+	},
+	&cli.DurationFlag{
+		Name:        "auth-webhook-cache-ttl",
+		Usage:       "(security) Duration to cache responses from the authentication webhook",
+		Destination: &ServerConfig.AuthWebhookCacheTTL,
+		Value:       2 * time.Minute,
+	},
 	&cli.StringFlag{
 		Name:        "servicelb-namespace",
 		Usage:       "(networking) Namespace of the pods for the servicelb component",
@@ -289,11 +483,27 @@ var ServerFlags = []cli.Flag{
 		EnvVar:      version.ProgramUpper + "_CLUSTER_RESET",
 		Destination: &ServerConfig.ClusterReset,
 	},
+	&cli.StringFlag{
+		Name:        "leader-election-priority",
+		Usage:       "(cluster) Priority of this server when electing leaders for the deploy, helm, etcd, and cloud controllers; one of 'preferred' (take leadership as soon as it is available), 'standby' (take leadership only if no preferred server does so first, and hand it back once a preferred server is available), or 'never' (do not run leader-elected controllers on this server)",
+		Destination: &ServerConfig.LeaderElectionPriority,
+		Value:       "preferred",
+	},
 	&cli.StringFlag{
 		Name:        "cluster-reset-restore-path",
-		Usage:       "(db) Path to snapshot file to be restored",
+		Usage:       "(db) Path to snapshot file to be restored, or s3://<bucket>/<key> to stream the snapshot from S3 using the etcd-s3 endpoint and credential flags",
 		Destination: &ServerConfig.ClusterResetRestorePath,
 	},
+	&cli.StringFlag{
+		Name:        "cluster-reset-restore-timestamp",
+		Usage:       "(db) Restore the datastore to its state at this time, as an RFC3339 or Unix timestamp, by replaying changes archived with etcd-s3-wal-archive on top of the snapshot. If cluster-reset-restore-path is not set, the latest snapshot in S3 taken before this time is restored. Requires etcd-s3",
+		Destination: &ServerConfig.ClusterResetRestoreTime,
+	},
+	&cli.BoolFlag{
+		Name:        "verify-snapshot",
+		Usage:       "(db) Verify the snapshot to be restored against the checksum in its manifest, and the manifest signature if etcd-snapshot-verification-key is set, before restoring it",
+		Destination: &ServerConfig.EtcdSnapshotVerify,
+	},
 	ExtraAPIArgs,
 	ExtraEtcdArgs,
 	ExtraControllerArgs,
@@ -327,6 +537,27 @@ var ServerFlags = []cli.Flag{
 		Destination: &ServerConfig.DatastoreKeyFile,
 		EnvVar:      version.ProgramUpper + "_DATASTORE_KEYFILE",
 	},
+	&cli.BoolFlag{
+		Name:        "kine-standalone",
+		Usage:       "(db) Run kine in a separate process, listening on a unix socket, instead of in the server process",
+		Destination: &ServerConfig.KineStandalone,
+	},
+	&cli.StringFlag{
+		Name:        "kine-cpu-limit",
+		Usage:       "(db) CPU limit of the standalone kine process, for example 500m or 2 (default: unlimited)",
+		Destination: &ServerConfig.KineCPULimit,
+	},
+	&cli.StringFlag{
+		Name:        "kine-memory-limit",
+		Usage:       "(db) Memory limit of the standalone kine process, for example 512Mi (default: unlimited)",
+		Destination: &ServerConfig.KineMemoryLimit,
+	},
+	&cli.DurationFlag{
+		Name:        "datastore-slow-query-threshold",
+		Usage:       "(db) Log SQL queries made by kine that take longer than this duration (0 to disable)",
+		Destination: &ServerConfig.DatastoreSlowQueryThreshold,
+		Value:       time.Second,
+	},
 	&cli.BoolFlag{
 		Name:        "etcd-expose-metrics",
 		Usage:       "(db) Expose etcd metrics to client interface. (default: false)",
@@ -355,6 +586,11 @@ var ServerFlags = []cli.Flag{
 		Destination: &ServerConfig.EtcdSnapshotRetention,
 		Value:       defaultSnapshotRentention,
 	},
+	&cli.StringFlag{
+		Name:        "etcd-snapshot-retention-policy",
+		Usage:       "(db) Grandfather-father-son retention policy applied to local and S3 snapshots instead of the retention count, for example 'hourly=24,daily=7,weekly=4,monthly=12'",
+		Destination: &ServerConfig.EtcdSnapshotRetentionPolicy,
+	},
 	&cli.StringFlag{
 		Name:        "etcd-snapshot-dir",
 		Usage:       "(db) Directory to save db snapshots. (default: ${data-dir}/db/snapshots)",
@@ -365,6 +601,37 @@ var ServerFlags = []cli.Flag{
 		Usage:       "(db) Compress etcd snapshot",
 		Destination: &ServerConfig.EtcdSnapshotCompress,
 	},
+	&cli.IntFlag{
+		Name:        "etcd-snapshot-min-free-percent",
+		Usage:       "(db) Percentage of the snapshot filesystem that must remain free after a scheduled snapshot is taken; snapshots are skipped if there is not enough space (0 to disable)",
+		Destination: &ServerConfig.EtcdSnapshotMinFree,
+	},
+	&cli.BoolFlag{
+		Name:        "etcd-snapshot-prune-first",
+		Usage:       "(db) Remove the oldest local snapshots before taking a scheduled snapshot, if there is not enough free space",
+		Destination: &ServerConfig.EtcdSnapshotPruneFirst,
+	},
+	&cli.StringFlag{
+		Name:        "etcd-snapshot-signing-key",
+		Usage:       "(db) Path to a PEM-encoded ed25519 private key used to sign the manifest written alongside each snapshot",
+		Destination: &ServerConfig.EtcdSnapshotSigningKey,
+	},
+	&cli.StringFlag{
+		Name:        "etcd-snapshot-verification-key",
+		Usage:       "(db) Path to a PEM-encoded ed25519 public key; a restored or verified snapshot's manifest must be signed by the matching private key",
+		Destination: &ServerConfig.EtcdSnapshotVerificationKey,
+	},
+	&cli.StringFlag{
+		Name:        "etcd-defrag-schedule",
+		Usage:       "(db) Defragmentation interval time in cron spec, for example weekly '0 3 * * 0'. Members are defragmented one at a time, with the leader last (default: disabled)",
+		Destination: &ServerConfig.EtcdDefragCron,
+	},
+	&cli.IntFlag{
+		Name:        "etcd-defrag-threshold-percent",
+		Usage:       "(db) Percentage of a member's database that must be fragmented for it to be defragmented by the defragmentation schedule",
+		Destination: &ServerConfig.EtcdDefragThreshold,
+		Value:       30,
+	},
 	&cli.BoolFlag{
 		Name:        "etcd-s3",
 		Usage:       "(db) Enable backup to S3",
@@ -419,6 +686,22 @@ var ServerFlags = []cli.Flag{
 		Usage:       "(db) Disables S3 over HTTPS",
 		Destination: &ServerConfig.EtcdS3Insecure,
 	},
+	&cli.BoolFlag{
+		Name:        "etcd-s3-incremental",
+		Usage:       "(db) Upload snapshots to S3 as content-addressed chunks, uploading only the chunks that were not uploaded with a previous snapshot",
+		Destination: &ServerConfig.EtcdS3Incremental,
+	},
+	&cli.BoolFlag{
+		Name:        "etcd-s3-wal-archive",
+		Usage:       "(db) Continuously archive changes to the datastore to S3, so that it can be restored to a point in time between snapshots with cluster-reset-restore-timestamp",
+		Destination: &ServerConfig.EtcdS3WALArchive,
+	},
+	&cli.DurationFlag{
+		Name:        "etcd-s3-wal-archive-interval",
+		Usage:       "(db) Interval at which archived changes to the datastore are uploaded to S3",
+		Destination: &ServerConfig.EtcdS3WALArchiveInterval,
+		Value:       10 * time.Second,
+	},
 	&cli.DurationFlag{
 		Name:        "etcd-s3-timeout",
 		Usage:       "(db) S3 timeout",
@@ -434,6 +717,30 @@ var ServerFlags = []cli.Flag{
 		Name:  "disable",
 		Usage: "(components) Do not deploy packaged components and delete any deployed components (valid items: " + DisableItems + ")",
 	},
+	&cli.StringSliceFlag{
+		Name:  "enable",
+		Usage: "(components) Deploy optional packaged components that are not deployed by default (valid items: " + EnableItems + ")",
+	},
+	&cli.BoolFlag{
+		Name:        "enable-gateway-api",
+		Usage:       "(components) Install the Gateway API CRDs, and configure the packaged Traefik with a default GatewayClass and Gateway exposed by its LoadBalancer service",
+		Destination: &ServerConfig.EnableGatewayAPI,
+	},
+	&cli.StringFlag{
+		Name:        "nvidia-mig-strategy",
+		Usage:       "(components) MIG strategy of the packaged NVIDIA device plugin (valid values: 'none', 'single', 'mixed') (default: 'mixed' if nvidia-mig-profile is set, otherwise 'none')",
+		Destination: &ServerConfig.NvidiaMIGStrategy,
+	},
+	&cli.StringSliceFlag{
+		Name:  "nvidia-mig-profile",
+		Usage: "(components) Number of MIG devices of a profile that each MIG-capable GPU is partitioned into by the packaged NVIDIA MIG manager, for example '1g.5gb=7'. Applied to nodes labeled nvidia.com/mig.config=k3s",
+		Value: &ServerConfig.NvidiaMIGProfiles,
+	},
+	&cli.StringSliceFlag{
+		Name:  "nvidia-time-slicing",
+		Usage: "(components) Number of time-sliced replicas that each GPU is shared as by the packaged NVIDIA device plugin, optionally for a specific resource, for example '4' or 'nvidia.com/mig-1g.5gb=2'",
+		Value: &ServerConfig.NvidiaTimeSlicing,
+	},
 	&cli.BoolFlag{
 		Name:        "disable-scheduler",
 		Usage:       "(components) Disable Kubernetes default scheduler",
@@ -477,10 +784,19 @@ var ServerFlags = []cli.Flag{
 		Usage:       "(experimental/components) Disable running etcd",
 		Destination: &ServerConfig.DisableETCD,
 	},
+	&cli.StringSliceFlag{
+		Name:  "disable-components",
+		Usage: "(components) Disable embedded components (valid items: apiserver, cloud-controller, control-plane-only, controller-manager, etcd, helm-controller, kube-proxy, network-policy, scheduler, servicelb), or individual controllers run by the controller managers, for example 'controller-manager/ephemeral-volume' or 'cloud-controller/cloud-node-lifecycle'",
+		Value: &ServerConfig.DisableComponents,
+	},
 	NodeNameFlag,
 	WithNodeIDFlag,
 	NodeLabels,
 	NodeTaints,
+	RebootSentinelFlag,
+	RebootCommandFlag,
+	RebootCheckPeriodFlag,
+	RebootDrainTimeoutFlag,
 	ImageCredProvBinDirFlag,
 	ImageCredProvConfigFlag,
 	DockerFlag,
@@ -495,20 +811,127 @@ var ServerFlags = []cli.Flag{
 		Destination: &ServerConfig.SystemDefaultRegistry,
 	},
 	AirgapExtraRegistryFlag,
+	LocalRegistryListenFlag,
+	LocalRegistryAuthFlag,
+	LocalRegistryNameFlag,
+	RegistryHealthIntervalFlag,
+	RegistryHealthTimeoutFlag,
+	RegistryAuthSecretFlag,
+	ImagePullBandwidthLimitFlag,
+	ContainerdLogMaxSizeFlag,
+	ContainerdLogMaxBackupsFlag,
+	RuntimeSearchPathFlag,
 	NodeIPFlag,
+	NodeIPDiscoveryFlag,
 	NodeExternalIPFlag,
+	NodeExternalIPDiscoveryFlag,
+	NodeExternalIPSTUNServerFlag,
 	ResolvConfFlag,
 	FlannelIfaceFlag,
+	NetworkPolicyLoggingFlag,
 	FlannelConfFlag,
 	FlannelCniConfFileFlag,
 	ExtraKubeletArgs,
 	ExtraKubeProxyArgs,
 	ProtectKernelDefaultsFlag,
+	FailSwapOnFlag,
+	SwapBehaviorFlag,
+	ReserveResourcesFlag,
+	KubeReservedFlag,
+	SystemReservedFlag,
+	ContainerLogMaxSizeFlag,
+	ContainerLogMaxFilesFlag,
+	ImageGCHighThresholdFlag,
+	ImageGCLowThresholdFlag,
+	PinPreloadedImagesFlag,
+	StaticPodDirFlag,
+	CertificateExpiryWindowFlag,
+	CPUManagerPolicyFlag,
+	ReservedCPUsFlag,
+	MemoryManagerPolicyFlag,
+	ReservedMemoryFlag,
+	TopologyManagerPolicyFlag,
+	TopologyManagerScopeFlag,
+	TuningProfileFlag,
+	HugePagesFlag,
+	KubeletRootDirFlag,
+	AllowedUnsafeSysctlsFlag,
+	PreflightFlag,
 	&cli.BoolFlag{
 		Name:        "secrets-encryption",
 		Usage:       "Enable secret encryption at rest",
 		Destination: &ServerConfig.EncryptSecrets,
 	},
+	&cli.StringSliceFlag{
+		Name:  "secrets-encryption-resources",
+		Usage: "Resources to encrypt at rest when secret encryption is enabled, as resource or resource.group, for example configmaps or widgets.example.com. Secrets are always encrypted (default: secrets)",
+		Value: &ServerConfig.EncryptResources,
+	},
+	&cli.StringFlag{
+		Name:        "kms-provider-config",
+		Usage:       "(security) Path to the configuration of a KMS v2 plugin used to encrypt secrets at rest, instead of keys stored on disk. Requires secrets-encryption",
+		Destination: &ServerConfig.KMSProviderConfig,
+	},
+	&cli.StringSliceFlag{
+		Name:  "node-webhook-url",
+		Usage: "(notifications) Webhook URL to notify when nodes are registered, approved, deleted, change roles, or remain NotReady",
+		Value: &ServerConfig.NodeWebhookURLs,
+	},
+	&cli.DurationFlag{
+		Name:        "node-webhook-not-ready-threshold",
+		Usage:       "(notifications) Duration that a node must remain NotReady before webhooks are notified",
+		Destination: &ServerConfig.NodeWebhookNotReady,
+		Value:       5 * time.Minute,
+	},
+	&cli.IntFlag{
+		Name:        "stale-node-cleanup-days",
+		Usage:       "(cluster) Number of days that a node must be missing before its leftover node password secret is removed (0 to disable)",
+		Destination: &ServerConfig.StaleNodeCleanupDays,
+		Value:       7,
+	},
+	&cli.DurationFlag{
+		Name:        "clock-skew-threshold",
+		Usage:       "(cluster) Maximum difference between the clocks of joining or running nodes and the server before warnings and events are emitted (0 to disable)",
+		Destination: &ServerConfig.ClockSkewThreshold,
+		Value:       30 * time.Second,
+	},
+	&cli.BoolFlag{
+		Name:        "clock-skew-reject",
+		Usage:       "(cluster) Reject nodes joining with a clock that differs from the server clock by more than the clock skew threshold",
+		Destination: &ServerConfig.ClockSkewReject,
+	},
+	&cli.StringFlag{
+		Name:        "node-join-approval",
+		Usage:       "(cluster) Node join approval mode, one of 'auto' (any node with a valid token may join), or 'manual' (new nodes are not issued kubelet certificates until their NodeJoinRequest is approved with '" + version.Program + " node approve')",
+		Destination: &ServerConfig.NodeJoinApproval,
+		Value:       "auto",
+	},
+	&cli.StringFlag{
+		Name:        "registry-policy-mode",
+		Usage:       "(security) Registry policy admission mode for pod images, one of 'disabled', 'audit' (warn and log violations), or 'enforce' (reject violations). Must be the same on all servers, and requires the supervisor to listen on the loopback address",
+		Destination: &ServerConfig.RegistryPolicyMode,
+		Value:       "disabled",
+	},
+	&cli.StringSliceFlag{
+		Name:  "registry-policy-allow",
+		Usage: "(security) Registry or repository that pod images may be pulled from, for example 'registry.example.com', '*.example.com' or 'docker.io/library' (if unset, all not denied are allowed)",
+		Value: &ServerConfig.RegistryPolicyAllow,
+	},
+	&cli.StringSliceFlag{
+		Name:  "registry-policy-deny",
+		Usage: "(security) Registry or repository that pod images may not be pulled from; takes precedence over allowed registries",
+		Value: &ServerConfig.RegistryPolicyDeny,
+	},
+	&cli.StringSliceFlag{
+		Name:  "registry-policy-exempt-namespace",
+		Usage: "(security) Namespace whose pods are exempt from the registry policy. kube-system is always exempt",
+		Value: &ServerConfig.RegistryPolicyExempt,
+	},
+	&cli.StringFlag{
+		Name:        "namespace-defaults-config",
+		Usage:       "(security) Path to a file with ResourceQuota and LimitRange templates that are applied to new namespaces, and label-based exemptions",
+		Destination: &ServerConfig.NamespaceDefaultsConfig,
+	},
 	// Experimental flags
 	&cli.BoolFlag{
 		Name:        "enable-pprof",
@@ -546,12 +969,26 @@ var ServerFlags = []cli.Flag{
 	},
 }
 
-func NewServerCommand(action func(*cli.Context) error) cli.Command {
+func NewServerCommand(action, renderManifests func(*cli.Context) error) cli.Command {
 	return cli.Command{
 		Name:      "server",
 		Usage:     "Run management server",
 		UsageText: appName + " server [OPTIONS]",
 		Action:    action,
 		Flags:     ServerFlags,
+		Subcommands: []cli.Command{
+			{
+				Name:      "render-manifests",
+				Usage:     "Render packaged component manifests using the current server configuration",
+				UsageText: appName + " server [OPTIONS] render-manifests [--diff]",
+				Action:    renderManifests,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "diff",
+						Usage: "Show differences between the rendered manifests and the manifests currently deployed on this node",
+					},
+				},
+			},
+		},
 	}
 }
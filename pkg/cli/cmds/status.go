@@ -0,0 +1,44 @@
+package cmds
+
+import (
+	"github.com/urfave/cli"
+)
+
+const StatusCommand = "status"
+
+// Status holds CLI values for the status command
+type Status struct {
+	Kubeconfig string
+	Output     string
+}
+
+var (
+	StatusConfig = Status{}
+	StatusFlags  = []cli.Flag{
+		DebugFlag,
+		LogFile,
+		AlsoLogToStderr,
+		cli.StringFlag{
+			Name:        "kubeconfig",
+			Usage:       "(cluster) Server to connect to",
+			EnvVar:      "KUBECONFIG",
+			Destination: &StatusConfig.Kubeconfig,
+		},
+		cli.StringFlag{
+			Name:        "output,o",
+			Usage:       "Output format. Default: table. Optional: json, yaml",
+			Destination: &StatusConfig.Output,
+		},
+	}
+)
+
+func NewStatusCommand(action func(*cli.Context) error) cli.Command {
+	return cli.Command{
+		Name:            StatusCommand,
+		Usage:           "Show which servers lead the leader-elected embedded controllers",
+		SkipFlagParsing: false,
+		SkipArgReorder:  true,
+		Action:          action,
+		Flags:           StatusFlags,
+	}
+}
@@ -0,0 +1,41 @@
+package cmds
+
+import (
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/urfave/cli"
+)
+
+const VersionCommand = "version"
+
+// Version holds CLI values for the version command
+type Version struct {
+	Components bool
+	Output     string
+}
+
+var (
+	VersionConfig = Version{}
+	VersionFlags  = []cli.Flag{
+		cli.BoolFlag{
+			Name:        "components",
+			Usage:       "List the embedded components and packaged images, with their versions, digests and licenses",
+			Destination: &VersionConfig.Components,
+		},
+		cli.StringFlag{
+			Name:        "output,o",
+			Usage:       "Output format. Default: text. Optional: json, yaml, cyclonedx",
+			Destination: &VersionConfig.Output,
+		},
+	}
+)
+
+func NewVersionCommand(action func(*cli.Context) error) cli.Command {
+	return cli.Command{
+		Name:            VersionCommand,
+		Usage:           "Show the version of " + version.Program + " and its components",
+		SkipFlagParsing: false,
+		SkipArgReorder:  true,
+		Action:          action,
+		Flags:           VersionFlags,
+	}
+}
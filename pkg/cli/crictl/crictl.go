@@ -1,7 +1,7 @@
 package crictl
 
 import (
-	"github.com/kubernetes-sigs/cri-tools/cmd/crictl"
+	"github.com/k3s-io/k3s/pkg/crictl"
 	"github.com/urfave/cli"
 )
 
@@ -0,0 +1,437 @@
+package datadir
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/configfilearg"
+	"github.com/k3s-io/k3s/pkg/datadir"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/opencontainers/selinux/go-selinux"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// maxRewriteSize is the largest file that will be checked for references to the old data dir.
+const maxRewriteSize = 1024 * 1024
+
+// skipRewriteDirs are paths within the data dir that contain binaries, images, and datastore or
+// workload content, which should be moved as-is.
+var skipRewriteDirs = map[string]bool{
+	"data":             true,
+	"storage":          true,
+	"server/db":        true,
+	"agent/containerd": true,
+	"agent/images":     true,
+}
+
+var dataDirKey = regexp.MustCompile(`(?m)^(\s*"?data-dir"?\s*:).*$`)
+
+func Migrate(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return migrate(app, &cmds.ServerConfig, &cmds.DataDirMigrateConfig)
+}
+
+func migrate(app *cli.Context, cfg *cmds.Server, migrateCfg *cmds.DataDirMigrate) error {
+	if os.Getuid() != 0 {
+		return errors.New("data-dir migrate must be run as root")
+	}
+
+	configFile := app.String("config")
+	if cfg.DataDir == "" {
+		parser := &configfilearg.Parser{
+			FlagNames:     []string{"--config", "-c"},
+			EnvName:       version.ProgramUpper + "_CONFIG_FILE",
+			DefaultConfig: configFile,
+		}
+		dataDir, err := parser.FindString(os.Args, "data-dir")
+		if err != nil {
+			return errors.Wrap(err, "failed to find data-dir in config")
+		}
+		cfg.DataDir = dataDir
+	}
+	oldDir, err := datadir.Resolve(cfg.DataDir)
+	if err != nil {
+		return err
+	}
+	newDir, err := filepath.Abs(migrateCfg.To)
+	if err != nil {
+		return err
+	}
+	if err := validatePaths(oldDir, newDir); err != nil {
+		return err
+	}
+
+	service := migrateCfg.Service
+	if service == "" {
+		service = detectService()
+	}
+	if service != "" {
+		logrus.Infof("Stopping %s", service)
+		if err := serviceCommand(service, "stop"); err != nil {
+			return errors.Wrapf(err, "failed to stop %s", service)
+		}
+	}
+	// Stopping the service leaves pods running; the killall script stops them and unmounts their volumes.
+	if killall, err := exec.LookPath(version.Program + "-killall.sh"); err == nil {
+		logrus.Infof("Stopping containers with %s", killall)
+		if err := runCommand(killall); err != nil {
+			return errors.Wrapf(err, "failed to run %s", killall)
+		}
+	}
+	if err := checkNotInUse(oldDir); err != nil {
+		return errors.Wrapf(err, "%s is still in use; stop %s and all containers before migrating", oldDir, version.Program)
+	}
+
+	logrus.Infof("Moving %s to %s", oldDir, newDir)
+	if err := moveDir(oldDir, newDir); err != nil {
+		return errors.Wrapf(err, "failed to move %s to %s", oldDir, newDir)
+	}
+	if err := rewriteDataDir(oldDir, newDir); err != nil {
+		return errors.Wrap(err, "failed to update generated configuration")
+	}
+	if !migrateCfg.NoSymlink {
+		// The symlink keeps paths that are stored outside the data dir, such as PersistentVolume host paths, working.
+		if err := os.Symlink(newDir, oldDir); err != nil {
+			return errors.Wrapf(err, "failed to create symlink from %s to %s", oldDir, newDir)
+		}
+	}
+	if err := updateConfig(configFile, newDir); err != nil {
+		return errors.Wrapf(err, "failed to set data-dir in %s", configFile)
+	}
+	if service != "" {
+		if err := updateService(service, oldDir, newDir); err != nil {
+			return errors.Wrapf(err, "failed to update %s service", service)
+		}
+	}
+
+	if selinux.GetEnabled() {
+		logrus.Warnf("SELinux is enabled; to label new files in %s correctly, run: semanage fcontext -a -e %s %s && restorecon -R %s", newDir, oldDir, newDir, newDir)
+	}
+	logrus.Infof("Data directory migrated to %s", newDir)
+
+	if service == "" || migrateCfg.SkipRestart {
+		return nil
+	}
+	logrus.Infof("Starting %s", service)
+	return serviceCommand(service, "start")
+}
+
+// validatePaths checks that the data dir can be moved to the new path.
+func validatePaths(oldDir, newDir string) error {
+	if oldDir == newDir || strings.HasPrefix(newDir, oldDir+string(os.PathSeparator)) || strings.HasPrefix(oldDir, newDir+string(os.PathSeparator)) {
+		return fmt.Errorf("cannot migrate %s to %s: paths must not overlap", oldDir, newDir)
+	}
+	info, err := os.Lstat(oldDir)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, _ := os.Readlink(oldDir)
+		return fmt.Errorf("%s is a symlink to %s, and has already been migrated", oldDir, target)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", oldDir)
+	}
+	entries, err := os.ReadDir(newDir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", newDir)
+	}
+	return nil
+}
+
+// detectService returns the name of the installed server or agent service, if any.
+func detectService() string {
+	for _, service := range []string{version.Program, version.Program + "-agent"} {
+		for _, file := range []string{"/etc/systemd/system/" + service + ".service", "/etc/init.d/" + service} {
+			if _, err := os.Stat(file); err == nil {
+				return service
+			}
+		}
+	}
+	return ""
+}
+
+// serviceCommand runs the action against the service using systemd or openrc.
+func serviceCommand(service, action string) error {
+	if _, err := os.Stat("/run/systemd/system"); err == nil {
+		return runCommand("systemctl", action, service)
+	}
+	if _, err := exec.LookPath("rc-service"); err == nil {
+		return runCommand("rc-service", service, action)
+	}
+	return fmt.Errorf("no supported service manager found to %s %s", action, service)
+}
+
+func runCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// checkNotInUse returns an error if any process other than this one is running a binary or has its working
+// directory within the data dir, or if any filesystems are mounted within it.
+func checkNotInUse(dir string) error {
+	prefix := dir + string(os.PathSeparator)
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return err
+	}
+	self := strconv.Itoa(os.Getpid())
+	for _, proc := range procs {
+		if _, err := strconv.Atoi(proc.Name()); err != nil || proc.Name() == self {
+			continue
+		}
+		for _, link := range []string{"exe", "cwd"} {
+			if target, err := os.Readlink(filepath.Join("/proc", proc.Name(), link)); err == nil && strings.HasPrefix(target, prefix) {
+				return fmt.Errorf("process %s is running from %s", proc.Name(), target)
+			}
+		}
+	}
+
+	mountinfo, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(mountinfo), "\n") {
+		if fields := strings.Fields(line); len(fields) > 4 && strings.HasPrefix(fields[4], prefix) {
+			return fmt.Errorf("%s is mounted", fields[4])
+		}
+	}
+	return nil
+}
+
+// moveDir renames the directory if possible. If the new path is on a different filesystem, the content is copied
+// with cp, which preserves ownership, SELinux labels and other extended attributes, hard links, and special files
+// such as sockets, and the original is then removed.
+func moveDir(oldDir, newDir string) error {
+	if err := os.MkdirAll(filepath.Dir(newDir), 0755); err != nil {
+		return err
+	}
+	err := os.Rename(oldDir, newDir)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	logrus.Infof("%s is on a different filesystem, copying content", newDir)
+	if err := os.MkdirAll(newDir, 0700); err != nil {
+		return err
+	}
+	if err := runCommand("cp", "-a", oldDir+"/.", newDir); err != nil {
+		return err
+	}
+	return os.RemoveAll(oldDir)
+}
+
+// rewriteDataDir updates generated configuration files and symlinks within the new data dir that reference
+// the old data dir. Files are rewritten in place so that their ownership and labels are retained.
+func rewriteDataDir(oldDir, newDir string) error {
+	// The current and previous symlinks to the extracted binaries are absolute.
+	binDirs, err := os.ReadDir(filepath.Join(newDir, "data"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, d := range binDirs {
+		if d.Type()&fs.ModeSymlink != 0 {
+			if err := rewriteSymlink(filepath.Join(newDir, "data", d.Name()), oldDir, newDir); err != nil {
+				return err
+			}
+		}
+	}
+
+	return filepath.WalkDir(newDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(newDir, path)
+		if err != nil {
+			return err
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			return rewriteSymlink(path, oldDir, newDir)
+		}
+		if d.IsDir() {
+			if skipRewriteDirs[rel] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || info.Size() > maxRewriteSize {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(content, 0) != -1 {
+			return nil
+		}
+		newContent, ok := replaceDataDir(content, oldDir, newDir)
+		if !ok {
+			return nil
+		}
+		logrus.Infof("Updating data dir path in %s", path)
+		return rewriteFile(path, newContent)
+	})
+}
+
+// replaceDataDir replaces references to the old data dir in the content with the new data dir. Only complete
+// path components are matched, so that sibling paths such as /var/lib/rancher/k3s-foo, or paths that merely
+// contain the old data dir such as /mnt/var/lib/rancher/k3s, are left alone. It returns false if there were no
+// references to replace.
+func replaceDataDir(content []byte, oldDir, newDir string) ([]byte, bool) {
+	old := []byte(oldDir)
+	var out []byte
+	last := 0
+	for start := 0; ; {
+		i := bytes.Index(content[start:], old)
+		if i == -1 {
+			break
+		}
+		i += start
+		start = i + len(old)
+		if i > 0 && isPathByte(content[i-1]) {
+			continue
+		}
+		if start < len(content) && content[start] != '/' && isPathByte(content[start]) {
+			continue
+		}
+		out = append(append(out, content[last:i]...), newDir...)
+		last = start
+	}
+	if out == nil {
+		return content, false
+	}
+	return append(out, content[last:]...), true
+}
+
+// isPathByte returns true if the byte may be part of a path component or separator.
+func isPathByte(b byte) bool {
+	return b == '/' || b == '.' || b == '-' || b == '_' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// rewriteSymlink updates the symlink if its target is within the old data dir.
+func rewriteSymlink(path, oldDir, newDir string) error {
+	target, err := os.Readlink(path)
+	if err != nil || !strings.HasPrefix(target, oldDir+string(os.PathSeparator)) {
+		return err
+	}
+	newTarget := newDir + strings.TrimPrefix(target, oldDir)
+	logrus.Debugf("Updating symlink %s to %s", path, newTarget)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return os.Symlink(newTarget, path)
+}
+
+// rewriteFile replaces the content of an existing file, retaining its mode and attributes.
+func rewriteFile(path string, content []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// updateConfig sets the data-dir in the config file and any drop-in config files that set it. If it is
+// not set by any of them, it is added to the config file.
+func updateConfig(configFile, newDir string) error {
+	value := "${1} " + strconv.Quote(newDir)
+	dropins, err := filepath.Glob(configFile + ".d/*.yaml")
+	if err != nil {
+		return err
+	}
+	updated := false
+	for _, file := range append([]string{configFile}, dropins...) {
+		content, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !dataDirKey.Match(content) {
+			continue
+		}
+		logrus.Infof("Updating data-dir in %s", file)
+		if err := rewriteFile(file, dataDirKey.ReplaceAll(content, []byte(value))); err != nil {
+			return err
+		}
+		updated = true
+	}
+	if updated {
+		return nil
+	}
+
+	logrus.Infof("Adding data-dir to %s", configFile)
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	content = append(content, []byte("data-dir: "+strconv.Quote(newDir)+"\n")...)
+	return os.WriteFile(configFile, content, 0600)
+}
+
+// updateService replaces references to the old data dir in the service definition and environment files,
+// in case the data dir was set there instead of in the config file.
+func updateService(service, oldDir, newDir string) error {
+	files := []string{
+		"/etc/systemd/system/" + service + ".service",
+		"/etc/systemd/system/" + service + ".service.env",
+		"/etc/init.d/" + service,
+		"/etc/rancher/" + version.Program + "/" + service + ".env",
+	}
+	reload := false
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		newContent, ok := replaceDataDir(content, oldDir, newDir)
+		if !ok {
+			continue
+		}
+		logrus.Infof("Updating data dir path in %s", file)
+		if err := rewriteFile(file, newContent); err != nil {
+			return err
+		}
+		reload = reload || strings.HasPrefix(file, "/etc/systemd/")
+	}
+	if reload {
+		return runCommand("systemctl", "daemon-reload")
+	}
+	return nil
+}
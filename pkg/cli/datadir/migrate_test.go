@@ -0,0 +1,143 @@
+package datadir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_UnitReplaceDataDir(t *testing.T) {
+	const oldDir = "/var/lib/rancher/k3s"
+	const newDir = "/data/k3s"
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOK  bool
+	}{
+		{
+			name:    "no references",
+			content: "root = \"/var/lib/rancher/other\"\n",
+			want:    "root = \"/var/lib/rancher/other\"\n",
+		},
+		{
+			name:    "exact path",
+			content: "K3S_DATA_DIR=/var/lib/rancher/k3s\n",
+			want:    "K3S_DATA_DIR=/data/k3s\n",
+			wantOK:  true,
+		},
+		{
+			name:    "subpath",
+			content: "root = \"/var/lib/rancher/k3s/agent/containerd\"\n",
+			want:    "root = \"/data/k3s/agent/containerd\"\n",
+			wantOK:  true,
+		},
+		{
+			name:    "end of content",
+			content: "--data-dir /var/lib/rancher/k3s",
+			want:    "--data-dir /data/k3s",
+			wantOK:  true,
+		},
+		{
+			name:    "multiple references",
+			content: "/var/lib/rancher/k3s:/var/lib/rancher/k3s/bin /var/lib/rancher/k3s",
+			want:    "/data/k3s:/data/k3s/bin /data/k3s",
+			wantOK:  true,
+		},
+		{
+			name:    "sibling path",
+			content: "path: /var/lib/rancher/k3s-foo/bin\n",
+			want:    "path: /var/lib/rancher/k3s-foo/bin\n",
+		},
+		{
+			name:    "sibling path with suffix",
+			content: "path: /var/lib/rancher/k3s2 /var/lib/rancher/k3s.bak /var/lib/rancher/k3s_old\n",
+			want:    "path: /var/lib/rancher/k3s2 /var/lib/rancher/k3s.bak /var/lib/rancher/k3s_old\n",
+		},
+		{
+			name:    "nested path",
+			content: "path: /mnt/var/lib/rancher/k3s/server\n",
+			want:    "path: /mnt/var/lib/rancher/k3s/server\n",
+		},
+		{
+			name:    "sibling and matching paths",
+			content: "/var/lib/rancher/k3s-foo /var/lib/rancher/k3s/server /var/lib/rancher/k3sfoo",
+			want:    "/var/lib/rancher/k3s-foo /data/k3s/server /var/lib/rancher/k3sfoo",
+			wantOK:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := replaceDataDir([]byte(tt.content), oldDir, newDir)
+			if ok != tt.wantOK {
+				t.Errorf("replaceDataDir() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if string(got) != tt.want {
+				t.Errorf("replaceDataDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitRewriteDataDir(t *testing.T) {
+	tempDir := t.TempDir()
+	oldDir := filepath.Join(tempDir, "k3s")
+	newDir := filepath.Join(tempDir, "new")
+	files := map[string]string{
+		"agent/etc/containerd/config.toml": "root = \"" + oldDir + "/agent/containerd\"\n",
+		"agent/etc/sibling.conf":           "path = \"" + oldDir + "-foo/bin\"\n",
+		"server/db/etcd/config":            "data-dir: " + oldDir + "/server/db/etcd\n",
+	}
+	want := map[string]string{
+		"agent/etc/containerd/config.toml": "root = \"" + newDir + "/agent/containerd\"\n",
+		"agent/etc/sibling.conf":           "path = \"" + oldDir + "-foo/bin\"\n",
+		"server/db/etcd/config":            "data-dir: " + oldDir + "/server/db/etcd\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(newDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"data/current": oldDir + "/data/abc",
+		"data/sibling": oldDir + "-foo/data/abc",
+	}
+	wantLinks := map[string]string{
+		"data/current": newDir + "/data/abc",
+		"data/sibling": oldDir + "-foo/data/abc",
+	}
+	if err := os.MkdirAll(filepath.Join(newDir, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(newDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := rewriteDataDir(oldDir, newDir); err != nil {
+		t.Fatalf("rewriteDataDir() error = %v", err)
+	}
+	for name, content := range want {
+		b, err := os.ReadFile(filepath.Join(newDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != content {
+			t.Errorf("rewriteDataDir() %s = %q, want %q", name, b, content)
+		}
+	}
+	for name, target := range wantLinks {
+		got, err := os.Readlink(filepath.Join(newDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != target {
+			t.Errorf("rewriteDataDir() %s target = %q, want %q", name, got, target)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package etcdmember
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/erikdubbelboer/gspt"
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/clientaccess"
+	"github.com/k3s-io/k3s/pkg/etcd"
+	"github.com/k3s-io/k3s/pkg/server"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+var membersPath = "/v1-" + version.Program + "/etcd/members"
+
+func commandPrep(app *cli.Context, cfg *cmds.Server) (*clientaccess.Info, error) {
+	// hide process arguments from ps output, since they may contain
+	// database credentials or other secrets.
+	gspt.SetProcTitle(os.Args[0] + " etcd")
+
+	dataDir, err := server.ResolveDataDir(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Token == "" {
+		fp := filepath.Join(dataDir, "token")
+		tokenByte, err := os.ReadFile(fp)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Token = string(bytes.TrimRight(tokenByte, "\n"))
+	}
+	return clientaccess.ParseAndValidateToken(cfg.ServerURL, cfg.Token, clientaccess.WithUser("server"))
+}
+
+func wrapServerError(err error) error {
+	return errors.Wrap(err, "see server log for details")
+}
+
+// List prints the etcd cluster members. The applied raft index of learners is shown as a percentage of
+// the leader's, so that operators can see whether a learner is catching up or has stalled.
+func List(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	info, err := commandPrep(app, &cmds.ServerConfig)
+	if err != nil {
+		return err
+	}
+	data, err := info.Get(membersPath)
+	if err != nil {
+		return wrapServerError(err)
+	}
+	members := []etcd.MemberStatus{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	switch cmds.EtcdConfig.Output {
+	case "json":
+		return json.NewEncoder(os.Stdout).Encode(members)
+	case "yaml":
+		return yaml.NewEncoder(os.Stdout).Encode(members)
+	}
+
+	var leaderIndex uint64
+	for _, member := range members {
+		if member.IsLeader {
+			leaderIndex = member.RaftAppliedIndex
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", "ID", "NAME", "ROLE", "APPLIED INDEX", "SYNC", "PEER URLS")
+	for _, member := range members {
+		role := "voter"
+		if member.IsLeader {
+			role = "leader"
+		} else if member.IsLearner {
+			role = "learner"
+		}
+		applied := "<unknown>"
+		sync := "-"
+		if member.Error == "" {
+			applied = fmt.Sprintf("%d", member.RaftAppliedIndex)
+			if member.IsLearner && leaderIndex > 0 {
+				sync = fmt.Sprintf("%.1f%%", float64(member.RaftAppliedIndex)*100/float64(leaderIndex))
+				if member.LastProgress != nil {
+					sync += fmt.Sprintf(" (last progress %s ago)", duration.ShortHumanDuration(time.Since(*member.LastProgress)))
+				}
+			}
+		}
+		peerURLs := "<none>"
+		if len(member.PeerURLs) > 0 {
+			peerURLs = member.PeerURLs[0]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", member.ID, member.Name, role, applied, sync, peerURLs)
+	}
+	return nil
+}
+
+// Promote requests that the server promote a learner to a voting member.
+func Promote(app *cli.Context) error {
+	return memberAction(app, "promote", "Promoted")
+}
+
+// Remove requests that the server remove a member from the etcd cluster.
+func Remove(app *cli.Context) error {
+	return memberAction(app, "remove", "Removed")
+}
+
+func memberAction(app *cli.Context, action, done string) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	if app.NArg() != 1 {
+		return fmt.Errorf("exactly one etcd member name or ID must be provided")
+	}
+	name := app.Args().First()
+	info, err := commandPrep(app, &cmds.ServerConfig)
+	if err != nil {
+		return err
+	}
+	if err := info.Put(membersPath+"/"+url.PathEscape(name)+"/"+action, nil); err != nil {
+		return wrapServerError(err)
+	}
+	fmt.Printf("%s etcd member %s\n", done, name)
+	return nil
+}
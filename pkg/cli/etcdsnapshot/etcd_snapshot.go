@@ -1,6 +1,7 @@
 package etcdsnapshot
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,11 +13,14 @@ import (
 
 	"github.com/erikdubbelboer/gspt"
 	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/clientaccess"
 	"github.com/k3s-io/k3s/pkg/cluster"
 	"github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/etcd"
 	"github.com/k3s-io/k3s/pkg/server"
 	util2 "github.com/k3s-io/k3s/pkg/util"
+	"github.com/k3s-io/k3s/pkg/version"
+	pkgerrors "github.com/pkg/errors"
 	"github.com/rancher/wrangler/pkg/signals"
 	"github.com/urfave/cli"
 	"gopkg.in/yaml.v2"
@@ -48,6 +52,8 @@ func commandSetup(app *cli.Context, cfg *cmds.Server, sc *server.Config) error {
 	sc.ControlConfig.EtcdSnapshotName = cfg.EtcdSnapshotName
 	sc.ControlConfig.EtcdSnapshotDir = cfg.EtcdSnapshotDir
 	sc.ControlConfig.EtcdSnapshotCompress = cfg.EtcdSnapshotCompress
+	sc.ControlConfig.EtcdSnapshotSigningKey = cfg.EtcdSnapshotSigningKey
+	sc.ControlConfig.EtcdSnapshotVerificationKey = cfg.EtcdSnapshotVerificationKey
 	sc.ControlConfig.EtcdListFormat = strings.ToLower(cfg.EtcdListFormat)
 	sc.ControlConfig.EtcdS3 = cfg.EtcdS3
 	sc.ControlConfig.EtcdS3Endpoint = cfg.EtcdS3Endpoint
@@ -59,6 +65,10 @@ func commandSetup(app *cli.Context, cfg *cmds.Server, sc *server.Config) error {
 	sc.ControlConfig.EtcdS3Region = cfg.EtcdS3Region
 	sc.ControlConfig.EtcdS3Folder = cfg.EtcdS3Folder
 	sc.ControlConfig.EtcdS3Insecure = cfg.EtcdS3Insecure
+	sc.ControlConfig.EtcdS3Incremental = cfg.EtcdS3Incremental
+	if cfg.EtcdS3Incremental && cfg.EtcdSnapshotCompress {
+		return errors.New("s3-incremental cannot be used with snapshot-compress, as compressed snapshots cannot be deduplicated")
+	}
 	sc.ControlConfig.EtcdS3Timeout = cfg.EtcdS3Timeout
 	sc.ControlConfig.Runtime = config.NewRuntime(nil)
 	sc.ControlConfig.Runtime.ETCDServerCA = filepath.Join(dataDir, "tls", "etcd", "server-ca.crt")
@@ -78,16 +88,20 @@ func Save(app *cli.Context) error {
 }
 
 func save(app *cli.Context, cfg *cmds.Server) error {
+	if len(app.Args()) > 0 {
+		return util2.ErrCommandNoArgs
+	}
+
+	if cfg.ServerURL != "" {
+		return saveRemote(app, cfg)
+	}
+
 	var serverConfig server.Config
 
 	if err := commandSetup(app, cfg, &serverConfig); err != nil {
 		return err
 	}
 
-	if len(app.Args()) > 0 {
-		return util2.ErrCommandNoArgs
-	}
-
 	serverConfig.ControlConfig.EtcdSnapshotRetention = 0 // disable retention check
 
 	ctx := signals.SetupSignalContext()
@@ -119,6 +133,57 @@ func save(app *cli.Context, cfg *cmds.Server) error {
 	return cluster.Snapshot(ctx, &serverConfig.ControlConfig)
 }
 
+// saveRemote requests an on-demand snapshot from a running server via the supervisor API,
+// and waits for it to complete. The snapshot is taken using the server's snapshot configuration.
+func saveRemote(app *cli.Context, cfg *cmds.Server) error {
+	gspt.SetProcTitle(os.Args[0] + " etcd-snapshot")
+
+	dataDir, err := server.ResolveDataDir(cfg.DataDir)
+	if err != nil {
+		return err
+	}
+	if cfg.Token == "" {
+		tokenByte, err := os.ReadFile(filepath.Join(dataDir, "token"))
+		if err != nil {
+			return err
+		}
+		cfg.Token = string(bytes.TrimRight(tokenByte, "\n"))
+	}
+	info, err := clientaccess.ParseAndValidateToken(cfg.ServerURL, cfg.Token, clientaccess.WithUser("server"))
+	if err != nil {
+		return err
+	}
+
+	path := "/v1-" + version.Program + "/etcd/snapshot"
+	if err := info.Put(path, nil); err != nil {
+		return pkgerrors.Wrap(err, "failed to request snapshot; see server log for details")
+	}
+
+	ctx := signals.SetupSignalContext()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+		data, err := info.Get(path)
+		if err != nil {
+			return pkgerrors.Wrap(err, "failed to get snapshot status")
+		}
+		status := server.SnapshotStatus{}
+		if err := json.Unmarshal(data, &status); err != nil {
+			return err
+		}
+		switch status.State {
+		case server.SnapshotStateSucceeded:
+			fmt.Printf("Snapshot completed on %s\n", cfg.ServerURL)
+			return nil
+		case server.SnapshotStateFailed:
+			return fmt.Errorf("snapshot failed on %s: %s", cfg.ServerURL, status.Error)
+		}
+	}
+}
+
 func Delete(app *cli.Context) error {
 	if err := cmds.InitLogging(); err != nil {
 		return err
@@ -243,6 +308,10 @@ func prune(app *cli.Context, cfg *cmds.Server) error {
 	}
 
 	serverConfig.ControlConfig.EtcdSnapshotRetention = cfg.EtcdSnapshotRetention
+	if _, err := etcd.ParseRetentionPolicy(cfg.EtcdSnapshotRetentionPolicy); err != nil {
+		return pkgerrors.Wrap(err, "invalid snapshot-retention-policy")
+	}
+	serverConfig.ControlConfig.EtcdSnapshotRetentionPolicy = cfg.EtcdSnapshotRetentionPolicy
 
 	ctx := signals.SetupSignalContext()
 	e := etcd.NewETCD()
@@ -258,3 +327,35 @@ func prune(app *cli.Context, cfg *cmds.Server) error {
 
 	return e.PruneSnapshots(ctx)
 }
+
+func Verify(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return verify(app, &cmds.ServerConfig)
+}
+
+func verify(app *cli.Context, cfg *cmds.Server) error {
+	var serverConfig server.Config
+
+	if err := commandSetup(app, cfg, &serverConfig); err != nil {
+		return err
+	}
+
+	snapshots := app.Args()
+	if len(snapshots) == 0 {
+		return errors.New("no snapshots given for verification")
+	}
+
+	ctx := signals.SetupSignalContext()
+	e := etcd.NewETCD()
+	if err := e.SetControlConfig(ctx, &serverConfig.ControlConfig); err != nil {
+		return err
+	}
+
+	if err := e.VerifySnapshots(ctx, snapshots); err != nil {
+		return err
+	}
+	fmt.Printf("Verified %d snapshot(s)\n", len(snapshots))
+	return nil
+}
@@ -0,0 +1,139 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/k3s-io/k3s/pkg/agent/containerd"
+	"github.com/k3s-io/k3s/pkg/airgap"
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/datadir"
+	"github.com/pkg/errors"
+	"github.com/rancher/wharfie/pkg/tarfile"
+	"github.com/rancher/wrangler/pkg/signals"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// Export pulls the images used by this version, and any extra images, and writes them to an image tarball
+// that can be copied to air-gapped nodes.
+func Export(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return export(app, &cmds.ImagesConfig)
+}
+
+func export(app *cli.Context, cfg *cmds.Images) error {
+	ctx := signals.SetupSignalContext()
+	platform := v1.Platform{OS: runtime.GOOS, Architecture: cfg.Arch}
+	fetch, err := airgap.RegistryFetcher(ctx, cfg.PrivateRegistry, platform)
+	if err != nil {
+		return err
+	}
+	refs := airgap.Images(cfg.ExtraImages)
+	if err := airgap.Export(refs, cfg.Output, fetch); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d images for %s to %s\n", len(refs), platform.OS+"/"+platform.Architecture, cfg.Output)
+	return nil
+}
+
+// Import copies image tarballs into the agent images directory, so that they are imported every time the
+// agent starts, and imports them into containerd immediately if it is already running.
+func Import(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return importImages(app, &cmds.ServerConfig, &cmds.ImagesConfig)
+}
+
+func importImages(app *cli.Context, serverCfg *cmds.Server, cfg *cmds.Images) error {
+	if app.NArg() == 0 {
+		return errors.New("at least one image tarball must be provided")
+	}
+	dataDir, err := datadir.Resolve(serverCfg.DataDir)
+	if err != nil {
+		return err
+	}
+	imagesDir := filepath.Join(dataDir, "agent", "images")
+	if err := os.MkdirAll(imagesDir, 0755); err != nil {
+		return err
+	}
+
+	ctx := signals.SetupSignalContext()
+	running := containerdRunning(ctx, cfg.ContainerdAddr)
+	for _, file := range app.Args() {
+		// Check that the file can be imported before copying it, as the agent skips unsupported files
+		if _, err := tarfile.GetOpener(file); err != nil {
+			return errors.Wrapf(err, "cannot import %s", file)
+		}
+		dest := filepath.Join(imagesDir, filepath.Base(file))
+		if err := copyFile(file, dest); err != nil {
+			return errors.Wrapf(err, "failed to copy %s to %s", file, imagesDir)
+		}
+		fmt.Printf("Copied %s to %s\n", file, dest)
+
+		if !running {
+			continue
+		}
+		start := time.Now()
+		images, err := containerd.Import(ctx, cfg.ContainerdAddr, dest)
+		if err != nil {
+			return errors.Wrapf(err, "failed to import images from %s", dest)
+		}
+		fmt.Printf("Imported %d images from %s in %s\n", len(images), dest, time.Since(start).Round(time.Millisecond))
+	}
+	if !running {
+		fmt.Println("Containerd is not running; images will be imported when the agent starts")
+	}
+	return nil
+}
+
+// containerdRunning returns true if containerd is listening at the given address.
+func containerdRunning(ctx context.Context, address string) bool {
+	client, err := containerd.Client(address)
+	if err != nil {
+		logrus.Debugf("Failed to create containerd client for %s: %v", address, err)
+		return false
+	}
+	defer client.Close()
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	serving, err := client.IsServing(ctx)
+	if err != nil {
+		logrus.Debugf("Failed to connect to containerd at %s: %v", address, err)
+	}
+	return serving
+}
+
+// copyFile copies a file to a temporary file next to the destination, and renames it into place once
+// complete, so that the agent never imports a partially copied tarball.
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), dest)
+}
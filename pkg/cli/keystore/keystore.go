@@ -0,0 +1,113 @@
+package keystore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/erikdubbelboer/gspt"
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/cluster"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/daemons/control/deps"
+	"github.com/k3s-io/k3s/pkg/datadir"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// commandSetup returns the server config with the paths of the keys and tokens in the data directory.
+// If no token was provided, it is read from the data directory.
+func commandSetup(cfg *cmds.Server) (*config.Control, error) {
+	// hide process arguments from ps output, since they may contain the token.
+	gspt.SetProcTitle(os.Args[0] + " keystore")
+
+	dataDir, err := datadir.Resolve(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	controlConfig := &config.Control{
+		DataDir: filepath.Join(dataDir, "server"),
+		Runtime: config.NewRuntime(nil),
+	}
+	deps.CreateRuntimeCertFiles(controlConfig)
+
+	if cfg.Token == "" {
+		tokenByte, err := os.ReadFile(filepath.Join(controlConfig.DataDir, "token"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, errors.New("--token is required when the data directory does not contain a token")
+			}
+			return nil, err
+		}
+		cfg.Token = string(bytes.TrimRight(tokenByte, "\n"))
+	}
+	controlConfig.Token = cfg.Token
+	return controlConfig, nil
+}
+
+func Export(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return export(&cmds.ServerConfig, &cmds.KeystoreConfig)
+}
+
+func export(cfg *cmds.Server, ks *cmds.Keystore) error {
+	controlConfig, err := commandSetup(cfg)
+	if err != nil {
+		return err
+	}
+
+	var k *cluster.Keystore
+	if ks.EtcdSnapshot != "" {
+		k, err = cluster.ReadKeystoreFromSnapshot(ks.EtcdSnapshot, controlConfig.Token)
+	} else {
+		k, err = cluster.ReadKeystore(controlConfig)
+	}
+	if err != nil {
+		return err
+	}
+	data, err := k.Encrypt(controlConfig.Token)
+	if err != nil {
+		return err
+	}
+
+	if ks.File == "" {
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	if err := os.WriteFile(ks.File, data, 0600); err != nil {
+		return err
+	}
+	logrus.Infof("Keystore written to %s", ks.File)
+	return nil
+}
+
+func Import(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return importKeystore(&cmds.ServerConfig, &cmds.KeystoreConfig)
+}
+
+func importKeystore(cfg *cmds.Server, ks *cmds.Keystore) error {
+	controlConfig, err := commandSetup(cfg)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(ks.File)
+	if err != nil {
+		return err
+	}
+	k, err := cluster.DecryptKeystore(data, controlConfig.Token)
+	if err != nil {
+		return err
+	}
+	if err := k.WriteToDisk(controlConfig, ks.Force); err != nil {
+		return err
+	}
+	logrus.Infof("Imported %d files from keystore created at %s to %s", len(k.Files), k.CreatedAt.Format("2006-01-02T15:04:05Z"), controlConfig.DataDir)
+	return nil
+}
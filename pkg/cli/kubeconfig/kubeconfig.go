@@ -0,0 +1,88 @@
+package kubeconfig
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/kubectl"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+func Add(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return add(app, &cmds.KubeconfigConfig)
+}
+
+func add(app *cli.Context, cfg *cmds.Kubeconfig) error {
+	if app.NArg() != 2 {
+		return errors.New("a context name and kubeconfig file must be given")
+	}
+	dir, err := kubectl.ContextDir(cfg.ContextDir)
+	if err != nil {
+		return err
+	}
+	path, err := kubectl.AddContext(dir, app.Args().Get(0), app.Args().Get(1), cfg.Context, cfg.Server)
+	if err != nil {
+		return err
+	}
+	logrus.Infof("Added context %s to %s", app.Args().Get(0), path)
+	return nil
+}
+
+func Remove(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return remove(app, &cmds.KubeconfigConfig)
+}
+
+func remove(app *cli.Context, cfg *cmds.Kubeconfig) error {
+	if app.NArg() == 0 {
+		return errors.New("at least one context name must be given")
+	}
+	dir, err := kubectl.ContextDir(cfg.ContextDir)
+	if err != nil {
+		return err
+	}
+	for _, name := range app.Args() {
+		if err := kubectl.RemoveContext(dir, name); err != nil {
+			return err
+		}
+		logrus.Infof("Removed context %s", name)
+	}
+	return nil
+}
+
+func List(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return list(app, &cmds.KubeconfigConfig)
+}
+
+func list(app *cli.Context, cfg *cmds.Kubeconfig) error {
+	dir, err := kubectl.ContextDir(cfg.ContextDir)
+	if err != nil {
+		return err
+	}
+	contexts, err := kubectl.ListContexts(dir)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
+	defer w.Flush()
+
+	format := "%s\t%s\t%s\n"
+	fmt.Fprintf(w, format, "NAME", "SERVER", "FILE")
+	for _, context := range contexts {
+		fmt.Fprintf(w, format, context.Name, context.Server, context.File)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package logs
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/erikdubbelboer/gspt"
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/clientaccess"
+	"github.com/k3s-io/k3s/pkg/server"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+// Run prints the recent logs of an embedded component, as retrieved from the supervisor.
+func Run(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return logs(app, &cmds.ServerConfig, &cmds.LogsConfig)
+}
+
+func logs(app *cli.Context, cfg *cmds.Server, logsCfg *cmds.Logs) error {
+	// hide process arguments from ps output, since they may contain
+	// database credentials or other secrets.
+	gspt.SetProcTitle(os.Args[0] + " logs")
+
+	dataDir, err := server.ResolveDataDir(cfg.DataDir)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Token == "" {
+		tokenByte, err := os.ReadFile(filepath.Join(dataDir, "token"))
+		if err != nil {
+			return err
+		}
+		cfg.Token = string(bytes.TrimRight(tokenByte, "\n"))
+	}
+	info, err := clientaccess.ParseAndValidateToken(cfg.ServerURL, cfg.Token, clientaccess.WithUser("server"))
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("component", logsCfg.Component)
+	query.Set("since", logsCfg.Since)
+	if logsCfg.Node != "" {
+		query.Set("node", logsCfg.Node)
+	}
+	data, err := info.Get("/v1-" + version.Program + "/logs?" + query.Encode())
+	if err != nil {
+		return errors.Wrap(err, "see server log for details")
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
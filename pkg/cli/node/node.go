@@ -0,0 +1,74 @@
+package node
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/erikdubbelboer/gspt"
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/clientaccess"
+	"github.com/k3s-io/k3s/pkg/server"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var nodesPath = "/v1-" + version.Program + "/nodes"
+
+func commandPrep(app *cli.Context, cfg *cmds.Server) (*clientaccess.Info, error) {
+	// hide process arguments from ps output, since they may contain
+	// database credentials or other secrets.
+	gspt.SetProcTitle(os.Args[0] + " node")
+
+	dataDir, err := server.ResolveDataDir(cfg.DataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Token == "" {
+		fp := filepath.Join(dataDir, "token")
+		tokenByte, err := os.ReadFile(fp)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Token = string(bytes.TrimRight(tokenByte, "\n"))
+	}
+	return clientaccess.ParseAndValidateToken(cfg.ServerURL, cfg.Token, clientaccess.WithUser("server"))
+}
+
+func wrapServerError(err error) error {
+	return errors.Wrap(err, "see server log for details")
+}
+
+// Approve approves the join request for a node, so that it is issued kubelet certificates the next time it
+// requests them. Join requests can also be approved by setting spec.approved on the NodeJoinRequest with kubectl.
+func Approve(app *cli.Context) error {
+	return joinRequestAction(app, "approve", "Approved")
+}
+
+// Deny denies the join request for a node. The node is rejected until the request is approved or deleted.
+func Deny(app *cli.Context) error {
+	return joinRequestAction(app, "deny", "Denied")
+}
+
+func joinRequestAction(app *cli.Context, action, done string) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	if app.NArg() != 1 {
+		return fmt.Errorf("exactly one node name must be provided")
+	}
+	name := app.Args().First()
+	info, err := commandPrep(app, &cmds.ServerConfig)
+	if err != nil {
+		return err
+	}
+	if err := info.Post(nodesPath+"/"+url.PathEscape(name)+"/"+action, nil); err != nil {
+		return wrapServerError(err)
+	}
+	fmt.Printf("%s join request for node %s\n", done, name)
+	return nil
+}
@@ -0,0 +1,104 @@
+package node
+
+import (
+	"encoding/pem"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/urfave/cli"
+)
+
+func Test_UnitJoinRequestAction(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		action   string
+		wantPath string
+		wantErr  bool
+	}{
+		{
+			name:     "approve",
+			args:     []string{"node1"},
+			action:   "approve",
+			wantPath: nodesPath + "/node1/approve",
+		},
+		{
+			name:     "deny",
+			args:     []string{"node1"},
+			action:   "deny",
+			wantPath: nodesPath + "/node1/deny",
+		},
+		{
+			name:     "escaped name",
+			args:     []string{"node/1"},
+			action:   "approve",
+			wantPath: nodesPath + "/node%2F1/approve",
+		},
+		{
+			name:     "no join request",
+			args:     []string{"node2"},
+			action:   "approve",
+			wantPath: nodesPath + "/node2/approve",
+			wantErr:  true,
+		},
+		{
+			name:    "no node name",
+			action:  "approve",
+			wantErr: true,
+		},
+		{
+			name:    "multiple node names",
+			args:    []string{"node1", "node2"},
+			action:  "approve",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath string
+			var server *httptest.Server
+			server = httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/cacerts" {
+					rw.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+					return
+				}
+				if username, password, ok := req.BasicAuth(); !ok || username != "server" || password != "secret" {
+					rw.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if req.Method != http.MethodPost {
+					rw.WriteHeader(http.StatusMethodNotAllowed)
+					return
+				}
+				gotPath = req.URL.EscapedPath()
+				if gotPath != nodesPath+"/node1/"+tt.action && gotPath != nodesPath+"/node%2F1/"+tt.action {
+					rw.WriteHeader(http.StatusNotFound)
+					rw.Write([]byte("nodejoinrequests.k3s.cattle.io not found"))
+					return
+				}
+				rw.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			defer func(cfg cmds.Server) { cmds.ServerConfig = cfg }(cmds.ServerConfig)
+			cmds.ServerConfig.DataDir = t.TempDir()
+			cmds.ServerConfig.ServerURL = server.URL
+			cmds.ServerConfig.Token = "secret"
+
+			set := flag.NewFlagSet(tt.action, flag.ContinueOnError)
+			if err := set.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			err := joinRequestAction(cli.NewContext(nil, set, nil), tt.action, "Done")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("joinRequestAction() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotPath != tt.wantPath {
+				t.Errorf("joinRequestAction() requested path %q, want %q", gotPath, tt.wantPath)
+			}
+		})
+	}
+}
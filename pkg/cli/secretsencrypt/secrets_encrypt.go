@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/erikdubbelboer/gspt"
 	"github.com/k3s-io/k3s/pkg/cli/cmds"
@@ -17,6 +18,7 @@ import (
 	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/utils/pointer"
 )
 
@@ -123,6 +125,12 @@ func Status(app *cli.Context) error {
 		statusOutput += "Encryption Status: Disabled\n"
 	}
 	statusOutput += fmt.Sprintln("Current Rotation Stage:", status.Stage)
+	if len(status.Resources) > 0 {
+		statusOutput += fmt.Sprintln("Encrypted Resources:", strings.Join(status.Resources, ", "))
+	}
+	if len(status.DecryptOnly) > 0 {
+		statusOutput += fmt.Sprintln("Resources Pending Reencrypt:", strings.Join(status.DecryptOnly, ", "))
+	}
 
 	if status.HashMatch {
 		statusOutput += fmt.Sprintln("Server Encryption Hashes: All hashes match")
@@ -135,17 +143,59 @@ func Status(app *cli.Context) error {
 	fmt.Fprintf(w, "\n")
 	fmt.Fprintf(w, "Active\tKey Type\tName\n")
 	fmt.Fprintf(w, "------\t--------\t----\n")
+	keyType := func(name string) string {
+		if name == status.KMSProvider {
+			return "KMSv2"
+		}
+		return "AES-CBC"
+	}
 	if status.ActiveKey != "" {
-		fmt.Fprintf(w, " *\t%s\t%s\n", "AES-CBC", status.ActiveKey)
+		fmt.Fprintf(w, " *\t%s\t%s\n", keyType(status.ActiveKey), status.ActiveKey)
 	}
 	for _, k := range status.InactiveKeys {
-		fmt.Fprintf(w, "\t%s\t%s\n", "AES-CBC", k)
+		fmt.Fprintf(w, "\t%s\t%s\n", keyType(k), k)
 	}
 	w.Flush()
 	fmt.Println(statusOutput + tabBuffer.String())
+	printReencryptProgress(status.ReencryptProgress)
 	return nil
 }
 
+// printReencryptProgress prints the progress of the most recent reencryption, if any.
+func printReencryptProgress(progress []secretsencrypt.ReencryptProgress) {
+	if len(progress) == 0 {
+		return
+	}
+	fmt.Println("Reencryption Progress:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Resource\tDone\tRemaining\tFailed\tServer\tStatus\n")
+	fmt.Fprintf(w, "--------\t----\t---------\t------\t------\t------\n")
+	for _, p := range progress {
+		status := "In progress, updated " + duration.HumanDuration(time.Since(p.Updated.Time)) + " ago"
+		if p.Completed != nil {
+			status = "Completed " + duration.HumanDuration(time.Since(p.Completed.Time)) + " ago"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\n", p.Resource, p.Done, p.Remaining, p.Failed, p.Server, status)
+	}
+	w.Flush()
+	for _, p := range progress {
+		if len(p.Failures) == 0 {
+			continue
+		}
+		fmt.Printf("\nFailed %s:\n", p.Resource)
+		for _, f := range p.Failures {
+			name := f.Name
+			if f.Namespace != "" {
+				name = f.Namespace + "/" + f.Name
+			}
+			fmt.Printf("  %s: %s\n", name, f.Reason)
+		}
+		if p.Failed > len(p.Failures) {
+			fmt.Printf("  ... and %d more\n", p.Failed-len(p.Failures))
+		}
+	}
+}
+
 func Prepare(app *cli.Context) error {
 	var err error
 	if err = cmds.InitLogging(); err != nil {
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/clientaccess"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/server"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	utilsnet "k8s.io/utils/net"
+)
+
+// ulaPrefixFile is the name of the file in the server data dir that stores the IPv6 unique local address prefix
+// generated for the cluster, so that the same prefix is used when the server is restarted.
+const ulaPrefixFile = "ipv6-ula-prefix"
+
+// setDualStackRanges adds IPv6 cluster and service CIDRs when dual-stack operation is requested but only IPv4 ranges
+// are configured. Dual-stack operation is requested by the dual-stack flag, or by configuring dual-stack ranges for
+// only one of the cluster or service CIDRs. The IPv6 ranges are allocated from a unique local address prefix
+// that is generated once for the cluster; servers joining an existing cluster use the ranges in use by the cluster.
+func setDualStackRanges(controlConfig *config.Control, cfg *cmds.Server, nodeIPs []net.IP) error {
+	clusterIPv6 := hasIPv6Net(controlConfig.ClusterIPRanges)
+	serviceIPv6 := hasIPv6Net(controlConfig.ServiceIPRanges)
+	if clusterIPv6 && serviceIPv6 {
+		return nil
+	}
+	clusterDual, err := utilsnet.IsDualStackCIDRs(controlConfig.ClusterIPRanges)
+	if err != nil {
+		return errors.Wrap(err, "failed to validate cluster-cidr")
+	}
+	serviceDual, err := utilsnet.IsDualStackCIDRs(controlConfig.ServiceIPRanges)
+	if err != nil {
+		return errors.Wrap(err, "failed to validate service-cidr")
+	}
+	if !cfg.DualStack && !clusterDual && !serviceDual {
+		return nil
+	}
+	if clusterIPv6 && !clusterDual || serviceIPv6 && !serviceDual {
+		return errors.New("dual-stack operation requires IPv4 cluster-cidr and service-cidr ranges")
+	}
+	if dualNode, err := utilsnet.IsDualStackIPs(nodeIPs); err != nil {
+		return errors.Wrap(err, "failed to validate node-ip")
+	} else if !dualNode {
+		return fmt.Errorf("dual-stack operation requires both IPv4 and IPv6 node-ip addresses, found %v", nodeIPs)
+	}
+
+	clusterCIDR, serviceCIDR, err := getDualStackRanges(controlConfig, cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate IPv6 cluster-cidr and service-cidr for dual-stack operation")
+	}
+	if !clusterIPv6 {
+		logrus.Infof("Adding IPv6 cluster-cidr %s for dual-stack operation", clusterCIDR)
+		controlConfig.ClusterIPRanges = append(controlConfig.ClusterIPRanges, clusterCIDR)
+	}
+	if !serviceIPv6 {
+		logrus.Infof("Adding IPv6 service-cidr %s for dual-stack operation", serviceCIDR)
+		controlConfig.ServiceIPRanges = append(controlConfig.ServiceIPRanges, serviceCIDR)
+	}
+	return nil
+}
+
+// getDualStackRanges returns the IPv6 cluster and service CIDRs for the cluster. The ranges are read from the cluster
+// when joining, and otherwise allocated from the prefix stored in the data dir, which is generated if it does not exist.
+func getDualStackRanges(controlConfig *config.Control, cfg *cmds.Server) (*net.IPNet, *net.IPNet, error) {
+	dataDir, err := server.ResolveDataDir(cfg.DataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefixFile := filepath.Join(dataDir, ulaPrefixFile)
+
+	var prefix *net.IPNet
+	if b, err := os.ReadFile(prefixFile); err == nil {
+		if _, prefix, err = net.ParseCIDR(strings.TrimSpace(string(b))); err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid prefix in %s", prefixFile)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	if prefix == nil && controlConfig.JoinURL != "" {
+		clusterCIDR, serviceCIDR, err := getClusterDualStackRanges(controlConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		if clusterCIDR != nil && serviceCIDR != nil {
+			// Store the cluster's prefix if the ranges were allocated from it, so that the server can be restarted
+			// without contacting the cluster.
+			prefix := &net.IPNet{IP: clusterCIDR.IP.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}
+			if c, s := ulaRanges(prefix); c.String() == clusterCIDR.String() && s.String() == serviceCIDR.String() {
+				if err := writeULAPrefix(prefixFile, prefix); err != nil {
+					return nil, nil, err
+				}
+			}
+			return clusterCIDR, serviceCIDR, nil
+		}
+		return nil, nil, errors.New("the cluster being joined does not have IPv6 cluster-cidr and service-cidr ranges")
+	}
+
+	if prefix == nil {
+		if prefix, err = generateULAPrefix(); err != nil {
+			return nil, nil, err
+		}
+		if err := writeULAPrefix(prefixFile, prefix); err != nil {
+			return nil, nil, err
+		}
+		logrus.Infof("Generated IPv6 unique local address prefix %s for dual-stack operation", prefix)
+	}
+
+	clusterCIDR, serviceCIDR := ulaRanges(prefix)
+	return clusterCIDR, serviceCIDR, nil
+}
+
+// ulaRanges returns the cluster and service CIDRs allocated from a /48 prefix. Subnets 42xx and 43xx are used,
+// consistent with the default IPv6-only ranges.
+func ulaRanges(prefix *net.IPNet) (*net.IPNet, *net.IPNet) {
+	clusterCIDR := &net.IPNet{IP: make(net.IP, net.IPv6len), Mask: net.CIDRMask(56, 128)}
+	copy(clusterCIDR.IP, prefix.IP.To16())
+	clusterCIDR.IP[6] = 0x42
+	serviceCIDR := &net.IPNet{IP: make(net.IP, net.IPv6len), Mask: net.CIDRMask(112, 128)}
+	copy(serviceCIDR.IP, prefix.IP.To16())
+	serviceCIDR.IP[6] = 0x43
+	return clusterCIDR, serviceCIDR
+}
+
+func writeULAPrefix(prefixFile string, prefix *net.IPNet) error {
+	if err := os.MkdirAll(filepath.Dir(prefixFile), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(prefixFile, []byte(prefix.String()+"\n"), 0600)
+}
+
+// getClusterDualStackRanges retrieves the IPv6 cluster and service CIDRs used by an existing cluster,
+// so that joining servers use the same ranges.
+func getClusterDualStackRanges(controlConfig *config.Control) (*net.IPNet, *net.IPNet, error) {
+	token := controlConfig.AgentToken
+	if token == "" {
+		token = controlConfig.Token
+	}
+	info, err := clientaccess.ParseAndValidateToken(controlConfig.JoinURL, token, clientaccess.WithUser("node"))
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := info.Get("/v1-" + version.Program + "/config")
+	if err != nil {
+		return nil, nil, err
+	}
+	clusterControl := &config.Control{}
+	if err := json.Unmarshal(b, clusterControl); err != nil {
+		return nil, nil, err
+	}
+	return firstIPv6Net(clusterControl.ClusterIPRanges), firstIPv6Net(clusterControl.ServiceIPRanges), nil
+}
+
+// generateULAPrefix generates a random /48 unique local address prefix, as described in RFC 4193.
+func generateULAPrefix() (*net.IPNet, error) {
+	ip := make(net.IP, net.IPv6len)
+	ip[0] = 0xfd
+	if _, err := rand.Read(ip[1:6]); err != nil {
+		return nil, err
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(48, 128)}, nil
+}
+
+func hasIPv6Net(nets []*net.IPNet) bool {
+	return firstIPv6Net(nets) != nil
+}
+
+func firstIPv6Net(nets []*net.IPNet) *net.IPNet {
+	for _, n := range nets {
+		if utilsnet.IsIPv6CIDR(n) {
+			return n
+		}
+	}
+	return nil
+}
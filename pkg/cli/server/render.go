@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/datadir"
+	"github.com/k3s-io/k3s/pkg/deploy"
+	"github.com/k3s-io/k3s/pkg/server"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// RenderManifests renders the packaged component manifests using the current server configuration. The rendered
+// manifests are either printed, or compared against the manifests that are currently deployed on this node, so that
+// the effect of an upgrade or configuration change can be reviewed before the server is restarted.
+func RenderManifests(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	// Server flags are parsed by the parent server command, as the config file flags are inserted after it.
+	serverApp := app
+	if app.Parent() != nil {
+		serverApp = app.Parent()
+	}
+	return renderManifests(serverApp, &cmds.ServerConfig, app.Bool("diff"), os.Stdout)
+}
+
+func renderManifests(app *cli.Context, cfg *cmds.Server, diff bool, out io.Writer) error {
+	if cfg.Rootless {
+		dataDir, err := datadir.LocalHome(cfg.DataDir, true)
+		if err != nil {
+			return err
+		}
+		cfg.DataDir = dataDir
+	}
+	serverDataDir, err := server.ResolveDataDir(cfg.DataDir)
+	if err != nil {
+		return err
+	}
+
+	serverConfig, err := newServerConfig(app, cfg)
+	if err != nil {
+		return err
+	}
+	controlConfig := &serverConfig.ControlConfig
+
+	templateVars := server.ManifestTemplateVars(controlConfig)
+	manifests, err := deploy.Render(templateVars, controlConfig.Skips)
+	if err != nil {
+		return err
+	}
+	gatewayAPIManifest, gatewayAPICRDs := server.GatewayAPICRDs()
+	if controlConfig.EnableGatewayAPI && gatewayAPICRDs != nil {
+		manifests[gatewayAPIManifest] = gatewayAPICRDs
+	}
+
+	if !diff {
+		for _, name := range sortedNames(manifests) {
+			fmt.Fprintf(out, "---\n# Source: %s\n", name)
+			out.Write(manifests[name])
+		}
+		return nil
+	}
+
+	// Render all manifests without skips, so that disabled manifests that will be deleted can be found.
+	all, err := deploy.Render(templateVars, nil)
+	if err != nil {
+		return err
+	}
+	if gatewayAPICRDs != nil {
+		// The server removes the Gateway API CRDs manifest if Gateway API is not enabled, so it is shown as deleted.
+		all[gatewayAPIManifest] = gatewayAPICRDs
+		if !controlConfig.EnableGatewayAPI {
+			controlConfig.Disables[trimExt(gatewayAPIManifest)] = true
+		}
+	}
+	return diffManifests(filepath.Join(serverDataDir, "manifests"), all, manifests, controlConfig.Disables, out)
+}
+
+// diffManifests writes a unified diff between the deployed and rendered content of each packaged manifest.
+// Manifests that are disabled are shown as deleted, as the deploy controller will remove them.
+func diffManifests(manifestsDir string, all, rendered map[string][]byte, disables map[string]bool, out io.Writer) error {
+	changed := 0
+	for _, name := range sortedNames(all) {
+		deployedFile := filepath.Join(manifestsDir, name)
+		if _, err := os.Stat(deployedFile + ".skip"); err == nil {
+			logrus.Infof("Not comparing %s: manifest is skipped by %s.skip", name, deployedFile)
+			continue
+		}
+		content, ok := rendered[name]
+		if !ok && !disabled(name, disables) {
+			continue
+		}
+		deployed, err := os.ReadFile(deployedFile)
+		isDeployed := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if !ok && !isDeployed {
+			continue
+		}
+		if ok && isDeployed && bytes.Equal(deployed, content) {
+			continue
+		}
+
+		if err := writeDiff(name, deployed, content, out); err != nil {
+			return err
+		}
+		changed++
+	}
+
+	if changed == 0 {
+		logrus.Infof("Rendered manifests match the manifests deployed in %s", manifestsDir)
+	} else {
+		logrus.Infof("%d manifests in %s will be changed", changed, manifestsDir)
+	}
+	return nil
+}
+
+// writeDiff writes a unified diff between the deployed and rendered content of a manifest. Content that is
+// not deployed, or will be deleted, is empty.
+func writeDiff(name string, deployed, rendered []byte, out io.Writer) error {
+	return difflib.WriteUnifiedDiff(out, difflib.UnifiedDiff{
+		A:        splitLines(deployed),
+		B:        splitLines(rendered),
+		FromFile: "deployed/" + name,
+		ToFile:   "rendered/" + name,
+		Context:  3,
+	})
+}
+
+// splitLines splits content into lines that each end with a newline, as required by the diff writer.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n"
+	}
+	return lines
+}
+
+// disabled returns true if the manifest, or any directory containing it, is disabled.
+func disabled(name string, disables map[string]bool) bool {
+	for dir := name; dir != "."; dir = filepath.Dir(dir) {
+		if disables[dir] || disables[trimExt(dir)] {
+			return true
+		}
+	}
+	return false
+}
+
+func trimExt(name string) string {
+	return name[:len(name)-len(filepath.Ext(name))]
+}
+
+func sortedNames(manifests map[string][]byte) []string {
+	names := make([]string, 0, len(manifests))
+	for name := range manifests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/deploy"
+	"github.com/k3s-io/k3s/pkg/server"
+	"github.com/urfave/cli"
+)
+
+func Test_UnitSplitLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:    "trailing newline",
+			content: "a\nb\n",
+			want:    []string{"a\n", "b\n"},
+		},
+		{
+			name:    "no trailing newline",
+			content: "a\nb",
+			want:    []string{"a\n", "b\n"},
+		},
+		{
+			name:    "blank lines",
+			content: "a\n\n",
+			want:    []string{"a\n", "\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitLines([]byte(tt.content)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitDiffManifests(t *testing.T) {
+	deployed := map[string]string{
+		"coredns.yaml":                           "kind: ConfigMap\nname: coredns\nimage: coredns:1.10.0\n",
+		"local-storage.yaml":                     "kind: StorageClass\n",
+		"traefik.yaml":                           "kind: HelmChart\n",
+		"ccm.yaml":                               "kind: Deployment\nname: ccm\n",
+		"ccm.yaml.skip":                          "",
+		"metrics-server/metrics-apiservice.yaml": "kind: APIService\n",
+	}
+	all := map[string][]byte{
+		"coredns.yaml":                           []byte("kind: ConfigMap\nname: coredns\nimage: coredns:1.10.1\n"),
+		"local-storage.yaml":                     []byte("kind: StorageClass\n"),
+		"traefik.yaml":                           []byte("kind: HelmChart\n"),
+		"ccm.yaml":                               []byte("kind: Deployment\nname: ccm\nreplicas: 2\n"),
+		"npd.yaml":                               []byte("kind: DaemonSet\n"),
+		"nodelocaldns.yaml":                      []byte("kind: DaemonSet\n"),
+		"metrics-server/metrics-apiservice.yaml": []byte("kind: APIService\n"),
+	}
+	rendered := map[string][]byte{
+		"coredns.yaml":       all["coredns.yaml"],
+		"local-storage.yaml": all["local-storage.yaml"],
+		"ccm.yaml":           all["ccm.yaml"],
+		"npd.yaml":           all["npd.yaml"],
+	}
+	disables := map[string]bool{"traefik": true, "metrics-server": true}
+
+	manifestsDir := t.TempDir()
+	for name, content := range deployed {
+		path := filepath.Join(manifestsDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	if err := diffManifests(manifestsDir, all, rendered, disables, out); err != nil {
+		t.Fatalf("diffManifests() error = %v", err)
+	}
+	want := `--- deployed/coredns.yaml
++++ rendered/coredns.yaml
+@@ -1,3 +1,3 @@
+ kind: ConfigMap
+ name: coredns
+-image: coredns:1.10.0
++image: coredns:1.10.1
+--- deployed/metrics-server/metrics-apiservice.yaml
++++ rendered/metrics-server/metrics-apiservice.yaml
+@@ -1 +0,0 @@
+-kind: APIService
+--- deployed/npd.yaml
++++ rendered/npd.yaml
+@@ -0,0 +1 @@
++kind: DaemonSet
+--- deployed/traefik.yaml
++++ rendered/traefik.yaml
+@@ -1 +0,0 @@
+-kind: HelmChart
+`
+	if got := out.String(); got != want {
+		t.Errorf("diffManifests() output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// newTestServerContext resets the server and agent config, and returns a context for the server command with the
+// given data dir and arguments, as parsed by the server flags.
+func newTestServerContext(t *testing.T, dataDir string, args []string) *cli.Context {
+	cmds.ServerConfig = cmds.Server{}
+	cmds.AgentConfig = cmds.Agent{}
+	set := flag.NewFlagSet("server", flag.ContinueOnError)
+	for _, f := range cmds.ServerFlags {
+		f.Apply(set)
+	}
+	if err := set.Parse(append([]string{"--data-dir=" + dataDir}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	return cli.NewContext(nil, set, nil)
+}
+
+func Test_UnitRenderManifestsServerConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantVars     map[string]string
+		wantSkips    []string
+		wantDeployed []string
+	}{
+		{
+			name: "defaults",
+			wantVars: map[string]string{
+				"%{CLUSTER_CIDR}%":       "10.42.0.0/16",
+				"%{CLUSTER_DNS}%":        "10.43.0.10",
+				"%{ENABLE_GATEWAY_API}%": "false",
+			},
+			wantSkips:    []string{"calico", "cilium", "npd", "nvidia-device-plugin"},
+			wantDeployed: []string{"ccm", "coredns", "nodelocaldns", "traefik"},
+		},
+		{
+			name:         "servicelb disabled by component",
+			args:         []string{"--disable-cloud-controller", "--disable-components=servicelb"},
+			wantSkips:    []string{"ccm", "servicelb"},
+			wantDeployed: []string{"coredns"},
+		},
+		{
+			name: "custom cidrs and calico",
+			args: []string{"--cluster-cidr=10.100.0.0/16", "--service-cidr=10.200.0.0/16", "--cni=calico"},
+			wantVars: map[string]string{
+				"%{CLUSTER_CIDR}%": "10.100.0.0/16",
+				"%{CLUSTER_DNS}%":  "10.200.0.10",
+			},
+			wantSkips:    []string{"cilium"},
+			wantDeployed: []string{"calico"},
+		},
+		{
+			name: "nvidia mig profiles",
+			args: []string{"--enable=nvidia-device-plugin", "--nvidia-mig-profile=1g.5gb=7"},
+			wantVars: map[string]string{
+				"%{NVIDIA_DEVICE_PLUGIN_CONFIG}%": `{"version":"v1","flags":{"migStrategy":"mixed"}}`,
+			},
+			wantDeployed: []string{"nvidia-device-plugin"},
+		},
+		{
+			name:         "coredns disabled",
+			args:         []string{"--disable=coredns"},
+			wantSkips:    []string{"coredns", "nodelocaldns"},
+			wantDeployed: []string{"traefik"},
+		},
+		{
+			name: "gateway api without traefik",
+			args: []string{"--enable-gateway-api", "--disable=traefik"},
+			wantVars: map[string]string{
+				"%{ENABLE_GATEWAY_API}%": "true",
+			},
+			wantSkips: []string{"traefik"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			serverConfig, err := newServerConfig(newTestServerContext(t, dataDir, tt.args), &cmds.ServerConfig)
+			if err != nil {
+				t.Fatalf("newServerConfig() error = %v", err)
+			}
+			templateVars := server.ManifestTemplateVars(&serverConfig.ControlConfig)
+			for name, want := range tt.wantVars {
+				if got := templateVars[name]; got != want {
+					t.Errorf("ManifestTemplateVars() %s = %s, want %s", name, got, want)
+				}
+			}
+			for _, name := range tt.wantSkips {
+				if !serverConfig.ControlConfig.Skips[name] {
+					t.Errorf("Skips[%s] = false, want true", name)
+				}
+			}
+			for _, name := range tt.wantDeployed {
+				if serverConfig.ControlConfig.Skips[name] {
+					t.Errorf("Skips[%s] = true, want false", name)
+				}
+			}
+
+			// The manifests staged by the server and those printed by render-manifests must be the same.
+			manifests, err := deploy.Render(templateVars, serverConfig.ControlConfig.Skips)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := &bytes.Buffer{}
+			for _, name := range sortedNames(manifests) {
+				fmt.Fprintf(want, "---\n# Source: %s\n", name)
+				want.Write(manifests[name])
+			}
+			got := &bytes.Buffer{}
+			if err := renderManifests(newTestServerContext(t, dataDir, tt.args), &cmds.ServerConfig, false, got); err != nil {
+				t.Fatalf("renderManifests() error = %v", err)
+			}
+			if got.String() != want.String() {
+				t.Errorf("renderManifests() output does not match the manifests staged by the server")
+			}
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,15 +20,22 @@ import (
 	"github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/datadir"
 	"github.com/k3s-io/k3s/pkg/etcd"
+	"github.com/k3s-io/k3s/pkg/kine"
+	"github.com/k3s-io/k3s/pkg/nsdefaults"
+	"github.com/k3s-io/k3s/pkg/preflight"
+	"github.com/k3s-io/k3s/pkg/registrypolicy"
 	"github.com/k3s-io/k3s/pkg/rootless"
+	"github.com/k3s-io/k3s/pkg/secretsencrypt"
 	"github.com/k3s-io/k3s/pkg/server"
 	"github.com/k3s-io/k3s/pkg/token"
 	"github.com/k3s-io/k3s/pkg/util"
 	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/pkg/errors"
 	"github.com/rancher/wrangler/pkg/signals"
+	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	"k8s.io/apimachinery/pkg/api/resource"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	kubeapiserverflag "k8s.io/component-base/cli/flag"
 	"k8s.io/kubernetes/pkg/controlplane"
@@ -92,22 +101,166 @@ func run(app *cli.Context, cfg *cmds.Server, leaderControllers server.CustomCont
 
 	agentReady := make(chan struct{})
 
-	serverConfig := server.Config{}
-	serverConfig.DisableAgent = cfg.DisableAgent
+	serverConfig, err := newServerConfig(app, cfg)
+	if err != nil {
+		return err
+	}
 	serverConfig.ControlConfig.Runtime = config.NewRuntime(agentReady)
+	serverConfig.StartupHooks = append(serverConfig.StartupHooks, cfg.StartupHooks...)
+	serverConfig.LeaderControllers = append(serverConfig.LeaderControllers, leaderControllers...)
+	serverConfig.Controllers = append(serverConfig.Controllers, controllers...)
+
+	// If performing a cluster reset, make sure control-plane components are
+	// disabled so we only perform a reset or restore and bail out.
+	if cfg.ClusterReset {
+		serverConfig.ControlConfig.ClusterInit = true
+		serverConfig.ControlConfig.DisableAPIServer = true
+		serverConfig.ControlConfig.DisableControllerManager = true
+		serverConfig.ControlConfig.DisableScheduler = true
+		serverConfig.ControlConfig.DisableCCM = true
+
+		// If the supervisor and apiserver are on the same port, everything is running embedded
+		// and we don't need the kubelet or containerd up to perform a cluster reset.
+		if serverConfig.ControlConfig.SupervisorPort == serverConfig.ControlConfig.HTTPSPort {
+			cfg.DisableAgent = true
+		}
+
+		dataDir, err := datadir.LocalHome(cfg.DataDir, false)
+		if err != nil {
+			return err
+		}
+		// delete local loadbalancers state for apiserver and supervisor servers
+		loadbalancer.ResetLoadBalancer(filepath.Join(dataDir, "agent"), loadbalancer.SupervisorServiceName)
+		loadbalancer.ResetLoadBalancer(filepath.Join(dataDir, "agent"), loadbalancer.APIServerServiceName)
+
+		if cfg.ClusterResetRestorePath != "" || cfg.ClusterResetRestoreTime != "" {
+			// at this point we're doing a restore. Check to see if we've
+			// passed in a token and if not, check if the token file exists.
+			// If it doesn't, return an error indicating the token is necessary.
+			if cfg.Token == "" {
+				tokenFile := filepath.Join(dataDir, "server", "token")
+				if _, err := os.Stat(tokenFile); err != nil {
+					if os.IsNotExist(err) {
+						return errors.New(tokenFile + " does not exist, please pass --token to complete the restoration")
+					}
+				}
+			}
+		}
+	}
+
+	if err := preflight.Run(cmds.AgentConfig.Preflight, &preflight.Config{
+		DataDir:         filepath.Dir(serverConfig.ControlConfig.DataDir),
+		Ports:           preflightPorts(&serverConfig.ControlConfig, cfg),
+		Agent:           !cfg.DisableAgent,
+		Rootless:        cfg.Rootless,
+		ExternalRuntime: cmds.AgentConfig.Docker || cmds.AgentConfig.ContainerRuntimeEndpoint != "",
+	}); err != nil {
+		return err
+	}
+
+	logrus.Info("Starting " + version.Program + " " + app.App.Version)
+
+	notifySocket := os.Getenv("NOTIFY_SOCKET")
+	os.Unsetenv("NOTIFY_SOCKET")
+
+	ctx := signals.SetupSignalContext()
+
+	if err := server.StartServer(ctx, serverConfig, cfg); err != nil {
+		return err
+	}
+
+	go func() {
+		if !serverConfig.ControlConfig.DisableAPIServer {
+			<-serverConfig.ControlConfig.Runtime.APIServerReady
+			logrus.Info("Kube API server is now running")
+			serverConfig.ControlConfig.Runtime.StartupHooksWg.Wait()
+		}
+		if !serverConfig.ControlConfig.DisableETCD {
+			<-serverConfig.ControlConfig.Runtime.ETCDReady
+			logrus.Info("ETCD server is now running")
+		}
+
+		logrus.Info(version.Program + " is up and running")
+		os.Setenv("NOTIFY_SOCKET", notifySocket)
+		systemd.SdNotify(true, "READY=1\n")
+	}()
+
+	url := fmt.Sprintf("https://%s:%d", serverConfig.ControlConfig.SupervisorAddressOrLoopback(false, true), serverConfig.ControlConfig.SupervisorPort)
+	token, err := clientaccess.FormatToken(serverConfig.ControlConfig.Runtime.AgentToken, serverConfig.ControlConfig.Runtime.ServerCA)
+	if err != nil {
+		return err
+	}
+
+	agentConfig := cmds.AgentConfig
+	agentConfig.AgentReady = agentReady
+	agentConfig.Debug = app.GlobalBool("debug")
+	agentConfig.DataDir = filepath.Dir(serverConfig.ControlConfig.DataDir)
+	agentConfig.ServerURL = url
+	agentConfig.Token = token
+	agentConfig.DisableLoadBalancer = !serverConfig.ControlConfig.DisableAPIServer
+	agentConfig.DisableServiceLB = serverConfig.ControlConfig.DisableServiceLB
+	agentConfig.ETCDAgent = serverConfig.ControlConfig.DisableAPIServer
+	agentConfig.Server = true
+	agentConfig.ClusterReset = serverConfig.ControlConfig.ClusterReset
+	agentConfig.Rootless = cfg.Rootless
+
+	if agentConfig.Rootless {
+		// let agent specify Rootless kubelet flags, but not unshare twice
+		agentConfig.RootlessAlreadyUnshared = true
+	}
+
+	if serverConfig.ControlConfig.DisableAPIServer {
+		if cfg.ServerURL == "" {
+			// If this node is the initial member of the cluster and is not hosting an apiserver,
+			// always bootstrap the agent off local supervisor, and go through the process of reading
+			// apiserver endpoints from etcd and blocking further startup until one is available.
+			// This ensures that we don't end up in a chicken-and-egg situation on cluster restarts,
+			// where the loadbalancer is routing traffic to existing apiservers, but the apiservers
+			// are non-functional because they're waiting for us to start etcd.
+			loadbalancer.ResetLoadBalancer(filepath.Join(agentConfig.DataDir, "agent"), loadbalancer.SupervisorServiceName)
+		} else {
+			// If this is a secondary member of the cluster and is not hosting an apiserver,
+			// bootstrap the agent off the existing supervisor, instead of bootstrapping locally.
+			agentConfig.ServerURL = cfg.ServerURL
+		}
+		// initialize the apiAddress Channel for receiving the api address from etcd
+		agentConfig.APIAddressCh = make(chan []string)
+		go getAPIAddressFromEtcd(ctx, *serverConfig, agentConfig)
+	}
+
+	if cfg.DisableAgent {
+		agentConfig.ContainerRuntimeEndpoint = "/dev/null"
+		err = agent.RunStandalone(ctx, agentConfig)
+	} else {
+		err = agent.Run(ctx, agentConfig)
+	}
+
+	// wait for in-flight requests to drain before exiting, if stopping
+	if ctx.Err() != nil {
+		serverConfig.ControlConfig.Runtime.ShutdownWg.Wait()
+	}
+	return err
+}
+
+// newServerConfig returns the server configuration for the server flags. It is used both to start the server, and
+// to render the packaged manifests, so that the rendered manifests match the manifests that the server deploys.
+func newServerConfig(app *cli.Context, cfg *cmds.Server) (*server.Config, error) {
+	var err error
+	serverConfig := &server.Config{}
+	serverConfig.DisableAgent = cfg.DisableAgent
 	serverConfig.ControlConfig.Token = cfg.Token
 	serverConfig.ControlConfig.AgentToken = cfg.AgentToken
 	serverConfig.ControlConfig.JoinURL = cfg.ServerURL
 	if cfg.AgentTokenFile != "" {
 		serverConfig.ControlConfig.AgentToken, err = token.ReadFile(cfg.AgentTokenFile)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 	if cfg.TokenFile != "" {
 		serverConfig.ControlConfig.Token, err = token.ReadFile(cfg.TokenFile)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 	serverConfig.ControlConfig.DataDir = cfg.DataDir
@@ -115,12 +268,14 @@ func run(app *cli.Context, cfg *cmds.Server, leaderControllers server.CustomCont
 	serverConfig.ControlConfig.KubeConfigMode = cfg.KubeConfigMode
 	serverConfig.ControlConfig.Rootless = cfg.Rootless
 	serverConfig.ControlConfig.ServiceLBNamespace = cfg.ServiceLBNamespace
-	serverConfig.ControlConfig.SANs = util.SplitStringSlice(cfg.TLSSan)
+	serverConfig.ControlConfig.SANs = util.NormalizeSANs(util.SplitStringSlice(cfg.TLSSan))
 	serverConfig.ControlConfig.BindAddress = cfg.BindAddress
 	serverConfig.ControlConfig.SupervisorPort = cfg.SupervisorPort
 	serverConfig.ControlConfig.HTTPSPort = cfg.HTTPSPort
 	serverConfig.ControlConfig.APIServerPort = cfg.APIServerPort
 	serverConfig.ControlConfig.APIServerBindAddress = cfg.APIServerBindAddress
+	serverConfig.ControlConfig.SupervisorBindAddress = cfg.SupervisorBindAddress
+	serverConfig.ControlConfig.SupervisorAdvertiseAddress = cfg.SupervisorAdvertiseAddress
 	serverConfig.ControlConfig.EnablePProf = cfg.EnablePProf
 	serverConfig.ControlConfig.ExtraAPIArgs = cfg.ExtraAPIArgs
 	serverConfig.ControlConfig.ExtraControllerArgs = cfg.ExtraControllerArgs
@@ -131,13 +286,54 @@ func run(app *cli.Context, cfg *cmds.Server, leaderControllers server.CustomCont
 	serverConfig.ControlConfig.Datastore.BackendTLSConfig.CAFile = cfg.DatastoreCAFile
 	serverConfig.ControlConfig.Datastore.BackendTLSConfig.CertFile = cfg.DatastoreCertFile
 	serverConfig.ControlConfig.Datastore.BackendTLSConfig.KeyFile = cfg.DatastoreKeyFile
+	serverConfig.ControlConfig.KineStandalone = cfg.KineStandalone
+	serverConfig.ControlConfig.KineSlowSQLThreshold = cfg.DatastoreSlowQueryThreshold
+	if cfg.KineCPULimit != "" || cfg.KineMemoryLimit != "" {
+		if !cfg.KineStandalone {
+			return nil, errors.New("kine-cpu-limit and kine-memory-limit require kine-standalone")
+		}
+		limits, err := parseKineLimits(cfg.KineCPULimit, cfg.KineMemoryLimit)
+		if err != nil {
+			return nil, err
+		}
+		serverConfig.ControlConfig.KineLimits = limits
+	}
 	serverConfig.ControlConfig.AdvertiseIP = cfg.AdvertiseIP
 	serverConfig.ControlConfig.AdvertisePort = cfg.AdvertisePort
 	serverConfig.ControlConfig.MultiClusterCIDR = cfg.MultiClusterCIDR
 	serverConfig.ControlConfig.FlannelBackend = cfg.FlannelBackend
+	serverConfig.ControlConfig.CNI = strings.ToLower(cfg.CNI)
 	serverConfig.ControlConfig.FlannelIPv6Masq = cfg.FlannelIPv6Masq
 	serverConfig.ControlConfig.FlannelExternalIP = cfg.FlannelExternalIP
-	serverConfig.ControlConfig.EgressSelectorMode = cfg.EgressSelectorMode
+	serverConfig.ControlConfig.FlannelKeyRotation = cfg.FlannelKeyRotation
+	if cfg.FlannelKeyRotation < 0 {
+		return nil, errors.New("invalid flag use; --flannel-key-rotation-interval must not be negative")
+	}
+	serverConfig.ControlConfig.EgressSelectorMode = strings.ToLower(cfg.EgressSelectorMode)
+	serverConfig.ControlConfig.AuditLogMode = strings.ToLower(cfg.AuditLogMode)
+	serverConfig.ControlConfig.PodSecurityProfile = strings.ToLower(cfg.PodSecurityProfile)
+	serverConfig.ControlConfig.PodSecurityExemptNamespaces = util.SplitStringSlice(cfg.PodSecurityExemptNamespaces)
+	serverConfig.ControlConfig.AuthOIDC = config.OIDC{
+		IssuerURL:      cfg.AuthOIDCIssuerURL,
+		ClientID:       cfg.AuthOIDCClientID,
+		CAFile:         cfg.AuthOIDCCAFile,
+		UsernameClaim:  cfg.AuthOIDCUsernameClaim,
+		UsernamePrefix: cfg.AuthOIDCUsernamePrefix,
+		GroupsClaim:    cfg.AuthOIDCGroupsClaim,
+		GroupsPrefix:   cfg.AuthOIDCGroupsPrefix,
+		RequiredClaims: util.SplitStringSlice(cfg.AuthOIDCRequiredClaims),
+	}
+	if cfg.AuthOIDCCAFile != "" {
+		if serverConfig.ControlConfig.AuthOIDC.CAFile, err = filepath.Abs(cfg.AuthOIDCCAFile); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.AuthWebhookConfig != "" {
+		if serverConfig.ControlConfig.AuthWebhookConfig, err = filepath.Abs(cfg.AuthWebhookConfig); err != nil {
+			return nil, err
+		}
+	}
+	serverConfig.ControlConfig.AuthWebhookCacheTTL = cfg.AuthWebhookCacheTTL
 	serverConfig.ControlConfig.ExtraCloudControllerArgs = cfg.ExtraCloudControllerArgs
 	serverConfig.ControlConfig.DisableCCM = cfg.DisableCCM
 	serverConfig.ControlConfig.DisableNPC = cfg.DisableNPC
@@ -147,17 +343,85 @@ func run(app *cli.Context, cfg *cmds.Server, leaderControllers server.CustomCont
 	serverConfig.ControlConfig.DisableAPIServer = cfg.DisableAPIServer
 	serverConfig.ControlConfig.DisableScheduler = cfg.DisableScheduler
 	serverConfig.ControlConfig.DisableControllerManager = cfg.DisableControllerManager
+
+	disabledComponents, err := config.ParseDisabledComponents(util.SplitStringSlice(cfg.DisableComponents))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid disable-components")
+	}
+	applyDisabledComponents(&serverConfig.ControlConfig, disabledComponents)
 	serverConfig.ControlConfig.ClusterInit = cfg.ClusterInit
+	serverConfig.ControlConfig.LeaderElectionPriority = cfg.LeaderElectionPriority
 	serverConfig.ControlConfig.EncryptSecrets = cfg.EncryptSecrets
+	serverConfig.ControlConfig.EncryptResources, err = secretsencrypt.ParseEncryptionResources(cfg.EncryptResources)
+	if err != nil {
+		return nil, err
+	}
+	serverConfig.ControlConfig.EncryptKMSProvider, err = secretsencrypt.LoadKMSConfig(cfg.KMSProviderConfig)
+	if err != nil {
+		return nil, err
+	}
+	if serverConfig.ControlConfig.EncryptKMSProvider != nil && !cfg.EncryptSecrets {
+		return nil, errors.New("kms-provider-config requires secrets-encryption")
+	}
+	serverConfig.ControlConfig.NodeWebhookURLs = cfg.NodeWebhookURLs
+	serverConfig.ControlConfig.NodeWebhookNotReady = cfg.NodeWebhookNotReady
+	serverConfig.ControlConfig.StaleNodeCleanupDays = cfg.StaleNodeCleanupDays
+	serverConfig.ControlConfig.ClockSkewThreshold = cfg.ClockSkewThreshold
+	serverConfig.ControlConfig.ClockSkewReject = cfg.ClockSkewReject
+	serverConfig.ControlConfig.NodeJoinApproval = strings.ToLower(cfg.NodeJoinApproval)
+	serverConfig.ControlConfig.ShutdownDrainTimeout = cfg.ShutdownDrainTimeout
+	serverConfig.ControlConfig.SupervisorRateLimit = cfg.SupervisorRateLimit
+	serverConfig.ControlConfig.SupervisorRateBurst = cfg.SupervisorRateBurst
+	serverConfig.ControlConfig.AuthFailureLimit = cfg.AuthFailureLimit
+	serverConfig.ControlConfig.AuthLockoutDuration = cfg.AuthLockoutDuration
+	serverConfig.ControlConfig.RegistryPolicy, err = registrypolicy.New(cfg.RegistryPolicyMode, cfg.RegistryPolicyAllow, cfg.RegistryPolicyDeny, cfg.RegistryPolicyExempt)
+	if err != nil {
+		return nil, err
+	}
+	serverConfig.ControlConfig.RegistryPolicyMode = serverConfig.ControlConfig.RegistryPolicy.Mode
+	// The registry policy webhook is called by each apiserver at the loopback address, so the supervisor must
+	// listen on all addresses.
+	if serverConfig.ControlConfig.RegistryPolicy.Enabled() {
+		supervisorBindAddress := cfg.BindAddress
+		if cfg.SupervisorBindAddress != "" {
+			supervisorBindAddress = cfg.SupervisorBindAddress
+		}
+		if ip := net.ParseIP(supervisorBindAddress); ip != nil && !ip.IsUnspecified() {
+			return nil, fmt.Errorf("invalid flag use; --registry-policy-mode %s requires the supervisor to listen on the loopback address, but it is bound to %s", serverConfig.ControlConfig.RegistryPolicyMode, supervisorBindAddress)
+		}
+	}
+	if cfg.NamespaceDefaultsConfig != "" {
+		serverConfig.ControlConfig.NamespaceDefaults, err = nsdefaults.Load(cfg.NamespaceDefaultsConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
 	serverConfig.ControlConfig.EtcdExposeMetrics = cfg.EtcdExposeMetrics
 	serverConfig.ControlConfig.EtcdDisableSnapshots = cfg.EtcdDisableSnapshots
+	if cfg.EtcdDefragCron != "" {
+		if _, err := cron.ParseStandard(cfg.EtcdDefragCron); err != nil {
+			return nil, errors.Wrap(err, "invalid etcd-defrag-schedule")
+		}
+		if cfg.EtcdDefragThreshold < 0 || cfg.EtcdDefragThreshold > 100 {
+			return nil, fmt.Errorf("invalid etcd-defrag-threshold-percent %d; must be between 0 and 100", cfg.EtcdDefragThreshold)
+		}
+		serverConfig.ControlConfig.EtcdDefragCron = cfg.EtcdDefragCron
+		serverConfig.ControlConfig.EtcdDefragThreshold = cfg.EtcdDefragThreshold
+	}
 
 	if !cfg.EtcdDisableSnapshots {
 		serverConfig.ControlConfig.EtcdSnapshotCompress = cfg.EtcdSnapshotCompress
+		serverConfig.ControlConfig.EtcdSnapshotSigningKey = cfg.EtcdSnapshotSigningKey
+		serverConfig.ControlConfig.EtcdSnapshotMinFree = cfg.EtcdSnapshotMinFree
+		serverConfig.ControlConfig.EtcdSnapshotPruneFirst = cfg.EtcdSnapshotPruneFirst
 		serverConfig.ControlConfig.EtcdSnapshotName = cfg.EtcdSnapshotName
 		serverConfig.ControlConfig.EtcdSnapshotCron = cfg.EtcdSnapshotCron
 		serverConfig.ControlConfig.EtcdSnapshotDir = cfg.EtcdSnapshotDir
 		serverConfig.ControlConfig.EtcdSnapshotRetention = cfg.EtcdSnapshotRetention
+		if _, err := etcd.ParseRetentionPolicy(cfg.EtcdSnapshotRetentionPolicy); err != nil {
+			return nil, errors.Wrap(err, "invalid etcd-snapshot-retention-policy")
+		}
+		serverConfig.ControlConfig.EtcdSnapshotRetentionPolicy = cfg.EtcdSnapshotRetentionPolicy
 		serverConfig.ControlConfig.EtcdS3 = cfg.EtcdS3
 		serverConfig.ControlConfig.EtcdS3Endpoint = cfg.EtcdS3Endpoint
 		serverConfig.ControlConfig.EtcdS3EndpointCA = cfg.EtcdS3EndpointCA
@@ -168,25 +432,88 @@ func run(app *cli.Context, cfg *cmds.Server, leaderControllers server.CustomCont
 		serverConfig.ControlConfig.EtcdS3Region = cfg.EtcdS3Region
 		serverConfig.ControlConfig.EtcdS3Folder = cfg.EtcdS3Folder
 		serverConfig.ControlConfig.EtcdS3Insecure = cfg.EtcdS3Insecure
+		serverConfig.ControlConfig.EtcdS3Incremental = cfg.EtcdS3Incremental
+		if cfg.EtcdS3Incremental && cfg.EtcdSnapshotCompress {
+			return nil, errors.New("etcd-s3-incremental cannot be used with etcd-snapshot-compress, as compressed snapshots cannot be deduplicated")
+		}
+		serverConfig.ControlConfig.EtcdS3WALArchive = cfg.EtcdS3WALArchive
+		serverConfig.ControlConfig.EtcdS3WALArchiveInterval = cfg.EtcdS3WALArchiveInterval
+		if cfg.EtcdS3WALArchive && (!cfg.EtcdS3 || cfg.EtcdS3WALArchiveInterval <= 0) {
+			return nil, errors.New("invalid flag use; --etcd-s3-wal-archive requires --etcd-s3 and a positive --etcd-s3-wal-archive-interval")
+		}
 		serverConfig.ControlConfig.EtcdS3Timeout = cfg.EtcdS3Timeout
 	} else {
 		logrus.Info("ETCD snapshots are disabled")
 	}
 
 	if cfg.ClusterResetRestorePath != "" && !cfg.ClusterReset {
-		return errors.New("invalid flag use; --cluster-reset required with --cluster-reset-restore-path")
+		return nil, errors.New("invalid flag use; --cluster-reset required with --cluster-reset-restore-path")
 	}
 
 	serverConfig.ControlConfig.ClusterReset = cfg.ClusterReset
+	if strings.HasPrefix(cfg.ClusterResetRestorePath, etcd.S3URLPrefix) {
+		if _, _, err := etcd.ParseS3URL(cfg.ClusterResetRestorePath); err != nil {
+			return nil, errors.Wrap(err, "invalid flag use; --cluster-reset-restore-path")
+		}
+	}
 	serverConfig.ControlConfig.ClusterResetRestorePath = cfg.ClusterResetRestorePath
+	if cfg.ClusterResetRestoreTime != "" {
+		if !cfg.ClusterReset || !cfg.EtcdS3 {
+			return nil, errors.New("invalid flag use; --cluster-reset and --etcd-s3 required with --cluster-reset-restore-timestamp")
+		}
+		restoreTime, err := parseTimestamp(cfg.ClusterResetRestoreTime)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid flag use; --cluster-reset-restore-timestamp")
+		}
+		serverConfig.ControlConfig.ClusterResetRestoreTime = restoreTime
+	}
+	if cfg.EtcdSnapshotVerify && cfg.ClusterResetRestorePath == "" {
+		return nil, errors.New("invalid flag use; --cluster-reset-restore-path required with --verify-snapshot")
+	}
+	serverConfig.ControlConfig.EtcdSnapshotVerify = cfg.EtcdSnapshotVerify
+	serverConfig.ControlConfig.EtcdSnapshotVerificationKey = cfg.EtcdSnapshotVerificationKey
 	serverConfig.ControlConfig.SystemDefaultRegistry = cfg.SystemDefaultRegistry
 
 	if serverConfig.ControlConfig.SupervisorPort == 0 {
 		serverConfig.ControlConfig.SupervisorPort = serverConfig.ControlConfig.HTTPSPort
 	}
 
+	// If the supervisor and apiserver are on the same port, the apiserver is only reachable through the
+	// supervisor, so they cannot be bound to different addresses or use different certificates.
+	if serverConfig.ControlConfig.SupervisorPort == serverConfig.ControlConfig.HTTPSPort {
+		if cfg.SupervisorBindAddress != "" && cfg.SupervisorBindAddress != cfg.BindAddress {
+			return nil, errors.New("invalid flag use; --supervisor-bind-address requires --supervisor-port to be set to a port other than --https-listen-port")
+		}
+		if cfg.SupervisorAdvertiseAddress != "" && cfg.SupervisorAdvertiseAddress != cfg.AdvertiseIP {
+			return nil, errors.New("invalid flag use; --supervisor-advertise-address requires --supervisor-port to be set to a port other than --https-listen-port")
+		}
+		if len(cfg.SupervisorTLSSan) > 0 {
+			return nil, errors.New("invalid flag use; --supervisor-tls-san requires --supervisor-port to be set to a port other than --https-listen-port")
+		}
+	} else if serverConfig.ControlConfig.APIServerPort == 0 {
+		// If the supervisor is on a separate port, the apiserver listens directly on the externally-facing port.
+		// Control-plane components reach the apiserver via the loopback address, so unless a specific apiserver
+		// bind address was requested, it must listen on all addresses.
+		serverConfig.ControlConfig.APIServerPort = serverConfig.ControlConfig.HTTPSPort
+		if serverConfig.ControlConfig.APIServerBindAddress == "" {
+			serverConfig.ControlConfig.APIServerBindAddress = "0.0.0.0"
+		}
+	}
+
+	// The embedded apiserver binds its own listener, so the apiserver port can only be passed by systemd socket
+	// activation when the apiserver is reached through the supervisor listener.
+	if serverConfig.ControlConfig.SupervisorPort != serverConfig.ControlConfig.HTTPSPort && util.HasActivatedListener(serverConfig.ControlConfig.HTTPSPort) {
+		return nil, fmt.Errorf("invalid socket activation; the apiserver port %d can only be passed by systemd socket activation when --supervisor-port is not set to a different port", serverConfig.ControlConfig.HTTPSPort)
+	}
+
+	switch serverConfig.ControlConfig.LeaderElectionPriority {
+	case config.LeaderElectionPreferred, config.LeaderElectionStandby, config.LeaderElectionNever:
+	default:
+		return nil, fmt.Errorf("invalid leader-election-priority %s", serverConfig.ControlConfig.LeaderElectionPriority)
+	}
+
 	if serverConfig.ControlConfig.DisableETCD && serverConfig.ControlConfig.JoinURL == "" {
-		return errors.New("invalid flag use; --server is required with --disable-etcd")
+		return nil, errors.New("invalid flag use; --server is required with --disable-etcd")
 	}
 
 	if serverConfig.ControlConfig.DisableAPIServer {
@@ -229,7 +556,7 @@ func run(app *cli.Context, cfg *cmds.Server, leaderControllers server.CustomCont
 	// service endpoint are added later when the certificates are created.
 	nodeName, nodeIPs, err := util.GetHostnameAndIPs(cmds.AgentConfig.NodeName, cmds.AgentConfig.NodeIP)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	serverConfig.ControlConfig.ServerNodeName = nodeName
 	serverConfig.ControlConfig.SANs = append(serverConfig.ControlConfig.SANs, "127.0.0.1", "::1", "localhost", nodeName)
@@ -237,6 +564,28 @@ func run(app *cli.Context, cfg *cmds.Server, leaderControllers server.CustomCont
 		serverConfig.ControlConfig.SANs = append(serverConfig.ControlConfig.SANs, ip.String())
 	}
 
+	// If supervisor SANs were provided, the supervisor cert uses those in place of the apiserver SANs,
+	// so that names and addresses on the apiserver network are not included. Otherwise, the supervisor
+	// cert uses the apiserver SANs, plus the supervisor bind and advertise addresses if the supervisor
+	// listens on a different interface.
+	var supervisorAddresses []string
+	for _, address := range []string{cfg.SupervisorBindAddress, cfg.SupervisorAdvertiseAddress} {
+		if address != "" && address != "0.0.0.0" && address != "::" {
+			supervisorAddresses = append(supervisorAddresses, address)
+		}
+	}
+	if len(cfg.SupervisorTLSSan) > 0 {
+		serverConfig.ControlConfig.SupervisorSANs = util.NormalizeSANs(util.SplitStringSlice(cfg.SupervisorTLSSan))
+		serverConfig.ControlConfig.SupervisorSANs = append(serverConfig.ControlConfig.SupervisorSANs, supervisorAddresses...)
+		serverConfig.ControlConfig.SupervisorSANs = append(serverConfig.ControlConfig.SupervisorSANs, "127.0.0.1", "::1", "localhost", nodeName)
+		for _, ip := range nodeIPs {
+			serverConfig.ControlConfig.SupervisorSANs = append(serverConfig.ControlConfig.SupervisorSANs, ip.String())
+		}
+	} else if len(supervisorAddresses) > 0 {
+		serverConfig.ControlConfig.SupervisorSANs = append([]string{}, serverConfig.ControlConfig.SANs...)
+		serverConfig.ControlConfig.SupervisorSANs = append(serverConfig.ControlConfig.SupervisorSANs, supervisorAddresses...)
+	}
+
 	// configure ClusterIPRanges
 	_, _, IPv6only, _ := util.GetFirstIP(nodeIPs)
 	if len(cmds.ServerConfig.ClusterCIDR) == 0 {
@@ -249,7 +598,7 @@ func run(app *cli.Context, cfg *cmds.Server, leaderControllers server.CustomCont
 	for _, cidr := range util.SplitStringSlice(cmds.ServerConfig.ClusterCIDR) {
 		_, parsed, err := net.ParseCIDR(cidr)
 		if err != nil {
-			return errors.Wrapf(err, "invalid cluster-cidr %s", cidr)
+			return nil, errors.Wrapf(err, "invalid cluster-cidr %s", cidr)
 		}
 		serverConfig.ControlConfig.ClusterIPRanges = append(serverConfig.ControlConfig.ClusterIPRanges, parsed)
 	}
@@ -258,115 +607,78 @@ func run(app *cli.Context, cfg *cmds.Server, leaderControllers server.CustomCont
 	// unless only IPv6 range given
 	clusterIPRange, err := util.GetFirstNet(serverConfig.ControlConfig.ClusterIPRanges)
 	if err != nil {
-		return errors.Wrap(err, "cannot configure IPv4/IPv6 cluster-cidr")
+		return nil, errors.Wrap(err, "cannot configure IPv4/IPv6 cluster-cidr")
 	}
 	serverConfig.ControlConfig.ClusterIPRange = clusterIPRange
 
-	// configure ServiceIPRanges
-	if len(cmds.ServerConfig.ServiceCIDR) == 0 {
-		serviceCIDR := "10.43.0.0/16"
-		if IPv6only {
-			serviceCIDR = "fd00:43::/112"
-		}
-		cmds.ServerConfig.ServiceCIDR.Set(serviceCIDR)
-	}
-	for _, cidr := range util.SplitStringSlice(cmds.ServerConfig.ServiceCIDR) {
-		_, parsed, err := net.ParseCIDR(cidr)
-		if err != nil {
-			return errors.Wrapf(err, "invalid service-cidr %s", cidr)
-		}
-		serverConfig.ControlConfig.ServiceIPRanges = append(serverConfig.ControlConfig.ServiceIPRanges, parsed)
+	if err := setServiceIPRanges(&serverConfig.ControlConfig, IPv6only); err != nil {
+		return nil, err
 	}
 
-	// set ServiceIPRange to the first IPv4 block, for legacy clients
-	// unless only IPv6 range given
-	serviceIPRange, err := util.GetFirstNet(serverConfig.ControlConfig.ServiceIPRanges)
-	if err != nil {
-		return errors.Wrap(err, "cannot configure IPv4/IPv6 service-cidr")
+	if err := setDualStackRanges(&serverConfig.ControlConfig, cfg, nodeIPs); err != nil {
+		return nil, err
 	}
-	serverConfig.ControlConfig.ServiceIPRange = serviceIPRange
 
 	serverConfig.ControlConfig.ServiceNodePortRange, err = utilnet.ParsePortRange(cfg.ServiceNodePortRange)
 	if err != nil {
-		return errors.Wrapf(err, "invalid port range %s", cfg.ServiceNodePortRange)
+		return nil, errors.Wrapf(err, "invalid port range %s", cfg.ServiceNodePortRange)
 	}
 
 	// the apiserver service does not yet support dual-stack operation
 	_, apiServerServiceIP, err := controlplane.ServiceIPRange(*serverConfig.ControlConfig.ServiceIPRanges[0])
 	if err != nil {
-		return err
+		return nil, err
 	}
 	serverConfig.ControlConfig.SANs = append(serverConfig.ControlConfig.SANs, apiServerServiceIP.String())
 
-	// If cluster-dns CLI arg is not set, we set ClusterDNS address to be the first IPv4 ServiceCIDR network + 10,
-	// i.e. when you set service-cidr to 192.168.0.0/16 and don't provide cluster-dns, it will be set to 192.168.0.10
-	// If there are no IPv4 ServiceCIDRs, an IPv6 ServiceCIDRs will be used.
-	// If neither of IPv4 or IPv6 are found an error is raised.
-	if len(cmds.ServerConfig.ClusterDNS) == 0 {
-		clusterDNS, err := utilsnet.GetIndexedIP(serverConfig.ControlConfig.ServiceIPRange, 10)
-		if err != nil {
-			return errors.Wrap(err, "cannot configure default cluster-dns address")
-		}
-		serverConfig.ControlConfig.ClusterDNS = clusterDNS
-		serverConfig.ControlConfig.ClusterDNSs = []net.IP{serverConfig.ControlConfig.ClusterDNS}
-	} else {
-		for _, ip := range util.SplitStringSlice(cmds.ServerConfig.ClusterDNS) {
-			parsed := net.ParseIP(ip)
-			if parsed == nil {
-				return fmt.Errorf("invalid cluster-dns address %s", ip)
-			}
-			serverConfig.ControlConfig.ClusterDNSs = append(serverConfig.ControlConfig.ClusterDNSs, parsed)
-		}
-		// Set ClusterDNS to the first IPv4 address, for legacy clients
-		// unless only IPv6 range given
-		clusterDNS, _, _, err := util.GetFirstIP(serverConfig.ControlConfig.ClusterDNSs)
-		if err != nil {
-			return errors.Wrap(err, "cannot configure IPv4/IPv6 cluster-dns address")
-		}
-		serverConfig.ControlConfig.ClusterDNS = clusterDNS
+	if err := setClusterDNS(&serverConfig.ControlConfig); err != nil {
+		return nil, err
 	}
 
-	if err := validateNetworkConfiguration(serverConfig); err != nil {
-		return err
+	if err := validateNetworkConfiguration(*serverConfig); err != nil {
+		return nil, err
 	}
 
-	if cfg.DefaultLocalStoragePath == "" {
-		dataDir, err := datadir.LocalHome(cfg.DataDir, false)
-		if err != nil {
-			return err
-		}
-		serverConfig.ControlConfig.DefaultLocalStoragePath = filepath.Join(dataDir, "/storage")
-	} else {
-		serverConfig.ControlConfig.DefaultLocalStoragePath = cfg.DefaultLocalStoragePath
+	serverConfig.ControlConfig.DefaultLocalStoragePath, err = defaultLocalStoragePath(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	serverConfig.ControlConfig.Skips = map[string]bool{}
-	serverConfig.ControlConfig.Disables = map[string]bool{}
-	for _, disable := range util.SplitStringSlice(app.StringSlice("disable")) {
-		disable = strings.TrimSpace(disable)
-		serverConfig.ControlConfig.Skips[disable] = true
-		serverConfig.ControlConfig.Disables[disable] = true
+	disables := app.StringSlice("disable")
+	if disabledComponents.Components[config.ComponentServiceLB] {
+		disables = append(disables, "servicelb")
 	}
-	if serverConfig.ControlConfig.Skips["servicelb"] {
-		serverConfig.ControlConfig.DisableServiceLB = true
+	setSkipsAndDisables(&serverConfig.ControlConfig, disables, app.StringSlice("enable"))
+
+	if err := setCNI(&serverConfig.ControlConfig, app.IsSet("flannel-backend")); err != nil {
+		return nil, err
 	}
 
-	if serverConfig.ControlConfig.DisableCCM && serverConfig.ControlConfig.DisableServiceLB {
-		serverConfig.ControlConfig.Skips["ccm"] = true
-		serverConfig.ControlConfig.Disables["ccm"] = true
+	setNodeLocalDNS(&serverConfig.ControlConfig)
+
+	serverConfig.ControlConfig.EnableGatewayAPI = cfg.EnableGatewayAPI
+	if cfg.EnableGatewayAPI && serverConfig.ControlConfig.Skips["traefik"] {
+		logrus.Warn("Gateway API is enabled, but the packaged Traefik is disabled; only the Gateway API CRDs will be installed")
+	}
+
+	serverConfig.ControlConfig.NvidiaDevicePluginConfig, err = server.NvidiaDevicePluginConfig(cfg.NvidiaMIGStrategy, cfg.NvidiaMIGProfiles, cfg.NvidiaTimeSlicing)
+	if err != nil {
+		return nil, err
+	}
+	serverConfig.ControlConfig.NvidiaMIGPartedConfig, err = server.NvidiaMIGPartedConfig(cfg.NvidiaMIGProfiles)
+	if err != nil {
+		return nil, err
+	}
+	if serverConfig.ControlConfig.Skips["nvidia-device-plugin"] && (app.IsSet("nvidia-mig-strategy") || app.IsSet("nvidia-mig-profile") || app.IsSet("nvidia-time-slicing")) {
+		logrus.Warn("NVIDIA GPU sharing is configured, but the packaged NVIDIA device plugin is not enabled; use --enable=nvidia-device-plugin to deploy it")
 	}
 
 	tlsMinVersionArg := getArgValueFromList("tls-min-version", serverConfig.ControlConfig.ExtraAPIArgs)
 	serverConfig.ControlConfig.TLSMinVersion, err = kubeapiserverflag.TLSVersion(tlsMinVersionArg)
 	if err != nil {
-		return errors.Wrap(err, "invalid tls-min-version")
+		return nil, errors.Wrap(err, "invalid tls-min-version")
 	}
 
-	serverConfig.StartupHooks = append(serverConfig.StartupHooks, cfg.StartupHooks...)
-
-	serverConfig.LeaderControllers = append(serverConfig.LeaderControllers, leaderControllers...)
-	serverConfig.Controllers = append(serverConfig.Controllers, controllers...)
-
 	// TLS config based on mozilla ssl-config generator
 	// https://ssl-config.mozilla.org/#server=golang&version=1.13.6&config=intermediate&guideline=5.4
 	// Need to disable the TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256 Cipher for TLS1.2
@@ -388,122 +700,240 @@ func run(app *cli.Context, cfg *cmds.Server, leaderControllers server.CustomCont
 	}
 	serverConfig.ControlConfig.TLSCipherSuites, err = kubeapiserverflag.TLSCipherSuites(tlsCipherSuites)
 	if err != nil {
-		return errors.Wrap(err, "invalid tls-cipher-suites")
+		return nil, errors.Wrap(err, "invalid tls-cipher-suites")
 	}
 
-	// If performing a cluster reset, make sure control-plane components are
-	// disabled so we only perform a reset or restore and bail out.
-	if cfg.ClusterReset {
-		serverConfig.ControlConfig.ClusterInit = true
-		serverConfig.ControlConfig.DisableAPIServer = true
-		serverConfig.ControlConfig.DisableControllerManager = true
-		serverConfig.ControlConfig.DisableScheduler = true
-		serverConfig.ControlConfig.DisableCCM = true
+	return serverConfig, nil
+}
 
-		// If the supervisor and apiserver are on the same port, everything is running embedded
-		// and we don't need the kubelet or containerd up to perform a cluster reset.
-		if serverConfig.ControlConfig.SupervisorPort == serverConfig.ControlConfig.HTTPSPort {
-			cfg.DisableAgent = true
+// setServiceIPRanges configures the service CIDRs, using the default range for the node's address family if none are set.
+func setServiceIPRanges(controlConfig *config.Control, IPv6only bool) error {
+	if len(cmds.ServerConfig.ServiceCIDR) == 0 {
+		serviceCIDR := "10.43.0.0/16"
+		if IPv6only {
+			serviceCIDR = "fd00:43::/112"
 		}
-
-		dataDir, err := datadir.LocalHome(cfg.DataDir, false)
+		cmds.ServerConfig.ServiceCIDR.Set(serviceCIDR)
+	}
+	for _, cidr := range util.SplitStringSlice(cmds.ServerConfig.ServiceCIDR) {
+		_, parsed, err := net.ParseCIDR(cidr)
 		if err != nil {
-			return err
+			return errors.Wrapf(err, "invalid service-cidr %s", cidr)
 		}
-		// delete local loadbalancers state for apiserver and supervisor servers
-		loadbalancer.ResetLoadBalancer(filepath.Join(dataDir, "agent"), loadbalancer.SupervisorServiceName)
-		loadbalancer.ResetLoadBalancer(filepath.Join(dataDir, "agent"), loadbalancer.APIServerServiceName)
+		controlConfig.ServiceIPRanges = append(controlConfig.ServiceIPRanges, parsed)
+	}
 
-		if cfg.ClusterResetRestorePath != "" {
-			// at this point we're doing a restore. Check to see if we've
-			// passed in a token and if not, check if the token file exists.
-			// If it doesn't, return an error indicating the token is necessary.
-			if cfg.Token == "" {
-				tokenFile := filepath.Join(dataDir, "server", "token")
-				if _, err := os.Stat(tokenFile); err != nil {
-					if os.IsNotExist(err) {
-						return errors.New(tokenFile + " does not exist, please pass --token to complete the restoration")
-					}
-				}
+	// set ServiceIPRange to the first IPv4 block, for legacy clients
+	// unless only IPv6 range given
+	serviceIPRange, err := util.GetFirstNet(controlConfig.ServiceIPRanges)
+	if err != nil {
+		return errors.Wrap(err, "cannot configure IPv4/IPv6 service-cidr")
+	}
+	controlConfig.ServiceIPRange = serviceIPRange
+	return nil
+}
+
+// setClusterDNS configures the cluster DNS addresses. If cluster-dns CLI arg is not set, we set ClusterDNS address to be
+// the first IPv4 ServiceCIDR network + 10, i.e. when you set service-cidr to 192.168.0.0/16 and don't provide cluster-dns,
+// it will be set to 192.168.0.10. If there are no IPv4 ServiceCIDRs, an IPv6 ServiceCIDRs will be used.
+// If neither of IPv4 or IPv6 are found an error is raised.
+func setClusterDNS(controlConfig *config.Control) error {
+	if len(cmds.ServerConfig.ClusterDNS) == 0 {
+		clusterDNS, err := utilsnet.GetIndexedIP(controlConfig.ServiceIPRange, 10)
+		if err != nil {
+			return errors.Wrap(err, "cannot configure default cluster-dns address")
+		}
+		controlConfig.ClusterDNS = clusterDNS
+		controlConfig.ClusterDNSs = []net.IP{controlConfig.ClusterDNS}
+	} else {
+		for _, ip := range util.SplitStringSlice(cmds.ServerConfig.ClusterDNS) {
+			parsed := net.ParseIP(ip)
+			if parsed == nil {
+				return fmt.Errorf("invalid cluster-dns address %s", ip)
 			}
+			controlConfig.ClusterDNSs = append(controlConfig.ClusterDNSs, parsed)
 		}
+		// Set ClusterDNS to the first IPv4 address, for legacy clients
+		// unless only IPv6 range given
+		clusterDNS, _, _, err := util.GetFirstIP(controlConfig.ClusterDNSs)
+		if err != nil {
+			return errors.Wrap(err, "cannot configure IPv4/IPv6 cluster-dns address")
+		}
+		controlConfig.ClusterDNS = clusterDNS
 	}
+	return nil
+}
 
-	logrus.Info("Starting " + version.Program + " " + app.App.Version)
-
-	notifySocket := os.Getenv("NOTIFY_SOCKET")
-	os.Unsetenv("NOTIFY_SOCKET")
-
-	ctx := signals.SetupSignalContext()
+// defaultLocalStoragePath returns the path used by the local-path provisioner, defaulting to a path within the data dir.
+func defaultLocalStoragePath(cfg *cmds.Server) (string, error) {
+	if cfg.DefaultLocalStoragePath != "" {
+		return cfg.DefaultLocalStoragePath, nil
+	}
+	dataDir, err := datadir.LocalHome(cfg.DataDir, false)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "/storage"), nil
+}
 
-	if err := server.StartServer(ctx, &serverConfig, cfg); err != nil {
-		return err
+// parseTimestamp parses a timestamp given as either RFC3339 or Unix seconds.
+func parseTimestamp(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
 	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q; must be RFC3339 or Unix seconds", value)
+	}
+	return t, nil
+}
 
-	go func() {
-		if !serverConfig.ControlConfig.DisableAPIServer {
-			<-serverConfig.ControlConfig.Runtime.APIServerReady
-			logrus.Info("Kube API server is now running")
-			serverConfig.ControlConfig.Runtime.StartupHooksWg.Wait()
+// parseKineLimits parses the CPU and memory limits of the standalone kine process as resource quantities.
+func parseKineLimits(cpu, memory string) (kine.Limits, error) {
+	limits := kine.Limits{}
+	if cpu != "" {
+		q, err := resource.ParseQuantity(cpu)
+		if err != nil || q.Sign() <= 0 {
+			return limits, fmt.Errorf("invalid kine-cpu-limit %q", cpu)
 		}
-		if !serverConfig.ControlConfig.DisableETCD {
-			<-serverConfig.ControlConfig.Runtime.ETCDReady
-			logrus.Info("ETCD server is now running")
+		limits.MilliCPU = q.MilliValue()
+	}
+	if memory != "" {
+		q, err := resource.ParseQuantity(memory)
+		if err != nil || q.Sign() <= 0 {
+			return limits, fmt.Errorf("invalid kine-memory-limit %q", memory)
 		}
+		limits.MemoryBytes = q.Value()
+	}
+	return limits, nil
+}
 
-		logrus.Info(version.Program + " is up and running")
-		os.Setenv("NOTIFY_SOCKET", notifySocket)
-		systemd.SdNotify(true, "READY=1\n")
-	}()
-
-	url := fmt.Sprintf("https://%s:%d", serverConfig.ControlConfig.BindAddressOrLoopback(false, true), serverConfig.ControlConfig.SupervisorPort)
-	token, err := clientaccess.FormatToken(serverConfig.ControlConfig.Runtime.AgentToken, serverConfig.ControlConfig.Runtime.ServerCA)
-	if err != nil {
-		return err
+// setNodeLocalDNS configures whether the node-local DNS cache is deployed. It forwards queries to CoreDNS, and
+// only listens on an IPv4 link-local address, so it is not deployed if CoreDNS is disabled or the cluster DNS
+// service is IPv6-only.
+func setNodeLocalDNS(controlConfig *config.Control) {
+	packaged := false
+	for _, item := range strings.Split(cmds.DisableItems, ",") {
+		if strings.TrimSpace(item) == "nodelocaldns" {
+			packaged = true
+		}
 	}
+	if !packaged || controlConfig.Skips["nodelocaldns"] {
+		return
+	}
+	if controlConfig.Skips["coredns"] || utilsnet.IsIPv6(controlConfig.ClusterDNS) {
+		logrus.Info("Not deploying node-local DNS cache, as CoreDNS is disabled or the cluster DNS address is IPv6")
+		controlConfig.Skips["nodelocaldns"] = true
+		controlConfig.Disables["nodelocaldns"] = true
+		return
+	}
+	controlConfig.NodeLocalDNS = true
+}
 
-	agentConfig := cmds.AgentConfig
-	agentConfig.AgentReady = agentReady
-	agentConfig.Debug = app.GlobalBool("debug")
-	agentConfig.DataDir = filepath.Dir(serverConfig.ControlConfig.DataDir)
-	agentConfig.ServerURL = url
-	agentConfig.Token = token
-	agentConfig.DisableLoadBalancer = !serverConfig.ControlConfig.DisableAPIServer
-	agentConfig.DisableServiceLB = serverConfig.ControlConfig.DisableServiceLB
-	agentConfig.ETCDAgent = serverConfig.ControlConfig.DisableAPIServer
-	agentConfig.ClusterReset = serverConfig.ControlConfig.ClusterReset
-	agentConfig.Rootless = cfg.Rootless
+// applyDisabledComponents disables the components and controllers selected by --disable-components, in
+// addition to those disabled by the individual disable flags.
+func applyDisabledComponents(controlConfig *config.Control, disabled *config.DisabledComponents) {
+	components := disabled.Components
+	controlConfig.DisableAPIServer = controlConfig.DisableAPIServer || components[config.ComponentAPIServer]
+	controlConfig.DisableETCD = controlConfig.DisableETCD || components[config.ComponentETCD]
+	controlConfig.DisableScheduler = controlConfig.DisableScheduler || components[config.ComponentScheduler]
+	controlConfig.DisableControllerManager = controlConfig.DisableControllerManager || components[config.ComponentControllerManager]
+	controlConfig.DisableCCM = controlConfig.DisableCCM || components[config.ComponentCloudController]
+	controlConfig.DisableHelmController = controlConfig.DisableHelmController || components[config.ComponentHelmController]
+	controlConfig.DisableNPC = controlConfig.DisableNPC || components[config.ComponentNetworkPolicy]
+	controlConfig.DisableKubeProxy = controlConfig.DisableKubeProxy || components[config.ComponentKubeProxy]
+	controlConfig.DisableControllers = disabled.Controllers[config.ComponentControllerManager]
+	controlConfig.DisableCloudControllers = disabled.Controllers[config.ComponentCloudController]
+}
 
-	if agentConfig.Rootless {
-		// let agent specify Rootless kubelet flags, but not unshare twice
-		agentConfig.RootlessAlreadyUnshared = true
+// setSkipsAndDisables configures the packaged components that are not deployed. Optional components
+// are skipped and disabled unless they have been enabled. The cloud controller is not deployed if both
+// the cloud controller and servicelb are disabled, as neither of its controllers would run.
+func setSkipsAndDisables(controlConfig *config.Control, disables, enables []string) {
+	controlConfig.Skips = map[string]bool{}
+	controlConfig.Disables = map[string]bool{}
+	for _, disable := range util.SplitStringSlice(disables) {
+		disable = strings.TrimSpace(disable)
+		controlConfig.Skips[disable] = true
+		controlConfig.Disables[disable] = true
+	}
+	enabled := map[string]bool{}
+	for _, enable := range util.SplitStringSlice(enables) {
+		enabled[strings.TrimSpace(enable)] = true
+	}
+	for _, optional := range util.SplitStringSlice([]string{cmds.EnableItems}) {
+		optional = strings.TrimSpace(optional)
+		if optional != "" && !enabled[optional] {
+			controlConfig.Skips[optional] = true
+			controlConfig.Disables[optional] = true
+		}
+	}
+	if controlConfig.Skips["servicelb"] {
+		controlConfig.DisableServiceLB = true
 	}
 
-	if serverConfig.ControlConfig.DisableAPIServer {
-		if cfg.ServerURL == "" {
-			// If this node is the initial member of the cluster and is not hosting an apiserver,
-			// always bootstrap the agent off local supervisor, and go through the process of reading
-			// apiserver endpoints from etcd and blocking further startup until one is available.
-			// This ensures that we don't end up in a chicken-and-egg situation on cluster restarts,
-			// where the loadbalancer is routing traffic to existing apiservers, but the apiservers
-			// are non-functional because they're waiting for us to start etcd.
-			loadbalancer.ResetLoadBalancer(filepath.Join(agentConfig.DataDir, "agent"), loadbalancer.SupervisorServiceName)
-		} else {
-			// If this is a secondary member of the cluster and is not hosting an apiserver,
-			// bootstrap the agent off the existing supervisor, instead of bootstrapping locally.
-			agentConfig.ServerURL = cfg.ServerURL
+	if controlConfig.DisableCCM && controlConfig.DisableServiceLB {
+		controlConfig.Skips["ccm"] = true
+		controlConfig.Disables["ccm"] = true
+	}
+}
+
+// setCNI configures flannel and the network policy controller for the selected CNI. Calico and Cilium are
+// deployed from packaged charts, and provide their own network policy enforcement; the charts for CNIs that
+// are not selected are skipped.
+func setCNI(controlConfig *config.Control, flannelBackendSet bool) error {
+	switch controlConfig.CNI {
+	case config.CNIFlannel:
+	case config.CNICalico, config.CNICilium, config.CNINone:
+		if flannelBackendSet && controlConfig.FlannelBackend != config.FlannelBackendNone {
+			return fmt.Errorf("flannel-backend %s cannot be used with cni %s", controlConfig.FlannelBackend, controlConfig.CNI)
 		}
-		// initialize the apiAddress Channel for receiving the api address from etcd
-		agentConfig.APIAddressCh = make(chan []string)
-		go getAPIAddressFromEtcd(ctx, serverConfig, agentConfig)
+		controlConfig.FlannelBackend = config.FlannelBackendNone
+		if controlConfig.CNI != config.CNINone {
+			controlConfig.DisableNPC = true
+		}
+	default:
+		return fmt.Errorf("invalid cni %s", controlConfig.CNI)
 	}
 
-	if cfg.DisableAgent {
-		agentConfig.ContainerRuntimeEndpoint = "/dev/null"
-		return agent.RunStandalone(ctx, agentConfig)
+	if controlConfig.CNI == config.CNICilium && controlConfig.ClusterIPRange.IP.To4() == nil {
+		return errors.New("cni cilium requires an IPv4 cluster-cidr")
+	}
+	if len(controlConfig.ClusterIPRanges) > 1 && (controlConfig.CNI == config.CNICalico || controlConfig.CNI == config.CNICilium) {
+		logrus.Warnf("Only the primary cluster-cidr %s is configured in the packaged %s chart; use a HelmChartConfig to configure additional pod CIDRs", controlConfig.ClusterIPRange, controlConfig.CNI)
 	}
 
-	return agent.Run(ctx, agentConfig)
+	for _, packaged := range []string{config.CNICalico, config.CNICilium} {
+		if controlConfig.CNI != packaged {
+			controlConfig.Skips[packaged] = true
+		}
+	}
+	return nil
+}
+
+// preflightPorts returns the ports that the server will listen on, so that they can be checked before startup.
+// Ports that are passed to the server by systemd socket activation are not checked.
+func preflightPorts(controlConfig *config.Control, cfg *cmds.Server) []int {
+	ports := []int{}
+	// the supervisor port is already bound if it is held open by systemd socket activation
+	if !util.HasActivatedListener(controlConfig.SupervisorPort) {
+		ports = append(ports, controlConfig.SupervisorPort)
+	}
+	if controlConfig.HTTPSPort != controlConfig.SupervisorPort {
+		ports = append(ports, controlConfig.HTTPSPort)
+	}
+	if !controlConfig.DisableAPIServer {
+		ports = append(ports, controlConfig.APIServerPort)
+	}
+	if !controlConfig.DisableETCD && controlConfig.Datastore.Endpoint == "" {
+		if _, err := os.Stat(filepath.Join(controlConfig.DataDir, "db", "etcd")); err == nil || controlConfig.ClusterInit || cfg.ServerURL != "" {
+			ports = append(ports, 2379, 2380)
+		}
+	}
+	if !cfg.DisableAgent {
+		ports = append(ports, 10250)
+	}
+	return ports
 }
 
 // validateNetworkConfig ensures that the network configuration values make sense.
@@ -530,6 +960,70 @@ func validateNetworkConfiguration(serverConfig server.Config) error {
 		return fmt.Errorf("invalid egress-selector-mode %s", serverConfig.ControlConfig.EgressSelectorMode)
 	}
 
+	switch serverConfig.ControlConfig.AuditLogMode {
+	case config.AuditLogModeNone, config.AuditLogModeMetadata, config.AuditLogModeRequest, config.AuditLogModeFull:
+	default:
+		return fmt.Errorf("invalid audit-log-mode %s", serverConfig.ControlConfig.AuditLogMode)
+	}
+
+	switch serverConfig.ControlConfig.NodeJoinApproval {
+	case config.NodeJoinApprovalAuto, config.NodeJoinApprovalManual:
+	default:
+		return fmt.Errorf("invalid node-join-approval %s", serverConfig.ControlConfig.NodeJoinApproval)
+	}
+
+	switch serverConfig.ControlConfig.PodSecurityProfile {
+	case "", config.PodSecurityProfilePrivileged, config.PodSecurityProfileBaseline, config.PodSecurityProfileRestricted:
+	default:
+		return fmt.Errorf("invalid pod-security-profile %s", serverConfig.ControlConfig.PodSecurityProfile)
+	}
+	if serverConfig.ControlConfig.PodSecurityProfile == "" && len(serverConfig.ControlConfig.PodSecurityExemptNamespaces) > 0 {
+		return errors.New("invalid flag use; --pod-security-exempt-namespace requires --pod-security-profile")
+	}
+	if serverConfig.ControlConfig.PodSecurityProfile != "" && getArgValueFromList("admission-control-config-file", serverConfig.ControlConfig.ExtraAPIArgs) != "" {
+		return errors.New("invalid flag use; --pod-security-profile cannot be used with kube-apiserver-arg admission-control-config-file")
+	}
+
+	return validateAuthConfiguration(&serverConfig.ControlConfig)
+}
+
+// validateAuthConfiguration validates the OIDC and authentication webhook flags. The webhook config and OIDC CA
+// file are validated when they are written to the data dir.
+func validateAuthConfiguration(controlConfig *config.Control) error {
+	oidc := controlConfig.AuthOIDC
+	if oidc.IssuerURL == "" {
+		if oidc.ClientID != "" || oidc.CAFile != "" || oidc.GroupsClaim != "" || oidc.GroupsPrefix != "" || oidc.UsernamePrefix != "" || len(oidc.RequiredClaims) > 0 {
+			return errors.New("invalid flag use; --auth-oidc flags require --auth-oidc-issuer-url")
+		}
+	} else {
+		u, err := url.Parse(oidc.IssuerURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("invalid auth-oidc-issuer-url %s; must be an HTTPS URL", oidc.IssuerURL)
+		}
+		if oidc.ClientID == "" {
+			return errors.New("invalid flag use; --auth-oidc-issuer-url requires --auth-oidc-client-id")
+		}
+		if oidc.UsernameClaim == "" {
+			return errors.New("invalid flag use; --auth-oidc-username-claim must not be empty")
+		}
+		for _, claim := range oidc.RequiredClaims {
+			if k, _, ok := strings.Cut(claim, "="); !ok || k == "" {
+				return fmt.Errorf("invalid auth-oidc-required-claim %s; must be 'claim=value'", claim)
+			}
+		}
+		if getArgValueFromList("oidc-issuer-url", controlConfig.ExtraAPIArgs) != "" {
+			return errors.New("invalid flag use; --auth-oidc-issuer-url cannot be used with kube-apiserver-arg oidc-issuer-url")
+		}
+	}
+	if controlConfig.AuthWebhookConfig != "" {
+		if controlConfig.AuthWebhookCacheTTL < 0 {
+			return errors.New("invalid flag use; --auth-webhook-cache-ttl must not be negative")
+		}
+		if getArgValueFromList("authentication-token-webhook-config-file", controlConfig.ExtraAPIArgs) != "" {
+			return errors.New("invalid flag use; --auth-webhook-config cannot be used with kube-apiserver-arg authentication-token-webhook-config-file")
+		}
+	}
+
 	return nil
 }
 
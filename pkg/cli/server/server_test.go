@@ -0,0 +1,169 @@
+package server
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+)
+
+func Test_UnitSetCNI(t *testing.T) {
+	_, ipv4CIDR, _ := net.ParseCIDR("10.42.0.0/16")
+	_, ipv6CIDR, _ := net.ParseCIDR("2001:cafe:42::/56")
+	tests := []struct {
+		name              string
+		cni               string
+		flannelBackend    string
+		flannelBackendSet bool
+		clusterIPRanges   []*net.IPNet
+		wantBackend       string
+		wantDisableNPC    bool
+		wantSkips         map[string]bool
+		wantErr           bool
+	}{
+		{
+			name:            "flannel",
+			cni:             config.CNIFlannel,
+			flannelBackend:  "vxlan",
+			clusterIPRanges: []*net.IPNet{ipv4CIDR},
+			wantBackend:     "vxlan",
+			wantSkips:       map[string]bool{config.CNICalico: true, config.CNICilium: true},
+		},
+		{
+			name:              "flannel with backend",
+			cni:               config.CNIFlannel,
+			flannelBackend:    "wireguard-native",
+			flannelBackendSet: true,
+			clusterIPRanges:   []*net.IPNet{ipv4CIDR},
+			wantBackend:       "wireguard-native",
+			wantSkips:         map[string]bool{config.CNICalico: true, config.CNICilium: true},
+		},
+		{
+			name:            "calico",
+			cni:             config.CNICalico,
+			flannelBackend:  "vxlan",
+			clusterIPRanges: []*net.IPNet{ipv4CIDR},
+			wantBackend:     config.FlannelBackendNone,
+			wantDisableNPC:  true,
+			wantSkips:       map[string]bool{config.CNICilium: true},
+		},
+		{
+			name:            "calico dual-stack",
+			cni:             config.CNICalico,
+			flannelBackend:  "vxlan",
+			clusterIPRanges: []*net.IPNet{ipv4CIDR, ipv6CIDR},
+			wantBackend:     config.FlannelBackendNone,
+			wantDisableNPC:  true,
+			wantSkips:       map[string]bool{config.CNICilium: true},
+		},
+		{
+			name:            "cilium",
+			cni:             config.CNICilium,
+			flannelBackend:  "vxlan",
+			clusterIPRanges: []*net.IPNet{ipv4CIDR},
+			wantBackend:     config.FlannelBackendNone,
+			wantDisableNPC:  true,
+			wantSkips:       map[string]bool{config.CNICalico: true},
+		},
+		{
+			name:            "cilium ipv6-only",
+			cni:             config.CNICilium,
+			flannelBackend:  "vxlan",
+			clusterIPRanges: []*net.IPNet{ipv6CIDR},
+			wantErr:         true,
+		},
+		{
+			name:            "none",
+			cni:             config.CNINone,
+			flannelBackend:  "vxlan",
+			clusterIPRanges: []*net.IPNet{ipv4CIDR},
+			wantBackend:     config.FlannelBackendNone,
+			wantSkips:       map[string]bool{config.CNICalico: true, config.CNICilium: true},
+		},
+		{
+			name:              "none with flannel-backend none",
+			cni:               config.CNINone,
+			flannelBackend:    config.FlannelBackendNone,
+			flannelBackendSet: true,
+			clusterIPRanges:   []*net.IPNet{ipv4CIDR},
+			wantBackend:       config.FlannelBackendNone,
+			wantSkips:         map[string]bool{config.CNICalico: true, config.CNICilium: true},
+		},
+		{
+			name:              "calico with flannel backend",
+			cni:               config.CNICalico,
+			flannelBackend:    "vxlan",
+			flannelBackendSet: true,
+			clusterIPRanges:   []*net.IPNet{ipv4CIDR},
+			wantErr:           true,
+		},
+		{
+			name:            "invalid",
+			cni:             "weave",
+			flannelBackend:  "vxlan",
+			clusterIPRanges: []*net.IPNet{ipv4CIDR},
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controlConfig := &config.Control{Skips: map[string]bool{}}
+			controlConfig.CNI = tt.cni
+			controlConfig.FlannelBackend = tt.flannelBackend
+			controlConfig.ClusterIPRanges = tt.clusterIPRanges
+			controlConfig.ClusterIPRange = tt.clusterIPRanges[0]
+			err := setCNI(controlConfig, tt.flannelBackendSet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setCNI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if controlConfig.FlannelBackend != tt.wantBackend {
+				t.Errorf("setCNI() FlannelBackend = %s, want %s", controlConfig.FlannelBackend, tt.wantBackend)
+			}
+			if controlConfig.DisableNPC != tt.wantDisableNPC {
+				t.Errorf("setCNI() DisableNPC = %v, want %v", controlConfig.DisableNPC, tt.wantDisableNPC)
+			}
+			if !reflect.DeepEqual(controlConfig.Skips, tt.wantSkips) {
+				t.Errorf("setCNI() Skips = %v, want %v", controlConfig.Skips, tt.wantSkips)
+			}
+		})
+	}
+}
+
+func Test_UnitNewServerConfigRegistryPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{
+			name: "enforce",
+			args: []string{"--registry-policy-mode=enforce"},
+		},
+		{
+			name: "enforce with unspecified bind address",
+			args: []string{"--registry-policy-mode=enforce", "--bind-address=0.0.0.0"},
+		},
+		{
+			name:    "enforce with bind address",
+			args:    []string{"--registry-policy-mode=enforce", "--bind-address=10.0.0.1"},
+			wantErr: true,
+		},
+		{
+			name: "disabled with bind address",
+			args: []string{"--bind-address=10.0.0.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newServerConfig(newTestServerContext(t, t.TempDir(), tt.args), &cmds.ServerConfig)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newServerConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
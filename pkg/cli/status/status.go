@@ -0,0 +1,100 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/util"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// leaderElection describes a lease used to elect the leader for a set of embedded controllers.
+type leaderElection struct {
+	Controllers string     `json:"controllers" yaml:"controllers"`
+	Lease       string     `json:"lease" yaml:"lease"`
+	Holder      string     `json:"holder,omitempty" yaml:"holder,omitempty"`
+	RenewTime   *time.Time `json:"renewTime,omitempty" yaml:"renewTime,omitempty"`
+	Transitions int32      `json:"transitions" yaml:"transitions"`
+}
+
+// leases are the leases used by leader-elected embedded controllers, in the kube-system namespace.
+var leases = []leaderElection{
+	{Controllers: "helm, node, secrets-encrypt", Lease: version.Program},
+	{Controllers: "etcd", Lease: version.Program + "-etcd"},
+	{Controllers: "cloud-controller-manager", Lease: version.Program + "-cloud-controller-manager"},
+	{Controllers: "kube-controller-manager", Lease: "kube-controller-manager"},
+	{Controllers: "kube-scheduler", Lease: "kube-scheduler"},
+}
+
+func Run(app *cli.Context) error {
+	if err := cmds.InitLogging(); err != nil {
+		return err
+	}
+	return status(app, &cmds.StatusConfig)
+}
+
+func status(app *cli.Context, cfg *cmds.Status) error {
+	cfg.Kubeconfig = util.GetKubeConfigPath(cfg.Kubeconfig)
+	client, err := util.GetClientSet(cfg.Kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	elections := make([]leaderElection, 0, len(leases))
+	for _, election := range leases {
+		lease, err := client.CoordinationV1().Leases(metav1.NamespaceSystem).Get(context.TODO(), election.Lease, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			elections = append(elections, election)
+			continue
+		} else if err != nil {
+			return errors.Wrapf(err, "failed to get lease %s", election.Lease)
+		}
+		if lease.Spec.HolderIdentity != nil {
+			election.Holder = *lease.Spec.HolderIdentity
+		}
+		if lease.Spec.RenewTime != nil {
+			election.RenewTime = &lease.Spec.RenewTime.Time
+		}
+		if lease.Spec.LeaseTransitions != nil {
+			election.Transitions = *lease.Spec.LeaseTransitions
+		}
+		elections = append(elections, election)
+	}
+
+	switch cfg.Output {
+	case "json":
+		return json.NewEncoder(os.Stdout).Encode(elections)
+	case "yaml":
+		return yaml.NewEncoder(os.Stdout).Encode(elections)
+	default:
+		format := "%s\t%s\t%s\t%s\t%d\n"
+		w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "CONTROLLERS", "LEASE", "HOLDER", "RENEWED", "TRANSITIONS")
+		for _, election := range elections {
+			holder := "<none>"
+			if election.Holder != "" {
+				holder = election.Holder
+			}
+			renewed := "<never>"
+			if election.RenewTime != nil {
+				renewed = duration.ShortHumanDuration(time.Since(*election.RenewTime)) + " ago"
+			}
+			fmt.Fprintf(w, format, election.Controllers, metav1.NamespaceSystem+"/"+election.Lease, holder, renewed, election.Transitions)
+		}
+	}
+
+	return nil
+}
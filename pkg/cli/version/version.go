@@ -0,0 +1,74 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/inventory"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// versionInfo is the version of the binary, and optionally its components.
+type versionInfo struct {
+	Version    string                `json:"version" yaml:"version"`
+	GitCommit  string                `json:"gitCommit" yaml:"gitCommit"`
+	GoVersion  string                `json:"goVersion" yaml:"goVersion"`
+	Components []inventory.Component `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+func Run(app *cli.Context) error {
+	return printVersion(app, &cmds.VersionConfig)
+}
+
+func printVersion(app *cli.Context, cfg *cmds.Version) error {
+	info := versionInfo{
+		Version:   version.Version,
+		GitCommit: version.GitCommit,
+		GoVersion: runtime.Version(),
+	}
+	if cfg.Components || cfg.Output == "cyclonedx" {
+		info.Components = inventory.Components()
+	}
+
+	switch cfg.Output {
+	case "json":
+		return json.NewEncoder(os.Stdout).Encode(info)
+	case "yaml":
+		return yaml.NewEncoder(os.Stdout).Encode(info)
+	case "cyclonedx":
+		b, err := inventory.CycloneDX(info.Components)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(os.Stdout, string(b))
+		return err
+	case "", "text":
+		fmt.Printf("%s version %s (%s)\n", app.App.Name, info.Version, info.GitCommit)
+		fmt.Printf("go version %s\n", info.GoVersion)
+		if !cfg.Components {
+			return nil
+		}
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 10, 4, 3, ' ', 0)
+		defer w.Flush()
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", "NAME", "TYPE", "VERSION", "SOURCE", "LICENSES")
+		for _, component := range info.Components {
+			licenses := "<unknown>"
+			if len(component.Licenses) > 0 {
+				licenses = strings.Join(component.Licenses, ",")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", component.Name, component.Type, component.Version, component.Source, licenses)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid output format %s", cfg.Output)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/k3s-io/k3s/pkg/kubeadm"
+	"github.com/k3s-io/k3s/pkg/util"
 	"github.com/pkg/errors"
 	certutil "github.com/rancher/dynamiclistener/cert"
 	"github.com/sirupsen/logrus"
@@ -34,6 +35,7 @@ var (
 	insecureClient = &http.Client{
 		Timeout: defaultClientTimeout,
 		Transport: &http.Transport{
+			Proxy: util.ProxyFunc(util.ProxySupervisorPrefix, false),
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: true,
 			},
@@ -177,6 +179,19 @@ func ParseUsernamePassword(token string) (string, string, bool) {
 	return info.Username, info.Password, true
 }
 
+// ParseTokenSecret returns the secret portion of a token string: the bootstrap token for bootstrap tokens,
+// or the password for all other tokens.
+func ParseTokenSecret(token string) (string, bool) {
+	info, err := parseToken(token)
+	if err != nil {
+		return "", false
+	}
+	if info.BootstrapTokenString != nil {
+		return info.BootstrapTokenString.String(), true
+	}
+	return info.Password, true
+}
+
 // parseToken parses a token into an Info struct
 func parseToken(token string) (*Info, error) {
 	var info Info
@@ -254,6 +269,7 @@ func GetHTTPClient(cacerts []byte, certFile, keyFile string) *http.Client {
 	return &http.Client{
 		Timeout: defaultClientTimeout,
 		Transport: &http.Transport{
+			Proxy:             util.ProxyFunc(util.ProxySupervisorPrefix, false),
 			DisableKeepAlives: true,
 			TLSClientConfig:   tlsConfig,
 		},
@@ -287,7 +303,37 @@ func (i *Info) Put(path string, body []byte) error {
 	}
 	p.Scheme = u.Scheme
 	p.Host = u.Host
-	return put(p.String(), body, GetHTTPClient(i.CACerts, i.CertFile, i.KeyFile), i.Username, i.Password, i.Token())
+	return send(http.MethodPut, p.String(), body, GetHTTPClient(i.CACerts, i.CertFile, i.KeyFile), i.Username, i.Password, i.Token())
+}
+
+// Post makes a request to a subpath of info's BaseURL
+func (i *Info) Post(path string, body []byte) error {
+	u, err := url.Parse(i.BaseURL)
+	if err != nil {
+		return err
+	}
+	p, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	p.Scheme = u.Scheme
+	p.Host = u.Host
+	return send(http.MethodPost, p.String(), body, GetHTTPClient(i.CACerts, i.CertFile, i.KeyFile), i.Username, i.Password, i.Token())
+}
+
+// Delete makes a request to a subpath of info's BaseURL
+func (i *Info) Delete(path string) error {
+	u, err := url.Parse(i.BaseURL)
+	if err != nil {
+		return err
+	}
+	p, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	p.Scheme = u.Scheme
+	p.Host = u.Host
+	return send(http.MethodDelete, p.String(), nil, GetHTTPClient(i.CACerts, i.CertFile, i.KeyFile), i.Username, i.Password, i.Token())
 }
 
 // setServer sets the BaseURL and CACerts fields of the Info by connecting to the server
@@ -302,6 +348,11 @@ func (i *Info) setServer(server string) error {
 		return errors.New("only https:// URLs are supported, invalid scheme: " + server)
 	}
 
+	// url.Parse accepts unbracketed IPv6 literals, but treats the last segment as the port.
+	if strings.Contains(url.Hostname(), ":") && !strings.HasPrefix(url.Host, "[") {
+		return errors.New("IPv6 addresses must be enclosed in brackets, invalid server url: " + server)
+	}
+
 	for strings.HasSuffix(url.Path, "/") {
 		url.Path = url.Path[:len(url.Path)-1]
 	}
@@ -394,10 +445,10 @@ func get(u string, client *http.Client, username, password, token string) ([]byt
 	return io.ReadAll(resp.Body)
 }
 
-// put makes a request to a url using a provided client, username, and password
+// send makes a request to a url using the provided method, client, username, and password
 // only an error is returned
-func put(u string, body []byte, client *http.Client, username, password, token string) error {
-	req, err := http.NewRequest(http.MethodPut, u, bytes.NewBuffer(body))
+func send(method, u string, body []byte, client *http.Client, username, password, token string) error {
+	req, err := http.NewRequest(method, u, bytes.NewBuffer(body))
 	if err != nil {
 		return err
 	}
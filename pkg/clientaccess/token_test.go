@@ -127,6 +127,7 @@ func Test_UnitInvalidServers(t *testing.T) {
 	}{
 		{" https://localhost:6443", "token", "Invalid server url, failed to parse:  https://localhost:6443: parse \" https://localhost:6443\": first path segment in URL cannot contain colon"},
 		{"http://localhost:6443", "token", "only https:// URLs are supported, invalid scheme: http://localhost:6443"},
+		{"https://fd00::1:6443", "token", "IPv6 addresses must be enclosed in brackets, invalid server url: https://fd00::1:6443"},
 	}
 
 	for _, testCase := range testCases {
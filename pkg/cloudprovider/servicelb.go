@@ -313,9 +313,12 @@ func (k *k3s) patchStatus(svc *core.Service, previousStatus, newStatus *core.Loa
 }
 
 // podIPs returns a list of IPs for Nodes hosting ServiceLB Pods.
-// If at least one node has External IPs available, only external IPs are returned.
-// If no nodes have External IPs set, the Internal IPs of all nodes running pods are returned.
+// For each IP family, if at least one node has External IPs of that family available, only external IPs
+// of that family are returned. If no nodes have External IPs of that family set, the Internal IPs of that
+// family of all nodes running pods are returned. This ensures that dual-stack nodes that only have
+// External IPs of one family still publish addresses of both families.
 func (k *k3s) podIPs(pods []*core.Pod, svc *core.Service, readyNodes map[string]bool) ([]string, error) {
+	localTraffic := servicehelper.RequestsOnlyLocalTraffic(svc)
 	// Go doesn't have sets so we stuff things into a map of bools and then get lists of keys
 	// to determine the unique set of IPs in use by pods.
 	extIPs := map[string]bool{}
@@ -339,30 +342,10 @@ func (k *k3s) podIPs(pods []*core.Pod, svc *core.Service, readyNodes map[string]
 			return nil, err
 		}
 
-		for _, addr := range node.Status.Addresses {
-			if addr.Type == core.NodeExternalIP {
-				extIPs[addr.Address] = true
-			} else if addr.Type == core.NodeInternalIP {
-				intIPs[addr.Address] = true
-			}
-		}
-	}
-
-	keys := func(addrs map[string]bool) (ips []string) {
-		for k := range addrs {
-			ips = append(ips, k)
-		}
-		return ips
-	}
-
-	var ips []string
-	if len(extIPs) > 0 {
-		ips = keys(extIPs)
-	} else {
-		ips = keys(intIPs)
+		addNodeIPs(node, pod, localTraffic, extIPs, intIPs)
 	}
 
-	ips, err := filterByIPFamily(ips, svc)
+	ips, err := filterByIPFamily(preferredIPs(keys(extIPs), keys(intIPs)), svc)
 	if err != nil {
 		return nil, err
 	}
@@ -374,6 +357,55 @@ func (k *k3s) podIPs(pods []*core.Pod, svc *core.Service, readyNodes map[string]
 	return ips, nil
 }
 
+// keys returns the keys of a map, sorted for consistent ordering of ingress addresses.
+func keys(addrs map[string]bool) []string {
+	ips := make([]string, 0, len(addrs))
+	for k := range addrs {
+		ips = append(ips, k)
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// preferredIPs returns the external IPs of each IP family, or the internal IPs of that family if there
+// are no external IPs of that family.
+func preferredIPs(extIPs, intIPs []string) []string {
+	var ips []string
+	for _, isFamily := range []func(string) bool{utilsnet.IsIPv4String, utilsnet.IsIPv6String} {
+		var familyIPs []string
+		for _, ip := range extIPs {
+			if isFamily(ip) {
+				familyIPs = append(familyIPs, ip)
+			}
+		}
+		if len(familyIPs) == 0 {
+			for _, ip := range intIPs {
+				if isFamily(ip) {
+					familyIPs = append(familyIPs, ip)
+				}
+			}
+		}
+		ips = append(ips, familyIPs...)
+	}
+	return ips
+}
+
+// addNodeIPs adds the external and internal IPs of the node hosting a ServiceLB pod to the sets of IPs.
+// For services with ExternalTrafficPolicy set to Local, traffic is forwarded to the pod's host IP, which
+// only has a single family, so only node IPs of the same family as the host IP are added.
+func addNodeIPs(node *core.Node, pod *core.Pod, localTraffic bool, extIPs, intIPs map[string]bool) {
+	for _, addr := range node.Status.Addresses {
+		if localTraffic && utilsnet.IsIPv6String(addr.Address) != utilsnet.IsIPv6String(pod.Status.HostIP) {
+			continue
+		}
+		if addr.Type == core.NodeExternalIP {
+			extIPs[addr.Address] = true
+		} else if addr.Type == core.NodeInternalIP {
+			intIPs[addr.Address] = true
+		}
+	}
+}
+
 // filterByIPFamily filters node IPs based on dual-stack parameters of the service
 func filterByIPFamily(ips []string, svc *core.Service) ([]string, error) {
 	var ipv4Addresses []string
@@ -544,6 +576,7 @@ func (k *k3s) newDaemonSet(svc *core.Service) (*apps.DaemonSet, error) {
 		}
 
 		if localTraffic {
+			// The host IP only has a single family, so only ingress addresses of that family are published for the node.
 			container.Env = append(container.Env,
 				core.EnvVar{
 					Name:  "DEST_PORT",
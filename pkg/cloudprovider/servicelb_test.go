@@ -89,3 +89,98 @@ func Test_UnitFilterByIPFamily(t *testing.T) {
 		})
 	}
 }
+
+func Test_UnitPreferredIPs(t *testing.T) {
+	tests := []struct {
+		name   string
+		extIPs []string
+		intIPs []string
+		want   []string
+	}{
+		{
+			name:   "internal IPs only",
+			intIPs: []string{"10.0.0.1", "fd00::1"},
+			want:   []string{"10.0.0.1", "fd00::1"},
+		},
+		{
+			name:   "external IPs of both families",
+			extIPs: []string{addrv4, addrv6},
+			intIPs: []string{"10.0.0.1", "fd00::1"},
+			want:   []string{addrv4, addrv6},
+		},
+		{
+			name:   "external IPv4 only",
+			extIPs: []string{addrv4},
+			intIPs: []string{"10.0.0.1", "fd00::1"},
+			want:   []string{addrv4, "fd00::1"},
+		},
+		{
+			name:   "external IPv6 only",
+			extIPs: []string{addrv6},
+			intIPs: []string{"10.0.0.1", "fd00::1"},
+			want:   []string{"10.0.0.1", addrv6},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := preferredIPs(tt.extIPs, tt.intIPs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("preferredIPs() = %+v\nWant = %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitAddNodeIPs(t *testing.T) {
+	node := &core.Node{
+		Status: core.NodeStatus{
+			Addresses: []core.NodeAddress{
+				{Type: core.NodeInternalIP, Address: "10.0.0.1"},
+				{Type: core.NodeInternalIP, Address: "fd00::1"},
+				{Type: core.NodeExternalIP, Address: addrv4},
+				{Type: core.NodeHostName, Address: "node1"},
+			},
+		},
+	}
+	tests := []struct {
+		name         string
+		hostIP       string
+		localTraffic bool
+		wantExtIPs   []string
+		wantIntIPs   []string
+	}{
+		{
+			name:       "cluster traffic",
+			hostIP:     "10.0.0.1",
+			wantExtIPs: []string{addrv4},
+			wantIntIPs: []string{"10.0.0.1", "fd00::1"},
+		},
+		{
+			name:         "local traffic with IPv4 host IP",
+			hostIP:       "10.0.0.1",
+			localTraffic: true,
+			wantExtIPs:   []string{addrv4},
+			wantIntIPs:   []string{"10.0.0.1"},
+		},
+		{
+			name:         "local traffic with IPv6 host IP",
+			hostIP:       "fd00::1",
+			localTraffic: true,
+			wantExtIPs:   []string{},
+			wantIntIPs:   []string{"fd00::1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &core.Pod{Status: core.PodStatus{HostIP: tt.hostIP}}
+			extIPs := map[string]bool{}
+			intIPs := map[string]bool{}
+			addNodeIPs(node, pod, tt.localTraffic, extIPs, intIPs)
+			if got := keys(extIPs); !reflect.DeepEqual(got, tt.wantExtIPs) {
+				t.Errorf("addNodeIPs() external IPs = %+v\nWant = %+v", got, tt.wantExtIPs)
+			}
+			if got := keys(intIPs); !reflect.DeepEqual(got, tt.wantIntIPs) {
+				t.Errorf("addNodeIPs() internal IPs = %+v\nWant = %+v", got, tt.wantIntIPs)
+			}
+		})
+	}
+}
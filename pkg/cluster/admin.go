@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// serveAdminSocket serves the supervisor request handler on a Unix socket in the server data dir.
+// The socket is only accessible by the user that the server runs as, so requests received on it are
+// handled as if they had been made over TLS with the admin client certificate. This allows local
+// administration when the network listeners are firewalled, or the serving certificate is not valid
+// for the addresses available to clients.
+func (c *Cluster) serveAdminSocket(ctx context.Context, handler http.Handler) error {
+	path := c.config.Runtime.AdminSocket
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	server := http.Server{
+		Handler: adminHandler(c.config.Runtime.ClientAdminCert, handler),
+	}
+
+	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		server.ErrorLog = log.New(logrus.StandardLogger().Writer(), "Cluster-Admin-Server ", log.LstdFlags)
+	} else {
+		server.ErrorLog = log.New(io.Discard, "Cluster-Admin-Server", 0)
+	}
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Errorf("Admin socket server stopped: %v", err)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	logrus.Infof("Serving local admin access on unix://%s", path)
+	return nil
+}
+
+// adminHandler sets the request's TLS connection state to contain the admin client certificate,
+// so that the supervisor and apiserver authenticate the request as the admin user. The certificate
+// is read from disk for each request, in order to pick up any changes following rotation.
+func adminHandler(certFile string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		certs, err := certutil.CertsFromFile(certFile)
+		if err != nil {
+			logrus.Errorf("Failed to load admin certificate for local admin socket: %v", err)
+			resp.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		req.TLS = &tls.ConnectionState{
+			HandshakeComplete: true,
+			PeerCertificates:  certs,
+		}
+		handler.ServeHTTP(resp, req)
+	})
+}
@@ -462,6 +462,16 @@ func (c *Cluster) compareConfig() error {
 		clusterControl.CriticalControlArgs.EgressSelectorMode = c.config.CriticalControlArgs.EgressSelectorMode
 	}
 
+	// Likewise, down-level servers do not rotate flannel keys.
+	if clusterControl.CriticalControlArgs.FlannelKeyRotation == 0 {
+		clusterControl.CriticalControlArgs.FlannelKeyRotation = c.config.CriticalControlArgs.FlannelKeyRotation
+	}
+
+	// Down-level servers do not have a registry policy.
+	if clusterControl.CriticalControlArgs.RegistryPolicyMode == "" {
+		clusterControl.CriticalControlArgs.RegistryPolicyMode = c.config.CriticalControlArgs.RegistryPolicyMode
+	}
+
 	if diff := deep.Equal(c.config.CriticalControlArgs, clusterControl.CriticalControlArgs); diff != nil {
 		rc := reflect.ValueOf(clusterControl.CriticalControlArgs).Type()
 		for _, d := range diff {
@@ -3,6 +3,7 @@ package cluster
 import (
 	"context"
 	"net/url"
+	"path/filepath"
 	"runtime"
 	"strings"
 
@@ -10,10 +11,13 @@ import (
 	"github.com/k3s-io/k3s/pkg/cluster/managed"
 	"github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/etcd"
+	"github.com/k3s-io/k3s/pkg/kine"
 	"github.com/k3s-io/k3s/pkg/util"
 	"github.com/k3s-io/kine/pkg/endpoint"
+	kinemetrics "github.com/k3s-io/kine/pkg/metrics"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"k8s.io/component-base/metrics/legacyregistry"
 )
 
 type Cluster struct {
@@ -133,8 +137,27 @@ func (c *Cluster) startStorage(ctx context.Context) error {
 	}
 	c.storageStarted = true
 
+	// check the default sqlite datastore for corruption before kine opens it
+	if c.config.Datastore.Endpoint == "" {
+		dbFile := filepath.Join(c.config.DataDir, "db", "state.db")
+		if err := checkSQLite(dbFile); err != nil {
+			return err
+		}
+		runSQLiteBackups(ctx, dbFile)
+	}
+
 	// start listening on the kine socket as an etcd endpoint, or return the external etcd endpoints
-	etcdConfig, err := endpoint.Listen(ctx, c.config.Datastore)
+	var etcdConfig endpoint.ETCDConfig
+	var err error
+	if c.config.KineStandalone {
+		etcdConfig, err = kine.Start(ctx, c.config.Datastore, c.config.DataDir, c.config.KineLimits, c.config.KineSlowSQLThreshold)
+	} else {
+		// Register the kine SQL latency, compaction and connection pool metrics with the legacy registry, so
+		// that they are exported alongside the apiserver metrics.
+		kinemetrics.SlowSQLThreshold = c.config.KineSlowSQLThreshold
+		c.config.Datastore.MetricsRegisterer = legacyregistry.Registerer()
+		etcdConfig, err = endpoint.Listen(ctx, c.config.Datastore)
+	}
 	if err != nil {
 		return errors.Wrap(err, "creating storage endpoint")
 	}
@@ -16,6 +16,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/etcd"
+	"github.com/k3s-io/k3s/pkg/util"
 	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/rancher/dynamiclistener"
 	"github.com/rancher/dynamiclistener/factory"
@@ -40,22 +41,32 @@ func (c *Cluster) newListener(ctx context.Context) (net.Listener, http.Handler,
 		}
 	}
 	ip := c.config.BindAddress
+	if c.config.SupervisorBindAddress != "" {
+		ip = c.config.SupervisorBindAddress
+	}
 	if utilsnet.IsIPv6String(ip) {
 		ip = fmt.Sprintf("[%s]", ip)
 	}
-	tcp, err := dynamiclistener.NewTCPListener(ip, c.config.SupervisorPort)
-	if err != nil {
-		return nil, nil, err
+	tcp := util.ActivatedListener(c.config.SupervisorPort)
+	if tcp == nil {
+		var err error
+		if tcp, err = dynamiclistener.NewTCPListener(ip, c.config.SupervisorPort); err != nil {
+			return nil, nil, err
+		}
 	}
 	cert, key, err := factory.LoadCerts(c.config.Runtime.ServerCA, c.config.Runtime.ServerCAKey)
 	if err != nil {
 		return nil, nil, err
 	}
+	sans := c.config.SANs
+	if len(c.config.SupervisorSANs) > 0 {
+		sans = c.config.SupervisorSANs
+	}
 	storage := tlsStorage(ctx, c.config.DataDir, c.config.Runtime)
 	return wrapHandler(dynamiclistener.NewListener(tcp, storage, cert, key, dynamiclistener.Config{
 		ExpirationDaysCheck: config.CertificateRenewDays,
 		Organization:        []string{version.Program},
-		SANs:                append(c.config.SANs, "kubernetes", "kubernetes.default", "kubernetes.default.svc", "kubernetes.default.svc."+c.config.ClusterDomain),
+		SANs:                append(sans, "kubernetes", "kubernetes.default", "kubernetes.default.svc", "kubernetes.default.svc."+c.config.ClusterDomain),
 		CN:                  version.Program,
 		TLSConfig: &tls.Config{
 			ClientAuth:   tls.RequestClientCert,
@@ -126,12 +137,29 @@ func (c *Cluster) initClusterAndHTTPS(ctx context.Context) error {
 		}
 	}()
 
-	// Shutdown the http server when the context is closed
+	// Shutdown the http server when the context is closed, waiting for in-flight requests to complete
+	// for up to the drain timeout.
+	c.config.Runtime.ShutdownWg.Add(1)
 	go func() {
+		defer c.config.Runtime.ShutdownWg.Done()
 		<-ctx.Done()
-		server.Shutdown(context.Background())
+		if c.config.ShutdownDrainTimeout <= 0 {
+			server.Shutdown(context.Background())
+			return
+		}
+		logrus.Infof("Draining supervisor requests for up to %s", c.config.ShutdownDrainTimeout)
+		drainCtx, cancel := context.WithTimeout(context.Background(), c.config.ShutdownDrainTimeout)
+		defer cancel()
+		if err := server.Shutdown(drainCtx); err != nil {
+			logrus.Warnf("Supervisor requests did not complete before the drain timeout: %v", err)
+		}
 	}()
 
+	// Serve the same handler on the local admin socket
+	if err := c.serveAdminSocket(ctx, handler); err != nil {
+		logrus.Warnf("Failed to start local admin socket: %v", err)
+	}
+
 	return nil
 }
 
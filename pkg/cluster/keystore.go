@@ -0,0 +1,115 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/bootstrap"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/etcd"
+	"github.com/pkg/errors"
+)
+
+// Keystore contains the cluster CA certificates and keys, service-account signing keys, secrets encryption
+// config, and the passwd file that holds the server and agent tokens - everything that must be restored, in
+// addition to the datastore, to rebuild the control-plane with the same identity. These are the same files that
+// are kept in the encrypted bootstrap data in the datastore, which is included in etcd snapshots.
+type Keystore struct {
+	CreatedAt time.Time
+	Files     bootstrap.PathsDataformat
+}
+
+// ReadKeystoreFromSnapshot reads the keystore from the encrypted bootstrap data in an etcd snapshot. The bootstrap
+// data is saved to the datastore under a key derived from the token, so the token that the cluster was using when
+// the snapshot was taken is required.
+func ReadKeystoreFromSnapshot(snapshotPath, token string) (*Keystore, error) {
+	normalizedToken, err := normalizeToken(token)
+	if err != nil {
+		return nil, err
+	}
+	data, err := etcd.ReadSnapshotKey(snapshotPath, storageKey(normalizedToken))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read bootstrap data from etcd snapshot; check that the token is correct")
+	}
+	plaintext, err := decrypt(normalizedToken, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt bootstrap data; check that the token is correct")
+	}
+	files := bootstrap.PathsDataformat{}
+	if err := json.Unmarshal(plaintext, &files); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no cluster keys found in etcd snapshot")
+	}
+	return &Keystore{CreatedAt: time.Now().UTC(), Files: files}, nil
+}
+
+// ReadKeystore reads the keystore from the files on disk.
+func ReadKeystore(config *config.Control) (*Keystore, error) {
+	buf := &bytes.Buffer{}
+	if err := bootstrap.ReadFromDisk(buf, &config.Runtime.ControlRuntimeBootstrap); err != nil {
+		return nil, err
+	}
+	files := bootstrap.PathsDataformat{}
+	if err := json.Unmarshal(buf.Bytes(), &files); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no cluster keys found on disk")
+	}
+	return &Keystore{CreatedAt: time.Now().UTC(), Files: files}, nil
+}
+
+// WriteToDisk writes the files in the keystore to disk. Existing files with different content are not replaced
+// unless force is true.
+func (k *Keystore) WriteToDisk(config *config.Control, force bool) error {
+	paths, err := bootstrap.ObjToMap(&config.Runtime.ControlRuntimeBootstrap)
+	if err != nil {
+		return err
+	}
+	if !force {
+		for pathKey, file := range k.Files {
+			path := paths[pathKey]
+			if path == "" {
+				continue
+			}
+			if data, err := os.ReadFile(path); err == nil && !bytes.Equal(data, file.Content) {
+				return errors.Errorf("%s already exists with different content; use --force to replace it", path)
+			}
+		}
+	}
+	return bootstrap.WriteToDiskFromStorage(k.Files, &config.Runtime.ControlRuntimeBootstrap)
+}
+
+// Encrypt returns the keystore, encrypted with the token.
+func (k *Keystore) Encrypt(token string) ([]byte, error) {
+	normalizedToken, err := normalizeToken(token)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(k)
+	if err != nil {
+		return nil, err
+	}
+	return encrypt(normalizedToken, data)
+}
+
+// DecryptKeystore decrypts a keystore that was encrypted with the token.
+func DecryptKeystore(data []byte, token string) (*Keystore, error) {
+	normalizedToken, err := normalizeToken(token)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := decrypt(normalizedToken, bytes.TrimSpace(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt keystore; check that the token is correct")
+	}
+	k := &Keystore{}
+	if err := json.Unmarshal(plaintext, k); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/bootstrap"
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+func Test_UnitKeystoreEncryption(t *testing.T) {
+	k := &Keystore{
+		CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Files: bootstrap.PathsDataformat{
+			"ServerCAKey": {Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Content: []byte("server-ca-key")},
+			"ServiceKey":  {Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Content: []byte("service-key")},
+		},
+	}
+	token := "K10aabbccddeeff00112233445566778899aabbccddeeff00112233445566778899::server:secret"
+
+	tests := []struct {
+		name         string
+		decryptToken string
+		wantErr      bool
+	}{
+		{
+			name:         "Full token",
+			decryptToken: token,
+		},
+		{
+			name:         "Password only",
+			decryptToken: "secret",
+		},
+		{
+			name:         "Wrong token",
+			decryptToken: "other",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := k.Encrypt(token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := DecryptKeystore(data, tt.decryptToken)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecryptKeystore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, k) {
+				t.Errorf("DecryptKeystore() = %v, want %v", got, k)
+			}
+		})
+	}
+}
+
+func Test_UnitReadKeystoreFromSnapshot(t *testing.T) {
+	token := "K10aabbccddeeff00112233445566778899aabbccddeeff00112233445566778899::server:secret"
+	files := bootstrap.PathsDataformat{
+		"ServerCAKey": {Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Content: []byte("server-ca-key")},
+	}
+	plaintext, err := json.Marshal(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := encrypt("secret", plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the bootstrap data to a bbolt database in the etcd backend format
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot")
+	db, err := bolt.Open(snapshotPath, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket([]byte("key"))
+		if err != nil {
+			return err
+		}
+		rev := make([]byte, 17)
+		binary.BigEndian.PutUint64(rev, 1)
+		rev[8] = '_'
+		kv := &mvccpb.KeyValue{Key: []byte(storageKey("secret")), Value: data, ModRevision: 1}
+		b, err := kv.Marshal()
+		if err != nil {
+			return err
+		}
+		return bucket.Put(rev, b)
+	})
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{
+			name:  "Full token",
+			token: token,
+		},
+		{
+			name:  "Password only",
+			token: "secret",
+		},
+		{
+			name:    "Wrong token",
+			token:   "other",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadKeystoreFromSnapshot(snapshotPath, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadKeystoreFromSnapshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got.Files, files) {
+				t.Errorf("ReadKeystoreFromSnapshot() = %v, want %v", got.Files, files)
+			}
+		})
+	}
+}
@@ -64,7 +64,7 @@ func (c *Cluster) start(ctx context.Context) error {
 
 	if c.config.ClusterReset {
 		// If we're restoring from a snapshot, don't check the reset-flag - just reset and restore.
-		if c.config.ClusterResetRestorePath != "" {
+		if c.config.ClusterResetRestorePath != "" || !c.config.ClusterResetRestoreTime.IsZero() {
 			return c.managedDB.Reset(ctx, rebootstrap)
 		}
 		// If the reset-flag doesn't exist, reset. This will create the reset-flag if it succeeds.
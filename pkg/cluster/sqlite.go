@@ -0,0 +1,232 @@
+package cluster
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	sqliteBackupInterval      = 24 * time.Hour
+	sqliteBackupCheckInterval = time.Hour
+	sqliteBackupRetention     = 3
+	sqliteBackupInfix         = ".backup-"
+
+	// sqliteBackupMaxRestoreAge is the maximum age of a backup that is restored automatically. Restoring an
+	// older backup would silently roll back the cluster further than an administrator is likely to expect.
+	sqliteBackupMaxRestoreAge = 3 * sqliteBackupInterval
+)
+
+// errSQLiteCorrupt is returned by sqliteIntegrityCheck if the database is corrupt.
+var errSQLiteCorrupt = errors.New("database is corrupt")
+
+// checkSQLite verifies the integrity of the sqlite datastore before it is opened by kine. If the datastore
+// is intact, a backup is taken if the most recent backup is older than the backup interval. If the datastore
+// is corrupt, it is moved aside, and recovered by copying out any readable content; if that fails, the most
+// recent intact backup is restored, as long as it is not older than the maximum restore age. This allows
+// single-node servers to recover from corruption caused by power loss or full disks without manual
+// intervention, instead of failing to start.
+func checkSQLite(dbFile string) error {
+	if _, err := os.Stat(dbFile); os.IsNotExist(err) {
+		return nil
+	}
+
+	err := sqliteIntegrityCheck(dbFile)
+	if err == nil {
+		if err := backupSQLite(dbFile, time.Now()); err != nil {
+			logrus.Warnf("Failed to back up datastore: %v", err)
+		}
+		return nil
+	}
+	if !errors.Is(err, errSQLiteCorrupt) {
+		return errors.Wrap(err, "failed to check datastore integrity")
+	}
+
+	logrus.Errorf("Datastore %s failed integrity check: %v", dbFile, err)
+	corruptFile, err := moveCorruptSQLite(dbFile, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "failed to move corrupt datastore")
+	}
+	logrus.Warnf("Moved corrupt datastore to %s", filepath.Dir(corruptFile))
+
+	err = recoverSQLite(corruptFile, dbFile)
+	if err == nil {
+		logrus.Warnf("Recovered datastore content from corrupt datastore into %s; some recent changes may have been lost", dbFile)
+		return nil
+	}
+	logrus.Errorf("Failed to recover content from corrupt datastore: %v", err)
+
+	backup, err := restoreSQLiteBackup(dbFile, time.Now())
+	if err != nil {
+		return errors.Wrapf(err, "datastore is corrupt and could not be recovered; the corrupt datastore was moved to %s", filepath.Dir(corruptFile))
+	}
+	logrus.Warnf("Restored datastore from backup %s; changes made since the backup was taken have been lost", backup)
+	return nil
+}
+
+// sqliteIntegrityCheck runs a quick integrity check against the database, and returns errSQLiteCorrupt
+// if the database is corrupt. Other errors are returned as-is. The quick check skips verifying that
+// indexes match their tables, so that it does not noticeably delay startup on large datastores.
+func sqliteIntegrityCheck(dbFile string) error {
+	db, err := sql.Open("sqlite3", "file:"+dbFile+"?mode=rw")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA quick_check").Scan(&result); err != nil {
+		if isSQLiteCorrupt(err) {
+			return errors.Wrap(errSQLiteCorrupt, err.Error())
+		}
+		return err
+	}
+	if result != "ok" {
+		return errors.Wrap(errSQLiteCorrupt, result)
+	}
+	return nil
+}
+
+// vacuumInto copies the content of the source database into a new database file.
+func vacuumInto(source, target string) error {
+	db, err := sql.Open("sqlite3", "file:"+source+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec("VACUUM INTO ?", target)
+	return err
+}
+
+// runSQLiteBackups periodically backs up the datastore while the server is running, so that a recent
+// backup is available even if the server is not restarted.
+func runSQLiteBackups(ctx context.Context, dbFile string) {
+	go func() {
+		t := time.NewTicker(sqliteBackupCheckInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-t.C:
+				if err := backupSQLite(dbFile, now); err != nil {
+					logrus.Warnf("Failed to back up datastore: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// backupSQLite copies the database to a timestamped backup file, if the most recent backup is older than
+// the backup interval. Only the most recent backups are retained.
+func backupSQLite(dbFile string, now time.Time) error {
+	backups, err := sqliteBackups(dbFile)
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 {
+		if info, err := os.Stat(backups[0]); err == nil && now.Sub(info.ModTime()) < sqliteBackupInterval {
+			return nil
+		}
+	}
+
+	backup := dbFile + sqliteBackupInfix + strconv.FormatInt(now.Unix(), 10)
+	if err := vacuumInto(dbFile, backup); err != nil {
+		os.Remove(backup)
+		return err
+	}
+	logrus.Infof("Backed up datastore to %s", backup)
+
+	backups = append([]string{backup}, backups...)
+	for i, old := range backups {
+		if i < sqliteBackupRetention {
+			continue
+		}
+		if err := os.Remove(old); err != nil {
+			logrus.Warnf("Failed to remove old datastore backup %s: %v", old, err)
+		}
+	}
+	return nil
+}
+
+// sqliteBackups returns the list of backup files for the database, newest first.
+func sqliteBackups(dbFile string) ([]string, error) {
+	backups, err := filepath.Glob(dbFile + sqliteBackupInfix + "*")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backupTimestamp(backups[i], dbFile) > backupTimestamp(backups[j], dbFile)
+	})
+	return backups, nil
+}
+
+func backupTimestamp(backup, dbFile string) int64 {
+	ts, _ := strconv.ParseInt(backup[len(dbFile+sqliteBackupInfix):], 10, 64)
+	return ts
+}
+
+// moveCorruptSQLite moves the database, along with its WAL and shared-memory files, into a new directory
+// so that they are kept together for recovery and later inspection. The path of the moved database is returned.
+func moveCorruptSQLite(dbFile string, now time.Time) (string, error) {
+	corruptDir := filepath.Join(filepath.Dir(dbFile), "corrupt-"+strconv.FormatInt(now.Unix(), 10))
+	if err := os.MkdirAll(corruptDir, 0700); err != nil {
+		return "", err
+	}
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Rename(dbFile+suffix, filepath.Join(corruptDir, filepath.Base(dbFile)+suffix)); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	return filepath.Join(corruptDir, filepath.Base(dbFile)), nil
+}
+
+// recoverSQLite copies any readable content from the corrupt database into a new database at dbFile,
+// and verifies the integrity of the result.
+func recoverSQLite(corruptFile, dbFile string) error {
+	recovered := dbFile + ".recovered"
+	os.Remove(recovered)
+	if err := vacuumInto(corruptFile, recovered); err != nil {
+		os.Remove(recovered)
+		return err
+	}
+	if err := sqliteIntegrityCheck(recovered); err != nil {
+		os.Remove(recovered)
+		return err
+	}
+	return os.Rename(recovered, dbFile)
+}
+
+// restoreSQLiteBackup copies the most recent intact backup to dbFile, and returns the path of the backup.
+// Backups older than the maximum restore age are not restored, and must be restored manually if desired.
+func restoreSQLiteBackup(dbFile string, now time.Time) (string, error) {
+	backups, err := sqliteBackups(dbFile)
+	if err != nil {
+		return "", err
+	}
+	for _, backup := range backups {
+		if err := sqliteIntegrityCheck(backup); err != nil {
+			logrus.Warnf("Skipping datastore backup %s: %v", backup, err)
+			continue
+		}
+		if age := now.Sub(time.Unix(backupTimestamp(backup, dbFile), 0)); age > sqliteBackupMaxRestoreAge {
+			return "", fmt.Errorf("most recent intact backup %s is %s old, which is older than the maximum age of %s for automatic restore; copy it to %s to restore it manually",
+				backup, age.Round(time.Minute), sqliteBackupMaxRestoreAge, dbFile)
+		}
+		if err := vacuumInto(backup, dbFile); err != nil {
+			os.Remove(dbFile)
+			logrus.Warnf("Failed to restore datastore backup %s: %v", backup, err)
+			continue
+		}
+		return backup, nil
+	}
+	return "", fmt.Errorf("no intact backups found")
+}
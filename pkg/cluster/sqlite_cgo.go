@@ -0,0 +1,15 @@
+//go:build cgo
+// +build cgo
+
+package cluster
+
+import (
+	"github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+// isSQLiteCorrupt returns true if the error was returned by sqlite because the database file is corrupt.
+func isSQLiteCorrupt(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrCorrupt || sqliteErr.Code == sqlite3.ErrNotADB)
+}
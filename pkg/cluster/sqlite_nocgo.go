@@ -0,0 +1,9 @@
+//go:build !cgo
+// +build !cgo
+
+package cluster
+
+// isSQLiteCorrupt always returns false, as sqlite is not available without cgo.
+func isSQLiteCorrupt(err error) bool {
+	return false
+}
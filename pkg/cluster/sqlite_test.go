@@ -0,0 +1,126 @@
+package cluster
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func createTestSQLite(t *testing.T, dbFile string, rows int) {
+	db, err := sql.Open("sqlite3", "file:"+dbFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE kine (id INTEGER PRIMARY KEY, name TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < rows; i++ {
+		if _, err := db.Exec("INSERT INTO kine (name) VALUES (?)", "/registry/test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func countTestSQLite(t *testing.T, dbFile string) int {
+	db, err := sql.Open("sqlite3", "file:"+dbFile+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM kine").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func Test_UnitCheckSQLite(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(t *testing.T, dbFile string)
+		wantErr   bool
+		wantRows  int
+		wantFiles int
+	}{
+		{
+			name:  "Missing datastore",
+			setup: func(t *testing.T, dbFile string) {},
+		},
+		{
+			name: "Intact datastore is backed up",
+			setup: func(t *testing.T, dbFile string) {
+				createTestSQLite(t, dbFile, 10)
+			},
+			wantRows:  10,
+			wantFiles: 1,
+		},
+		{
+			name: "Recent backup is not replaced",
+			setup: func(t *testing.T, dbFile string) {
+				createTestSQLite(t, dbFile, 10)
+				if err := backupSQLite(dbFile, time.Now().Add(-time.Hour)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantRows:  10,
+			wantFiles: 1,
+		},
+		{
+			name: "Corrupt datastore is restored from backup",
+			setup: func(t *testing.T, dbFile string) {
+				createTestSQLite(t, dbFile, 10)
+				if err := backupSQLite(dbFile, time.Now().Add(-48*time.Hour)); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(dbFile, []byte("this is not a database"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantRows:  10,
+			wantFiles: 1,
+		},
+		{
+			name: "Corrupt datastore is not restored from stale backup",
+			setup: func(t *testing.T, dbFile string) {
+				createTestSQLite(t, dbFile, 10)
+				if err := backupSQLite(dbFile, time.Now().Add(-sqliteBackupMaxRestoreAge-time.Hour)); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(dbFile, []byte("this is not a database"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "Corrupt datastore without backup",
+			setup: func(t *testing.T, dbFile string) {
+				if err := os.WriteFile(dbFile, []byte("this is not a database"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbFile := filepath.Join(t.TempDir(), "state.db")
+			tt.setup(t, dbFile)
+			if err := checkSQLite(dbFile); (err != nil) != tt.wantErr {
+				t.Fatalf("checkSQLite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr || tt.wantRows == 0 {
+				return
+			}
+			if rows := countTestSQLite(t, dbFile); rows != tt.wantRows {
+				t.Errorf("checkSQLite() datastore rows = %d\nWant = %d", rows, tt.wantRows)
+			}
+			if backups, _ := sqliteBackups(dbFile); len(backups) != tt.wantFiles {
+				t.Errorf("checkSQLite() backups = %v\nWant %d backups", backups, tt.wantFiles)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ var DefaultParser = &Parser{
 	EnvName:       version.ProgramUpper + "_CONFIG_FILE",
 	DefaultConfig: "/etc/rancher/" + version.Program + "/config.yaml",
 	ValidFlags:    map[string][]cli.Flag{"server": cmds.ServerFlags, "etcd-snapshot": cmds.EtcdSnapshotFlags},
+	LastKnownGood: true,
 }
 
 func MustParse(args []string) []string {
@@ -63,6 +63,9 @@ func Test_UnitMustParse(t *testing.T) {
 				"--etcd-s3=true", "--etcd-s3-bucket=my-backup", "--notaflag=true", "--kubelet-arg=max-pods=999"},
 		},
 	}
+	// Do not write last-known-good copies of the config into testdata
+	defer func(v bool) { DefaultParser.LastKnownGood = v }(DefaultParser.LastKnownGood)
+	DefaultParser.LastKnownGood = false
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			DefaultParser.DefaultConfig = tt.config
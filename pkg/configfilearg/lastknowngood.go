@@ -0,0 +1,148 @@
+package configfilearg
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+const lastKnownGoodSuffix = ".last-known-good"
+
+// validateFlags checks that the config file values can be parsed by the command's flags, so that
+// invalid values are caught before the command starts, instead of after it has begun replacing
+// running components.
+func (p *Parser) validateFlags(command string, values []string) error {
+	cmdFlags := p.ValidFlags[command]
+	if len(cmdFlags) == 0 {
+		return nil
+	}
+	set := flag.NewFlagSet(command, flag.ContinueOnError)
+	set.SetOutput(io.Discard)
+	for _, f := range cmdFlags {
+		validationFlag(f).Apply(set)
+	}
+	if err := set.Parse(values); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validationFlag returns a copy of the flag that does not store parsed values into the flag's
+// destination, so that validating the config does not modify the command's flag values.
+func validationFlag(f cli.Flag) cli.Flag {
+	switch f := f.(type) {
+	case *cli.BoolFlag:
+		c := *f
+		c.Destination = nil
+		return c
+	case cli.BoolFlag:
+		f.Destination = nil
+		return f
+	case *cli.StringFlag:
+		c := *f
+		c.Destination = nil
+		return c
+	case cli.StringFlag:
+		f.Destination = nil
+		return f
+	case *cli.IntFlag:
+		c := *f
+		c.Destination = nil
+		return c
+	case cli.IntFlag:
+		f.Destination = nil
+		return f
+	case *cli.DurationFlag:
+		c := *f
+		c.Destination = nil
+		return c
+	case cli.DurationFlag:
+		f.Destination = nil
+		return f
+	case *cli.StringSliceFlag:
+		c := *f
+		c.Value = &cli.StringSlice{}
+		return c
+	case cli.StringSliceFlag:
+		f.Value = &cli.StringSlice{}
+		return f
+	}
+	// Accept any value for flag types that we do not know how to copy.
+	return cli.StringFlag{Name: f.GetName()}
+}
+
+// lastKnownGoodFile returns the path that the last-known-good config is stored at, or an empty string
+// if the last-known-good config is not enabled or cannot be stored for the config file.
+func (p *Parser) lastKnownGoodFile(configFile string) string {
+	if !p.LastKnownGood {
+		return ""
+	}
+	if u, err := url.Parse(configFile); err != nil || u.Scheme == "http" || u.Scheme == "https" {
+		return ""
+	}
+	return configFile + lastKnownGoodSuffix
+}
+
+// saveLastKnownGood stores the config file values, if they differ from the current last-known-good config.
+// The values are stored in flag form, so that the contents of the config file and all drop-in files are
+// captured as of the time they were validated. As the values may include secrets such as the token, the
+// file is only readable by its owner. Failure to save is not fatal.
+func (p *Parser) saveLastKnownGood(configFile string, values []string) {
+	file := p.lastKnownGoodFile(configFile)
+	if file == "" {
+		return
+	}
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		logrus.Warnf("Failed to marshal last-known-good config: %v", err)
+		return
+	}
+	if existing, err := os.ReadFile(file); err == nil && bytes.Equal(existing, data) {
+		if err := os.Chmod(file, 0600); err != nil {
+			logrus.Debugf("Failed to set permissions on last-known-good config: %v", err)
+		}
+		return
+	}
+	// Remove any leftover temp file, as WriteFile does not change the permissions of an existing file
+	tmp := file + ".tmp"
+	os.Remove(tmp)
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		logrus.Debugf("Failed to save last-known-good config: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		logrus.Debugf("Failed to save last-known-good config: %v", err)
+	}
+}
+
+// loadLastKnownGood reads and validates the last-known-good config, and returns the values to be
+// passed to the command.
+func (p *Parser) loadLastKnownGood(command, configFile string) ([]string, error) {
+	file := p.lastKnownGoodFile(configFile)
+	if file == "" {
+		return nil, fmt.Errorf("last-known-good config is not available for %s", configFile)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	values := []string{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		if !strings.HasPrefix(value, "-") {
+			return nil, fmt.Errorf("invalid value %q in %s", value, file)
+		}
+	}
+	return p.validateConfig(command, values)
+}
@@ -0,0 +1,102 @@
+package configfilearg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func Test_UnitParser_LastKnownGood(t *testing.T) {
+	var port int
+	validFlags := map[string][]cli.Flag{
+		"server": {
+			&cli.IntFlag{Name: "https-listen-port", Destination: &port},
+			&cli.StringSliceFlag{Name: "disable"},
+			&cli.StringFlag{Name: "token,t"},
+		},
+	}
+	tests := []struct {
+		name    string
+		configs []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "Valid config",
+			configs: []string{"https-listen-port: 6444\ndisable: [traefik]\n"},
+			want:    []string{"k3s", "server", "--https-listen-port=6444", "--disable=traefik"},
+		},
+		{
+			name:    "Invalid yaml without last-known-good",
+			configs: []string{"https-listen-port: [6444\n"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid value without last-known-good",
+			configs: []string{"https-listen-port: foo\n"},
+			wantErr: true,
+		},
+		{
+			name: "Invalid yaml with last-known-good",
+			configs: []string{
+				"https-listen-port: 6444\ntoken: secret\n",
+				"https-listen-port: [6445\n",
+			},
+			want: []string{"k3s", "server", "--https-listen-port=6444", "--token=secret"},
+		},
+		{
+			name: "Invalid value with last-known-good",
+			configs: []string{
+				"https-listen-port: 6444\n",
+				"https-listen-port: foo\n",
+			},
+			want: []string{"k3s", "server", "--https-listen-port=6444"},
+		},
+		{
+			name: "Updated valid config replaces last-known-good",
+			configs: []string{
+				"https-listen-port: 6444\n",
+				"https-listen-port: 6445\n",
+				"https-listen-port: foo\n",
+			},
+			want: []string{"k3s", "server", "--https-listen-port=6445"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			p := &Parser{
+				After:         []string{"server"},
+				FlagNames:     []string{"-c", "--config"},
+				DefaultConfig: configFile,
+				ValidFlags:    validFlags,
+				LastKnownGood: true,
+			}
+
+			var got []string
+			var err error
+			for _, config := range tt.configs {
+				if err := os.WriteFile(configFile, []byte(config), 0600); err != nil {
+					t.Fatal(err)
+				}
+				got, err = p.Parse([]string{"k3s", "server"})
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parser.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parser.Parse() = %+v\nWant = %+v", got, tt.want)
+			}
+			if port != 0 {
+				t.Errorf("Parser.Parse() modified flag destination: %d", port)
+			}
+			if info, err := os.Stat(configFile + lastKnownGoodSuffix); err == nil && info.Mode().Perm() != 0600 {
+				t.Errorf("Parser.Parse() saved last-known-good config with mode %v, want 0600", info.Mode().Perm())
+			}
+		})
+	}
+}
@@ -24,6 +24,9 @@ type Parser struct {
 	EnvName       string
 	DefaultConfig string
 	ValidFlags    map[string][]cli.Flag
+	// LastKnownGood enables saving a copy of the most recent valid config alongside the config file,
+	// which is used in place of the config file if it fails to validate.
+	LastKnownGood bool
 }
 
 // Parse will parse an os.Args style slice looking for Parser.FlagNames after Parse.After.
@@ -44,21 +47,48 @@ func (p *Parser) Parse(args []string) ([]string, error) {
 		values, err := readConfigFile(configFile)
 		if !isSet && os.IsNotExist(err) {
 			return args, nil
-		} else if err != nil {
-			return nil, err
 		}
+		var command string
 		if len(args) > 1 {
-			values, err = p.stripInvalidFlags(args[1], values)
-			if err != nil {
-				return nil, err
-			}
+			command = args[1]
+		}
+		var flags []string
+		if err == nil {
+			flags, err = p.validateConfig(command, values)
+		}
+		if err == nil {
+			p.saveLastKnownGood(configFile, values)
+			return append(prefix, append(flags, suffix...)...), nil
+		}
+		flags, lkgErr := p.loadLastKnownGood(command, configFile)
+		if lkgErr != nil {
+			logrus.Debugf("Unable to use last-known-good config: %v", lkgErr)
+			return nil, err
 		}
-		return append(prefix, append(values, suffix...)...), nil
+		logrus.Errorf("Invalid config %s: %v", configFile, err)
+		logrus.Errorf("Using last-known-good config from %s; fix the config file and restart to apply changes", p.lastKnownGoodFile(configFile))
+		return append(prefix, append(flags, suffix...)...), nil
 	}
 
 	return args, nil
 }
 
+// validateConfig strips unknown flags from the config file values, and checks that the remaining
+// values can be parsed by the command's flags. The values to be passed to the command are returned.
+func (p *Parser) validateConfig(command string, values []string) ([]string, error) {
+	if command == "" {
+		return values, nil
+	}
+	values, err := p.stripInvalidFlags(command, values)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.validateFlags(command, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
 func (p *Parser) stripInvalidFlags(command string, args []string) ([]string, error) {
 	var result []string
 	var cmdFlags []cli.Flag
@@ -11,5 +11,12 @@ func List() []crd.CRD {
 		WithColumn("Source", ".spec.source").
 		WithColumn("Checksum", ".spec.checksum")
 
-	return []crd.CRD{addon}
+	nodeJoinRequest := crd.NamespacedType("NodeJoinRequest.k3s.cattle.io/v1").
+		WithSchemaFromStruct(v1.NodeJoinRequest{}).
+		WithColumn("Node", ".spec.nodeName").
+		WithColumn("Address", ".spec.remoteAddress").
+		WithColumn("Approved", ".spec.approved").
+		WithColumn("Denied", ".spec.denied")
+
+	return []crd.CRD{addon, nodeJoinRequest}
 }
@@ -0,0 +1,71 @@
+package crictl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubernetes-sigs/cri-tools/cmd/crictl"
+	"github.com/kubernetes-sigs/cri-tools/pkg/common"
+)
+
+const (
+	// ConfigFileEnv is the environment variable used by crictl to locate its config file.
+	ConfigFileEnv     = "CRI_CONFIG_FILE"
+	defaultConfigFile = "/etc/crictl.yaml"
+)
+
+// Main runs crictl. The `config print` subcommand is handled here, as upstream crictl
+// can only get and set individual options.
+func Main() {
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "crictl" {
+		args = args[1:]
+	}
+	if len(args) > 1 && args[0] == "config" && args[1] == "print" {
+		if err := printConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "crictl: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	crictl.Main()
+}
+
+// ConfigFile returns the path to the crictl config file that will be used.
+func ConfigFile() string {
+	if configFile := os.Getenv(ConfigFileEnv); configFile != "" {
+		return configFile
+	}
+	return defaultConfigFile
+}
+
+// ContainerdAddress returns the containerd socket address from the crictl config file,
+// if the configured runtime endpoint is a local socket that does not belong to cri-dockerd.
+func ContainerdAddress() string {
+	config, err := common.ReadConfig(ConfigFile())
+	if err != nil {
+		return ""
+	}
+	if !strings.HasPrefix(config.RuntimeEndpoint, "unix://") || strings.Contains(config.RuntimeEndpoint, "cri-dockerd") {
+		return ""
+	}
+	return strings.TrimPrefix(config.RuntimeEndpoint, "unix://")
+}
+
+// printConfig prints the effective crictl configuration, and the file that it was read from.
+func printConfig() error {
+	configFile := ConfigFile()
+	config, err := common.ReadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("# %s\n", configFile)
+	fmt.Printf("runtime-endpoint: %s\n", config.RuntimeEndpoint)
+	fmt.Printf("image-endpoint: %s\n", config.ImageEndpoint)
+	fmt.Printf("timeout: %d\n", config.Timeout)
+	fmt.Printf("debug: %t\n", config.Debug)
+	fmt.Printf("pull-image-on-create: %t\n", config.PullImageOnCreate)
+	fmt.Printf("disable-pull-on-run: %t\n", config.DisablePullOnRun)
+	return nil
+}
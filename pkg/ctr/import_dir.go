@@ -0,0 +1,76 @@
+package ctr
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cmd/ctr/commands"
+	"github.com/pkg/errors"
+	"github.com/rancher/wharfie/pkg/tarfile"
+	"github.com/rancher/wrangler/pkg/merr"
+	"github.com/urfave/cli"
+)
+
+// importDirCommand imports all image tarballs found in a directory, using the same
+// file formats supported by the agent's airgap image import.
+var importDirCommand = cli.Command{
+	Name:      "import-dir",
+	Usage:     "Import all image tarballs from a directory",
+	ArgsUsage: "<directory>",
+	Description: `Import every image tarball in the given directory into the current namespace.
+Supported formats are .tar, .tar.gz, .tgz, .tar.bz2, .tbz, .tar.lz4, and .tar.zst.
+Files in other formats are skipped.`,
+	Action: func(context *cli.Context) error {
+		dir := context.Args().First()
+		if dir == "" || context.NArg() > 1 {
+			return errors.New("exactly one directory must be provided")
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		client, ctx, cancel, err := commands.NewClient(context)
+		if err != nil {
+			return err
+		}
+		defer cancel()
+		defer client.Close()
+
+		ctx, done, err := client.WithLease(ctx)
+		if err != nil {
+			return err
+		}
+		defer done(ctx)
+
+		var errs []error
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			filePath := filepath.Join(dir, entry.Name())
+			opener, err := tarfile.GetOpener(filePath)
+			if err != nil {
+				continue
+			}
+			imageReader, err := opener()
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			fmt.Printf("importing %s\n", filePath)
+			imgs, err := client.Import(ctx, imageReader, containerd.WithAllPlatforms(true))
+			imageReader.Close()
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to import %s", filePath))
+				continue
+			}
+			for _, img := range imgs {
+				fmt.Printf("imported %s\n", img.Name)
+			}
+		}
+		return merr.NewErrors(errs...)
+	},
+}
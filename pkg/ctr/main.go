@@ -21,7 +21,9 @@ import (
 	"os"
 
 	"github.com/containerd/containerd/cmd/ctr/app"
+	"github.com/containerd/containerd/pkg/cri/constants"
 	"github.com/containerd/containerd/pkg/seed"
+	"github.com/k3s-io/k3s/pkg/crictl"
 	"github.com/urfave/cli"
 )
 
@@ -30,20 +32,32 @@ func Main() {
 }
 
 func main() {
+	defaultAddress := "/run/k3s/containerd/containerd.sock"
+	if address := crictl.ContainerdAddress(); address != "" {
+		defaultAddress = address
+	}
+
 	seed.WithTimeAndRand()
 	app := app.New()
 	for i, flag := range app.Flags {
 		if sFlag, ok := flag.(cli.StringFlag); ok {
 			if sFlag.Name == "address, a" {
-				sFlag.Value = "/run/k3s/containerd/containerd.sock"
+				sFlag.Value = defaultAddress
 				app.Flags[i] = sFlag
 			} else if sFlag.Name == "namespace, n" {
-				sFlag.Value = "k8s.io"
+				sFlag.Value = constants.K8sContainerdNamespace
 				app.Flags[i] = sFlag
 			}
 		}
 	}
 
+	for i, command := range app.Commands {
+		if command.Name == "images" {
+			command.Subcommands = append(command.Subcommands, importDirCommand)
+			app.Commands[i] = command
+		}
+	}
+
 	if err := app.Run(os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "ctr: %s\n", err)
 		os.Exit(1)
@@ -2,8 +2,11 @@ package agent
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/k3s-io/k3s/pkg/agent/config"
@@ -44,7 +47,24 @@ func startKubeProxy(ctx context.Context, cfg *daemonconfig.Agent) error {
 }
 
 func startKubelet(ctx context.Context, cfg *daemonconfig.Agent) error {
+	if cfg.SwapBehavior != "" {
+		if err := writeKubeletConfig(cfg); err != nil {
+			return err
+		}
+	}
+
 	argsMap := kubeletArgs(cfg)
+	// Settings distributed by the supervisor override the defaults, but not any user-provided kubelet args.
+	for k, v := range daemonconfig.KubeletSettingsArgs(cfg.KubeletSettings) {
+		argsMap[k] = v
+	}
+	// Image garbage collection thresholds set on the node override the settings distributed by the supervisor.
+	if cfg.ImageGCHighThreshold > 0 {
+		argsMap["image-gc-high-threshold"] = strconv.Itoa(cfg.ImageGCHighThreshold)
+	}
+	if cfg.ImageGCLowThreshold > 0 {
+		argsMap["image-gc-low-threshold"] = strconv.Itoa(cfg.ImageGCLowThreshold)
+	}
 
 	args := daemonconfig.GetArgs(argsMap, cfg.ExtraKubeletArgs)
 	logrus.Infof("Running kubelet %s", daemonconfig.ArgString(args))
@@ -52,16 +72,51 @@ func startKubelet(ctx context.Context, cfg *daemonconfig.Agent) error {
 	return executor.Kubelet(ctx, args)
 }
 
+// writeKubeletConfig writes a kubelet config file containing settings that are not available as flags.
+func writeKubeletConfig(cfg *daemonconfig.Agent) error {
+	config := fmt.Sprintf(`apiVersion: kubelet.config.k8s.io/v1beta1
+kind: KubeletConfiguration
+memorySwap:
+  swapBehavior: %s
+`, cfg.SwapBehavior)
+	if err := os.MkdirAll(filepath.Dir(cfg.KubeletConfig), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(cfg.KubeletConfig, []byte(config), 0600)
+}
+
 // ImageCredProvAvailable checks to see if the kubelet image credential provider bin dir and config
 // files exist and are of the correct types. This is exported so that it may be used by downstream projects.
+// If only one of the two is present, the credential provider is probably misconfigured, so a warning is
+// logged instead of silently falling back to pulling workload images without plugin credentials.
 func ImageCredProvAvailable(cfg *daemonconfig.Agent) bool {
-	if info, err := os.Stat(cfg.ImageCredProvBinDir); err != nil || !info.IsDir() {
-		logrus.Debugf("Kubelet image credential provider bin directory check failed: %v", err)
-		return false
+	binDirErr := checkImageCredProvPath(cfg.ImageCredProvBinDir, true)
+	configErr := checkImageCredProvPath(cfg.ImageCredProvConfig, false)
+	switch {
+	case binDirErr == nil && configErr == nil:
+		return true
+	case binDirErr == nil:
+		logrus.Warnf("Kubelet image credential provider bin directory %s found, but config file check failed: %v", cfg.ImageCredProvBinDir, configErr)
+	case configErr == nil:
+		logrus.Warnf("Kubelet image credential provider config file %s found, but bin directory check failed: %v", cfg.ImageCredProvConfig, binDirErr)
+	default:
+		logrus.Debugf("Kubelet image credential provider bin directory check failed: %v", binDirErr)
+		logrus.Debugf("Kubelet image credential provider config file check failed: %v", configErr)
+	}
+	return false
+}
+
+// checkImageCredProvPath returns an error if the path does not exist, or is not of the expected type.
+func checkImageCredProvPath(path string, dir bool) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
 	}
-	if info, err := os.Stat(cfg.ImageCredProvConfig); err != nil || info.IsDir() {
-		logrus.Debugf("Kubelet image credential provider config file check failed: %v", err)
-		return false
+	if dir && !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
 	}
-	return true
+	if !dir && info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
 }
@@ -6,11 +6,14 @@ package agent
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/k3s-io/k3s/pkg/cgroups"
 	"github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/util"
+	"github.com/rancher/wrangler/pkg/slice"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 	"k8s.io/apimachinery/pkg/util/net"
@@ -64,7 +67,7 @@ func kubeletArgs(cfg *config.Agent) map[string]string {
 		"kubeconfig":                   cfg.KubeConfigKubelet,
 		"eviction-hard":                "imagefs.available<5%,nodefs.available<5%",
 		"eviction-minimum-reclaim":     "imagefs.available=10%,nodefs.available=10%",
-		"fail-swap-on":                 "false",
+		"fail-swap-on":                 strconv.FormatBool(cfg.FailSwapOn),
 		"cgroup-driver":                "cgroupfs",
 		"authentication-token-webhook": "true",
 		"anonymous-auth":               "false",
@@ -152,7 +155,6 @@ func kubeletArgs(cfg *config.Agent) map[string]string {
 
 	if ImageCredProvAvailable(cfg) {
 		logrus.Infof("Kubelet image credential provider bin dir and configuration file found.")
-		argsMap["feature-gates"] = util.AddFeatureGate(argsMap["feature-gates"], "KubeletCredentialProviders=true")
 		argsMap["image-credential-provider-bin-dir"] = cfg.ImageCredProvBinDir
 		argsMap["image-credential-provider-config"] = cfg.ImageCredProvConfig
 	}
@@ -169,8 +171,72 @@ func kubeletArgs(cfg *config.Agent) map[string]string {
 		argsMap["protect-kernel-defaults"] = "true"
 	}
 
+	if cfg.ReserveResources != "" {
+		var info unix.Sysinfo_t
+		if err := unix.Sysinfo(&info); err != nil {
+			logrus.Warnf("Failed to get node memory; resources will not be reserved: %v", err)
+		} else {
+			reserved := CalculateReservedResources(cfg.ReserveResources, runtime.NumCPU(), uint64(info.Totalram)*uint64(info.Unit))
+			logrus.Infof("Reserving resources using %s profile: kube-reserved=%s system-reserved=%s %s",
+				cfg.ReserveResources, reserved.KubeReserved, reserved.SystemReserved, reserved.EvictionHard)
+			argsMap["kube-reserved"] = reserved.KubeReserved
+			argsMap["system-reserved"] = reserved.SystemReserved
+			argsMap["eviction-hard"] = argsMap["eviction-hard"] + "," + reserved.EvictionHard
+		}
+	}
+	if cfg.KubeReserved != "" {
+		argsMap["kube-reserved"] = MergeReservations(argsMap["kube-reserved"], cfg.KubeReserved)
+	}
+	if cfg.SystemReserved != "" {
+		argsMap["system-reserved"] = MergeReservations(argsMap["system-reserved"], cfg.SystemReserved)
+	}
+
+	if cfg.ContainerLogMaxSize != "" {
+		argsMap["container-log-max-size"] = cfg.ContainerLogMaxSize
+	}
+	if cfg.ContainerLogMaxFiles > 0 {
+		argsMap["container-log-max-files"] = strconv.Itoa(cfg.ContainerLogMaxFiles)
+	}
+	if cfg.CPUManagerPolicy != "" {
+		argsMap["cpu-manager-policy"] = cfg.CPUManagerPolicy
+	}
+	if cfg.ReservedCPUs != "" {
+		argsMap["reserved-cpus"] = cfg.ReservedCPUs
+	}
+	if cfg.TuningProfile == config.TuningProfileLowLatency {
+		// do not share physical cores between exclusive containers and other workloads
+		argsMap["cpu-manager-policy-options"] = "full-pcpus-only=true"
+	}
+	if cfg.MemoryManagerPolicy != "" {
+		argsMap["memory-manager-policy"] = cfg.MemoryManagerPolicy
+	}
+	if cfg.ReservedMemory != "" {
+		argsMap["reserved-memory"] = cfg.ReservedMemory
+	}
+	if cfg.TopologyManagerPolicy != "" {
+		argsMap["topology-manager-policy"] = cfg.TopologyManagerPolicy
+	}
+	if cfg.TopologyManagerScope != "" {
+		argsMap["topology-manager-scope"] = cfg.TopologyManagerScope
+	}
+
+	// Swap behavior can only be set via the config file; all other settings are still passed as flags.
+	if cfg.SwapBehavior != "" {
+		argsMap["feature-gates"] = util.AddFeatureGate(argsMap["feature-gates"], "NodeSwap=true")
+		argsMap["config"] = cfg.KubeletConfig
+	}
+
+	// Copy the configured sysctls, so that appending the ServiceLB sysctls does not modify the agent config.
+	sysctls := append([]string{}, cfg.AllowedUnsafeSysctls...)
 	if !cfg.DisableServiceLB {
-		argsMap["allowed-unsafe-sysctls"] = "net.ipv4.ip_forward,net.ipv6.conf.all.forwarding"
+		for _, name := range []string{"net.ipv4.ip_forward", "net.ipv6.conf.all.forwarding"} {
+			if !slice.ContainsString(sysctls, name) {
+				sysctls = append(sysctls, name)
+			}
+		}
+	}
+	if len(sysctls) > 0 {
+		argsMap["allowed-unsafe-sysctls"] = strings.Join(sysctls, ",")
 	}
 
 	return argsMap
@@ -0,0 +1,94 @@
+//go:build linux
+// +build linux
+
+package agent
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	daemonconfig "github.com/k3s-io/k3s/pkg/daemons/config"
+)
+
+func Test_UnitKubeletArgsRootDir(t *testing.T) {
+	tests := []struct {
+		name        string
+		rootDir     string
+		wantRootDir string
+		wantCertDir string
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:        "root dir",
+			rootDir:     "/data/kubelet",
+			wantRootDir: "/data/kubelet",
+			wantCertDir: "/data/kubelet/pki",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &daemonconfig.Agent{
+				RootDir:      tt.rootDir,
+				PodManifests: filepath.Join(t.TempDir(), "pod-manifests"),
+			}
+			argsMap := kubeletArgs(cfg)
+			if argsMap["root-dir"] != tt.wantRootDir {
+				t.Errorf("kubeletArgs() root-dir = %s, want %s", argsMap["root-dir"], tt.wantRootDir)
+			}
+			if argsMap["cert-dir"] != tt.wantCertDir {
+				t.Errorf("kubeletArgs() cert-dir = %s, want %s", argsMap["cert-dir"], tt.wantCertDir)
+			}
+		})
+	}
+}
+
+func Test_UnitKubeletArgsAllowedUnsafeSysctls(t *testing.T) {
+	tests := []struct {
+		name             string
+		sysctls          []string
+		disableServiceLB bool
+		want             string
+	}{
+		{
+			name:             "none",
+			disableServiceLB: true,
+		},
+		{
+			name:             "configured",
+			sysctls:          []string{"net.core.somaxconn", "net.ipv4.tcp_*"},
+			disableServiceLB: true,
+			want:             "net.core.somaxconn,net.ipv4.tcp_*",
+		},
+		{
+			name: "servicelb",
+			want: "net.ipv4.ip_forward,net.ipv6.conf.all.forwarding",
+		},
+		{
+			name:    "configured and servicelb",
+			sysctls: []string{"net.core.somaxconn", "net.ipv4.ip_forward"},
+			want:    "net.core.somaxconn,net.ipv4.ip_forward,net.ipv6.conf.all.forwarding",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Leave spare capacity in the configured sysctls, so that appending to them in place would be detected.
+			sysctls := make([]string, len(tt.sysctls), len(tt.sysctls)+2)
+			copy(sysctls, tt.sysctls)
+			cfg := &daemonconfig.Agent{
+				AllowedUnsafeSysctls: sysctls,
+				DisableServiceLB:     tt.disableServiceLB,
+				PodManifests:         filepath.Join(t.TempDir(), "pod-manifests"),
+			}
+			argsMap := kubeletArgs(cfg)
+			if argsMap["allowed-unsafe-sysctls"] != tt.want {
+				t.Errorf("kubeletArgs() allowed-unsafe-sysctls = %s, want %s", argsMap["allowed-unsafe-sysctls"], tt.want)
+			}
+			if !reflect.DeepEqual(cfg.AllowedUnsafeSysctls[:cap(cfg.AllowedUnsafeSysctls)][len(tt.sysctls):], make([]string, 2)) {
+				t.Errorf("kubeletArgs() modified the configured sysctls: %v", cfg.AllowedUnsafeSysctls[:cap(cfg.AllowedUnsafeSysctls)])
+			}
+		})
+	}
+}
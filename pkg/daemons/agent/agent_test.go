@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	daemonconfig "github.com/k3s-io/k3s/pkg/daemons/config"
+)
+
+func Test_UnitImageCredProvAvailable(t *testing.T) {
+	tempDir := t.TempDir()
+	binDir := filepath.Join(tempDir, "bin")
+	configFile := filepath.Join(tempDir, "config.yaml")
+	if err := os.Mkdir(binDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configFile, []byte("kind: CredentialProviderConfig\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		binDir string
+		config string
+		want   bool
+	}{
+		{
+			name:   "Bin dir and config file exist",
+			binDir: binDir,
+			config: configFile,
+			want:   true,
+		},
+		{
+			name:   "Neither exists",
+			binDir: filepath.Join(tempDir, "missing"),
+			config: filepath.Join(tempDir, "missing.yaml"),
+			want:   false,
+		},
+		{
+			name:   "Config file missing",
+			binDir: binDir,
+			config: filepath.Join(tempDir, "missing.yaml"),
+			want:   false,
+		},
+		{
+			name:   "Bin dir is a file",
+			binDir: configFile,
+			config: configFile,
+			want:   false,
+		},
+		{
+			name:   "Config file is a directory",
+			binDir: binDir,
+			config: binDir,
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &daemonconfig.Agent{
+				ImageCredProvBinDir: tt.binDir,
+				ImageCredProvConfig: tt.config,
+			}
+			if got := ImageCredProvAvailable(cfg); got != tt.want {
+				t.Errorf("ImageCredProvAvailable() = %+v\nWant = %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,14 +4,22 @@
 package agent
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Microsoft/hcsshim"
+	"github.com/containernetworking/cni/pkg/invoke"
+	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/util"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/kubernetes/pkg/kubeapiserver/authorizer/modes"
 )
 
@@ -35,9 +43,73 @@ func kubeProxyArgs(cfg *config.Agent) map[string]string {
 		argsMap["hostname-override"] = cfg.NodeName
 	}
 
+	if cfg.NetworkName != "" {
+		argsMap["network-name"] = cfg.NetworkName
+		if sourceVIP, err := getSourceVIP(cfg); err != nil {
+			logrus.Errorf("Failed to reserve kube-proxy source VIP on network %s: %v", cfg.NetworkName, err)
+		} else if sourceVIP != "" {
+			argsMap["source-vip"] = sourceVIP
+		}
+	}
+
 	return argsMap
 }
 
+// getSourceVIP waits for the HNS network to be created by flannel and, if it is an overlay network,
+// reserves an address from the node's pod subnet for kube-proxy to use as the source of load-balanced
+// traffic. The address is allocated by the host-local IPAM plugin used by the flannel CNI plugin, so that
+// it is not also assigned to a pod.
+func getSourceVIP(cfg *config.Agent) (string, error) {
+	var network *hcsshim.HNSNetwork
+	err := wait.PollImmediateInfinite(5*time.Second, func() (bool, error) {
+		var err error
+		network, err = hcsshim.GetHNSNetworkByName(cfg.NetworkName)
+		if err != nil || len(network.Subnets) == 0 {
+			logrus.Infof("Waiting for HNS network %s to be created by flannel", cfg.NetworkName)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(network.Type, "Overlay") {
+		return "", nil
+	}
+
+	pluginPath, err := invoke.FindInPath("host-local", []string{cfg.CNIBinDir})
+	if err != nil {
+		return "", err
+	}
+	ipamConf := fmt.Sprintf(`{
+  "cniVersion":"1.0.0",
+  "name":"%s",
+  "ipam":{
+    "type":"host-local",
+    "ranges":[[{"subnet":"%s"}]]
+  }
+}`, cfg.NetworkName, network.Subnets[0].AddressPrefix)
+	args := &invoke.Args{
+		Command:     "ADD",
+		ContainerID: "kube-proxy-source-vip",
+		NetNS:       "none",
+		IfName:      "source-vip",
+		Path:        cfg.CNIBinDir,
+	}
+	r, err := invoke.ExecPluginWithResult(context.Background(), pluginPath, []byte(ipamConf), args, nil)
+	if err != nil {
+		return "", err
+	}
+	result, err := current.NewResultFromResult(r)
+	if err != nil {
+		return "", err
+	}
+	if len(result.IPs) == 0 {
+		return "", fmt.Errorf("host-local did not allocate an address from %s", network.Subnets[0].AddressPrefix)
+	}
+	return result.IPs[0].Address.IP.String(), nil
+}
+
 func kubeletArgs(cfg *config.Agent) map[string]string {
 	bindAddress := "127.0.0.1"
 	_, IPv6only, _ := util.GetFirstString([]string{cfg.NodeIP})
@@ -114,7 +186,6 @@ func kubeletArgs(cfg *config.Agent) map[string]string {
 
 	if ImageCredProvAvailable(cfg) {
 		logrus.Infof("Kubelet image credential provider bin dir and configuration file found.")
-		argsMap["feature-gates"] = util.AddFeatureGate(argsMap["feature-gates"], "KubeletCredentialProviders=true")
 		argsMap["image-credential-provider-bin-dir"] = cfg.ImageCredProvBinDir
 		argsMap["image-credential-provider-config"] = cfg.ImageCredProvConfig
 	}
@@ -122,5 +193,17 @@ func kubeletArgs(cfg *config.Agent) map[string]string {
 	if cfg.ProtectKernelDefaults {
 		argsMap["protect-kernel-defaults"] = "true"
 	}
+	if cfg.KubeReserved != "" {
+		argsMap["kube-reserved"] = cfg.KubeReserved
+	}
+	if cfg.SystemReserved != "" {
+		argsMap["system-reserved"] = cfg.SystemReserved
+	}
+	if cfg.ContainerLogMaxSize != "" {
+		argsMap["container-log-max-size"] = cfg.ContainerLogMaxSize
+	}
+	if cfg.ContainerLogMaxFiles > 0 {
+		argsMap["container-log-max-files"] = strconv.Itoa(cfg.ContainerLogMaxFiles)
+	}
 	return argsMap
 }
@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	ReserveResourcesAuto     = "auto"
+	ReserveResourcesMinimal  = "minimal"
+	ReserveResourcesStandard = "standard"
+	ReserveResourcesGenerous = "generous"
+)
+
+// reserveProfileScale contains the factor applied to the standard reservations for each profile.
+// The auto profile uses the standard reservations.
+var reserveProfileScale = map[string]float64{
+	ReserveResourcesAuto:     1,
+	ReserveResourcesMinimal:  0.5,
+	ReserveResourcesStandard: 1,
+	ReserveResourcesGenerous: 1.5,
+}
+
+// memoryTier reserves a fraction of node memory, up to the given size in MiB.
+type memoryTier struct {
+	size     uint64
+	fraction float64
+}
+
+// kubeReservedMemoryTiers are applied in order to the node's memory. Any memory not covered
+// by a tier is reserved at the rate of the final tier.
+var kubeReservedMemoryTiers = []memoryTier{
+	{size: 4096, fraction: 0.25},
+	{size: 4096, fraction: 0.20},
+	{size: 8192, fraction: 0.10},
+	{size: 114688, fraction: 0.06},
+	{size: 0, fraction: 0.02},
+}
+
+const (
+	// minKubeReservedMemory is reserved for nodes with less than 1GiB of memory, where
+	// the tiered calculation would not leave enough memory for the kubelet and containerd.
+	minKubeReservedMemory = 255
+	evictionMemory        = 100
+)
+
+// ReservedResources contains kubelet resource reservations, formatted for use as kubelet args.
+type ReservedResources struct {
+	KubeReserved   string
+	SystemReserved string
+	EvictionHard   string
+}
+
+// ValidateReserveResources returns an error if the reservation profile is not known.
+func ValidateReserveResources(profile string) error {
+	if _, ok := reserveProfileScale[profile]; !ok {
+		profiles := make([]string, 0, len(reserveProfileScale))
+		for p := range reserveProfileScale {
+			profiles = append(profiles, "'"+p+"'")
+		}
+		sort.Strings(profiles)
+		return fmt.Errorf("invalid reserve-resources %s; valid values are %s", profile, strings.Join(profiles, ", "))
+	}
+	return nil
+}
+
+// CalculateReservedResources computes kube-reserved, system-reserved, and memory eviction thresholds
+// for a node with the given number of CPUs and bytes of memory. Reservations grow with the size of
+// the node, but are a smaller proportion of the total on larger nodes.
+func CalculateReservedResources(profile string, cpus int, memory uint64) ReservedResources {
+	scale, ok := reserveProfileScale[profile]
+	if !ok {
+		scale = 1
+	}
+
+	kubeCPU := scaled(kubeReservedCPU(cpus), scale)
+	kubeMemory := scaled(kubeReservedMemory(memory/1024/1024), scale)
+
+	return ReservedResources{
+		KubeReserved:   fmt.Sprintf("cpu=%dm,memory=%dMi", kubeCPU, kubeMemory),
+		SystemReserved: fmt.Sprintf("cpu=%dm,memory=%dMi", kubeCPU/2, kubeMemory/2),
+		EvictionHard:   fmt.Sprintf("memory.available<%dMi", scaled(evictionMemory, scale)),
+	}
+}
+
+// kubeReservedCPU returns the millicores reserved for the given number of CPUs: 6% of the
+// first core, 1% of the second core, 0.5% of the next two cores, and 0.25% of any others.
+func kubeReservedCPU(cpus int) uint64 {
+	var millicores float64
+	for i := 1; i <= cpus; i++ {
+		switch {
+		case i == 1:
+			millicores += 60
+		case i == 2:
+			millicores += 10
+		case i <= 4:
+			millicores += 5
+		default:
+			millicores += 2.5
+		}
+	}
+	return uint64(millicores)
+}
+
+// kubeReservedMemory returns the MiB reserved for the given MiB of memory.
+func kubeReservedMemory(memory uint64) uint64 {
+	if memory < 1024 {
+		return minKubeReservedMemory
+	}
+	var reserved float64
+	remaining := memory
+	for _, tier := range kubeReservedMemoryTiers {
+		size := remaining
+		if tier.size != 0 && tier.size < size {
+			size = tier.size
+		}
+		reserved += float64(size) * tier.fraction
+		remaining -= size
+		if remaining == 0 {
+			break
+		}
+	}
+	return uint64(reserved)
+}
+
+// reservationResources are the resources that may be reserved with kube-reserved and system-reserved.
+var reservationResources = map[string]bool{
+	"cpu":               true,
+	"memory":            true,
+	"ephemeral-storage": true,
+	"pid":               true,
+}
+
+// ValidateReservation returns an error if the value is not a comma-separated list of resource=quantity pairs,
+// as accepted by the kubelet's kube-reserved and system-reserved flags.
+func ValidateReservation(value string) error {
+	for _, pair := range strings.Split(value, ",") {
+		name, quantity, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !reservationResources[name] {
+			return fmt.Errorf("invalid reservation %q; must be resource=quantity, where resource is one of cpu, memory, ephemeral-storage, or pid", pair)
+		}
+		if q, err := resource.ParseQuantity(quantity); err != nil || q.Sign() < 0 {
+			return fmt.Errorf("invalid quantity %q for %s reservation", quantity, name)
+		}
+	}
+	return nil
+}
+
+// MergeReservations returns the reservations with any resources in the overrides replacing the calculated
+// value, so that individual resources can be overridden while the rest are still calculated from the size of
+// the node. The overrides must have been validated with ValidateReservation.
+func MergeReservations(calculated, overrides string) string {
+	if overrides == "" {
+		return calculated
+	}
+	if calculated == "" {
+		return overrides
+	}
+	order := []string{}
+	values := map[string]string{}
+	for _, reservations := range []string{calculated, overrides} {
+		for _, pair := range strings.Split(reservations, ",") {
+			name, quantity, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			if _, ok := values[name]; !ok {
+				order = append(order, name)
+			}
+			values[name] = quantity
+		}
+	}
+	pairs := make([]string, 0, len(order))
+	for _, name := range order {
+		pairs = append(pairs, name+"="+values[name])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func scaled(value uint64, scale float64) uint64 {
+	return uint64(float64(value) * scale)
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_UnitCalculateReservedResources(t *testing.T) {
+	type args struct {
+		profile string
+		cpus    int
+		memory  uint64
+	}
+	tests := []struct {
+		name string
+		args args
+		want ReservedResources
+	}{
+		{
+			name: "Small node with auto profile",
+			args: args{
+				profile: ReserveResourcesAuto,
+				cpus:    1,
+				memory:  1024 * 1024 * 1024,
+			},
+			want: ReservedResources{
+				KubeReserved:   "cpu=60m,memory=256Mi",
+				SystemReserved: "cpu=30m,memory=128Mi",
+				EvictionHard:   "memory.available<100Mi",
+			},
+		},
+		{
+			name: "Medium node with standard profile",
+			args: args{
+				profile: ReserveResourcesStandard,
+				cpus:    4,
+				memory:  8 * 1024 * 1024 * 1024,
+			},
+			want: ReservedResources{
+				KubeReserved:   "cpu=80m,memory=1843Mi",
+				SystemReserved: "cpu=40m,memory=921Mi",
+				EvictionHard:   "memory.available<100Mi",
+			},
+		},
+		{
+			name: "Tiny node with minimal profile",
+			args: args{
+				profile: ReserveResourcesMinimal,
+				cpus:    2,
+				memory:  512 * 1024 * 1024,
+			},
+			want: ReservedResources{
+				KubeReserved:   "cpu=35m,memory=127Mi",
+				SystemReserved: "cpu=17m,memory=63Mi",
+				EvictionHard:   "memory.available<50Mi",
+			},
+		},
+		{
+			name: "Large node with generous profile",
+			args: args{
+				profile: ReserveResourcesGenerous,
+				cpus:    16,
+				memory:  64 * 1024 * 1024 * 1024,
+			},
+			want: ReservedResources{
+				KubeReserved:   "cpu=165m,memory=8416Mi",
+				SystemReserved: "cpu=82m,memory=4208Mi",
+				EvictionHard:   "memory.available<150Mi",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalculateReservedResources(tt.args.profile, tt.args.cpus, tt.args.memory); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CalculateReservedResources() = %+v\nWant = %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitValidateReservation(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{value: "cpu=100m,memory=300Mi"},
+		{value: "memory=1Gi, ephemeral-storage=1Gi, pid=100"},
+		{value: "memory", wantErr: true},
+		{value: "gpu=1", wantErr: true},
+		{value: "memory=lots", wantErr: true},
+		{value: "cpu=-100m", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if err := ValidateReservation(tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateReservation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_UnitMergeReservations(t *testing.T) {
+	tests := []struct {
+		name       string
+		calculated string
+		overrides  string
+		want       string
+	}{
+		{
+			name:       "No overrides",
+			calculated: "cpu=60m,memory=255Mi",
+			want:       "cpu=60m,memory=255Mi",
+		},
+		{
+			name:      "Overrides only",
+			overrides: "memory=300Mi",
+			want:      "memory=300Mi",
+		},
+		{
+			name:       "Override one resource",
+			calculated: "cpu=60m,memory=255Mi",
+			overrides:  "memory=300Mi",
+			want:       "cpu=60m,memory=300Mi",
+		},
+		{
+			name:       "Add resource",
+			calculated: "cpu=60m,memory=255Mi",
+			overrides:  "pid=1000, cpu=100m",
+			want:       "cpu=100m,memory=255Mi,pid=1000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MergeReservations(tt.calculated, tt.overrides); got != tt.want {
+				t.Errorf("MergeReservations() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
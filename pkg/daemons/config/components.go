@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Components that can be disabled with --disable-components. Individual controllers are disabled by
+// prefixing the controller name with the name of the controller manager that runs it, for example
+// controller-manager/ephemeral-volume or cloud-controller/cloud-node-lifecycle.
+const (
+	ComponentAPIServer         = "apiserver"
+	ComponentETCD              = "etcd"
+	ComponentScheduler         = "scheduler"
+	ComponentControllerManager = "controller-manager"
+	ComponentCloudController   = "cloud-controller"
+	ComponentHelmController    = "helm-controller"
+	ComponentNetworkPolicy     = "network-policy"
+	ComponentKubeProxy         = "kube-proxy"
+	ComponentServiceLB         = "servicelb"
+
+	// ProfileControlPlaneOnly disables everything except the apiserver and datastore, so that an external
+	// scheduler or controllers can be run against an otherwise unmanaged cluster.
+	ProfileControlPlaneOnly = "control-plane-only"
+)
+
+var componentProfiles = map[string][]string{
+	ProfileControlPlaneOnly: {
+		ComponentScheduler,
+		ComponentControllerManager,
+		ComponentCloudController,
+		ComponentHelmController,
+		ComponentServiceLB,
+	},
+}
+
+var disableableComponents = map[string]bool{
+	ComponentAPIServer:         true,
+	ComponentETCD:              true,
+	ComponentScheduler:         true,
+	ComponentControllerManager: true,
+	ComponentCloudController:   true,
+	ComponentHelmController:    true,
+	ComponentNetworkPolicy:     true,
+	ComponentKubeProxy:         true,
+	ComponentServiceLB:         true,
+}
+
+// DisabledComponents are the components and controllers disabled by --disable-components.
+type DisabledComponents struct {
+	// Components are the disabled components, after expanding profiles
+	Components map[string]bool
+	// Controllers are the disabled controllers, keyed by the component that runs them
+	Controllers map[string][]string
+}
+
+// ParseDisabledComponents parses the values of --disable-components, which may be component names,
+// profiles, or controllers prefixed by the name of their controller manager.
+func ParseDisabledComponents(values []string) (*DisabledComponents, error) {
+	disabled := &DisabledComponents{
+		Components:  map[string]bool{},
+		Controllers: map[string][]string{},
+	}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if components, ok := componentProfiles[value]; ok {
+			for _, component := range components {
+				disabled.Components[component] = true
+			}
+			continue
+		}
+		if component, controller, ok := strings.Cut(value, "/"); ok {
+			if component != ComponentControllerManager && component != ComponentCloudController {
+				return nil, fmt.Errorf("invalid component %q: controllers can only be disabled in %s or %s", value, ComponentControllerManager, ComponentCloudController)
+			}
+			if controller == "" || strings.ContainsAny(controller, ",*/") || strings.HasPrefix(controller, "-") {
+				return nil, fmt.Errorf("invalid controller name in %q", value)
+			}
+			disabled.Controllers[component] = append(disabled.Controllers[component], controller)
+			continue
+		}
+		if !disableableComponents[value] {
+			return nil, fmt.Errorf("invalid component %q (valid items: %s)", value, strings.Join(ValidDisableComponents(), ", "))
+		}
+		disabled.Components[value] = true
+	}
+	return disabled, nil
+}
+
+// ValidDisableComponents returns the component and profile names accepted by --disable-components.
+func ValidDisableComponents() []string {
+	names := []string{}
+	for name := range disableableComponents {
+		names = append(names, name)
+	}
+	for name := range componentProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DisableControllersArg appends the disabled controllers to the value of a controller manager's
+// --controllers argument.
+func DisableControllersArg(controllers string, disabled []string) string {
+	for _, controller := range disabled {
+		controllers += ",-" + controller
+	}
+	return controllers
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_UnitParseDisabledComponents(t *testing.T) {
+	tests := []struct {
+		name            string
+		values          []string
+		wantComponents  map[string]bool
+		wantControllers map[string][]string
+		wantErr         bool
+	}{
+		{
+			name:            "empty",
+			values:          []string{""},
+			wantComponents:  map[string]bool{},
+			wantControllers: map[string][]string{},
+		},
+		{
+			name:            "components",
+			values:          []string{"scheduler", " cloud-controller "},
+			wantComponents:  map[string]bool{ComponentScheduler: true, ComponentCloudController: true},
+			wantControllers: map[string][]string{},
+		},
+		{
+			name:           "controllers",
+			values:         []string{"cloud-controller/cloud-node-lifecycle", "controller-manager/ephemeral-volume", "controller-manager/ttl-after-finished"},
+			wantComponents: map[string]bool{},
+			wantControllers: map[string][]string{
+				ComponentCloudController:   {"cloud-node-lifecycle"},
+				ComponentControllerManager: {"ephemeral-volume", "ttl-after-finished"},
+			},
+		},
+		{
+			name:   "profile",
+			values: []string{"control-plane-only"},
+			wantComponents: map[string]bool{
+				ComponentScheduler:         true,
+				ComponentControllerManager: true,
+				ComponentCloudController:   true,
+				ComponentHelmController:    true,
+				ComponentServiceLB:         true,
+			},
+			wantControllers: map[string][]string{},
+		},
+		{
+			name:    "unknown component",
+			values:  []string{"kubelet"},
+			wantErr: true,
+		},
+		{
+			name:    "controller in component without controllers",
+			values:  []string{"scheduler/default"},
+			wantErr: true,
+		},
+		{
+			name:    "controller with argument syntax",
+			values:  []string{"controller-manager/-route,*"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDisabledComponents(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDisabledComponents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Components, tt.wantComponents) {
+				t.Errorf("ParseDisabledComponents() components = %v, want %v", got.Components, tt.wantComponents)
+			}
+			if !reflect.DeepEqual(got.Controllers, tt.wantControllers) {
+				t.Errorf("ParseDisabledComponents() controllers = %v, want %v", got.Controllers, tt.wantControllers)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// KubeletSettingsConfigMap is the name of the ConfigMap in the kube-system namespace that contains
+// kubelet settings which are distributed to agents by the supervisor.
+var KubeletSettingsConfigMap = version.Program + "-kubelet-settings"
+
+// kubeletSetting describes a kubelet setting that may be changed at runtime via the supervisor.
+type kubeletSetting struct {
+	flag     string
+	validate func(string) error
+}
+
+// kubeletSettings maps the names of the settings that may be changed at runtime to the kubelet
+// flags that they are applied as. The names match the corresponding KubeletConfiguration fields.
+var kubeletSettings = map[string]kubeletSetting{
+	"evictionHard":                {flag: "eviction-hard", validate: validateEvictionThresholds},
+	"evictionSoft":                {flag: "eviction-soft", validate: validateEvictionThresholds},
+	"evictionSoftGracePeriod":     {flag: "eviction-soft-grace-period", validate: validateEvictionGracePeriods},
+	"imageGCHighThresholdPercent": {flag: "image-gc-high-threshold", validate: validatePercent},
+	"imageGCLowThresholdPercent":  {flag: "image-gc-low-threshold", validate: validatePercent},
+	"imageMinimumGCAge":           {flag: "minimum-image-ttl-duration", validate: validateDuration},
+	"maxPods":                     {flag: "max-pods", validate: validateNonNegativeInt},
+	"logVerbosity":                {flag: "v", validate: validateNonNegativeInt},
+}
+
+// ValidateKubeletSettings returns an error if any of the settings are not supported, or have invalid values.
+func ValidateKubeletSettings(settings map[string]string) error {
+	for name, value := range settings {
+		setting, ok := kubeletSettings[name]
+		if !ok {
+			names := make([]string, 0, len(kubeletSettings))
+			for n := range kubeletSettings {
+				names = append(names, "'"+n+"'")
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unsupported kubelet setting %s; supported settings are %s", name, strings.Join(names, ", "))
+		}
+		if err := setting.validate(value); err != nil {
+			return fmt.Errorf("invalid value for kubelet setting %s: %v", name, err)
+		}
+	}
+	if high, ok := settings["imageGCHighThresholdPercent"]; ok {
+		if low, ok := settings["imageGCLowThresholdPercent"]; ok {
+			h, _ := strconv.Atoi(high)
+			l, _ := strconv.Atoi(low)
+			if l > h {
+				return fmt.Errorf("kubelet setting imageGCLowThresholdPercent %d must not be greater than imageGCHighThresholdPercent %d", l, h)
+			}
+		}
+	}
+	return nil
+}
+
+// KubeletSettingsArgs converts the settings to kubelet args. Settings are assumed to have been validated.
+func KubeletSettingsArgs(settings map[string]string) map[string]string {
+	args := map[string]string{}
+	for name, value := range settings {
+		if setting, ok := kubeletSettings[name]; ok {
+			args[setting.flag] = value
+		}
+	}
+	return args
+}
+
+// validateEvictionThresholds validates a list of thresholds in the form 'memory.available<100Mi,nodefs.available<10%'.
+func validateEvictionThresholds(value string) error {
+	for _, threshold := range strings.Split(value, ",") {
+		signal, quantity, ok := strings.Cut(strings.TrimSpace(threshold), "<")
+		if !ok || signal == "" {
+			return fmt.Errorf("threshold %q must be in the form signal<quantity", threshold)
+		}
+		if strings.HasSuffix(quantity, "%") {
+			if _, err := strconv.ParseFloat(strings.TrimSuffix(quantity, "%"), 64); err != nil {
+				return fmt.Errorf("threshold %q has invalid percentage", threshold)
+			}
+		} else if _, err := resource.ParseQuantity(quantity); err != nil {
+			return fmt.Errorf("threshold %q has invalid quantity: %v", threshold, err)
+		}
+	}
+	return nil
+}
+
+// validateEvictionGracePeriods validates a list of grace periods in the form 'memory.available=1m30s'.
+func validateEvictionGracePeriods(value string) error {
+	for _, period := range strings.Split(value, ",") {
+		signal, duration, ok := strings.Cut(strings.TrimSpace(period), "=")
+		if !ok || signal == "" {
+			return fmt.Errorf("grace period %q must be in the form signal=duration", period)
+		}
+		if err := validateDuration(duration); err != nil {
+			return fmt.Errorf("grace period %q has invalid duration: %v", period, err)
+		}
+	}
+	return nil
+}
+
+func validatePercent(value string) error {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	if i < 0 || i > 100 {
+		return fmt.Errorf("%d is not between 0 and 100", i)
+	}
+	return nil
+}
+
+func validateNonNegativeInt(value string) error {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	if i < 0 {
+		return fmt.Errorf("%d is negative", i)
+	}
+	return nil
+}
+
+func validateDuration(value string) error {
+	_, err := time.ParseDuration(value)
+	return err
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_UnitValidateKubeletSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "No settings",
+			settings: map[string]string{},
+		},
+		{
+			name: "Valid settings",
+			settings: map[string]string{
+				"evictionHard":                "memory.available<100Mi,nodefs.available<10%",
+				"evictionSoft":                "memory.available<200Mi",
+				"evictionSoftGracePeriod":     "memory.available=1m30s",
+				"imageGCHighThresholdPercent": "80",
+				"imageGCLowThresholdPercent":  "70",
+				"imageMinimumGCAge":           "5m",
+				"maxPods":                     "200",
+				"logVerbosity":                "4",
+			},
+		},
+		{
+			name:     "Unsupported setting",
+			settings: map[string]string{"cgroupDriver": "systemd"},
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid eviction threshold",
+			settings: map[string]string{"evictionHard": "memory.available=100Mi"},
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid eviction quantity",
+			settings: map[string]string{"evictionHard": "memory.available<lots"},
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid grace period",
+			settings: map[string]string{"evictionSoftGracePeriod": "memory.available=soon"},
+			wantErr:  true,
+		},
+		{
+			name:     "Percent out of range",
+			settings: map[string]string{"imageGCHighThresholdPercent": "101"},
+			wantErr:  true,
+		},
+		{
+			name: "Low threshold above high threshold",
+			settings: map[string]string{
+				"imageGCHighThresholdPercent": "70",
+				"imageGCLowThresholdPercent":  "80",
+			},
+			wantErr: true,
+		},
+		{
+			name:     "Negative max pods",
+			settings: map[string]string{"maxPods": "-1"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateKubeletSettings(tt.settings); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateKubeletSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_UnitKubeletSettingsArgs(t *testing.T) {
+	settings := map[string]string{
+		"evictionHard": "memory.available<100Mi",
+		"maxPods":      "200",
+		"logVerbosity": "2",
+	}
+	want := map[string]string{
+		"eviction-hard": "memory.available<100Mi",
+		"max-pods":      "200",
+		"v":             "2",
+	}
+	if got := KubeletSettingsArgs(settings); !reflect.DeepEqual(got, want) {
+		t.Errorf("KubeletSettingsArgs() = %+v\nWant = %+v", got, want)
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net"
@@ -10,12 +11,19 @@ import (
 	"sync"
 	"time"
 
+	"github.com/k3s-io/k3s/pkg/agent/bwlimit"
+	"github.com/k3s-io/k3s/pkg/generated/controllers/k3s.cattle.io"
+	"github.com/k3s-io/k3s/pkg/kine"
+	"github.com/k3s-io/k3s/pkg/nsdefaults"
+	"github.com/k3s-io/k3s/pkg/registrypolicy"
 	"github.com/k3s-io/k3s/pkg/util"
 	"github.com/k3s-io/kine/pkg/endpoint"
 	"github.com/rancher/wrangler/pkg/generated/controllers/core"
 	"github.com/rancher/wrangler/pkg/leader"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
+	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/config/v1"
 	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/client-go/kubernetes"
 	utilsnet "k8s.io/utils/net"
 )
 
@@ -25,12 +33,37 @@ const (
 	FlannelBackendHostGW          = "host-gw"
 	FlannelBackendIPSEC           = "ipsec"
 	FlannelBackendWireguardNative = "wireguard-native"
-	EgressSelectorModeAgent       = "agent"
-	EgressSelectorModeCluster     = "cluster"
-	EgressSelectorModeDisabled    = "disabled"
-	EgressSelectorModePod         = "pod"
+	CNIFlannel                    = "flannel"
+	CNICalico                     = "calico"
+	CNICilium                     = "cilium"
+	CNINone                       = "none"
+	EgressSelectorModeAgent       = "agent"    // tunnel apiserver connections to kubelets only
+	EgressSelectorModeCluster     = "cluster"  // also tunnel connections to addresses within the cluster CIDR
+	EgressSelectorModeDisabled    = "disabled" // connect directly to kubelets and pods
+	EgressSelectorModePod         = "pod"      // also tunnel connections to pods, as tracked by the agent
+	SwapBehaviorLimited           = "LimitedSwap"
+	SwapBehaviorUnlimited         = "UnlimitedSwap"
+	CPUManagerPolicyNone          = "none"
+	CPUManagerPolicyStatic        = "static"
+	MemoryManagerPolicyNone       = "None"
+	MemoryManagerPolicyStatic     = "Static"
+	TuningProfileLowLatency       = "low-latency"
+	LeaderElectionPreferred       = "preferred"     // take leadership as soon as the lease is available
+	LeaderElectionStandby         = "standby"       // take leadership only if no preferred server has taken it after a grace period
+	LeaderElectionNever           = "never"         // do not run leader-elected controllers
+	NodeLocalDNSAddress           = "169.254.20.10" // link-local address of the node-local DNS cache
+	AuditLogModeNone              = "none"          // do not write an audit log
+	AuditLogModeMetadata          = "metadata"      // log request metadata only
+	AuditLogModeRequest           = "request"       // also log the body of write requests
+	AuditLogModeFull              = "full"          // also log the body of responses to write requests
+	PodSecurityProfilePrivileged  = "privileged"    // allow all pods
+	PodSecurityProfileBaseline    = "baseline"      // prevent known privilege escalations
+	PodSecurityProfileRestricted  = "restricted"    // also enforce pod hardening best practices
+	NodeJoinApprovalAuto          = "auto"          // issue certificates to any node with a valid token
+	NodeJoinApprovalManual        = "manual"        // issue certificates to new nodes only once their join request is approved
 	CertificateRenewDays          = 90
 	StreamServerPort              = "10010"
+	LogsServerPort                = "10011" // served by agents through the tunnel only, and not listened on
 )
 
 type Node struct {
@@ -45,6 +78,7 @@ type Node struct {
 	FlannelIface             *net.Interface
 	FlannelIPv6Masq          bool
 	FlannelExternalIP        bool
+	FlannelKeyRotation       time.Duration
 	EgressSelectorMode       string
 	Containerd               Containerd
 	CRIDockerd               CRIDockerd
@@ -56,15 +90,22 @@ type Node struct {
 }
 
 type Containerd struct {
-	Address  string
-	Log      string
-	Root     string
-	State    string
-	Config   string
-	Opt      string
-	Template string
-	SELinux  bool
-	Debug    bool
+	Address       string
+	Log           string
+	LogMaxSize    int
+	LogMaxBackups int
+	Root          string
+	State         string
+	Config        string
+	Registry      string
+	Opt           string
+	Template      string
+	SELinux       bool
+	Debug         bool
+	// RuntimeSearchPaths are the directories searched for additional container runtimes and shims.
+	RuntimeSearchPaths []string
+	// Runtimes are the names of the additional runtime handlers found and added to the containerd config.
+	Runtimes []string
 }
 
 type CRIDockerd struct {
@@ -87,6 +128,7 @@ type Agent struct {
 	ClusterCIDRs            []*net.IPNet
 	ClusterDNS              net.IP
 	ClusterDNSs             []net.IP
+	NodeLocalDNS            net.IP
 	ClusterDomain           string
 	ResolvConf              string
 	RootDir                 string
@@ -103,6 +145,7 @@ type Agent struct {
 	ClientCA                string
 	CNIBinDir               string
 	CNIConfDir              string
+	NetworkName             string
 	ExtraKubeletArgs        []string
 	ExtraKubeProxyArgs      []string
 	PauseImage              string
@@ -120,33 +163,87 @@ type Agent struct {
 	AirgapExtraRegistry     []string
 	DisableCCM              bool
 	DisableNPC              bool
+	NetworkPolicyLogging    bool
 	Rootless                bool
 	ProtectKernelDefaults   bool
+	FailSwapOn              bool
+	SwapBehavior            string
+	KubeletConfig           string
+	ReserveResources        string
+	KubeReserved            string
+	SystemReserved          string
+	CPUManagerPolicy        string
+	ReservedCPUs            string
+	MemoryManagerPolicy     string
+	ReservedMemory          string
+	TopologyManagerPolicy   string
+	TopologyManagerScope    string
+	TuningProfile           string
+	HugePages               []string
+	AllowedUnsafeSysctls    []string
+	ContainerLogMaxSize     string
+	ContainerLogMaxFiles    int
+	ImageGCHighThreshold    int
+	ImageGCLowThreshold     int
+	PinPreloadedImages      bool
+	KubeletSettings         map[string]string
 	DisableServiceLB        bool
 	EnableIPv4              bool
 	EnableIPv6              bool
+	// NodeExternalIPDiscovery lists the methods used to discover the node's external IP, if not configured
+	NodeExternalIPDiscovery  []string
+	NodeExternalIPSTUNServer string
+	// LocalRegistryListen is the address of the writable local registry backed by containerd, if enabled
+	LocalRegistryListen string
+	LocalRegistryAuth   string
+	// LocalRegistryName is the registry name under which images pushed to the local registry are stored
+	LocalRegistryName string
+	// RegistryHealthInterval is the interval at which registry mirror endpoints are probed, or 0 if disabled
+	RegistryHealthInterval time.Duration
+	RegistryHealthTimeout  time.Duration
+	// RegistryAuthSecret enables loading registry credentials from a Secret, instead of only from the private registry config
+	RegistryAuthSecret bool
+	// CertificateExpiryWindow is the window within which an expiring certificate sets the node condition, or 0 if disabled
+	CertificateExpiryWindow time.Duration
+	// ImagePullBandwidth are the bandwidth limits applied to image pulls by containerd
+	ImagePullBandwidth bwlimit.Limits
 }
 
 // CriticalControlArgs contains parameters that all control plane nodes in HA must share
 // The cli tag is used to provide better error information to the user on mismatch
 type CriticalControlArgs struct {
-	ClusterDNSs           []net.IP     `cli:"cluster-dns"`
-	ClusterIPRanges       []*net.IPNet `cli:"cluster-cidr"`
-	ClusterDNS            net.IP       `cli:"cluster-dns"`
-	ClusterDomain         string       `cli:"cluster-domain"`
-	ClusterIPRange        *net.IPNet   `cli:"cluster-cidr"`
-	DisableCCM            bool         `cli:"disable-cloud-controller"`
-	DisableHelmController bool         `cli:"disable-helm-controller"`
-	DisableNPC            bool         `cli:"disable-network-policy"`
-	DisableServiceLB      bool         `cli:"disable-service-lb"`
-	EncryptSecrets        bool         `cli:"secrets-encryption"`
-	MultiClusterCIDR      bool         `cli:"multi-cluster-cidr"`
-	FlannelBackend        string       `cli:"flannel-backend"`
-	FlannelIPv6Masq       bool         `cli:"flannel-ipv6-masq"`
-	FlannelExternalIP     bool         `cli:"flannel-external-ip"`
-	EgressSelectorMode    string       `cli:"egress-selector-mode"`
-	ServiceIPRange        *net.IPNet   `cli:"service-cidr"`
-	ServiceIPRanges       []*net.IPNet `cli:"service-cidr"`
+	ClusterDNSs           []net.IP      `cli:"cluster-dns"`
+	ClusterIPRanges       []*net.IPNet  `cli:"cluster-cidr"`
+	ClusterDNS            net.IP        `cli:"cluster-dns"`
+	ClusterDomain         string        `cli:"cluster-domain"`
+	ClusterIPRange        *net.IPNet    `cli:"cluster-cidr"`
+	DisableCCM            bool          `cli:"disable-cloud-controller"`
+	DisableHelmController bool          `cli:"disable-helm-controller"`
+	DisableNPC            bool          `cli:"disable-network-policy"`
+	DisableServiceLB      bool          `cli:"disable-service-lb"`
+	CNI                   string        `cli:"cni"`
+	EncryptSecrets        bool          `cli:"secrets-encryption"`
+	MultiClusterCIDR      bool          `cli:"multi-cluster-cidr"`
+	FlannelBackend        string        `cli:"flannel-backend"`
+	FlannelIPv6Masq       bool          `cli:"flannel-ipv6-masq"`
+	FlannelExternalIP     bool          `cli:"flannel-external-ip"`
+	FlannelKeyRotation    time.Duration `cli:"flannel-key-rotation-interval"`
+	EgressSelectorMode    string        `cli:"egress-selector-mode"`
+	RegistryPolicyMode    string        `cli:"registry-policy-mode"`
+	ServiceIPRange        *net.IPNet    `cli:"service-cidr"`
+	ServiceIPRanges       []*net.IPNet  `cli:"service-cidr"`
+}
+
+// OIDC configures the apiserver to authenticate users with ID tokens issued by an OpenID Connect provider.
+type OIDC struct {
+	IssuerURL      string
+	ClientID       string
+	CAFile         string
+	UsernameClaim  string
+	UsernamePrefix string
+	GroupsClaim    string
+	GroupsPrefix   string
+	RequiredClaims []string
 }
 
 type Control struct {
@@ -158,63 +255,115 @@ type Control struct {
 	// The port which custom k3s API runs on
 	SupervisorPort int
 	// The port which kube-apiserver runs on
-	APIServerPort            int
-	APIServerBindAddress     string
-	AgentToken               string `json:"-"`
-	Token                    string `json:"-"`
-	ServiceNodePortRange     *utilnet.PortRange
-	KubeConfigOutput         string
-	KubeConfigMode           string
-	DataDir                  string
-	Datastore                endpoint.Config `json:"-"`
-	Disables                 map[string]bool
-	DisableAPIServer         bool
-	DisableControllerManager bool
-	DisableETCD              bool
-	DisableKubeProxy         bool
-	DisableScheduler         bool
-	DisableServiceLB         bool
-	Rootless                 bool
-	ServiceLBNamespace       string
-	EnablePProf              bool
-	ExtraAPIArgs             []string
-	ExtraControllerArgs      []string
-	ExtraCloudControllerArgs []string
-	ExtraEtcdArgs            []string
-	ExtraSchedulerAPIArgs    []string
-	NoLeaderElect            bool
-	JoinURL                  string
-	IPSECPSK                 string
-	DefaultLocalStoragePath  string
-	Skips                    map[string]bool
-	SystemDefaultRegistry    string
-	ClusterInit              bool
-	ClusterReset             bool
-	ClusterResetRestorePath  string
-	EncryptForce             bool
-	EncryptSkip              bool
-	TLSMinVersion            uint16
-	TLSCipherSuites          []uint16
-	EtcdSnapshotName         string        `json:"-"`
-	EtcdDisableSnapshots     bool          `json:"-"`
-	EtcdExposeMetrics        bool          `json:"-"`
-	EtcdSnapshotDir          string        `json:"-"`
-	EtcdSnapshotCron         string        `json:"-"`
-	EtcdSnapshotRetention    int           `json:"-"`
-	EtcdSnapshotCompress     bool          `json:"-"`
-	EtcdListFormat           string        `json:"-"`
-	EtcdS3                   bool          `json:"-"`
-	EtcdS3Endpoint           string        `json:"-"`
-	EtcdS3EndpointCA         string        `json:"-"`
-	EtcdS3SkipSSLVerify      bool          `json:"-"`
-	EtcdS3AccessKey          string        `json:"-"`
-	EtcdS3SecretKey          string        `json:"-"`
-	EtcdS3BucketName         string        `json:"-"`
-	EtcdS3Region             string        `json:"-"`
-	EtcdS3Folder             string        `json:"-"`
-	EtcdS3Timeout            time.Duration `json:"-"`
-	EtcdS3Insecure           bool          `json:"-"`
-	ServerNodeName           string
+	APIServerPort              int
+	APIServerBindAddress       string
+	SupervisorBindAddress      string
+	SupervisorAdvertiseAddress string
+	SupervisorSANs             []string
+	AgentToken                 string `json:"-"`
+	Token                      string `json:"-"`
+	ServiceNodePortRange       *utilnet.PortRange
+	KubeConfigOutput           string
+	KubeConfigMode             string
+	DataDir                    string
+	Datastore                  endpoint.Config `json:"-"`
+	KineStandalone             bool
+	KineLimits                 kine.Limits
+	KineSlowSQLThreshold       time.Duration
+	Disables                   map[string]bool
+	DisableAPIServer           bool
+	DisableControllerManager   bool
+	DisableETCD                bool
+	DisableKubeProxy           bool
+	DisableScheduler           bool
+	DisableServiceLB           bool
+	Rootless                   bool
+	ServiceLBNamespace         string
+	EnablePProf                bool
+	ExtraAPIArgs               []string
+	ExtraControllerArgs        []string
+	ExtraCloudControllerArgs   []string
+	// DisableControllers and DisableCloudControllers are the controllers that are not run by the
+	// kube-controller-manager and cloud-controller-manager
+	DisableControllers          []string
+	DisableCloudControllers     []string
+	ExtraEtcdArgs               []string
+	ExtraSchedulerAPIArgs       []string
+	NoLeaderElect               bool
+	LeaderElectionPriority      string
+	JoinURL                     string
+	IPSECPSK                    string
+	DefaultLocalStoragePath     string
+	Skips                       map[string]bool
+	SystemDefaultRegistry       string
+	ClusterInit                 bool
+	ClusterReset                bool
+	ClusterResetRestorePath     string
+	ClusterResetRestoreTime     time.Time
+	EncryptForce                bool
+	EncryptSkip                 bool
+	EncryptResources            []string
+	EncryptKMSProvider          *apiserverconfigv1.KMSConfiguration
+	AuditLogMode                string
+	PodSecurityProfile          string
+	PodSecurityExemptNamespaces []string
+	AuthOIDC                    OIDC
+	AuthWebhookConfig           string
+	AuthWebhookCacheTTL         time.Duration
+	TLSMinVersion               uint16
+	TLSCipherSuites             []uint16
+	EtcdSnapshotName            string        `json:"-"`
+	EtcdDisableSnapshots        bool          `json:"-"`
+	EtcdExposeMetrics           bool          `json:"-"`
+	EtcdSnapshotDir             string        `json:"-"`
+	EtcdSnapshotCron            string        `json:"-"`
+	EtcdSnapshotRetention       int           `json:"-"`
+	EtcdSnapshotRetentionPolicy string        `json:"-"`
+	EtcdSnapshotCompress        bool          `json:"-"`
+	EtcdSnapshotMinFree         int           `json:"-"`
+	EtcdSnapshotPruneFirst      bool          `json:"-"`
+	EtcdSnapshotSigningKey      string        `json:"-"`
+	EtcdSnapshotVerificationKey string        `json:"-"`
+	EtcdSnapshotVerify          bool          `json:"-"`
+	EtcdDefragCron              string        `json:"-"`
+	EtcdDefragThreshold         int           `json:"-"`
+	EtcdListFormat              string        `json:"-"`
+	EtcdS3                      bool          `json:"-"`
+	EtcdS3Endpoint              string        `json:"-"`
+	EtcdS3EndpointCA            string        `json:"-"`
+	EtcdS3SkipSSLVerify         bool          `json:"-"`
+	EtcdS3AccessKey             string        `json:"-"`
+	EtcdS3SecretKey             string        `json:"-"`
+	EtcdS3BucketName            string        `json:"-"`
+	EtcdS3Region                string        `json:"-"`
+	EtcdS3Folder                string        `json:"-"`
+	EtcdS3Timeout               time.Duration `json:"-"`
+	EtcdS3Insecure              bool          `json:"-"`
+	EtcdS3Incremental           bool          `json:"-"`
+	EtcdS3WALArchive            bool          `json:"-"`
+	EtcdS3WALArchiveInterval    time.Duration `json:"-"`
+	ServerNodeName              string
+	NodeWebhookURLs             []string      `json:"-"`
+	NodeWebhookNotReady         time.Duration `json:"-"`
+	StaleNodeCleanupDays        int           `json:"-"`
+	ClockSkewThreshold          time.Duration `json:"-"`
+	ClockSkewReject             bool          `json:"-"`
+	NodeJoinApproval            string        `json:"-"`
+	NvidiaDevicePluginConfig    string        `json:"-"`
+	NvidiaMIGPartedConfig       string        `json:"-"`
+	EnableGatewayAPI            bool          `json:"-"`
+	// NodeLocalDNS is set if the packaged node-local DNS cache is deployed, and agents should use it as the cluster DNS
+	NodeLocalDNS         bool
+	ShutdownDrainTimeout time.Duration `json:"-"`
+	SupervisorRateLimit  float64       `json:"-"`
+	SupervisorRateBurst  int           `json:"-"`
+	AuthFailureLimit     int           `json:"-"`
+	AuthLockoutDuration  time.Duration `json:"-"`
+
+	// RegistryPolicy restricts the registries that pod images may be pulled from
+	RegistryPolicy *registrypolicy.Policy `json:"-"`
+	// NamespaceDefaults are the quota and limit range templates applied to new namespaces
+	NamespaceDefaults *nsdefaults.Defaults `json:"-"`
 
 	BindAddress string
 	SANs        []string
@@ -229,7 +378,30 @@ type Control struct {
 // the loopback address is returned. If the urlSafe parameter is true, IPv6 addresses
 // are enclosed in square brackets, as per RFC2732.
 func (c *Control) BindAddressOrLoopback(chooseHostInterface, urlSafe bool) string {
-	ip := c.BindAddress
+	return c.addressOrLoopback(c.BindAddress, chooseHostInterface, urlSafe)
+}
+
+// SupervisorAddressOrLoopback returns an address suitable for embedding in supervisor URLs.
+// This is the supervisor bind address if one was configured; otherwise it behaves the same
+// as BindAddressOrLoopback.
+func (c *Control) SupervisorAddressOrLoopback(chooseHostInterface, urlSafe bool) string {
+	if c.SupervisorBindAddress != "" {
+		return c.addressOrLoopback(c.SupervisorBindAddress, chooseHostInterface, urlSafe)
+	}
+	return c.BindAddressOrLoopback(chooseHostInterface, urlSafe)
+}
+
+// SupervisorAdvertiseAddressOrLoopback returns an address suitable for embedding in supervisor URLs
+// given to other nodes. This is the supervisor advertise address if one was configured; otherwise it
+// behaves the same as SupervisorAddressOrLoopback.
+func (c *Control) SupervisorAdvertiseAddressOrLoopback(chooseHostInterface, urlSafe bool) string {
+	if c.SupervisorAdvertiseAddress != "" {
+		return c.addressOrLoopback(c.SupervisorAdvertiseAddress, chooseHostInterface, urlSafe)
+	}
+	return c.SupervisorAddressOrLoopback(chooseHostInterface, urlSafe)
+}
+
+func (c *Control) addressOrLoopback(ip string, chooseHostInterface, urlSafe bool) string {
 	if ip == "" && chooseHostInterface {
 		if hostIP, _ := utilnet.ChooseHostInterface(); len(hostIP) > 0 {
 			ip = hostIP.String()
@@ -281,7 +453,9 @@ type ControlRuntime struct {
 	APIServerReady                       <-chan struct{}
 	AgentReady                           <-chan struct{}
 	ETCDReady                            <-chan struct{}
+	DeployReady                          <-chan struct{}
 	StartupHooksWg                       *sync.WaitGroup
+	ShutdownWg                           sync.WaitGroup
 	ClusterControllerStarts              map[string]leader.Callback
 	LeaderElectedClusterControllerStarts map[string]leader.Callback
 
@@ -292,6 +466,7 @@ type ControlRuntime struct {
 	SigningClientCA   string
 	SigningServerCA   string
 	ServiceCurrentKey string
+	AdminSocket       string
 
 	KubeConfigAdmin           string
 	KubeConfigController      string
@@ -311,6 +486,10 @@ type ControlRuntime struct {
 
 	EgressSelectorConfig  string
 	CloudControllerConfig string
+	AuditPolicyConfig     string
+	PodSecurityConfig     string
+	AuthWebhookConfig     string
+	AuthOIDCCA            string
 
 	ClientAuthProxyCert string
 	ClientAuthProxyKey  string
@@ -337,7 +516,12 @@ type ControlRuntime struct {
 	ClientETCDKey            string
 
 	Core       *core.Factory
+	K3s        *k3s.Factory
+	K8s        kubernetes.Interface
 	EtcdConfig endpoint.ETCDConfig
+
+	// ClusterSnapshot takes an on-demand snapshot of the cluster datastore, if supported.
+	ClusterSnapshot func(ctx context.Context, config *Control) error
 }
 
 func NewRuntime(agentReady <-chan struct{}) *ControlRuntime {
@@ -0,0 +1,99 @@
+package deps
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	certutil "github.com/rancher/dynamiclistener/cert"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// RenderAuthWebhookConfig validates the authentication webhook kubeconfig, and renders it with any certificate
+// and key files that it references inlined. The apiserver only reads the config when it starts, so the rendered
+// copy does not depend on files that may change or be removed while it is running.
+func RenderAuthWebhookConfig(path string) ([]byte, error) {
+	webhookConfig, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load authentication webhook config %s: %w", path, err)
+	}
+	if webhookConfig.CurrentContext == "" {
+		return nil, fmt.Errorf("authentication webhook config %s does not set current-context", path)
+	}
+	// Paths in the config are relative to the file, as for any kubeconfig loaded by the apiserver.
+	if err := clientcmd.ResolveLocalPaths(webhookConfig); err != nil {
+		return nil, fmt.Errorf("failed to resolve paths in authentication webhook config %s: %w", path, err)
+	}
+	if err := clientcmd.Validate(*webhookConfig); err != nil {
+		return nil, fmt.Errorf("invalid authentication webhook config %s: %w", path, err)
+	}
+	if err := clientcmdapi.FlattenConfig(webhookConfig); err != nil {
+		return nil, fmt.Errorf("failed to read files referenced by authentication webhook config %s: %w", path, err)
+	}
+	return clientcmd.Write(*webhookConfig)
+}
+
+// ReadOIDCCA reads the CA bundle used to verify the OIDC issuer, returning an error if it does not contain any
+// certificates.
+func ReadOIDCCA(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OIDC CA file: %w", err)
+	}
+	if _, err := certutil.ParseCertsPEM(b); err != nil {
+		return nil, fmt.Errorf("invalid OIDC CA file %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// AuthConfigChanged returns true if the authentication webhook config or OIDC CA file have changed since they
+// were written to the data dir. An error is returned if the changed files are not valid.
+func AuthConfigChanged(controlConfig *config.Control) (bool, error) {
+	files, err := renderAuthConfig(controlConfig)
+	if err != nil {
+		return false, err
+	}
+	for path, b := range files {
+		if current, err := os.ReadFile(path); err != nil || !bytes.Equal(current, b) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// genAuthConfig writes the rendered authentication webhook config and OIDC CA file to the data dir, for use by
+// the apiserver.
+func genAuthConfig(controlConfig *config.Control) error {
+	files, err := renderAuthConfig(controlConfig)
+	if err != nil {
+		return err
+	}
+	for path, b := range files {
+		if err := os.WriteFile(path, b, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderAuthConfig returns the content of the authentication files to be written to the data dir, by path.
+func renderAuthConfig(controlConfig *config.Control) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if controlConfig.AuthWebhookConfig != "" {
+		b, err := RenderAuthWebhookConfig(controlConfig.AuthWebhookConfig)
+		if err != nil {
+			return nil, err
+		}
+		files[controlConfig.Runtime.AuthWebhookConfig] = b
+	}
+	if controlConfig.AuthOIDC.CAFile != "" {
+		b, err := ReadOIDCCA(controlConfig.AuthOIDC.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		files[controlConfig.Runtime.AuthOIDCCA] = b
+	}
+	return files, nil
+}
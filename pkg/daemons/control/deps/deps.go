@@ -15,6 +15,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"text/template"
 	"time"
@@ -29,11 +30,15 @@ import (
 	certutil "github.com/rancher/dynamiclistener/cert"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/apis/apiserver"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
 	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/config/v1"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/util/keyutil"
+	podsecurityv1 "k8s.io/pod-security-admission/admission/api/v1"
 )
 
 const (
@@ -117,6 +122,7 @@ func CreateRuntimeCertFiles(config *config.Control) {
 	runtime.SigningClientCA = filepath.Join(config.DataDir, "tls", "client-ca.nochain.crt")
 	runtime.SigningServerCA = filepath.Join(config.DataDir, "tls", "server-ca.nochain.crt")
 	runtime.ServiceCurrentKey = filepath.Join(config.DataDir, "tls", "service.current.key")
+	runtime.AdminSocket = filepath.Join(config.DataDir, "admin.sock")
 
 	runtime.KubeConfigAdmin = filepath.Join(config.DataDir, "cred", "admin.kubeconfig")
 	runtime.KubeConfigController = filepath.Join(config.DataDir, "cred", "controller.kubeconfig")
@@ -147,6 +153,10 @@ func CreateRuntimeCertFiles(config *config.Control) {
 
 	runtime.EgressSelectorConfig = filepath.Join(config.DataDir, "etc", "egress-selector-config.yaml")
 	runtime.CloudControllerConfig = filepath.Join(config.DataDir, "etc", "cloud-config.yaml")
+	runtime.AuditPolicyConfig = filepath.Join(config.DataDir, "etc", "audit-policy.yaml")
+	runtime.PodSecurityConfig = filepath.Join(config.DataDir, "etc", "pod-security-admission.yaml")
+	runtime.AuthWebhookConfig = filepath.Join(config.DataDir, "etc", "authentication-webhook.yaml")
+	runtime.AuthOIDCCA = filepath.Join(config.DataDir, "etc", "oidc-ca.crt")
 
 	runtime.ClientAuthProxyCert = filepath.Join(config.DataDir, "tls", "client-auth-proxy.crt")
 	runtime.ClientAuthProxyKey = filepath.Join(config.DataDir, "tls", "client-auth-proxy.key")
@@ -200,6 +210,18 @@ func GenServerDeps(config *config.Control) error {
 		return err
 	}
 
+	if err := genAuditPolicyConfig(config); err != nil {
+		return err
+	}
+
+	if err := genPodSecurityConfig(config); err != nil {
+		return err
+	}
+
+	if err := genAuthConfig(config); err != nil {
+		return err
+	}
+
 	return readTokens(runtime)
 }
 
@@ -708,13 +730,30 @@ func genEncryptionConfigAndState(controlConfig *config.Control) error {
 	if !controlConfig.EncryptSecrets {
 		return nil
 	}
+	resources := controlConfig.EncryptResources
+	if len(resources) == 0 {
+		resources = []string{"secrets"}
+	}
 	if s, err := os.Stat(runtime.EncryptionConfig); err == nil && s.Size() > 0 {
+		curEncryptionByte, err := os.ReadFile(runtime.EncryptionConfig)
+		if err != nil {
+			return err
+		}
+		// The existing config is shared by all servers, and is not modified here; changes to the list of resources
+		// are applied by the secrets-encrypt subcommands.
+		curEncryption := apiserverconfigv1.EncryptionConfiguration{}
+		if err := json.Unmarshal(curEncryptionByte, &curEncryption); err == nil && len(curEncryption.Resources) > 0 {
+			if !reflect.DeepEqual(curEncryption.Resources[0].Resources, resources) {
+				logrus.Warnf("Encrypted resources %v do not match configured resources %v; run '%s secrets-encrypt enable' and restart all servers, then run '%s secrets-encrypt reencrypt --force' to apply the change",
+					curEncryption.Resources[0].Resources, resources, version.Program, version.Program)
+			}
+			if kms := controlConfig.EncryptKMSProvider; kms != nil && !hasKMSProvider(curEncryption.Resources[0].Providers, kms) {
+				logrus.Warnf("KMS provider %s is not used by the current encryption config; run '%s secrets-encrypt enable' and restart all servers, then run '%s secrets-encrypt reencrypt --force' to encrypt existing data with the KMS provider",
+					kms.Name, version.Program, version.Program)
+			}
+		}
 		// On upgrade from older versions, the encryption hash may not exist, create it
 		if _, err := os.Stat(runtime.EncryptionHash); errors.Is(err, os.ErrNotExist) {
-			curEncryptionByte, err := os.ReadFile(runtime.EncryptionConfig)
-			if err != nil {
-				return err
-			}
 			encryptionConfigHash := sha256.Sum256(curEncryptionByte)
 			ann := "start-" + hex.EncodeToString(encryptionConfigHash[:])
 			return os.WriteFile(controlConfig.Runtime.EncryptionHash, []byte(ann), 0600)
@@ -722,12 +761,26 @@ func genEncryptionConfigAndState(controlConfig *config.Control) error {
 		return nil
 	}
 
-	aescbcKey := make([]byte, aescbcKeySize, aescbcKeySize)
-	_, err := cryptorand.Read(aescbcKey)
-	if err != nil {
-		return err
+	// When a KMS provider is configured, no keys are generated or stored on disk.
+	var provider apiserverconfigv1.ProviderConfiguration
+	if controlConfig.EncryptKMSProvider != nil {
+		provider.KMS = controlConfig.EncryptKMSProvider
+	} else {
+		aescbcKey := make([]byte, aescbcKeySize, aescbcKeySize)
+		_, err := cryptorand.Read(aescbcKey)
+		if err != nil {
+			return err
+		}
+		encodedKey := b64.StdEncoding.EncodeToString(aescbcKey)
+		provider.AESCBC = &apiserverconfigv1.AESConfiguration{
+			Keys: []apiserverconfigv1.Key{
+				{
+					Name:   "aescbckey",
+					Secret: encodedKey,
+				},
+			},
+		}
 	}
-	encodedKey := b64.StdEncoding.EncodeToString(aescbcKey)
 
 	encConfig := apiserverconfigv1.EncryptionConfiguration{
 		TypeMeta: metav1.TypeMeta{
@@ -736,18 +789,9 @@ func genEncryptionConfigAndState(controlConfig *config.Control) error {
 		},
 		Resources: []apiserverconfigv1.ResourceConfiguration{
 			{
-				Resources: []string{"secrets"},
+				Resources: resources,
 				Providers: []apiserverconfigv1.ProviderConfiguration{
-					{
-						AESCBC: &apiserverconfigv1.AESConfiguration{
-							Keys: []apiserverconfigv1.Key{
-								{
-									Name:   "aescbckey",
-									Secret: encodedKey,
-								},
-							},
-						},
-					},
+					provider,
 					{
 						Identity: &apiserverconfigv1.IdentityConfiguration{},
 					},
@@ -767,6 +811,16 @@ func genEncryptionConfigAndState(controlConfig *config.Control) error {
 	return os.WriteFile(controlConfig.Runtime.EncryptionHash, []byte(ann), 0600)
 }
 
+// hasKMSProvider returns true if the providers include a KMS provider with the same name as the configured provider.
+func hasKMSProvider(providers []apiserverconfigv1.ProviderConfiguration, kms *apiserverconfigv1.KMSConfiguration) bool {
+	for _, p := range providers {
+		if p.KMS != nil && p.KMS.Name == kms.Name {
+			return true
+		}
+	}
+	return false
+}
+
 func genEgressSelectorConfig(controlConfig *config.Control) error {
 	var clusterConn apiserver.Connection
 
@@ -779,7 +833,7 @@ func genEgressSelectorConfig(controlConfig *config.Control) error {
 			ProxyProtocol: apiserver.ProtocolHTTPConnect,
 			Transport: &apiserver.Transport{
 				TCP: &apiserver.TCPTransport{
-					URL: fmt.Sprintf("https://%s:%d", controlConfig.BindAddressOrLoopback(false, true), controlConfig.SupervisorPort),
+					URL: fmt.Sprintf("https://%s:%d", controlConfig.SupervisorAddressOrLoopback(false, true), controlConfig.SupervisorPort),
 					TLSConfig: &apiserver.TLSConfig{
 						CABundle:   controlConfig.Runtime.ServerCA,
 						ClientKey:  controlConfig.Runtime.ClientKubeAPIKey,
@@ -828,3 +882,142 @@ func genCloudConfig(controlConfig *config.Control) error {
 	return os.WriteFile(controlConfig.Runtime.CloudControllerConfig, b, 0600)
 
 }
+
+// genAuditPolicyConfig writes the audit policy for the audit log mode. Health checks and the high-volume
+// watches made by node components are not logged. Reads, and requests for secrets, configmaps and token
+// reviews, are only logged at the metadata level, so that credentials are never written to the log, and
+// the log is dominated by changes to the cluster rather than by controllers listing resources.
+func genAuditPolicyConfig(controlConfig *config.Control) error {
+	var level auditv1.Level
+	switch controlConfig.AuditLogMode {
+	case config.AuditLogModeNone, "":
+		return nil
+	case config.AuditLogModeMetadata:
+		level = auditv1.LevelMetadata
+	case config.AuditLogModeRequest:
+		level = auditv1.LevelRequest
+	case config.AuditLogModeFull:
+		level = auditv1.LevelRequestResponse
+	default:
+		return fmt.Errorf("invalid audit log mode %s", controlConfig.AuditLogMode)
+	}
+
+	policy := auditv1.Policy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Policy",
+			APIVersion: "audit.k8s.io/v1",
+		},
+		OmitStages: []auditv1.Stage{auditv1.StageRequestReceived},
+		Rules: []auditv1.PolicyRule{
+			{
+				Level:           auditv1.LevelNone,
+				NonResourceURLs: []string{"/healthz*", "/livez*", "/readyz*", "/version", "/metrics"},
+			},
+			{
+				Level: auditv1.LevelNone,
+				Users: []string{"system:kube-proxy"},
+				Verbs: []string{"watch"},
+				Resources: []auditv1.GroupResources{
+					{Group: "", Resources: []string{"endpoints", "services", "services/status"}},
+					{Group: "discovery.k8s.io", Resources: []string{"endpointslices"}},
+				},
+			},
+			{
+				Level:      auditv1.LevelNone,
+				UserGroups: []string{"system:nodes"},
+				Verbs:      []string{"get"},
+				Resources: []auditv1.GroupResources{
+					{Group: "", Resources: []string{"nodes", "nodes/status"}},
+				},
+			},
+			{
+				Level: auditv1.LevelNone,
+				Verbs: []string{"get", "update"},
+				Resources: []auditv1.GroupResources{
+					{Group: "coordination.k8s.io", Resources: []string{"leases"}},
+				},
+			},
+			{
+				Level: auditv1.LevelMetadata,
+				Resources: []auditv1.GroupResources{
+					{Group: "", Resources: []string{"secrets", "configmaps", "serviceaccounts/token"}},
+					{Group: "authentication.k8s.io", Resources: []string{"tokenreviews"}},
+				},
+			},
+			{
+				Level: auditv1.LevelMetadata,
+				Verbs: []string{"get", "list", "watch"},
+			},
+			{
+				Level: level,
+			},
+		},
+	}
+
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(controlConfig.Runtime.AuditPolicyConfig, b, 0600)
+}
+
+// genPodSecurityConfig writes the admission configuration for the pod security profile, which is enforced, audited
+// and warned on for all namespaces that are not exempt. The kube-system namespace and the servicelb namespace are
+// always exempt, as the packaged components and servicelb pods require host access that the baseline and restricted
+// profiles do not allow. The configuration is regenerated each time the server starts, so that changes to the
+// profile or exemptions take effect on restart.
+func genPodSecurityConfig(controlConfig *config.Control) error {
+	switch controlConfig.PodSecurityProfile {
+	case "":
+		return nil
+	case config.PodSecurityProfilePrivileged, config.PodSecurityProfileBaseline, config.PodSecurityProfileRestricted:
+	default:
+		return fmt.Errorf("invalid pod security profile %s", controlConfig.PodSecurityProfile)
+	}
+
+	namespaces := []string{metav1.NamespaceSystem}
+	if !controlConfig.DisableServiceLB && controlConfig.ServiceLBNamespace != "" {
+		namespaces = append(namespaces, controlConfig.ServiceLBNamespace)
+	}
+	namespaces = sets.NewString(append(namespaces, controlConfig.PodSecurityExemptNamespaces...)...).List()
+
+	podSecurityConfig := podsecurityv1.PodSecurityConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodSecurityConfiguration",
+			APIVersion: "pod-security.admission.config.k8s.io/v1",
+		},
+		Defaults: podsecurityv1.PodSecurityDefaults{
+			Enforce:        controlConfig.PodSecurityProfile,
+			EnforceVersion: "latest",
+			Audit:          controlConfig.PodSecurityProfile,
+			AuditVersion:   "latest",
+			Warn:           controlConfig.PodSecurityProfile,
+			WarnVersion:    "latest",
+		},
+		Exemptions: podsecurityv1.PodSecurityExemptions{
+			Namespaces: namespaces,
+		},
+	}
+	pb, err := json.Marshal(podSecurityConfig)
+	if err != nil {
+		return err
+	}
+
+	admissionConfig := apiserverv1.AdmissionConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AdmissionConfiguration",
+			APIVersion: "apiserver.config.k8s.io/v1",
+		},
+		Plugins: []apiserverv1.AdmissionPluginConfiguration{
+			{
+				Name:          "PodSecurity",
+				Configuration: &k8sruntime.Unknown{Raw: pb},
+			},
+		},
+	}
+	b, err := json.Marshal(admissionConfig)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(controlConfig.Runtime.PodSecurityConfig, b, 0600)
+}
@@ -1,11 +1,19 @@
 package deps
 
 import (
+	"encoding/json"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/k3s-io/k3s/pkg/daemons/config"
 	certutil "github.com/rancher/dynamiclistener/cert"
+	apiserverv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
+	auditv1 "k8s.io/apiserver/pkg/apis/audit/v1"
+	podsecurityv1 "k8s.io/pod-security-admission/admission/api/v1"
 )
 
 func Test_UnitAddSANs(t *testing.T) {
@@ -49,3 +57,216 @@ func Test_UnitAddSANs(t *testing.T) {
 		})
 	}
 }
+
+func Test_UnitGenAuditPolicyConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		wantLevel auditv1.Level
+		wantFile  bool
+		wantErr   bool
+	}{
+		{name: "none", mode: config.AuditLogModeNone},
+		{name: "unset"},
+		{name: "metadata", mode: config.AuditLogModeMetadata, wantLevel: auditv1.LevelMetadata, wantFile: true},
+		{name: "request", mode: config.AuditLogModeRequest, wantLevel: auditv1.LevelRequest, wantFile: true},
+		{name: "full", mode: config.AuditLogModeFull, wantLevel: auditv1.LevelRequestResponse, wantFile: true},
+		{name: "invalid", mode: "everything", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controlConfig := &config.Control{AuditLogMode: tt.mode, Runtime: &config.ControlRuntime{}}
+			controlConfig.Runtime.AuditPolicyConfig = filepath.Join(t.TempDir(), "audit-policy.yaml")
+			err := genAuditPolicyConfig(controlConfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("genAuditPolicyConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			b, err := os.ReadFile(controlConfig.Runtime.AuditPolicyConfig)
+			if !tt.wantFile {
+				if err == nil {
+					t.Errorf("genAuditPolicyConfig() wrote a policy for mode %q", tt.mode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			policy := auditv1.Policy{}
+			if err := json.Unmarshal(b, &policy); err != nil {
+				t.Fatal(err)
+			}
+			last := policy.Rules[len(policy.Rules)-1]
+			if last.Level != tt.wantLevel {
+				t.Errorf("genAuditPolicyConfig() default level = %s, want %s", last.Level, tt.wantLevel)
+			}
+			for _, rule := range policy.Rules[:len(policy.Rules)-1] {
+				if rule.Level != auditv1.LevelNone && rule.Level != auditv1.LevelMetadata {
+					t.Errorf("genAuditPolicyConfig() rule %v logs above the metadata level", rule)
+				}
+			}
+		})
+	}
+}
+
+func Test_UnitGenPodSecurityConfig(t *testing.T) {
+	tests := []struct {
+		name             string
+		profile          string
+		exempt           []string
+		disableServiceLB bool
+		wantNamespaces   []string
+		wantFile         bool
+		wantErr          bool
+	}{
+		{name: "unset"},
+		{name: "privileged", profile: config.PodSecurityProfilePrivileged, wantNamespaces: []string{"kube-system", "svclb"}, wantFile: true},
+		{name: "restricted with exemptions", profile: config.PodSecurityProfileRestricted, exempt: []string{"monitoring", "kube-system"}, wantNamespaces: []string{"kube-system", "monitoring", "svclb"}, wantFile: true},
+		{name: "baseline without servicelb", profile: config.PodSecurityProfileBaseline, disableServiceLB: true, wantNamespaces: []string{"kube-system"}, wantFile: true},
+		{name: "invalid", profile: "strict", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controlConfig := &config.Control{
+				PodSecurityProfile:          tt.profile,
+				PodSecurityExemptNamespaces: tt.exempt,
+				ServiceLBNamespace:          "svclb",
+				Runtime:                     &config.ControlRuntime{},
+			}
+			controlConfig.DisableServiceLB = tt.disableServiceLB
+			controlConfig.Runtime.PodSecurityConfig = filepath.Join(t.TempDir(), "pod-security-admission.yaml")
+			err := genPodSecurityConfig(controlConfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("genPodSecurityConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			b, err := os.ReadFile(controlConfig.Runtime.PodSecurityConfig)
+			if !tt.wantFile {
+				if err == nil {
+					t.Errorf("genPodSecurityConfig() wrote a configuration for profile %q", tt.profile)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			admissionConfig := apiserverv1.AdmissionConfiguration{}
+			if err := json.Unmarshal(b, &admissionConfig); err != nil {
+				t.Fatal(err)
+			}
+			if len(admissionConfig.Plugins) != 1 || admissionConfig.Plugins[0].Name != "PodSecurity" {
+				t.Fatalf("genPodSecurityConfig() plugins = %v, want PodSecurity", admissionConfig.Plugins)
+			}
+			podSecurityConfig := podsecurityv1.PodSecurityConfiguration{}
+			if err := json.Unmarshal(admissionConfig.Plugins[0].Configuration.Raw, &podSecurityConfig); err != nil {
+				t.Fatal(err)
+			}
+			if podSecurityConfig.Defaults.Enforce != tt.profile {
+				t.Errorf("genPodSecurityConfig() enforce = %s, want %s", podSecurityConfig.Defaults.Enforce, tt.profile)
+			}
+			if !reflect.DeepEqual(podSecurityConfig.Exemptions.Namespaces, tt.wantNamespaces) {
+				t.Errorf("genPodSecurityConfig() exempt namespaces = %v, want %v", podSecurityConfig.Exemptions.Namespaces, tt.wantNamespaces)
+			}
+		})
+	}
+}
+
+func Test_UnitGenAuthConfig(t *testing.T) {
+	caCert, _, err := certutil.GenerateSelfSignedCertKey("oidc.example.com", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhookConfig := func(currentContext string) string {
+		return `apiVersion: v1
+kind: Config
+clusters:
+- name: webhook
+  cluster:
+    server: https://authn.example.com/authenticate
+    certificate-authority: ca.crt
+users:
+- name: apiserver
+  user:
+    token: secret
+contexts:
+- name: webhook
+  context:
+    cluster: webhook
+    user: apiserver
+current-context: ` + currentContext + "\n"
+	}
+	tests := []struct {
+		name          string
+		webhookConfig string
+		oidcCA        []byte
+		wantErr       bool
+	}{
+		{name: "none"},
+		{name: "webhook", webhookConfig: webhookConfig("webhook")},
+		{name: "webhook without current context", webhookConfig: webhookConfig(`""`), wantErr: true},
+		{name: "webhook with unknown context", webhookConfig: webhookConfig("other"), wantErr: true},
+		{name: "OIDC CA", oidcCA: caCert},
+		{name: "invalid OIDC CA", oidcCA: []byte("not a certificate"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srcDir, dataDir := t.TempDir(), t.TempDir()
+			controlConfig := &config.Control{Runtime: &config.ControlRuntime{
+				AuthWebhookConfig: filepath.Join(dataDir, "authentication-webhook.yaml"),
+				AuthOIDCCA:        filepath.Join(dataDir, "oidc-ca.crt"),
+			}}
+			if err := os.WriteFile(filepath.Join(srcDir, "ca.crt"), caCert, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if tt.webhookConfig != "" {
+				controlConfig.AuthWebhookConfig = filepath.Join(srcDir, "webhook.yaml")
+				if err := os.WriteFile(controlConfig.AuthWebhookConfig, []byte(tt.webhookConfig), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.oidcCA != nil {
+				controlConfig.AuthOIDC.CAFile = filepath.Join(srcDir, "oidc-ca.crt")
+				if err := os.WriteFile(controlConfig.AuthOIDC.CAFile, tt.oidcCA, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := genAuthConfig(controlConfig); (err != nil) != tt.wantErr {
+				t.Fatalf("genAuthConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if changed, err := AuthConfigChanged(controlConfig); err != nil || changed {
+				t.Fatalf("AuthConfigChanged() = %v, %v after writing config", changed, err)
+			}
+			if tt.webhookConfig != "" {
+				b, err := os.ReadFile(controlConfig.Runtime.AuthWebhookConfig)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(b), "certificate-authority-data") {
+					t.Errorf("genAuthConfig() did not inline the webhook CA:\n%s", b)
+				}
+
+				// Changing a file referenced by the webhook config changes the rendered config.
+				otherCert, _, err := certutil.GenerateSelfSignedCertKey("authn.example.com", nil, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(srcDir, "ca.crt"), otherCert, 0600); err != nil {
+					t.Fatal(err)
+				}
+				if changed, err := AuthConfigChanged(controlConfig); err != nil || !changed {
+					t.Errorf("AuthConfigChanged() = %v, %v after changing webhook CA", changed, err)
+				}
+			}
+			if tt.oidcCA != nil {
+				if err := os.WriteFile(controlConfig.AuthOIDC.CAFile, []byte("not a certificate"), 0600); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := AuthConfigChanged(controlConfig); err == nil {
+					t.Errorf("AuthConfigChanged() succeeded after replacing OIDC CA with invalid file")
+				}
+			}
+		})
+	}
+}
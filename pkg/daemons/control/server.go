@@ -55,6 +55,9 @@ func Server(ctx context.Context, cfg *config.Control) error {
 
 	if !cfg.DisableAPIServer {
 		go waitForAPIServerHandlers(ctx, cfg.Runtime)
+		if cfg.AuthWebhookConfig != "" || cfg.AuthOIDC.CAFile != "" {
+			go watchAuthConfig(ctx, cfg)
+		}
 
 		if err := apiServer(ctx, cfg); err != nil {
 			return err
@@ -78,7 +81,9 @@ func Server(ctx context.Context, cfg *config.Control) error {
 		}
 	}
 
-	if !cfg.DisableCCM || !cfg.DisableServiceLB {
+	if cfg.LeaderElectionPriority == config.LeaderElectionNever && !cfg.NoLeaderElect {
+		logrus.Infof("Not running cloud-controller-manager due to leader-election-priority %s", cfg.LeaderElectionPriority)
+	} else if !cfg.DisableCCM || !cfg.DisableServiceLB {
 		if err := cloudControllerManager(ctx, cfg); err != nil {
 			return err
 		}
@@ -124,6 +129,7 @@ func controllerManager(ctx context.Context, cfg *config.Control) error {
 		argsMap["configure-cloud-routes"] = "false"
 		argsMap["controllers"] = argsMap["controllers"] + ",-service,-route,-cloud-node-lifecycle"
 	}
+	argsMap["controllers"] = config.DisableControllersArg(argsMap["controllers"], cfg.DisableControllers)
 
 	args := config.GetArgs(argsMap, cfg.ExtraControllerArgs)
 	logrus.Infof("Running kube-controller-manager %s", config.ArgString(args))
@@ -211,13 +217,108 @@ func apiServer(ctx context.Context, cfg *config.Control) error {
 	if cfg.EncryptSecrets {
 		argsMap["encryption-provider-config"] = runtime.EncryptionConfig
 	}
-	args := config.GetArgs(argsMap, cfg.ExtraAPIArgs)
+	if cfg.AuditLogMode != "" && cfg.AuditLogMode != config.AuditLogModeNone {
+		auditLogDir := filepath.Join(cfg.DataDir, "logs", "audit")
+		if err := os.MkdirAll(auditLogDir, 0700); err != nil {
+			return err
+		}
+		argsMap["audit-policy-file"] = runtime.AuditPolicyConfig
+		argsMap["audit-log-path"] = filepath.Join(auditLogDir, "audit.log")
+		argsMap["audit-log-format"] = "json"
+		argsMap["audit-log-maxsize"] = "100"
+		argsMap["audit-log-maxbackup"] = "10"
+		argsMap["audit-log-maxage"] = "30"
+		argsMap["audit-log-compress"] = "true"
+	}
+	if cfg.PodSecurityProfile != "" {
+		argsMap["admission-control-config-file"] = runtime.PodSecurityConfig
+	}
+	extraArgs := authArgs(argsMap, cfg)
+	args := config.GetArgs(argsMap, append(extraArgs, cfg.ExtraAPIArgs...))
 
 	logrus.Infof("Running kube-apiserver %s", config.ArgString(args))
 
 	return executor.APIServer(ctx, runtime.ETCDReady, args)
 }
 
+// authArgs sets the apiserver args for the OIDC and authentication webhook flags, and returns the args that may be
+// repeated, so that they can be passed with the user's extra args.
+func authArgs(argsMap map[string]string, cfg *config.Control) []string {
+	var extraArgs []string
+	if oidc := cfg.AuthOIDC; oidc.IssuerURL != "" {
+		argsMap["oidc-issuer-url"] = oidc.IssuerURL
+		argsMap["oidc-client-id"] = oidc.ClientID
+		argsMap["oidc-username-claim"] = oidc.UsernameClaim
+		if oidc.CAFile != "" {
+			argsMap["oidc-ca-file"] = cfg.Runtime.AuthOIDCCA
+		}
+		if oidc.UsernamePrefix != "" {
+			argsMap["oidc-username-prefix"] = oidc.UsernamePrefix
+		}
+		if oidc.GroupsClaim != "" {
+			argsMap["oidc-groups-claim"] = oidc.GroupsClaim
+		}
+		if oidc.GroupsPrefix != "" {
+			argsMap["oidc-groups-prefix"] = oidc.GroupsPrefix
+		}
+		for _, claim := range oidc.RequiredClaims {
+			extraArgs = append(extraArgs, "oidc-required-claim+="+claim)
+		}
+		logrus.Infof("Authenticating OIDC ID tokens issued by %s for client %s, with user names from the %s claim", oidc.IssuerURL, oidc.ClientID, oidc.UsernameClaim)
+	}
+	if cfg.AuthWebhookConfig != "" {
+		argsMap["authentication-token-webhook-config-file"] = cfg.Runtime.AuthWebhookConfig
+		argsMap["authentication-token-webhook-cache-ttl"] = cfg.AuthWebhookCacheTTL.String()
+		argsMap["authentication-token-webhook-version"] = "v1"
+		logrus.Infof("Authenticating bearer tokens with the webhook configured by %s", cfg.AuthWebhookConfig)
+	}
+	return extraArgs
+}
+
+// authConfigRestartSpread is the period over which servers restart to apply a changed authentication
+// configuration, so that servers whose files are updated at the same time do not all restart at once.
+const authConfigRestartSpread = 5 * time.Minute
+
+// watchAuthConfig restarts the server when the authentication webhook config or OIDC CA file change, as the
+// apiserver only reads them when it starts; this is not a hot reload. The server exits after a random delay, so
+// that it is restarted by the service manager with the new configuration. Changes that are not valid are logged,
+// and the apiserver continues to run with the current configuration. If the files are changed back before the
+// delay expires, the server does not restart.
+func watchAuthConfig(ctx context.Context, cfg *config.Control) {
+	var lastErr string
+	var restart <-chan time.Time
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-restart:
+			logrus.Fatalf("Authentication configuration changed; exiting so that %s can be restarted with the new configuration", version.Program)
+		case <-ticker.C:
+		}
+		changed, err := deps.AuthConfigChanged(cfg)
+		if err != nil {
+			if err.Error() != lastErr {
+				logrus.Errorf("Authentication configuration changed but is not valid; continuing with current configuration: %v", err)
+				lastErr = err.Error()
+			}
+			restart = nil
+			continue
+		}
+		lastErr = ""
+		switch {
+		case changed && restart == nil:
+			delay := time.Duration(rand.Int63n(int64(authConfigRestartSpread)))
+			logrus.Warnf("Authentication configuration changed; restarting %s in %s to apply it", version.Program, delay.Round(time.Second))
+			restart = time.After(delay)
+		case !changed && restart != nil:
+			logrus.Infof("Authentication configuration changed back to the current configuration; not restarting %s", version.Program)
+			restart = nil
+		}
+	}
+}
+
 func defaults(config *config.Control) {
 	if config.ClusterIPRange == nil {
 		_, clusterIPNet, _ := net.ParseCIDR("10.42.0.0/16")
@@ -271,6 +372,7 @@ func prepare(ctx context.Context, config *config.Control) error {
 	deps.CreateRuntimeCertFiles(config)
 
 	cluster := cluster.New(config)
+	config.Runtime.ClusterSnapshot = cluster.Snapshot
 
 	if err := cluster.Bootstrap(ctx, false); err != nil {
 		return err
@@ -327,6 +429,10 @@ func cloudControllerManager(ctx context.Context, cfg *config.Control) error {
 	if cfg.NoLeaderElect {
 		argsMap["leader-elect"] = "false"
 	}
+	if cfg.LeaderElectionPriority == config.LeaderElectionStandby {
+		// Extend the default 15s lease duration, so that preferred servers take over an expired lease first.
+		argsMap["leader-elect-lease-duration"] = "30s"
+	}
 	if cfg.DisableCCM {
 		argsMap["controllers"] = argsMap["controllers"] + ",-cloud-node,-cloud-node-lifecycle"
 		argsMap["secure-port"] = "0"
@@ -338,6 +444,7 @@ func cloudControllerManager(ctx context.Context, cfg *config.Control) error {
 	if cfg.DisableServiceLB {
 		argsMap["controllers"] = argsMap["controllers"] + ",-service"
 	}
+	argsMap["controllers"] = config.DisableControllersArg(argsMap["controllers"], cfg.DisableCloudControllers)
 	args := config.GetArgs(argsMap, cfg.ExtraCloudControllerArgs)
 
 	logrus.Infof("Running cloud-controller-manager %s", config.ArgString(args))
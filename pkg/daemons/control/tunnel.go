@@ -39,10 +39,11 @@ func loggingErrorWriter(rw http.ResponseWriter, req *http.Request, code int, err
 
 func setupTunnel(ctx context.Context, cfg *config.Control) (http.Handler, error) {
 	tunnel := &TunnelServer{
-		cidrs:  cidranger.NewPCTrieRanger(),
-		config: cfg,
-		server: remotedialer.New(authorizer, loggingErrorWriter),
-		egress: map[string]bool{},
+		cidrs:   cidranger.NewPCTrieRanger(),
+		config:  cfg,
+		server:  remotedialer.New(authorizer, loggingErrorWriter),
+		egress:  map[string]bool{},
+		nodeIPs: map[string][]net.IPNet{},
 	}
 	cfg.Runtime.ClusterControllerStarts["tunnel-server"] = tunnel.watch
 	return tunnel, nil
@@ -71,6 +72,9 @@ type TunnelServer struct {
 	config *config.Control
 	server *remotedialer.Server
 	egress map[string]bool
+	// nodeIPs tracks the addresses registered for each node, so that stale
+	// entries can be removed when node addresses change or nodes are deleted.
+	nodeIPs map[string][]net.IPNet
 }
 
 // explicit interface check
@@ -119,24 +123,32 @@ func (t *TunnelServer) watch(ctx context.Context) {
 }
 
 // onChangeNode updates the node address mappings by observing changes to nodes.
+// Addresses that are no longer listed on the node, or belong to deleted nodes, are removed
+// so that kubelet connections are not tunneled to a node that no longer owns the address.
 func (t *TunnelServer) onChangeNode(nodeName string, node *v1.Node) (*v1.Node, error) {
-	if node != nil {
-		t.Lock()
-		defer t.Unlock()
-		_, t.egress[nodeName] = node.Labels[nodeconfig.ClusterEgressLabel]
-		// Add all node IP addresses
-		for _, addr := range node.Status.Addresses {
-			if addr.Type == v1.NodeInternalIP || addr.Type == v1.NodeExternalIP {
-				if n, err := util.IPStringToIPNet(addr.Address); err == nil {
-					if node.DeletionTimestamp != nil {
-						logrus.Debugf("Tunnel server egress proxy removing Node %s IP %v", nodeName, n)
-						t.cidrs.Remove(*n)
-					} else {
-						logrus.Debugf("Tunnel server egress proxy updating Node %s IP %v", nodeName, n)
-						kubeletPort := strconv.FormatInt(int64(node.Status.DaemonEndpoints.KubeletEndpoint.Port), 10)
-						t.cidrs.Insert(&tunnelEntry{cidr: *n, nodeName: nodeName, kubeletPort: kubeletPort})
-					}
-				}
+	t.Lock()
+	defer t.Unlock()
+
+	for _, n := range t.nodeIPs[nodeName] {
+		t.cidrs.Remove(n)
+	}
+	delete(t.nodeIPs, nodeName)
+
+	if node == nil || node.DeletionTimestamp != nil {
+		logrus.Debugf("Tunnel server egress proxy removing Node %s", nodeName)
+		delete(t.egress, nodeName)
+		return node, nil
+	}
+
+	_, t.egress[nodeName] = node.Labels[nodeconfig.ClusterEgressLabel]
+	// Add all node IP addresses
+	kubeletPort := strconv.FormatInt(int64(node.Status.DaemonEndpoints.KubeletEndpoint.Port), 10)
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP || addr.Type == v1.NodeExternalIP {
+			if n, err := util.IPStringToIPNet(addr.Address); err == nil {
+				logrus.Debugf("Tunnel server egress proxy updating Node %s IP %v", nodeName, n)
+				t.cidrs.Insert(&tunnelEntry{cidr: *n, nodeName: nodeName, kubeletPort: kubeletPort})
+				t.nodeIPs[nodeName] = append(t.nodeIPs[nodeName], *n)
 			}
 		}
 	}
@@ -206,6 +218,12 @@ func (t *TunnelServer) serveConnect(resp http.ResponseWriter, req *http.Request)
 // tunnel connection, the agent may return an error if the agent's authorizer
 // denies the connection, or if there is some other error in actually dialing
 // the requested endpoint.
+// DialNode dials a port on the loopback address of a node through its tunnel. There is no fallback to a direct
+// connection, as this would connect to the local node instead of the requested one.
+func (t *TunnelServer) DialNode(ctx context.Context, nodeName, port string) (net.Conn, error) {
+	return t.server.Dialer(nodeName)(ctx, "tcp", net.JoinHostPort(t.config.Loopback(false), port))
+}
+
 func (t *TunnelServer) dialBackend(ctx context.Context, addr string) (net.Conn, error) {
 	host, port, err := net.SplitHostPort(addr)
 	if err != nil {
@@ -254,8 +272,9 @@ func (t *TunnelServer) dialBackend(ctx context.Context, addr string) (net.Conn,
 		dialContext := t.server.Dialer(nodeName)
 		if conn, err := dialContext(ctx, "tcp", addr); err != nil {
 			logrus.Debugf("Tunnel server egress proxy dial error: %v", err)
-			if toKubelet && strings.HasPrefix(err.Error(), "failed to find Session for client") {
-				// Don't have a session and we're trying to remote dial the kubelet via loopback, reject the connection.
+			if toKubelet {
+				// We're trying to remote dial the kubelet via loopback; falling back to a direct connection
+				// would connect to the local kubelet instead of the requested node, so reject the connection.
 				return conn, err
 			}
 			// any other error is ignored; fall back to to dialing directly.
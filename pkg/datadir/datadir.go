@@ -14,6 +14,8 @@ var (
 	DefaultHomeDataDir = "${HOME}/.rancher/" + version.Program
 	HomeConfig         = "${HOME}/.kube/" + version.Program + ".yaml"
 	GlobalConfig       = "/etc/rancher/" + version.Program + "/" + version.Program + ".yaml"
+	HomeContextDir     = "${HOME}/.kube/" + version.Program + "-contexts"
+	GlobalContextDir   = "/etc/rancher/" + version.Program + "/contexts"
 )
 
 func Resolve(dataDir string) (string, error) {
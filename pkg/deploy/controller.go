@@ -46,8 +46,10 @@ const (
 )
 
 // WatchFiles sets up an OnChange callback to start a periodic goroutine to watch files for changes once the controller has started up.
-func WatchFiles(ctx context.Context, client kubernetes.Interface, apply apply.Apply, addons controllersv1.AddonController, disables map[string]bool, bases ...string) error {
+// The ready channel is closed once the initial pass over all files has completed, even if some of them failed to apply.
+func WatchFiles(ctx context.Context, ready chan<- struct{}, client kubernetes.Interface, apply apply.Apply, addons controllersv1.AddonController, disables map[string]bool, bases ...string) error {
 	w := &watcher{
+		ready:      ready,
 		apply:      apply,
 		addonCache: addons.Cache(),
 		addons:     addons,
@@ -72,6 +74,8 @@ func WatchFiles(ctx context.Context, client kubernetes.Interface, apply apply.Ap
 type watcher struct {
 	sync.Mutex
 
+	ready      chan<- struct{}
+	readyOnce  sync.Once
 	apply      apply.Apply
 	addonCache controllersv1.AddonCache
 	addons     controllersv1.AddonClient
@@ -93,6 +97,7 @@ func (w *watcher) start(ctx context.Context, client kubernetes.Interface) {
 		} else {
 			logrus.Errorf("Failed to process config: %v", err)
 		}
+		w.readyOnce.Do(func() { close(w.ready) })
 		select {
 		case <-ctx.Done():
 			return
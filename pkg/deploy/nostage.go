@@ -6,3 +6,7 @@ package deploy
 func Stage(dataDir string, templateVars map[string]string, skips map[string]bool) error {
 	return nil
 }
+
+func Render(templateVars map[string]string, skips map[string]bool) (map[string][]byte, error) {
+	return map[string][]byte{}, nil
+}
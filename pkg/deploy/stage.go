@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -13,6 +14,32 @@ import (
 )
 
 func Stage(dataDir string, templateVars map[string]string, skips map[string]bool) error {
+	manifests, err := Render(templateVars, skips)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(manifests))
+	for name := range manifests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := filepath.Join(dataDir, name)
+		os.MkdirAll(filepath.Dir(p), 0700)
+		logrus.Info("Writing manifest: ", p)
+		if err := os.WriteFile(p, manifests[name], 0600); err != nil {
+			return errors.Wrapf(err, "failed to write to %s", name)
+		}
+	}
+
+	return nil
+}
+
+// Render returns the content of all packaged manifests that are not skipped, keyed by asset name,
+// with template variables replaced.
+func Render(templateVars map[string]string, skips map[string]bool) (map[string][]byte, error) {
+	manifests := map[string][]byte{}
 staging:
 	for _, name := range AssetNames() {
 		nameNoExtension := strings.TrimSuffix(name, filepath.Ext(name))
@@ -29,18 +56,13 @@ staging:
 
 		content, err := Asset(name)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		for k, v := range templateVars {
 			content = bytes.Replace(content, []byte(k), []byte(v), -1)
 		}
-		p := filepath.Join(dataDir, name)
-		os.MkdirAll(filepath.Dir(p), 0700)
-		logrus.Info("Writing manifest: ", p)
-		if err := os.WriteFile(p, content, 0600); err != nil {
-			return errors.Wrapf(err, "failed to write to %s", name)
-		}
+		manifests[name] = content
 	}
 
-	return nil
+	return manifests, nil
 }
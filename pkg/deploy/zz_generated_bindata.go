@@ -1,6 +1,8 @@
 // Code generated for package deploy by go-bindata DO NOT EDIT. (@generated)
 // sources:
+// manifests/calico.yaml
 // manifests/ccm.yaml
+// manifests/cilium.yaml
 // manifests/coredns.yaml
 // manifests/local-storage.yaml
 // manifests/metrics-server/aggregated-metrics-reader.yaml
@@ -10,7 +12,11 @@
 // manifests/metrics-server/metrics-server-deployment.yaml
 // manifests/metrics-server/metrics-server-service.yaml
 // manifests/metrics-server/resource-reader.yaml
+// manifests/nodelocaldns.yaml
+// manifests/npd.yaml
+// manifests/nvidia-device-plugin.yaml
 // manifests/rolebindings.yaml
+// manifests/runtimes.yaml
 // manifests/traefik.yaml
 //go:build !no_stage
 // +build !no_stage
@@ -91,7 +97,27 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _ccmYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x94\x41\x8f\x13\x31\x0c\x85\xef\xf3\x2b\xa2\x1e\x91\xd2\x15\xe2\x82\xe6\x08\x07\xee\x2b\xc1\xdd\x4d\x1e\xdd\xd0\x4c\x1c\xd9\x4e\x61\xf9\xf5\x68\x3a\x5d\x51\x3a\xdb\xd2\x16\x10\x7b\x8b\xac\xf8\xf3\xf3\x73\x62\xaa\xe9\x13\x44\x13\x97\xde\xc9\x8a\xc2\x92\x9a\x3d\xb0\xa4\xef\x64\x89\xcb\x72\xf3\x56\x97\x89\xef\xb6\xaf\xbb\x4d\x2a\xb1\x77\xef\x73\x53\x83\xdc\x73\x46\x37\xc0\x28\x92\x51\xdf\x39\x57\x68\x40\xef\x36\x6f\xd4\x87\xcc\x2d\xfa\xc0\xc5\x84\x73\x86\xf8\x81\x0a\xad\x21\x9d\xb4\x0c\xed\x3b\xef\xa8\xa6\x0f\xc2\xad\xea\x98\xe8\x5d\x60\x96\x98\xca\x61\xbd\xce\x39\x81\x72\x93\x80\xfd\xa5\x0c\x52\x68\xe7\xdc\x16\xb2\xda\xc7\xd6\xb0\x09\x20\x20\xc3\xee\xd8\x6a\x1c\x8f\xb3\x1a\x8b\xc5\x1c\x89\x2d\x8a\x1d\x21\x0f\x50\x95\x2c\x3c\x5c\x0d\x2d\x1c\x8f\x65\x2e\x5e\x2d\xae\xc8\xbd\x53\x23\x6b\xba\x0b\x28\x64\x9b\xc2\x61\xec\x00\x3b\xe9\xbb\x08\xfc\xc4\x99\xf2\x38\x9e\xf0\x31\x27\x9d\x0e\x5f\x6f\x42\xcf\xb4\x5d\xeb\xdd\x9e\x45\x21\x70\x3b\x37\x99\x51\xef\x65\x86\xd2\x00\xad\x34\x93\xf7\x3b\x16\xd5\xaa\x73\x5a\x24\x0c\x5c\x14\xc7\xca\x9e\x9f\x6f\x4c\x1a\x78\x0b\x79\xdc\x3f\xe9\xe7\x1e\x60\x89\x95\x53\x31\xcd\x73\x07\x4f\xcd\xc4\xfb\xee\xf6\x1f\xfb\x2e\x95\x98\xca\xfa\xea\x8f\xcb\x19\xf7\xf8\x3c\xde\x7e\xea\xf2\x4c\xe5\xce\xb9\xf9\xaa\xb8\xa8\x8e\xb6\xd5\x17\x04\xdb\xed\x88\x09\xf1\x51\x21\x97\xe5\xba\x9f\xc3\xee\xdd\xa6\xad\xe0\xf5\x51\x0d\xc3\x7f\x71\xcc\x8f\x7c\x1f\x91\xb1\x26\xe3\xbf\x6a\xe0\xd4\x55\x7f\x54\xe0\xa5\x38\xf7\x87\x96\xa1\x58\x0a\x3b\xb2\x17\x50\x3c\x27\xee\x46\x4b\x7f\xf1\x12\xdf\x0c\x65\xec\xcd\x53\x4d\xe3\xf2\x39\x29\xe3\x9f\xf8\xfb\x23\x00\x00\xff\xff\xde\xc0\x02\x82\x7a\x07\x00\x00")
+var _calicoYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x90\x41\x6b\xdb\x40\x10\x85\xef\xfb\x2b\x86\x80\xa1\x3d\x48\x22\x2d\xf4\xb0\x37\x57\x51\x5a\x53\xd7\x35\x92\x1c\x9a\x93\x19\xaf\x06\x79\xf1\x6a\x57\xcc\x8e\x1c\xdc\x34\xff\xbd\xc8\x75\xec\x92\x9a\x39\xed\xf2\xcd\x9b\xf7\x5e\x92\x24\x0a\x7b\xfb\x40\x1c\x6d\xf0\x1a\xf6\xb7\x6a\x67\x7d\xa3\x61\x81\x1d\xc5\x1e\x0d\xa9\x8e\x04\x1b\x14\xd4\x0a\xc0\x63\x47\x1a\xc4\xb6\xc4\x98\x84\x9e\x18\x25\xb0\x7a\x2b\xb2\x25\xd7\xa5\x06\x45\x1c\xa5\x36\x64\x67\xcd\xaf\xe4\xba\x7c\x8b\x2c\x57\x34\x0d\x3a\x6b\xc2\xe9\x79\x3c\xac\x61\x37\x6c\x28\x89\x87\x28\xd4\xa9\xd8\x93\x19\x1d\x98\x71\x5f\xc3\x56\xa4\x8f\x3a\xcb\x26\xcf\xdf\x56\x9f\x8b\x72\x51\xd4\x45\xb5\x9e\x2e\x67\x2f\x93\x2c\x0a\x8a\x35\xd9\x11\x8c\xd9\x1b\xaf\xc9\xfe\x63\xfa\xe1\x53\x7a\x9b\x4a\xfb\x4b\x01\x08\x72\x4b\x72\xce\xfa\x7f\x34\x80\x4d\x08\x12\x85\xb1\xd7\x20\x3c\x90\x02\xd8\xa3\x1b\x28\xe6\xc1\x0b\x79\xd1\xf0\x3b\x51\x00\x00\xd6\x47\x41\xe7\x50\xc6\x1e\x8f\x3f\x00\x4c\xad\x8d\xc2\x07\x0d\x37\x93\xe7\xea\xb1\xaa\x8b\xef\xeb\xbb\xe2\x7e\xba\x9a\xd7\xeb\xb2\xf8\x32\xab\xea\xf2\xf1\x65\x72\x73\xa2\x8d\xb7\xaf\x8b\x00\x72\xe8\x49\x43\xfe\xda\xca\x38\x7f\x2b\x5a\x90\x3c\x05\xde\x5d\x48\x13\xbc\xa0\xf5\xc4\xb3\xe5\x7d\xe0\x27\xe4\xc6\xfa\x56\x43\xe1\x71\xe3\xa8\x39\x63\xb6\x5f\x86\xe0\xe2\x65\x2f\x01\x63\x1b\x3e\x5a\xcb\xe7\xab\xaa\x2e\xca\x75\x3e\xbb\x2b\x2f\x7e\xc6\x21\x6f\xb0\x8f\xc3\x29\x16\x3c\xfc\x9c\x4f\x17\x39\x87\x18\xab\x61\xe3\x49\xfe\x21\x3d\xca\x8f\x41\xda\x70\xf5\x38\x80\x0f\x0d\x55\xe4\xc8\x48\x60\x0d\xe8\xdc\xbb\xf7\xea\xcf\x00\xdf\x26\x1e\x78\x7d\x02\x00\x00")
+
+func calicoYamlBytes() ([]byte, error) {
+	return bindataRead(
+		_calicoYaml,
+		"calico.yaml",
+	)
+}
+
+func calicoYaml() (*asset, error) {
+	bytes, err := calicoYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "calico.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _ccmYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x94\x4f\x8f\xd3\x30\x10\xc5\xef\xf9\x14\x56\x8f\x48\xee\x0a\x71\x41\x39\xc2\x81\xfb\x4a\x70\x9f\xda\x8f\xae\xa9\xeb\xb1\x3c\xe3\xc0\xf2\xe9\x91\x93\xae\x54\x1a\x5a\x25\x05\x04\xa7\x38\x96\xfd\x9b\xe7\x37\x7f\x28\x87\x4f\x28\x12\x38\xf5\xa6\xec\xc8\x6d\xa9\xea\x13\x97\xf0\x9d\x34\x70\xda\x1e\xde\xca\x36\xf0\xc3\xf0\xba\x3b\x84\xe4\x7b\xf3\x3e\x56\x51\x94\x47\x8e\xe8\x8e\x50\xf2\xa4\xd4\x77\xc6\x24\x3a\xa2\x37\x87\x37\x62\x5d\xe4\xea\xad\xe3\xa4\x85\x63\x44\xb1\x47\x4a\xb4\x47\xe9\x4a\x8d\x90\xbe\xb3\x86\x72\xf8\x50\xb8\x66\x69\x17\xad\x71\xcc\xc5\x87\x74\x1e\xaf\x33\xa6\x40\xb8\x16\x87\xd3\xa1\x08\x12\x48\x67\xcc\x80\xb2\x3b\xed\xed\xa1\xe3\xd7\x15\x90\x62\x5c\xd6\xec\xdb\x72\x16\x63\xb3\x99\x23\x31\x20\xe9\x05\xf2\x0c\x95\x49\xdd\xd3\x6a\x68\x62\x7f\x29\x73\xf3\x6a\xb3\xe2\xee\x83\x28\x69\x6d\x08\x6b\x04\x65\x08\xee\x7c\xef\x0c\x3b\xe9\x5b\x04\x7e\xe1\x8c\x3f\x99\xfd\x15\x1f\x63\x90\xc9\xd0\xaf\x77\xa1\x67\xda\xd6\x7a\x77\x62\x91\x73\x5c\x6f\x65\xa6\xe5\x7d\x11\xb0\x15\xa5\x64\x72\x58\xc9\xa2\x9c\x65\x4e\xf3\x84\x23\x27\x81\x2e\xca\xaf\x0f\xe2\x78\x40\x79\x3e\x95\xf4\x2f\xe4\x21\xf9\xcc\x21\xa9\xc4\xe0\xae\xd5\xf6\x65\x4e\xac\xed\xee\xef\xd8\x77\x21\xf9\x90\xf6\xab\x1b\x97\x23\x1e\xf1\xb9\x09\x7b\x79\xe5\x8d\xc8\x9d\x31\xf3\x51\xb1\x28\x8e\xd4\xdd\x17\x38\x1d\x67\xc4\x84\xf8\x28\x28\xcb\xee\x4e\x87\xc6\x64\xf7\xe6\x50\x77\xb0\xf2\x2c\x8a\xe3\x3f\x71\xcc\x36\xbe\xf5\x88\xd8\x93\xf2\x1f\x35\x70\x7a\x55\x7f\x11\xe0\x7f\x71\xee\x37\x2d\x43\xd2\xe0\x46\xb2\x2d\x20\x7f\x4b\xdc\x9d\x96\xfe\xe4\x25\xbe\x29\x52\xeb\x23\x4b\x39\xb4\xe1\x73\x55\xc6\x5f\xf1\xf7\xc7\x00\xde\xc0\x02\x82\x7a\x07\x00\x00")
 
 func ccmYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -111,7 +137,27 @@ func ccmYaml() (*asset, error) {
 	return a, nil
 }
 
-var _corednsYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x57\x51\x6f\xdb\x38\x12\x7e\xf7\xaf\x20\x04\xe4\xe5\x70\x72\xe2\x0b\xda\xcb\xf1\x2d\x8d\xdd\x36\xb8\xc4\x35\x6c\xa7\x40\xb1\x58\x04\x34\x35\xb6\xb8\xa1\x38\x5c\x92\x72\xe2\xed\xe6\xbf\x2f\x28\xc9\x32\x69\x2b\x69\x92\xed\xfa\xc5\x92\x86\xf3\x0d\xf9\x71\xf8\xcd\x90\x69\xf1\x15\x8c\x15\xa8\x28\x59\x0f\x7a\x77\x42\x65\x94\xcc\xc0\xac\x05\x87\x73\xce\xb1\x54\xae\x57\x80\x63\x19\x73\x8c\xf6\x08\x51\xac\x00\x4a\x38\x1a\xc8\x94\x6d\xde\xad\x66\x1c\x28\xb9\x2b\x17\x90\xda\x8d\x75\x50\xf4\xd2\x34\xed\x85\xd0\x66\xc1\x78\x9f\x95\x2e\x47\x23\xfe\x60\x4e\xa0\xea\xdf\x9d\xd9\xbe\xc0\xe3\x36\xe8\x85\x2c\xad\x03\x33\x45\x09\x51\x44\xc9\x16\x20\xad\x7f\x22\x55\x08\xa3\xc0\x41\xe5\xba\x40\x74\xd6\x19\xa6\xb5\x50\xab\x3a\x46\x9a\xc1\x92\x95\xd2\xd9\x76\xaa\xf5\x84\xe8\x76\xc6\xa6\x94\x60\x69\x2f\x25\x4c\x8b\x4f\x06\x4b\x5d\x21\xa7\x24\x49\x7a\x84\x18\xb0\x58\x1a\x0e\xcd\x37\x50\x99\x46\xa1\x2a\xb0\x94\xd8\x9a\x94\xfa\x45\x63\x56\x3f\xb4\xeb\xf7\xaf\x6b\x30\x8b\xc6\x57\x0a\xeb\xaa\x87\x7b\xe6\x78\x7e\x18\x2f\x13\x96\xe3\x1a\xcc\xa6\xe1\xe1\x99\xe8\x52\xfc\x10\xfd\x6f\xb1\xfd\x41\xa8\x4c\xa8\x55\x44\x3a\x53\x0a\x5d\xe5\xd9\x30\xdf\x05\x19\x6d\x06\x2b\x1d\x96\x3a\x63\x0e\x28\x49\x9c\x29\x21\xf9\xf9\x7b\x87\x12\xa6\xb0\xac\xe6\xd7\xb0\xf9\xcc\x5a\x7b\x84\x1c\x26\xd6\x13\xc8\xb6\x5c\xfc\x06\xdc\x55\x89\xd1\x79\x04\xde\x9c\xf8\x3b\xc2\x51\x2d\xc5\xea\x9a\xe9\xb7\x1c\xa7\xed\xf0\x0b\x34\xb0\x14\x12\x28\xf9\xb3\xe2\xb4\x4f\xdf\x9d\x92\xef\xd5\xa3\xff\x81\x31\x68\x6c\xfb\x9a\x03\x93\x2e\x6f\x5f\x0d\xb0\x6c\xd3\xbe\xed\xb6\x83\x1c\x7d\xbf\xb8\xba\x99\xcd\x47\xd3\xdb\xe1\x97\xeb\xf3\xcb\xf1\xe3\x11\x11\x2a\x65\x59\x66\xfa\xcc\x68\x46\x84\x7e\x5f\x3f\xec\x22\x91\xea\x04\x10\xa1\x2c\xf0\xd2\x40\xf0\x7d\xc9\xa4\x74\xb9\xc1\x72\x95\x77\xa3\xb4\x63\x1f\x77\x13\x45\xeb\x2c\x39\x06\xc7\x8f\x1b\x2a\x8e\xc7\x98\xc1\xe7\xea\x73\x18\xd4\x39\x49\xde\x9f\x04\x1f\x0c\x48\x64\x19\x19\xbc\xb3\xdd\x53\xe8\x08\xa6\x0d\x16\xe0\x72\x28\x2d\xa1\xff\x1b\xbc\x3b\x6d\x0d\x4b\x34\xf7\xcc\x64\xa4\x5f\xcf\xc4\x1f\x47\xb9\xee\x73\x54\xcb\x76\x08\x67\x3c\x07\x72\xba\x9b\x81\x44\xd4\xbd\x78\x32\x81\x8d\x65\x0b\x26\x99\xe2\x35\x3f\xf5\x14\x44\xa1\xd1\xb8\x78\xb1\xbc\xb4\x0e\x8b\xe3\x7f\xf5\xbd\xc6\x80\x39\x48\x22\xa6\xb5\xdd\x1d\xdd\x21\x68\x89\x9b\x02\xde\xa6\xcc\x7b\x87\xf2\xcc\xa6\x4c\xeb\x66\x48\xed\xb8\x7f\x54\x6b\xe0\xc4\xe7\xde\x70\x3c\x4b\x7a\x56\x03\xa7\x95\x5e\xad\x85\x9f\xdf\x67\x61\x1d\x9a\xcd\x95\x28\x84\xa3\xc4\x73\xe3\x0f\xb6\x83\xd5\xa6\x8e\xe1\x36\x1a\x28\x99\xa2\x94\x42\xad\x6e\x2a\x89\xa8\x25\x25\xfc\x42\x1b\xda\x0a\xf6\x70\xa3\xd8\x9a\x09\xc9\x16\x3e\xcf\x07\x1e\x0e\x24\x70\x87\xa6\x1e\x53\x78\xc9\xbb\x0a\xd6\xd0\xbd\x0a\x07\x85\x96\x2d\x70\x48\x54\xb5\x37\x91\xff\x53\x3c\x6c\x57\x5a\xa7\x8d\x40\x23\xdc\xe6\x42\x32\x6b\xc7\x35\x25\x35\xa5\x29\xaf\x05\x26\xe5\x46\x38\xc1\x99\x4c\x1a\x17\x1b\x69\xc8\x78\x6f\x7f\x2a\x6a\x50\x82\x09\x65\xd6\xff\x52\x72\x07\x1b\x4f\x78\x03\x77\x9e\x65\xa8\xec\x17\x25\x37\x49\x90\xe4\xa8\xbd\x27\x1a\x4a\x92\xd1\x83\xb0\xce\x26\x07\x00\x0a\x33\x48\xbd\x68\xee\x49\x35\x47\xe5\x0c\xca\x54\x4b\xa6\xe0\x85\x98\x84\xc0\x72\x09\xdc\x51\x92\x8c\x71\xc6\x73\xc8\x4a\x09\x2f\x0f\x59\x30\xcf\xd0\xcf\x88\xe5\x23\xcc\xa2\x84\x38\xcc\x58\xb4\x94\x48\xa1\xca\x87\x96\x66\x8d\x12\x57\x9b\x99\xf6\x1a\x78\x81\xca\x27\xa8\x2f\xad\x21\xe9\x05\x7b\x98\xdd\xc1\x7d\x9d\x72\xdb\xdf\xd6\xf3\xff\x7e\x75\x71\x10\x2f\x5a\xfe\x68\x04\xa3\xef\x73\x50\x37\xca\x32\x27\xec\x52\xd4\xf9\x3b\xc4\x31\xba\xed\x1a\x82\xa1\x55\x02\x1e\xae\xe3\x89\x04\x7f\x3e\x4d\x09\xf1\x3b\xca\x84\x02\xd3\x7a\xa4\x07\x7a\x50\xff\x44\xc1\x56\x40\xc9\xd1\xf7\xd9\xb7\xd9\x7c\x74\x7d\x3b\x1c\x7d\x3c\xbf\xb9\x9a\xdf\x4e\x47\x9f\x2e\x67\xf3\xe9\xb7\xc7\x23\xc3\x14\xcf\xc1\x1c\x17\xc2\x57\x13\xc8\xd2\x06\x62\xfb\x4f\x07\xfd\xc1\x49\x7f\x10\x23\x4e\x4a\x29\x27\x28\x05\xdf\x50\x72\xb9\x1c\xa3\x9b\x18\xb0\x50\x15\xce\xfa\x17\x35\x37\x2d\x09\x5e\x32\xf6\x16\x59\x40\x81\x66\x43\xc9\xe0\xbf\x27\xd7\x22\x52\xfa\xdf\x4b\xb0\xfb\xa3\xb9\x2e\x29\x19\x9c\x9c\x14\x9d\x18\x11\x04\x33\x2b\x4b\xc9\x2f\x24\x49\xbd\xa4\x27\xff\x26\x49\xa4\xc1\xdb\xd2\x9a\x90\x5f\x5b\x97\x35\xca\xb2\x80\x6b\x7f\x7a\xa3\x54\xd9\x52\xeb\x2b\x7a\x5a\x0f\x0a\xe2\x17\x7e\xfc\x84\xb9\x9c\x46\x2a\x1f\xad\x85\x65\xfe\x3c\x53\xe2\x1b\xa5\x43\xe0\xaa\x1c\xa4\xaf\xc4\x6f\xaa\xc8\x8f\xc3\xf8\xfa\x13\x2d\xa7\xcd\x9e\x09\x1a\x47\x49\x50\x12\xb7\x55\x25\x9e\xbe\x36\xe8\x90\xa3\xa4\xe4\x66\x38\x79\x2d\x4e\xea\xb8\xee\xc4\x9a\x5f\x3c\x83\x15\x15\xea\x2d\x5a\x01\xce\x08\xde\x3d\xb3\x10\xad\xea\x51\xbc\x74\xa3\x72\xf0\xe0\xc2\x0c\x62\x52\xe2\xfd\xc4\x88\xb5\x90\xb0\x82\x91\xe5\x4c\x56\x72\x4c\x7d\x13\x61\x43\xd6\x39\xd3\x6c\x21\xa4\x70\x02\xf6\x72\x90\x65\x59\xfc\x21\x25\xe3\xd1\xfc\xf6\xc3\xe5\x78\x78\x3b\x1b\x4d\xbf\x5e\x5e\x8c\x22\x73\x66\x50\xef\x3b\x30\x29\x3b\x36\x6e\x8a\xe8\x3e\x0a\x09\x4d\xb7\x1a\x6f\xa3\x14\x6b\x50\x60\xed\xc4\xe0\x02\x42\xbc\xdc\x39\xfd\x09\x5c\x1c\x42\xd7\xf9\xb2\xd7\x12\x92\x26\x1d\x28\x39\x3b\x39\x3b\x89\x3e\x5b\x9e\x83\x27\xf9\xf3\x7c\x3e\x09\x0c\x42\x09\x27\x98\x1c\x82\x64\x9b\x19\x70\x54\x99\xa5\x71\x4b\xa6\xc1\x08\xcc\x5a\xdb\x20\xb4\x39\x51\x00\x96\x6e\x67\x0c\x6c\xb6\xe4\x1c\xac\x9d\xe7\x06\x6c\x8e\x32\x8b\xad\x4b\x26\x64\x69\x20\xb0\x9e\x46\x8d\xad\x78\x35\x15\x71\x3b\x1c\x30\x31\x38\x1b\xbc\x99\x89\x67\x88\xf8\xcf\x3f\xcc\x43\xa6\xec\x56\x81\x87\xf5\x45\xaa\x31\xd4\x02\xf2\x0a\x01\xe3\xdb\xab\x4a\xcc\x5b\x77\x41\xa9\xa8\x70\x50\xd8\xfd\x94\xae\x1a\x82\xad\xaa\xee\xd5\xb1\x7a\x0b\x3a\x8d\x8d\x63\xdb\xff\x77\x7a\x1e\x5a\x5f\xa8\x9d\x2f\x59\x5a\x7a\x20\xa4\xbe\x5b\xf1\xaa\xc0\x64\x73\x06\x9f\xbc\xe5\x35\xd7\xc6\x8e\xc6\x3c\xa8\xd8\x4f\x76\xe6\x07\xb7\xee\xdd\x5d\xc5\x77\x1c\x75\x7e\x26\x5e\x0b\x93\x0e\xb3\xe5\x86\xe9\x27\x6f\xdf\x2f\x68\xf4\xb7\x7d\x6c\xd3\xb7\x06\x48\x2f\xbd\x12\xc4\x9d\x7a\x57\xcc\x26\xc6\xe5\x84\x86\xd7\xce\xf1\xec\xf1\xa8\x17\x54\xa6\x74\xaf\xee\xe8\xb0\xa0\xec\x97\x9f\xb4\xa3\xb8\x3c\xe1\x50\x57\x85\xb4\xa3\x7e\xe8\xb8\xcc\xc4\x2e\x7f\x05\x00\x00\xff\xff\xfd\x41\xe7\x07\x25\x13\x00\x00")
+var _ciliumYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x8e\x4d\x6b\xc3\x30\x0c\x86\xef\xfe\x15\xa2\x90\x63\x62\xc2\x7a\xf2\x6d\xcb\x02\x2b\x2b\x25\xf4\x63\xd7\xa2\x38\x5a\x63\xea\xd8\xc6\x56\x0a\x5b\xd7\xff\x3e\xd2\xb5\xec\x52\x74\x7a\x79\x1e\x49\x6f\x9e\xe7\x02\x83\xf9\xa0\x98\x8c\x77\x0a\x7a\xb2\x43\xa1\x91\xd9\x52\x61\xbc\x3c\x95\xe2\x68\x5c\xa7\xe0\x8d\xec\x50\xf5\x18\x59\x0c\xc4\xd8\x21\xa3\x12\x00\x0e\x07\x52\xa0\x8d\x35\xe3\x70\x8b\x29\xa0\x26\x05\xc7\xb1\xa5\x3c\x7d\x25\xa6\x41\xa4\x40\x7a\xb2\xf5\xb4\xaf\xa0\x67\x0e\x49\x49\x99\x9d\xdf\x77\x2f\xf5\x7a\x55\x6f\xeb\xcd\xfe\xb9\x59\x5c\x32\x99\x18\xd9\x68\x79\x15\x93\xfc\xbb\x9b\x97\x45\xf9\x54\xcc\x0b\x3e\x7c\x0b\x00\xc6\x78\x20\x5e\x3d\x7e\x04\xd0\x7a\xcf\x89\x23\x06\x05\x1c\x47\x12\x00\x27\xb4\x23\xa5\xca\x3b\x26\xc7\x0a\x7e\x72\x01\x00\x60\x02\x0e\x53\xa5\x69\x7c\xa0\x88\xec\xe3\x3d\x03\x68\x3b\x26\xa6\xd8\x78\x6f\x17\xcd\x69\xde\xf8\xae\x5a\xbc\xae\x97\x26\xf1\xbf\x93\xc3\x2c\x3b\x57\xcb\xdd\x66\x5b\xaf\xf7\x13\xbe\x64\xb3\x2b\xd4\xce\xdc\xad\xd6\xb8\x06\xb9\x57\x20\x7d\x60\xa9\x9d\x91\xad\x71\x37\xa6\xbd\xfb\xbc\x41\x62\x7d\x85\x8e\xb8\xe8\xc4\xa3\x4a\x91\x82\x35\x1a\x93\x82\x52\xfc\x0e\x00\xc5\xfd\x6f\xdf\xb2\x01\x00\x00")
+
+func ciliumYamlBytes() ([]byte, error) {
+	return bindataRead(
+		_ciliumYaml,
+		"cilium.yaml",
+	)
+}
+
+func ciliumYaml() (*asset, error) {
+	bytes, err := ciliumYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "cilium.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _corednsYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x57\x5f\x6f\xe3\xb8\x11\x7f\xf7\xa7\x18\x08\xc8\x4b\x51\x39\x71\x17\x7b\xcd\xf1\x6d\xcf\xf6\xed\x06\x4d\xbc\x86\xed\x1c\x70\x28\x8a\x80\xa6\xc6\x16\x1b\x8a\xc3\x92\x94\x13\x77\x9b\xef\x5e\x50\xff\x2c\xd9\x72\x36\xd9\x5e\x21\x03\x96\x38\x9c\x19\xce\x8f\xc3\xdf\x0c\xb9\x91\xbf\xa1\x75\x92\x34\x83\xdd\x68\xf0\x28\x75\xc2\x60\x89\x76\x27\x05\x7e\x12\x82\x72\xed\x07\x19\x7a\x9e\x70\xcf\xd9\x00\x40\xf3\x0c\x19\x08\xb2\x98\x68\x57\x7d\x3b\xc3\x05\x32\x78\xcc\xd7\x18\xbb\xbd\xf3\x98\x0d\xe2\x38\x1e\xb4\x4d\xdb\x35\x17\x43\x9e\xfb\x94\xac\xfc\x37\xf7\x92\xf4\xf0\xf1\xda\x0d\x25\x5d\x36\x4e\xc7\x2a\x77\x1e\xed\x82\x14\x76\x3c\x2a\xbe\x46\xe5\x82\x6f\x28\x5c\x58\x8d\x1e\x0b\xd5\x35\x91\x77\xde\x72\x63\xa4\xde\x96\x3e\xe2\x04\x37\x3c\x57\xbe\x5e\x1a\x83\x72\x41\xac\x5e\xb1\xcd\x15\x3a\x36\x88\x81\x1b\xf9\xd9\x52\x6e\x0a\xcb\x31\x44\xd1\x00\xc0\xa2\xa3\xdc\x0a\xac\xc6\x50\x27\x86\xa4\x2e\x8c\xc5\xe0\x4a\x50\xca\x0f\x43\x49\xf9\xd2\xc4\x1f\x3e\x77\x68\xd7\x95\xae\x92\xce\x17\x2f\x4f\xdc\x8b\xf4\xd4\x5f\x22\x9d\xa0\x1d\xda\x7d\x85\xc3\x2b\xde\x95\xfc\xae\xf5\xff\x09\xed\x5f\xa4\x4e\xa4\xde\x76\x40\xe7\x5a\x93\x2f\x34\x2b\xe4\xfb\x4c\x76\x36\x83\xe7\x9e\x72\x93\x70\x8f\x0c\x22\x6f\x73\x8c\xfe\xf8\xbd\x23\x85\x0b\xdc\x04\x73\x35\x9a\xaf\xc4\x3a\x00\x38\x4d\xac\x33\x96\x5d\xbe\xfe\x27\x0a\x5f\x24\x46\xef\x11\xa8\xf5\xde\x9d\xf8\x07\xc0\x49\x6f\xe4\xf6\x8e\x9b\x1f\x39\x4e\xf5\xf4\x31\x59\xdc\x48\x85\x0c\xfe\x53\xec\xca\x90\x7d\xfc\x00\xdf\x8a\xd7\xf0\x43\x6b\xc9\xba\xe6\x33\x45\xae\x7c\xda\x7c\x5a\xe4\xc9\xbe\xf9\x3a\x6c\x07\x5c\x7c\x1b\xdf\xde\x2f\x57\xd3\xc5\xc3\xe4\xeb\xdd\xa7\x9b\xd9\xcb\x05\x48\x1d\xf3\x24\xb1\x43\x6e\x0d\x07\x69\x7e\x2a\x5f\x0e\x9e\xa0\x38\x01\x20\xb5\x43\x91\x5b\x6c\x8d\x6f\xb8\x52\x3e\xb5\x94\x6f\xd3\x7e\x2b\xcd\xdc\x97\xe6\x2d\x25\xe7\x1d\x5c\xa2\x17\x97\x15\x14\x97\x33\x4a\xf0\x4b\x31\xdc\x76\xea\xbd\x82\x9f\xae\x5a\x03\x16\x15\xf1\x04\x46\x1f\x5d\xff\x12\x7a\x9c\x19\x4b\x19\xfa\x14\x73\x07\xec\xe7\xd1\xc7\x0f\x8d\x60\x43\xf6\x89\xdb\x04\x86\xe5\x4a\x02\x19\xa8\xdd\x50\x90\xde\x34\x53\x04\x17\x29\xc2\x87\xc3\x0a\x14\x91\x69\x3e\xca\xc5\xb4\x64\x3c\x59\x73\xc5\xb5\x28\xf1\x29\xe3\x95\x99\x21\xeb\xbb\xc1\x8a\xdc\x79\xca\x2e\xff\x34\x0c\x1c\x83\xf6\x24\x89\xb8\x31\xee\x70\x74\x27\x68\x14\xed\x33\xfc\x31\x66\x3e\x3a\x94\xd7\x2e\xe6\xc6\x54\x53\xca\xcc\x3e\x3e\xaa\x21\xd3\x19\x44\x21\xf7\x26\xb3\x65\x34\x70\x06\x45\xd0\xb6\xb8\x93\x81\xdd\xbf\x48\xe7\xc9\xee\x6f\x65\x26\x3d\x83\x80\x4d\x38\xd8\x1e\xb7\xfb\x30\x0b\xc0\xef\x0d\x32\x58\x90\x52\x52\x6f\xef\x0b\x8a\x28\xc6\x6d\x7b\x84\x55\xb0\x65\xfc\xf9\x5e\xf3\x1d\x97\x8a\xaf\x43\x9e\x8f\x82\x39\x54\x28\x3c\xd9\x72\x4e\x16\x28\xef\xb6\x15\x43\x7f\x14\x1e\x33\xa3\x1a\xc3\x6d\xa0\x00\xba\x18\x9c\xc7\xa1\x8e\x34\x3c\xc6\x4a\xb2\xd2\xef\xc7\x8a\x3b\x37\x2b\x21\x29\xcf\x7c\x2c\xca\xca\x15\x0b\x2b\xbd\x14\x5c\x45\x95\x8a\xeb\x70\xc8\xec\x68\x7f\xc2\xe3\x49\xa1\x6d\xd3\x6c\x78\x62\x78\xc4\x7d\x00\xbc\x32\xf7\x29\x49\x48\xbb\xaf\x5a\xed\x6b\xc3\xe1\x21\x13\x34\xc9\x32\x88\xa6\xcf\xd2\x79\x17\x9d\x18\xd0\x94\x60\x6c\x49\xe1\x11\x55\x0b\xd2\xde\x92\x8a\x8d\xe2\x1a\xdf\x68\x13\x00\x37\x1b\x14\x9e\x41\x34\xa3\xa5\x48\x31\xc9\x15\xbe\xdd\x65\xc6\x03\x42\x7f\x84\xaf\x10\xd4\xb2\x93\x10\xa7\x19\x4b\x8e\x81\x92\x3a\x7f\xae\xe4\x9e\x0c\x29\xda\xee\x97\x26\x70\xe0\x98\x74\x48\xd0\x50\xd8\xdb\xa0\x67\xfc\x79\xf9\x88\x4f\x65\xca\x01\x74\x35\xff\x16\xa2\xeb\x3a\x09\xa4\x15\x8e\x46\x6b\xf6\x53\x8a\xfa\x5e\x3b\xee\xa5\xdb\xc8\x32\x7f\x27\x34\x23\x5f\xc7\xd0\x9a\x5a\x24\xe0\x69\x1c\x67\x12\xfc\xf5\x34\x05\x08\x3b\xca\xa5\x46\xdb\x68\xc4\x27\x7c\x50\x3e\x32\xe3\x5b\x64\x70\xf1\x6d\xf9\xfb\x72\x35\xbd\x7b\x98\x4c\x7f\xfd\x74\x7f\xbb\x7a\x58\x4c\x3f\xdf\x2c\x57\x8b\xdf\x5f\x2e\x2c\xd7\x22\x45\x7b\x99\xc9\x50\x4d\x30\x89\x2b\x13\xf5\x3f\x1b\x0d\x47\x57\xc3\x03\x48\x85\xc5\x79\xae\xd4\x9c\x94\x14\x7b\x06\x37\x9b\x19\xf9\xb9\x45\x17\x18\xaa\x9e\xd5\x69\x6e\xea\x47\x05\xca\xe8\x8c\x00\x64\x98\x91\xdd\x33\x18\xfd\xf5\xea\x4e\xb6\x24\x16\xff\x95\xa3\x3b\x9e\x2d\x4c\xce\x60\x74\x75\x95\xf5\xda\xe8\x98\xe0\x76\xeb\x18\xfc\x1d\xa2\x38\x50\x7a\xf4\x67\x88\x3a\x1c\x5c\x97\xd6\x08\xfe\xd1\xa8\xec\x48\xe5\x19\xde\x85\xd3\xdb\xf2\x7b\x80\x36\x54\xf4\xb8\x9c\xd4\x48\x01\xb2\x30\x7f\xce\x7d\xca\x3a\x2c\xdf\x9a\x11\xb2\xf0\xab\x56\x7b\x06\xa1\x51\x3a\x35\x5c\x94\x83\xf8\x9d\xf6\xab\x2a\xf2\x7d\x37\xa1\xfe\x74\xc2\x69\xb2\x67\x4e\xd6\x33\x68\x95\xc4\xba\xaa\x74\x97\x6f\x2c\x79\x12\xa4\x18\xdc\x4f\xe6\xef\xb5\x13\x7b\x61\x7a\x6d\xad\xc6\xaf\xd8\xfa\x79\xd4\x63\x2d\x43\x6f\xa5\x70\xdf\xb5\x56\xf4\x28\x81\xba\x49\x7b\x7c\xf6\x87\xd0\x01\xb8\x52\xf4\x34\xb7\x72\x27\x15\x6e\x71\xea\x04\x57\x05\x1d\x33\xd8\x70\xe5\xda\xa8\x0b\x6e\xf8\x5a\x2a\xe9\x65\x37\x87\x01\x78\x92\x74\x07\x62\x98\x4d\x57\x0f\xbf\xdc\xcc\x26\x0f\xcb\xe9\xe2\xb7\x9b\xf1\xb4\x23\x4e\x2c\x99\x63\x05\xae\x54\xcf\xc6\x2d\x88\xfc\xaf\x52\x61\xd5\xad\x76\xb7\x51\xc9\x1d\x6a\x74\x6e\x6e\x69\xdd\x94\xcf\xf0\x4b\xbd\x37\x9f\xb1\x13\x26\x80\x29\xf3\xf1\xa8\x25\xac\xd3\x81\xc1\xf5\xd5\x75\xbb\xaf\x02\x70\x22\xc5\xb0\xf5\x5f\x56\xab\x03\x92\x00\x52\x4b\x2f\xb9\x9a\xa0\xe2\xfb\x25\x0a\xd2\x89\x63\xdd\x96\xcc\xa0\x95\x94\x34\xb2\x51\x5b\xe6\x65\x86\x94\xfb\x83\xb0\x25\x73\xb9\x10\xe8\xdc\x2a\xb5\xe8\x52\x52\x49\x57\xba\xe1\x52\xe5\x16\x5b\xd2\x43\x3e\x84\xe3\x24\xdf\x0d\x45\xb7\x1d\x6e\x21\x31\xba\x1e\xfd\x30\x12\xaf\x00\xf1\x97\xff\x33\x0e\x89\x76\x35\x03\x4f\xca\x4b\x70\x25\x28\x09\xc4\xb1\x53\x9e\x39\x43\x30\xa2\xbe\xaa\x74\x71\xeb\x2f\x28\xe1\x91\x1e\xb3\xa3\x43\x51\x35\x04\x35\xab\x76\x64\xf5\x16\xf4\x0a\x2b\xc5\xa6\xff\xef\xd5\x3c\x95\xbe\x91\x3b\xdf\x12\x5a\x7c\x42\xa4\xa1\x5b\x09\xac\xc0\x55\x45\xa5\x67\x6f\x79\xd5\xb5\xb1\xa7\x31\x6f\x55\xec\xb3\x9d\xf9\xc9\xad\xfb\x70\x57\x09\x1d\x47\x99\x9f\x51\xe0\xc2\xa8\x47\xec\x84\xe5\xe6\xec\xed\xbb\xb7\x73\xe8\x76\x34\x75\x1f\x5b\xf5\xad\x2d\x4b\x6f\xbd\x12\x74\x3b\xf5\x3e\x9f\x95\x8f\x9b\x39\x6b\x5f\x3b\x67\xcb\x97\x8b\x41\xab\x32\xd5\xbb\x59\xaf\xd3\xb4\x0b\xca\x81\xe4\xcb\xf2\x13\xf7\x14\x97\x33\x0a\xab\x71\x5b\xa1\x5d\x3f\x4c\xb7\xcc\x74\x55\xfe\x3b\x00\xfd\x41\xe7\x07\x25\x13\x00\x00")
 
 func corednsYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -131,7 +177,7 @@ func corednsYaml() (*asset, error) {
 	return a, nil
 }
 
-var _localStorageYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x56\x5f\x6f\xdb\xb6\x16\x7f\xd7\xa7\x38\x57\xb7\x79\xb8\x17\xa5\x9d\x6c\x05\x32\xb0\xd8\x83\x9b\x38\x69\x80\xc4\x36\x6c\x77\x43\x51\x14\x06\x2d\x1d\xdb\x6c\x28\x92\x20\x29\xb7\x6a\x96\xef\x3e\x90\x94\x1d\xc9\x71\x13\x07\xdb\xde\xa6\x17\x81\x87\xe7\xef\xef\xfc\x23\xd3\xfc\x37\x34\x96\x2b\x49\x61\x7d\x92\xdc\x72\x99\x53\x98\xa0\x59\xf3\x0c\x7b\x59\xa6\x4a\xe9\x92\x02\x1d\xcb\x99\x63\x34\x01\x90\xac\x40\x0a\x42\x65\x4c\x10\xcd\xdc\x8a\x68\xa3\xd6\xdc\xcb\xa3\x21\x36\xca\x11\x56\x0b\x46\x76\xab\x59\x86\x14\x6e\xcb\x39\x12\x5b\x59\x87\x45\x42\x08\x49\x9a\x96\xcd\x9c\x65\x1d\x56\xba\x95\x32\xfc\x3b\x73\x5c\xc9\xce\xed\x2f\xb6\xc3\x55\x77\xeb\xd3\x99\x28\xad\x43\x33\x56\x02\x0f\x77\xc8\x78\x6e\x53\x0a\xb4\x34\x21\xc0\x34\xbf\x34\xaa\xd4\x96\xc2\xa7\x34\xfd\x9c\x00\x18\xb4\xaa\x34\x19\x06\x8a\x54\x39\xda\xf4\x35\xa4\xda\xbb\x65\x1d\x4a\xb7\x56\xa2\x2c\x30\x13\x8c\x17\xe1\x26\x53\x72\xc1\x97\x05\xd3\x36\x88\xaf\xd1\xcc\x83\xe8\x12\x9d\xbf\x16\xdc\x86\xff\x57\xe6\xb2\x55\xfa\xf9\x79\x93\x28\x73\xad\xb8\x74\x7b\xcd\x46\xa2\xca\x77\x6c\xfd\xff\x20\xc5\x6b\xf4\x5a\x5b\x82\x99\x41\xe6\x30\x28\xdd\xef\x9f\x75\xca\xb0\x25\xd6\xd0\x3f\x56\x5a\xdf\x67\x82\x59\x8b\x07\x22\xf0\x97\x12\xfd\x8e\xcb\x9c\xcb\xe5\xe1\xf9\x9e\x73\x99\x27\x3e\xe9\x63\x5c\x78\xe6\x4d\x78\x4f\x18\x4e\x00\x1e\x17\xd8\x21\x65\x65\xcb\xf9\x17\xcc\x5c\xa8\xac\xbd\x6d\xf3\x4f\x35\x0b\xd3\xda\x3e\xc0\x75\x8e\x5a\xa8\xaa\xc0\x17\xf4\xe9\x8f\x4d\x59\x8d\x19\x0d\x69\x8f\xbc\xef\xb9\xcf\x79\x75\xcd\x0b\xee\x28\x1c\x27\x00\xd6\x19\xe6\x70\x59\x79\x2e\x00\x57\x69\xa4\x30\x56\x42\x70\xb9\xfc\xa0\x73\xe6\x30\xd0\x4d\x93\x12\x59\x01\x0a\xf6\xed\x83\x64\x6b\xc6\x05\x9b\x0b\xa4\x70\xe2\xd5\xa1\xc0\xcc\x29\x13\x79\x0a\x5f\x35\xd7\x6c\x8e\xc2\x6e\x84\x98\xd6\x4f\x84\xe1\xb0\xd0\x62\x6b\xa2\x19\xbf\xff\x44\x4b\xd3\x73\xba\x00\x36\xd1\xfb\x4f\x1b\xae\x0c\x77\xd5\x99\x2f\xf6\x41\x00\x33\x8d\x20\x11\x3f\x27\x48\x66\xb8\xe3\x19\x13\x69\xcd\x6f\x5b\xb9\x1f\xbc\x2c\xf1\x01\x4a\x25\xd0\x84\xc2\x6c\x78\x0c\x40\xe0\x16\x2b\x0a\xe9\x59\x6d\xaf\x97\xe7\x4a\xda\xa1\x14\x55\xda\xe0\x02\x50\xda\x4b\x2b\x43\x21\xed\x7f\xe3\xd6\xd9\x74\x8f\x92\xe0\xb9\x2f\xde\x8e\x4f\xba\x91\xe8\x30\xf4\x5e\xa6\xa4\x33\x4a\x10\x2d\x98\xc4\x17\xe8\x05\xc0\xc5\x02\x33\x47\x21\x1d\xa8\x49\xb6\xc2\xbc\x14\xf8\x12\xc3\x05\xf3\x2d\xf7\x77\x59\xf4\x61\x30\x2e\xd1\x6c\x11\x24\xcf\xf5\x41\xfc\x78\xc1\x96\x48\xe1\xe8\x6e\xf2\x71\x32\xed\xdf\xcc\xce\xfb\x17\xbd\x0f\xd7\xd3\xd9\xb8\x7f\x79\x35\x99\x8e\x3f\xde\x1f\x19\x26\xb3\x15\x9a\xee\x7e\x45\x74\x7d\xdc\x39\xee\xfc\xf4\xa6\xad\x70\x54\x0a\x31\x52\x82\x67\x15\x85\xab\xc5\x40\xb9\x91\x41\x8b\xdb\x84\x7b\x7f\x8b\x82\xc9\xfc\x21\xdd\xe4\x39\x47\x09\x58\xc7\x8c\x6b\x9c\x09\x89\x3b\xa9\x41\xea\xa2\xcb\xba\x91\x5a\xff\x3a\x5f\xac\x92\x5b\x8e\xb8\x5d\x6e\x7c\xed\xd9\xa6\xed\x08\x55\x94\x20\x91\xa9\x81\x7c\xe1\xf9\x47\xcc\xad\x68\xcb\xc0\x96\x03\xe5\xfa\xb1\xb2\xd1\xf0\x7c\x36\xe8\xdd\xf4\x27\xa3\xde\x59\xbf\xa1\x6c\xcd\x44\x89\x17\x46\x15\xb4\x95\xdb\x05\x47\x91\xd7\xa3\xfb\x11\x3d\xda\xde\xf4\x78\x67\x3b\xc1\x92\x66\x54\x2f\x08\x28\xd2\x6f\x98\x6e\x5b\x7b\x54\x30\x35\xbe\xbb\x53\xb8\xbd\x2c\x1f\xe6\xf1\x24\xd2\xc3\xdc\x78\x72\x22\xfb\xf5\x24\xa5\x72\xcd\x9e\x6f\x6e\xd8\x9d\x56\xe1\x96\xe4\xb8\x60\xa5\x70\x24\x5c\x53\x48\x9d\x29\x31\x4d\x9a\x75\x08\x75\x9d\x7a\x81\x86\xa5\x18\x7b\xbd\x4d\x6f\x54\x8e\x14\x7e\x67\xdc\x5d\x28\x73\xc1\x8d\x75\x67\x4a\xda\xb2\x40\x93\x98\xf8\xd4\xd9\x14\xed\x39\x0a\x74\x18\x22\xaf\x57\xe4\x06\xb2\x64\xe7\xd9\xf8\xe4\xe6\xd9\x16\xe8\x0f\x96\xce\x46\xb0\x51\xab\x14\xfe\x20\x01\x90\xbb\x3a\x37\x61\x82\xf8\x0a\xb8\x61\x3a\xa5\x9f\x6a\xea\xdd\x36\x73\xe1\x3e\xa5\xe9\xa6\x73\x47\xbd\xe9\xfb\xd9\xc5\x70\x3c\x1b\x0c\x07\xb3\xeb\xab\xc9\xb4\x7f\x3e\x1b\x0c\xcf\xfb\x93\xf4\xf5\x83\x8c\xf7\xce\xa6\xf4\x53\x7a\x74\xb7\x91\xbb\x1e\x9e\xf5\xae\x67\x93\xe9\x70\xdc\xbb\xec\x07\x2d\xf7\x47\xe1\xa1\xe3\xbf\xfb\xfa\x1f\xcf\xf7\x61\x7d\x39\xff\xb8\xa8\x9d\xfd\xef\x7f\xba\x73\x2e\xbb\x76\x15\x4e\x5f\x57\x5c\x20\x2c\xd1\x29\xed\x2c\xa4\x05\xb5\x54\xd3\x14\x94\x8e\xed\x9b\xab\x87\x39\xc0\x2c\xc2\x2b\xa5\x1d\x70\xd9\xaa\x45\xfd\xbf\xd6\x91\xcd\xad\x12\xa5\x0b\x38\xfc\xfa\x6a\x38\x9a\xf6\xc6\x97\x2d\x86\xb7\x6f\x5b\x47\xdb\x16\xb7\xfc\x3b\x5e\xc9\x77\x95\x43\x7b\x88\x74\xd1\x96\x5e\x2b\xe1\x2b\xe7\x39\x49\xb4\x2c\xab\xe3\x93\xb1\xdb\x8a\xdb\x9c\x1b\x20\x05\x1c\x9f\x9e\x9e\x02\xd1\xf0\xea\xae\x19\x48\x04\x35\x5b\x15\x2a\x87\xd3\xe3\xe3\xdd\xdb\x6e\xa7\x13\xf6\x3c\x33\xb9\xfa\x2a\xff\x85\xfa\x49\xa8\x4d\x01\xc4\x2c\xf6\x00\xbc\x42\xa1\xd1\x8c\x54\xde\xa9\x58\x21\xb6\x28\xee\x74\xb1\x27\xc5\x46\x1f\xa9\x7c\xef\x8b\x2a\xf6\x76\xd4\x46\x74\xcd\xd4\x7c\x36\xfd\x78\x05\xef\x08\xc1\x8b\xd6\x6e\xc1\x8d\x51\x06\x73\x22\xf8\xdc\x30\x53\x91\x79\x69\xab\xb9\xfa\x46\x4f\x3a\x3f\xbf\xe9\x9c\x1c\xb8\x77\xff\x0c\x00\x00\xff\xff\x7c\x3e\x44\xe7\xec\x0e\x00\x00")
+var _localStorageYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xec\x56\x5f\x6f\xdb\x36\x10\x7f\xf7\xa7\xb8\x69\xcd\xc3\x86\x52\x4e\xb6\x02\x19\x58\xec\xc1\x4d\x9c\x34\x40\x62\x1b\xb6\xbb\xa1\x28\x0a\x83\xa6\xce\x36\x1b\xfe\x03\x49\xb9\x55\xb3\x7c\xf7\x81\x92\x6c\xcb\x8e\x93\x38\xd8\xf6\x36\xf0\x41\x20\x79\xbf\xbb\xe3\xef\xfe\x89\x59\xf1\x07\x3a\x2f\x8c\xa6\xb0\x3c\x69\xdd\x0a\x9d\x51\x18\xa1\x5b\x0a\x8e\x1d\xce\x4d\xae\x43\x4b\x61\x60\x19\x0b\x8c\xb6\x00\x34\x53\x48\x41\x1a\xce\x24\xb1\x2c\x2c\x88\x75\x66\x29\x22\x1e\x1d\xf1\x15\x8e\xb0\x1a\x58\x89\x7b\xcb\x38\x52\xb8\xcd\xa7\x48\x7c\xe1\x03\xaa\x16\x21\xa4\xd5\xb4\xec\xa6\x8c\xa7\x2c\x0f\x0b\xe3\xc4\x77\x16\x84\xd1\xe9\xed\x6f\x3e\x15\xa6\xbd\xf6\xe9\x4c\xe6\x3e\xa0\x1b\x1a\x89\x87\x3b\xe4\xa2\xb4\xcb\x25\x7a\xda\x22\xc0\xac\xb8\x74\x26\xb7\x9e\xc2\xa7\x24\xf9\xdc\x02\x70\xe8\x4d\xee\x38\x96\x27\xda\x64\xe8\x93\xd7\x90\xd8\xe8\x96\x0f\xa8\xc3\xd2\xc8\x5c\x21\x97\x4c\xa8\xf2\x86\x1b\x3d\x13\x73\xc5\xac\x2f\xe1\x4b\x74\xd3\x12\x3a\xc7\x10\xaf\xa5\xf0\xe5\xf7\x2b\x0b\x7c\x91\x7c\x7e\xde\x24\xea\xcc\x1a\xa1\xc3\x5e\xb3\xd5\xa1\xc9\x76\x6c\xfd\x7c\x90\xe2\x25\xea\xb0\x03\xe4\x0e\x59\xc0\x52\xe9\x7e\xff\x7c\x30\x8e\xcd\xb1\xa6\xfe\xa1\xd2\xfa\x9e\x4b\xe6\x3d\x1e\xc8\xc0\x3f\x0a\xf4\x3b\xa1\x33\xa1\xe7\x87\xc7\x7b\x2a\x74\xd6\x8a\x41\x1f\xe2\x2c\x66\xeb\xea\x79\x4f\x18\x6e\x01\x3c\x4c\xb0\x43\xd2\xca\xe7\xd3\x2f\xc8\x43\x99\x59\x7b\xcb\xe6\xbf\x2a\x16\x66\xad\xdf\xd0\x75\x8e\x56\x9a\x42\xe1\x0b\xea\xf4\x71\x53\xde\x22\x8f\xbc\x39\xac\xdc\x7c\x2f\x62\xcc\x8b\x6b\xa1\x44\xa0\x70\xdc\x02\xf0\xc1\xb1\x80\xf3\x22\x4a\x01\x84\xc2\x22\x85\xa1\x91\x52\xe8\xf9\x07\x9b\xb1\x10\xb9\x03\x70\xcd\x93\x4a\x14\x40\xb1\x6f\x1f\x34\x5b\x32\x21\xd9\x54\x22\x85\x93\xa8\x0e\x25\xf2\x60\x5c\x25\xa3\x62\x5e\x5e\xb3\x29\x4a\xbf\x02\x31\x6b\x9f\x78\x46\x40\x65\xe5\xda\x44\xf3\xfd\x71\xc9\x2d\x4d\xcf\xe9\x02\x58\xbd\x3e\x2e\xeb\x84\x71\x22\x14\x67\x31\xd9\x7b\x25\x99\x49\xd5\xbc\x48\xec\x13\x84\x3b\x11\x04\x67\x32\xa9\xe5\xfd\x56\xec\x7b\x2f\x0b\x7c\xd4\x10\x8c\x44\x57\x56\x44\xc3\x63\x00\x02\xb7\x58\x50\x48\xce\x6a\x7b\x9d\x2c\x33\xda\xf7\xb5\x2c\x56\x96\xab\x65\x6c\x44\x1b\x47\x21\xe9\x7e\x13\x3e\xf8\x64\x8f\x92\xd2\xf3\x58\x1e\x69\x0c\xba\xd3\x18\xb0\xac\x3d\x6e\x74\x70\x46\x12\x2b\x99\xc6\x17\xe8\x05\xc0\xd9\x0c\x79\xa0\x90\xf4\xcc\x88\x2f\x30\xcb\x25\xbe\xc4\xb0\x62\xb1\xa7\xff\x5b\x16\xe3\x33\x98\xd0\xe8\xd6\x0c\x92\xe7\xea\xa0\x5a\x42\xb1\x39\x52\x38\xba\x1b\x7d\x1c\x8d\xbb\x37\x93\xf3\xee\x45\xe7\xc3\xf5\x78\x32\xec\x5e\x5e\x8d\xc6\xc3\x8f\xf7\x47\x8e\x69\xbe\x40\xd7\xde\xaf\x88\x2e\x8f\xd3\xe3\xf4\x97\x37\xdb\x0a\x07\xb9\x94\x03\x23\x05\x2f\x28\x5c\xcd\x7a\x26\x0c\x1c\x7a\x5c\x07\x3c\xfa\xab\x14\xd3\xd9\x26\xdc\xe4\x39\x47\x09\xf8\xc0\xdc\x46\x03\x01\x42\xaa\x99\xd4\x38\x6a\x63\xe0\xed\xea\xb4\xfe\xa4\x5f\xbc\xd1\x6b\x89\x6a\xa8\xdd\xc4\xdc\x6b\xa4\xda\x8a\xaa\x0a\x41\x2a\xa1\xf5\x2d\x80\x8a\xf2\x03\x16\x16\x74\xcb\xc0\x5a\x02\xf5\xf2\xa1\xb2\x41\xff\x7c\xd2\xeb\xdc\x74\x47\x83\xce\x59\x77\x7d\x0b\xb0\x64\x32\xc7\x0b\x67\xd4\x06\x12\xd7\x4c\xa0\xcc\xea\xd6\xdd\x5c\xe5\x79\x65\x7b\x55\xe3\xe9\xba\x83\xd5\xb2\xf5\xcc\x7c\xe8\xc3\x63\x0f\xaa\xce\x6f\x98\xdd\xb6\xf6\x20\x61\x6a\x7e\x77\xbb\xf0\xf6\xb0\xdc\xf4\xe3\x51\x75\x5e\xf6\x8d\x27\x3b\x72\x1c\x4f\x5a\x9b\xd0\xac\xf9\xe6\x84\xdd\x29\x15\xe1\x49\x86\x33\x96\xcb\x40\xca\x01\x4c\x21\x09\x2e\xc7\xa4\xd5\xc8\x13\x0a\x75\x9e\xc6\xa2\x6e\x58\xaa\xb8\xa9\xa7\xe9\x8d\xc9\x90\xc2\x9f\x4c\x84\x0b\xe3\x2e\x84\xf3\xe1\xcc\x68\x9f\x2b\x74\x2d\x17\x2d\x0b\xb5\x4a\xda\x73\x94\x18\xb0\xfc\x59\xab\x47\xe4\x8a\xb2\x2d\x26\x96\x27\x4f\x4f\x9e\x75\x82\x3e\x32\x74\x56\xc0\x46\xae\x52\xf8\x8b\x94\x51\xb9\xab\x63\x53\x76\x90\x98\x01\x37\xcc\x26\xf4\x53\x7d\xba\xba\xad\xef\x13\x9a\xac\x2a\x77\xd0\x19\xbf\x9f\x5c\xf4\x87\x93\x5e\xbf\x37\xb9\xbe\x1a\x8d\xbb\xe7\x93\x5e\xff\xbc\x3b\x4a\x5e\x6f\x30\x91\x1b\x9f\xd0\x4f\xc9\xd1\xdd\x0a\x77\xdd\x3f\xeb\x5c\x4f\x46\xe3\xfe\xb0\x73\xd9\x2d\xb5\xdc\x1f\x95\x3f\x3a\x11\x71\x5f\x7f\xab\x7d\xdc\x79\x0c\xb9\x5d\x3b\xfb\xe3\x0f\xed\xa9\xd0\x6d\xbf\x28\x77\x5f\x17\x42\x22\xcc\x31\x18\x1b\x3c\x24\x8a\x7a\x6a\x69\x02\xc6\x56\xe5\x9b\x99\x5a\x1b\x00\x67\x1e\xe1\x95\xb1\x01\xc4\xa6\x4a\xe3\xb2\x3f\x6d\x6d\xd9\xd4\x1b\x99\x87\x92\x87\xdf\x5f\xf5\x07\xe3\xce\xf0\x72\x4b\xe0\xed\xdb\xad\xad\xdf\x86\x7b\xf1\x1d\xaf\xf4\xbb\x22\xa0\x3f\x04\xad\xb6\xd1\x4b\x23\x63\xe6\x3c\x87\x44\xcf\x78\xfd\x3e\x5d\x55\x9b\xba\xcd\x84\x03\xa2\xe0\xf8\xf4\xf4\x14\x88\x85\x57\x77\xcd\x87\x44\x1a\x01\xf8\x42\x99\x0c\x4e\x8f\x8f\x77\x6f\xdb\x69\x5a\xce\x79\xe6\x32\xf3\x55\xff\x4f\xf5\x93\x54\x3b\x05\xc4\xcd\xf6\x10\xbc\x40\x69\xd1\x0d\x4c\x96\x16\x4c\xc9\x35\x8b\x3b\x55\x1c\x75\x55\x85\x3e\x30\xd9\xde\x3f\xaa\x58\xc1\xb4\xd6\x46\xac\xc9\x1e\xfc\x36\x3d\x3e\x82\x77\x40\x2f\x1b\xbb\x4a\x38\x67\x1c\x66\x44\x8a\xa9\x63\xae\x20\xd3\xdc\x17\x53\xf3\x8d\x9e\xa4\xbf\xbe\x49\x4f\x0e\x9c\xbb\x7f\x0f\x00\x7c\x3e\x44\xe7\xec\x0e\x00\x00")
 
 func localStorageYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -151,7 +197,7 @@ func localStorageYaml() (*asset, error) {
 	return a, nil
 }
 
-var _metricsServerAggregatedMetricsReaderYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\xcf\x31\x6b\xf4\x30\x0c\xc6\xf1\xdd\x9f\x42\x78\x7e\x93\x97\x6e\xc5\x6b\x87\xee\x1d\xba\x94\x1b\x94\xf8\x21\x27\xce\xb1\x83\x24\xe7\x68\x3f\x7d\xb9\x70\xdc\x58\x68\x27\x0d\x7f\x7e\x0f\xe8\x22\x35\x27\x7a\x29\xdd\x1c\xfa\xd6\x0a\x02\x6f\xf2\x0e\x35\x69\x35\x91\x4e\x3c\x8f\xdc\xfd\xdc\x54\xbe\xd8\xa5\xd5\xf1\xf2\x6c\xa3\xb4\xff\xfb\x53\x58\xe1\x9c\xd9\x39\x05\xa2\xca\x2b\x12\xd9\xa7\x39\xd6\xc4\xcb\xa2\x58\xd8\x91\x87\x15\xae\x32\xdb\xa0\xe0\x0c\x0d\x44\x85\x27\x14\xbb\x11\xfa\x61\xfd\xb1\x30\x78\x1b\x76\xc1\x35\x51\x74\xed\x88\xbf\x71\xc8\xe2\x7f\x71\x9c\x57\xa9\x0f\xa8\xbd\xc0\x52\x18\x88\x37\x79\xd5\xd6\x37\x4b\xf4\x11\xef\x7f\xdd\x7d\x3c\x05\x22\x85\xb5\xae\x33\x8e\xbe\xb5\x6c\xf1\x1f\xc5\xda\x32\xec\xc8\x3b\x74\x3a\xd2\x02\xbf\x95\x22\x76\xdc\x2b\xfb\x7c\x8e\xa7\xf0\x1d\x00\x00\xff\xff\xe5\x1d\x7a\x17\x89\x01\x00\x00")
+var _metricsServerAggregatedMetricsReaderYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\xcf\x31\x6b\xf4\x30\x0c\xc6\xf1\xdd\x9f\x42\x78\x7e\x93\x97\x6e\xc5\x6b\x87\xee\x1d\xba\x94\x1b\x94\xf8\x21\x27\xce\xb1\x83\x24\xe7\x68\x3f\x7d\xb9\x70\xdc\x58\x68\x27\x0d\x7f\x7e\x0f\xe8\x22\x35\x27\x7a\x29\xdd\x1c\xfa\xd6\x0a\x02\x6f\xf2\x0e\x35\x69\x35\x91\x4e\x3c\x8f\xdc\xfd\xdc\x54\xbe\xd8\xa5\xd5\xf1\xf2\x6c\xa3\xb4\xff\xfb\x53\x58\xe1\x9c\xd9\x39\x05\xa2\xca\x2b\x12\xd9\xa7\x39\xd6\xc4\xcb\xa2\x58\xd8\x91\x87\x15\xae\x32\xdb\xa0\xe0\x0c\x0d\x44\x85\x27\x14\xbb\x11\xfa\x61\xfd\xb1\x30\x78\x1b\x76\xc1\x35\x51\x74\xed\x88\xbf\x71\xc8\xe2\x7f\x71\x9c\x57\xa9\x0f\xa8\xbd\xc0\x52\x18\x88\x37\x79\xd5\xd6\x37\x4b\xf4\x11\xef\x7f\xdd\x7d\x3c\x05\x22\x85\xb5\xae\x33\x8e\xbe\xb5\x6c\xf1\x1f\xc5\xda\x32\xec\xc8\x3b\x74\x3a\xd2\x02\xbf\x95\x22\x76\xdc\x2b\xfb\x7c\x8e\xa7\xf0\x3d\x00\xe5\x1d\x7a\x17\x89\x01\x00\x00")
 
 func metricsServerAggregatedMetricsReaderYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -171,7 +217,7 @@ func metricsServerAggregatedMetricsReaderYaml() (*asset, error) {
 	return a, nil
 }
 
-var _metricsServerAuthDelegatorYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8e\x31\x4e\xc4\x40\x0c\x45\xfb\x39\xc5\x5c\x60\x82\xe8\x90\x3b\xa0\xa0\x5f\x24\x7a\x67\xf2\x59\x4c\x92\x71\x64\x7b\x22\xc1\xe9\xd1\x8a\x88\x06\xd8\xf6\x4b\xff\xbd\x57\x4a\x49\xbc\xc9\x0b\xcc\x45\x1b\x65\x1b\xb9\x0e\xdc\xe3\x4d\x4d\x3e\x39\x44\xdb\x30\xdf\xf9\x20\x7a\xb3\xdf\xa6\x59\xda\x44\xf9\x71\xe9\x1e\xb0\x93\x2e\x78\x90\x36\x49\x3b\xa7\x15\xc1\x13\x07\x53\xca\xb9\xf1\x0a\xca\x2b\xc2\xa4\x7a\x71\xd8\x0e\x23\xff\xf0\xc0\x4a\x17\x70\x99\xb0\xe0\xcc\xa1\x96\x4c\x17\x9c\xf0\x7a\x79\xf1\x26\x4f\xa6\x7d\xbb\x52\x90\x72\xfe\x15\xf0\xe3\xfb\x5b\xe0\x7d\x7c\x47\x0d\xa7\x54\x8e\xef\x33\x6c\x97\x8a\xfb\x5a\xb5\xb7\xf8\x27\xf7\x98\x7d\xe3\x0a\xca\x73\x1f\x51\xbe\xf9\xe9\x2b\x00\x00\xff\xff\xa5\xb5\x26\x22\x2f\x01\x00\x00")
+var _metricsServerAuthDelegatorYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8e\x31\x4e\xc4\x30\x10\x45\x7b\x9f\xc2\x17\x70\x10\x1d\x72\x07\x14\xf4\x8b\x44\x3f\x71\x3e\xcb\x90\xd8\x63\xcd\x8c\x23\x2d\xa7\x47\x2b\x45\x34\xc0\xb6\x5f\x7a\xff\xbd\x94\x52\xa0\xce\x6f\x50\x63\x69\x39\xea\x4c\x65\xa2\xe1\x1f\xa2\xfc\x45\xce\xd2\xa6\xf5\xc1\x26\x96\xbb\xfd\x3e\xac\xdc\x96\x1c\x9f\xb7\x61\x0e\x3d\xc9\x86\x27\x6e\x0b\xb7\x73\xa8\x70\x5a\xc8\x29\x87\x18\x1b\x55\xe4\x58\xe1\xca\xc5\x92\x41\x77\x68\xb6\x8b\x39\x6a\xbe\x1e\xa7\x05\x1b\xce\xe4\xa2\x41\x65\xc3\x09\xef\x57\x8a\x3a\xbf\xa8\x8c\x7e\xa3\x20\xc4\xf8\x2b\xe0\xc7\xf7\xb7\xc0\xc6\xfc\x89\xe2\x96\x43\x3a\xd8\x57\xe8\xce\x05\x8f\xa5\xc8\x68\xfe\x4f\xee\x31\x5b\xa7\x82\x1c\xd7\x31\x23\xd9\xc5\x1c\x35\x7c\x0f\x00\xa5\xb5\x26\x22\x2f\x01\x00\x00")
 
 func metricsServerAuthDelegatorYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -191,7 +237,7 @@ func metricsServerAuthDelegatorYaml() (*asset, error) {
 	return a, nil
 }
 
-var _metricsServerAuthReaderYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8f\x3d\x4e\x04\x31\x0c\x46\xfb\x9c\x22\x17\xf0\x22\x3a\x94\x0e\x1a\xfa\x45\xa2\xf7\x64\x3e\xc0\xcc\x8e\x13\xd9\xce\x08\x38\x3d\x1a\xb4\xfc\x34\x4b\x6f\xbf\xef\x3d\x22\x4a\xdc\xe5\x11\xe6\xd2\xb4\x64\x9b\xb8\x1e\x78\xc4\x4b\x33\xf9\xe0\x90\xa6\x87\xe5\xc6\x0f\xd2\xae\xb6\xeb\xb4\x88\xce\x25\x1f\xdb\x09\x77\xa2\xb3\xe8\x73\x5a\x11\x3c\x73\x70\x49\x39\x2b\xaf\x28\x79\x45\x98\x54\x27\x87\x6d\x30\xda\x51\x64\xe0\x19\x76\x3e\xf1\xce\x15\x25\x2f\x63\x02\xf9\xbb\x07\xd6\x64\xed\x84\x23\x9e\x76\x08\x77\xb9\xb7\x36\xfa\x3f\x26\x29\xe7\x5f\x91\x9f\x5d\xbc\x05\x74\x6f\x20\xee\xf2\x67\x1c\x1a\x52\xbf\xde\xbf\x35\x7c\x4c\xaf\xa8\xe1\x25\xd1\x19\xf4\x00\xdb\xa4\xe2\xb6\xd6\x36\x34\x2e\xa4\x5c\xd6\xff\x0c\x00\x00\xff\xff\x2a\x39\xe6\xe4\x44\x01\x00\x00")
+var _metricsServerAuthReaderYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8f\xbb\x4e\x04\x31\x0c\x45\xfb\x7c\x45\x7e\xc0\x8b\xe8\x50\x3a\x68\xe8\x17\x89\xde\x93\xb9\x80\x99\x1d\x27\xb2\x9d\x11\xf0\xf5\x68\xd0\xf2\x68\x96\xfe\xea\xdc\x73\x88\x28\x71\x97\x47\x98\x4b\xd3\x92\x6d\xe2\x7a\xe0\x11\x2f\xcd\xe4\x83\x43\x9a\x1e\x96\x1b\x3f\x48\xbb\xda\xae\xd3\x22\x3a\x97\x7c\x6c\x27\xdc\x89\xce\xa2\xcf\x69\x45\xf0\xcc\xc1\x25\xe5\xac\xbc\xa2\xe4\x15\x61\x52\x9d\x1c\xb6\xc1\x68\x47\x91\x81\x67\xd8\x79\xe2\x9d\x2b\x4a\x5e\xc6\x04\xf2\x77\x0f\xac\xc9\xda\x09\x47\x3c\xed\x10\xee\x72\x6f\x6d\xf4\x7f\x4c\x52\xce\xbf\x22\x3f\xbf\x78\x0b\xe8\xde\x40\xdc\xe5\xcf\x39\x34\xa4\x7e\x85\x7c\x6b\xf8\x98\x5e\x51\xc3\x4b\xa2\x33\xe8\x01\xb6\x49\xc5\x6d\xad\x6d\x68\x5c\x48\xb9\xac\xff\x39\x00\x2a\x39\xe6\xe4\x44\x01\x00\x00")
 
 func metricsServerAuthReaderYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -211,7 +257,7 @@ func metricsServerAuthReaderYaml() (*asset, error) {
 	return a, nil
 }
 
-var _metricsServerMetricsApiserviceYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x44\x8e\x4d\x6a\xc4\x30\x0c\x85\xf7\x3e\x85\x2e\xe0\x74\xb2\x2b\xde\x75\x59\x68\x61\x20\x65\xf6\x1a\x8f\x3a\x88\xe0\x1f\x24\xd9\x90\xdb\x97\x30\x71\xba\x13\x7a\xef\xfb\x24\xef\xbd\xc3\xca\x37\x12\xe5\x92\x03\x60\x65\xa1\x27\xab\x09\x1a\x97\x3c\xad\xef\x3a\x71\x79\xeb\xb3\x5b\x39\x3f\x02\x7c\x5c\x3f\x17\x92\xce\x91\x5c\x22\xc3\x07\x1a\x06\x07\x90\x31\x51\x80\x3e\xdf\xc9\x70\x9e\x12\x99\x70\xd4\x03\x76\x5a\x29\xee\x25\x7d\x81\xfb\x38\x88\xa3\xe9\xf7\x88\xe4\x0c\xb4\x62\xa4\x00\x6b\xbb\x93\xd7\x4d\x8d\x92\x03\x78\x4a\x69\xf5\x44\x86\x1c\xa0\x8f\xdf\x8f\xf3\x0e\x80\xb3\x52\x6c\x42\xcb\xca\xf5\xe7\x6b\xb9\x91\xf0\xef\x16\xc0\xa4\xd1\x10\x5d\x85\x8b\xb0\x6d\xdf\x9c\x39\xb5\x14\x60\xbe\x5c\xfe\x65\x23\x7d\xad\xff\x02\x00\x00\xff\xff\x14\x74\xa9\x1b\x25\x01\x00\x00")
+var _metricsServerMetricsApiserviceYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\x8e\x4d\x6a\xc4\x30\x0c\x46\xf7\x3e\x85\x2e\x90\x34\xde\x15\xed\xba\x2c\xb4\x30\x90\x32\x7b\x8d\x47\x1d\x44\xf0\x0f\x92\x1c\xc8\xed\x4b\x68\xd2\xc2\xec\x0c\xef\x7b\xcf\x1a\x86\x21\x50\x93\x2b\xab\x49\x2d\x08\xd4\x44\xf9\x21\xe6\x4a\x2e\xb5\x8c\xcb\xab\x8d\x52\x5f\xd6\x18\x16\x29\x77\x84\xb7\xcb\xfb\xcc\xba\x4a\xe2\x90\xd9\xe9\x4e\x4e\x18\x00\x0a\x65\x46\x58\xe3\x8d\x9d\xe2\x98\xd9\x55\x92\x1d\x72\xb0\xc6\x69\x1f\xd9\xaf\xb8\x3f\x4f\xe3\x58\x0e\x3b\x62\xfd\x03\xd6\x28\x31\xc2\xd2\x6f\x3c\xd8\x66\xce\x39\x00\x3c\xb4\xf6\x86\xf0\x14\x07\x58\xcf\xdb\x8f\xef\x03\x80\x14\xe3\xd4\x95\xe7\x45\xda\xd7\xc7\x7c\x65\x95\xef\x0d\xc1\xb5\xf3\x19\xba\xa8\x54\x15\xdf\x3e\xa5\x48\xee\x19\x21\x4e\xd3\x7f\xec\xa4\x08\x71\x9a\xc2\xcf\x00\x14\x74\xa9\x1b\x25\x01\x00\x00")
 
 func metricsServerMetricsApiserviceYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -231,7 +277,7 @@ func metricsServerMetricsApiserviceYaml() (*asset, error) {
 	return a, nil
 }
 
-var _metricsServerMetricsServerDeploymentYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x55\xdf\x4f\x1b\x47\x10\x7e\xf7\x5f\x31\x72\xc5\xe3\x61\x9b\x2a\x69\xb5\x12\x0f\x08\x9b\xa4\x12\x50\xcb\x67\x2a\xf1\x84\x96\xbd\x31\x5e\xb1\xbf\x3a\x33\xe7\x70\x45\xfc\xef\xd5\xfa\xc8\xe5\x8e\x40\x94\xaa\xc9\x3d\xce\x37\xf3\xcd\xb7\xdf\xee\xcc\x15\x45\x31\xd2\xc9\xfe\x85\xc4\x36\x06\x05\xbb\xd9\xe8\xde\x86\x4a\x41\x89\xb4\xb3\x06\x4f\x8c\x89\x75\x90\x91\x47\xd1\x95\x16\xad\x46\x00\x41\x7b\x54\xe0\x51\xc8\x1a\x2e\x18\x69\x87\xf4\x1c\xe6\xa4\x0d\x2a\xb8\xaf\x6f\xb1\xe0\x86\x05\xfd\xe8\x65\x07\x9d\x12\x4f\xba\x36\x73\x4c\x2e\x36\x1e\xff\x57\x0b\x00\xa7\x6f\xd1\x71\xae\x04\xb8\xff\x9d\x0b\x9d\xd2\x57\xe5\x9c\xd0\xe4\x0c\xc2\x9d\xcd\x52\x3e\x5a\x96\x48\xcd\xb9\xf5\x56\x14\x4c\x47\x00\x2c\xa4\x05\xef\x9a\x96\x47\x9a\x84\x0a\x56\xd1\x39\x1b\xee\xae\x52\xa5\x05\xf7\x71\xea\x47\xda\x54\x00\xaf\x1f\xae\x82\xde\x69\xeb\xf4\xad\x43\x05\xb3\x4c\x87\x0e\x8d\x44\x6a\x73\xbc\x16\xb3\x3d\xef\xe9\x7c\x5b\x29\x80\xa0\x4f\xae\xa3\xef\x3b\x93\xbf\x37\xdc\xc9\x9f\x1b\x34\xf8\x56\x0b\x80\xcf\x86\xe4\x2f\x91\x8d\x64\xa5\x39\x75\x9a\xf9\x72\xcf\x3f\x6e\xdd\x2d\x42\xac\xb0\x30\x64\xc5\x1a\xed\xc6\xcf\xf9\x3c\x78\x1e\x97\x6f\x0b\x92\xe8\x90\xb4\xd8\x18\x7a\xaa\x0a\xb8\xc7\x46\xc1\xf8\xf4\x99\xf5\xa4\xaa\x62\xe0\x3f\x83\x6b\xc6\x5d\x0e\x40\x4c\xb9\x32\x92\x82\xf1\xe2\xc1\xb2\xf0\xf8\x2b\x82\xbd\x36\x8a\x0e\x0f\xf3\x7b\xa0\x80\x82\x7c\x68\xe3\xc4\xc4\x20\x14\x5d\x91\x9c\x0e\xf8\x9d\x9c\x00\xb8\xd9\xa0\x11\x05\xe3\xcb\x58\x9a\x2d\x56\xb5\xc3\xef\x6f\xe9\x35\x0b\xd2\x8f\xe8\xb5\x8b\xae\xf6\xd8\xd9\xf5\x0b\xf8\xec\x31\xd8\x00\xe2\x13\x70\x84\x4f\x08\x46\x07\x60\xbd\x41\xd7\x40\xcd\x08\x1b\x8a\xbe\x60\x43\xf9\x8d\x81\xf5\xfa\x0e\x19\x74\xa8\x26\x91\x80\x50\x57\x45\x0c\xae\x81\x6c\x8a\xb6\x01\x89\x47\x9f\x8f\xd4\xbe\x24\xf1\xa9\xa8\x2c\x75\xea\xd0\x27\x69\xe6\x96\x14\x3c\x3e\x3d\x07\xbf\xd4\xaa\x17\xc5\xaf\xde\x3a\xb4\x22\x14\x1c\x3c\x96\xd7\xe5\x7a\x71\x71\x33\x5f\x9c\x9d\x5c\x9d\xaf\x6f\x56\x8b\x0f\x7f\x94\xeb\xd5\xf5\xd3\x01\xe9\x60\xb6\x48\x13\x6f\x89\x22\x61\x55\x0c\x99\xd4\x6e\x7a\xf8\xfe\xf0\xa8\x23\xd4\x74\x37\x78\x41\x45\x61\x90\x24\xeb\x3e\x9e\x88\x4f\x03\x84\xd1\xd4\x84\x45\x8a\x24\xc7\xb3\xe9\xd1\xbb\xe9\x00\xcd\xf7\xe6\x50\x8a\x44\xb8\x41\xca\x9d\x75\x55\x11\x32\x17\x79\xe4\xf9\xf8\xe0\x71\xb9\x5a\x9c\x2d\x56\xab\xc5\xfc\xe6\x64\x3e\x5f\x2d\xca\xf2\x66\x7d\xbd\x5c\x94\x4f\x07\xaf\xf2\xd4\x8c\xed\x90\xb0\x68\xa9\x79\xdf\x76\x90\xd8\x1e\xac\x20\xe4\xe8\xea\x3c\x0a\xc7\xb3\x77\xdc\x65\xe4\x70\x4d\x06\x7b\xa7\xcb\xc1\xbf\x6b\x64\x19\xc4\x00\x4c\xaa\x15\xcc\xa6\x53\x3f\x88\x7a\xf4\x91\x1a\x05\xbf\x4d\x2f\x6c\x07\x64\x11\x03\xbf\xda\xdb\xda\x8a\x24\xee\x55\x77\xf7\xba\x8c\x24\x99\xbb\x6f\x56\x5e\x0b\x51\xa2\x89\x4e\xc1\xfa\x74\xd9\x53\xac\x2b\x1b\x90\x79\x49\xf1\x16\xfb\x12\x33\xfd\x07\x94\xa1\xea\xa4\x65\xab\x60\x92\xab\x9a\x7f\x86\xc8\xbe\xe9\x4b\x4d\x00\x6c\xb6\x98\xd5\x7e\x5c\xaf\x97\x65\x0f\xb1\xc1\x8a\xd5\x6e\x8e\x4e\x37\x25\x9a\x18\x2a\x6e\x37\x77\x47\x88\x64\x63\xd5\x41\x47\x3d\x48\xac\xc7\x58\x4b\x87\xcd\x7a\x18\xd7\xc6\x20\xf3\x7a\x4b\xc8\xdb\xe8\xaa\x21\xba\xd1\xd6\xd5\x84\x3d\xf4\xd7\x0e\x75\x76\x87\xff\xd9\x89\x5c\xf4\x13\x8c\x78\xff\x0d\x27\x66\xd3\x9f\x6e\xc5\x7e\xe8\xf2\x2f\x24\x06\xc1\x07\x19\xbe\x66\x5d\xe5\xed\xbe\x8a\x51\xce\xac\xc3\xf6\xcf\xa2\x40\xa8\xc6\x7e\x5a\x1d\x4e\xf8\x32\x86\x9c\xf6\x3a\x78\xc5\x48\xfb\x09\xe8\x1f\x47\x3b\x17\x3f\x2d\xc9\xee\xac\xc3\x3b\x5c\xb0\xd1\x6e\xff\xc3\x51\xb0\xd1\x8e\xbf\x70\xb4\x7b\xf5\x22\x2f\xd3\x57\x26\xe3\xe5\x12\x84\x76\xed\x2e\xdb\x2b\xcb\x1b\xe6\xdf\x00\x00\x00\xff\xff\x14\xc8\x49\x02\x2c\x09\x00\x00")
+var _metricsServerMetricsServerDeploymentYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x55\x5b\x4f\x23\x47\x13\x7d\xf7\xaf\x28\xf9\x13\x8f\x8d\xed\xfd\xb4\x9b\xa8\x25\x1e\x10\x36\xbb\x91\x80\x58\xb6\x89\xc4\x13\x6a\x7a\xca\xb8\x45\xdf\x52\x55\xe3\x65\x82\xf8\xef\x51\xfb\xc6\x0c\x0b\xab\x8d\x92\xf5\xf8\xa9\x6e\xe7\xf4\xa9\xea\x6a\xa5\x54\xcf\x64\xf7\x07\x12\xbb\x14\x35\xac\x47\xbd\x07\x17\x2b\x0d\x73\xa4\xb5\xb3\x78\x6a\x6d\xaa\xa3\xf4\x02\x8a\xa9\x8c\x18\xdd\x03\x88\x26\xa0\x86\x80\x42\xce\xb2\x62\xa4\x35\xd2\xce\xcc\xd9\x58\xd4\xf0\x50\xdf\xa1\xe2\x86\x05\x43\xef\x35\x82\xc9\x99\x07\x07\x98\x31\x66\x9f\x9a\x80\xff\x0a\x02\xc0\x9b\x3b\xf4\x5c\xc8\x01\x3c\xfc\xca\xca\xe4\xfc\x4d\x3a\x67\xb4\x25\x82\x70\xed\x0a\x95\x2f\x8e\x25\x51\x73\xe1\x82\x13\x0d\xc3\x1e\x00\x0b\x19\xc1\xfb\xa6\x44\x01\x48\x93\x51\xc3\x2c\x79\xef\xe2\xfd\x75\xae\x8c\xe0\xc6\x4e\x6d\xcb\x36\x14\x20\x98\xc7\xeb\x68\xd6\xc6\x79\x73\xe7\x51\xc3\xa8\x94\x43\x8f\x56\x12\x6d\x63\x82\x11\xbb\xba\x68\xf1\x7c\x9f\x29\x80\x60\xc8\xfe\x50\xbe\xad\x0c\xc0\xbb\xea\x00\x74\x85\xf8\x3e\x04\xc0\x5e\x90\xf2\x65\x72\x89\x9c\x34\x67\xde\x30\x5f\x6d\xd4\xef\x6f\xd5\x55\x31\x55\xa8\x2c\x39\x71\xd6\xf8\xfe\x2e\x9e\x3b\xe3\x71\xf5\x3e\x21\x49\x1e\xc9\x88\x4b\xb1\xc5\x4a\xc1\x03\x36\x1a\xfa\x67\xbb\xaa\xa7\x55\x95\x22\xff\x1e\x7d\xb3\xaf\x5f\xbe\x94\x4b\x66\x22\x0d\xfd\xc9\xa3\x63\xe1\xfe\x37\x05\x36\xdc\x28\x79\x3c\x2e\x23\x47\x11\x05\xf9\xd8\xa5\x81\x4d\x51\x28\x79\x95\xbd\x89\xf8\x83\x35\x01\x70\xb9\x44\x2b\x1a\xfa\x57\x69\x6e\x57\x58\xd5\x1e\x7f\x1c\x32\x18\x16\xa4\xff\x02\x6b\x9d\x7c\x1d\xf0\x20\xd7\xff\x20\x14\x8d\xc1\x45\x90\x90\x81\x13\x7c\x45\xb0\x26\x02\x9b\x25\xfa\x06\x6a\x46\x58\x52\x0a\x8a\x2d\x95\x19\x03\x17\xcc\x3d\x32\x98\x58\x0d\x12\x01\xa1\xa9\x54\x8a\xbe\x81\x22\x8a\x71\x11\x89\x77\x95\xd5\x6e\x92\x24\x64\x55\xb9\x7d\xc7\x00\x30\x64\x69\xc6\x8e\x34\x3c\x3d\xef\x8c\x2f\xb9\xfa\x55\xf2\x9b\x5d\x87\x2d\x09\x0d\x47\x4f\xf3\x9b\xf9\x62\x72\x79\x3b\x9e\x9c\x9f\x5e\x5f\x2c\x6e\x67\x93\xcf\xbf\xcd\x17\xb3\x9b\xe7\x23\x32\xd1\xae\x90\x06\xc1\x11\x25\xc2\x4a\x75\x2b\xe9\xf5\xf0\xf8\xd3\xf1\x87\x43\x41\x43\xf7\x07\xec\x82\xae\x94\x45\x92\xc2\xfb\x64\x20\x21\x77\x3c\x8c\xb6\x26\x54\x39\x91\x9c\x8c\x86\x1f\x3e\x0e\x3b\xde\x32\x2a\x1e\x45\x65\xc2\x25\x52\x41\x36\x55\x45\xc8\xac\xca\x95\xe7\x93\xa3\xa7\xe9\x6c\x72\x3e\x99\xcd\x26\xe3\xdb\xd3\xf1\x78\x36\x99\xcf\x6f\x17\x37\xd3\xc9\xfc\xf9\xe8\xcd\x3a\x35\xe3\xf6\x92\xb0\x18\xa9\x79\x03\xdb\x09\xdc\x1e\x4c\x11\x72\xf2\x75\xb9\x0a\x27\xa3\x8f\xfb\x1e\x94\x7d\xc4\xa9\x26\xfb\xd2\xf0\xf2\x11\xfe\x59\x23\x4b\xc7\x06\x60\x73\xad\x61\x34\x1c\x86\x8e\x35\x60\x48\xd4\x68\xf8\x65\x78\xe9\x0e\x8e\x42\xa2\x95\xbd\xef\xd6\x4a\x24\xbf\x40\xb7\xfa\x3a\x4d\x24\x1a\xba\x62\x95\xb5\x90\x24\xd9\xe4\x35\x2c\xce\xa6\x07\x7b\x19\x29\x17\x91\x79\x4a\xe9\xee\xb0\x02\xcb\xbf\x94\xff\x8c\xd2\x36\x01\x64\x23\x2b\x0d\x83\x92\xd5\xfc\xd5\xf5\x6c\x40\x5f\x73\x02\x60\xbb\xc2\xc2\xf6\xcb\x62\x31\x9d\xb7\x3c\x2e\x3a\x71\xc6\x8f\xd1\x9b\x66\x8e\x36\xc5\x8a\xb7\x9b\x7b\xff\xcb\x48\x2e\x55\x07\xd7\xcb\xf4\x00\x88\x0b\x98\x6a\x39\xf8\x46\x2d\x1f\xd7\xd6\x22\xf3\x62\x45\xc8\xab\xe4\xab\xae\x77\x69\x9c\xaf\x09\x5b\xde\xff\x1f\xbc\xde\xad\xf1\x1f\x2b\x51\x92\x7e\x82\x10\x9f\xbe\xa3\xc4\x68\xf8\xd3\xa5\xd8\x5c\xba\xf2\x84\xa4\x28\xf8\xd8\x39\x79\x69\x7d\xd9\xee\xb3\x94\xe4\xdc\x79\xdc\xbe\x2c\x1a\x84\x6a\x6c\x87\xd5\xf1\x94\xaf\x52\x2c\x61\x6f\x3b\xaf\x19\xa9\x4c\xe9\xb0\x7d\x1c\xe3\x7d\xfa\x3a\x25\xb7\x76\x1e\xef\x71\xc2\xd6\xf8\xcd\x83\xa3\x61\x69\x3c\xbf\xd4\xd8\xee\xd5\xcb\xb2\x4c\xdf\xb8\x19\xaf\x97\x20\x6c\xd7\xee\xd4\xc8\x4a\xc3\x40\x42\xee\xfd\x3d\x00\x14\xc8\x49\x02\x2c\x09\x00\x00")
 
 func metricsServerMetricsServerDeploymentYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -251,7 +297,7 @@ func metricsServerMetricsServerDeploymentYaml() (*asset, error) {
 	return a, nil
 }
 
-var _metricsServerMetricsServerServiceYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x8e\x3f\x4b\x04\x31\x10\xc5\xfb\x7c\x8a\x61\xfb\x28\xe2\x15\x92\xd6\x5a\x38\x50\xec\xe7\x72\x0f\x0d\x97\x4d\xc2\xcc\xec\x82\xdf\x5e\x76\xf6\x9a\x83\xed\x92\x37\xef\xcf\x2f\xc6\x18\x78\x94\x6f\x88\x96\xde\x12\xad\x2f\xe1\x56\xda\x35\xd1\x27\x64\x2d\x19\x61\x86\xf1\x95\x8d\x53\x20\x6a\x3c\x23\xd1\x0c\x93\x92\x35\x2a\x64\x85\xdc\x65\x1d\x9c\x91\xe8\xb6\x5c\x10\xf5\x4f\x0d\x73\x20\xaa\x7c\x41\xd5\x2d\x49\x7e\x91\x06\x83\x3e\x95\xfe\xbc\x37\x4d\x1f\x0f\x55\xd3\x81\x31\xd7\x45\x0d\xe2\x8e\xb2\x2d\x4c\x26\x0b\xa6\xa0\x03\x79\x2b\x56\x54\x64\xeb\x72\x1f\x79\xd3\xc8\x63\x1c\x30\x8e\x2e\xe6\x24\xd1\x9f\x89\x4e\xa7\x57\x8f\xec\x24\xbf\x66\x43\xfd\x3f\xa4\x5b\xcf\xbd\x26\xfa\x7a\x3f\xbb\x62\x2c\x3f\xb0\xb3\xa7\x76\xdf\x7f\x00\x00\x00\xff\xff\x7e\x3b\x1f\x83\x35\x01\x00\x00")
+var _metricsServerMetricsServerServiceYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x8e\xbd\x6a\x03\x31\x10\x84\x7b\x3d\xc5\x72\xbd\x12\x42\x5c\x04\xb5\xa9\x03\x86\x84\xf4\xb2\x3c\xc4\xc2\xba\xd3\xb2\x3b\x77\x90\xb7\x0f\x27\xbb\x09\xb8\x93\x66\xe7\xe7\x8b\x31\x86\xac\xf5\x1b\xe6\xb5\x2f\x49\xb6\x97\x70\xad\xcb\x39\xc9\x27\x6c\xab\x05\x61\x06\xf3\x39\x33\xa7\x20\xb2\xe4\x19\x49\x66\xd0\x6a\xf1\xe8\xb0\x0d\x76\x97\x5d\x73\x41\x92\xeb\x7a\x42\xf4\x5f\x27\xe6\x20\xd2\xf2\x09\xcd\xf7\xa4\x8c\x8b\x2d\x20\xfc\xa9\xf6\xe7\x5b\xd3\xf4\xf1\xaf\x6a\x7a\x60\x2c\x6d\x75\xc2\x86\xa3\xee\x0b\x13\x6d\xc5\x14\x5c\x51\xf6\x62\x47\x43\x61\xb7\xfb\xc8\x9b\xc7\xac\xfa\x80\x51\xbb\x71\x90\xc4\xf1\x4c\x72\x38\xbc\x8e\xc8\x8d\xe4\x42\xaa\x8f\xbf\x5a\x67\x2f\xbd\x25\xf9\x7a\x3f\x0e\x85\xd9\x7e\xc0\x63\x37\x26\xb9\x90\xea\xe1\x6f\x00\x7e\x3b\x1f\x83\x35\x01\x00\x00")
 
 func metricsServerMetricsServerServiceYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -271,7 +317,7 @@ func metricsServerMetricsServerServiceYaml() (*asset, error) {
 	return a, nil
 }
 
-var _metricsServerResourceReaderYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x90\x41\x4b\xc4\x30\x10\x85\xef\xf9\x15\xc3\xde\xd3\xc5\x9b\xe4\xa6\x1e\xbc\xaf\xe0\x3d\x4d\x9f\xbb\x63\xdb\xa4\xcc\x4c\x2a\xfa\xeb\xa5\xb6\x2a\xb8\xb0\x2c\x78\x4a\x98\xe4\x7d\x8f\xf9\xbc\xf7\x2e\x4e\xfc\x0c\x51\x2e\x39\x90\xb4\x31\x35\xb1\xda\xa9\x08\x7f\x44\xe3\x92\x9b\xfe\x56\x1b\x2e\xfb\xf9\xc6\xf5\x9c\xbb\x40\x0f\x43\x55\x83\x1c\xca\x00\x37\xc2\x62\x17\x2d\x06\x47\x94\xe3\x88\x40\xfa\xae\x86\x31\x8c\x30\xe1\xa4\x5e\x21\x33\xc4\x49\x1d\xa0\xc1\x79\x8a\x13\x3f\x4a\xa9\x93\x2e\x09\x4f\xbb\x9d\x23\x12\x68\xa9\x92\xb0\xcd\x72\xe9\xa0\xfb\x0d\xe0\x88\x66\x48\xbb\x3d\x1d\x61\xd7\x31\xa6\xd2\xe9\x2f\xec\x1c\xb2\x9c\x03\xeb\x7a\x79\x8b\x96\x4e\xee\x7f\x26\xee\x39\x77\x9c\x8f\xd7\x0b\x29\x03\x0e\x78\x59\xbe\x7d\xaf\x73\xa1\xd2\x11\x9d\xbb\xbf\x5c\xa0\xb5\x7d\x45\xb2\x2f\xe9\x6b\xf6\x09\x32\x73\xc2\x5d\x4a\xa5\x66\xfb\x89\xff\xc9\xad\x63\x9d\x62\x42\xa0\xbe\xb6\xf0\x2b\xdf\x7d\x06\x00\x00\xff\xff\xdb\x55\x9e\x61\x2a\x02\x00\x00")
+var _metricsServerResourceReaderYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x90\xc1\x4e\xeb\x30\x10\x45\xf7\xfe\x8a\x51\xf7\x4e\xf5\x76\x4f\xde\x01\x0b\xf6\x45\x62\xef\x38\x97\x76\x48\x62\x47\x33\xe3\xa0\xf2\xf5\x28\x24\x80\x44\xa5\xaa\x12\x2b\x5b\x63\xdd\x73\x3d\xc7\x7b\xef\xe2\xc4\xcf\x10\xe5\x92\x03\x49\x1b\x53\x13\xab\x9d\x8a\xf0\x7b\x34\x2e\xb9\xe9\xff\x6b\xc3\x65\x3f\xff\x73\x3d\xe7\x2e\xd0\xc3\x50\xd5\x20\x87\x32\xc0\x8d\xb0\xd8\x45\x8b\xc1\x11\xe5\x38\x22\x90\x9e\xd5\x30\x86\x11\x26\x9c\xd4\x2b\x64\x86\x38\xa9\x03\x34\x38\x4f\x71\xe2\x47\x29\x75\xd2\x25\xe1\x69\xb7\x73\x44\x02\x2d\x55\x12\xb6\x59\x2e\x1d\x74\xbf\x01\x1c\xd1\x0c\x69\xb7\xa7\x23\xec\x36\xc6\x54\x3a\xfd\x81\x5d\x42\x96\x73\x60\x5d\x2f\x6f\xd1\xd2\xc9\xfd\xcd\xc4\x3d\xe7\x8e\xf3\xf1\x76\x21\x65\xc0\x01\x2f\xcb\x8f\xbe\xd6\xb9\x52\xe9\x88\x2e\xdd\x5f\x2f\xd0\xda\xbe\x22\xd9\xa7\xf4\x35\xfb\x04\x99\x39\xe1\x2e\xa5\x52\xb3\x7d\xc7\x7f\xe5\xd6\xb1\x4e\x31\x21\x50\x5f\x5b\x78\x3d\xab\x61\x74\x1f\x03\x00\xdb\x55\x9e\x61\x2a\x02\x00\x00")
 
 func metricsServerResourceReaderYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -291,7 +337,67 @@ func metricsServerResourceReaderYaml() (*asset, error) {
 	return a, nil
 }
 
-var _rolebindingsYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x94\x31\x6f\xe3\x30\x0c\x85\x77\xfd\x0a\x21\xbb\x72\x38\xdc\x72\xf0\xd8\x0e\xdd\x03\xb4\xbb\x2c\xb1\x09\x6b\x59\x14\x48\x39\x41\xfb\xeb\x0b\xc7\x4e\xd2\xc4\x76\xe0\xb4\xe9\x66\x0b\xe2\xfb\x48\xbe\x07\xd9\x84\x2f\xc0\x82\x14\x0b\xcd\xa5\x75\x4b\xdb\xe4\x0d\x31\x7e\xd8\x8c\x14\x97\xd5\x7f\x59\x22\xfd\xd9\xfe\x55\x15\x46\x5f\xe8\xc7\xd0\x48\x06\x5e\x51\x80\x07\x8c\x1e\xe3\x5a\xd5\x90\xad\xb7\xd9\x16\x4a\xeb\x68\x6b\x28\x74\xd5\x94\x60\x6c\x42\x01\xde\x02\x9b\xf6\x37\x40\x36\xd6\xd7\x18\x15\x53\x80\x15\xbc\xb6\xb7\x6d\xc2\x27\xa6\x26\x5d\x21\x2b\xad\x07\xe0\x23\x47\xde\x25\x43\x5d\x1c\xf5\x13\xf6\x0c\x69\xca\x37\x70\x59\x0a\x65\x6e\x82\x3c\x0b\xf0\xc4\x14\x4a\x19\x63\xd4\xf7\xb7\x35\xb2\xa6\x43\xfb\xff\xc4\x38\x8a\x99\x29\x04\x60\xc5\x4d\x80\xb3\xc6\xa5\xad\x30\x7a\xb1\x50\x5a\x33\x08\x35\xec\xa0\x3f\x8b\xe4\x41\x94\xd6\x5b\xe0\xb2\x3f\x5a\x43\x9e\x59\x6b\x6b\x90\x64\xdd\xa5\x40\x40\xc9\xfb\x8f\x9d\xcd\x6e\x33\xa2\x15\x21\xef\x88\x2b\x8c\xeb\x7e\xde\x31\xf1\xee\x4e\xa2\x80\x0e\xf7\x04\xa3\x5d\xb7\x0c\x87\x9e\x6f\x45\x8e\x10\x20\xfa\x44\x18\x73\xa7\x9d\xc8\x4f\x69\xb6\x0b\x39\x69\xff\xd0\xc5\xe9\xcc\x4f\x98\x79\xff\xb0\x9f\x03\x4e\x49\x6f\x67\x9c\xc7\xb8\x48\xfb\x75\xc0\xfd\x63\xff\x35\x07\xa6\x4d\xf0\x64\xe4\x07\x49\x1b\xc6\x60\x76\xa8\x7e\xcd\xf8\x91\x71\xee\x67\xfa\x50\xfc\xdc\xf0\xae\x72\x8f\x18\x3a\x79\x78\x1d\xe6\xb5\xf1\x19\x00\x00\xff\xff\x20\xa2\xda\xb0\x09\x06\x00\x00")
+var _nodelocaldnsYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xdc\x57\x5b\x4f\x23\x3b\x12\x7e\xcf\xaf\x28\x05\xf1\x46\x87\xdb\x32\x82\x96\xe6\x21\x22\xd9\x19\xb4\x21\x20\x02\x2b\x8d\x56\xab\xa8\xe2\xae\xa4\xad\xb8\x6d\x8f\x5d\x0d\x44\x2c\xff\x7d\xe5\xbe\x25\x0d\x09\x33\x73\xe6\xe5\x9c\xa3\x6e\x45\xe9\xaa\x72\xb9\x6e\xfe\xaa\x8c\x56\xfe\x9b\x9c\x97\x46\xc7\xf0\x78\xdc\x59\x4a\x9d\xc4\x30\x21\xf7\x28\x05\xf5\x85\x30\xb9\xe6\x4e\x46\x8c\x09\x32\xc6\x1d\x00\x8d\x19\xc5\xa0\x4d\x42\x91\x32\x02\x55\x94\x68\x5f\x91\xbd\x45\x41\x31\x2c\xf3\x19\x45\x7e\xe5\x99\xb2\x4e\x14\x45\x9d\xbd\x37\xd2\x30\x37\xee\x09\x5d\xe2\x41\xa8\xdc\x33\x39\xf8\x9e\x93\x93\xe4\x81\x0d\x5c\x1a\x47\x83\xf1\x04\x38\x75\x26\x5f\xa4\xc0\xa9\xf4\xe0\x4b\x73\x0e\x00\x3d\x70\x4a\xe5\x0e\x89\x6e\x18\x80\x49\xe2\xc8\xfb\xce\x1e\x64\xb8\x82\x19\x81\xd4\x4c\x4e\x90\x65\x4a\xc0\xe8\x62\x51\x30\xa2\xd7\xf9\xc8\xdd\x2d\x7e\xd6\x3b\x45\xb9\xf5\xec\x08\xb3\xdd\xae\x02\x28\x9c\x91\xf2\x21\x48\x00\xcb\x73\x1f\xa1\xb5\x6b\x0d\x25\x35\x9f\x91\xd3\xc4\xe4\x7b\xd2\x1c\x96\xa1\xec\xfe\x2b\x9f\x05\x97\x1f\xaa\x1d\xba\x1d\x6f\x49\x04\x2d\xd6\x38\x2e\xd4\x45\x95\x35\xb5\x9a\xc0\x88\xe1\xec\xb4\xd0\x69\x9d\x61\x23\x8c\x8a\xe1\x61\x70\x5b\x50\x18\xdd\x82\xf8\x76\x2d\xb4\xb1\x3e\x62\x61\x3f\xd2\x71\x7f\xb9\x43\x87\x27\x45\x82\x8d\xdb\xe5\x5e\x99\xe9\xe9\xf4\xf6\x6a\x34\xea\xdf\x4d\xa7\x97\xa3\x87\xc9\xfd\xf0\x6e\x3a\x1d\x8c\x27\xd3\x29\x48\x0f\x8e\xac\x42\x41\x09\xcc\x56\x6f\x2b\xe2\x49\x72\x5a\x24\xa9\x4a\x24\x98\x79\x2b\xd1\x4d\xf8\xeb\x8c\xf7\x3a\x7b\xd0\x57\xaa\xa9\x1c\x74\x54\x97\x15\x25\x1b\x75\x74\x00\x0e\x39\x25\x07\x9c\xa2\x06\x6d\x74\xf4\xb6\xe6\x66\x24\xf5\x02\x3c\x69\x86\x44\x3a\x12\xac\x56\x61\x7d\xd8\xbd\xc9\xf9\x5e\xb1\x2d\x39\x7f\x00\x3e\xb0\x90\x0b\xeb\xc6\x26\xa1\xaf\xc6\xb3\x07\xd4\x09\x88\xdc\xb3\xc9\x9a\x02\x16\x46\xcf\xe5\x22\x77\xc8\xd2\x68\xf0\x2c\x95\x02\xb4\x56\xad\x76\xd4\xe0\x65\x21\x7f\x8d\xf6\x37\x4e\x5b\xbd\x2a\xd8\x30\x97\x8a\x62\xf8\x5f\x91\xad\xfd\x97\x3a\x1b\x83\x9b\xeb\xfe\xd5\xf8\x75\x3f\x3e\x3b\x85\x97\x82\x17\x5e\x72\xce\x38\xdf\x7c\x0a\x14\x29\x6d\xb0\xc3\xeb\x73\x21\x42\x62\x2e\x2e\xce\xff\x01\xa7\x47\x2d\x5e\x42\x5a\xa2\x2a\x59\x67\x0d\xe7\xb5\xf9\xe7\x48\x19\x4c\x9a\x4f\x65\x8c\x6d\x3e\x66\x52\x27\xb0\xff\x32\xbe\x19\x0c\xa7\xa3\x9b\xcb\xfe\x28\xd4\xcb\xeb\x7e\xc3\xaf\x92\x0a\xbd\xdd\xa5\xd5\x36\x74\x6e\x9c\xa0\x69\x5d\xe5\x6d\x43\xac\x33\x19\x71\x4a\xb9\x87\xf8\xe2\xa4\xaa\xfd\xf0\xa6\x84\x8a\xd3\x2d\x76\xc4\xe7\x47\xe7\xa5\xb3\xa5\x16\xa9\xa3\x50\xa2\x3d\x74\x16\x7f\x22\x86\xa7\x47\x7f\x91\x20\x54\xde\xd9\x4f\x7f\x53\xcf\x7a\x7f\x26\x97\x7e\x64\x70\x09\xa5\xf7\x29\x81\xb4\x8c\x33\x45\x1e\x5c\x1e\x7e\x0b\xe4\xa1\x67\xca\x2c\x43\xd1\x24\x1d\xce\xe7\x52\xd4\x78\xa5\xa4\x5e\x96\x4d\xb9\x6e\x88\x30\x77\x26\x03\x61\xb4\x26\x51\xe0\x10\x3b\x14\xcb\x00\x78\x01\x30\x33\xd4\xb8\x28\xf0\xb8\xb3\x57\x00\x1a\x2e\x48\x73\x81\x71\x6f\xf0\xd9\x68\xb5\x02\x4f\xec\x21\xb7\x85\x64\xd1\x5f\xe7\x28\x68\x8d\x86\xf5\x96\xd2\xc3\xcc\xe4\x3a\xa0\x70\x1b\xea\xd0\x5a\x7f\xd8\xe0\xdd\x00\x29\x33\x7a\x42\xbf\x33\x5d\xec\x68\xb9\x6f\x96\xd7\xed\xd4\xd1\xa3\x0c\x96\x7c\x95\x9e\x8d\x5b\x8d\x64\x26\x39\x86\x90\xf4\xdc\x26\xc8\x34\x61\x87\x4c\x8b\x55\x90\x05\x70\x46\x29\xa9\x17\x0f\x05\x2b\xae\x52\x96\xe1\xf3\x83\xc6\x47\x94\x2a\xa4\x25\x86\xe3\xa3\xfd\x77\xad\x31\x43\x16\xe9\x68\xc3\xae\xdd\x96\x01\x30\x65\x56\x35\x1b\x6c\x46\x02\xa0\xed\xdd\xc7\x7a\xc2\x83\x5a\x1b\x2e\xda\x8d\x8f\xb7\x94\x58\x98\x38\xca\xc1\xa1\x1b\x0e\x47\x77\x87\x88\x17\x0e\x2d\xc5\xd0\x65\x97\x53\x29\x54\x07\x30\x3c\xd6\x49\xe3\x24\xaf\x2e\x15\x7a\x3f\x2e\xba\x53\xb7\xec\x3e\x51\x11\x76\xe1\x24\x4b\x81\xaa\x56\xef\x5b\x93\xe4\x78\x7b\x7a\x83\x60\x6a\x3c\x8f\x89\x9f\x8c\x5b\xc6\x10\xf6\xae\xe8\x89\xf6\xb7\x46\x49\xb1\x8a\x61\x40\x73\xcc\x15\x57\x0c\x36\x8a\xdc\x5b\x77\x23\x58\xd2\x2a\x86\xee\x65\x65\x46\x3f\x49\x8c\xf6\x37\x5a\xad\xd6\xfe\x02\x18\x1b\x56\x1a\x17\x43\x77\xf8\x2c\x3d\xfb\x35\x33\x02\x9a\xcf\x49\x70\x0c\xdd\xb1\x19\x3e\x93\xc8\x99\xfe\xc8\xd2\x89\x48\x29\xc9\xd5\xcf\xad\x0d\x01\x99\xb4\x8a\xe8\xfd\xb0\x68\x7c\x0c\x4a\xea\xfc\xb9\xe2\x0b\xa3\x19\xa5\x26\xd7\x78\x5f\x4f\x79\x41\x5b\x54\xa0\x74\xc5\x00\x90\x19\x2e\x28\x86\xfd\x97\xc9\xb7\xc9\xfd\xf0\x7a\x3a\x18\xfe\xb3\xff\x30\xba\x9f\xde\x0d\xbf\x5c\x4d\xee\xef\xbe\xbd\xee\x3b\xd4\x22\x25\x77\x98\xc9\x80\xf6\x94\x44\xa1\xd6\xc2\xe4\xb5\xd6\x16\x1f\xf7\x4e\x4e\x7a\x27\x6b\x94\x44\xb7\xf0\x31\xfc\x07\xba\x65\x32\xa5\xed\x1e\x40\xf7\x3d\x32\x06\x6a\x14\xc6\xa1\xf0\xe7\x90\x58\x1c\xd6\xf3\x49\x20\x34\xa3\x9d\x7f\x14\xe1\xfb\xdd\xc8\x17\x88\x91\x27\xce\x6d\x8d\x86\x9f\xe7\xa8\x3c\x95\xf4\xa5\xb4\x4c\xe8\x12\xf3\xa4\x3f\x87\xaa\xe9\xc2\x7f\x1b\xfb\x1c\x79\x93\x3b\x41\x1b\xf5\x11\x50\xe0\x7b\x4e\x9e\x5b\x34\x00\x61\xf3\x18\x4e\xce\xb2\x16\x31\xa3\xcc\xb8\x55\x0c\x67\xd7\x72\x83\xae\x02\x6e\xf8\x78\xab\xe4\xf1\xd1\xd1\x86\xac\x27\x91\x17\x67\xc5\x68\xa6\x67\xde\x5c\x22\xd0\xe2\x4c\x2a\xc9\xb2\x6d\x1d\x04\xe4\x6e\x13\x22\x18\x0f\xef\xa7\xfd\xc1\xf5\xd5\xb8\xa1\x37\x37\x83\x5a\xa4\xa9\x86\x8d\x89\xbd\x7e\xda\x77\x07\x80\xf6\xc0\x5f\x5f\x1a\x7e\x41\x4f\x73\x87\x00\xd8\x7e\x79\xd8\xaa\xeb\xe2\x64\x8b\xb6\x8c\xd8\x49\xe1\x7f\xa8\x4d\xc9\x47\xd2\xe4\xfd\xad\x33\xb3\x0a\x2c\x2b\xd8\x60\xb6\x5f\xa8\x15\xdb\x12\x4b\xe2\x8f\x7a\x74\x78\x2d\x72\x1a\xc3\x61\x39\xfa\xb5\x39\x85\xbd\xcd\xe4\x57\x1d\x21\x2d\x59\xa2\x1a\x90\xc2\xd5\x84\x84\xd1\x89\x8f\xe1\xd3\xa6\x04\xcb\x8c\x4c\xce\x0d\x73\x3d\x0a\x3f\x1a\x95\x67\x74\x1d\x10\xb0\x95\xb5\x32\xa2\xe1\x68\xc8\x45\x54\x0a\x35\x5c\x80\x2c\xc8\xdf\x96\x56\x86\x53\x23\xc2\xb9\x6c\xb2\x58\x8a\xff\x82\x3a\x51\x5f\x30\xd6\x4b\xd6\x79\xd8\x0a\xc9\x95\xe3\x4c\xd9\xc6\x36\x1b\x28\x5b\x1f\xe3\x16\xaf\x0e\x6c\xcd\xec\xcd\xd0\x53\xe7\xff\x03\x00\x4a\x96\xa7\x0b\x66\x10\x00\x00")
+
+func nodelocaldnsYamlBytes() ([]byte, error) {
+	return bindataRead(
+		_nodelocaldnsYaml,
+		"nodelocaldns.yaml",
+	)
+}
+
+func nodelocaldnsYaml() (*asset, error) {
+	bytes, err := nodelocaldnsYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "nodelocaldns.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _npdYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x59\x6d\x6f\xdb\x38\x12\xfe\xee\x5f\x31\x55\xdb\x6d\xbb\x8d\xec\xa4\xb9\xe0\x7a\x5a\xe4\x80\x6c\x92\x36\x45\x93\xb8\x88\x93\x03\x16\x4d\x61\xd0\xd4\xc8\xe6\x8a\x22\xb5\x24\xe5\xd4\x7d\xf9\xef\x07\x52\xaf\xb6\x25\xc7\xb9\xf6\x16\x2a\x1a\x9b\x9c\x79\xe6\xe1\x70\x38\x43\x8d\x7d\xdf\xef\x91\x94\xfd\x07\x95\x66\x52\x04\x30\xdf\xeb\xc5\x4c\x84\x01\x8c\x50\xcd\x19\xc5\x23\x4a\x65\x26\x4c\x2f\x41\x43\x42\x62\x48\xd0\x03\x10\x24\xc1\x00\x84\x0c\xd1\x4f\x95\x9c\x70\x4c\xfc\x10\x0d\x52\x23\x55\x31\xab\x53\x42\x31\x80\x38\x9b\xa0\xaf\x17\xda\x60\xd2\x5b\x35\xa4\x26\x84\xf6\x49\x66\x66\x52\xb1\x2f\xc4\x30\x29\xfa\xf1\x6b\xdd\x67\x72\x50\x51\x38\xe6\x99\x36\xa8\xae\x24\xc7\x16\xfb\x39\x6e\xd0\x4e\x43\x65\x1c\x75\xd0\xf3\x81\xa4\xec\xad\x92\x59\xaa\x2d\x71\x1f\x3c\xaf\x07\xa0\x50\xcb\x4c\x51\x2c\xc6\x2c\x82\xee\x01\xcc\x51\x4d\x8a\xa1\x29\x9a\x07\xe8\x0e\xb4\x21\x26\x5b\x81\x48\x89\xa1\xb3\xed\x40\x70\x8e\xc2\xac\xa8\x53\x85\xc4\x60\x03\xc9\x7e\xca\xd2\xd0\x0e\xfe\x98\x2b\x7f\x67\x22\x64\x62\xfa\x60\x8f\x4a\x8e\x57\x18\x59\xc6\xe5\x92\x36\x58\xee\x01\xac\xef\xe1\x56\x76\x74\x36\xf9\x13\xa9\x71\x9b\xd7\x1a\x89\x25\xca\x0f\xc6\x5f\xed\x1a\x29\x22\x36\xbd\x20\x69\x8b\x47\x5a\x6d\xf8\xd4\x69\x74\x9b\x2a\x31\x62\x54\x02\xb9\x9f\x48\xc1\x8c\x54\xfd\x3f\xb5\xdd\xac\x6f\x3d\x00\x80\xaf\xee\x7f\x00\x2f\xe5\xd9\x94\x09\x2f\x00\x2f\x4e\xf4\xd4\xdb\x29\xc7\xb9\x9c\x7e\x20\x66\x66\x27\x06\x21\xce\x07\xab\xb3\x32\x9e\x10\x1a\xdb\xe9\x83\xa4\x1e\x9f\x64\x51\x84\x6a\xc4\xbe\xa0\x17\xc0\xde\x6e\x35\x9e\xc7\xbb\x95\x5e\xe6\x54\x6b\x52\x29\x42\x66\x37\x50\x7b\x01\x7c\x2c\x46\x6b\x9e\xf6\xf1\xcc\x22\xb5\xb8\xde\x7b\x87\x71\x82\x24\xe4\x92\xc6\x15\x86\xfd\xe7\x29\x24\x5a\x8a\x5a\xea\x8c\xe8\x4b\xd9\x2e\x9a\xa0\xd6\x64\xda\x60\x05\x33\xa2\x41\x48\x08\x4b\xf1\x4a\xfa\xfb\xce\x66\x46\x57\x48\x42\x29\xf8\xe2\x0d\xe3\x98\xef\x77\x17\xab\x5a\xe2\x9d\xbe\x94\xc6\x2a\x0e\x05\x5f\x74\x32\xab\xe5\x81\x59\x72\x06\x14\x92\xd0\xb7\xc6\x1a\xf4\x8a\x4f\x9f\x4a\x14\xcf\xe5\x9f\xfb\x3d\x69\x30\x49\xa5\x22\x6a\xd1\x45\x77\x38\xbc\x78\xcf\x38\x67\xa2\xde\x7c\xfb\x78\x29\x31\x06\x95\x13\xb1\xf3\x18\x42\xaa\x24\x45\xad\xe1\xf6\x36\x7c\x09\xcf\xfb\x2f\x5f\x80\x91\x86\x70\x7f\x9e\x04\x76\x28\xfe\x7d\x07\x88\x90\xc2\x57\x5a\x57\x03\x11\xe3\xd8\x18\xe8\xff\xba\xbd\xc7\xef\x65\x7e\x4d\x74\x7c\x96\x6d\xe0\x6d\x88\x8e\xe1\xe3\xed\xed\x08\x3e\xbd\x0c\x6e\x6f\xef\x5e\xc2\xc4\xc6\x08\x86\x10\x49\x05\x89\x54\x08\x66\x46\x04\xb8\x29\x8d\x36\x40\xf5\xed\x6d\xff\x27\x52\x3c\xb6\xf6\xf2\xa3\x71\x23\xb4\x21\x13\x8e\x9d\x6c\x69\x2d\x1b\x40\xff\xd7\xac\x90\x7f\x88\xcb\x52\x54\x09\x11\x28\xcc\xb2\x91\xea\xe4\x6d\x7f\xb4\x8e\x6e\xde\x8c\x6e\x12\x9b\x0d\xef\xf7\x70\xe6\xe4\x6e\x6f\xfb\x24\x8b\xf4\xff\xc5\xcf\xdb\xac\xeb\x7f\x3b\xa0\xed\xa7\xb3\xb1\xc2\x2b\x74\xab\x63\x62\x0a\x51\xa5\xb7\xf1\x84\xf6\xca\x6f\xf1\xbe\xde\x36\x33\xd3\x4c\x1b\x59\x13\x2e\x66\x8e\x5d\x15\xf0\x82\x86\x53\x3c\x26\xe6\x32\xc6\x31\x13\x06\xd5\x9c\x70\xab\xbc\xbf\xab\x1b\xf4\x3d\xc3\x12\x94\x99\xb1\x33\x7b\xcb\x33\x09\xf9\x3c\x96\x99\x49\x33\x33\xe6\x28\xa6\x2e\xf7\xbf\xae\x52\x78\xee\x50\x9a\x29\x85\x82\x2e\xbc\x00\xf6\x1b\x33\x28\xc8\x84\xe3\xb8\x48\x5b\x63\x3a\x23\x62\x8a\xe3\x09\xd1\x18\x8e\xab\x6d\x18\xe7\xb7\x07\x2f\x80\x88\x70\x8d\xbd\x95\xcd\x6d\xd6\x88\xda\x35\x15\xc1\x07\x15\x88\x63\x29\x0c\x61\x02\xd5\x95\xdd\x9c\x04\x3f\xe4\x15\xb4\x6b\xcb\x57\xc5\xdf\xe9\x33\x24\xdc\xcc\xba\xb3\x32\x2d\x35\x42\x9b\x95\xa3\x4c\x50\xcb\xcc\x86\x41\xaa\x64\x8a\x6a\x69\xeb\x77\x36\x93\x3d\x21\x86\x9c\x30\xf5\x41\xa1\xd6\x99\xc2\x2e\x92\x85\xd8\x19\xd1\xa3\x2c\x8a\x18\x65\x28\xcc\xc8\xd6\xfe\x4e\x92\x83\x39\x51\x03\xce\x26\x03\x45\x04\x9d\xa1\x72\xe5\x4d\x57\xca\x10\x29\x44\x70\x37\x95\xed\xc9\xba\x94\x35\x8a\xf1\xae\x8b\xa6\x13\x78\xa7\x47\x0b\x41\x67\x4a\x0a\xf6\x05\xc3\x4e\x82\xc5\x71\x71\xa9\xcd\xfa\x51\x37\x95\xd6\x8f\xce\x43\x8b\xdb\x36\x79\xe1\x87\x23\xe5\x46\xcc\xda\x62\x25\x2d\xef\x4e\xf9\x5d\x6d\x40\x67\x48\x63\xbf\x0e\x9b\xbe\x9e\x2d\x2b\x34\xce\xe5\x81\xfe\xb9\xb9\xef\x61\x01\xf6\x4e\x9f\xcb\xbb\xa1\x68\x09\xad\xf6\x35\xd9\xcb\xa6\x1f\x32\xf5\x77\xae\x68\xdb\x28\xbc\x94\xa6\x3b\x10\x3b\xb6\xc8\x6a\x6e\xbd\x96\xe2\x53\x9d\xd5\x1f\xc3\xb1\x4b\xd6\x90\xe7\x68\x0d\xf8\x99\x19\xd8\x85\xbb\x19\x0a\x30\x33\x74\x6f\xad\x36\xd6\x8b\xa8\xd9\x81\xbd\x7c\x8e\x40\x71\xcd\xb7\x93\xf9\xcb\x08\x86\xf6\xca\x14\xc2\xab\x5a\xdb\xbe\xe8\xa1\x95\xc8\x44\x2c\xe4\x9d\xe8\xf7\x00\x5a\x42\xab\xac\x25\x8f\x1f\x0d\x26\x4c\x0c\xf4\xcc\xf1\x1b\x0d\x8f\xdf\x9f\x5e\x1f\x0e\x54\x26\x06\xf1\xbe\x1e\xd4\x2a\x8d\x8f\x7d\x2d\x69\xec\xc4\x59\x04\x1f\xe1\x11\xf8\x23\xf0\x9e\x7c\xcd\x75\xbf\x7b\xf0\xe9\x37\xbb\x0c\x51\x2c\x1c\xe9\x4c\x2e\x65\x43\xab\x8d\x06\x2a\x05\x08\x25\xe6\xd7\x56\xfc\xcc\xb4\x29\x5d\xe7\xbc\xb2\xe7\xbe\x44\xcc\xfd\x39\x1f\xbe\x3d\x5c\x4d\x58\x8e\x25\x99\xa2\x30\x1d\x5c\xb9\x9c\xd6\x54\x7d\x01\xde\x93\xe7\x11\x13\xa1\x25\x7c\x3e\x7c\xfb\xdd\x03\x3f\x49\x98\x00\xff\x00\x5e\xfd\xdb\xbd\xc5\x88\x8c\xf3\x17\x1e\x7c\x82\x5f\x7e\x01\x43\x18\x07\x5f\xc0\xde\xee\x6e\xad\xf0\x0d\xa6\x0a\x53\xf0\xff\x82\x67\x1c\xe7\xc8\x0f\x23\x62\x08\x7f\xb6\x79\xd1\x36\xaf\x2a\xa4\x28\x0c\x5f\x00\x97\xd3\xa9\xbd\xdb\x58\x3d\x40\xa5\xa4\xd2\xdd\xab\x76\x7e\xd8\xad\x36\xb1\x71\x96\xda\xb7\xf0\xfa\xec\xea\x74\x74\x36\x3c\x3f\x39\xfc\x97\xd5\x02\xb8\x19\x1d\xbd\x3d\x3d\x7c\xf2\x3c\x8c\xc0\xff\x00\x6b\x19\xbf\xb1\x6c\xf8\x06\xe4\x2e\x86\x67\x97\x57\x87\x87\xaf\xe0\xab\xce\x26\xcf\xbd\xa7\xde\x0e\x78\xde\x0e\x3c\x39\x78\xf1\x1b\xa4\x8a\x09\x03\x4f\x0e\xbe\x3f\x7b\xd1\x70\xea\x17\xeb\x1b\x67\xa5\x63\xf3\x33\x57\xfb\xc1\xd8\xd7\x26\x83\x2a\x61\x02\x21\x64\x3a\x86\xcc\x16\x4b\x77\xc7\x5b\xa5\xb5\xe4\x8f\x57\x4d\x7f\xb8\x98\x6b\x18\xf4\xa7\x68\xed\x57\xcb\xee\xe0\xb0\xb6\x6e\xa6\xa1\x04\x79\x0a\x51\xc6\xf9\xd6\x5b\x50\x9e\xff\x76\xff\x3f\x2e\x7a\x07\xa1\x6f\xf3\xb5\xad\x57\x61\xd1\x29\xd1\x60\x66\xf6\x2a\xc0\x38\x82\x14\xd4\xde\x69\x11\x1c\x98\x2b\xbb\x13\x44\xb1\x54\xdf\xea\xe5\x3e\x02\x3f\x04\x77\x28\x0b\xec\x41\x89\xdd\xba\x56\x3b\xd9\x40\x72\x9d\x2b\x97\x17\x32\x5d\xbe\x21\x92\x39\x61\xdc\xee\xca\x3d\x8e\x7e\x04\x3e\xb6\x5b\x1e\x34\xa9\xb6\xd2\x58\xad\xdd\xd6\x6e\x53\xe9\x5e\x87\xaf\xf6\x43\x48\x9a\xea\xba\x5f\x74\x42\x30\x91\x62\x84\x3f\xa1\xf1\x07\xc0\xc9\x04\xb9\xeb\x6b\xd9\xde\x51\xda\x85\xa0\x53\xa4\x56\x48\xe1\x9c\x59\x4e\x67\x4c\x1b\xa9\x16\xe7\x2c\x61\x26\x70\x21\xa2\x91\x3b\x51\x2b\x05\x90\xd8\xbe\xd8\x79\x03\x7b\x23\x3a\x80\x7d\x1f\xe4\xc4\x60\xa1\xdd\x58\x17\xc0\x32\xc9\x7b\xa1\x00\x4a\xb2\xf6\x49\x15\x93\x8a\x99\xc5\x31\x27\x5a\x5f\xba\xc6\x91\x97\x2f\xde\x77\x2b\xa5\x8a\x19\x46\x49\x75\x08\xf4\x52\x43\xeb\x72\xa3\x53\xed\x63\x24\x47\xe5\x02\xad\xc1\xcf\x87\x18\x17\xb6\xd4\x16\xe0\x47\x61\x28\x85\x1e\x2e\xbd\xf5\x00\xd8\xcb\x30\xb1\x1e\x03\xef\xd4\x16\x00\xed\xad\x01\x38\xcb\x4a\x72\xec\xdb\x1e\x96\x12\x68\xd0\xb5\x61\x6d\x9e\x57\x92\xfb\x29\x27\x02\xb7\xc4\x04\xc0\x28\x42\x6a\x02\xf0\x2e\xe5\x88\xce\x30\xcc\x38\x6e\x6f\x32\x21\xb6\x49\xf9\x33\x6c\x55\xd5\xa1\xf2\x98\x7f\x4f\xf4\x3a\x21\x60\x09\x99\x62\x00\x4f\xbf\x8e\xfe\x18\x5d\x9f\x5e\x8c\x4f\x4e\xdf\x1c\xdd\x9c\x5f\x8f\xaf\x4e\xdf\xbe\x1b\x5d\x5f\xfd\xf1\xfd\x69\x91\xe2\x06\x09\xb3\xb5\x05\x43\xbf\x15\x30\x98\xef\xf6\x5f\xf7\xf7\xf6\x2b\x60\x2a\x93\x84\x88\xb0\xb9\x7f\x83\xcd\x54\x7c\xf0\x7d\x2e\xa7\x46\x6a\x13\xa2\x5a\x1e\xcf\x6f\xb5\xfd\x22\xc8\xb8\x9c\x96\xef\x6b\x87\xe5\x6d\xaa\xa5\xf7\xd8\x86\x90\xbf\xda\xfa\xf9\x6d\x69\x1d\x64\x5f\xb7\x23\xa0\x98\x37\x57\x92\x7b\xf6\x72\x78\x72\x3a\xbe\x3c\xba\x38\xad\x66\x00\xe6\x84\x67\xf8\x46\xc9\xa4\x16\xb7\x4f\xc4\x90\x87\x45\x37\xb9\xf9\xb8\x71\xdb\xf7\x0c\xdc\x01\xeb\x5b\x0f\xd9\xf3\x51\x89\x2d\x75\xce\xcb\x47\xe1\x5f\x19\x6a\xb3\x34\x06\x40\xd3\x2c\x80\xbd\xdd\x64\x69\x30\xc1\x44\xaa\x45\x00\xff\xd8\xbd\x60\x8d\x09\x6e\x73\x8c\x0e\x5a\x45\xf7\x76\x9b\xb2\x1a\x69\xe6\xce\xba\x14\x06\x3f\x9b\xa6\x4a\xaa\xd8\x9c\x71\x9c\x62\x18\x80\x51\x59\x4d\x7a\x2e\x79\x96\xe0\x85\xed\x54\xe8\x75\xb7\x55\xdd\xe4\xf2\x71\x2d\x8d\xdc\x09\x83\xb5\x49\x55\xb4\x44\x56\x4c\x94\x60\xb6\x4d\xdc\x01\x55\x76\x91\xb7\x07\xe3\xb2\x0b\xcb\xd5\x7b\xf9\x00\xa8\xfa\xb6\xd6\x81\xb8\x7e\x29\xde\x1e\xbc\xbc\xb6\x75\x40\xaf\x5c\x4e\xb6\xc7\x2d\xab\x71\x07\x6e\xa3\x70\x6f\x8f\xc9\x25\x25\xdc\x02\x77\x80\xa2\xa1\x83\x36\x99\x56\xd8\x3c\xb0\xaa\x98\xea\x88\x28\x5a\xfe\xc0\x51\xc7\xde\x96\xbf\x6f\x94\x4f\x88\x11\xc9\xb8\xb9\x90\x21\x06\xb0\xfb\xcf\x83\x83\x15\x83\x4b\x61\x35\x93\x3a\x0f\x93\x6a\x04\x20\x6d\x0d\xc1\xb6\x38\xdb\xa0\xbd\x1c\x74\x1b\x22\x6b\x03\x46\x67\x98\x75\xc6\xd2\x7d\x84\xd6\x02\xab\x33\x7a\xee\xa1\xb5\x1e\x4a\xf6\x95\x3c\x80\x13\xa6\xdc\xb6\x2c\x86\xea\xb8\xfc\x51\x70\x73\x44\x6d\x30\xd4\x15\x5e\xb9\x29\xdb\x7b\x1d\xaa\x63\x85\xc4\x60\xef\xbf\x03\x00\x04\xac\x0a\x97\x1a\x1e\x00\x00")
+
+func npdYamlBytes() ([]byte, error) {
+	return bindataRead(
+		_npdYaml,
+		"npd.yaml",
+	)
+}
+
+func npdYaml() (*asset, error) {
+	bytes, err := npdYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "npd.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _nvidiaDevicePluginYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x56\x4d\x6f\xe3\x36\x10\xbd\xeb\x57\x0c\x02\xe4\x28\x29\xdb\x6e\x81\x85\x6e\x5e\x5b\x49\x84\x26\x8e\x61\x3b\xe9\xa1\x28\x0c\x9a\x1a\xcb\x6c\x28\x92\xe5\x87\x1b\xef\x76\xff\x7b\x41\xc9\xb2\xa5\xc8\xf6\xba\xd8\x82\x01\x22\x0f\x39\x6f\x86\x6f\x66\x9e\x14\x86\x61\x40\x14\x7b\x41\x6d\x98\x14\x09\x08\x99\x63\xf4\xfa\xc9\x44\x4c\xc6\x9b\x0f\xc1\x2b\x13\x79\x02\x53\x27\x2c\x2b\x71\xc8\x89\x31\x41\x89\x96\xe4\xc4\x92\x24\x00\x10\xa4\xc4\x04\xc4\x86\xe5\x8c\x04\x6b\x22\x72\x8e\x7a\xff\xfb\x3d\xf4\x1a\x79\x19\x51\x62\x2d\xc7\x0e\xfa\x3d\xf2\x72\xb8\x26\xda\x9e\x84\x0e\x73\xdc\x30\x8a\xa1\xe2\xae\x60\x62\xb7\x69\x14\xa1\x98\xc0\xab\x5b\x62\x68\xb6\xc6\x62\x19\x18\x85\xd4\xa7\x45\x3d\x5a\x02\x6b\x6b\x95\x49\xe2\xf8\xfa\xeb\xaf\xcf\x9f\xd3\xe9\x38\x9d\xa7\xb3\xc5\x60\x92\x7d\xbb\x8e\x8d\x25\x96\xd1\xb8\x3a\x68\xe2\x63\x51\xc2\x9b\xe8\xc3\xc7\xe8\x43\x64\x8b\x2f\x01\x80\x25\xba\x40\x3b\x3e\x1e\x16\x60\x43\xb8\x43\x33\x94\xc2\xa2\xb0\x09\xfc\x13\x06\x00\x00\xba\x45\xdb\xb8\xcd\x94\xdf\x54\x9a\x49\xcd\xec\xb6\xb5\x7b\x55\x5f\x23\xf4\x35\x08\xa9\x66\x96\x51\xc2\xaf\x2a\x28\x2a\xc5\x8a\x15\xfe\x6e\x7e\x95\x44\x35\x8f\x00\x39\xae\x88\xe3\x87\xa8\xf5\xba\xfe\x3a\x7e\xc9\x46\xd9\x60\x31\x4a\x5f\xb2\x61\xba\x98\x3c\x3c\xdf\x65\xe3\xc5\xf0\x69\x7c\x9b\xdd\x7d\xbb\xee\x15\x67\x5f\x8d\x19\x6a\xcf\xf5\x80\x52\xe9\xc4\x99\x92\x94\xac\x08\x4b\x22\x48\x81\xfa\x74\x41\xde\x47\xd1\x4b\x42\x23\xe2\xec\x5a\x6a\xf6\x85\x58\x26\x45\xaf\xd7\x86\xdc\x19\x8b\x7a\x2a\x39\x5e\x16\x5c\x3b\x8e\x26\x09\x42\x20\x8a\xdd\x69\xe9\x94\xf1\xb9\x86\x70\xe5\x99\xd3\x68\xa4\xd3\x14\x77\x36\xcf\xac\x09\x00\x36\xa8\x97\x3b\x53\x81\xb6\xfa\xcf\x99\xa9\x1f\xfe\x26\x96\xae\xab\x27\xa7\x72\x62\xb1\x7a\x54\x95\xf1\xa2\x18\x4a\xe6\x97\x87\xc8\x91\xa3\xc5\x1f\xe4\xe9\x33\x13\x39\x13\xc5\x85\x74\x49\x8e\x53\x5c\xf9\xcb\x37\x97\x39\x13\x2f\x00\xe8\x97\xe5\x0c\xba\x71\xcb\x3f\x91\xda\xaa\x1e\x47\xfb\xe9\xff\xe8\xa2\x03\x07\xd5\x54\x3c\x12\x75\xfe\xea\x8a\x68\x8b\x79\x58\xcf\xd0\xe9\x30\x8d\x7f\x7d\x2e\xda\x92\x92\xef\x87\x6a\x3f\x4e\x8f\xd9\xdd\x62\x32\x98\xce\xd3\xd1\x05\xb3\x74\x61\x7e\x85\x72\x21\xe5\x0c\x85\x35\x17\x64\x57\x1f\xec\xa6\xb7\x69\x85\xf6\xbf\x6b\x9f\x3c\x34\xf5\x30\x9b\x04\x7e\xff\xa3\x97\x26\x51\xca\x1c\xfa\x69\x44\xb0\x94\x62\x86\x3f\x3a\xf2\x00\x9c\x2c\x91\x57\xad\xef\x5b\x4c\x1d\x75\x6f\x94\xda\x20\x47\x6a\xa5\xf6\xcf\x5e\xd7\x2c\x5d\x3f\xb4\xdc\x4f\x03\x00\x58\x2c\x15\x27\x16\x77\xae\xad\xa4\x01\xba\x49\x9c\xc7\x01\x68\x92\xf1\xcb\x74\xda\xb5\xad\xda\x3d\xb7\xef\x08\xfc\x7f\x95\x78\x80\xb5\x34\x76\x92\x8d\x12\xb0\xda\x61\xcb\x96\x4d\x86\x1d\x1b\x59\xad\x98\x60\x76\x7b\xb8\x9d\xd7\xb5\x41\xcf\xea\xa5\xe9\x2f\xc7\x34\xe6\x23\xa7\x99\x28\x66\x74\x8d\xb9\xe3\x4c\x14\x59\x21\xe4\xde\x9c\xbe\x21\x75\x7e\xe6\xdb\x9e\x35\xe6\x6c\x57\x9e\x39\xea\xb2\x45\xa6\xff\x0b\xeb\x6a\xa5\x6f\x4a\xa3\xf1\xcd\xf7\x6e\xdf\x9f\x78\xc5\x6d\x43\x49\x44\x65\x19\x97\xac\x88\xf6\x53\xd8\x5d\x52\xa1\x26\xbe\x11\x20\x7d\x63\xa6\x1a\x04\x6f\xb6\x92\x7b\x7b\x1b\xbe\x0f\x5b\x28\x17\x7c\x07\x07\x00\x57\x2b\xa4\x36\x81\xb1\xdc\xd1\xd0\xb0\x49\xa5\xb0\x84\x09\xd4\xad\x08\x67\xda\xbe\x3a\x01\xac\x24\x45\x25\x32\x54\xfb\xb7\x57\x7d\x32\xa6\x5c\xba\x3c\x14\xc4\xb2\x0d\xc6\xaf\x9f\x4c\xdb\x35\xd9\xdc\x44\xbf\x44\x3f\x87\x6e\xe9\x84\x75\x3f\xdd\x44\x37\x1f\xf7\x68\x28\x36\x4d\xec\x43\xf4\xf1\xd3\x28\x5d\x8c\x07\x8f\xe9\x7e\x67\xf7\x9d\x71\xab\x65\x79\x38\xee\xd7\x8a\x21\xcf\x77\xa2\xde\x5e\x95\x7d\x42\xec\x3a\xa9\x7a\x3c\xf2\x25\xf5\x7d\xda\x8b\x55\x4b\xd9\xe2\x36\x7b\xe8\x45\x4b\x20\xee\x69\x68\xdc\x92\xc8\x1e\xd6\xdd\xe4\x79\x31\x7c\xc8\xd2\xf1\x7c\x76\x0a\xb0\x25\x78\x71\x5b\xcf\x7a\x58\xa3\xf4\x76\xf0\xfc\x30\x5f\xb4\x31\x3d\x25\xb3\xc9\x60\x78\x04\xb8\xab\x43\x5d\xa8\xdf\xb2\xf9\xfd\x62\x9a\x7e\x7e\x7a\x9a\xf7\x1d\xaf\x56\x84\x1b\x6c\xe6\xd0\xab\x00\x75\xd5\xe4\xfa\x2f\xba\x37\xdb\xe6\x55\x69\xb6\x61\x1c\x0b\xcc\x3b\x33\x09\xb0\x91\xdc\x95\xf8\xe8\x65\xc3\x24\xbd\xf0\x7e\x8e\x7d\x6a\xfb\x0d\x80\xd2\x1f\xad\xab\x13\xb7\x77\x3a\x2e\x5a\x4a\x7b\xc2\xc7\xef\xf7\xb6\xb4\x54\xa4\xa8\x06\x26\x81\x7b\x69\xec\x5c\x0e\x9b\xfe\xee\x05\x38\xf6\x6e\x3c\x16\xe8\xf4\xb9\x06\xa9\xfb\x06\x3b\x86\xd1\x3f\x51\xf3\xd5\x1b\xba\x1e\x51\xde\x50\xb1\xb4\xb7\x00\xa8\x3e\x6b\x00\x76\xab\x7c\xcb\x30\x5d\x49\xd6\xf6\x18\x6e\x87\xcd\x33\xc0\xef\x7c\x4f\x13\x40\x9b\x37\x7c\x1b\xa4\xa7\x1e\xc7\x7c\xcf\x51\x77\x19\x6a\xdb\xf3\xdf\x01\x00\xb9\xab\xc4\xb1\xc7\x0d\x00\x00")
+
+func nvidiaDevicePluginYamlBytes() ([]byte, error) {
+	return bindataRead(
+		_nvidiaDevicePluginYaml,
+		"nvidia-device-plugin.yaml",
+	)
+}
+
+func nvidiaDevicePluginYaml() (*asset, error) {
+	bytes, err := nvidiaDevicePluginYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "nvidia-device-plugin.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _rolebindingsYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x94\x31\x6f\xdb\x30\x10\x85\x77\xfe\x0a\x22\x3b\x5d\x14\x5d\x0a\x8d\xed\xd0\xad\x43\x80\x76\xa7\xc9\xab\x72\x35\xc5\x23\xee\x4e\x0e\xd2\x5f\x5f\xc8\x96\x63\x2b\x92\x5c\xd9\x55\x46\xd3\xe4\x7b\xe4\xbb\x4f\xcf\x17\xfc\x09\x2c\x48\xb9\xb2\xbc\xf5\x61\xe3\x5b\x7d\x22\xc6\x3f\x5e\x91\xf2\x66\xf7\x59\x36\x48\x1f\xf6\x1f\xcd\x0e\x73\xac\xec\xd7\xd4\x8a\x02\x3f\x52\x82\x2f\x98\x23\xe6\xda\x34\xa0\x3e\x7a\xf5\x95\xb1\x36\xfb\x06\x2a\xbb\x6b\xb7\xe0\x7c\x41\x01\xde\x03\xbb\xee\x67\x02\x75\x3e\x36\x98\x0d\x53\x82\x47\xf8\xd5\xed\xf6\x05\xbf\x31\xb5\xe5\x8a\xb3\xb1\x76\x64\xfc\xea\x23\x2f\xa2\xd0\x54\xaf\xfa\x05\x7b\x0f\x69\xb7\xbf\x21\xa8\x54\xc6\xdd\x64\xf2\x43\x80\x67\x5e\x61\x8c\x73\xce\xdc\x9f\xd6\x44\x4c\xa7\xeb\x7f\x12\x17\x28\x2b\x53\x4a\xc0\x86\xdb\x04\x83\x8b\x4b\x17\x95\xb3\x0f\x0f\xc6\x5a\x06\xa1\x96\x03\xf4\x6b\x99\x22\x88\xb1\x76\x0f\xbc\xed\x97\x6a\xd0\x85\x67\x7d\x03\x52\x7c\x78\x2b\x90\x50\xf4\xa0\xf4\xec\x35\x3c\x4d\x68\x65\xd0\x67\xe2\x1d\xe6\xba\x7f\xef\x94\xf8\x71\x4f\xa1\x84\x01\x0f\x0e\xce\x86\x63\x18\x01\x23\xdf\x6a\x39\xe1\x00\x39\x16\xc2\xac\x9d\x94\xb3\x85\xe2\x9c\x66\x0d\x97\xda\xff\x39\xc5\x79\xe6\x67\x86\xb9\x3e\xec\x43\x83\x33\xe9\xd6\x9e\x73\xbb\xee\xf1\x86\xf6\xeb\x06\xeb\x63\x7f\xc9\x81\xeb\x08\x9e\x45\x7e\x44\xda\x18\x83\xc5\x50\xbd\xdb\xe0\x27\x9e\xb3\xde\xd0\xc7\xe2\xc3\x81\x1f\x4f\x1e\x3e\xcf\xf1\x24\x4f\xed\xb0\xec\x1a\xf7\x04\xf4\xef\x62\x63\xa8\x51\x94\x5f\x5c\x27\xd4\x6f\x38\xb4\x4e\xdf\xaf\x47\xe8\x6e\x2a\x3d\x81\xc0\xa0\x72\xf1\xc7\xf7\x4e\xb4\x0f\x64\xc2\xf3\x02\x8a\x53\x19\xac\x00\xc7\xe2\x3a\x58\x1a\xc1\x7d\xd4\xcc\x75\xc4\xd0\xf5\x4c\x8d\xbb\x49\xfd\x44\xd6\x40\x3e\x53\x04\x31\x7f\x07\x00\x89\x76\x15\x84\x39\x08\x00\x00")
 
 func rolebindingsYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -311,7 +417,27 @@ func rolebindingsYaml() (*asset, error) {
 	return a, nil
 }
 
-var _traefikYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x91\x5f\x6f\xdb\x3a\x0c\xc5\xdf\xfd\x29\x08\x03\x79\xba\x90\xdd\xe4\xa9\xd7\x6f\xb9\xa9\x7b\x57\x6c\xeb\x8a\x38\xdd\xd0\xa7\x80\x91\x99\x58\x88\x2c\x09\x14\x1d\x2c\xeb\xfa\xdd\x07\x25\xe9\x3f\xa0\xc0\x86\x61\x7b\x13\x44\xf2\x77\xc8\x73\x94\x52\x19\x06\xf3\x99\x38\x1a\xef\x2a\xe8\xc8\xf6\x85\x46\x11\x4b\x85\xf1\xe5\x6e\x9c\x6d\x8d\x6b\x2b\x78\x47\xb6\x9f\x75\xc8\x92\xf5\x24\xd8\xa2\x60\x95\x01\x38\xec\xa9\x02\x61\xa4\xb5\xd9\x2a\xcd\xed\xe9\x2f\x06\xd4\x54\xc1\x76\x58\x91\x8a\xfb\x28\xd4\x67\x31\x90\x4e\x23\x3a\x41\x2a\xe8\x44\x42\xac\xca\x72\x74\xff\xfe\xf6\xbf\x7a\x7e\x5d\x2f\xea\x66\x39\xbd\xb9\x7a\x18\x95\x51\x50\x8c\x2e\x0f\x8d\xb1\x7c\x01\x57\x93\x71\x31\x29\xc6\xff\x0c\xe1\xf0\x38\x2b\x64\xf3\x2d\xfb\x83\x07\xfc\xbd\xe5\xdf\x5a\x1c\x20\x92\x24\x28\xc0\xc6\xfa\x15\xda\xe2\x28\x76\x41\x6b\x1c\xac\xcc\x69\x63\xa2\xf0\xbe\x82\x7c\x74\xdf\xdc\x35\x8b\xfa\xe3\xf2\xa2\xbe\x9c\xde\x7e\x58\x2c\xe7\xf5\xff\x57\xcd\x62\x7e\xb7\x9c\x4f\xbf\x3c\x8c\xf2\x0c\x60\x87\x76\xa0\x38\xf3\x4e\xc8\x49\x05\xdf\xd5\x81\x1b\x7c\x3b\x75\xce\xa7\x95\xbc\x8b\x47\x2d\x80\xc0\xbe\x27\xe9\x68\x88\xc9\xa0\xe0\xd3\x45\xf9\xf9\xd9\xf9\x24\x7f\xb3\x21\x6a\xc6\x40\x15\xe4\xc2\x03\x1d\x5b\x02\xfb\x9d\x69\x89\x9f\x90\xc9\x2b\x76\x24\x14\xaf\xdc\x86\x29\x3e\x15\x00\xc2\xb0\xb2\x26\x76\xd4\x36\xc4\x3b\xa3\xe9\xb9\x02\x40\x0e\x57\x96\xda\x14\xc0\x40\x27\xb2\xf1\x6c\x64\x3f\xb3\x18\xe3\xf5\x21\x9c\xfc\x68\x8b\xd2\x76\x88\x42\xac\x34\x1b\x31\x1a\xed\x71\x15\xd3\xe3\xe6\x89\xc9\x14\x7c\x34\xe2\x0f\xae\x31\x3a\xdd\x11\x97\xbd\x61\xf6\x4c\xad\xb2\x66\xc5\xc8\x7b\x75\x0a\xe5\xf1\x5a\xc1\x4d\x05\xf9\xa4\xf8\xb7\x18\x9f\x1d\xff\xc4\x5b\xe2\x97\x9e\x29\xd8\x52\x42\xce\x4e\xd2\xd3\xb6\xf5\x2e\x7e\x72\x76\xff\x08\xf1\x21\x4d\x78\xae\x20\xaf\xbf\x9a\x28\x31\x7f\x35\xe8\x7c\x4b\x8a\xbd\xa5\xe2\xd9\xa9\xe4\xad\xf6\x4e\xd8\x5b\x15\x2c\x3a\xfa\x09\x0b\x80\xd6\x6b\xd2\x29\xac\x6b\xdf\xe8\x8e\xda\xc1\xd2\xaf\xc9\xf4\x98\x9c\xfb\x7d\x7e\x7c\x1d\x9d\x09\x97\xd8\x1b\xbb\xbf\xf1\xd6\xe8\xa4\x7b\xc3\xb4\x26\xbe\x18\xd0\x36\x82\x7a\x9b\x67\x3f\x02\x00\x00\xff\xff\x12\x80\xc2\x85\x56\x04\x00\x00")
+var _runtimesYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\xce\xc1\x8a\xc3\x20\x10\xc6\xf1\xbb\x4f\x31\x78\x4f\x96\x65\x2f\x8b\xd7\xbe\x41\x0b\xbd\x0f\x3a\x6d\x44\x33\x96\x71\xec\xf3\x97\x98\x42\x72\x0d\xe4\xf8\x89\x7f\xfd\x0d\xc3\x60\xf0\x15\xef\x24\x35\x16\x76\xc0\x25\xd0\x98\xfe\xeb\x18\xcb\xcf\xfb\xd7\xa4\xc8\xc1\xc1\xb5\xb1\xc6\x99\x2e\x19\x6b\x35\x33\x29\x06\x54\x74\x06\x80\x71\x26\x07\x09\x15\xcd\x84\x1c\x32\xc9\x77\x55\x3f\x51\x68\x39\xf2\xb3\x5f\x2b\x81\x6e\x94\xc9\x6b\x91\x65\x03\xc8\xfa\xe2\x98\xfe\xfa\x4f\x4b\xe3\xc0\xaa\x34\xb2\xe6\x0c\x92\x34\xae\x7e\x33\xad\xf3\x20\xaa\x47\xa7\xaa\x1e\x51\xc8\x0b\xfa\x44\xb2\xd9\xf6\x87\x07\x85\xbb\xf4\x54\xa7\x97\xc6\x1b\xb0\xaf\x83\xb2\xa5\x71\x60\x55\x1a\x59\xf3\x19\x00\x92\xc4\x4e\x6e\x64\x02\x00\x00")
+
+func runtimesYamlBytes() ([]byte, error) {
+	return bindataRead(
+		_runtimesYaml,
+		"runtimes.yaml",
+	)
+}
+
+func runtimesYaml() (*asset, error) {
+	bytes, err := runtimesYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "runtimes.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _traefikYaml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xb4\x92\x41\x4f\xe3\x48\x10\x85\xef\xfe\x15\x25\x4b\x39\xad\xec\x90\x9c\xd8\xbe\x99\x60\x58\xb4\x6c\x16\x25\x61\x10\xa7\xa8\xd2\xae\x24\xad\xb4\xbb\x5b\xd5\xe5\x0c\x1e\x86\xff\x3e\x72\x12\x02\x0c\x48\x33\x1a\xcd\xdc\xac\x72\xd5\x57\x5d\xef\xbd\x2c\xcb\x12\x0c\xe6\x13\x71\x34\xde\x29\x58\x93\xad\x73\x8d\x22\x96\x72\xe3\xfb\xdb\x41\xb2\x31\xae\x52\xf0\x0f\xd9\x7a\xb4\x46\x96\xa4\x26\xc1\x0a\x05\x55\x02\xe0\xb0\x26\x05\xc2\x48\x4b\xb3\xc9\x34\x57\x87\x5a\x0c\xa8\x49\xc1\xa6\x59\x50\x16\xdb\x28\x54\x27\x31\x90\xee\x46\x74\x07\x51\xb0\x16\x09\x51\xf5\xfb\xbd\xc7\x7f\x6f\xcf\xca\xc9\xb8\x9c\x95\xd3\x79\x71\x73\xf5\xd4\xeb\x47\x41\x31\xba\xbf\x6b\x8c\xfd\x57\xf0\x6c\x38\xc8\x87\xf9\xe0\xaf\x26\xec\x3e\x4e\x72\x59\x7d\x49\x7e\xe3\x01\x7f\xee\xf1\x1f\x3d\x1c\x20\x92\x74\x50\x80\x95\xf5\x0b\xb4\xf9\x5e\xa9\x73\x5a\x62\x63\x65\x42\x2b\x13\x85\x5b\x05\x69\xef\x71\x7a\x3f\x9d\x95\xff\xcd\xcf\xcb\x8b\xe2\xf6\x7a\x36\x9f\x94\x97\x57\xd3\xd9\xe4\x7e\x3e\x29\xee\x9e\x7a\x69\x02\xb0\x45\xdb\x50\x1c\x79\x27\xe4\x44\xc1\xd7\x6c\xc7\x0d\xbe\x2a\x9c\xf3\x9d\x9e\xde\xc5\xfd\x2e\x80\xc0\xbe\x26\x59\x53\x13\x3b\x87\x83\xef\xec\x48\x4f\x4f\x4e\x87\xe9\x87\x0d\x51\x33\x06\x52\x90\x0a\x37\xb4\x6f\x09\xec\xb7\xa6\x22\x3e\x22\x3b\xad\xd8\x91\x50\xbc\x72\x2b\xa6\x78\xfc\x01\x10\x9a\x85\x35\x71\x4d\xd5\x94\x78\x6b\x34\xbd\xfc\x01\x20\x87\x0b\x4b\x55\x67\x40\x43\xbb\x3a\x3d\x04\x62\x53\x93\x13\xb4\xef\xe1\x97\x28\xf4\x19\x5b\x95\xbc\x03\xf4\x1e\xcb\x71\x71\x76\x5d\xce\x2f\x8b\x59\x79\x57\xdc\xef\xa3\x74\xec\x5b\x7d\x3f\xf8\xd1\x6e\x80\x57\xfe\xdf\x78\x6b\x74\xab\xa0\xb0\xf6\x70\xb2\xf1\x6c\xa4\x1d\x59\x8c\x71\xbc\x8b\x7d\xba\xf7\x2b\xd3\xb6\x89\x42\x9c\x69\x36\x62\x34\xda\xbd\x46\xa6\xc6\xd5\xf1\x58\xa6\xe0\xa3\x11\xbf\xb3\x93\xd1\xe9\x35\x71\xbf\x36\xcc\x9e\xa9\xca\xac\x59\x30\x72\x9b\x1d\xd2\xf2\x6c\x83\xe0\x4a\x41\x3a\xcc\xff\xce\x07\x27\xfb\x9a\x78\x4b\xfc\xda\xcc\x0c\x36\xd4\x21\x47\x87\xd5\x45\x55\x79\x17\xff\x77\xb6\x7d\x86\xf8\xd0\x4d\x78\x56\x90\x96\x0f\x26\x4a\x4c\xdf\x0c\x3a\x5f\x51\xc6\xde\x52\xfe\xa2\x72\x67\xba\xf6\x4e\xd8\xdb\x2c\x58\x74\xf4\x03\x16\x00\x2d\x97\xa4\xbb\x14\x8d\xfd\x54\xaf\xa9\x6a\x2c\xfd\xdc\x9a\x1a\x3b\xe5\x7e\x9d\x1f\xdf\x66\xca\x84\x0b\xac\x8d\x6d\x9f\xcd\x4b\x6f\x98\x96\xc4\xe7\x0d\xda\xa9\xa0\xde\xa4\xc9\xb7\x01\x00\xc6\x12\xd7\x2c\xef\x04\x00\x00")
 
 func traefikYamlBytes() ([]byte, error) {
 	return bindataRead(
@@ -383,7 +509,9 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
+	"calico.yaml":        calicoYaml,
 	"ccm.yaml":           ccmYaml,
+	"cilium.yaml":        ciliumYaml,
 	"coredns.yaml":       corednsYaml,
 	"local-storage.yaml": localStorageYaml,
 	"metrics-server/aggregated-metrics-reader.yaml": metricsServerAggregatedMetricsReaderYaml,
@@ -393,7 +521,11 @@ var _bindata = map[string]func() (*asset, error){
 	"metrics-server/metrics-server-deployment.yaml": metricsServerMetricsServerDeploymentYaml,
 	"metrics-server/metrics-server-service.yaml":    metricsServerMetricsServerServiceYaml,
 	"metrics-server/resource-reader.yaml":           metricsServerResourceReaderYaml,
+	"nodelocaldns.yaml":                             nodelocaldnsYaml,
+	"npd.yaml":                                      npdYaml,
+	"nvidia-device-plugin.yaml":                     nvidiaDevicePluginYaml,
 	"rolebindings.yaml":                             rolebindingsYaml,
+	"runtimes.yaml":                                 runtimesYaml,
 	"traefik.yaml":                                  traefikYaml,
 }
 
@@ -440,7 +572,9 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
+	"calico.yaml":        &bintree{calicoYaml, map[string]*bintree{}},
 	"ccm.yaml":           &bintree{ccmYaml, map[string]*bintree{}},
+	"cilium.yaml":        &bintree{ciliumYaml, map[string]*bintree{}},
 	"coredns.yaml":       &bintree{corednsYaml, map[string]*bintree{}},
 	"local-storage.yaml": &bintree{localStorageYaml, map[string]*bintree{}},
 	"metrics-server": &bintree{nil, map[string]*bintree{
@@ -452,8 +586,12 @@ var _bintree = &bintree{nil, map[string]*bintree{
 		"metrics-server-service.yaml":    &bintree{metricsServerMetricsServerServiceYaml, map[string]*bintree{}},
 		"resource-reader.yaml":           &bintree{metricsServerResourceReaderYaml, map[string]*bintree{}},
 	}},
-	"rolebindings.yaml": &bintree{rolebindingsYaml, map[string]*bintree{}},
-	"traefik.yaml":      &bintree{traefikYaml, map[string]*bintree{}},
+	"nodelocaldns.yaml":         &bintree{nodelocaldnsYaml, map[string]*bintree{}},
+	"npd.yaml":                  &bintree{npdYaml, map[string]*bintree{}},
+	"nvidia-device-plugin.yaml": &bintree{nvidiaDevicePluginYaml, map[string]*bintree{}},
+	"rolebindings.yaml":         &bintree{rolebindingsYaml, map[string]*bintree{}},
+	"runtimes.yaml":             &bintree{runtimesYaml, map[string]*bintree{}},
+	"traefik.yaml":              &bintree{traefikYaml, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory
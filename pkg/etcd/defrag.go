@@ -0,0 +1,140 @@
+package etcd
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// defragTimeout limits the time taken to defragment a single member, which blocks reads and writes on the
+// member until it completes.
+const defragTimeout = 5 * time.Minute
+
+var (
+	defragLastTimestamp = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Name:           version.Program + "_etcd_defrag_last_timestamp_seconds",
+		StabilityLevel: metrics.ALPHA,
+		Help:           "Time at which each etcd member was last defragmented by a scheduled defragmentation on this server, in seconds since the epoch.",
+	}, []string{"member"})
+	defragReclaimedBytes = metrics.NewCounterVec(&metrics.CounterOpts{
+		Name:           version.Program + "_etcd_defrag_reclaimed_bytes_total",
+		StabilityLevel: metrics.ALPHA,
+		Help:           "Total number of bytes reclaimed from the database of each etcd member by scheduled defragmentation on this server.",
+	}, []string{"member"})
+)
+
+func init() {
+	legacyregistry.MustRegister(defragLastTimestamp, defragReclaimedBytes)
+}
+
+// defragCandidate is an etcd cluster member that may be defragmented.
+type defragCandidate struct {
+	member   *etcdserverpb.Member
+	endpoint string
+	status   *clientv3.StatusResponse
+}
+
+// fragmentation returns the percentage of the database that is not in use.
+func fragmentation(status *clientv3.StatusResponse) int64 {
+	if status.DbSize <= 0 {
+		return 0
+	}
+	return (status.DbSize - status.DbSizeInUse) * 100 / status.DbSize
+}
+
+// sortDefragCandidates orders the candidates by name, with the leader last. Defragmenting the leader
+// may cause it to miss heartbeats, so it is done after all followers have been defragmented.
+func sortDefragCandidates(candidates []defragCandidate, leader uint64) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if iLeader, jLeader := candidates[i].member.ID == leader, candidates[j].member.ID == leader; iLeader != jLeader {
+			return jLeader
+		}
+		return candidates[i].member.Name < candidates[j].member.Name
+	})
+}
+
+// setDefragFunction schedules defragmentation of the etcd cluster at the configured interval.
+func (e *ETCD) setDefragFunction(ctx context.Context) {
+	skipJob := cron.SkipIfStillRunning(cronLogger)
+	e.cron.AddJob(e.config.EtcdDefragCron, skipJob(cron.FuncJob(func() {
+		if err := e.defragMembers(ctx); err != nil {
+			logrus.Errorf("Scheduled etcd defragmentation failed: %v", err)
+		}
+	})))
+}
+
+// defragMembers defragments each etcd cluster member whose database fragmentation is at or above the configured
+// threshold, one member at a time. All servers run the schedule, but only the server whose member is the
+// current leader defragments the cluster.
+func (e *ETCD) defragMembers(ctx context.Context) error {
+	if e.client == nil {
+		return errors.New("etcd client was nil")
+	}
+
+	localStatus, err := memberEndpointStatus(ctx, e.client, getEndpoints(e.config)[0])
+	if err != nil {
+		return errors.Wrap(err, "failed to get local etcd member status")
+	}
+	leader := localStatus.Leader
+	if localStatus.Header.MemberId != leader {
+		logrus.Debugf("Skipping scheduled etcd defragmentation; local member is not the leader")
+		return nil
+	}
+
+	listCtx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+	members, err := e.client.MemberList(listCtx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list etcd members")
+	}
+
+	candidates := []defragCandidate{}
+	for _, member := range members.Members {
+		for _, ep := range member.ClientURLs {
+			status, err := memberEndpointStatus(ctx, e.client, ep)
+			if err != nil {
+				logrus.Warnf("Failed to get status of etcd member %s from %s: %v", member.Name, ep, err)
+				continue
+			}
+			candidates = append(candidates, defragCandidate{member: member, endpoint: ep, status: status})
+			break
+		}
+	}
+	sortDefragCandidates(candidates, leader)
+
+	for _, candidate := range candidates {
+		fragmented := fragmentation(candidate.status)
+		if fragmented < int64(e.config.EtcdDefragThreshold) {
+			logrus.Debugf("Skipping defragmentation of etcd member %s; %d%% of the database is fragmented", candidate.member.Name, fragmented)
+			continue
+		}
+		logrus.Infof("Defragmenting etcd member %s; %d%% of the %d byte database is fragmented", candidate.member.Name, fragmented, candidate.status.DbSize)
+		defragCtx, cancel := context.WithTimeout(ctx, defragTimeout)
+		_, err := e.client.Defragment(defragCtx, candidate.endpoint)
+		cancel()
+		if err != nil {
+			return errors.Wrapf(err, "failed to defragment etcd member %s", candidate.member.Name)
+		}
+		defragLastTimestamp.WithLabelValues(candidate.member.Name).SetToCurrentTime()
+
+		status, err := memberEndpointStatus(ctx, e.client, candidate.endpoint)
+		if err != nil {
+			logrus.Warnf("Failed to get status of etcd member %s after defragmentation: %v", candidate.member.Name, err)
+			continue
+		}
+		if reclaimed := candidate.status.DbSize - status.DbSize; reclaimed > 0 {
+			defragReclaimedBytes.WithLabelValues(candidate.member.Name).Add(float64(reclaimed))
+			logrus.Infof("Defragmented etcd member %s; reclaimed %d bytes", candidate.member.Name, reclaimed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package etcd
+
+import (
+	"reflect"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func Test_UnitFragmentation(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *clientv3.StatusResponse
+		want   int64
+	}{
+		{
+			name:   "empty",
+			status: &clientv3.StatusResponse{},
+			want:   0,
+		},
+		{
+			name:   "not fragmented",
+			status: &clientv3.StatusResponse{DbSize: 4096, DbSizeInUse: 4096},
+			want:   0,
+		},
+		{
+			name:   "partially fragmented",
+			status: &clientv3.StatusResponse{DbSize: 1000, DbSizeInUse: 250},
+			want:   75,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fragmentation(tt.status); got != tt.want {
+				t.Errorf("fragmentation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitSortDefragCandidates(t *testing.T) {
+	tests := []struct {
+		name   string
+		ids    []uint64
+		leader uint64
+		want   []string
+	}{
+		{
+			name:   "leader first",
+			ids:    []uint64{1, 2, 3},
+			leader: 1,
+			want:   []string{"server-2", "server-3", "server-1"},
+		},
+		{
+			name:   "leader in middle",
+			ids:    []uint64{3, 2, 1},
+			leader: 2,
+			want:   []string{"server-1", "server-3", "server-2"},
+		},
+		{
+			name:   "leader unreachable",
+			ids:    []uint64{2, 3},
+			leader: 1,
+			want:   []string{"server-2", "server-3"},
+		},
+	}
+	names := map[uint64]string{1: "server-1", 2: "server-2", 3: "server-3"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := []defragCandidate{}
+			for _, id := range tt.ids {
+				candidates = append(candidates, defragCandidate{member: &etcdserverpb.Member{ID: id, Name: names[id]}})
+			}
+			sortDefragCandidates(candidates, tt.leader)
+			got := []string{}
+			for _, c := range candidates {
+				got = append(got, c.member.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortDefragCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/ed25519"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
@@ -51,6 +52,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 )
 
@@ -120,6 +122,7 @@ type ETCD struct {
 	s3          *S3
 	cancel      context.CancelFunc
 	snapshotSem *semaphore.Weighted
+	recorder    record.EventRecorder
 }
 
 type learnerProgress struct {
@@ -285,6 +288,10 @@ func (e *ETCD) IsInitialized(ctx context.Context, config *config.Control) (bool,
 
 // Reset resets an etcd node to a single node cluster.
 func (e *ETCD) Reset(ctx context.Context, rebootstrap func() error) error {
+	// replayed is closed once changes archived after the restored snapshot have been replayed, if restoring
+	// to a point in time.
+	replayed := make(chan struct{})
+
 	// Wait for etcd to come up as a new single-node cluster, then exit
 	go func() {
 		<-e.config.Runtime.AgentReady
@@ -314,6 +321,7 @@ func (e *ETCD) Reset(ctx context.Context, rebootstrap func() error) error {
 					logrus.Fatal(err)
 				}
 
+				<-replayed
 				if len(members.Members) == 1 && members.Members[0].Name == e.name {
 					// Cancel the etcd server context and allow it time to shutdown cleanly.
 					// Ideally we would use a waitgroup and properly sequence shutdown of the various components.
@@ -342,9 +350,28 @@ func (e *ETCD) Reset(ctx context.Context, rebootstrap func() error) error {
 		}
 	}()
 
+	// If asked to restore to a point in time without a snapshot, use the latest snapshot taken before it
+	restoreTime := e.config.ClusterResetRestoreTime
+	if !restoreTime.IsZero() && e.config.ClusterResetRestorePath == "" {
+		name, err := e.latestS3SnapshotBefore(ctx, restoreTime)
+		if err != nil {
+			return err
+		}
+		e.config.ClusterResetRestorePath = name
+	}
+
 	// If asked to restore from a snapshot, do so
+	var snapshotRev int64
 	if e.config.ClusterResetRestorePath != "" {
-		if e.config.EtcdS3 {
+		if strings.HasPrefix(e.config.ClusterResetRestorePath, S3URLPrefix) {
+			restorePath, err := e.streamS3Snapshot(ctx, e.config.ClusterResetRestorePath)
+			if err != nil {
+				return err
+			}
+			defer os.Remove(restorePath)
+			defer removeSnapshotSidecars(restorePath)
+			e.config.ClusterResetRestorePath = restorePath
+		} else if e.config.EtcdS3 {
 			if err := e.initS3IfNil(ctx); err != nil {
 				return err
 			}
@@ -362,11 +389,38 @@ func (e *ETCD) Reset(ctx context.Context, rebootstrap func() error) error {
 		if info.IsDir() {
 			return fmt.Errorf("etcd: snapshot path must be a file, not a directory: %s", e.config.ClusterResetRestorePath)
 		}
+		if !restoreTime.IsZero() {
+			if snapshotRev, err = snapshotRevision(e.config.ClusterResetRestorePath); err != nil {
+				return errors.Wrap(err, "failed to read snapshot revision")
+			}
+		}
 		if err := e.Restore(ctx); err != nil {
 			return err
 		}
 	}
 
+	if restoreTime.IsZero() {
+		close(replayed)
+	} else {
+		go func() {
+			defer close(replayed)
+			<-e.config.Runtime.AgentReady
+			for {
+				if err := e.Test(ctx); err == nil {
+					break
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(5 * time.Second):
+				}
+			}
+			if err := e.replayWAL(ctx, snapshotRev, restoreTime); err != nil {
+				logrus.Fatalf("Failed to restore etcd to %s: %v", restoreTime.Format(time.RFC3339), err)
+			}
+		}()
+	}
+
 	if err := e.setName(true); err != nil {
 		return err
 	}
@@ -389,8 +443,17 @@ func (e *ETCD) Start(ctx context.Context, clientAccessInfo *clientaccess.Info) e
 		e.cron.Start()
 	}
 
+	if e.config.EtcdDefragCron != "" {
+		e.setDefragFunction(ctx)
+		e.cron.Start()
+	}
+
 	go e.manageLearners(ctx)
 
+	if e.config.EtcdS3 && e.config.EtcdS3WALArchive {
+		go e.archiveWAL(ctx)
+	}
+
 	if isInitialized {
 		//check etcd dir permission
 		etcdDir := DBDir(e.config)
@@ -444,32 +507,73 @@ func (e *ETCD) Start(ctx context.Context, clientAccessInfo *clientaccess.Info) e
 	return nil
 }
 
-// join attempts to add a member to an existing cluster
+// join attempts to add a member to an existing cluster. Members are added one at a time, while
+// holding the join lock, so that servers that start at the same time do not race to join.
 func (e *ETCD) join(ctx context.Context, clientAccessInfo *clientaccess.Info) error {
 	clientCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
 
-	var (
-		cluster []string
-		add     = true
-	)
-
 	clientURLs, memberList, err := ClientURLs(clientCtx, clientAccessInfo, e.config.PrivateIP)
 	if err != nil {
 		return err
 	}
 
-	client, err := GetClient(clientCtx, e.config, clientURLs...)
+	cluster, add, err := e.memberCluster(memberList.Members)
 	if err != nil {
 		return err
 	}
-	defer client.Close()
 
-	for _, member := range memberList.Members {
+	if add {
+		lock, err := e.acquireJoinLock(ctx, clientURLs)
+		if err != nil {
+			return errors.Wrap(err, "failed to acquire etcd cluster join lock")
+		}
+
+		// Other members may have been added while waiting for the lock, so the member list must be retrieved again.
+		members, err := lock.waitForHealthyCluster(ctx)
+		if err != nil {
+			lock.close()
+			return err
+		}
+		cluster, add, err = e.memberCluster(members)
+		if err != nil {
+			lock.close()
+			return err
+		}
+
+		if add {
+			logrus.Infof("Adding member %s=%s to etcd cluster %v", e.name, e.peerURL(), cluster)
+			addCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+			defer cancel()
+			if _, err = lock.client.MemberAddAsLearner(addCtx, []string{e.peerURL()}); err != nil {
+				lock.close()
+				return err
+			}
+			cluster = append(cluster, fmt.Sprintf("%s=%s", e.name, e.peerURL()))
+		}
+		go lock.releaseAfterPromotion(ctx, e.peerURL())
+	}
+
+	logrus.Infof("Starting etcd to join cluster with members %v", cluster)
+	return e.cluster(ctx, false, executor.InitialOptions{
+		Cluster: strings.Join(cluster, ","),
+		State:   "existing",
+	})
+}
+
+// memberCluster returns the initial cluster list for the current members, and a boolean indicating
+// whether or not this server needs to be added to the cluster.
+func (e *ETCD) memberCluster(members []*etcdserverpb.Member) ([]string, bool, error) {
+	var (
+		cluster []string
+		add     = true
+	)
+
+	for _, member := range members {
 		for _, peer := range member.PeerURLs {
 			u, err := url.Parse(peer)
 			if err != nil {
-				return err
+				return nil, false, err
 			}
 			// An uninitialized joining member won't have a name; if it has our
 			// address it must be us.
@@ -501,23 +605,10 @@ func (e *ETCD) join(ctx context.Context, clientAccessInfo *clientaccess.Info) er
 			if err := os.Remove(nameFile); err != nil {
 				logrus.Errorf("Failed to remove etcd name file %s: %v", nameFile, err)
 			}
-			return errors.New("duplicate node name found, please use a unique name for this node")
-		}
-	}
-
-	if add {
-		logrus.Infof("Adding member %s=%s to etcd cluster %v", e.name, e.peerURL(), cluster)
-		if _, err = client.MemberAddAsLearner(clientCtx, []string{e.peerURL()}); err != nil {
-			return err
+			return nil, false, errors.New("duplicate node name found, please use a unique name for this node")
 		}
-		cluster = append(cluster, fmt.Sprintf("%s=%s", e.name, e.peerURL()))
 	}
-
-	logrus.Infof("Starting etcd to join cluster with members %v", cluster)
-	return e.cluster(ctx, false, executor.InitialOptions{
-		Cluster: strings.Join(cluster, ","),
-		State:   "existing",
-	})
+	return cluster, add, nil
 }
 
 // Register configures a new etcd client and adds db info routes for the http request handler.
@@ -813,7 +904,7 @@ func (e *ETCD) clientURL() string {
 // on other nodes connect mid-process.
 func (e *ETCD) advertiseClientURLs(reset bool) string {
 	if reset {
-		return fmt.Sprintf("https://%s", net.JoinHostPort(e.config.Loopback(true), "2379"))
+		return fmt.Sprintf("https://%s", net.JoinHostPort(e.config.Loopback(false), "2379"))
 	}
 	return e.clientURL()
 }
@@ -923,7 +1014,7 @@ func addPort(address string, offset int) (string, error) {
 		return "", err
 	}
 	port += offset
-	return fmt.Sprintf("%s://%s:%d", u.Scheme, u.Hostname(), port), nil
+	return fmt.Sprintf("%s://%s", u.Scheme, net.JoinHostPort(u.Hostname(), strconv.Itoa(port))), nil
 }
 
 // RemovePeer removes a peer from the cluster. The peer name and IP address must both match.
@@ -1351,6 +1442,13 @@ func (e *ETCD) Snapshot(ctx context.Context, config *config.Control) error {
 		return nil
 	}
 
+	var signingKey ed25519.PrivateKey
+	if e.config.EtcdSnapshotSigningKey != "" {
+		if signingKey, err = LoadSigningKey(e.config.EtcdSnapshotSigningKey); err != nil {
+			return errors.Wrap(err, "failed to load snapshot signing key")
+		}
+	}
+
 	snapshotDir, err := snapshotDir(e.config, true)
 	if err != nil {
 		return errors.Wrap(err, "failed to get the snapshot dir")
@@ -1395,6 +1493,15 @@ func (e *ETCD) Snapshot(ctx context.Context, config *config.Control) error {
 		}
 	}
 
+	// The checksum is computed before compression, as the manifest records the checksum of the database.
+	var checksum string
+	var dbSize int64
+	if sf == nil {
+		if checksum, dbSize, err = fileChecksum(snapshotPath); err != nil {
+			return errors.Wrap(err, "failed to compute snapshot checksum")
+		}
+	}
+
 	if e.config.EtcdSnapshotCompress {
 		zipPath, err := e.compressSnapshot(snapshotDir, snapshotName, snapshotPath)
 		if err != nil {
@@ -1426,11 +1533,16 @@ func (e *ETCD) Snapshot(ctx context.Context, config *config.Control) error {
 			Compressed: e.config.EtcdSnapshotCompress,
 		}
 
+		sidecars, err := writeSnapshotManifest(snapshotPath, checksum, dbSize, signingKey)
+		if err != nil {
+			return errors.Wrap(err, "failed to write snapshot manifest")
+		}
+
 		if err := e.addSnapshotData(*sf); err != nil {
 			return errors.Wrap(err, "failed to save local snapshot data to configmap")
 		}
 
-		if err := snapshotRetention(e.config.EtcdSnapshotRetention, e.config.EtcdSnapshotName, snapshotDir); err != nil {
+		if err := snapshotRetention(e.config.EtcdSnapshotRetention, snapshotRetentionPolicy(e.config), e.config.EtcdSnapshotName, snapshotDir); err != nil {
 			return errors.Wrap(err, "failed to apply local snapshot retention policy")
 		}
 
@@ -1468,6 +1580,11 @@ func (e *ETCD) Snapshot(ctx context.Context, config *config.Control) error {
 					return err
 				}
 				logrus.Infof("S3 upload complete for %s", snapshotName)
+				if sf.Status == successfulSnapshotStatus {
+					if err := e.s3.uploadSidecars(ctx, sidecars); err != nil {
+						logrus.Errorf("Failed to upload manifest of snapshot %s to S3: %v", snapshotName, err)
+					}
+				}
 				if err := e.s3.snapshotRetention(ctx); err != nil {
 					return errors.Wrap(err, "failed to apply s3 snapshot retention policy")
 				}
@@ -1533,6 +1650,9 @@ func (e *ETCD) listLocalSnapshots() (map[string]snapshotFile, error) {
 	nodeName := os.Getenv("NODE_NAME")
 
 	for _, de := range dirEntries {
+		if isSnapshotSidecar(de.Name()) {
+			continue
+		}
 		file, err := de.Info()
 		if err != nil {
 			return nil, err
@@ -1582,7 +1702,7 @@ func (e *ETCD) listS3Snapshots(ctx context.Context) (map[string]snapshotFile, er
 			if obj.Err != nil {
 				return nil, obj.Err
 			}
-			if obj.Size == 0 {
+			if obj.Size == 0 || isSnapshotSidecar(obj.Key) || isSnapshotChunk(obj.Key) || isWALSegment(obj.Key) {
 				continue
 			}
 
@@ -1630,6 +1750,34 @@ func (e *ETCD) initS3IfNil(ctx context.Context) error {
 	return nil
 }
 
+// streamS3Snapshot streams the snapshot referred to by an s3://<bucket>/<key> URL to the local snapshot
+// directory, using the configured S3 endpoint and credentials, and returns the path to the local file.
+// The bucket in the URL is used instead of the configured bucket, and the key is not relative to the
+// configured folder.
+func (e *ETCD) streamS3Snapshot(ctx context.Context, s3URL string) (string, error) {
+	bucket, key, err := ParseS3URL(s3URL)
+	if err != nil {
+		return "", err
+	}
+	s3Config := *e.config
+	s3Config.EtcdS3 = true
+	s3Config.EtcdS3BucketName = bucket
+	s3, err := NewS3(ctx, &s3Config)
+	if err != nil {
+		return "", err
+	}
+	logrus.Infof("Streaming etcd snapshot %s from S3 bucket %s", key, bucket)
+	restorePath, err := s3.streamSnapshot(ctx, bucket, key)
+	if err != nil {
+		return "", err
+	}
+	if err := s3.downloadSidecars(ctx, bucket, snapshotKey(key), restorePath); err != nil {
+		os.Remove(restorePath)
+		return "", err
+	}
+	return restorePath, nil
+}
+
 // PruneSnapshots performs a retention run with the given
 // retention duration and removes expired snapshots.
 func (e *ETCD) PruneSnapshots(ctx context.Context) error {
@@ -1637,7 +1785,7 @@ func (e *ETCD) PruneSnapshots(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to get the snapshot dir")
 	}
-	if err := snapshotRetention(e.config.EtcdSnapshotRetention, e.config.EtcdSnapshotName, snapshotDir); err != nil {
+	if err := snapshotRetention(e.config.EtcdSnapshotRetention, snapshotRetentionPolicy(e.config), e.config.EtcdSnapshotName, snapshotDir); err != nil {
 		logrus.Errorf("Error applying snapshot retention policy: %v", err)
 	}
 
@@ -1701,7 +1849,7 @@ func (e *ETCD) DeleteSnapshots(ctx context.Context, snapshots []string) error {
 				// add them to the channel for remove if they're
 				// actually found from the bucket listing.
 				for _, snapshot := range snapshots {
-					if snapshot == obj.Key {
+					if snapshot == obj.Key || (isSnapshotSidecar(obj.Key) && strings.HasPrefix(obj.Key, snapshotKey(snapshot)+manifestExtension)) {
 						objectsCh <- obj
 					}
 				}
@@ -1740,6 +1888,9 @@ func (e *ETCD) DeleteSnapshots(ctx context.Context, snapshots []string) error {
 		if err := os.Remove(sf); err != nil {
 			return err
 		}
+		if err := removeSnapshotSidecars(sf); err != nil {
+			return err
+		}
 		logrus.Debug("Removed snapshot ", s)
 	}
 
@@ -1963,6 +2114,10 @@ func (e *ETCD) setSnapshotFunction(ctx context.Context) {
 		// having all the nodes take a snapshot at the exact same time can lead to excessive retry thrashing
 		// when updating the snapshot list configmap.
 		time.Sleep(time.Duration(rand.Float64() * float64(snapshotJitterMax)))
+		if err := e.checkScheduledSnapshot(ctx); err != nil {
+			e.snapshotSkipped(err)
+			return
+		}
 		if err := e.Snapshot(ctx, e.config); err != nil {
 			logrus.Error(err)
 		}
@@ -2000,6 +2155,20 @@ func (e *ETCD) Restore(ctx context.Context) error {
 		restorePath = e.config.ClusterResetRestorePath
 	}
 
+	if e.config.EtcdSnapshotVerify {
+		var verificationKey ed25519.PublicKey
+		if e.config.EtcdSnapshotVerificationKey != "" {
+			var err error
+			if verificationKey, err = LoadVerificationKey(e.config.EtcdSnapshotVerificationKey); err != nil {
+				return errors.Wrap(err, "failed to load snapshot verification key")
+			}
+		}
+		if err := verifySnapshotManifest(restorePath, e.config.ClusterResetRestorePath+manifestExtension, verificationKey); err != nil {
+			return errors.Wrap(err, "failed to verify snapshot")
+		}
+		logrus.Infof("Verified etcd snapshot %s", e.config.ClusterResetRestorePath)
+	}
+
 	// move the data directory to a temp path
 	if err := os.Rename(DBDir(e.config), oldDataDir); err != nil {
 		return err
@@ -2023,41 +2192,62 @@ func (e *ETCD) Restore(ctx context.Context) error {
 }
 
 // snapshotRetention iterates through the snapshots and removes the oldest
-// leaving the desired number of snapshots.
-func snapshotRetention(retention int, snapshotPrefix string, snapshotDir string) error {
-	if retention < 1 {
+// leaving the desired number of snapshots. If a retention policy is set, it
+// is used instead of the retention count.
+func snapshotRetention(retention int, policy *RetentionPolicy, snapshotPrefix string, snapshotDir string) error {
+	if retention < 1 && policy == nil {
 		return nil
 	}
 
 	nodeName := os.Getenv("NODE_NAME")
-	logrus.Infof("Applying local snapshot retention policy: retention: %d, snapshotPrefix: %s, directory: %s", retention, snapshotPrefix+"-"+nodeName, snapshotDir)
+	if policy != nil {
+		logrus.Infof("Applying local snapshot retention policy: policy: %s, snapshotPrefix: %s, directory: %s", policy, snapshotPrefix+"-"+nodeName, snapshotDir)
+	} else {
+		logrus.Infof("Applying local snapshot retention policy: retention: %d, snapshotPrefix: %s, directory: %s", retention, snapshotPrefix+"-"+nodeName, snapshotDir)
+	}
 
 	var snapshotFiles []os.FileInfo
 	if err := filepath.Walk(snapshotDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if strings.HasPrefix(info.Name(), snapshotPrefix+"-"+nodeName) {
+		if strings.HasPrefix(info.Name(), snapshotPrefix+"-"+nodeName) && !isSnapshotSidecar(info.Name()) {
 			snapshotFiles = append(snapshotFiles, info)
 		}
 		return nil
 	}); err != nil {
 		return err
 	}
-	if len(snapshotFiles) <= retention {
+	if policy == nil && len(snapshotFiles) <= retention {
 		return nil
 	}
 	sort.Slice(snapshotFiles, func(i, j int) bool {
 		return snapshotFiles[i].Name() < snapshotFiles[j].Name()
 	})
 
-	delCount := len(snapshotFiles) - retention
-	for _, df := range snapshotFiles[:delCount] {
+	var deleteFiles []os.FileInfo
+	if policy != nil {
+		times := make([]time.Time, len(snapshotFiles))
+		for i, f := range snapshotFiles {
+			times[i] = f.ModTime()
+		}
+		for i, retain := range policy.Retain(times) {
+			if !retain {
+				deleteFiles = append(deleteFiles, snapshotFiles[i])
+			}
+		}
+	} else {
+		deleteFiles = snapshotFiles[:len(snapshotFiles)-retention]
+	}
+	for _, df := range deleteFiles {
 		snapshotPath := filepath.Join(snapshotDir, df.Name())
 		logrus.Infof("Removing local snapshot %s", snapshotPath)
 		if err := os.Remove(snapshotPath); err != nil {
 			return err
 		}
+		if err := removeSnapshotSidecars(snapshotPath); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -354,3 +354,57 @@ func Test_UnitETCD_Start(t *testing.T) {
 		})
 	}
 }
+
+func Test_UnitParseS3URL(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		wantBucket string
+		wantKey    string
+		wantErr    bool
+	}{
+		{
+			name:       "bucket and key",
+			url:        "s3://snapshots/on-demand-server-1",
+			wantBucket: "snapshots",
+			wantKey:    "on-demand-server-1",
+		},
+		{
+			name:       "key with folder",
+			url:        "s3://snapshots/cluster-a/etcd-snapshot-server-1-1686000000.zip",
+			wantBucket: "snapshots",
+			wantKey:    "cluster-a/etcd-snapshot-server-1-1686000000.zip",
+		},
+		{
+			name:    "missing key",
+			url:     "s3://snapshots",
+			wantErr: true,
+		},
+		{
+			name:    "folder without key",
+			url:     "s3://snapshots/cluster-a/",
+			wantErr: true,
+		},
+		{
+			name:    "missing bucket",
+			url:     "s3:///on-demand-server-1",
+			wantErr: true,
+		},
+		{
+			name:    "not an s3 URL",
+			url:     "/var/lib/rancher/k3s/server/db/snapshots/on-demand-server-1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, key, err := ParseS3URL(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseS3URL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if bucket != tt.wantBucket || key != tt.wantKey {
+				t.Errorf("ParseS3URL() = %q, %q, want %q, %q", bucket, key, tt.wantBucket, tt.wantKey)
+			}
+		})
+	}
+}
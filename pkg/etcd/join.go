@@ -0,0 +1,191 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// joinLockTTL is the TTL of the session lease used to hold the join lock, in seconds. If the
+	// joining server exits while holding the lock, it is released once the lease expires.
+	joinLockTTL = 60
+
+	// joinCheckInterval is the interval at which cluster health and learner promotion are checked while joining.
+	joinCheckInterval = 5 * time.Second
+
+	// joinHealthTimeout bounds the time that a joining server waits for the cluster to become healthy.
+	joinHealthTimeout = 5 * time.Minute
+
+	// joinReleaseTimeout bounds the time that the join lock is held waiting for this member to be
+	// promoted; learners that stall for longer than learnerMaxStallTime are removed by the leader.
+	joinReleaseTimeout = learnerMaxStallTime * 2
+)
+
+var joinLockKey = version.Program + "/etcd/joinLock"
+
+// joinLock serializes the addition of new members to the cluster, so that servers that start at the same time
+// join one at a time, each waiting for the cluster to be healthy before adding itself.
+type joinLock struct {
+	client  *clientv3.Client
+	session *concurrency.Session
+	mutex   *concurrency.Mutex
+}
+
+// acquireJoinLock waits for any other servers that are joining the cluster to complete, and then acquires the join lock.
+// Servers waiting for the lock are granted it in the order that they requested it.
+func (e *ETCD) acquireJoinLock(ctx context.Context, endpoints []string) (*joinLock, error) {
+	client, err := GetClient(ctx, e.config, endpoints...)
+	if err != nil {
+		return nil, err
+	}
+	session, err := concurrency.NewSession(client, concurrency.WithTTL(joinLockTTL))
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	mutex := concurrency.NewMutex(session, joinLockKey)
+
+	l := &joinLock{client: client, session: session, mutex: mutex}
+	err = mutex.TryLock(ctx)
+	if errors.Is(err, concurrency.ErrLocked) {
+		logrus.Infof("Waiting for other servers to finish joining the etcd cluster")
+		err = mutex.Lock(ctx)
+	}
+	if err != nil {
+		l.close()
+		return nil, err
+	}
+	logrus.Infof("Acquired etcd cluster join lock")
+	return l, nil
+}
+
+// waitForHealthyCluster waits until there are no learners in the cluster, and enough voting members are healthy that
+// the cluster keeps quorum once this server has been added and promoted, and returns the current members. Adding a
+// member while another is still catching up, or while too many members are unreachable, may leave the cluster without
+// quorum. Unreachable members that do not affect quorum, such as a failed server that is being replaced, do not block
+// the join. An error is returned if the cluster does not become healthy within joinHealthTimeout.
+func (l *joinLock) waitForHealthyCluster(ctx context.Context) ([]*etcdserverpb.Member, error) {
+	ctx, cancel := context.WithTimeout(ctx, joinHealthTimeout)
+	defer cancel()
+
+	var members []*etcdserverpb.Member
+	var lastErr error
+	logrus.Infof("Waiting for etcd cluster to become healthy before joining")
+	err := wait.PollImmediateUntilWithContext(ctx, joinCheckInterval, func(ctx context.Context) (bool, error) {
+		ctx, cancel := context.WithTimeout(ctx, testTimeout)
+		defer cancel()
+
+		resp, err := l.client.MemberList(ctx)
+		if err != nil {
+			lastErr = errors.Wrap(err, "failed to list etcd cluster members")
+			logrus.Debugf("Waiting to list etcd cluster members before joining: %v", err)
+			return false, nil
+		}
+		healthy, voters, err := clusterHealth(ctx, l.client, resp.Members)
+		if healthy < 0 {
+			lastErr = err
+			logrus.Debugf("Waiting for etcd cluster to become healthy before joining: %v", err)
+			return false, nil
+		}
+		if !joinKeepsQuorum(healthy, voters) {
+			lastErr = errors.Wrapf(err, "only %d of %d voting members are healthy", healthy, voters)
+			logrus.Debugf("Waiting for etcd cluster to become healthy before joining: %v", lastErr)
+			return false, nil
+		}
+		if err != nil {
+			logrus.Warnf("Joining etcd cluster with %d of %d voting members healthy: %v", healthy, voters, err)
+		}
+		members = resp.Members
+		return true, nil
+	})
+	if err != nil && lastErr != nil {
+		return nil, errors.Wrapf(lastErr, "etcd cluster did not become healthy within %s", joinHealthTimeout)
+	}
+	return members, err
+}
+
+// joinKeepsQuorum returns true if the healthy voting members have quorum, both in the current cluster and once
+// another member has been added and promoted.
+func joinKeepsQuorum(healthy, voters int) bool {
+	return healthy >= voters/2+1 && healthy+1 >= (voters+1)/2+1
+}
+
+// releaseAfterPromotion releases the lock once the member with the given peer URL has been promoted to a voting member
+// and the cluster is healthy, or has been removed from the cluster, so that the next server can join.
+func (l *joinLock) releaseAfterPromotion(ctx context.Context, peerURL string) {
+	defer l.close()
+	ctx, cancel := context.WithTimeout(ctx, joinReleaseTimeout)
+	defer cancel()
+
+	err := wait.PollUntilWithContext(ctx, joinCheckInterval, func(ctx context.Context) (bool, error) {
+		ctx, cancel := context.WithTimeout(ctx, testTimeout)
+		defer cancel()
+
+		resp, err := l.client.MemberList(ctx)
+		if err != nil {
+			return false, nil
+		}
+		for _, member := range resp.Members {
+			if len(member.PeerURLs) > 0 && member.PeerURLs[0] == peerURL && member.IsLearner {
+				return false, nil
+			}
+		}
+		healthy, voters, _ := clusterHealth(ctx, l.client, resp.Members)
+		return healthy >= voters/2+1, nil
+	})
+	if err != nil {
+		logrus.Warnf("Releasing etcd cluster join lock before member was promoted: %v", err)
+		return
+	}
+	logrus.Infof("Released etcd cluster join lock")
+}
+
+// close releases the lock, and closes the session and client.
+func (l *joinLock) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	if err := l.mutex.Unlock(ctx); err != nil {
+		logrus.Debugf("Failed to release etcd cluster join lock: %v", err)
+	}
+	l.session.Close()
+	l.client.Close()
+}
+
+// clusterHealth returns the number of healthy voting members and the number of voting members, and an error
+// describing the last unhealthy member, if any. If any member is a learner, -1 is returned for the number of
+// healthy members, as etcd does not allow another member to be added until the learner has been promoted.
+func clusterHealth(ctx context.Context, client *clientv3.Client, members []*etcdserverpb.Member) (int, int, error) {
+	var healthy int
+	var lastErr error
+	for _, member := range members {
+		if member.IsLearner {
+			return -1, 0, fmt.Errorf("learner %s has not yet been promoted", member.Name)
+		}
+	}
+	for _, member := range members {
+		if member.Name == "" || len(member.ClientURLs) == 0 {
+			lastErr = fmt.Errorf("member with peer URLs %v has not started", member.PeerURLs)
+			continue
+		}
+		status, err := client.Status(ctx, member.ClientURLs[0])
+		if err != nil {
+			lastErr = errors.Wrapf(err, "member %s is not reachable", member.Name)
+			continue
+		}
+		if len(status.Errors) > 0 {
+			lastErr = fmt.Errorf("member %s has errors: %v", member.Name, status.Errors)
+			continue
+		}
+		healthy++
+	}
+	return healthy, len(members), lastErr
+}
@@ -0,0 +1,28 @@
+package etcd
+
+import "testing"
+
+func Test_UnitJoinKeepsQuorum(t *testing.T) {
+	tests := []struct {
+		name    string
+		healthy int
+		voters  int
+		want    bool
+	}{
+		{name: "single healthy member", healthy: 1, voters: 1, want: true},
+		{name: "all healthy", healthy: 3, voters: 3, want: true},
+		{name: "one of two unhealthy", healthy: 1, voters: 2, want: false},
+		{name: "one of three unhealthy", healthy: 2, voters: 3, want: true},
+		{name: "two of three unhealthy", healthy: 1, voters: 3, want: false},
+		{name: "one of four unhealthy", healthy: 3, voters: 4, want: true},
+		{name: "two of four unhealthy", healthy: 2, voters: 4, want: false},
+		{name: "two of five unhealthy", healthy: 3, voters: 5, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinKeepsQuorum(tt.healthy, tt.voters); got != tt.want {
+				t.Errorf("joinKeepsQuorum(%d, %d) = %v, want %v", tt.healthy, tt.voters, got, tt.want)
+			}
+		})
+	}
+}
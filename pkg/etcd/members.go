@@ -0,0 +1,220 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// MemberStatus describes an etcd cluster member, as returned by the etcd member management API.
+type MemberStatus struct {
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	PeerURLs         []string   `json:"peerURLs"`
+	ClientURLs       []string   `json:"clientURLs"`
+	IsLearner        bool       `json:"isLearner"`
+	IsLeader         bool       `json:"isLeader"`
+	RaftAppliedIndex uint64     `json:"raftAppliedIndex"`
+	DBSize           int64      `json:"dbSize"`
+	LastProgress     *time.Time `json:"lastProgress,omitempty"`
+	Error            string     `json:"error,omitempty"`
+}
+
+// memberID formats a member ID the same way as etcdctl.
+func memberID(id uint64) string {
+	return fmt.Sprintf("%x", id)
+}
+
+// ListMembers returns the status of all etcd cluster members. The raft applied index of each member is
+// retrieved from the member's first reachable client URL, so that the sync progress of learners can be
+// compared against the leader. Members whose status cannot be retrieved are still listed, with an error.
+func ListMembers(ctx context.Context, control *config.Control) ([]MemberStatus, error) {
+	client, err := GetClient(ctx, control)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+
+	members, err := client.MemberList(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list etcd members")
+	}
+
+	// Learner progress is only recorded by the leader while a learner is stalled, and is not an error if missing
+	progress := &learnerProgress{}
+	if resp, err := client.Get(ctx, learnerProgressKey); err == nil && resp.Count > 0 {
+		if err := json.Unmarshal(resp.Kvs[0].Value, progress); err != nil {
+			logrus.Debugf("Failed to decode recorded learner progress: %v", err)
+		}
+	}
+
+	var leader uint64
+	statuses := make([]MemberStatus, 0, len(members.Members))
+	for _, member := range members.Members {
+		status := MemberStatus{
+			ID:         memberID(member.ID),
+			Name:       member.Name,
+			PeerURLs:   member.PeerURLs,
+			ClientURLs: member.ClientURLs,
+			IsLearner:  member.IsLearner,
+		}
+		if member.IsLearner && progress.ID == member.ID && !progress.LastProgress.IsZero() {
+			lastProgress := progress.LastProgress.Time
+			status.LastProgress = &lastProgress
+		}
+		if len(member.ClientURLs) == 0 {
+			// Members that have been added but not yet started do not have any client URLs
+			status.Error = "member has not started"
+		}
+		for _, ep := range member.ClientURLs {
+			memberStatus, err := memberEndpointStatus(ctx, client, ep)
+			if err != nil {
+				status.Error = err.Error()
+				continue
+			}
+			status.Error = ""
+			status.RaftAppliedIndex = memberStatus.RaftAppliedIndex
+			status.DBSize = memberStatus.DbSize
+			leader = memberStatus.Leader
+			break
+		}
+		statuses = append(statuses, status)
+	}
+	for i, member := range members.Members {
+		statuses[i].IsLeader = member.ID == leader
+	}
+	return statuses, nil
+}
+
+func memberEndpointStatus(ctx context.Context, client *clientv3.Client, endpoint string) (*clientv3.StatusResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultDialTimeout)
+	defer cancel()
+	return client.Status(ctx, endpoint)
+}
+
+// PromoteMember promotes a learner to a full voting member of the etcd cluster. Promotion is rejected by
+// etcd if the learner is not yet in sync with the leader.
+func PromoteMember(ctx context.Context, control *config.Control, name string) error {
+	return withMember(ctx, control, name, func(ctx context.Context, client *clientv3.Client, member *etcdserverpb.Member, _ []*etcdserverpb.Member) error {
+		if !member.IsLearner {
+			return errors.Errorf("etcd member %s is not a learner", member.Name)
+		}
+		if _, err := client.MemberPromote(ctx, member.ID); err != nil {
+			return errors.Wrapf(err, "failed to promote etcd member %s", member.Name)
+		}
+		logrus.Infof("Promoted learner name=%s id=%s via supervisor API", member.Name, memberID(member.ID))
+		return nil
+	})
+}
+
+// RemoveMember removes a member from the etcd cluster. If the removed member's server is still running,
+// it will need its etcd data reset before it can rejoin the cluster. Removal of the local member, of the
+// leader, or of a voting member whose removal would leave the cluster without a healthy quorum, is rejected.
+func RemoveMember(ctx context.Context, control *config.Control, name string) error {
+	return withMember(ctx, control, name, func(ctx context.Context, client *clientv3.Client, member *etcdserverpb.Member, members []*etcdserverpb.Member) error {
+		local, err := memberEndpointStatus(ctx, client, client.Endpoints()[0])
+		if err != nil {
+			return errors.Wrap(err, "failed to get local etcd member status")
+		}
+		healthy := map[uint64]bool{}
+		for _, m := range members {
+			if len(m.ClientURLs) == 0 {
+				continue
+			}
+			if status, err := memberEndpointStatus(ctx, client, m.ClientURLs[0]); err == nil && len(status.Errors) == 0 {
+				healthy[m.ID] = true
+			}
+		}
+		if err := checkMemberRemoval(member, members, local.Header.MemberId, local.Leader, healthy); err != nil {
+			return err
+		}
+		if _, err := client.MemberRemove(ctx, member.ID); err != nil {
+			return errors.Wrapf(err, "failed to remove etcd member %s", member.Name)
+		}
+		logrus.Infof("Removed etcd member name=%s id=%s via supervisor API", member.Name, memberID(member.ID))
+		return nil
+	})
+}
+
+// checkMemberRemoval returns an error if the member is the local or leader member, or if the healthy voting members
+// that remain after the member is removed would not have quorum.
+func checkMemberRemoval(member *etcdserverpb.Member, members []*etcdserverpb.Member, localID, leaderID uint64, healthy map[uint64]bool) error {
+	if member.ID == localID {
+		return errors.Errorf("etcd member %s is the local member of this server; remove it via another server, or uninstall this server", member.Name)
+	}
+	if member.ID == leaderID {
+		return errors.Errorf("etcd member %s is the leader; restart its server so that another member is elected leader before removing it", member.Name)
+	}
+	if member.IsLearner {
+		return nil
+	}
+	var voters, healthyVoters int
+	for _, m := range members {
+		if m.IsLearner || m.ID == member.ID {
+			continue
+		}
+		voters++
+		if healthy[m.ID] {
+			healthyVoters++
+		}
+	}
+	if voters == 0 {
+		return errors.Errorf("etcd member %s is the only voting member", member.Name)
+	}
+	if quorum := voters/2 + 1; healthyVoters < quorum {
+		return errors.Errorf("removing etcd member %s would leave %d healthy of %d voting members, which is less than the quorum of %d", member.Name, healthyVoters, voters, quorum)
+	}
+	return nil
+}
+
+// withMember calls f with the cluster member whose name or ID matches the provided name, and the list of all members.
+func withMember(ctx context.Context, control *config.Control, name string, f func(context.Context, *clientv3.Client, *etcdserverpb.Member, []*etcdserverpb.Member) error) error {
+	client, err := GetClient(ctx, control)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, memberRemovalTimeout)
+	defer cancel()
+
+	members, err := client.MemberList(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list etcd members")
+	}
+	member := findMember(members.Members, name)
+	if member == nil {
+		return &MemberNotFoundError{Name: name}
+	}
+	return f(ctx, client, member, members.Members)
+}
+
+// findMember returns the member whose name or ID matches the provided name, or nil if there is none.
+func findMember(members []*etcdserverpb.Member, name string) *etcdserverpb.Member {
+	for _, member := range members {
+		if member.Name == name || memberID(member.ID) == strings.ToLower(name) {
+			return member
+		}
+	}
+	return nil
+}
+
+// MemberNotFoundError is returned when a member management request names a member that is not in the cluster.
+type MemberNotFoundError struct {
+	Name string
+}
+
+func (e *MemberNotFoundError) Error() string {
+	return fmt.Sprintf("etcd member %s not found", e.Name)
+}
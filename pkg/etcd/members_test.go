@@ -0,0 +1,224 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	testutil "github.com/k3s-io/k3s/tests"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/server/v3/etcdserver"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func Test_UnitFindMember(t *testing.T) {
+	members := []*etcdserverpb.Member{
+		{ID: 0x8e9e05c52164694d, Name: "server-1-3f2a1b4c"},
+		{ID: 0x91bc3c398fb3c146, Name: "server-2-7d6e5f4a", IsLearner: true},
+	}
+	tests := []struct {
+		name   string
+		lookup string
+		wantID uint64
+	}{
+		{
+			name:   "by name",
+			lookup: "server-2-7d6e5f4a",
+			wantID: 0x91bc3c398fb3c146,
+		},
+		{
+			name:   "by id",
+			lookup: "8e9e05c52164694d",
+			wantID: 0x8e9e05c52164694d,
+		},
+		{
+			name:   "by uppercase id",
+			lookup: "91BC3C398FB3C146",
+			wantID: 0x91bc3c398fb3c146,
+		},
+		{
+			name:   "not found",
+			lookup: "server-3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			member := findMember(members, tt.lookup)
+			if tt.wantID == 0 {
+				if member != nil {
+					t.Errorf("findMember() = %s, want nil", member.Name)
+				}
+				return
+			}
+			if member == nil || member.ID != tt.wantID {
+				t.Errorf("findMember() = %v, want member with ID %x", member, tt.wantID)
+			}
+		})
+	}
+}
+
+func Test_UnitCheckMemberRemoval(t *testing.T) {
+	members := []*etcdserverpb.Member{
+		{ID: 1, Name: "server-1"},
+		{ID: 2, Name: "server-2"},
+		{ID: 3, Name: "server-3"},
+		{ID: 4, Name: "server-4", IsLearner: true},
+	}
+	tests := []struct {
+		name    string
+		remove  uint64
+		members []*etcdserverpb.Member
+		healthy map[uint64]bool
+		wantErr bool
+	}{
+		{
+			name:    "healthy voter",
+			remove:  3,
+			healthy: map[uint64]bool{1: true, 2: true, 3: true},
+		},
+		{
+			name:    "unhealthy voter",
+			remove:  3,
+			healthy: map[uint64]bool{1: true, 2: true},
+		},
+		{
+			name:    "learner with unhealthy voters",
+			remove:  4,
+			healthy: map[uint64]bool{1: true},
+		},
+		{
+			name:    "local member",
+			remove:  1,
+			healthy: map[uint64]bool{1: true, 2: true, 3: true},
+			wantErr: true,
+		},
+		{
+			name:    "leader",
+			remove:  2,
+			healthy: map[uint64]bool{1: true, 2: true, 3: true},
+			wantErr: true,
+		},
+		{
+			name:    "voter when another voter is unhealthy",
+			remove:  3,
+			members: append(members, &etcdserverpb.Member{ID: 5, Name: "server-5"}),
+			healthy: map[uint64]bool{1: true, 2: true, 3: true},
+		},
+		{
+			name:    "voter that would leave no quorum",
+			remove:  3,
+			healthy: map[uint64]bool{1: true, 3: true},
+			wantErr: true,
+		},
+		{
+			name:    "only voter",
+			remove:  3,
+			members: []*etcdserverpb.Member{{ID: 3, Name: "server-3"}, {ID: 4, Name: "server-4", IsLearner: true}},
+			healthy: map[uint64]bool{3: true},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.members == nil {
+				tt.members = members
+			}
+			member := findMember(tt.members, memberID(tt.remove))
+			err := checkMemberRemoval(member, tt.members, 1, 2, tt.healthy)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkMemberRemoval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_UnitRemoveMember(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e := &ETCD{
+		config:  generateTestConfig(),
+		address: mustGetAddress(),
+		name:    "default",
+	}
+	e.config.EtcdDisableSnapshots = true
+	if err := testutil.GenerateRuntime(e.config); err != nil {
+		t.Fatal(err)
+	}
+	defer testutil.CleanupDataDir(e.config)
+	client, err := GetClient(ctx, e.config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.client = client
+	if err := e.Start(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		// RemoveSelf will fail with a specific error, but it still does cleanup for testing purposes
+		if err := e.RemoveSelf(ctx); err != nil && err.Error() != etcdserver.ErrNotEnoughStartedMembers.Error() {
+			t.Error(err)
+		}
+		e.client.Close()
+		cancel()
+		time.Sleep(5 * time.Second)
+	}()
+
+	var local *etcdserverpb.Member
+	if err := wait.PollImmediateWithContext(ctx, time.Second, 30*time.Second, func(ctx context.Context) (bool, error) {
+		members, err := client.MemberList(ctx)
+		if err != nil || len(members.Members) == 0 || members.Members[0].Name == "" {
+			return false, nil
+		}
+		local = members.Members[0]
+		return true, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	learner, err := client.MemberAddAsLearner(ctx, []string{"https://127.0.0.2:2380"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		member       string
+		wantNotFound bool
+		wantErr      bool
+	}{
+		{
+			name:         "unknown member",
+			member:       "server-1",
+			wantNotFound: true,
+			wantErr:      true,
+		},
+		{
+			name:    "local member",
+			member:  local.Name,
+			wantErr: true,
+		},
+		{
+			name:   "learner",
+			member: memberID(learner.Member.ID),
+		},
+		{
+			name:         "removed learner",
+			member:       memberID(learner.Member.ID),
+			wantNotFound: true,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RemoveMember(ctx, e.config, tt.member)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RemoveMember() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var notFound *MemberNotFoundError
+			if errors.As(err, &notFound) != tt.wantNotFound {
+				t.Errorf("RemoveMember() error = %v, wantNotFound %v", err, tt.wantNotFound)
+			}
+		})
+	}
+}
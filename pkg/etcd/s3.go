@@ -1,6 +1,7 @@
 package etcd
 
 import (
+	"archive/zip"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -90,6 +91,21 @@ func NewS3(ctx context.Context, config *config.Control) (*S3, error) {
 	}, nil
 }
 
+// checkBucket verifies that the configured bucket is reachable and exists.
+func (s *S3) checkBucket(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+	defer cancel()
+
+	exists, err := s.client.BucketExists(ctx, s.config.EtcdS3BucketName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("bucket: %s does not exist", s.config.EtcdS3BucketName)
+	}
+	return nil
+}
+
 // upload uploads the given snapshot to the configured S3
 // compatible backend.
 func (s *S3) upload(ctx context.Context, snapshot, extraMetadata string, now time.Time) (*snapshotFile, error) {
@@ -103,15 +119,21 @@ func (s *S3) upload(ctx context.Context, snapshot, extraMetadata string, now tim
 		snapshotFileName = basename
 	}
 
-	toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
-	defer cancel()
-	opts := minio.PutObjectOptions{NumThreads: 2}
-	if strings.HasSuffix(snapshot, compressedExtension) {
-		opts.ContentType = "application/zip"
+	var uploadInfo minio.UploadInfo
+	var err error
+	if s.config.EtcdS3Incremental {
+		uploadInfo, err = s.uploadChunks(ctx, snapshot, snapshotFileName)
 	} else {
-		opts.ContentType = "application/octet-stream"
+		toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+		defer cancel()
+		opts := minio.PutObjectOptions{NumThreads: 2}
+		if strings.HasSuffix(snapshot, compressedExtension) {
+			opts.ContentType = "application/zip"
+		} else {
+			opts.ContentType = "application/octet-stream"
+		}
+		uploadInfo, err = s.client.FPutObject(toCtx, s.config.EtcdS3BucketName, snapshotFileName, snapshot, opts)
 	}
-	uploadInfo, err := s.client.FPutObject(toCtx, s.config.EtcdS3BucketName, snapshotFileName, snapshot, opts)
 	if err != nil {
 		sf = snapshotFile{
 			Name:     filepath.Base(uploadInfo.Key),
@@ -164,7 +186,8 @@ func (s *S3) upload(ctx context.Context, snapshot, extraMetadata string, now tim
 }
 
 // download downloads the given snapshot from the configured S3
-// compatible backend.
+// compatible backend. Snapshots uploaded as chunks are reassembled
+// from their chunks.
 func (s *S3) Download(ctx context.Context) error {
 	var remotePath string
 	if s.config.EtcdS3Folder != "" {
@@ -173,6 +196,19 @@ func (s *S3) Download(ctx context.Context) error {
 		remotePath = s.config.ClusterResetRestorePath
 	}
 
+	if isChunkIndex(remotePath) {
+		snapshotDir, err := snapshotDir(s.config, true)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the snapshot dir")
+		}
+		fullSnapshotPath := filepath.Join(snapshotDir, snapshotKey(s.config.ClusterResetRestorePath))
+		if err := s.downloadChunks(ctx, s.config.EtcdS3BucketName, remotePath, fullSnapshotPath); err != nil {
+			return err
+		}
+		s.config.ClusterResetRestorePath = fullSnapshotPath
+		return s.downloadSidecars(ctx, s.config.EtcdS3BucketName, snapshotKey(remotePath), fullSnapshotPath)
+	}
+
 	logrus.Debugf("retrieving snapshot: %s", remotePath)
 	toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
 	defer cancel()
@@ -206,7 +242,135 @@ func (s *S3) Download(ctx context.Context) error {
 
 	s.config.ClusterResetRestorePath = fullSnapshotPath
 
-	return os.Chmod(fullSnapshotPath, 0600)
+	if err := os.Chmod(fullSnapshotPath, 0600); err != nil {
+		return err
+	}
+	return s.downloadSidecars(ctx, s.config.EtcdS3BucketName, remotePath, fullSnapshotPath)
+}
+
+// uploadSidecars uploads the manifest and signature files of a snapshot to the configured S3 compatible
+// backend, alongside the snapshot.
+func (s *S3) uploadSidecars(ctx context.Context, sidecars []string) error {
+	for _, sidecar := range sidecars {
+		key := filepath.Base(sidecar)
+		if s.config.EtcdS3Folder != "" {
+			key = filepath.Join(s.config.EtcdS3Folder, key)
+		}
+		toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+		_, err := s.client.FPutObject(toCtx, s.config.EtcdS3BucketName, key, sidecar, minio.PutObjectOptions{ContentType: "text/plain"})
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadSidecars downloads the manifest and signature of the snapshot object key, if they exist,
+// alongside the local snapshot file. Missing files are not an error here; they are reported when the
+// snapshot is verified.
+func (s *S3) downloadSidecars(ctx context.Context, bucket, key, snapshotPath string) error {
+	for i, path := range snapshotSidecars(snapshotPath) {
+		toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+		err := s.client.FGetObject(toCtx, bucket, snapshotSidecars(key)[i], path, minio.GetObjectOptions{})
+		cancel()
+		if err != nil {
+			if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+				continue
+			}
+			return errors.Wrapf(err, "failed to get %s from bucket %s", snapshotSidecars(key)[i], bucket)
+		}
+	}
+	return nil
+}
+
+// S3URLPrefix is the prefix of cluster-reset-restore-path values that refer to a snapshot object in S3,
+// in the form s3://<bucket>/<key>.
+const S3URLPrefix = "s3://"
+
+// ParseS3URL returns the bucket and object key from an s3://<bucket>/<key> snapshot URL. The key is
+// used as-is, without the configured S3 folder.
+func ParseS3URL(s3URL string) (bucket, key string, err error) {
+	path, ok := strings.CutPrefix(s3URL, S3URLPrefix)
+	if !ok {
+		return "", "", fmt.Errorf("snapshot URL %s does not start with %s", s3URL, S3URLPrefix)
+	}
+	bucket, key, _ = strings.Cut(path, "/")
+	if bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return "", "", fmt.Errorf("snapshot URL %s must be in the form %s<bucket>/<key>", s3URL, S3URLPrefix)
+	}
+	return bucket, key, nil
+}
+
+// streamSnapshot streams the snapshot object from the bucket to a file in the local snapshot directory,
+// and returns the path to the file. Compressed snapshots are decompressed using ranged reads of the
+// object, so that only the uncompressed snapshot is written to disk. The caller is responsible for
+// removing the file once the snapshot has been restored.
+func (s *S3) streamSnapshot(ctx context.Context, bucket, key string) (string, error) {
+	if isChunkIndex(key) {
+		snapshotDir, err := snapshotDir(s.config, true)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get the snapshot dir")
+		}
+		fullSnapshotPath := filepath.Join(snapshotDir, filepath.Base(snapshotKey(key))+".restore")
+		if err := s.downloadChunks(ctx, bucket, key, fullSnapshotPath); err != nil {
+			return "", err
+		}
+		return fullSnapshotPath, nil
+	}
+
+	toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+	defer cancel()
+
+	obj, err := s.client.GetObject(toCtx, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get snapshot %s from bucket %s", key, bucket)
+	}
+	defer obj.Close()
+	stat, err := obj.Stat()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get snapshot %s from bucket %s", key, bucket)
+	}
+
+	var src io.Reader = obj
+	if strings.HasSuffix(key, compressedExtension) {
+		zr, err := zip.NewReader(obj, stat.Size)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read compressed snapshot %s", key)
+		}
+		if len(zr.File) != 1 {
+			return "", fmt.Errorf("compressed snapshot %s must contain exactly one file, found %d", key, len(zr.File))
+		}
+		rc, err := zr.File[0].Open()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read compressed snapshot %s", key)
+		}
+		defer rc.Close()
+		src = rc
+	}
+
+	snapshotDir, err := snapshotDir(s.config, true)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get the snapshot dir")
+	}
+	fullSnapshotPath := filepath.Join(snapshotDir, strings.TrimSuffix(filepath.Base(key), compressedExtension)+".restore")
+	sf, err := os.OpenFile(fullSnapshotPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer sf.Close()
+
+	n, err := io.Copy(sf, src)
+	if err != nil {
+		os.Remove(fullSnapshotPath)
+		return "", errors.Wrapf(err, "failed to stream snapshot %s from bucket %s", key, bucket)
+	}
+	if err := sf.Sync(); err != nil {
+		os.Remove(fullSnapshotPath)
+		return "", err
+	}
+	logrus.Infof("Streamed %d bytes of etcd snapshot %s from bucket %s", n, key, bucket)
+	return fullSnapshotPath, nil
 }
 
 // snapshotPrefix returns the prefix used in the
@@ -223,12 +387,35 @@ func (s *S3) snapshotPrefix() string {
 	return prefix
 }
 
-// snapshotRetention prunes snapshots in the configured S3 compatible backend for this specific node.
+// snapshotRetention prunes snapshots in the configured S3 compatible backend for this specific node,
+// and then removes any of the node's snapshot chunks that are no longer referenced by a snapshot.
 func (s *S3) snapshotRetention(ctx context.Context) error {
-	if s.config.EtcdSnapshotRetention < 1 {
+	if err := s.pruneSnapshots(ctx); err != nil {
+		return err
+	}
+	if s.config.EtcdS3Incremental {
+		if err := s.pruneChunks(ctx); err != nil {
+			return err
+		}
+	}
+	if s.config.EtcdS3WALArchive {
+		return s.pruneWALSegments(ctx)
+	}
+	return nil
+}
+
+// pruneSnapshots removes snapshots in the configured S3 compatible backend for this specific node
+// that are not retained by the retention count or policy.
+func (s *S3) pruneSnapshots(ctx context.Context) error {
+	policy := snapshotRetentionPolicy(s.config)
+	if s.config.EtcdSnapshotRetention < 1 && policy == nil {
 		return nil
 	}
-	logrus.Infof("Applying snapshot retention policy to snapshots stored in S3: retention: %d, snapshotPrefix: %s", s.config.EtcdSnapshotRetention, s.snapshotPrefix())
+	if policy != nil {
+		logrus.Infof("Applying snapshot retention policy to snapshots stored in S3: policy: %s, snapshotPrefix: %s", policy, s.snapshotPrefix())
+	} else {
+		logrus.Infof("Applying snapshot retention policy to snapshots stored in S3: retention: %d, snapshotPrefix: %s", s.config.EtcdSnapshotRetention, s.snapshotPrefix())
+	}
 
 	var snapshotFiles []minio.ObjectInfo
 
@@ -243,10 +430,13 @@ func (s *S3) snapshotRetention(ctx context.Context) error {
 		if info.Err != nil {
 			return info.Err
 		}
+		if isSnapshotSidecar(info.Key) {
+			continue
+		}
 		snapshotFiles = append(snapshotFiles, info)
 	}
 
-	if len(snapshotFiles) <= s.config.EtcdSnapshotRetention {
+	if policy == nil && len(snapshotFiles) <= s.config.EtcdSnapshotRetention {
 		return nil
 	}
 
@@ -254,12 +444,30 @@ func (s *S3) snapshotRetention(ctx context.Context) error {
 		return snapshotFiles[i].Key < snapshotFiles[j].Key
 	})
 
-	delCount := len(snapshotFiles) - s.config.EtcdSnapshotRetention
-	for _, df := range snapshotFiles[:delCount] {
+	var deleteFiles []minio.ObjectInfo
+	if policy != nil {
+		times := make([]time.Time, len(snapshotFiles))
+		for i, f := range snapshotFiles {
+			times[i] = f.LastModified
+		}
+		for i, retain := range policy.Retain(times) {
+			if !retain {
+				deleteFiles = append(deleteFiles, snapshotFiles[i])
+			}
+		}
+	} else {
+		deleteFiles = snapshotFiles[:len(snapshotFiles)-s.config.EtcdSnapshotRetention]
+	}
+	for _, df := range deleteFiles {
 		logrus.Infof("Removing S3 snapshot: %s", df.Key)
 		if err := s.client.RemoveObject(ctx, s.config.EtcdS3BucketName, df.Key, minio.RemoveObjectOptions{}); err != nil {
 			return err
 		}
+		for _, sidecar := range snapshotSidecars(snapshotKey(df.Key)) {
+			if err := s.client.RemoveObject(ctx, s.config.EtcdS3BucketName, sidecar, minio.RemoveObjectOptions{}); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -0,0 +1,301 @@
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// chunkIndexExtension is appended to the object key of the index of a snapshot uploaded as chunks.
+	chunkIndexExtension = ".chunks"
+	// chunksDir is the directory, relative to the S3 folder, that snapshot chunks are stored under.
+	chunksDir = ".chunks"
+	// snapshotChunkSize is the size of the chunks that snapshots are split into. The etcd database is
+	// written in fixed-size pages, so unchanged pages remain at the same offsets between snapshots, and
+	// fixed-size chunks deduplicate well without content-defined chunking.
+	snapshotChunkSize = 4 << 20
+)
+
+// chunkIndex lists the chunks that make up a snapshot uploaded to S3 in incremental mode. Chunks are
+// named by the hex-encoded SHA-256 of their content, and are shared between snapshots.
+type chunkIndex struct {
+	Size        int64    `json:"size"`
+	SHA256      string   `json:"sha256"`
+	ChunkSize   int64    `json:"chunkSize"`
+	ChunkPrefix string   `json:"chunkPrefix"`
+	Chunks      []string `json:"chunks"`
+}
+
+// isChunkIndex returns true if the object key is the index of a snapshot uploaded as chunks.
+func isChunkIndex(key string) bool {
+	return strings.HasSuffix(key, chunkIndexExtension)
+}
+
+// isSnapshotChunk returns true if the object key is a chunk of a snapshot uploaded as chunks.
+func isSnapshotChunk(key string) bool {
+	return strings.HasPrefix(key, chunksDir+"/") || strings.Contains(key, "/"+chunksDir+"/")
+}
+
+// snapshotKey returns the key that sidecars of the snapshot object are stored alongside. The sidecars of a
+// snapshot uploaded as chunks are named after the snapshot, not its index.
+func snapshotKey(key string) string {
+	return strings.TrimSuffix(key, chunkIndexExtension)
+}
+
+// writeChunks splits the file into chunks, and calls put with the hash and content of each chunk that has
+// not already been seen. It returns the index of the chunks, and the number of chunks that were put.
+func writeChunks(path string, chunkSize int64, seen map[string]bool, put func(hash string, data []byte) error) (*chunkIndex, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	index := &chunkIndex{ChunkSize: chunkSize}
+	fileHash := sha256.New()
+	buf := make([]byte, chunkSize)
+	var count int
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			data := buf[:n]
+			fileHash.Write(data)
+			sum := sha256.Sum256(data)
+			hash := hex.EncodeToString(sum[:])
+			if !seen[hash] {
+				if err := put(hash, data); err != nil {
+					return nil, count, err
+				}
+				seen[hash] = true
+				count++
+			}
+			index.Chunks = append(index.Chunks, hash)
+			index.Size += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, count, err
+		}
+	}
+	index.SHA256 = hex.EncodeToString(fileHash.Sum(nil))
+	return index, count, nil
+}
+
+// readChunks writes the chunks listed in the index to w, in order, verifying the hash of each chunk and
+// of the reassembled snapshot.
+func readChunks(index *chunkIndex, w io.Writer, get func(hash string) (io.ReadCloser, error)) error {
+	fileHash := sha256.New()
+	var size int64
+	for _, hash := range index.Chunks {
+		rc, err := get(hash)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get chunk %s", hash)
+		}
+		chunkHash := sha256.New()
+		n, err := io.Copy(io.MultiWriter(w, fileHash, chunkHash), rc)
+		rc.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to read chunk %s", hash)
+		}
+		if sum := hex.EncodeToString(chunkHash.Sum(nil)); sum != hash {
+			return fmt.Errorf("chunk %s is corrupt: content has hash %s", hash, sum)
+		}
+		size += n
+	}
+	if size != index.Size {
+		return fmt.Errorf("reassembled snapshot is %d bytes, expected %d", size, index.Size)
+	}
+	if sum := hex.EncodeToString(fileHash.Sum(nil)); sum != index.SHA256 {
+		return fmt.Errorf("reassembled snapshot has hash %s, expected %s", sum, index.SHA256)
+	}
+	return nil
+}
+
+// chunkPrefix returns the prefix of the keys of the chunks uploaded by this node. Each node uploads chunks
+// under its own prefix, so that unreferenced chunks can be removed by the node's retention run without
+// racing with uploads from other nodes.
+func (s *S3) chunkPrefix() string {
+	return filepath.Join(s.config.EtcdS3Folder, chunksDir, os.Getenv("NODE_NAME")) + "/"
+}
+
+// listChunks returns the hashes of the chunks stored under the prefix.
+func (s *S3) listChunks(ctx context.Context, prefix string) (map[string]bool, error) {
+	toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+	defer cancel()
+
+	chunks := map[string]bool{}
+	for info := range s.client.ListObjects(toCtx, s.config.EtcdS3BucketName, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, info.Err
+		}
+		chunks[strings.TrimPrefix(info.Key, prefix)] = true
+	}
+	return chunks, nil
+}
+
+// uploadChunks uploads the snapshot as chunks, skipping chunks that have already been uploaded by a
+// previous snapshot, followed by the index of the chunks. The returned upload info is that of the index,
+// with the size of the snapshot.
+func (s *S3) uploadChunks(ctx context.Context, snapshot, key string) (minio.UploadInfo, error) {
+	prefix := s.chunkPrefix()
+	seen, err := s.listChunks(ctx, prefix)
+	if err != nil {
+		return minio.UploadInfo{}, errors.Wrap(err, "failed to list uploaded chunks")
+	}
+
+	var uploaded int64
+	index, put, err := writeChunks(snapshot, snapshotChunkSize, seen, func(hash string, data []byte) error {
+		toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+		defer cancel()
+		if _, err := s.client.PutObject(toCtx, s.config.EtcdS3BucketName, prefix+hash, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{ContentType: "application/octet-stream"}); err != nil {
+			return err
+		}
+		uploaded += int64(len(data))
+		return nil
+	})
+	if err != nil {
+		return minio.UploadInfo{}, errors.Wrap(err, "failed to upload snapshot chunks")
+	}
+	index.ChunkPrefix = prefix
+
+	b, err := json.Marshal(index)
+	if err != nil {
+		return minio.UploadInfo{}, err
+	}
+	toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+	defer cancel()
+	uploadInfo, err := s.client.PutObject(toCtx, s.config.EtcdS3BucketName, key+chunkIndexExtension, bytes.NewReader(b), int64(len(b)), minio.PutObjectOptions{ContentType: "application/json"})
+	if err != nil {
+		return uploadInfo, errors.Wrap(err, "failed to upload snapshot chunk index")
+	}
+	logrus.Infof("Uploaded %d of %d chunks (%d of %d bytes) of snapshot %s to S3", put, len(index.Chunks), uploaded, index.Size, filepath.Base(snapshot))
+	uploadInfo.Size = index.Size
+	if uploadInfo.LastModified.IsZero() {
+		uploadInfo.LastModified = time.Now()
+	}
+	return uploadInfo, nil
+}
+
+// getChunkIndex downloads and decodes the chunk index object.
+func (s *S3) getChunkIndex(ctx context.Context, bucket, key string) (*chunkIndex, error) {
+	toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+	defer cancel()
+
+	obj, err := s.client.GetObject(toCtx, bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Close()
+	index := &chunkIndex{}
+	if err := json.NewDecoder(obj).Decode(index); err != nil {
+		return nil, errors.Wrapf(err, "failed to read snapshot chunk index %s", key)
+	}
+	return index, nil
+}
+
+// downloadChunks reassembles the snapshot whose chunk index is stored at the key, and writes it to path.
+func (s *S3) downloadChunks(ctx context.Context, bucket, key, path string) error {
+	index, err := s.getChunkIndex(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = readChunks(index, f, func(hash string) (io.ReadCloser, error) {
+		toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+		obj, err := s.client.GetObject(toCtx, bucket, index.ChunkPrefix+hash, minio.GetObjectOptions{})
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		return &cancelReadCloser{ReadCloser: obj, cancel: cancel}, nil
+	})
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		os.Remove(path)
+		return errors.Wrapf(err, "failed to reassemble snapshot %s from bucket %s", key, bucket)
+	}
+	logrus.Infof("Reassembled %d bytes of etcd snapshot %s from %d chunks", index.Size, snapshotKey(key), len(index.Chunks))
+	return nil
+}
+
+// pruneChunks removes chunks uploaded by this node that are not referenced by any snapshot index in the
+// folder. All indexes are checked, not only those matching the snapshot prefix, as on-demand snapshots are
+// named with a different prefix.
+func (s *S3) pruneChunks(ctx context.Context) error {
+	prefix := s.chunkPrefix()
+	chunks, err := s.listChunks(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+	defer cancel()
+	var indexes []string
+	for info := range s.client.ListObjects(toCtx, s.config.EtcdS3BucketName, minio.ListObjectsOptions{Prefix: s.config.EtcdS3Folder, Recursive: true}) {
+		if info.Err != nil {
+			return info.Err
+		}
+		if isChunkIndex(info.Key) {
+			indexes = append(indexes, info.Key)
+		}
+	}
+	for _, key := range indexes {
+		index, err := s.getChunkIndex(ctx, s.config.EtcdS3BucketName, key)
+		if err != nil {
+			return err
+		}
+		if index.ChunkPrefix != prefix {
+			continue
+		}
+		for _, hash := range index.Chunks {
+			delete(chunks, hash)
+		}
+	}
+
+	for hash := range chunks {
+		if err := s.client.RemoveObject(ctx, s.config.EtcdS3BucketName, prefix+hash, minio.RemoveObjectOptions{}); err != nil {
+			return err
+		}
+	}
+	if len(chunks) > 0 {
+		logrus.Infof("Removed %d unreferenced snapshot chunks from S3", len(chunks))
+	}
+	return nil
+}
+
+// cancelReadCloser cancels the context of a request when its body is closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
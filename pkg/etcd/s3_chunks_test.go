@@ -0,0 +1,119 @@
+package etcd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_UnitSnapshotChunks(t *testing.T) {
+	previous := bytes.Repeat([]byte("a"), 10)
+	tests := []struct {
+		name      string
+		data      []byte
+		chunkSize int64
+		wantPut   int
+		corrupt   bool
+		wantErr   bool
+	}{
+		{
+			name:      "Empty snapshot",
+			data:      []byte{},
+			chunkSize: 4,
+		},
+		{
+			name:      "Unchanged snapshot",
+			data:      previous,
+			chunkSize: 4,
+		},
+		{
+			name:      "Changed last chunk",
+			data:      append(bytes.Repeat([]byte("a"), 8), 'b', 'b'),
+			chunkSize: 4,
+			wantPut:   1,
+		},
+		{
+			name:      "Repeated new chunk",
+			data:      append(bytes.Repeat([]byte("c"), 8), 'a', 'a'),
+			chunkSize: 4,
+			wantPut:   1,
+		},
+		{
+			name:      "Corrupt chunk",
+			data:      []byte("abcdefgh"),
+			chunkSize: 4,
+			wantPut:   2,
+			corrupt:   true,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := map[string][]byte{}
+			seen := map[string]bool{}
+			put := func(hash string, data []byte) error {
+				store[hash] = append([]byte{}, data...)
+				return nil
+			}
+
+			dir := t.TempDir()
+			previousPath := filepath.Join(dir, "previous")
+			if err := os.WriteFile(previousPath, previous, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := writeChunks(previousPath, tt.chunkSize, seen, put); err != nil {
+				t.Fatal(err)
+			}
+
+			snapshotPath := filepath.Join(dir, "snapshot")
+			if err := os.WriteFile(snapshotPath, tt.data, 0600); err != nil {
+				t.Fatal(err)
+			}
+			index, gotPut, err := writeChunks(snapshotPath, tt.chunkSize, seen, put)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotPut != tt.wantPut {
+				t.Errorf("writeChunks() put %d chunks, want %d", gotPut, tt.wantPut)
+			}
+			if index.Size != int64(len(tt.data)) {
+				t.Errorf("writeChunks() size = %d, want %d", index.Size, len(tt.data))
+			}
+			if tt.corrupt {
+				store[index.Chunks[0]] = []byte("xxxx")
+			}
+
+			buf := &bytes.Buffer{}
+			err = readChunks(index, buf, func(hash string) (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(store[hash])), nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readChunks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(buf.Bytes(), tt.data) {
+				t.Errorf("readChunks() = %q, want %q", buf.Bytes(), tt.data)
+			}
+		})
+	}
+}
+
+func Test_UnitIsSnapshotChunk(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "etcd-snapshot-node-1700000000", want: false},
+		{key: "etcd-snapshot-node-1700000000.chunks", want: false},
+		{key: ".chunks/node/0123abcd", want: true},
+		{key: "folder/.chunks/node/0123abcd", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := isSnapshotChunk(tt.key); got != tt.want {
+				t.Errorf("isSnapshotChunk() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
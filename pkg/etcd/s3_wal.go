@@ -0,0 +1,444 @@
+package etcd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// walArchiveDir is the directory, relative to the S3 folder, that archived changes are stored under.
+const walArchiveDir = ".wal"
+
+// errStopReplay is returned by the replay callback once the restore timestamp has been reached.
+var errStopReplay = errors.New("restore timestamp reached")
+
+// walEntry is a single change to a key, as archived to S3. Changes are archived from a watch on all keys,
+// rather than from the raft log, so that they can be replayed onto a restored snapshot through the client.
+type walEntry struct {
+	Revision int64  `json:"rev"`
+	Time     int64  `json:"time"`
+	Delete   bool   `json:"delete,omitempty"`
+	Key      []byte `json:"key"`
+	Value    []byte `json:"value,omitempty"`
+	Lease    int64  `json:"lease,omitempty"`
+}
+
+// walSegment is an object in the WAL archive, holding the changes from the start to the end revision,
+// inclusive. The time of the last change in the segment is included in the name, so that segments can
+// be selected for restore and pruning without downloading them.
+type walSegment struct {
+	Key      string
+	Start    int64
+	End      int64
+	LastTime time.Time
+}
+
+// isWALSegment returns true if the object key is a segment of the WAL archive.
+func isWALSegment(key string) bool {
+	return strings.HasPrefix(key, walArchiveDir+"/") || strings.Contains(key, "/"+walArchiveDir+"/")
+}
+
+// walSegmentName returns the base name of the segment holding the entries. Revisions are zero-padded
+// so that segments sort in revision order.
+func walSegmentName(entries []walEntry) string {
+	first, last := entries[0], entries[len(entries)-1]
+	return fmt.Sprintf("%020d-%020d-%d", first.Revision, last.Revision, time.Unix(0, last.Time).Unix())
+}
+
+// parseWALSegment parses the start and end revisions, and the time of the last change, from the name of a
+// segment object.
+func parseWALSegment(key string) (walSegment, error) {
+	parts := strings.Split(path.Base(key), "-")
+	if len(parts) != 3 {
+		return walSegment{}, fmt.Errorf("invalid WAL archive segment name %s", key)
+	}
+	var values [3]int64
+	for i, part := range parts {
+		v, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return walSegment{}, errors.Wrapf(err, "invalid WAL archive segment name %s", key)
+		}
+		values[i] = v
+	}
+	return walSegment{Key: key, Start: values[0], End: values[1], LastTime: time.Unix(values[2], 0)}, nil
+}
+
+// writeWALSegment writes the entries as gzip-compressed JSON lines.
+func writeWALSegment(w io.Writer, entries []walEntry) error {
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return gz.Close()
+}
+
+// readWALSegment calls fn with each entry in a segment, in order. Reading stops at the first error returned
+// by fn.
+func readWALSegment(r io.Reader, fn func(walEntry) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	dec := json.NewDecoder(bufio.NewReader(gz))
+	for {
+		entry := walEntry{}
+		if err := dec.Decode(&entry); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
+
+// walSegmentsForRestore returns the segments holding changes after the snapshot revision, up to and including
+// the first segment with changes after the restore time. An error is returned if any revisions between the
+// snapshot and the restore time are missing from the archive.
+func walSegmentsForRestore(segments []walSegment, snapshotRevision int64, until time.Time) ([]walSegment, error) {
+	sort.Slice(segments, func(i, j int) bool { return segments[i].Start < segments[j].Start })
+	selected := []walSegment{}
+	next := snapshotRevision + 1
+	for _, segment := range segments {
+		if segment.End < next {
+			continue
+		}
+		if segment.Start > next {
+			return nil, fmt.Errorf("WAL archive is missing revisions %d to %d", next, segment.Start-1)
+		}
+		selected = append(selected, segment)
+		next = segment.End + 1
+		if segment.LastTime.After(until) {
+			break
+		}
+	}
+	return selected, nil
+}
+
+// walPrefix returns the prefix of the keys of the WAL archive segments.
+func (s *S3) walPrefix() string {
+	return filepath.Join(s.config.EtcdS3Folder, walArchiveDir) + "/"
+}
+
+// listWALSegments returns the segments in the WAL archive.
+func (s *S3) listWALSegments(ctx context.Context) ([]walSegment, error) {
+	toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+	defer cancel()
+
+	segments := []walSegment{}
+	for info := range s.client.ListObjects(toCtx, s.config.EtcdS3BucketName, minio.ListObjectsOptions{Prefix: s.walPrefix(), Recursive: true}) {
+		if info.Err != nil {
+			return nil, info.Err
+		}
+		segment, err := parseWALSegment(info.Key)
+		if err != nil {
+			logrus.Warnf("Skipping object in WAL archive: %v", err)
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// uploadWALSegment uploads the entries to the WAL archive as a single segment.
+func (s *S3) uploadWALSegment(ctx context.Context, entries []walEntry) error {
+	buf := &bytes.Buffer{}
+	if err := writeWALSegment(buf, entries); err != nil {
+		return err
+	}
+	toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+	defer cancel()
+	_, err := s.client.PutObject(toCtx, s.config.EtcdS3BucketName, s.walPrefix()+walSegmentName(entries), buf, int64(buf.Len()), minio.PutObjectOptions{ContentType: "application/gzip"})
+	return err
+}
+
+// readWALSegmentObject downloads a segment, and calls fn with each of its entries.
+func (s *S3) readWALSegmentObject(ctx context.Context, key string, fn func(walEntry) error) error {
+	toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+	defer cancel()
+	obj, err := s.client.GetObject(toCtx, s.config.EtcdS3BucketName, key, minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+	return readWALSegment(obj, fn)
+}
+
+// pruneWALSegments removes segments holding only changes made before the oldest snapshot in the folder, as they
+// are included in every snapshot that could be restored.
+func (s *S3) pruneWALSegments(ctx context.Context) error {
+	toCtx, cancel := context.WithTimeout(ctx, s.config.EtcdS3Timeout)
+	defer cancel()
+
+	var oldest time.Time
+	for info := range s.client.ListObjects(toCtx, s.config.EtcdS3BucketName, minio.ListObjectsOptions{Prefix: s.config.EtcdS3Folder, Recursive: true}) {
+		if info.Err != nil {
+			return info.Err
+		}
+		if info.Size == 0 || isSnapshotSidecar(info.Key) || isSnapshotChunk(info.Key) || isWALSegment(info.Key) {
+			continue
+		}
+		if takenAt := snapshotTakenAt(info.Key, info.LastModified); oldest.IsZero() || takenAt.Before(oldest) {
+			oldest = takenAt
+		}
+	}
+	if oldest.IsZero() {
+		return nil
+	}
+
+	segments, err := s.listWALSegments(ctx)
+	if err != nil {
+		return err
+	}
+	var pruned int
+	for _, segment := range segments {
+		if !segment.LastTime.Before(oldest) {
+			continue
+		}
+		if err := s.client.RemoveObject(ctx, s.config.EtcdS3BucketName, segment.Key, minio.RemoveObjectOptions{}); err != nil {
+			return err
+		}
+		pruned++
+	}
+	if pruned > 0 {
+		logrus.Infof("Removed %d WAL archive segments older than the oldest snapshot in S3", pruned)
+	}
+	return nil
+}
+
+// snapshotTakenAt returns the time that a snapshot was taken, from the Unix timestamp at the end of its name, or
+// the time it was uploaded if its name does not end with a timestamp. Snapshots are uploaded some time after they
+// are taken, so changes made shortly before the upload may not be included in the snapshot.
+func snapshotTakenAt(key string, uploaded time.Time) time.Time {
+	name := strings.TrimSuffix(snapshotKey(path.Base(key)), compressedExtension)
+	if i := strings.LastIndex(name, "-"); i >= 0 {
+		if ts, err := strconv.ParseInt(name[i+1:], 10, 64); err == nil && ts > 0 {
+			return time.Unix(ts, 0)
+		}
+	}
+	return uploaded
+}
+
+// archiveWAL archives changes to S3 while the local member is the etcd leader, so that the datastore can be
+// restored to a point in time between snapshots. Only the leader archives changes, so that they are not
+// archived more than once; a new leader resumes from the last revision in the archive.
+func (e *ETCD) archiveWAL(ctx context.Context) {
+	ticker := time.NewTicker(e.config.EtcdS3WALArchiveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if leader, err := e.isLeader(ctx); err != nil || !leader {
+			continue
+		}
+		if err := e.initS3IfNil(ctx); err != nil {
+			logrus.Errorf("Failed to initialize S3 client for WAL archive: %v", err)
+			continue
+		}
+		logrus.Infof("Archiving etcd changes to S3 bucket %s", e.config.EtcdS3BucketName)
+		if err := e.archiveWALSession(ctx, ticker.C); err != nil {
+			logrus.Errorf("Failed to archive etcd changes to S3: %v", err)
+		}
+	}
+}
+
+// isLeader returns true if the local member is the etcd leader.
+func (e *ETCD) isLeader(ctx context.Context) (bool, error) {
+	endpoints := getEndpoints(e.config)
+	status, err := e.client.Status(ctx, endpoints[0])
+	if err != nil {
+		return false, err
+	}
+	return status.Leader == status.Header.MemberId, nil
+}
+
+// archiveWALSession watches all keys from the revision after the last archived revision, and uploads the changes
+// each time the ticker fires. It returns once the local member is no longer the leader.
+func (e *ETCD) archiveWALSession(ctx context.Context, tick <-chan time.Time) error {
+	segments, err := e.s3.listWALSegments(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list WAL archive segments")
+	}
+	var next int64
+	for _, segment := range segments {
+		if segment.End >= next {
+			next = segment.End + 1
+		}
+	}
+	if next == 0 {
+		resp, err := e.client.Get(ctx, "/", clientv3.WithCountOnly())
+		if err != nil {
+			return err
+		}
+		next = resp.Header.Revision + 1
+	}
+
+	ctx = clientv3.WithRequireLeader(ctx)
+	watchFrom := func(rev int64) (clientv3.WatchChan, context.CancelFunc) {
+		watchCtx, cancel := context.WithCancel(ctx)
+		return e.client.Watch(watchCtx, "", clientv3.WithPrefix(), clientv3.WithRev(rev)), cancel
+	}
+	watch, stopWatch := watchFrom(next)
+	defer func() { stopWatch() }()
+	entries := []walEntry{}
+	flush := func() error {
+		if len(entries) == 0 {
+			return nil
+		}
+		if err := e.s3.uploadWALSegment(ctx, entries); err != nil {
+			return errors.Wrap(err, "failed to upload WAL archive segment")
+		}
+		logrus.Debugf("Archived %d etcd changes up to revision %d to S3", len(entries), entries[len(entries)-1].Revision)
+		entries = entries[:0]
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return flush()
+		case <-tick:
+			// Changes that fail to upload are kept, and retried with the next segment.
+			if err := flush(); err != nil {
+				logrus.Errorf("Failed to archive etcd changes to S3: %v", err)
+			}
+			if leader, err := e.isLeader(ctx); err == nil && !leader {
+				return flush()
+			}
+		case resp, ok := <-watch:
+			if !ok {
+				return flush()
+			}
+			if resp.CompactRevision != 0 {
+				// The changes have been compacted away, so archiving resumes from the oldest available revision.
+				// Restores to times between the last archived change and the next snapshot will fail, as the
+				// archive is missing the revisions in between.
+				logrus.Warnf("etcd revisions %d to %d were compacted before they could be archived", next, resp.CompactRevision-1)
+				if err := flush(); err != nil {
+					return err
+				}
+				next = resp.CompactRevision
+				stopWatch()
+				watch, stopWatch = watchFrom(next)
+				continue
+			}
+			if err := resp.Err(); err != nil {
+				flush()
+				return err
+			}
+			now := time.Now().UnixNano()
+			for _, event := range resp.Events {
+				entries = append(entries, walEntry{
+					Revision: event.Kv.ModRevision,
+					Time:     now,
+					Delete:   event.Type == mvccpb.DELETE,
+					Key:      event.Kv.Key,
+					Value:    event.Kv.Value,
+					Lease:    event.Kv.Lease,
+				})
+				next = event.Kv.ModRevision + 1
+			}
+		}
+	}
+}
+
+// replayWAL applies the archived changes made after the snapshot revision, up to the restore time. Keys attached
+// to a lease are not replayed, as the leases are not restored.
+func (e *ETCD) replayWAL(ctx context.Context, snapshotRevision int64, until time.Time) error {
+	if err := e.initS3IfNil(ctx); err != nil {
+		return err
+	}
+	segments, err := e.s3.listWALSegments(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list WAL archive segments")
+	}
+	selected, err := walSegmentsForRestore(segments, snapshotRevision, until)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Replaying etcd changes after revision %d up to %s from %d WAL archive segments", snapshotRevision, until.Format(time.RFC3339), len(selected))
+	var applied int
+	apply := func(entry walEntry) error {
+		if entry.Revision <= snapshotRevision {
+			return nil
+		}
+		if time.Unix(0, entry.Time).After(until) {
+			return errStopReplay
+		}
+		if entry.Lease != 0 {
+			return nil
+		}
+		var err error
+		if entry.Delete {
+			_, err = e.client.Delete(ctx, string(entry.Key))
+		} else {
+			_, err = e.client.Put(ctx, string(entry.Key), string(entry.Value))
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to replay revision %d", entry.Revision)
+		}
+		applied++
+		return nil
+	}
+	for _, segment := range selected {
+		if err := e.s3.readWALSegmentObject(ctx, segment.Key, apply); err == errStopReplay {
+			break
+		} else if err != nil {
+			return errors.Wrapf(err, "failed to replay WAL archive segment %s", segment.Key)
+		}
+	}
+	if len(selected) == 0 || !selected[len(selected)-1].LastTime.After(until) {
+		logrus.Warnf("WAL archive ends before %s; the datastore was restored to the last archived change", until.Format(time.RFC3339))
+	}
+	logrus.Infof("Replayed %d etcd changes from the WAL archive", applied)
+	return nil
+}
+
+// latestS3SnapshotBefore returns the name of the most recent snapshot in S3 that was taken before the time.
+func (e *ETCD) latestS3SnapshotBefore(ctx context.Context, until time.Time) (string, error) {
+	snapshots, err := e.listS3Snapshots(ctx)
+	if err != nil {
+		return "", err
+	}
+	var name string
+	var latest time.Time
+	for _, sf := range snapshots {
+		if sf.CreatedAt == nil {
+			continue
+		}
+		takenAt := snapshotTakenAt(sf.Name, sf.CreatedAt.Time)
+		if takenAt.After(until) || !takenAt.After(latest) {
+			continue
+		}
+		name, latest = sf.Name, takenAt
+	}
+	if name == "" {
+		return "", fmt.Errorf("no snapshot in S3 was taken before %s", until.Format(time.RFC3339))
+	}
+	return name, nil
+}
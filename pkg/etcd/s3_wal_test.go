@@ -0,0 +1,173 @@
+package etcd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func Test_UnitWALSegment(t *testing.T) {
+	last := time.Unix(1700000000, 500)
+	entries := []walEntry{
+		{Revision: 5, Time: last.Add(-time.Second).UnixNano(), Key: []byte("/registry/a"), Value: []byte("1")},
+		{Revision: 6, Time: last.UnixNano(), Key: []byte("/registry/a"), Delete: true},
+		{Revision: 9, Time: last.UnixNano(), Key: []byte("/registry/b"), Value: []byte("2"), Lease: 42},
+	}
+
+	name := walSegmentName(entries)
+	segment, err := parseWALSegment("folder/" + walArchiveDir + "/" + name)
+	if err != nil {
+		t.Fatalf("parseWALSegment() error = %v", err)
+	}
+	if segment.Start != 5 || segment.End != 9 || !segment.LastTime.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("parseWALSegment() = %+v, want revisions 5 to 9 at %v", segment, time.Unix(1700000000, 0))
+	}
+	if _, err := parseWALSegment("folder/" + walArchiveDir + "/invalid"); err == nil {
+		t.Errorf("parseWALSegment() of invalid name succeeded")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeWALSegment(buf, entries); err != nil {
+		t.Fatalf("writeWALSegment() error = %v", err)
+	}
+	got := []walEntry{}
+	if err := readWALSegment(bytes.NewReader(buf.Bytes()), func(entry walEntry) error {
+		got = append(got, entry)
+		if entry.Revision == 6 {
+			return errStopReplay
+		}
+		return nil
+	}); err != errStopReplay {
+		t.Fatalf("readWALSegment() error = %v, want %v", err, errStopReplay)
+	}
+	if len(got) != 2 {
+		t.Fatalf("readWALSegment() read %d entries, want 2", len(got))
+	}
+	if string(got[0].Key) != "/registry/a" || string(got[0].Value) != "1" || got[0].Time != entries[0].Time || !got[1].Delete {
+		t.Errorf("readWALSegment() = %+v, want %+v", got, entries[:2])
+	}
+}
+
+func Test_UnitWALSegmentsForRestore(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	segments := func() []walSegment {
+		return []walSegment{
+			{Key: "c", Start: 21, End: 30, LastTime: base.Add(3 * time.Minute)},
+			{Key: "a", Start: 1, End: 10, LastTime: base.Add(time.Minute)},
+			{Key: "b", Start: 11, End: 20, LastTime: base.Add(2 * time.Minute)},
+		}
+	}
+	tests := []struct {
+		name     string
+		segments []walSegment
+		snapRev  int64
+		until    time.Time
+		want     []string
+		wantErr  bool
+	}{
+		{
+			name:     "Snapshot within first segment",
+			segments: segments(),
+			snapRev:  5,
+			until:    base.Add(90 * time.Second),
+			want:     []string{"a", "b"},
+		},
+		{
+			name:     "Snapshot at segment boundary",
+			segments: segments(),
+			snapRev:  10,
+			until:    base.Add(5 * time.Minute),
+			want:     []string{"b", "c"},
+		},
+		{
+			name:     "Snapshot after archive",
+			segments: segments(),
+			snapRev:  30,
+			until:    base.Add(5 * time.Minute),
+			want:     []string{},
+		},
+		{
+			name:     "Missing revisions",
+			segments: append(segments()[:2], walSegment{Key: "d", Start: 35, End: 40, LastTime: base.Add(4 * time.Minute)}),
+			snapRev:  10,
+			until:    base.Add(5 * time.Minute),
+			wantErr:  true,
+		},
+		{
+			name:     "Missing revisions after restore time",
+			segments: append(segments()[1:], walSegment{Key: "d", Start: 35, End: 40, LastTime: base.Add(4 * time.Minute)}),
+			snapRev:  5,
+			until:    base.Add(30 * time.Second),
+			want:     []string{"a"},
+		},
+		{
+			name:     "Archive starts after snapshot",
+			segments: segments()[:1],
+			snapRev:  5,
+			until:    base.Add(5 * time.Minute),
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := walSegmentsForRestore(tt.segments, tt.snapRev, tt.until)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("walSegmentsForRestore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			keys := []string{}
+			for _, segment := range got {
+				keys = append(keys, segment.Key)
+			}
+			if len(keys) != len(tt.want) {
+				t.Fatalf("walSegmentsForRestore() = %v, want %v", keys, tt.want)
+			}
+			for i := range keys {
+				if keys[i] != tt.want[i] {
+					t.Errorf("walSegmentsForRestore() = %v, want %v", keys, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func Test_UnitSnapshotTakenAt(t *testing.T) {
+	uploaded := time.Unix(1700000300, 0)
+	tests := []struct {
+		key  string
+		want time.Time
+	}{
+		{key: "folder/etcd-snapshot-node-1700000000", want: time.Unix(1700000000, 0)},
+		{key: "etcd-snapshot-node-1700000000.zip", want: time.Unix(1700000000, 0)},
+		{key: "etcd-snapshot-node-1700000000.chunks", want: time.Unix(1700000000, 0)},
+		{key: "my-snapshot", want: uploaded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := snapshotTakenAt(tt.key, uploaded); !got.Equal(tt.want) {
+				t.Errorf("snapshotTakenAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitIsWALSegment(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "etcd-snapshot-node-1700000000", want: false},
+		{key: "etcd-snapshot.wal-1700000000", want: false},
+		{key: ".wal/00000000000000000001-00000000000000000010-1700000000", want: true},
+		{key: "folder/.wal/00000000000000000001-00000000000000000010-1700000000", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := isWALSegment(tt.key); got != tt.want {
+				t.Errorf("isWALSegment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
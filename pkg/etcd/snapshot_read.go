@@ -0,0 +1,153 @@
+package etcd
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+// revBytesLen is the length of the revision that bbolt keys in an etcd database consist of.
+// Keys that are one byte longer, ending in 't', mark the deletion of the key at that revision.
+const revBytesLen = 8 + 1 + 8
+
+// ReadSnapshotKey returns the latest value of a key from an etcd snapshot, without restoring the snapshot.
+// Compressed snapshots are decompressed to a temporary directory first.
+func ReadSnapshotKey(snapshotPath, key string) ([]byte, error) {
+	db, closeSnapshot, err := openSnapshot(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSnapshot()
+
+	var value []byte
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte("key"))
+		if bucket == nil {
+			return errors.New("snapshot does not contain any keys")
+		}
+		// Revisions are stored in ascending order, so the last matching entry holds the latest value.
+		return bucket.ForEach(func(rev, v []byte) error {
+			kv := &mvccpb.KeyValue{}
+			if err := kv.Unmarshal(v); err != nil {
+				return err
+			}
+			if string(kv.Key) != key {
+				return nil
+			}
+			if len(rev) == revBytesLen+1 && rev[revBytesLen] == 't' {
+				value = nil
+			} else {
+				value = append([]byte{}, kv.Value...)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, errors.Errorf("key %s not found in snapshot", key)
+	}
+	return value, nil
+}
+
+// snapshotRevision returns the revision of the datastore at the time an etcd snapshot was taken, without
+// restoring the snapshot. This is the latest revision of any key, or the revision of the last compaction if
+// the latest revision was a deletion that has since been compacted.
+func snapshotRevision(snapshotPath string) (int64, error) {
+	db, closeSnapshot, err := openSnapshot(snapshotPath)
+	if err != nil {
+		return 0, err
+	}
+	defer closeSnapshot()
+
+	var revision int64
+	err = db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte("key")); bucket != nil {
+			if rev, _ := bucket.Cursor().Last(); len(rev) >= 8 {
+				revision = int64(binary.BigEndian.Uint64(rev))
+			}
+		}
+		if bucket := tx.Bucket([]byte("meta")); bucket != nil {
+			if rev := bucket.Get([]byte("finishedCompactRev")); len(rev) >= 8 {
+				if compacted := int64(binary.BigEndian.Uint64(rev)); compacted > revision {
+					revision = compacted
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if revision == 0 {
+		return 0, errors.New("snapshot does not contain any revisions")
+	}
+	return revision, nil
+}
+
+// openSnapshot opens an etcd snapshot read-only. Compressed snapshots are decompressed to a temporary
+// directory, which is removed when the returned function is called.
+func openSnapshot(snapshotPath string) (*bolt.DB, func(), error) {
+	var tmpDir string
+	if strings.HasSuffix(snapshotPath, compressedExtension) {
+		var err error
+		if tmpDir, err = os.MkdirTemp("", "etcd-snapshot-"); err != nil {
+			return nil, nil, err
+		}
+		if snapshotPath, err = unzipSnapshot(snapshotPath, tmpDir); err != nil {
+			os.RemoveAll(tmpDir)
+			return nil, nil, errors.Wrap(err, "failed to decompress snapshot")
+		}
+	}
+
+	db, err := bolt.Open(snapshotPath, 0400, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		if tmpDir != "" {
+			os.RemoveAll(tmpDir)
+		}
+		return nil, nil, errors.Wrap(err, "failed to open snapshot")
+	}
+	return db, func() {
+		db.Close()
+		if tmpDir != "" {
+			os.RemoveAll(tmpDir)
+		}
+	}, nil
+}
+
+// unzipSnapshot extracts the snapshot from a compressed snapshot into the directory, and returns its path.
+func unzipSnapshot(zipPath, dir string) (string, error) {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	if len(r.File) != 1 {
+		return "", errors.Errorf("expected a single file in compressed snapshot, found %d", len(r.File))
+	}
+
+	src, err := r.File[0].Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	path := filepath.Join(dir, filepath.Base(r.File[0].Name))
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+	if _, err := io.Copy(dst, src); err != nil {
+		return "", err
+	}
+	return path, nil
+}
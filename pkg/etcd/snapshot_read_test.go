@@ -0,0 +1,151 @@
+package etcd
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+)
+
+type testRevision struct {
+	key       string
+	value     string
+	tombstone bool
+}
+
+// writeTestSnapshot writes a bbolt database in the etcd backend format, with one revision per entry.
+func writeTestSnapshot(t *testing.T, path string, revisions []testRevision) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket([]byte("key"))
+		if err != nil {
+			return err
+		}
+		for i, r := range revisions {
+			rev := make([]byte, revBytesLen, revBytesLen+1)
+			binary.BigEndian.PutUint64(rev, uint64(i+1))
+			rev[8] = '_'
+			if r.tombstone {
+				rev = append(rev, 't')
+			}
+			kv := &mvccpb.KeyValue{Key: []byte(r.key), Value: []byte(r.value), ModRevision: int64(i + 1)}
+			data, err := kv.Marshal()
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put(rev, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_UnitReadSnapshotKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		revisions []testRevision
+		compress  bool
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "Latest value",
+			revisions: []testRevision{{key: "/k3s/keystore", value: "v1"}, {key: "/other", value: "x"}, {key: "/k3s/keystore", value: "v2"}},
+			want:      "v2",
+		},
+		{
+			name:      "Compressed snapshot",
+			revisions: []testRevision{{key: "/k3s/keystore", value: "v1"}},
+			compress:  true,
+			want:      "v1",
+		},
+		{
+			name:      "Deleted key",
+			revisions: []testRevision{{key: "/k3s/keystore", value: "v1"}, {key: "/k3s/keystore", tombstone: true}},
+			wantErr:   true,
+		},
+		{
+			name:      "Missing key",
+			revisions: []testRevision{{key: "/other", value: "x"}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "etcd-snapshot")
+			writeTestSnapshot(t, path, tt.revisions)
+			if tt.compress {
+				zipPath := path + compressedExtension
+				f, err := os.Create(zipPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				w := zip.NewWriter(f)
+				fw, err := w.Create(filepath.Base(path))
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				fw.Write(data)
+				w.Close()
+				f.Close()
+				path = zipPath
+			}
+
+			got, err := ReadSnapshotKey(path, "/k3s/keystore")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadSnapshotKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ReadSnapshotKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitSnapshotRevision(t *testing.T) {
+	tests := []struct {
+		name      string
+		revisions []testRevision
+		want      int64
+		wantErr   bool
+	}{
+		{
+			name:      "Latest revision",
+			revisions: []testRevision{{key: "/a", value: "1"}, {key: "/b", value: "2"}, {key: "/a", tombstone: true}},
+			want:      3,
+		},
+		{
+			name:    "Empty snapshot",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "etcd-snapshot")
+			writeTestSnapshot(t, path, tt.revisions)
+			got, err := snapshotRevision(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("snapshotRevision() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("snapshotRevision() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
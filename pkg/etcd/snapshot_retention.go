@@ -0,0 +1,105 @@
+package etcd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/sirupsen/logrus"
+)
+
+// RetentionPolicy is a grandfather-father-son snapshot retention policy. For each period, the newest
+// snapshot in each of the most recent periods that contain a snapshot is retained, up to the given count.
+// A snapshot retained for one period also counts towards the others, so the policy
+// hourly=24,daily=7,weekly=4 retains at most 35 snapshots, and usually fewer.
+type RetentionPolicy struct {
+	Hourly  int
+	Daily   int
+	Weekly  int
+	Monthly int
+}
+
+// ParseRetentionPolicy parses a retention policy in the form hourly=24,daily=7,weekly=4,monthly=12.
+// Periods that are omitted retain no snapshots. An empty policy returns nil.
+func ParseRetentionPolicy(policy string) (*RetentionPolicy, error) {
+	if strings.TrimSpace(policy) == "" {
+		return nil, nil
+	}
+	p := &RetentionPolicy{}
+	for _, item := range strings.Split(policy, ",") {
+		period, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid retention policy item %q; expected period=count", item)
+		}
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid snapshot count %q for %s retention", value, period)
+		}
+		switch period {
+		case "hourly":
+			p.Hourly = count
+		case "daily":
+			p.Daily = count
+		case "weekly":
+			p.Weekly = count
+		case "monthly":
+			p.Monthly = count
+		default:
+			return nil, fmt.Errorf("invalid retention period %q; valid periods are hourly, daily, weekly and monthly", period)
+		}
+	}
+	if p.Hourly+p.Daily+p.Weekly+p.Monthly == 0 {
+		return nil, fmt.Errorf("retention policy %q does not retain any snapshots", policy)
+	}
+	return p, nil
+}
+
+// snapshotRetentionPolicy returns the configured retention policy, or nil if snapshots are retained by count.
+// The policy is validated when the server starts, so an invalid policy falls back to the retention count.
+func snapshotRetentionPolicy(config *config.Control) *RetentionPolicy {
+	policy, err := ParseRetentionPolicy(config.EtcdSnapshotRetentionPolicy)
+	if err != nil {
+		logrus.Errorf("Ignoring invalid etcd snapshot retention policy: %v", err)
+		return nil
+	}
+	return policy
+}
+
+func (p *RetentionPolicy) String() string {
+	return fmt.Sprintf("hourly=%d,daily=%d,weekly=%d,monthly=%d", p.Hourly, p.Daily, p.Weekly, p.Monthly)
+}
+
+// Retain returns true for each snapshot that is retained by the policy, given the snapshot creation times
+// sorted from oldest to newest. Periods are calculated in UTC, so that all servers agree on them.
+func (p *RetentionPolicy) Retain(times []time.Time) []bool {
+	periods := []struct {
+		count int
+		key   func(time.Time) string
+	}{
+		{p.Hourly, func(t time.Time) string { return t.Format("2006-01-02T15") }},
+		{p.Daily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{p.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{p.Monthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	retain := make([]bool, len(times))
+	last := make([]string, len(periods))
+	for i := len(times) - 1; i >= 0; i-- {
+		t := times[i].UTC()
+		for j := range periods {
+			if periods[j].count == 0 {
+				continue
+			}
+			if key := periods[j].key(t); key != last[j] {
+				last[j] = key
+				periods[j].count--
+				retain[i] = true
+			}
+		}
+	}
+	return retain
+}
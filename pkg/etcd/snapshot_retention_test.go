@@ -0,0 +1,119 @@
+package etcd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_UnitParseRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		want    *RetentionPolicy
+		wantErr bool
+	}{
+		{
+			name:   "empty",
+			policy: "",
+		},
+		{
+			name:   "all periods",
+			policy: "hourly=24, daily=7,weekly=4,monthly=12",
+			want:   &RetentionPolicy{Hourly: 24, Daily: 7, Weekly: 4, Monthly: 12},
+		},
+		{
+			name:   "some periods",
+			policy: "daily=7",
+			want:   &RetentionPolicy{Daily: 7},
+		},
+		{
+			name:    "unknown period",
+			policy:  "yearly=2",
+			wantErr: true,
+		},
+		{
+			name:    "invalid count",
+			policy:  "hourly=-1",
+			wantErr: true,
+		},
+		{
+			name:    "missing count",
+			policy:  "hourly",
+			wantErr: true,
+		},
+		{
+			name:    "retains nothing",
+			policy:  "hourly=0,daily=0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRetentionPolicy(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRetentionPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRetentionPolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitRetentionPolicyRetain(t *testing.T) {
+	// Snapshots taken every 6 hours for 30 days, starting on Monday 2026-06-01
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	times := []time.Time{}
+	for i := 0; i < 30*4; i++ {
+		times = append(times, start.Add(time.Duration(i)*6*time.Hour))
+	}
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		want   []time.Time
+	}{
+		{
+			name:   "hourly",
+			policy: RetentionPolicy{Hourly: 3},
+			want:   times[len(times)-3:],
+		},
+		{
+			name:   "daily",
+			policy: RetentionPolicy{Daily: 2},
+			// the newest snapshot on each of the last two days
+			want: []time.Time{start.Add(28*24*time.Hour + 18*time.Hour), start.Add(29*24*time.Hour + 18*time.Hour)},
+		},
+		{
+			name:   "hourly and weekly",
+			policy: RetentionPolicy{Hourly: 2, Weekly: 3},
+			want: []time.Time{
+				// the newest snapshot in the weeks starting 2026-06-15 and 2026-06-22
+				start.Add(20*24*time.Hour + 18*time.Hour),
+				start.Add(27*24*time.Hour + 18*time.Hour),
+				// the newest two snapshots, the second of which is also the newest in the week starting 2026-06-29
+				start.Add(29*24*time.Hour + 12*time.Hour),
+				start.Add(29*24*time.Hour + 18*time.Hour),
+			},
+		},
+		{
+			name:   "more periods than snapshots",
+			policy: RetentionPolicy{Monthly: 12},
+			want:   []time.Time{times[len(times)-1]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []time.Time{}
+			for i, retain := range tt.policy.Retain(times) {
+				if retain {
+					got = append(got, times[i])
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Retain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/k3s-io/k3s/pkg/util"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	snapshotSkippedEvent       = "EtcdSnapshotSkipped"
+	snapshotS3UnreachableEvent = "EtcdSnapshotS3Unreachable"
+)
+
+// checkScheduledSnapshot verifies that a scheduled snapshot can be saved without filling the snapshot filesystem.
+// If there is not enough free space and pruning is enabled, the oldest local snapshots are removed, while always
+// retaining at least one, and the space is checked again. An error is returned if the snapshot should be skipped.
+// If S3 is enabled but not reachable, a warning is recorded, but the snapshot is still saved locally.
+func (e *ETCD) checkScheduledSnapshot(ctx context.Context) error {
+	if err := e.preSnapshotSetup(ctx, e.config); err != nil {
+		return err
+	}
+
+	if e.config.EtcdS3 {
+		err := e.initS3IfNil(ctx)
+		if err == nil {
+			err = e.s3.checkBucket(ctx)
+		}
+		if err != nil {
+			logrus.Warnf("S3 is not reachable; scheduled etcd snapshot will only be saved locally: %v", err)
+			e.recordSnapshotEvent(snapshotS3UnreachableEvent, "S3 is not reachable; scheduled etcd snapshot will only be saved locally: %v", err)
+		}
+	}
+
+	if e.config.EtcdSnapshotMinFree <= 0 {
+		return nil
+	}
+
+	snapshotDir, err := snapshotDir(e.config, true)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the snapshot dir")
+	}
+	status, err := e.client.Status(ctx, getEndpoints(e.config)[0])
+	if err != nil {
+		return errors.Wrap(err, "failed to check etcd status for snapshot")
+	}
+
+	err = checkSnapshotSpace(snapshotDir, uint64(status.DbSize), e.config.EtcdSnapshotMinFree)
+	// Pruning an extra snapshot first is only possible with a retention count, as a retention policy
+	// retains snapshots by age rather than by number.
+	if err == nil || !e.config.EtcdSnapshotPruneFirst || e.config.EtcdSnapshotRetention <= 1 || e.config.EtcdSnapshotRetentionPolicy != "" {
+		return err
+	}
+	logrus.Warnf("%v; removing old snapshots before taking scheduled snapshot", err)
+	if err := snapshotRetention(e.config.EtcdSnapshotRetention-1, nil, e.config.EtcdSnapshotName, snapshotDir); err != nil {
+		return errors.Wrap(err, "failed to apply local snapshot retention policy")
+	}
+	return checkSnapshotSpace(snapshotDir, uint64(status.DbSize), e.config.EtcdSnapshotMinFree)
+}
+
+// checkSnapshotSpace returns an error if saving a snapshot of the given size would leave less than the minimum
+// percentage of the filesystem free. Snapshots are not compressed until after they have been saved, so the full
+// size of the database is required.
+func checkSnapshotSpace(snapshotDir string, dbSize uint64, minFreePercent int) error {
+	free, total, err := filesystemSpace(snapshotDir)
+	if err != nil {
+		logrus.Debugf("Unable to check free space for etcd snapshot: %v", err)
+		return nil
+	}
+	required := dbSize + total*uint64(minFreePercent)/100
+	if free < required {
+		return fmt.Errorf("insufficient space in %s for etcd snapshot: %dMi free, %dMi required to save %dMi snapshot with %d%% of filesystem remaining free", snapshotDir, free/1024/1024, required/1024/1024, dbSize/1024/1024, minFreePercent)
+	}
+	return nil
+}
+
+// snapshotSkipped logs the reason that a scheduled snapshot was skipped, and records an event against this node.
+func (e *ETCD) snapshotSkipped(err error) {
+	logrus.Errorf("Skipping scheduled etcd snapshot: %v", err)
+	e.recordSnapshotEvent(snapshotSkippedEvent, "Skipped scheduled etcd snapshot: %v", err)
+}
+
+// recordSnapshotEvent records a warning event against this node.
+func (e *ETCD) recordSnapshotEvent(reason, messageFmt string, args ...interface{}) {
+	if e.recorder == nil {
+		if e.config.Runtime.K8s == nil {
+			return
+		}
+		e.recorder = util.BuildControllerEventRecorder(e.config.Runtime.K8s, version.Program+"-etcd-snapshot", metav1.NamespaceDefault)
+	}
+	nodeName := os.Getenv("NODE_NAME")
+	ref := &v1.ObjectReference{
+		Kind: "Node",
+		Name: nodeName,
+		UID:  types.UID(nodeName),
+	}
+	e.recorder.Eventf(ref, v1.EventTypeWarning, reason, messageFmt, args...)
+}
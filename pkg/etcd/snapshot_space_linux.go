@@ -0,0 +1,15 @@
+//go:build linux
+// +build linux
+
+package etcd
+
+import "golang.org/x/sys/unix"
+
+// filesystemSpace returns the free and total bytes on the filesystem containing path.
+func filesystemSpace(path string) (uint64, uint64, error) {
+	stat := &unix.Statfs_t{}
+	if err := unix.Statfs(path, stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), stat.Blocks * uint64(stat.Bsize), nil
+}
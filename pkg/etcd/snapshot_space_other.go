@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package etcd
+
+import "errors"
+
+// filesystemSpace is not implemented on this platform.
+func filesystemSpace(path string) (uint64, uint64, error) {
+	return 0, 0, errors.New("not supported on this platform")
+}
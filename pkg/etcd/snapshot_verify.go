@@ -0,0 +1,266 @@
+package etcd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// manifestExtension is appended to the name of a snapshot to get the name of its manifest.
+	manifestExtension = ".manifest"
+	// signatureExtension is appended to the name of a manifest to get the name of its signature.
+	signatureExtension = ".sig"
+)
+
+// snapshotManifest records the checksum of the database in a snapshot. The checksum is that of the
+// uncompressed database, so that compressed snapshots can be verified after decompression, including
+// when they are streamed from S3.
+type snapshotManifest struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// isSnapshotSidecar returns true if the file is a manifest or signature written alongside a snapshot.
+func isSnapshotSidecar(name string) bool {
+	return strings.HasSuffix(name, manifestExtension) || strings.HasSuffix(name, manifestExtension+signatureExtension)
+}
+
+// snapshotSidecars returns the paths of the manifest and signature of a snapshot.
+func snapshotSidecars(snapshotPath string) []string {
+	return []string{snapshotPath + manifestExtension, snapshotPath + manifestExtension + signatureExtension}
+}
+
+// removeSnapshotSidecars removes the manifest and signature of a snapshot, if they exist.
+func removeSnapshotSidecars(snapshotPath string) error {
+	for _, path := range snapshotSidecars(snapshotPath) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadSigningKey loads a PEM-encoded PKCS #8 ed25519 private key used to sign snapshot manifests.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.Errorf("no PEM data found in %s", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse private key in %s", path)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.Errorf("private key in %s is not an ed25519 key", path)
+	}
+	return privateKey, nil
+}
+
+// LoadVerificationKey loads a PEM-encoded PKIX ed25519 public key used to verify snapshot manifest signatures.
+func LoadVerificationKey(path string) (ed25519.PublicKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.Errorf("no PEM data found in %s", path)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse public key in %s", path)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("public key in %s is not an ed25519 key", path)
+	}
+	return publicKey, nil
+}
+
+// fileChecksum returns the hex-encoded SHA-256 checksum and size of a file.
+func fileChecksum(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// writeSnapshotManifest writes the manifest of a snapshot, recording the checksum and size of its uncompressed
+// database, and signs the manifest if a signing key is set. It returns the paths of the files written.
+func writeSnapshotManifest(snapshotPath, checksum string, size int64, signingKey ed25519.PrivateKey) ([]string, error) {
+	manifest, err := json.Marshal(snapshotManifest{
+		Name:   filepath.Base(snapshotPath),
+		SHA256: checksum,
+		Size:   size,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sidecars := snapshotSidecars(snapshotPath)
+	if err := os.WriteFile(sidecars[0], manifest, 0600); err != nil {
+		return nil, err
+	}
+	if signingKey == nil {
+		return sidecars[:1], nil
+	}
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(signingKey, manifest))
+	if err := os.WriteFile(sidecars[1], []byte(signature+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return sidecars, nil
+}
+
+// verifySnapshotManifest verifies the database of a snapshot against the checksum in the manifest at
+// manifestPath. If a verification key is set, the manifest must have a valid signature from the key.
+func verifySnapshotManifest(dbPath, manifestPath string, verificationKey ed25519.PublicKey) error {
+	b, err := os.ReadFile(manifestPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.Errorf("snapshot manifest %s not found", filepath.Base(manifestPath))
+		}
+		return err
+	}
+
+	if verificationKey != nil {
+		sig, err := os.ReadFile(manifestPath + signatureExtension)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return errors.Errorf("snapshot manifest signature %s not found", filepath.Base(manifestPath+signatureExtension))
+			}
+			return err
+		}
+		signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil {
+			return errors.Wrap(err, "failed to decode snapshot manifest signature")
+		}
+		if !ed25519.Verify(verificationKey, b, signature) {
+			return errors.New("snapshot manifest signature is not valid")
+		}
+	}
+
+	manifest := snapshotManifest{}
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return errors.Wrap(err, "failed to parse snapshot manifest")
+	}
+	checksum, size, err := fileChecksum(dbPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to compute snapshot checksum")
+	}
+	if size != manifest.Size || checksum != manifest.SHA256 {
+		return errors.Errorf("snapshot checksum sha256:%s (%d bytes) does not match manifest checksum sha256:%s (%d bytes)", checksum, size, manifest.SHA256, manifest.Size)
+	}
+	return nil
+}
+
+// VerifySnapshot verifies a local snapshot against the manifest written alongside it. Compressed snapshots
+// are decompressed to a temporary directory first.
+func VerifySnapshot(snapshotPath string, verificationKey ed25519.PublicKey) error {
+	dbPath := snapshotPath
+	if strings.HasSuffix(snapshotPath, compressedExtension) {
+		tmpDir, err := os.MkdirTemp("", "etcd-snapshot-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmpDir)
+		if dbPath, err = unzipSnapshot(snapshotPath, tmpDir); err != nil {
+			return errors.Wrap(err, "failed to decompress snapshot")
+		}
+	}
+	if err := verifySnapshotManifest(dbPath, snapshotPath+manifestExtension, verificationKey); err != nil {
+		return err
+	}
+	logrus.Infof("Verified etcd snapshot %s", snapshotPath)
+	return nil
+}
+
+// VerifySnapshots verifies the given snapshots against their manifests. If S3 is enabled, the snapshots
+// are downloaded from the configured bucket and folder to a temporary directory; otherwise, snapshots
+// are read from the snapshot directory, unless an absolute path is given.
+func (e *ETCD) VerifySnapshots(ctx context.Context, snapshots []string) error {
+	var verificationKey ed25519.PublicKey
+	if e.config.EtcdSnapshotVerificationKey != "" {
+		var err error
+		if verificationKey, err = LoadVerificationKey(e.config.EtcdSnapshotVerificationKey); err != nil {
+			return errors.Wrap(err, "failed to load snapshot verification key")
+		}
+	}
+
+	var failed []string
+	for _, snapshot := range snapshots {
+		if err := e.verifySnapshot(ctx, snapshot, verificationKey); err != nil {
+			logrus.Errorf("Failed to verify etcd snapshot %s: %v", snapshot, err)
+			failed = append(failed, snapshot)
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("failed to verify %d of %d snapshots: %s", len(failed), len(snapshots), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// verifySnapshot verifies a single snapshot, downloading it from S3 first if S3 is enabled.
+func (e *ETCD) verifySnapshot(ctx context.Context, snapshot string, verificationKey ed25519.PublicKey) error {
+	if !e.config.EtcdS3 {
+		snapshotPath := snapshot
+		if !filepath.IsAbs(snapshotPath) {
+			snapshotDir, err := snapshotDir(e.config, false)
+			if err != nil {
+				return errors.Wrap(err, "failed to get the snapshot dir")
+			}
+			snapshotPath = filepath.Join(snapshotDir, snapshot)
+		}
+		return VerifySnapshot(snapshotPath, verificationKey)
+	}
+
+	if err := e.initS3IfNil(ctx); err != nil {
+		return err
+	}
+	key := snapshot
+	if e.config.EtcdS3Folder != "" {
+		key = filepath.Join(e.config.EtcdS3Folder, snapshot)
+	}
+	tmpDir, err := os.MkdirTemp("", "etcd-snapshot-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshotPath := filepath.Join(tmpDir, filepath.Base(key))
+	toCtx, cancel := context.WithTimeout(ctx, e.config.EtcdS3Timeout)
+	defer cancel()
+	if err := e.s3.client.FGetObject(toCtx, e.config.EtcdS3BucketName, key, snapshotPath, minio.GetObjectOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to get snapshot %s from bucket %s", key, e.config.EtcdS3BucketName)
+	}
+	if err := e.s3.downloadSidecars(ctx, e.config.EtcdS3BucketName, key, snapshotPath); err != nil {
+		return err
+	}
+	return VerifySnapshot(snapshotPath, verificationKey)
+}
@@ -0,0 +1,108 @@
+package etcd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_UnitVerifySnapshot(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		signingKey      ed25519.PrivateKey
+		verificationKey ed25519.PublicKey
+		tamper          bool
+		noManifest      bool
+		wantErr         bool
+	}{
+		{
+			name: "Unsigned manifest",
+		},
+		{
+			name:            "Signed manifest",
+			signingKey:      privateKey,
+			verificationKey: publicKey,
+		},
+		{
+			name:       "Signed manifest without verification key",
+			signingKey: privateKey,
+		},
+		{
+			name:    "Modified snapshot",
+			tamper:  true,
+			wantErr: true,
+		},
+		{
+			name:       "Missing manifest",
+			noManifest: true,
+			wantErr:    true,
+		},
+		{
+			name:            "Missing signature",
+			verificationKey: publicKey,
+			wantErr:         true,
+		},
+		{
+			name:            "Signature from another key",
+			signingKey:      privateKey,
+			verificationKey: otherKey,
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshotPath := filepath.Join(t.TempDir(), "on-demand-node-1700000000")
+			if err := os.WriteFile(snapshotPath, []byte("snapshot data"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			checksum, size, err := fileChecksum(snapshotPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.noManifest {
+				if _, err := writeSnapshotManifest(snapshotPath, checksum, size, tt.signingKey); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.tamper {
+				if err := os.WriteFile(snapshotPath, []byte("snapshot dat4"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := VerifySnapshot(snapshotPath, tt.verificationKey); (err != nil) != tt.wantErr {
+				t.Errorf("VerifySnapshot() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_UnitIsSnapshotSidecar(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "etcd-snapshot-node-1700000000", want: false},
+		{name: "etcd-snapshot-node-1700000000.zip", want: false},
+		{name: "etcd-snapshot-node-1700000000.manifest", want: true},
+		{name: "etcd-snapshot-node-1700000000.zip.manifest.sig", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSnapshotSidecar(tt.name); got != tt.want {
+				t.Errorf("isSnapshotSidecar() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
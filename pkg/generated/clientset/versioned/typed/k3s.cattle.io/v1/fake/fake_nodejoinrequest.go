@@ -0,0 +1,129 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1 "github.com/k3s-io/k3s/pkg/apis/k3s.cattle.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeNodeJoinRequests implements NodeJoinRequestInterface
+type FakeNodeJoinRequests struct {
+	Fake *FakeK3sV1
+	ns   string
+}
+
+var nodejoinrequestsResource = v1.SchemeGroupVersion.WithResource("nodejoinrequests")
+
+var nodejoinrequestsKind = v1.SchemeGroupVersion.WithKind("NodeJoinRequest")
+
+// Get takes name of the nodeJoinRequest, and returns the corresponding nodeJoinRequest object, and an error if there is any.
+func (c *FakeNodeJoinRequests) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.NodeJoinRequest, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(nodejoinrequestsResource, c.ns, name), &v1.NodeJoinRequest{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1.NodeJoinRequest), err
+}
+
+// List takes label and field selectors, and returns the list of NodeJoinRequests that match those selectors.
+func (c *FakeNodeJoinRequests) List(ctx context.Context, opts metav1.ListOptions) (result *v1.NodeJoinRequestList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(nodejoinrequestsResource, nodejoinrequestsKind, c.ns, opts), &v1.NodeJoinRequestList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1.NodeJoinRequestList{ListMeta: obj.(*v1.NodeJoinRequestList).ListMeta}
+	for _, item := range obj.(*v1.NodeJoinRequestList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested nodeJoinRequests.
+func (c *FakeNodeJoinRequests) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(nodejoinrequestsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a nodeJoinRequest and creates it.  Returns the server's representation of the nodeJoinRequest, and an error, if there is any.
+func (c *FakeNodeJoinRequests) Create(ctx context.Context, nodeJoinRequest *v1.NodeJoinRequest, opts metav1.CreateOptions) (result *v1.NodeJoinRequest, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(nodejoinrequestsResource, c.ns, nodeJoinRequest), &v1.NodeJoinRequest{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1.NodeJoinRequest), err
+}
+
+// Update takes the representation of a nodeJoinRequest and updates it. Returns the server's representation of the nodeJoinRequest, and an error, if there is any.
+func (c *FakeNodeJoinRequests) Update(ctx context.Context, nodeJoinRequest *v1.NodeJoinRequest, opts metav1.UpdateOptions) (result *v1.NodeJoinRequest, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(nodejoinrequestsResource, c.ns, nodeJoinRequest), &v1.NodeJoinRequest{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1.NodeJoinRequest), err
+}
+
+// Delete takes name of the nodeJoinRequest and deletes it. Returns an error if one occurs.
+func (c *FakeNodeJoinRequests) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteActionWithOptions(nodejoinrequestsResource, c.ns, name, opts), &v1.NodeJoinRequest{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeNodeJoinRequests) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(nodejoinrequestsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1.NodeJoinRequestList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched nodeJoinRequest.
+func (c *FakeNodeJoinRequests) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NodeJoinRequest, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(nodejoinrequestsResource, c.ns, name, pt, data, subresources...), &v1.NodeJoinRequest{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1.NodeJoinRequest), err
+}
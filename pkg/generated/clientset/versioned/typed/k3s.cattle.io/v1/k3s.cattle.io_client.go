@@ -29,6 +29,7 @@ import (
 type K3sV1Interface interface {
 	RESTClient() rest.Interface
 	AddonsGetter
+	NodeJoinRequestsGetter
 }
 
 // K3sV1Client is used to interact with features provided by the k3s.cattle.io group.
@@ -40,6 +41,10 @@ func (c *K3sV1Client) Addons(namespace string) AddonInterface {
 	return newAddons(c, namespace)
 }
 
+func (c *K3sV1Client) NodeJoinRequests(namespace string) NodeJoinRequestInterface {
+	return newNodeJoinRequests(c, namespace)
+}
+
 // NewForConfig creates a new K3sV1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
@@ -0,0 +1,178 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/k3s-io/k3s/pkg/apis/k3s.cattle.io/v1"
+	scheme "github.com/k3s-io/k3s/pkg/generated/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// NodeJoinRequestsGetter has a method to return a NodeJoinRequestInterface.
+// A group's client should implement this interface.
+type NodeJoinRequestsGetter interface {
+	NodeJoinRequests(namespace string) NodeJoinRequestInterface
+}
+
+// NodeJoinRequestInterface has methods to work with NodeJoinRequest resources.
+type NodeJoinRequestInterface interface {
+	Create(ctx context.Context, nodeJoinRequest *v1.NodeJoinRequest, opts metav1.CreateOptions) (*v1.NodeJoinRequest, error)
+	Update(ctx context.Context, nodeJoinRequest *v1.NodeJoinRequest, opts metav1.UpdateOptions) (*v1.NodeJoinRequest, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.NodeJoinRequest, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.NodeJoinRequestList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NodeJoinRequest, err error)
+	NodeJoinRequestExpansion
+}
+
+// nodeJoinRequests implements NodeJoinRequestInterface
+type nodeJoinRequests struct {
+	client rest.Interface
+	ns     string
+}
+
+// newNodeJoinRequests returns a NodeJoinRequests
+func newNodeJoinRequests(c *K3sV1Client, namespace string) *nodeJoinRequests {
+	return &nodeJoinRequests{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the nodeJoinRequest, and returns the corresponding nodeJoinRequest object, and an error if there is any.
+func (c *nodeJoinRequests) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.NodeJoinRequest, err error) {
+	result = &v1.NodeJoinRequest{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("nodejoinrequests").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of NodeJoinRequests that match those selectors.
+func (c *nodeJoinRequests) List(ctx context.Context, opts metav1.ListOptions) (result *v1.NodeJoinRequestList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.NodeJoinRequestList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("nodejoinrequests").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested nodeJoinRequests.
+func (c *nodeJoinRequests) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("nodejoinrequests").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a nodeJoinRequest and creates it.  Returns the server's representation of the nodeJoinRequest, and an error, if there is any.
+func (c *nodeJoinRequests) Create(ctx context.Context, nodeJoinRequest *v1.NodeJoinRequest, opts metav1.CreateOptions) (result *v1.NodeJoinRequest, err error) {
+	result = &v1.NodeJoinRequest{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("nodejoinrequests").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeJoinRequest).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a nodeJoinRequest and updates it. Returns the server's representation of the nodeJoinRequest, and an error, if there is any.
+func (c *nodeJoinRequests) Update(ctx context.Context, nodeJoinRequest *v1.NodeJoinRequest, opts metav1.UpdateOptions) (result *v1.NodeJoinRequest, err error) {
+	result = &v1.NodeJoinRequest{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("nodejoinrequests").
+		Name(nodeJoinRequest.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(nodeJoinRequest).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the nodeJoinRequest and deletes it. Returns an error if one occurs.
+func (c *nodeJoinRequests) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("nodejoinrequests").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *nodeJoinRequests) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("nodejoinrequests").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched nodeJoinRequest.
+func (c *nodeJoinRequests) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.NodeJoinRequest, err error) {
+	result = &v1.NodeJoinRequest{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("nodejoinrequests").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
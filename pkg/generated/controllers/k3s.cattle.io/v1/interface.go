@@ -32,6 +32,7 @@ func init() {
 
 type Interface interface {
 	Addon() AddonController
+	NodeJoinRequest() NodeJoinRequestController
 }
 
 func New(controllerFactory controller.SharedControllerFactory) Interface {
@@ -49,3 +50,9 @@ func (v *version) Addon() AddonController {
 		generic.NewController[*v1.Addon, *v1.AddonList](schema.GroupVersionKind{Group: "k3s.cattle.io", Version: "v1", Kind: "Addon"}, "addons", true, v.controllerFactory),
 	}
 }
+
+func (v *version) NodeJoinRequest() NodeJoinRequestController {
+	return &NodeJoinRequestGenericController{
+		generic.NewController[*v1.NodeJoinRequest, *v1.NodeJoinRequestList](schema.GroupVersionKind{Group: "k3s.cattle.io", Version: "v1", Kind: "NodeJoinRequest"}, "nodejoinrequests", true, v.controllerFactory),
+	}
+}
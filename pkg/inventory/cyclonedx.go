@@ -0,0 +1,92 @@
+package inventory
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+)
+
+// The subset of the CycloneDX 1.4 JSON format needed to describe the inventory.
+// See https://cyclonedx.org/docs/1.4/json/
+type cdxBOM struct {
+	BOMFormat   string         `json:"bomFormat"`
+	SpecVersion string         `json:"specVersion"`
+	Version     int            `json:"version"`
+	Metadata    cdxMetadata    `json:"metadata"`
+	Components  []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string       `json:"timestamp"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxComponent struct {
+	Type     string       `json:"type"`
+	Name     string       `json:"name"`
+	Version  string       `json:"version,omitempty"`
+	PURL     string       `json:"purl,omitempty"`
+	Hashes   []cdxHash    `json:"hashes,omitempty"`
+	Licenses []cdxLicense `json:"licenses,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxLicense struct {
+	License struct {
+		ID string `json:"id"`
+	} `json:"license"`
+}
+
+// CycloneDX returns the components as a CycloneDX JSON software bill of materials, for import into
+// vulnerability tracking tools. Go modules and images are identified by package URL.
+func CycloneDX(components []Component) ([]byte, error) {
+	bom := cdxBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.4",
+		Version:     1,
+		Metadata: cdxMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Component: cdxComponent{Type: "application", Name: version.Program, Version: version.Version},
+		},
+		Components: []cdxComponent{},
+	}
+	for _, component := range components {
+		if component.Name == version.Program && component.Type == TypeBinary {
+			continue
+		}
+		bom.Components = append(bom.Components, cdxFromComponent(component))
+	}
+	return json.MarshalIndent(bom, "", "  ")
+}
+
+func cdxFromComponent(component Component) cdxComponent {
+	c := cdxComponent{Name: component.Name, Version: component.Version}
+	switch component.Type {
+	case TypeModule:
+		c.Type = "library"
+		c.PURL = "pkg:golang/" + component.Source + "@" + component.Version
+	case TypeImage:
+		c.Type = "container"
+		c.PURL = "pkg:docker/" + strings.TrimPrefix(component.Source, "docker.io/")
+		if component.Version != "" {
+			c.PURL += "@" + component.Version
+		}
+		if alg, hash, ok := strings.Cut(component.Digest, ":"); ok && alg == "sha256" {
+			c.Hashes = []cdxHash{{Alg: "SHA-256", Content: hash}}
+		}
+	default:
+		c.Type = "application"
+	}
+	for _, id := range component.Licenses {
+		license := cdxLicense{}
+		license.License.ID = id
+		c.Licenses = append(c.Licenses, license)
+	}
+	return c
+}
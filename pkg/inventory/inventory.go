@@ -0,0 +1,201 @@
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/containerd/containerd/reference/docker"
+	"github.com/k3s-io/helm-controller/pkg/controllers/chart"
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/k3s-io/k3s/pkg/cloudprovider"
+	"github.com/k3s-io/k3s/pkg/deploy"
+	"github.com/k3s-io/k3s/pkg/version"
+)
+
+const (
+	TypeBinary = "binary"
+	TypeModule = "go-module"
+	TypeImage  = "image"
+)
+
+// Component describes a component that is embedded in the binary, or an image that is deployed by
+// the packaged manifests or used by the embedded controllers.
+type Component struct {
+	Name     string   `json:"name" yaml:"name"`
+	Type     string   `json:"type" yaml:"type"`
+	Version  string   `json:"version" yaml:"version"`
+	Source   string   `json:"source" yaml:"source"`
+	Digest   string   `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Licenses []string `json:"licenses,omitempty" yaml:"licenses,omitempty"`
+}
+
+// embeddedModules are the Go modules that provide the embedded components, keyed by module path.
+var embeddedModules = map[string]Component{
+	"k8s.io/kubernetes":                          {Name: "kubernetes", Licenses: []string{"Apache-2.0"}},
+	"github.com/containerd/containerd":           {Name: "containerd", Licenses: []string{"Apache-2.0"}},
+	"github.com/opencontainers/runc":             {Name: "runc", Licenses: []string{"Apache-2.0"}},
+	"go.etcd.io/etcd/server/v3":                  {Name: "etcd", Licenses: []string{"Apache-2.0"}},
+	"github.com/k3s-io/kine":                     {Name: "kine", Licenses: []string{"Apache-2.0"}},
+	"github.com/flannel-io/flannel":              {Name: "flannel", Licenses: []string{"Apache-2.0"}},
+	"github.com/cloudnativelabs/kube-router/v2":  {Name: "kube-router", Licenses: []string{"Apache-2.0"}},
+	"github.com/Mirantis/cri-dockerd":            {Name: "cri-dockerd", Licenses: []string{"Apache-2.0"}},
+	"github.com/kubernetes-sigs/cri-tools":       {Name: "crictl", Licenses: []string{"Apache-2.0"}},
+	"github.com/k3s-io/helm-controller":          {Name: "helm-controller", Licenses: []string{"Apache-2.0"}},
+	"github.com/rootless-containers/rootlesskit": {Name: "rootlesskit", Licenses: []string{"Apache-2.0"}},
+}
+
+// imageLicenses are the licenses of the packaged images, keyed by the image name with any registry,
+// namespace and mirror prefix removed.
+var imageLicenses = map[string][]string{
+	"coredns":                {"Apache-2.0"},
+	"klipper-helm":           {"Apache-2.0"},
+	"klipper-lb":             {"Apache-2.0"},
+	"local-path-provisioner": {"Apache-2.0"},
+	"busybox":                {"GPL-2.0-only"},
+	"traefik":                {"MIT"},
+	"metrics-server":         {"Apache-2.0"},
+	"node-problem-detector":  {"Apache-2.0"},
+	"k8s-dns-node-cache":     {"Apache-2.0"},
+	"pause":                  {"Apache-2.0"},
+}
+
+var (
+	imageRegexp      = regexp.MustCompile(`^\s*-?\s*image:\s*["']?([^\s"'{}]+)["']?\s*$`)
+	repositoryRegexp = regexp.MustCompile(`^\s*repository:\s*["']?([^\s"']+)["']?\s*$`)
+	tagRegexp        = regexp.MustCompile(`^\s*tag:\s*["']?([^\s"']+)["']?\s*$`)
+)
+
+// Components returns the inventory of embedded components and packaged images: the binary
+// itself, followed by the embedded Go modules and the images, each sorted by name.
+func Components() []Component {
+	components := []Component{
+		{Name: version.Program, Type: TypeBinary, Version: version.Version, Source: "github.com/k3s-io/k3s", Digest: version.GitCommit, Licenses: []string{"Apache-2.0"}},
+		{Name: "go", Type: TypeBinary, Version: runtime.Version(), Source: "golang.org", Licenses: []string{"BSD-3-Clause"}},
+	}
+	components = append(components, modules()...)
+	components = append(components, Images()...)
+	return components
+}
+
+// modules returns the embedded components, using the module versions and checksums recorded in the
+// binary's build info. If a module is replaced, the replacement module is reported as the source.
+func modules() []Component {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	components := []Component{}
+	for _, dep := range info.Deps {
+		component, ok := embeddedModules[dep.Path]
+		if !ok {
+			continue
+		}
+		module := dep
+		if dep.Replace != nil {
+			module = dep.Replace
+		}
+		component.Type = TypeModule
+		component.Source = module.Path
+		component.Version = module.Version
+		component.Digest = module.Sum
+		components = append(components, component)
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i].Name < components[j].Name })
+	return components
+}
+
+// Images returns the images referenced by the packaged manifests, and the default images used by the
+// embedded controllers and kubelet.
+func Images() []Component {
+	refs := map[string]bool{
+		chart.DefaultJobImage:        true,
+		cloudprovider.DefaultLBImage: true,
+		cmds.DefaultPauseImage:       true,
+	}
+	for _, name := range deploy.AssetNames() {
+		b, err := deploy.Asset(name)
+		if err != nil {
+			continue
+		}
+		for _, ref := range manifestImages(b) {
+			refs[ref] = true
+		}
+	}
+
+	components := []Component{}
+	seen := map[string]bool{}
+	for ref := range refs {
+		component, ok := imageComponent(ref)
+		key := component.Source + ":" + component.Version + "@" + component.Digest
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		components = append(components, component)
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Source == components[j].Source {
+			return components[i].Version < components[j].Version
+		}
+		return components[i].Source < components[j].Source
+	})
+	return components
+}
+
+// manifestImages returns the image references in a manifest, from pod specs and from Helm chart values
+// that set the image repository and tag separately. The system default registry placeholder is removed,
+// so that the images are reported by their canonical name.
+func manifestImages(b []byte) []string {
+	b = bytes.ReplaceAll(b, []byte("%{SYSTEM_DEFAULT_REGISTRY}%"), nil)
+	images := []string{}
+	repository := ""
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := imageRegexp.FindStringSubmatch(line); m != nil {
+			images = append(images, m[1])
+		} else if m := repositoryRegexp.FindStringSubmatch(line); m != nil {
+			repository = m[1]
+		} else if m := tagRegexp.FindStringSubmatch(line); m != nil && repository != "" {
+			images = append(images, repository+":"+m[1])
+			repository = ""
+		}
+	}
+	return images
+}
+
+// imageComponent returns the component for an image reference. References that cannot be parsed,
+// or that contain unexpanded template variables, are skipped.
+func imageComponent(ref string) (Component, bool) {
+	if strings.Contains(ref, "%{") {
+		return Component{}, false
+	}
+	named, err := docker.ParseNormalizedNamed(ref)
+	if err != nil {
+		return Component{}, false
+	}
+	named = docker.TagNameOnly(named)
+	component := Component{Type: TypeImage, Source: named.Name()}
+	if tagged, ok := named.(docker.Tagged); ok {
+		component.Version = tagged.Tag()
+	}
+	if digested, ok := named.(docker.Digested); ok {
+		component.Digest = digested.Digest().String()
+	}
+
+	path := docker.Path(named)
+	component.Name = path[strings.LastIndex(path, "/")+1:]
+	component.Name = strings.TrimPrefix(component.Name, "mirrored-")
+	component.Name = strings.TrimPrefix(component.Name, "library-")
+	// images mirrored from an organization of the same name, such as coredns/coredns, are named after the project
+	if org, name, ok := strings.Cut(component.Name, "-"); ok && org == name {
+		component.Name = name
+	}
+	component.Licenses = imageLicenses[component.Name]
+	return component, true
+}
@@ -0,0 +1,92 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_UnitManifestImages(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     []string
+	}{
+		{
+			name: "pod spec images",
+			manifest: `
+      containers:
+      - name: coredns
+        image: %{SYSTEM_DEFAULT_REGISTRY}%rancher/mirrored-coredns-coredns:1.10.1
+      - image: "rancher/mirrored-library-busybox:1.34.1"
+        name: helper
+`,
+			want: []string{"rancher/mirrored-coredns-coredns:1.10.1", "rancher/mirrored-library-busybox:1.34.1"},
+		},
+		{
+			name: "chart values repository and tag",
+			manifest: `
+    image:
+      repository: "rancher/mirrored-library-traefik"
+      tag: "2.9.10"
+`,
+			want: []string{"rancher/mirrored-library-traefik:2.9.10"},
+		},
+		{
+			name: "tag without repository",
+			manifest: `
+    tag: "2.9.10"
+`,
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := manifestImages([]byte(tt.manifest)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("manifestImages() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitImageComponent(t *testing.T) {
+	tests := []struct {
+		name   string
+		ref    string
+		want   Component
+		wantOK bool
+	}{
+		{
+			name:   "mirrored image",
+			ref:    "rancher/mirrored-coredns-coredns:1.10.1",
+			want:   Component{Name: "coredns", Type: TypeImage, Version: "1.10.1", Source: "docker.io/rancher/mirrored-coredns-coredns", Licenses: []string{"Apache-2.0"}},
+			wantOK: true,
+		},
+		{
+			name:   "mirrored library image",
+			ref:    "rancher/mirrored-library-traefik:2.9.10",
+			want:   Component{Name: "traefik", Type: TypeImage, Version: "2.9.10", Source: "docker.io/rancher/mirrored-library-traefik", Licenses: []string{"MIT"}},
+			wantOK: true,
+		},
+		{
+			name:   "image with digest",
+			ref:    "quay.io/tigera/operator:v1.30.4@sha256:1d8da1b5e797d02b40d5e2cb2ab09e5bd27ff3fc2bb6a34c8a34b5ce3087c70b",
+			want:   Component{Name: "operator", Type: TypeImage, Version: "v1.30.4", Source: "quay.io/tigera/operator", Digest: "sha256:1d8da1b5e797d02b40d5e2cb2ab09e5bd27ff3fc2bb6a34c8a34b5ce3087c70b"},
+			wantOK: true,
+		},
+		{
+			name: "template variable",
+			ref:  "%{IMAGE}%",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := imageComponent(tt.ref)
+			if ok != tt.wantOK {
+				t.Fatalf("imageComponent() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("imageComponent() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
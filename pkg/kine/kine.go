@@ -0,0 +1,158 @@
+// Package kine runs kine in a supervised child process, so that the cost of translating etcd requests to SQL
+// queries is accounted for, and can be limited, separately from the apiserver.
+package kine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/k3s-io/kine/pkg/drivers/generic"
+	"github.com/k3s-io/kine/pkg/endpoint"
+	"github.com/k3s-io/kine/pkg/metrics"
+	"github.com/k3s-io/kine/pkg/tls"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// Command is the name under which the kine child process is registered for reexec.
+	Command = "kine"
+
+	restartDelay  = 5 * time.Second
+	socketTimeout = 2 * time.Minute
+)
+
+// configEnv is the environment variable used to pass the configuration to the child process.
+var configEnv = "_" + version.ProgramUpper + "_KINE_CONFIG"
+
+// Limits are the resource limits applied to the kine child process. Zero values are not limited.
+type Limits struct {
+	// MilliCPU is the CPU limit, in thousandths of a core.
+	MilliCPU int64
+	// MemoryBytes is the memory limit, in bytes.
+	MemoryBytes int64
+}
+
+// childConfig is the subset of the kine endpoint configuration passed to the child process. The datastore
+// endpoint may contain credentials, so it is passed in the environment rather than on the command line.
+type childConfig struct {
+	Listener             string
+	Endpoint             string
+	ConnectionPoolConfig generic.ConnectionPoolConfig
+	BackendTLSConfig     tls.Config
+	SlowSQLThreshold     time.Duration
+}
+
+// Main is the entrypoint of the kine child process. It serves the datastore on the listener until the process
+// is killed, or until the datastore connection fails.
+func Main() {
+	cfg := childConfig{}
+	if err := json.Unmarshal([]byte(os.Getenv(configEnv)), &cfg); err != nil {
+		logrus.Fatalf("Failed to read kine config: %v", err)
+	}
+	os.Unsetenv(configEnv)
+
+	metrics.SlowSQLThreshold = cfg.SlowSQLThreshold
+	ctx := context.Background()
+	if _, err := endpoint.Listen(ctx, endpoint.Config{
+		Listener:             cfg.Listener,
+		Endpoint:             cfg.Endpoint,
+		ConnectionPoolConfig: cfg.ConnectionPoolConfig,
+		BackendTLSConfig:     cfg.BackendTLSConfig,
+	}); err != nil {
+		logrus.Fatalf("Failed to start kine: %v", err)
+	}
+	<-ctx.Done()
+}
+
+// Start runs kine in a child process listening on a unix socket in the data directory, restarting it if it
+// exits, and returns the etcd configuration used to connect to it. Etcd datastores do not use kine, and their
+// endpoints are returned as-is. Kine metrics are not exported from the child process.
+func Start(ctx context.Context, config endpoint.Config, dataDir string, limits Limits, slowSQLThreshold time.Duration) (endpoint.ETCDConfig, error) {
+	driver, _ := endpoint.ParseStorageEndpoint(config.Endpoint)
+	if driver == endpoint.ETCDBackend {
+		return endpoint.Listen(ctx, config)
+	}
+
+	socketDir := filepath.Join(dataDir, "kine")
+	if err := os.MkdirAll(socketDir, 0700); err != nil {
+		return endpoint.ETCDConfig{}, err
+	}
+	socket := filepath.Join(socketDir, "kine.sock")
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return endpoint.ETCDConfig{}, err
+	}
+
+	b, err := json.Marshal(childConfig{
+		Listener:             "unix://" + socket,
+		Endpoint:             config.Endpoint,
+		ConnectionPoolConfig: config.ConnectionPoolConfig,
+		BackendTLSConfig:     config.BackendTLSConfig,
+		SlowSQLThreshold:     slowSQLThreshold,
+	})
+	if err != nil {
+		return endpoint.ETCDConfig{}, err
+	}
+	env := append(os.Environ(), configEnv+"="+string(b))
+
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := run(ctx, env, limits); err != nil {
+			logrus.Errorf("Kine exited: %v", err)
+		}
+	}, restartDelay)
+
+	if err := waitForSocket(ctx, socket); err != nil {
+		return endpoint.ETCDConfig{}, err
+	}
+	logrus.Infof("Kine available at unix://%s", socket)
+
+	return endpoint.ETCDConfig{
+		Endpoints:   []string{"unix://" + socket},
+		LeaderElect: driver != endpoint.SQLiteBackend,
+	}, nil
+}
+
+// run starts the kine child process, applies the resource limits, and waits for it to exit.
+func run(ctx context.Context, env []string, limits Limits) error {
+	cmd := reexec.Command(Command)
+	cmd.Env = env
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	setDeathSignal(cmd)
+
+	logrus.Info("Starting kine")
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		cmd.Process.Kill()
+	}()
+	if err := applyLimits(cmd.Process.Pid, limits); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return errors.Wrap(err, "failed to apply kine resource limits")
+	}
+	return cmd.Wait()
+}
+
+// waitForSocket waits for the child process to create the socket.
+func waitForSocket(ctx context.Context, socket string) error {
+	ctx, cancel := context.WithTimeout(ctx, socketTimeout)
+	defer cancel()
+	err := wait.PollImmediateUntilWithContext(ctx, time.Second, func(ctx context.Context) (bool, error) {
+		_, err := os.Stat(socket)
+		return err == nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("timed out waiting for kine to listen on %s", socket)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+//go:build linux
+// +build linux
+
+package kine
+
+import (
+	"os/exec"
+	"syscall"
+
+	"github.com/containerd/cgroups"
+	cgroupsv2 "github.com/containerd/cgroups/v2"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// cpuPeriod is the CFS period, in microseconds, that the CPU quota is applied over.
+const cpuPeriod = 100000
+
+// cgroupPath is the path of the cgroup the kine child process is moved into. It is created at the root of the
+// hierarchy, as the cgroup of the server may contain other processes and so cannot have child cgroups with
+// controllers enabled.
+var cgroupPath = "/" + version.Program + "-kine"
+
+func setDeathSignal(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Pdeathsig: syscall.SIGKILL,
+	}
+}
+
+// applyLimits moves the process into the kine cgroup, creating it and applying the limits if necessary.
+func applyLimits(pid int, limits Limits) error {
+	if limits.MilliCPU == 0 && limits.MemoryBytes == 0 {
+		return nil
+	}
+	logrus.Infof("Applying kine resource limits in cgroup %s: cpu=%dm memory=%d", cgroupPath, limits.MilliCPU, limits.MemoryBytes)
+
+	if cgroups.Mode() == cgroups.Unified {
+		resources := &cgroupsv2.Resources{}
+		if limits.MilliCPU > 0 {
+			quota := limits.MilliCPU * cpuPeriod / 1000
+			period := uint64(cpuPeriod)
+			resources.CPU = &cgroupsv2.CPU{Max: cgroupsv2.NewCPUMax(&quota, &period)}
+		}
+		if limits.MemoryBytes > 0 {
+			resources.Memory = &cgroupsv2.Memory{Max: &limits.MemoryBytes}
+		}
+		m, err := cgroupsv2.NewManager("/sys/fs/cgroup", cgroupPath, resources)
+		if err != nil {
+			return err
+		}
+		return m.AddProc(uint64(pid))
+	}
+
+	resources := &specs.LinuxResources{}
+	if limits.MilliCPU > 0 {
+		quota := limits.MilliCPU * cpuPeriod / 1000
+		period := uint64(cpuPeriod)
+		resources.CPU = &specs.LinuxCPU{Quota: &quota, Period: &period}
+	}
+	if limits.MemoryBytes > 0 {
+		resources.Memory = &specs.LinuxMemory{Limit: &limits.MemoryBytes}
+	}
+	cg, err := cgroups.Load(cgroups.V1, cgroups.StaticPath(cgroupPath))
+	if err != nil {
+		cg, err = cgroups.New(cgroups.V1, cgroups.StaticPath(cgroupPath), resources)
+		if err != nil {
+			return err
+		}
+	} else if err := cg.Update(resources); err != nil {
+		return err
+	}
+	return cg.Add(cgroups.Process{Pid: pid})
+}
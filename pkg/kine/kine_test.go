@@ -0,0 +1,29 @@
+package kine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/k3s-io/kine/pkg/endpoint"
+	"github.com/k3s-io/kine/pkg/tls"
+)
+
+func Test_UnitStartETCD(t *testing.T) {
+	config := endpoint.Config{
+		Endpoint:         "https://10.0.0.1:2379,https://10.0.0.2:2379",
+		BackendTLSConfig: tls.Config{CAFile: "/etc/etcd/ca.crt"},
+	}
+	want := endpoint.ETCDConfig{
+		Endpoints:   []string{"https://10.0.0.1:2379", "https://10.0.0.2:2379"},
+		TLSConfig:   config.BackendTLSConfig,
+		LeaderElect: true,
+	}
+	got, err := Start(context.Background(), config, t.TempDir(), Limits{}, 0)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Start() = %+v, want %+v", got, want)
+	}
+}
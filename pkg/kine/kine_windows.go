@@ -0,0 +1,20 @@
+//go:build windows
+// +build windows
+
+package kine
+
+import (
+	"os/exec"
+
+	"github.com/sirupsen/logrus"
+)
+
+func setDeathSignal(cmd *exec.Cmd) {}
+
+// applyLimits warns that resource limits are not supported, as Windows does not have cgroups.
+func applyLimits(pid int, limits Limits) error {
+	if limits.MilliCPU != 0 || limits.MemoryBytes != 0 {
+		logrus.Warn("Kine resource limits are not supported on Windows and will be ignored")
+	}
+	return nil
+}
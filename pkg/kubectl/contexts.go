@@ -0,0 +1,245 @@
+package kubectl
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/k3s-io/k3s/pkg/datadir"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/rancher/wrangler/pkg/resolvehome"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ContextDirEnv may be set to the path of the contexts directory, overriding the default.
+var ContextDirEnv = version.ProgramUpper + "_CONTEXT_DIR"
+
+var contextNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Context describes a context in a kubeconfig file in the contexts directory.
+type Context struct {
+	Name   string
+	Server string
+	File   string
+}
+
+// ContextDir returns the path to the contexts directory. Kubeconfig files in this directory are merged
+// with the local kubeconfig when kubectl is run without KUBECONFIG or --kubeconfig. If dir is not set,
+// the directory is taken from the environment, or defaults to a directory alongside the default
+// kubeconfig for the current user.
+func ContextDir(dir string) (string, error) {
+	if dir == "" {
+		dir = os.Getenv(ContextDirEnv)
+	}
+	if dir == "" {
+		if os.Getuid() == 0 {
+			return datadir.GlobalContextDir, nil
+		}
+		return resolvehome.Resolve(datadir.HomeContextDir)
+	}
+	return filepath.Abs(dir)
+}
+
+// ContextFiles returns the paths of the kubeconfig files in the contexts directory, sorted by name.
+// A directory that does not exist contains no files.
+func ContextFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if ext := filepath.Ext(entry.Name()); ext == ".yaml" || ext == ".yml" {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// AddContext copies a context, along with its cluster and user, from a kubeconfig file to a new file in the
+// contexts directory. K3s kubeconfigs name all three "default", so they are renamed to the name of the new
+// context to allow kubeconfigs from several clusters to be merged. Certificates and keys referenced by path are
+// embedded, so that the source kubeconfig can be removed. It returns the path of the file written.
+func AddContext(dir, name, kubeConfig, contextName, server string) (string, error) {
+	if !contextNameRegexp.MatchString(name) {
+		return "", errors.Errorf("invalid context name %q: must contain only letters, numbers, '.', '_' and '-'", name)
+	}
+	path := filepath.Join(dir, name+".yaml")
+	if _, err := os.Stat(path); err == nil {
+		return "", errors.Errorf("context %s already exists in %s; remove it first to replace it", name, dir)
+	}
+
+	config, err := clientcmd.LoadFromFile(kubeConfig)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to load kubeconfig %s", kubeConfig)
+	}
+	if err := clientcmd.ResolveLocalPaths(config); err != nil {
+		return "", err
+	}
+	if err := clientcmdapi.FlattenConfig(config); err != nil {
+		return "", err
+	}
+
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+	context, ok := config.Contexts[contextName]
+	if !ok {
+		return "", errors.Errorf("context %q not found in %s", contextName, kubeConfig)
+	}
+	cluster, ok := config.Clusters[context.Cluster]
+	if !ok {
+		return "", errors.Errorf("cluster %q not found in %s", context.Cluster, kubeConfig)
+	}
+	if server != "" {
+		cluster.Server = server
+	} else if isLoopback(cluster.Server) {
+		logrus.Warnf("Server address %s for context %s is a loopback address; use --server to set the address of the cluster if it is not on this host", cluster.Server, name)
+	}
+
+	newConfig := clientcmdapi.NewConfig()
+	newConfig.Clusters[name] = cluster
+	newContext := &clientcmdapi.Context{Cluster: name, Namespace: context.Namespace}
+	if authInfo, ok := config.AuthInfos[context.AuthInfo]; ok {
+		newConfig.AuthInfos[name] = authInfo
+		newContext.AuthInfo = name
+	}
+	newConfig.Contexts[name] = newContext
+
+	if err := clientcmd.WriteToFile(*newConfig, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// RemoveContext removes the file for a context from the contexts directory.
+func RemoveContext(dir, name string) error {
+	if !contextNameRegexp.MatchString(name) {
+		return errors.Errorf("invalid context name %q", name)
+	}
+	for _, ext := range []string{".yaml", ".yml"} {
+		err := os.Remove(filepath.Join(dir, name+ext))
+		if err == nil {
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return errors.Errorf("context %s not found in %s", name, dir)
+}
+
+// ListContexts returns the contexts in the kubeconfig files in the contexts directory, sorted by name.
+func ListContexts(dir string) ([]Context, error) {
+	files, err := ContextFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	var contexts []Context
+	for _, file := range files {
+		config, err := clientcmd.LoadFromFile(file)
+		if err != nil {
+			logrus.Warnf("Failed to load kubeconfig %s: %v", file, err)
+			continue
+		}
+		for name, context := range config.Contexts {
+			c := Context{Name: name, File: file}
+			if cluster, ok := config.Clusters[context.Cluster]; ok {
+				c.Server = cluster.Server
+			}
+			contexts = append(contexts, c)
+		}
+	}
+	sort.Slice(contexts, func(i, j int) bool { return contexts[i].Name < contexts[j].Name })
+	return contexts, nil
+}
+
+// withContextFiles returns a KUBECONFIG value that merges the kubeconfig files in the contexts directory
+// after the given kubeconfig, so that its contexts and current context take precedence. If no kubeconfig is
+// given, the default kubectl kubeconfig is used, as it would be if KUBECONFIG were not set.
+func withContextFiles(kubeConfig, contextDir string) string {
+	dir, err := ContextDir(contextDir)
+	if err != nil {
+		logrus.Warnf("Failed to get kubeconfig contexts dir: %v", err)
+		return kubeConfig
+	}
+	files, err := ContextFiles(dir)
+	if err != nil {
+		logrus.Warnf("Failed to read kubeconfig contexts dir: %v", err)
+		return kubeConfig
+	}
+	if len(files) == 0 {
+		return kubeConfig
+	}
+	if kubeConfig == "" {
+		kubeConfig = clientcmd.RecommendedHomeFile
+	}
+	return strings.Join(append([]string{kubeConfig}, files...), string(os.PathListSeparator))
+}
+
+// contextDirArg removes the --context-dir flag from the command line, as it is not a kubectl flag,
+// and returns its value.
+func contextDirArg() string {
+	var dir string
+	args := []string{}
+	for i := 0; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		if arg == "--" {
+			args = append(args, os.Args[i:]...)
+			break
+		}
+		if v, ok := strings.CutPrefix(arg, "--context-dir="); ok {
+			dir = v
+			continue
+		}
+		if arg == "--context-dir" && i+1 < len(os.Args) {
+			dir = os.Args[i+1]
+			i++
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
+	return dir
+}
+
+// kubeConfigServer returns the server address of the current context in a kubeconfig file.
+func kubeConfigServer(kubeConfig string) string {
+	config, err := clientcmd.LoadFromFile(kubeConfig)
+	if err != nil {
+		return ""
+	}
+	if context, ok := config.Contexts[config.CurrentContext]; ok {
+		if cluster, ok := config.Clusters[context.Cluster]; ok {
+			return cluster.Server
+		}
+	}
+	return ""
+}
+
+// isLoopback returns true if the host of a server URL is localhost or a loopback address.
+func isLoopback(server string) bool {
+	u, err := url.Parse(server)
+	if err != nil {
+		return false
+	}
+	if u.Hostname() == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(u.Hostname())
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,349 @@
+package kubectl
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// writeKubeConfig writes a kubeconfig with a default context, as generated by k3s, and a second context
+// that shares its user. The client certificate and key are referenced by path.
+func writeKubeConfig(t *testing.T, dir string) string {
+	for name, content := range map[string]string{
+		"ca.crt":     "ca-data",
+		"client.crt": "client-cert-data",
+		"client.key": "client-key-data",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := clientcmdapi.NewConfig()
+	config.Clusters["default"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443", CertificateAuthority: "ca.crt"}
+	config.Clusters["remote"] = &clientcmdapi.Cluster{Server: "https://10.0.0.1:6443", CertificateAuthority: "ca.crt"}
+	config.AuthInfos["default"] = &clientcmdapi.AuthInfo{ClientCertificate: "client.crt", ClientKey: "client.key"}
+	config.Contexts["default"] = &clientcmdapi.Context{Cluster: "default", AuthInfo: "default"}
+	config.Contexts["remote"] = &clientcmdapi.Context{Cluster: "remote", AuthInfo: "default", Namespace: "apps"}
+	config.Contexts["no-user"] = &clientcmdapi.Context{Cluster: "remote"}
+	config.Contexts["no-cluster"] = &clientcmdapi.Context{Cluster: "missing"}
+	config.CurrentContext = "default"
+	path := filepath.Join(dir, "k3s.yaml")
+	if err := clientcmd.WriteToFile(*config, path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_UnitAddContext(t *testing.T) {
+	tests := []struct {
+		name          string
+		contextName   string
+		newName       string
+		server        string
+		existing      bool
+		wantServer    string
+		wantNamespace string
+		wantAuthInfo  bool
+		wantErr       bool
+	}{
+		{
+			name:         "current context",
+			newName:      "edge1",
+			wantServer:   "https://127.0.0.1:6443",
+			wantAuthInfo: true,
+		},
+		{
+			name:         "server override",
+			newName:      "edge1",
+			server:       "https://edge1.example.com:6443",
+			wantServer:   "https://edge1.example.com:6443",
+			wantAuthInfo: true,
+		},
+		{
+			name:          "named context",
+			contextName:   "remote",
+			newName:       "edge2",
+			wantServer:    "https://10.0.0.1:6443",
+			wantNamespace: "apps",
+			wantAuthInfo:  true,
+		},
+		{
+			name:        "context without user",
+			contextName: "no-user",
+			newName:     "edge3",
+			wantServer:  "https://10.0.0.1:6443",
+		},
+		{
+			name:        "context not found",
+			contextName: "missing",
+			newName:     "edge1",
+			wantErr:     true,
+		},
+		{
+			name:        "cluster not found",
+			contextName: "no-cluster",
+			newName:     "edge1",
+			wantErr:     true,
+		},
+		{
+			name:    "invalid name",
+			newName: "../edge1",
+			wantErr: true,
+		},
+		{
+			name:     "already exists",
+			newName:  "edge1",
+			existing: true,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceDir := t.TempDir()
+			kubeConfig := writeKubeConfig(t, sourceDir)
+			dir := t.TempDir()
+			if tt.existing {
+				if err := os.WriteFile(filepath.Join(dir, tt.newName+".yaml"), nil, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			path, err := AddContext(dir, tt.newName, kubeConfig, tt.contextName, tt.server)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := filepath.Join(dir, tt.newName+".yaml"); path != want {
+				t.Errorf("AddContext() path = %s, want %s", path, want)
+			}
+
+			// The source kubeconfig and the files it references are removed, to check that they are embedded.
+			os.RemoveAll(sourceDir)
+			config, err := clientcmd.LoadFromFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(config.Contexts) != 1 || len(config.Clusters) != 1 {
+				t.Fatalf("AddContext() wrote %d contexts and %d clusters, want 1", len(config.Contexts), len(config.Clusters))
+			}
+			context, ok := config.Contexts[tt.newName]
+			if !ok {
+				t.Fatalf("AddContext() did not write context %s", tt.newName)
+			}
+			if context.Cluster != tt.newName {
+				t.Errorf("AddContext() context cluster = %s, want %s", context.Cluster, tt.newName)
+			}
+			if context.Namespace != tt.wantNamespace {
+				t.Errorf("AddContext() context namespace = %s, want %s", context.Namespace, tt.wantNamespace)
+			}
+			cluster := config.Clusters[tt.newName]
+			if cluster == nil {
+				t.Fatalf("AddContext() did not write cluster %s", tt.newName)
+			}
+			if cluster.Server != tt.wantServer {
+				t.Errorf("AddContext() server = %s, want %s", cluster.Server, tt.wantServer)
+			}
+			if string(cluster.CertificateAuthorityData) != "ca-data" || cluster.CertificateAuthority != "" {
+				t.Errorf("AddContext() did not embed the certificate authority")
+			}
+
+			authInfo, ok := config.AuthInfos[tt.newName]
+			if ok != tt.wantAuthInfo {
+				t.Fatalf("AddContext() wrote user = %v, want %v", ok, tt.wantAuthInfo)
+			}
+			if !tt.wantAuthInfo {
+				if context.AuthInfo != "" {
+					t.Errorf("AddContext() context user = %s, want none", context.AuthInfo)
+				}
+				return
+			}
+			if context.AuthInfo != tt.newName {
+				t.Errorf("AddContext() context user = %s, want %s", context.AuthInfo, tt.newName)
+			}
+			if string(authInfo.ClientCertificateData) != "client-cert-data" || string(authInfo.ClientKeyData) != "client-key-data" {
+				t.Errorf("AddContext() did not embed the client certificate and key")
+			}
+		})
+	}
+}
+
+func Test_UnitContextDirArg(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantDir  string
+		wantArgs []string
+	}{
+		{
+			name:     "not set",
+			args:     []string{"kubectl", "get", "pods"},
+			wantArgs: []string{"kubectl", "get", "pods"},
+		},
+		{
+			name:     "separate value",
+			args:     []string{"kubectl", "--context-dir", "/tmp/contexts", "get", "pods"},
+			wantDir:  "/tmp/contexts",
+			wantArgs: []string{"kubectl", "get", "pods"},
+		},
+		{
+			name:     "equals value",
+			args:     []string{"kubectl", "get", "pods", "--context-dir=/tmp/contexts"},
+			wantDir:  "/tmp/contexts",
+			wantArgs: []string{"kubectl", "get", "pods"},
+		},
+		{
+			name:     "last value wins",
+			args:     []string{"kubectl", "--context-dir=/tmp/a", "--context-dir", "/tmp/b", "get", "pods"},
+			wantDir:  "/tmp/b",
+			wantArgs: []string{"kubectl", "get", "pods"},
+		},
+		{
+			name:     "missing value",
+			args:     []string{"kubectl", "get", "pods", "--context-dir"},
+			wantArgs: []string{"kubectl", "get", "pods", "--context-dir"},
+		},
+		{
+			name:     "after end of flags",
+			args:     []string{"kubectl", "exec", "pod", "--", "ls", "--context-dir=/tmp/contexts"},
+			wantArgs: []string{"kubectl", "exec", "pod", "--", "ls", "--context-dir=/tmp/contexts"},
+		},
+		{
+			name:     "before end of flags",
+			args:     []string{"kubectl", "--context-dir", "/tmp/contexts", "exec", "pod", "--", "ls", "--context-dir", "/tmp/other"},
+			wantDir:  "/tmp/contexts",
+			wantArgs: []string{"kubectl", "exec", "pod", "--", "ls", "--context-dir", "/tmp/other"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(args []string) { os.Args = args }(os.Args)
+			os.Args = tt.args
+			if got := contextDirArg(); got != tt.wantDir {
+				t.Errorf("contextDirArg() = %s, want %s", got, tt.wantDir)
+			}
+			if !reflect.DeepEqual(os.Args, tt.wantArgs) {
+				t.Errorf("contextDirArg() args = %v, want %v", os.Args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func Test_UnitWithContextFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.yaml", "a.yml", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "c.yaml"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	sep := string(os.PathListSeparator)
+
+	tests := []struct {
+		name       string
+		kubeConfig string
+		contextDir string
+		envDir     string
+		want       string
+	}{
+		{
+			name:       "kubeconfig",
+			kubeConfig: "/etc/rancher/k3s/k3s.yaml",
+			contextDir: dir,
+			want:       strings.Join([]string{"/etc/rancher/k3s/k3s.yaml", filepath.Join(dir, "a.yml"), filepath.Join(dir, "b.yaml")}, sep),
+		},
+		{
+			name:       "default kubeconfig",
+			contextDir: dir,
+			want:       strings.Join([]string{clientcmd.RecommendedHomeFile, filepath.Join(dir, "a.yml"), filepath.Join(dir, "b.yaml")}, sep),
+		},
+		{
+			name:       "context dir from environment",
+			kubeConfig: "/etc/rancher/k3s/k3s.yaml",
+			envDir:     dir,
+			want:       strings.Join([]string{"/etc/rancher/k3s/k3s.yaml", filepath.Join(dir, "a.yml"), filepath.Join(dir, "b.yaml")}, sep),
+		},
+		{
+			name:       "missing context dir",
+			kubeConfig: "/etc/rancher/k3s/k3s.yaml",
+			contextDir: filepath.Join(dir, "missing"),
+			want:       "/etc/rancher/k3s/k3s.yaml",
+		},
+		{
+			name:       "empty context dir",
+			contextDir: filepath.Join(dir, "c.yaml"),
+			want:       "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ContextDirEnv, tt.envDir)
+			if got := withContextFiles(tt.kubeConfig, tt.contextDir); got != tt.want {
+				t.Errorf("withContextFiles() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitAdminSocketConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		server     string
+		host       string
+		wantSocket bool
+	}{
+		{
+			name:       "local server",
+			server:     "https://127.0.0.1:6443",
+			host:       "https://127.0.0.1:6443",
+			wantSocket: true,
+		},
+		{
+			name:   "other server",
+			server: "https://127.0.0.1:6443",
+			host:   "https://10.0.0.1:6443",
+		},
+		{
+			name:       "server not known",
+			host:       "https://10.0.0.1:6443",
+			wantSocket: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &rest.Config{
+				Host:            tt.host,
+				BearerToken:     "token",
+				TLSClientConfig: rest.TLSClientConfig{CAData: []byte("ca-data")},
+			}
+			got := adminSocketConfig("/var/lib/rancher/k3s/server/admin.sock", tt.server)(config)
+			if !tt.wantSocket {
+				if got != config || got.Host != tt.host || got.Dial != nil {
+					t.Errorf("adminSocketConfig() modified config for %s", tt.host)
+				}
+				return
+			}
+			if got.Host != "http://localhost" {
+				t.Errorf("adminSocketConfig() host = %s, want http://localhost", got.Host)
+			}
+			if got.Dial == nil {
+				t.Errorf("adminSocketConfig() did not set dialer")
+			}
+			if got.BearerToken != "" || len(got.TLSClientConfig.CAData) != 0 {
+				t.Errorf("adminSocketConfig() did not clear credentials and TLS config")
+			}
+			if config.Host != tt.host || config.BearerToken != "token" {
+				t.Errorf("adminSocketConfig() modified the original config")
+			}
+		})
+	}
+}
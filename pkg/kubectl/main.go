@@ -1,45 +1,189 @@
 package kubectl
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/k3s-io/k3s/pkg/datadir"
 	"github.com/k3s-io/k3s/pkg/server"
 	"github.com/sirupsen/logrus"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
 	"k8s.io/component-base/cli"
+	"k8s.io/component-base/version"
 	"k8s.io/kubectl/pkg/cmd"
+	"k8s.io/kubectl/pkg/cmd/plugin"
 	"k8s.io/kubectl/pkg/cmd/util"
 )
 
+const (
+	// VersionEnv is set to the version of the embedded kubectl, so that plugins and
+	// wrapper scripts can determine which kubectl they are being run by.
+	VersionEnv = "KUBECTL_EMBEDDED_VERSION"
+	// PluginsPathEnv may contain a list of additional directories to search for kubectl plugins.
+	PluginsPathEnv = "KUBECTL_PLUGINS_PATH"
+)
+
 func Main() {
+	contextDir := contextDirArg()
 	kubenv := os.Getenv("KUBECONFIG")
 	for i, arg := range os.Args {
 		if strings.HasPrefix(arg, "--kubeconfig=") {
-			kubenv = strings.Split(arg, "=")[1]
-		} else if strings.HasPrefix(arg, "--kubeconfig") && i+1 < len(os.Args) {
+			kubenv = strings.SplitN(arg, "=", 2)[1]
+		} else if arg == "--kubeconfig" && i+1 < len(os.Args) {
 			kubenv = os.Args[i+1]
 		}
 	}
+	var wrapConfig func(*rest.Config) *rest.Config
 	if kubenv == "" {
-		config, err := server.HomeKubeConfig(false, false)
-		if _, serr := os.Stat(config); err == nil && serr == nil {
-			os.Setenv("KUBECONFIG", config)
+		var kubeConfig string
+		if socket, adminKubeConfig := localAdminSocket(); socket != "" && !hasServerFlag() {
+			// The admin kubeconfig is only used to satisfy the client config loader;
+			// the server address and credentials are replaced by the socket.
+			kubeConfig = adminKubeConfig
+			wrapConfig = adminSocketConfig(socket, kubeConfigServer(adminKubeConfig))
+		} else {
+			config, err := server.HomeKubeConfig(false, false)
+			if _, serr := os.Stat(config); err == nil && serr == nil {
+				kubeConfig = config
+			}
+			if err := checkReadConfigPermissions(config); err != nil {
+				logrus.Warn(err)
+			}
+		}
+		if kubeConfig = withContextFiles(kubeConfig, contextDir); kubeConfig != "" {
+			os.Setenv("KUBECONFIG", kubeConfig)
+		}
+	}
+
+	os.Setenv(VersionEnv, Version())
+	if err := setPluginPath(); err != nil {
+		logrus.Warnf("Failed to set kubectl plugin path: %v", err)
+	}
+
+	main(wrapConfig)
+}
+
+// localAdminSocket returns the path to the local admin socket and admin kubeconfig of a server using
+// the default data dir, if the socket exists and the current user is allowed to connect to it.
+func localAdminSocket() (string, string) {
+	if os.Getuid() != 0 {
+		return "", ""
+	}
+	serverDir := filepath.Join(datadir.DefaultDataDir, "server")
+	socket := filepath.Join(serverDir, "admin.sock")
+	kubeConfig := filepath.Join(serverDir, "cred", "admin.kubeconfig")
+	if info, err := os.Stat(socket); err != nil || info.Mode()&os.ModeSocket == 0 {
+		return "", ""
+	}
+	if _, err := os.Stat(kubeConfig); err != nil {
+		return "", ""
+	}
+	conn, err := net.DialTimeout("unix", socket, time.Second)
+	if err != nil {
+		return "", ""
+	}
+	conn.Close()
+	return socket, kubeConfig
+}
+
+// hasServerFlag returns true if the apiserver address was set on the command line,
+// in which case the user has asked to connect to a specific server. The shorthand flag
+// may be followed by its value as a separate argument, after an equals sign, or directly.
+func hasServerFlag() bool {
+	for _, arg := range os.Args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--server" || strings.HasPrefix(arg, "--server=") {
+			return true
 		}
-		if err := checkReadConfigPermissions(config); err != nil {
-			logrus.Warn(err)
+		if strings.HasPrefix(arg, "-s") && !strings.HasPrefix(arg, "--") {
+			return true
 		}
 	}
+	return false
+}
+
+// adminSocketConfig returns a function that modifies client configs to connect to the
+// local admin socket. The socket does not use TLS or require credentials. If server is set,
+// only configs for that server are modified, so that contexts for other clusters are not.
+func adminSocketConfig(socket, server string) func(*rest.Config) *rest.Config {
+	return func(c *rest.Config) *rest.Config {
+		if server != "" && c.Host != server {
+			return c
+		}
+		c = rest.AnonymousClientConfig(c)
+		c.Host = "http://localhost"
+		c.TLSClientConfig = rest.TLSClientConfig{}
+		c.Dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+		return c
+	}
+}
+
+// Version returns the version of the embedded kubectl.
+func Version() string {
+	return version.Get().GitVersion
+}
+
+// setPluginPath adds the krew bin directory and any directories listed in KUBECTL_PLUGINS_PATH
+// to PATH, so that plugins installed for a standalone kubectl are also found by the embedded kubectl.
+// Directories that do not exist or are already present in PATH are skipped.
+func setPluginPath() error {
+	paths := filepath.SplitList(os.Getenv("PATH"))
+	dirs := filepath.SplitList(os.Getenv(PluginsPathEnv))
 
-	main()
+	krewRoot := os.Getenv("KREW_ROOT")
+	if krewRoot == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		krewRoot = filepath.Join(home, ".krew")
+	}
+	dirs = append(dirs, filepath.Join(krewRoot, "bin"))
+
+	existing := map[string]bool{}
+	for _, path := range paths {
+		existing[path] = true
+	}
+
+	changed := false
+	for _, dir := range dirs {
+		if dir == "" || existing[dir] {
+			continue
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		paths = append(paths, dir)
+		existing[dir] = true
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return os.Setenv("PATH", strings.Join(paths, string(os.PathListSeparator)))
 }
 
-func main() {
+func main(wrapConfig func(*rest.Config) *rest.Config) {
 	rand.Seed(time.Now().UnixNano())
 
-	command := cmd.NewDefaultKubectlCommand()
+	command := cmd.NewDefaultKubectlCommandWithArgs(cmd.KubectlOptions{
+		PluginHandler: cmd.NewDefaultPluginHandler(plugin.ValidPluginFilenamePrefixes),
+		Arguments:     os.Args,
+		ConfigFlags:   genericclioptions.NewConfigFlags(true).WithDeprecatedPasswordFlag().WithDiscoveryBurst(300).WithDiscoveryQPS(50.0).WithWrapConfigFn(wrapConfig),
+		IOStreams:     genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr},
+	})
 	if err := cli.RunNoErrOutput(command); err != nil {
 		util.CheckErr(err)
 	}
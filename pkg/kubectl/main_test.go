@@ -0,0 +1,61 @@
+package kubectl
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_UnitHasServerFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{
+			name: "no server",
+			args: []string{"kubectl", "get", "pods"},
+		},
+		{
+			name: "long flag",
+			args: []string{"kubectl", "--server", "https://10.0.0.1:6443", "get", "pods"},
+			want: true,
+		},
+		{
+			name: "long flag with equals",
+			args: []string{"kubectl", "get", "pods", "--server=https://10.0.0.1:6443"},
+			want: true,
+		},
+		{
+			name: "shorthand",
+			args: []string{"kubectl", "-s", "https://10.0.0.1:6443", "get", "pods"},
+			want: true,
+		},
+		{
+			name: "shorthand with equals",
+			args: []string{"kubectl", "-s=https://10.0.0.1:6443", "get", "pods"},
+			want: true,
+		},
+		{
+			name: "shorthand with value",
+			args: []string{"kubectl", "-shttps://10.0.0.1:6443", "get", "pods"},
+			want: true,
+		},
+		{
+			name: "other long flag",
+			args: []string{"kubectl", "get", "pods", "--selector=app=web", "--show-labels"},
+		},
+		{
+			name: "after end of flags",
+			args: []string{"kubectl", "exec", "pod", "--", "curl", "-s", "https://10.0.0.1:6443"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(args []string) { os.Args = args }(os.Args)
+			os.Args = tt.args
+			if got := hasServerFlag(); got != tt.want {
+				t.Errorf("hasServerFlag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package logs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/cli/cmds"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultSince = time.Hour
+
+var (
+	// logrusTime matches the timestamp written by logrus text formatters, as used by containerd and k3s.
+	logrusTime = regexp.MustCompile(`^time="([^"]+)"`)
+	// zapTime matches the timestamp written by the zap JSON encoder, as used by etcd.
+	zapTime = regexp.MustCompile(`^\{"level":"[a-z]+","ts":"([^"]+)"`)
+	// klogHeader matches the header written by klog, as used by the kubelet and other Kubernetes components.
+	klogHeader = regexp.MustCompile(`^[IWEF](\d{4} \d{2}:\d{2}:\d{2}\.\d{6})\s+\d+ ([^:\]]+):\d+\] `)
+	// kubeletSource matches the names of source files in the kubelet packages. The kubelet shares the process
+	// output with the other embedded Kubernetes components, so its lines are identified by the source file in the
+	// klog header. Names that are also used by other components, such as server.go, are not included.
+	kubeletSource = regexp.MustCompile(`^(kubelet[a-z_]*|kuberuntime_[a-z_]+|pod_workers|pod_container_deletor|volume_manager|` +
+		`reconciler_common|desired_state_of_world_populator|(cpu|memory|topology|eviction|image_gc|prober|status|plugin)_manager|` +
+		`container_manager_linux|(qos|node)_container_manager_linux|cgroup_manager_linux|container_gc|prober|plugin_watcher|` +
+		`remote_(runtime|image)|evented|generic)\.go$`)
+)
+
+// lineFilter returns true if a line of process output was written by a component.
+type lineFilter func(line string) bool
+
+// processComponents are the embedded components that write to the output of the k3s process.
+var processComponents = map[string]lineFilter{
+	"etcd":    zapTime.MatchString,
+	"kubelet": isKubeletLine,
+}
+
+// Handler returns the recent logs of an embedded component, so that node diagnostics can be retrieved without
+// access to the node's journal. The containerd log is read from the given path.
+func Handler(containerdLog string) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		now := time.Now()
+		since, err := ParseSince(req.URL.Query().Get("since"), now)
+		if err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var logs io.ReadCloser
+		var filter lineFilter
+		component := req.URL.Query().Get("component")
+		if component == "containerd" {
+			logs, now, err = openFile(containerdLog, now)
+		} else if f, ok := processComponents[component]; ok {
+			filter = f
+			logs, now, err = processLogs(req.Context(), since, now)
+		} else {
+			http.Error(resp, fmt.Sprintf("unknown component %q; must be one of containerd, etcd, kubelet", component), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Failed to open %s logs: %v", component, err)
+			http.Error(resp, "failed to open logs", http.StatusInternalServerError)
+			return
+		}
+		defer logs.Close()
+
+		resp.Header().Set("content-type", "text/plain")
+		if err := copyLogs(resp, logs, since, now, filter); err != nil {
+			logrus.Errorf("Failed to copy %s logs: %v", component, err)
+		}
+	})
+}
+
+// ParseSince parses the start time for log retrieval, which may either be a duration before the current time,
+// or an RFC3339 timestamp.
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return now.Add(-defaultSince), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, fmt.Errorf("invalid since %q; must be a duration such as 10m, or an RFC3339 timestamp", s)
+	}
+	return t, nil
+}
+
+// openFile opens a log file, and returns its modification time, which no line in the file can be newer than.
+func openFile(path string, now time.Time) (io.ReadCloser, time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, now, err
+	}
+	if info, err := f.Stat(); err == nil {
+		now = info.ModTime()
+	}
+	return f, now, nil
+}
+
+// processLogs returns the output of the k3s process. If a log file is not in use, the output is read from
+// journald, which already filters entries by time.
+func processLogs(ctx context.Context, since, now time.Time) (io.ReadCloser, time.Time, error) {
+	if cmds.LogConfig.LogFile != "" {
+		return openFile(cmds.LogConfig.LogFile, now)
+	}
+	journalctl, err := exec.LookPath("journalctl")
+	if err != nil {
+		return nil, now, errors.Wrap(err, "process output is not available without a log file or journald")
+	}
+	cmd := exec.CommandContext(ctx, journalctl, "--no-pager", "--output=cat", "--since=@"+strconv.FormatInt(since.Unix(), 10), "_PID="+strconv.Itoa(os.Getpid()))
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, now, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, now, err
+	}
+	return &cmdReadCloser{ReadCloser: stdout, cmd: cmd}, now, nil
+}
+
+// copyLogs copies the lines of a log that were written at or after the start time, and that match the filter,
+// if one is set. Lines without a timestamp, such as the continuation of a multi-line message, are copied if the
+// previous line was. The current time is used to infer the year of timestamps that do not include one.
+func copyLogs(w io.Writer, r io.Reader, since, now time.Time, filter lineFilter) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	include := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if t, ok := lineTime(line, now); ok {
+			include = !t.Before(since) && (filter == nil || filter(line))
+		}
+		if include {
+			if _, err := io.WriteString(w, line+"\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// lineTime returns the time at which a line was logged, if it starts with a known timestamp format. The klog
+// header does not include the year or time zone, so local time is assumed, and the year is the most recent one
+// in which the line would not have been written after the given time.
+func lineTime(line string, now time.Time) (time.Time, bool) {
+	if m := logrusTime.FindStringSubmatch(line); m != nil {
+		t, err := time.Parse(time.RFC3339Nano, m[1])
+		return t, err == nil
+	}
+	if m := zapTime.FindStringSubmatch(line); m != nil {
+		t, err := time.Parse("2006-01-02T15:04:05.999999999Z0700", m[1])
+		return t, err == nil
+	}
+	if m := klogHeader.FindStringSubmatch(line); m != nil {
+		t, err := time.ParseInLocation("0102 15:04:05.000000", m[1], now.Location())
+		if err != nil {
+			return t, false
+		}
+		t = t.AddDate(now.Year(), 0, 0)
+		// Allow for a day of clock or time zone skew before assuming that the line is from the previous year.
+		if t.After(now.Add(24 * time.Hour)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// isKubeletLine returns true if a line was logged by klog from a kubelet source file.
+func isKubeletLine(line string) bool {
+	m := klogHeader.FindStringSubmatch(line)
+	return m != nil && kubeletSource.MatchString(m[2])
+}
+
+// cmdReadCloser waits for a command to exit when its output is closed.
+type cmdReadCloser struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (c *cmdReadCloser) Close() error {
+	c.ReadCloser.Close()
+	return c.cmd.Wait()
+}
@@ -0,0 +1,108 @@
+package logs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_UnitLineTime(t *testing.T) {
+	now := time.Date(2026, time.January, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		line   string
+		want   time.Time
+		wantOK bool
+	}{
+		{
+			name:   "logrus",
+			line:   `time="2026-01-02T09:30:00Z" level=info msg="Starting containerd"`,
+			want:   time.Date(2026, time.January, 2, 9, 30, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "zap",
+			line:   `{"level":"info","ts":"2026-01-02T09:30:00.000000Z","caller":"etcdserver/server.go:1","msg":"ready"}`,
+			want:   time.Date(2026, time.January, 2, 9, 30, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "klog this year",
+			line:   "I0102 09:30:00.000000    1234 kubelet.go:100] Starting kubelet",
+			want:   time.Date(2026, time.January, 2, 9, 30, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name:   "klog previous year",
+			line:   "I1231 23:30:00.000000    1234 kubelet.go:100] Starting kubelet",
+			want:   time.Date(2025, time.December, 31, 23, 30, 0, 0, time.UTC),
+			wantOK: true,
+		},
+		{
+			name: "continuation",
+			line: "  goroutine 1 [running]:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lineTime(tt.line, now)
+			if ok != tt.wantOK {
+				t.Fatalf("lineTime() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("lineTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitCopyLogs(t *testing.T) {
+	now := time.Date(2026, time.January, 2, 10, 0, 0, 0, time.Local)
+	since := now.Add(-time.Hour)
+	logs := strings.Join([]string{
+		"I0102 08:00:00.000000    1234 kubelet.go:100] old kubelet line",
+		"I0102 09:30:00.000000    1234 kubelet_node_status.go:70] Successfully registered node",
+		"E0102 09:31:00.000000    1234 kuberuntime_manager.go:100] Failed to sync pod",
+		"\tcontinuation of kubelet error",
+		"I0102 09:32:00.000000    1234 controller.go:200] apiserver controller line",
+		"\tcontinuation of apiserver line",
+		"I0102 09:33:00.000000    1234 server.go:10] shared source file name",
+		`{"level":"info","ts":"2026-01-02T09:34:00.000000Z","msg":"etcd line"}`,
+		"I0102 09:35:00.000000    1234 pod_workers.go:900] Pod worker stopped",
+	}, "\n")
+	tests := []struct {
+		name   string
+		filter lineFilter
+		want   []string
+	}{
+		{
+			name:   "kubelet",
+			filter: processComponents["kubelet"],
+			want: []string{
+				"I0102 09:30:00.000000    1234 kubelet_node_status.go:70] Successfully registered node",
+				"E0102 09:31:00.000000    1234 kuberuntime_manager.go:100] Failed to sync pod",
+				"\tcontinuation of kubelet error",
+				"I0102 09:35:00.000000    1234 pod_workers.go:900] Pod worker stopped",
+			},
+		},
+		{
+			name:   "etcd",
+			filter: processComponents["etcd"],
+			want: []string{
+				`{"level":"info","ts":"2026-01-02T09:34:00.000000Z","msg":"etcd line"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := copyLogs(out, strings.NewReader(logs), since, now, tt.filter); err != nil {
+				t.Fatalf("copyLogs() error = %v", err)
+			}
+			if got, want := out.String(), strings.Join(tt.want, "\n")+"\n"; got != want {
+				t.Errorf("copyLogs() output:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package node
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	k3scontrollers "github.com/k3s-io/k3s/pkg/generated/controllers/k3s.cattle.io/v1"
+	"github.com/k3s-io/k3s/pkg/version"
+	coreclient "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const cleanupInterval = time.Hour
+
+// NodeMissingSinceAnnotation is set on node password secrets to the time at which the secret
+// was first observed to not have a corresponding node.
+var NodeMissingSinceAnnotation = version.Program + ".io/node-missing-since"
+
+// RegisterCleanup starts a periodic cleanup of node password secrets that have been left behind by
+// nodes that no longer exist. Node password secrets are normally deleted along with the node, but if the
+// node was removed while no server was able to handle the deletion, the secret remains, and the node
+// cannot rejoin the cluster with a new password after being re-imaged. Secrets are deleted once their
+// node has been missing for longer than the retention period. Secrets of nodes with a join request that
+// has not been denied are kept, as the node has not registered yet.
+// This should only be run on the elected leader.
+func RegisterCleanup(ctx context.Context,
+	retention time.Duration,
+	secretClient coreclient.SecretClient,
+	nodeClient coreclient.NodeClient,
+	joinRequestClient k3scontrollers.NodeJoinRequestClient,
+) error {
+	c := &cleanupHandler{
+		retention:         retention,
+		secretClient:      secretClient,
+		nodeClient:        nodeClient,
+		joinRequestClient: joinRequestClient,
+	}
+	go wait.UntilWithContext(ctx, c.cleanup, cleanupInterval)
+
+	return nil
+}
+
+type cleanupHandler struct {
+	retention         time.Duration
+	secretClient      coreclient.SecretClient
+	nodeClient        coreclient.NodeClient
+	joinRequestClient k3scontrollers.NodeJoinRequestClient
+}
+
+func (c *cleanupHandler) cleanup(ctx context.Context) {
+	nodeList, err := c.nodeClient.List(metav1.ListOptions{})
+	if err != nil {
+		logrus.Warnf("Failed to list nodes for node password cleanup: %v", err)
+		return
+	}
+	nodes := map[string]bool{}
+	for _, node := range nodeList.Items {
+		nodes[strings.ToLower(node.Name)] = true
+	}
+
+	// Nodes that are waiting for their join request to be approved, or that have been approved but not yet
+	// registered, are treated as existing nodes.
+	joinRequestList, err := c.joinRequestClient.List(metav1.NamespaceSystem, metav1.ListOptions{})
+	if err != nil {
+		logrus.Warnf("Failed to list node join requests for node password cleanup: %v", err)
+		return
+	}
+	for _, joinRequest := range joinRequestList.Items {
+		if !joinRequest.Spec.Denied {
+			nodes[strings.ToLower(joinRequest.Name)] = true
+		}
+	}
+
+	secretList, err := c.secretClient.List(metav1.NamespaceSystem, metav1.ListOptions{})
+	if err != nil {
+		logrus.Warnf("Failed to list secrets for node password cleanup: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, secret := range secretList.Items {
+		if !strings.HasSuffix(secret.Name, nodePasswordSuffix) {
+			continue
+		}
+		nodeName := strings.TrimSuffix(secret.Name, nodePasswordSuffix)
+		missingSince, isMissing := secret.Annotations[NodeMissingSinceAnnotation]
+
+		// The node exists; clear the annotation if the node was previously missing.
+		if nodes[nodeName] {
+			if isMissing {
+				secret := secret.DeepCopy()
+				delete(secret.Annotations, NodeMissingSinceAnnotation)
+				if _, err := c.secretClient.Update(secret); err != nil && !apierrors.IsNotFound(err) {
+					logrus.Warnf("Failed to update node password secret for node %s: %v", nodeName, err)
+				}
+			}
+			continue
+		}
+
+		// The node does not exist; record when it was first found to be missing.
+		since, err := time.Parse(time.RFC3339, missingSince)
+		if !isMissing || err != nil {
+			secret := secret.DeepCopy()
+			if secret.Annotations == nil {
+				secret.Annotations = map[string]string{}
+			}
+			secret.Annotations[NodeMissingSinceAnnotation] = now.UTC().Format(time.RFC3339)
+			if _, err := c.secretClient.Update(secret); err != nil && !apierrors.IsNotFound(err) {
+				logrus.Warnf("Failed to update node password secret for node %s: %v", nodeName, err)
+			}
+			continue
+		}
+
+		if now.Sub(since) < c.retention {
+			continue
+		}
+		// Confirm that the node has not been created or requested to join since the lists were retrieved.
+		if _, err := c.nodeClient.Get(nodeName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+			continue
+		}
+		if joinRequest, err := c.joinRequestClient.Get(metav1.NamespaceSystem, nodeName, metav1.GetOptions{}); err == nil {
+			if !joinRequest.Spec.Denied {
+				continue
+			}
+		} else if !apierrors.IsNotFound(err) {
+			continue
+		}
+		preconditions := metav1.Preconditions{ResourceVersion: &secret.ResourceVersion}
+		err = c.secretClient.Delete(metav1.NamespaceSystem, secret.Name, &metav1.DeleteOptions{Preconditions: &preconditions})
+		if err == nil {
+			logrus.Infof("Removed stale node password secret for node %s, missing since %s", nodeName, missingSince)
+		} else if !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+			logrus.Warnf("Failed to remove stale node password secret for node %s: %v", nodeName, err)
+		}
+	}
+}
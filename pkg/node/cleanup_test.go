@@ -0,0 +1,192 @@
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/k3s-io/k3s/pkg/apis/k3s.cattle.io/v1"
+	k3scontrollers "github.com/k3s-io/k3s/pkg/generated/controllers/k3s.cattle.io/v1"
+	coreclient "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func Test_UnitCleanup(t *testing.T) {
+	now := time.Now()
+	stale := now.Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	recent := now.Add(-time.Hour).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name         string
+		missingSince string
+		nodeExists   bool
+		joinRequest  *v1.NodeJoinRequestSpec
+		wantDeleted  bool
+		wantMissing  bool
+	}{
+		{
+			name:       "node exists",
+			nodeExists: true,
+		},
+		{
+			name:         "node exists again",
+			missingSince: stale,
+			nodeExists:   true,
+		},
+		{
+			name:        "node newly missing",
+			wantMissing: true,
+		},
+		{
+			name:         "node missing within retention",
+			missingSince: recent,
+			wantMissing:  true,
+		},
+		{
+			name:         "node missing beyond retention",
+			missingSince: stale,
+			wantDeleted:  true,
+		},
+		{
+			name:         "join request pending",
+			missingSince: stale,
+			joinRequest:  &v1.NodeJoinRequestSpec{NodeName: "node1"},
+		},
+		{
+			name:         "join request approved",
+			missingSince: stale,
+			joinRequest:  &v1.NodeJoinRequestSpec{NodeName: "node1", Approved: true},
+		},
+		{
+			name:         "join request denied",
+			missingSince: stale,
+			joinRequest:  &v1.NodeJoinRequestSpec{NodeName: "node1", Denied: true},
+			wantDeleted:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secretName := "node1" + nodePasswordSuffix
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: metav1.NamespaceSystem}}
+			if tt.missingSince != "" {
+				secret.Annotations = map[string]string{NodeMissingSinceAnnotation: tt.missingSince}
+			}
+			secrets := &mockSecretClient{secrets: map[string]*corev1.Secret{
+				secretName: secret,
+				"other":    {ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: metav1.NamespaceSystem}},
+			}}
+			nodes := &mockNodeClient{nodes: map[string]*corev1.Node{}}
+			if tt.nodeExists {
+				nodes.nodes["node1"] = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}}
+			}
+			joinRequests := &mockNodeJoinRequestClient{requests: map[string]*v1.NodeJoinRequest{}}
+			if tt.joinRequest != nil {
+				joinRequests.requests["node1"] = &v1.NodeJoinRequest{
+					ObjectMeta: metav1.ObjectMeta{Name: "node1", Namespace: metav1.NamespaceSystem},
+					Spec:       *tt.joinRequest,
+				}
+			}
+
+			c := &cleanupHandler{
+				retention:         24 * time.Hour,
+				secretClient:      secrets,
+				nodeClient:        nodes,
+				joinRequestClient: joinRequests,
+			}
+			c.cleanup(context.Background())
+
+			if _, ok := secrets.secrets["other"]; !ok {
+				t.Errorf("cleanup() deleted a secret that is not a node password secret")
+			}
+			secret, ok := secrets.secrets[secretName]
+			if ok == tt.wantDeleted {
+				t.Fatalf("cleanup() deleted node password secret = %v, want %v", !ok, tt.wantDeleted)
+			}
+			if tt.wantDeleted {
+				return
+			}
+			if _, missing := secret.Annotations[NodeMissingSinceAnnotation]; missing != tt.wantMissing {
+				t.Errorf("cleanup() node missing annotation = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+// mock secret client, storing secrets in the kube-system namespace. Methods that are not used by
+// the cleanup handler are not implemented.
+
+type mockSecretClient struct {
+	coreclient.SecretClient
+	secrets map[string]*corev1.Secret
+}
+
+func (m *mockSecretClient) Update(secret *corev1.Secret) (*corev1.Secret, error) {
+	if _, ok := m.secrets[secret.Name]; !ok {
+		return nil, apierrors.NewNotFound(schema.ParseGroupResource("secret"), secret.Name)
+	}
+	m.secrets[secret.Name] = secret.DeepCopy()
+	return secret, nil
+}
+
+func (m *mockSecretClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	if _, ok := m.secrets[name]; !ok {
+		return apierrors.NewNotFound(schema.ParseGroupResource("secret"), name)
+	}
+	delete(m.secrets, name)
+	return nil
+}
+
+func (m *mockSecretClient) List(namespace string, opts metav1.ListOptions) (*corev1.SecretList, error) {
+	list := &corev1.SecretList{}
+	for _, secret := range m.secrets {
+		list.Items = append(list.Items, *secret.DeepCopy())
+	}
+	return list, nil
+}
+
+// mock node client. Methods that are not used by the cleanup handler are not implemented.
+
+type mockNodeClient struct {
+	coreclient.NodeClient
+	nodes map[string]*corev1.Node
+}
+
+func (m *mockNodeClient) Get(name string, options metav1.GetOptions) (*corev1.Node, error) {
+	if node, ok := m.nodes[name]; ok {
+		return node.DeepCopy(), nil
+	}
+	return nil, apierrors.NewNotFound(schema.ParseGroupResource("node"), name)
+}
+
+func (m *mockNodeClient) List(opts metav1.ListOptions) (*corev1.NodeList, error) {
+	list := &corev1.NodeList{}
+	for _, node := range m.nodes {
+		list.Items = append(list.Items, *node.DeepCopy())
+	}
+	return list, nil
+}
+
+// mock node join request client, storing join requests in the kube-system namespace. Methods that are
+// not used by the cleanup handler are not implemented.
+
+type mockNodeJoinRequestClient struct {
+	k3scontrollers.NodeJoinRequestClient
+	requests map[string]*v1.NodeJoinRequest
+}
+
+func (m *mockNodeJoinRequestClient) Get(namespace, name string, options metav1.GetOptions) (*v1.NodeJoinRequest, error) {
+	if joinRequest, ok := m.requests[name]; ok {
+		return joinRequest.DeepCopy(), nil
+	}
+	return nil, apierrors.NewNotFound(schema.ParseGroupResource("nodejoinrequest.k3s.cattle.io"), name)
+}
+
+func (m *mockNodeJoinRequestClient) List(namespace string, opts metav1.ListOptions) (*v1.NodeJoinRequestList, error) {
+	list := &v1.NodeJoinRequestList{}
+	for _, joinRequest := range m.requests {
+		list.Items = append(list.Items, *joinRequest.DeepCopy())
+	}
+	return list, nil
+}
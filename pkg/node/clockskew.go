@@ -0,0 +1,116 @@
+package node
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/util"
+	"github.com/sirupsen/logrus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	EventClockSkew         = "ClockSkew"
+	EventClockSkewResolved = "ClockSkewResolved"
+
+	clockSkewControllerName = "clock-skew-monitor"
+
+	// clockSkewEventInterval is the interval at which events are repeated while a node's clock remains skewed.
+	clockSkewEventInterval = time.Hour
+)
+
+// RegisterClockSkewMonitor starts a controller that watches node leases, and emits events against nodes
+// whose clock differs from this server's clock by more than the threshold. Kubelets set the renew time on
+// their node lease from their own clock every few seconds, so the difference between the renew time and the
+// time at which the update is observed is a close estimate of the node's clock skew.
+// This should only be run on the elected leader, to avoid sending duplicate events.
+func RegisterClockSkewMonitor(ctx context.Context, threshold time.Duration, k8s kubernetes.Interface) error {
+	m := &clockSkewMonitor{
+		threshold: threshold,
+		recorder:  util.BuildControllerEventRecorder(k8s, clockSkewControllerName, metav1.NamespaceDefault),
+		skewed:    map[string]time.Time{},
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(k8s, 0, informers.WithNamespace(core.NamespaceNodeLease))
+	factory.Coordination().V1().Leases().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: m.onLeaseUpdate,
+	})
+	factory.Start(ctx.Done())
+
+	return nil
+}
+
+type clockSkewMonitor struct {
+	threshold time.Duration
+	recorder  record.EventRecorder
+
+	mu sync.Mutex
+	// skewed maps the names of nodes with skewed clocks to the time that an event was last emitted.
+	skewed map[string]time.Time
+}
+
+func (m *clockSkewMonitor) onLeaseUpdate(oldObj, newObj interface{}) {
+	oldLease, ok := oldObj.(*coordinationv1.Lease)
+	if !ok {
+		return
+	}
+	lease, ok := newObj.(*coordinationv1.Lease)
+	if !ok || lease.Spec.RenewTime == nil {
+		return
+	}
+	// Only consider updates that renewed the lease; the renew time is otherwise not current.
+	if oldLease.Spec.RenewTime != nil && oldLease.Spec.RenewTime.Equal(lease.Spec.RenewTime) {
+		return
+	}
+
+	now := time.Now()
+	skew := now.Sub(lease.Spec.RenewTime.Time)
+	if skew < 0 {
+		skew = -skew
+	}
+	ref := nodeReference(lease)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lastEvent, isSkewed := m.skewed[lease.Name]
+	if skew <= m.threshold {
+		if isSkewed {
+			delete(m.skewed, lease.Name)
+			logrus.Infof("Clock skew for node %s is now within %v", lease.Name, m.threshold)
+			m.recorder.Eventf(ref, core.EventTypeNormal, EventClockSkewResolved, "Node clock is within %v of server clock", m.threshold)
+		}
+		return
+	}
+	if isSkewed && now.Sub(lastEvent) < clockSkewEventInterval {
+		return
+	}
+	m.skewed[lease.Name] = now
+	skew = skew.Round(time.Second)
+	logrus.Warnf("Clock skew for node %s is approximately %v, which exceeds the threshold of %v", lease.Name, skew, m.threshold)
+	m.recorder.Eventf(ref, core.EventTypeWarning, EventClockSkew, "Node clock differs from server clock by approximately %v, which exceeds the threshold of %v; certificates and leases may not be handled correctly", skew, m.threshold)
+}
+
+// nodeReference returns a reference to the node that owns the lease.
+func nodeReference(lease *coordinationv1.Lease) *core.ObjectReference {
+	ref := &core.ObjectReference{
+		Kind:       "Node",
+		APIVersion: "v1",
+		Name:       lease.Name,
+		UID:        types.UID(lease.Name),
+	}
+	for _, owner := range lease.OwnerReferences {
+		if owner.Kind == "Node" {
+			ref.UID = owner.UID
+		}
+	}
+	return ref
+}
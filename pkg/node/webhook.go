@@ -0,0 +1,258 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/util"
+	"github.com/k3s-io/k3s/pkg/version"
+	coreclient "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	EventNodeRegistered   = "NodeRegistered"
+	EventNodeApproved     = "NodeApproved"
+	EventNodeNotReady     = "NodeNotReady"
+	EventNodeRolesChanged = "NodeRolesChanged"
+	EventNodeDeleted      = "NodeDeleted"
+
+	nodeRolePrefix = "node-role.kubernetes.io/"
+
+	webhookTimeout  = 10 * time.Second
+	webhookAttempts = 3
+)
+
+var nodePasswordSuffix = ".node-password." + version.Program
+
+// WebhookEvent is the JSON body sent to node webhook URLs.
+type WebhookEvent struct {
+	Event         string       `json:"event"`
+	Timestamp     time.Time    `json:"timestamp"`
+	Node          WebhookNode  `json:"node"`
+	PreviousRoles []string     `json:"previousRoles,omitempty"`
+	Reason        string       `json:"reason,omitempty"`
+	NotReadySince *metav1.Time `json:"notReadySince,omitempty"`
+}
+
+// WebhookNode contains node metadata included in webhook events.
+type WebhookNode struct {
+	Name           string            `json:"name"`
+	Roles          []string          `json:"roles"`
+	InternalIPs    []string          `json:"internalIPs,omitempty"`
+	ExternalIPs    []string          `json:"externalIPs,omitempty"`
+	KubeletVersion string            `json:"kubeletVersion,omitempty"`
+	OSImage        string            `json:"osImage,omitempty"`
+	Architecture   string            `json:"architecture,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+}
+
+// RegisterWebhooks starts a controller that notifies the configured webhook URLs of node lifecycle events.
+// This should only be run on the elected leader, to avoid sending duplicate notifications.
+func RegisterWebhooks(ctx context.Context,
+	urls []string,
+	notReadyThreshold time.Duration,
+	secrets coreclient.SecretController,
+	nodes coreclient.NodeController,
+) error {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = util.ProxyFunc(util.ProxyWebhookPrefix, true)
+	w := &webhookHandler{
+		ctx:               ctx,
+		urls:              urls,
+		notReadyThreshold: notReadyThreshold,
+		nodes:             nodes,
+		started:           time.Now(),
+		known:             map[string]WebhookNode{},
+		notified:          map[string]bool{},
+		client:            &http.Client{Timeout: webhookTimeout, Transport: transport},
+	}
+	nodes.OnChange(ctx, "node-webhook", w.onNodeChange)
+	secrets.OnChange(ctx, "node-webhook", w.onSecretChange)
+
+	return nil
+}
+
+type webhookHandler struct {
+	sync.Mutex
+
+	ctx               context.Context
+	urls              []string
+	notReadyThreshold time.Duration
+	nodes             coreclient.NodeController
+	started           time.Time
+	known             map[string]WebhookNode
+	notified          map[string]bool
+	client            *http.Client
+}
+
+// onNodeChange tracks nodes in order to detect registration, role changes, extended NotReady
+// conditions, and removal. Nodes that existed before the controller started are not considered new.
+func (w *webhookHandler) onNodeChange(key string, node *core.Node) (*core.Node, error) {
+	w.Lock()
+	defer w.Unlock()
+
+	if node == nil || node.DeletionTimestamp != nil {
+		if previous, ok := w.known[key]; ok {
+			delete(w.known, key)
+			delete(w.notified, key)
+			w.send(WebhookEvent{Event: EventNodeDeleted, Node: previous})
+		}
+		return node, nil
+	}
+
+	current := toWebhookNode(node)
+	previous, ok := w.known[key]
+	w.known[key] = current
+	if !ok && node.CreationTimestamp.Time.After(w.started) {
+		w.send(WebhookEvent{Event: EventNodeRegistered, Node: current})
+	} else if ok && !equalRoles(previous.Roles, current.Roles) {
+		w.send(WebhookEvent{Event: EventNodeRolesChanged, Node: current, PreviousRoles: previous.Roles})
+	}
+
+	if condition := getReadyCondition(node); condition != nil && condition.Status != core.ConditionTrue {
+		notReadyFor := time.Since(condition.LastTransitionTime.Time)
+		if notReadyFor < w.notReadyThreshold {
+			// requeue so that we can check again once the threshold has passed, even if the node is not updated.
+			w.nodes.EnqueueAfter(node.Name, w.notReadyThreshold-notReadyFor)
+		} else if !w.notified[key] {
+			w.notified[key] = true
+			since := condition.LastTransitionTime
+			w.send(WebhookEvent{Event: EventNodeNotReady, Node: current, Reason: condition.Reason, NotReadySince: &since})
+		}
+	} else {
+		delete(w.notified, key)
+	}
+
+	return node, nil
+}
+
+// onSecretChange notifies webhooks when a new node password secret is created, which occurs when
+// the supervisor approves a node for the first time.
+func (w *webhookHandler) onSecretChange(key string, secret *core.Secret) (*core.Secret, error) {
+	if secret == nil || secret.Namespace != metav1.NamespaceSystem || !strings.HasSuffix(secret.Name, nodePasswordSuffix) {
+		return secret, nil
+	}
+	if secret.DeletionTimestamp != nil || !secret.CreationTimestamp.Time.After(w.started) {
+		return secret, nil
+	}
+
+	w.Lock()
+	defer w.Unlock()
+	nodeName := strings.TrimSuffix(secret.Name, nodePasswordSuffix)
+	node, ok := w.known[nodeName]
+	if !ok {
+		node = WebhookNode{Name: nodeName, Roles: []string{}}
+	}
+	w.send(WebhookEvent{Event: EventNodeApproved, Node: node})
+	return secret, nil
+}
+
+// send delivers the event to all configured webhooks in the background.
+func (w *webhookHandler) send(event WebhookEvent) {
+	event.Timestamp = time.Now().UTC()
+	body, err := json.Marshal(event)
+	if err != nil {
+		logrus.Errorf("Failed to encode %s webhook for node %s: %v", event.Event, event.Node.Name, err)
+		return
+	}
+	for _, url := range w.urls {
+		go w.post(url, event, body)
+	}
+}
+
+func (w *webhookHandler) post(url string, event WebhookEvent, body []byte) {
+	var err error
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		if err = w.postOnce(url, body); err == nil {
+			logrus.Debugf("Sent %s webhook for node %s to %s", event.Event, event.Node.Name, url)
+			return
+		}
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-time.After(time.Duration(attempt) * 5 * time.Second):
+		}
+	}
+	logrus.Warnf("Failed to send %s webhook for node %s to %s: %v", event.Event, event.Node.Name, url, err)
+}
+
+func (w *webhookHandler) postOnce(url string, body []byte) error {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", version.Program+"/"+version.Version)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+func toWebhookNode(node *core.Node) WebhookNode {
+	result := WebhookNode{
+		Name:           node.Name,
+		Roles:          Roles(node),
+		KubeletVersion: node.Status.NodeInfo.KubeletVersion,
+		OSImage:        node.Status.NodeInfo.OSImage,
+		Architecture:   node.Status.NodeInfo.Architecture,
+		Labels:         node.Labels,
+	}
+	for _, address := range node.Status.Addresses {
+		switch address.Type {
+		case core.NodeInternalIP:
+			result.InternalIPs = append(result.InternalIPs, address.Address)
+		case core.NodeExternalIP:
+			result.ExternalIPs = append(result.ExternalIPs, address.Address)
+		}
+	}
+	return result
+}
+
+// Roles returns a sorted list of the node's roles, as indicated by node-role labels.
+func Roles(node *core.Node) []string {
+	roles := []string{}
+	for label, value := range node.Labels {
+		if strings.HasPrefix(label, nodeRolePrefix) && value == "true" {
+			roles = append(roles, strings.TrimPrefix(label, nodeRolePrefix))
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+func getReadyCondition(node *core.Node) *core.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == core.NodeReady {
+			return &node.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func equalRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
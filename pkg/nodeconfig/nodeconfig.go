@@ -20,6 +20,12 @@ var (
 	NodeEnvAnnotation        = version.Program + ".io/node-env"
 	NodeConfigHashAnnotation = version.Program + ".io/node-config-hash"
 	ClusterEgressLabel       = "egress." + version.Program + ".io/cluster"
+	// RuntimeHandlerLabelPrefix is the prefix of the labels that identify the additional container runtime
+	// handlers available on a node. The RuntimeClasses for these handlers select nodes with the label.
+	RuntimeHandlerLabelPrefix = "runtime." + version.Program + ".io/"
+	// CertRotationAnnotation is set on a node to request that the agent restart and re-issue its certificates.
+	// The value is an RFC3339 timestamp; agents started before this time will restart.
+	CertRotationAnnotation = version.Program + ".io/cert-rotation-requested"
 )
 
 const (
@@ -111,20 +117,38 @@ func SetNodeConfigLabels(nodeConfig *config.Node, node *corev1.Node) (bool, erro
 	if node.Labels == nil {
 		node.Labels = make(map[string]string)
 	}
+	changed := false
 	_, hasLabel := node.Labels[ClusterEgressLabel]
 	switch nodeConfig.EgressSelectorMode {
 	case config.EgressSelectorModeCluster, config.EgressSelectorModePod:
 		if !hasLabel {
 			node.Labels[ClusterEgressLabel] = "true"
-			return true, nil
+			changed = true
 		}
 	default:
 		if hasLabel {
 			delete(node.Labels, ClusterEgressLabel)
-			return true, nil
+			changed = true
 		}
 	}
-	return false, nil
+
+	runtimes := map[string]bool{}
+	for _, runtime := range nodeConfig.Containerd.Runtimes {
+		runtimes[RuntimeHandlerLabelPrefix+runtime] = true
+	}
+	for label := range node.Labels {
+		if strings.HasPrefix(label, RuntimeHandlerLabelPrefix) && !runtimes[label] {
+			delete(node.Labels, label)
+			changed = true
+		}
+	}
+	for label := range runtimes {
+		if node.Labels[label] != "true" {
+			node.Labels[label] = "true"
+			changed = true
+		}
+	}
+	return changed, nil
 }
 
 func isSecret(key string) bool {
@@ -2,6 +2,7 @@ package nodeconfig
 
 import (
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/k3s-io/k3s/pkg/daemons/config"
@@ -124,3 +125,61 @@ func Test_UnitSetNodeConfigAnnotations(t *testing.T) {
 		})
 	}
 }
+
+func Test_UnitSetNodeConfigLabels(t *testing.T) {
+	tests := []struct {
+		name       string
+		runtimes   []string
+		labels     map[string]string
+		want       bool
+		wantLabels map[string]string
+	}{
+		{
+			name:       "No runtimes",
+			want:       false,
+			wantLabels: map[string]string{},
+		},
+		{
+			name:     "Runtimes added",
+			runtimes: []string{"kata", "crun"},
+			labels:   map[string]string{"foo": "bar"},
+			want:     true,
+			wantLabels: map[string]string{
+				"foo":                              "bar",
+				RuntimeHandlerLabelPrefix + "kata": "true",
+				RuntimeHandlerLabelPrefix + "crun": "true",
+			},
+		},
+		{
+			name:       "Runtimes unchanged",
+			runtimes:   []string{"runsc"},
+			labels:     map[string]string{RuntimeHandlerLabelPrefix + "runsc": "true"},
+			want:       false,
+			wantLabels: map[string]string{RuntimeHandlerLabelPrefix + "runsc": "true"},
+		},
+		{
+			name:       "Runtime removed",
+			runtimes:   []string{"runsc"},
+			labels:     map[string]string{RuntimeHandlerLabelPrefix + "runsc": "true", RuntimeHandlerLabelPrefix + "kata": "true"},
+			want:       true,
+			wantLabels: map[string]string{RuntimeHandlerLabelPrefix + "runsc": "true"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeConfig := &config.Node{}
+			nodeConfig.Containerd.Runtimes = tt.runtimes
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: tt.labels}}
+			got, err := SetNodeConfigLabels(nodeConfig, node)
+			if err != nil {
+				t.Fatalf("SetNodeConfigLabels() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("SetNodeConfigLabels() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(node.Labels, tt.wantLabels) {
+				t.Errorf("SetNodeConfigLabels() labels = %v, want %v", node.Labels, tt.wantLabels)
+			}
+		})
+	}
+}
@@ -22,12 +22,13 @@ var (
 	Hasher = hash.NewSCrypt()
 )
 
-func getSecretName(nodeName string) string {
+// SecretName returns the name of the secret that holds the password hash for a node.
+func SecretName(nodeName string) string {
 	return strings.ToLower(nodeName + ".node-password." + version.Program)
 }
 
 func verifyHash(secretClient coreclient.SecretClient, nodeName, pass string) error {
-	name := getSecretName(nodeName)
+	name := SecretName(nodeName)
 	secret, err := secretClient.Get(metav1.NamespaceSystem, name, metav1.GetOptions{})
 	if err != nil {
 		return err
@@ -55,7 +56,7 @@ func Ensure(secretClient coreclient.SecretClient, nodeName, pass string) error {
 	immutable := true
 	_, err = secretClient.Create(&v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      getSecretName(nodeName),
+			Name:      SecretName(nodeName),
 			Namespace: metav1.NamespaceSystem,
 		},
 		Immutable: &immutable,
@@ -69,7 +70,7 @@ func Ensure(secretClient coreclient.SecretClient, nodeName, pass string) error {
 
 // Delete will remove a node-password secret
 func Delete(secretClient coreclient.SecretClient, nodeName string) error {
-	return secretClient.Delete(metav1.NamespaceSystem, getSecretName(nodeName), &metav1.DeleteOptions{})
+	return secretClient.Delete(metav1.NamespaceSystem, SecretName(nodeName), &metav1.DeleteOptions{})
 }
 
 // MigrateFile moves password file entries to secrets
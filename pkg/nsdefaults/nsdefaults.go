@@ -0,0 +1,241 @@
+package nsdefaults
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	coreclient "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	// AppliedAnnotation is set on namespaces once the defaults have been applied to them, so that quotas
+	// and limit ranges that are later changed or deleted by the namespace owner are not recreated.
+	AppliedAnnotation = version.Program + ".io/namespace-defaults-applied"
+	// EnabledAnnotation is set on the kube-system namespace to the time that the defaults were first enabled
+	// in the cluster. Namespaces created before this time are not modified.
+	EnabledAnnotation = version.Program + ".io/namespace-defaults-enabled"
+	// ExemptLabel may be set to "true" on a namespace to exempt it from the defaults.
+	ExemptLabel = version.Program + ".io/namespace-defaults-exempt"
+
+	// systemNamespaces are always exempt from the defaults.
+	systemNamespaces = []string{metav1.NamespaceDefault, metav1.NamespaceSystem, metav1.NamespacePublic, corev1.NamespaceNodeLease}
+)
+
+// Defaults holds the ResourceQuota and LimitRange templates that are applied to namespaces. The
+// templates are standard Kubernetes objects; their namespace is set to that of each namespace
+// they are applied to.
+type Defaults struct {
+	// ExemptNamespaces are the names of namespaces that the defaults are not applied to.
+	ExemptNamespaces []string `json:"exemptNamespaces,omitempty"`
+	// ExemptSelector is a label selector matching namespaces that the defaults are not applied to.
+	ExemptSelector string `json:"exemptSelector,omitempty"`
+	// ResourceQuotas are the ResourceQuota templates.
+	ResourceQuotas []corev1.ResourceQuota `json:"resourceQuotas,omitempty"`
+	// LimitRanges are the LimitRange templates.
+	LimitRanges []corev1.LimitRange `json:"limitRanges,omitempty"`
+
+	selector labels.Selector
+}
+
+// Load reads and validates the defaults from a YAML or JSON file.
+func Load(file string) (*Defaults, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read namespace defaults config")
+	}
+	return Parse(b)
+}
+
+// Parse parses and validates the defaults from YAML or JSON.
+func Parse(b []byte) (*Defaults, error) {
+	d := &Defaults{}
+	if err := yaml.UnmarshalStrict(b, d); err != nil {
+		return nil, errors.Wrap(err, "failed to parse namespace defaults config")
+	}
+	selector, err := labels.Parse(d.ExemptSelector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid namespace defaults exemptSelector %q", d.ExemptSelector)
+	}
+	if d.ExemptSelector == "" {
+		selector = labels.Nothing()
+	}
+	d.selector = selector
+
+	names := map[string]bool{}
+	for _, quota := range d.ResourceQuotas {
+		if quota.Name == "" {
+			return nil, errors.New("namespace defaults resourceQuotas must have a metadata.name")
+		}
+		if names["ResourceQuota/"+quota.Name] {
+			return nil, fmt.Errorf("namespace defaults resourceQuota %s is defined more than once", quota.Name)
+		}
+		names["ResourceQuota/"+quota.Name] = true
+	}
+	for _, limitRange := range d.LimitRanges {
+		if limitRange.Name == "" {
+			return nil, errors.New("namespace defaults limitRanges must have a metadata.name")
+		}
+		if names["LimitRange/"+limitRange.Name] {
+			return nil, fmt.Errorf("namespace defaults limitRange %s is defined more than once", limitRange.Name)
+		}
+		names["LimitRange/"+limitRange.Name] = true
+	}
+	return d, nil
+}
+
+// Exempt returns true if the defaults should not be applied to the namespace.
+func (d *Defaults) Exempt(ns *corev1.Namespace) bool {
+	for _, name := range systemNamespaces {
+		if ns.Name == name {
+			return true
+		}
+	}
+	for _, name := range d.ExemptNamespaces {
+		if ns.Name == name {
+			return true
+		}
+	}
+	if ns.Labels[ExemptLabel] == "true" {
+		return true
+	}
+	return d.selector != nil && d.selector.Matches(labels.Set(ns.Labels))
+}
+
+// objects returns the quotas and limit ranges to create in the namespace.
+func (d *Defaults) objects(namespace string) ([]*corev1.ResourceQuota, []*corev1.LimitRange) {
+	quotas := make([]*corev1.ResourceQuota, 0, len(d.ResourceQuotas))
+	for _, template := range d.ResourceQuotas {
+		quota := &corev1.ResourceQuota{
+			ObjectMeta: templateMeta(template.ObjectMeta, namespace),
+			Spec:       *template.Spec.DeepCopy(),
+		}
+		quotas = append(quotas, quota)
+	}
+	limitRanges := make([]*corev1.LimitRange, 0, len(d.LimitRanges))
+	for _, template := range d.LimitRanges {
+		limitRange := &corev1.LimitRange{
+			ObjectMeta: templateMeta(template.ObjectMeta, namespace),
+			Spec:       *template.Spec.DeepCopy(),
+		}
+		limitRanges = append(limitRanges, limitRange)
+	}
+	return quotas, limitRanges
+}
+
+func templateMeta(template metav1.ObjectMeta, namespace string) metav1.ObjectMeta {
+	meta := metav1.ObjectMeta{
+		Name:        template.Name,
+		Namespace:   namespace,
+		Labels:      map[string]string{"app.kubernetes.io/managed-by": version.Program},
+		Annotations: map[string]string{},
+	}
+	for k, v := range template.Labels {
+		meta.Labels[k] = v
+	}
+	for k, v := range template.Annotations {
+		meta.Annotations[k] = v
+	}
+	return meta
+}
+
+// Register starts a controller that applies the defaults to each namespace created after the defaults were
+// first enabled, that they have not yet been applied to, and that is not exempt. The defaults are applied once;
+// objects that are later modified or deleted are left as they are. The time that the defaults were first enabled
+// is recorded on the kube-system namespace, so that namespaces created while no server was running the controller
+// are still handled, while namespaces that existed before the defaults were enabled are never modified.
+// This should only be run on the elected leader.
+func Register(ctx context.Context, defaults *Defaults, k8s kubernetes.Interface, namespaces coreclient.NamespaceController) error {
+	enabled, err := enabledSince(ctx, k8s)
+	if err != nil {
+		return err
+	}
+	h := &handler{
+		ctx:      ctx,
+		defaults: defaults,
+		k8s:      k8s,
+		enabled:  enabled,
+	}
+	logrus.Infof("Applying %d default resource quotas and %d default limit ranges to new namespaces", len(defaults.ResourceQuotas), len(defaults.LimitRanges))
+	namespaces.OnChange(ctx, "namespace-defaults", h.onChange)
+	return nil
+}
+
+// enabledSince returns the time that the defaults were first enabled, recording the current time
+// on the kube-system namespace if it has not yet been set.
+func enabledSince(ctx context.Context, k8s kubernetes.Interface) (time.Time, error) {
+	ns, err := k8s.CoreV1().Namespaces().Get(ctx, metav1.NamespaceSystem, metav1.GetOptions{})
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to get kube-system namespace")
+	}
+	if v, ok := ns.Annotations[EnabledAnnotation]; ok {
+		enabled, err := time.Parse(time.RFC3339, v)
+		if err == nil {
+			return enabled, nil
+		}
+		logrus.Warnf("Resetting invalid %s annotation %q on namespace %s: %v", EnabledAnnotation, v, ns.Name, err)
+	}
+
+	// Truncate to match the precision of namespace creation timestamps
+	enabled := time.Now().Truncate(time.Second)
+	ns = ns.DeepCopy()
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Annotations[EnabledAnnotation] = enabled.UTC().Format(time.RFC3339)
+	if _, err := k8s.CoreV1().Namespaces().Update(ctx, ns, metav1.UpdateOptions{}); err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to record namespace defaults enabled time")
+	}
+	return enabled, nil
+}
+
+type handler struct {
+	ctx      context.Context
+	defaults *Defaults
+	k8s      kubernetes.Interface
+	enabled  time.Time
+}
+
+func (h *handler) onChange(key string, ns *corev1.Namespace) (*corev1.Namespace, error) {
+	if ns == nil || ns.DeletionTimestamp != nil || ns.Status.Phase == corev1.NamespaceTerminating {
+		return ns, nil
+	}
+	if _, ok := ns.Annotations[AppliedAnnotation]; ok || h.defaults.Exempt(ns) {
+		return ns, nil
+	}
+	if ns.CreationTimestamp.Time.Before(h.enabled) {
+		return ns, nil
+	}
+
+	ctx := h.ctx
+	quotas, limitRanges := h.defaults.objects(ns.Name)
+	for _, quota := range quotas {
+		if _, err := h.k8s.CoreV1().ResourceQuotas(ns.Name).Create(ctx, quota, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return ns, errors.Wrapf(err, "failed to create resource quota %s/%s", ns.Name, quota.Name)
+		}
+	}
+	for _, limitRange := range limitRanges {
+		if _, err := h.k8s.CoreV1().LimitRanges(ns.Name).Create(ctx, limitRange, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return ns, errors.Wrapf(err, "failed to create limit range %s/%s", ns.Name, limitRange.Name)
+		}
+	}
+	logrus.Infof("Applied default resource quotas and limit ranges to namespace %s", ns.Name)
+
+	ns = ns.DeepCopy()
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	ns.Annotations[AppliedAnnotation] = "true"
+	return h.k8s.CoreV1().Namespaces().Update(ctx, ns, metav1.UpdateOptions{})
+}
@@ -0,0 +1,263 @@
+package nsdefaults
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testConfig = `
+exemptNamespaces:
+- monitoring
+exemptSelector: tier=platform
+resourceQuotas:
+- metadata:
+    name: default-quota
+    labels:
+      team: shared
+  spec:
+    hard:
+      requests.cpu: "4"
+      pods: "20"
+limitRanges:
+- metadata:
+    name: default-limits
+  spec:
+    limits:
+    - type: Container
+      defaultRequest:
+        cpu: 100m
+`
+
+func Test_UnitParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{
+			name:   "valid config",
+			config: testConfig,
+		},
+		{
+			name:   "empty config",
+			config: "",
+		},
+		{
+			name:    "unknown field",
+			config:  "resourceQuota: []",
+			wantErr: true,
+		},
+		{
+			name:    "missing name",
+			config:  "limitRanges:\n- spec: {}",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate name",
+			config:  "resourceQuotas:\n- metadata: {name: a}\n- metadata: {name: a}",
+			wantErr: true,
+		},
+		{
+			name:    "invalid selector",
+			config:  "exemptSelector: '!!'",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.config)); (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_UnitExempt(t *testing.T) {
+	defaults, err := Parse([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		ns     metav1.ObjectMeta
+		exempt bool
+	}{
+		{
+			name:   "user namespace",
+			ns:     metav1.ObjectMeta{Name: "team-a"},
+			exempt: false,
+		},
+		{
+			name:   "system namespace",
+			ns:     metav1.ObjectMeta{Name: metav1.NamespaceSystem},
+			exempt: true,
+		},
+		{
+			name:   "exempt namespace",
+			ns:     metav1.ObjectMeta{Name: "monitoring"},
+			exempt: true,
+		},
+		{
+			name:   "exempt label",
+			ns:     metav1.ObjectMeta{Name: "team-b", Labels: map[string]string{ExemptLabel: "true"}},
+			exempt: true,
+		},
+		{
+			name:   "exempt selector",
+			ns:     metav1.ObjectMeta{Name: "team-c", Labels: map[string]string{"tier": "platform"}},
+			exempt: true,
+		},
+		{
+			name:   "selector does not match",
+			ns:     metav1.ObjectMeta{Name: "team-d", Labels: map[string]string{"tier": "apps"}},
+			exempt: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaults.Exempt(&corev1.Namespace{ObjectMeta: tt.ns}); got != tt.exempt {
+				t.Errorf("Exempt() = %v, want %v", got, tt.exempt)
+			}
+		})
+	}
+}
+
+func Test_UnitObjects(t *testing.T) {
+	defaults, err := Parse([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	quotas, limitRanges := defaults.objects("team-a")
+	if len(quotas) != 1 || len(limitRanges) != 1 {
+		t.Fatalf("objects() returned %d quotas and %d limit ranges, want 1 and 1", len(quotas), len(limitRanges))
+	}
+	quota := quotas[0]
+	if quota.Namespace != "team-a" || quota.Name != "default-quota" || quota.Labels["team"] != "shared" {
+		t.Errorf("objects() quota = %+v", quota.ObjectMeta)
+	}
+	if pods := quota.Spec.Hard[corev1.ResourcePods]; pods.String() != "20" {
+		t.Errorf("objects() quota pods = %s, want 20", pods.String())
+	}
+	if limitRanges[0].Namespace != "team-a" || len(limitRanges[0].Spec.Limits) != 1 {
+		t.Errorf("objects() limit range = %+v", limitRanges[0])
+	}
+}
+
+func Test_UnitEnabledSince(t *testing.T) {
+	ctx := context.Background()
+	recorded := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        time.Time
+	}{
+		{
+			name:        "previously enabled",
+			annotations: map[string]string{EnabledAnnotation: recorded.Format(time.RFC3339)},
+			want:        recorded,
+		},
+		{
+			name: "first enabled",
+		},
+		{
+			name:        "invalid annotation",
+			annotations: map[string]string{EnabledAnnotation: "yesterday"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8s := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: metav1.NamespaceSystem, Annotations: tt.annotations}})
+			before := time.Now().Truncate(time.Second)
+			got, err := enabledSince(ctx, k8s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.want.IsZero() && !got.Equal(tt.want) {
+				t.Errorf("enabledSince() = %v, want %v", got, tt.want)
+			}
+			if tt.want.IsZero() && got.Before(before) {
+				t.Errorf("enabledSince() = %v, want current time", got)
+			}
+
+			// The enabled time must be recorded, and returned again on the next call
+			again, err := enabledSince(ctx, k8s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !again.Equal(got) {
+				t.Errorf("enabledSince() second call = %v, want %v", again, got)
+			}
+		})
+	}
+}
+
+func Test_UnitOnChange(t *testing.T) {
+	ctx := context.Background()
+	defaults, err := Parse([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabled := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name        string
+		ns          metav1.ObjectMeta
+		wantApplied bool
+	}{
+		{
+			name:        "created after enabled",
+			ns:          metav1.ObjectMeta{Name: "team-a", CreationTimestamp: metav1.NewTime(enabled.Add(time.Minute))},
+			wantApplied: true,
+		},
+		{
+			name:        "created when enabled",
+			ns:          metav1.ObjectMeta{Name: "team-a", CreationTimestamp: metav1.NewTime(enabled)},
+			wantApplied: true,
+		},
+		{
+			name: "created before enabled",
+			ns:   metav1.ObjectMeta{Name: "team-a", CreationTimestamp: metav1.NewTime(enabled.Add(-time.Minute))},
+		},
+		{
+			name: "already applied",
+			ns:   metav1.ObjectMeta{Name: "team-a", CreationTimestamp: metav1.NewTime(enabled.Add(time.Minute)), Annotations: map[string]string{AppliedAnnotation: "true"}},
+		},
+		{
+			name: "exempt",
+			ns:   metav1.ObjectMeta{Name: "monitoring", CreationTimestamp: metav1.NewTime(enabled.Add(time.Minute))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ns := &corev1.Namespace{ObjectMeta: tt.ns}
+			k8s := fake.NewSimpleClientset(ns)
+			h := &handler{ctx: ctx, defaults: defaults, k8s: k8s, enabled: enabled}
+			if _, err := h.onChange(ns.Name, ns); err != nil {
+				t.Fatal(err)
+			}
+
+			quotas, err := k8s.CoreV1().ResourceQuotas(ns.Name).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			limitRanges, err := k8s.CoreV1().LimitRanges(ns.Name).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := k8s.CoreV1().Namespaces().Get(ctx, ns.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if applied := len(quotas.Items) == 1 && len(limitRanges.Items) == 1; applied != tt.wantApplied {
+				t.Errorf("onChange() created %d quotas and %d limit ranges, want applied = %v", len(quotas.Items), len(limitRanges.Items), tt.wantApplied)
+			}
+			if _, ok := got.Annotations[AppliedAnnotation]; tt.wantApplied && !ok {
+				t.Errorf("onChange() did not set %s annotation", AppliedAnnotation)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package preflight
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	iptablesLegacy = "legacy"
+	iptablesNFT    = "nft"
+)
+
+const (
+	ModeStrict   = "strict"   // refuse to start if any check fails
+	ModeWarn     = "warn"     // log failed checks, and continue starting
+	ModeDisabled = "disabled" // do not run checks
+)
+
+// Config contains the node settings that preflight checks are run against.
+type Config struct {
+	// DataDir is the data dir that will be used for node state and images.
+	DataDir string
+	// Ports are TCP ports that must not already be in use.
+	Ports []int
+	// Agent is true if the node will run the kubelet and container runtime.
+	Agent bool
+	// Rootless is true if the node will run in rootless mode.
+	Rootless bool
+	// ExternalRuntime is true if the node will use a container runtime that is not managed by us.
+	ExternalRuntime bool
+}
+
+// check is a single preflight check. The hint describes how to resolve a failure.
+type check struct {
+	name string
+	hint string
+	run  func(*Config) error
+	// skip returns true if the check does not apply to the config.
+	skip func(*Config) bool
+}
+
+// ValidateMode returns an error if the preflight mode is not known.
+func ValidateMode(mode string) error {
+	switch mode {
+	case ModeStrict, ModeWarn, ModeDisabled:
+		return nil
+	}
+	return fmt.Errorf("invalid preflight mode %s; valid values are '%s', '%s', and '%s'", mode, ModeStrict, ModeWarn, ModeDisabled)
+}
+
+// Run runs all preflight checks applicable to the config. Failed checks are logged along with a hint
+// for how to resolve them. In strict mode, an error is returned if any check fails.
+func Run(mode string, config *Config) error {
+	if err := ValidateMode(mode); err != nil {
+		return err
+	}
+	if mode == ModeDisabled {
+		return nil
+	}
+	checks := []check{{
+		name: "ports",
+		hint: "Stop the process using the port, or configure a different port",
+		run:  checkPorts,
+	}}
+	if config.Agent {
+		checks = append(checks, agentChecks...)
+	}
+	return runChecks(mode, config, checks)
+}
+
+func runChecks(mode string, config *Config, checks []check) error {
+	failed := []string{}
+	for _, c := range checks {
+		if c.skip != nil && c.skip(config) {
+			continue
+		}
+		err := c.run(config)
+		if err == nil {
+			logrus.Debugf("Preflight check %s passed", c.name)
+			continue
+		}
+		failed = append(failed, c.name)
+		if mode == ModeStrict {
+			logrus.Errorf("Preflight check %s failed: %v. %s", c.name, err, c.hint)
+		} else {
+			logrus.Warnf("Preflight check %s failed: %v. %s", c.name, err, c.hint)
+		}
+	}
+	if len(failed) > 0 && mode == ModeStrict {
+		return fmt.Errorf("preflight checks failed: %s; use --preflight=%s to start anyway", strings.Join(failed, ", "), ModeWarn)
+	}
+	return nil
+}
+
+// checkPorts returns an error listing any ports that cannot be listened on.
+func checkPorts(config *Config) error {
+	inUse := []string{}
+	seen := map[int]bool{}
+	for _, port := range config.Ports {
+		if port <= 0 || seen[port] {
+			continue
+		}
+		seen[port] = true
+		listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+		if err != nil {
+			inUse = append(inUse, strconv.Itoa(port))
+			continue
+		}
+		listener.Close()
+	}
+	if len(inUse) > 0 {
+		return fmt.Errorf("port %s already in use", strings.Join(inUse, ", "))
+	}
+	return nil
+}
+
+// countIptablesRules returns the number of rules in the output of iptables-save.
+func countIptablesRules(save []byte) int {
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(save))
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "-A ") {
+			count++
+		}
+	}
+	return count
+}
+
+// detectIptablesBackend returns the iptables backend that has rules, or an empty string if neither or both do.
+func detectIptablesBackend(counts map[string]int) string {
+	switch {
+	case counts[iptablesLegacy] > 0 && counts[iptablesNFT] == 0:
+		return iptablesLegacy
+	case counts[iptablesNFT] > 0 && counts[iptablesLegacy] == 0:
+		return iptablesNFT
+	}
+	return ""
+}
+
+// checkMixedIptablesBackends returns an error if both iptables backends have rules.
+func checkMixedIptablesBackends(counts map[string]int) error {
+	if counts[iptablesLegacy] > 0 && counts[iptablesNFT] > 0 {
+		return fmt.Errorf("found %d iptables rules in the legacy backend and %d in the nftables backend", counts[iptablesLegacy], counts[iptablesNFT])
+	}
+	return nil
+}
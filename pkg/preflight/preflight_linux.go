@@ -0,0 +1,191 @@
+//go:build linux
+// +build linux
+
+package preflight
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/k3s-io/k3s/pkg/cgroups"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	minFreeBytes   = 2 * 1024 * 1024 * 1024
+	minFreePercent = 10
+)
+
+var agentChecks = []check{
+	{
+		name: "kernel-modules",
+		hint: "Install the kernel modules package for the running kernel, or build the modules into the kernel",
+		run:  checkKernelModules,
+		skip: isRootless,
+	},
+	{
+		name: "cgroups",
+		hint: "Enable the missing controllers on the kernel command line, or delegate them to the service if running in a container or rootless",
+		run:  checkCgroups,
+		skip: isRootless,
+	},
+	{
+		name: "time-sync",
+		hint: "Enable an NTP client such as systemd-timesyncd or chronyd; clock skew between nodes will cause certificate and datastore errors",
+		run:  checkTimeSync,
+	},
+	{
+		name: "disk-space",
+		hint: "Free space on the filesystem containing the data dir, or move the data dir with --data-dir",
+		run:  checkDiskSpace,
+	},
+	{
+		name: "container-runtimes",
+		hint: "Stop and disable other Kubernetes distributions and container runtimes, as they will conflict over iptables rules, cgroups, and ports",
+		run:  checkContainerRuntimes,
+		skip: func(config *Config) bool { return config.ExternalRuntime },
+	},
+	{
+		name: "iptables-backends",
+		hint: "Configure the host firewall and any other container runtimes to use the same iptables backend, as rules in the legacy and nftables backends are evaluated independently and will conflict with service and network policy rules",
+		run:  checkIptablesBackends,
+		skip: isRootless,
+	},
+}
+
+func isRootless(config *Config) bool {
+	return config.Rootless
+}
+
+// requiredModules are loaded by the agent at startup, and must be either loaded, built in, or available to modprobe.
+var requiredModules = []string{"overlay", "nf_conntrack", "br_netfilter", "iptable_nat", "iptable_filter"}
+
+func checkKernelModules(config *Config) error {
+	missing := []string{}
+	for _, module := range requiredModules {
+		if _, err := os.Stat(filepath.Join("/sys/module", module)); err == nil {
+			continue
+		}
+		if err := exec.Command("modprobe", "--dry-run", "--", module).Run(); err != nil {
+			missing = append(missing, module)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("kernel modules %s are not loaded and cannot be loaded with modprobe", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func checkCgroups(config *Config) error {
+	_, _, controllers := cgroups.CheckCgroups()
+	missing := []string{}
+	for _, controller := range []string{"cpu", "cpuset", "memory", "pids"} {
+		if !controllers[controller] {
+			missing = append(missing, controller)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("cgroup controllers %s are not available", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func checkTimeSync(config *Config) error {
+	tx := &unix.Timex{}
+	if _, err := unix.Adjtimex(tx); err != nil {
+		return err
+	}
+	if tx.Status&unix.STA_UNSYNC != 0 {
+		return errors.New("system clock is not synchronized")
+	}
+	return nil
+}
+
+func checkDiskSpace(config *Config) error {
+	// The data dir may not exist yet on first startup, so check the nearest existing parent.
+	path := config.DataDir
+	for {
+		if _, err := os.Stat(path); err == nil || filepath.Dir(path) == path {
+			break
+		}
+		path = filepath.Dir(path)
+	}
+	stat := &unix.Statfs_t{}
+	if err := unix.Statfs(path, stat); err != nil {
+		return err
+	}
+	free := stat.Bavail * uint64(stat.Bsize)
+	total := stat.Blocks * uint64(stat.Bsize)
+	if free < minFreeBytes || (total > 0 && free*100/total < minFreePercent) {
+		return fmt.Errorf("only %dMi free on filesystem containing %s", free/1024/1024, config.DataDir)
+	}
+	return nil
+}
+
+// conflictingProcesses are process names that indicate another Kubernetes distribution or container runtime is running.
+var conflictingProcesses = map[string]bool{
+	"kubelet":    true,
+	"dockerd":    true,
+	"containerd": true,
+	"crio":       true,
+}
+
+func checkContainerRuntimes(config *Config) error {
+	dirs, err := os.ReadDir("/proc")
+	if err != nil {
+		return err
+	}
+	found := map[string]bool{}
+	for _, dir := range dirs {
+		comm, err := os.ReadFile(filepath.Join("/proc", dir.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSpace(string(comm))
+		if !conflictingProcesses[name] {
+			continue
+		}
+		// Ignore processes from a previous run that were started from our own data dir.
+		if exe, err := os.Readlink(filepath.Join("/proc", dir.Name(), "exe")); err == nil && strings.HasPrefix(exe, config.DataDir) {
+			continue
+		}
+		found[name] = true
+	}
+	if len(found) > 0 {
+		names := []string{}
+		for name := range found {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("found running %s", strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// checkIptablesBackends returns an error if there are rules in both the legacy and nftables iptables backends.
+// The check is skipped if the tools for either backend are not installed on the host.
+func checkIptablesBackends(config *Config) error {
+	counts := map[string]int{}
+	for _, backend := range []string{iptablesLegacy, iptablesNFT} {
+		path, err := exec.LookPath("iptables-" + backend + "-save")
+		if err != nil {
+			logrus.Debugf("Skipping iptables backend check: %v", err)
+			return nil
+		}
+		out, err := exec.Command(path).Output()
+		if err != nil {
+			return fmt.Errorf("failed to list %s iptables rules: %v", backend, err)
+		}
+		counts[backend] = countIptablesRules(out)
+	}
+	if backend := detectIptablesBackend(counts); backend != "" {
+		logrus.Infof("Detected existing iptables rules in the %s backend", backend)
+	}
+	return checkMixedIptablesBackends(counts)
+}
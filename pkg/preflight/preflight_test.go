@@ -0,0 +1,138 @@
+package preflight
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func Test_UnitRunChecks(t *testing.T) {
+	pass := check{name: "pass", run: func(*Config) error { return nil }}
+	fail := check{name: "fail", hint: "fix it", run: func(*Config) error { return errors.New("failed") }}
+	skipped := check{name: "skipped", run: func(*Config) error { return errors.New("failed") }, skip: func(*Config) bool { return true }}
+
+	tests := []struct {
+		name    string
+		mode    string
+		checks  []check
+		wantErr bool
+	}{
+		{
+			name:   "Strict mode with passing checks",
+			mode:   ModeStrict,
+			checks: []check{pass},
+		},
+		{
+			name:    "Strict mode with failing check",
+			mode:    ModeStrict,
+			checks:  []check{pass, fail},
+			wantErr: true,
+		},
+		{
+			name:   "Warn mode with failing check",
+			mode:   ModeWarn,
+			checks: []check{pass, fail},
+		},
+		{
+			name:   "Strict mode with skipped check",
+			mode:   ModeStrict,
+			checks: []check{pass, skipped},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runChecks(tt.mode, &Config{}, tt.checks); (err != nil) != tt.wantErr {
+				t.Errorf("runChecks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_UnitCheckPorts(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	inUse := listener.Addr().(*net.TCPAddr).Port
+
+	if err := checkPorts(&Config{Ports: []int{0}}); err != nil {
+		t.Errorf("checkPorts() with no ports error = %v", err)
+	}
+	if err := checkPorts(&Config{Ports: []int{inUse}}); err == nil {
+		t.Errorf("checkPorts() with port %d in use did not return an error", inUse)
+	}
+}
+
+func Test_UnitValidateMode(t *testing.T) {
+	for _, mode := range []string{ModeStrict, ModeWarn, ModeDisabled} {
+		if err := ValidateMode(mode); err != nil {
+			t.Errorf("ValidateMode(%q) error = %v", mode, err)
+		}
+	}
+	if err := ValidateMode("loud"); err == nil {
+		t.Errorf("ValidateMode(\"loud\") did not return an error")
+	}
+}
+
+func Test_UnitCheckMixedIptablesBackends(t *testing.T) {
+	legacySave := []byte(`# Generated by iptables-save v1.8.7 on Mon Jan  1 00:00:00 2026
+*filter
+:INPUT ACCEPT [0:0]
+:FORWARD DROP [0:0]
+-A FORWARD -j DOCKER-USER
+-A FORWARD -o docker0 -j DOCKER
+COMMIT
+`)
+	nftSave := []byte(`# Warning: iptables-legacy tables present, use iptables-legacy-save to see them
+*filter
+:INPUT ACCEPT [0:0]
+-A INPUT -i lo -j ACCEPT
+COMMIT
+`)
+	emptySave := []byte("*filter\n:INPUT ACCEPT [0:0]\nCOMMIT\n")
+
+	tests := []struct {
+		name        string
+		legacy      []byte
+		nft         []byte
+		wantBackend string
+		wantErr     bool
+	}{
+		{
+			name: "No rules",
+		},
+		{
+			name:        "Legacy rules only",
+			legacy:      legacySave,
+			nft:         emptySave,
+			wantBackend: iptablesLegacy,
+		},
+		{
+			name:        "Nftables rules only",
+			legacy:      emptySave,
+			nft:         nftSave,
+			wantBackend: iptablesNFT,
+		},
+		{
+			name:    "Mixed rules",
+			legacy:  legacySave,
+			nft:     nftSave,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counts := map[string]int{
+				iptablesLegacy: countIptablesRules(tt.legacy),
+				iptablesNFT:    countIptablesRules(tt.nft),
+			}
+			if backend := detectIptablesBackend(counts); backend != tt.wantBackend {
+				t.Errorf("detectIptablesBackend() = %q, want %q", backend, tt.wantBackend)
+			}
+			if err := checkMixedIptablesBackends(counts); (err != nil) != tt.wantErr {
+				t.Errorf("checkMixedIptablesBackends() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
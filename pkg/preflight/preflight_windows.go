@@ -0,0 +1,7 @@
+//go:build windows
+// +build windows
+
+package preflight
+
+// agentChecks is empty on Windows, as the node checks rely on Linux kernel interfaces.
+var agentChecks = []check{}
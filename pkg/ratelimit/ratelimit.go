@@ -0,0 +1,198 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// idleTimeout is the time after which the state of a client that has not made any requests, and is not
+// locked out, is discarded.
+const idleTimeout = 10 * time.Minute
+
+// client tracks the request rate and authentication failures of a single source address.
+type client struct {
+	limiter     *rate.Limiter
+	failures    int
+	lockedUntil time.Time
+	lastSeen    time.Time
+}
+
+// Limiter limits the rate of requests from each source address, and locks out addresses that repeatedly
+// fail to authenticate. Requests from loopback addresses are not limited, so that local clients of the
+// supervisor are never locked out.
+type Limiter struct {
+	Rate        rate.Limit
+	Burst       int
+	MaxFailures int
+	Lockout     time.Duration
+
+	mu      sync.Mutex
+	clients map[string]*client
+	lastGC  time.Time
+	now     func() time.Time
+}
+
+// New creates a limiter that allows the given rate of requests per second, and burst, from each source
+// address, and locks out addresses for the lockout duration after maxFailures consecutive authentication
+// failures. A rate of 0 disables rate limiting, and a maxFailures of 0 disables lockout.
+func New(requestRate float64, burst, maxFailures int, lockout time.Duration) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		Rate:        rate.Limit(requestRate),
+		Burst:       burst,
+		MaxFailures: maxFailures,
+		Lockout:     lockout,
+		clients:     map[string]*client{},
+		now:         time.Now,
+	}
+}
+
+// Allow returns true if a request from the address is allowed. If it is not, the time after which the
+// client may retry is returned.
+func (l *Limiter) Allow(addr string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	c := l.client(addr, now)
+	if now.Before(c.lockedUntil) {
+		return false, c.lockedUntil.Sub(now)
+	}
+	if c.limiter != nil {
+		reservation := c.limiter.ReserveN(now, 1)
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			reservation.CancelAt(now)
+			return false, delay
+		}
+	}
+	return true, 0
+}
+
+// Failure records an authentication failure from the address, and returns true if the address is now
+// locked out.
+func (l *Limiter) Failure(addr string) bool {
+	if l.MaxFailures <= 0 {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	c := l.client(addr, now)
+	c.failures++
+	if c.failures < l.MaxFailures {
+		return false
+	}
+	c.failures = 0
+	c.lockedUntil = now.Add(l.Lockout)
+	return true
+}
+
+// Success records a successful authentication from the address, resetting its failure count.
+func (l *Limiter) Success(addr string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if c, ok := l.clients[addr]; ok {
+		c.failures = 0
+	}
+}
+
+// client returns the state for the address, creating it if necessary. The lock must be held.
+func (l *Limiter) client(addr string, now time.Time) *client {
+	if now.Sub(l.lastGC) > idleTimeout {
+		for key, c := range l.clients {
+			if now.Sub(c.lastSeen) > idleTimeout && now.After(c.lockedUntil) {
+				delete(l.clients, key)
+			}
+		}
+		l.lastGC = now
+	}
+	c, ok := l.clients[addr]
+	if !ok {
+		c = &client{}
+		if l.Rate > 0 {
+			c.limiter = rate.NewLimiter(l.Rate, l.Burst)
+		}
+		l.clients[addr] = c
+	}
+	c.lastSeen = now
+	return c
+}
+
+// Middleware limits requests to the handler, and records authentication failures when the handler
+// responds with 401 Unauthorized. Requests that are authenticated but forbidden, such as those from a node
+// with a mismatched node password, are logged but do not count towards a lockout, as they are not attempts
+// to guess a token. Rejected and failed requests are logged, so that attempts to guess a token can be
+// identified.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		addr := sourceAddress(req)
+		if addr == nil || addr.IsLoopback() {
+			next.ServeHTTP(rw, req)
+			return
+		}
+
+		key := addr.String()
+		nodeName := req.Header.Get(version.Program + "-Node-Name")
+		if ok, retryAfter := l.Allow(key); !ok {
+			logrus.Warnf("Audit: rejected %s %s from %s (node %q): too many requests", req.Method, req.URL.Path, key, nodeName)
+			rw.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second)/time.Second)+1))
+			http.Error(rw, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		srw := &statusResponseWriter{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(srw, req)
+		switch srw.status {
+		case http.StatusUnauthorized:
+			logrus.Warnf("Audit: authentication failed for %s %s from %s (node %q)", req.Method, req.URL.Path, key, nodeName)
+			if l.Failure(key) {
+				logrus.Warnf("Audit: locked out %s for %s after %d authentication failures", key, l.Lockout, l.MaxFailures)
+			}
+		case http.StatusForbidden:
+			logrus.Warnf("Audit: forbidden %s %s from %s (node %q)", req.Method, req.URL.Path, key, nodeName)
+		default:
+			if srw.status < http.StatusBadRequest {
+				logrus.Debugf("Audit: %s %s from %s (node %q) completed with status %d", req.Method, req.URL.Path, key, nodeName, srw.status)
+				l.Success(key)
+			}
+		}
+	})
+}
+
+// sourceAddress returns the IP address that the request was received from. Forwarding headers are not
+// trusted, as the supervisor is not expected to be behind a proxy that sets them.
+func sourceAddress(req *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// statusResponseWriter records the status code written to the response.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
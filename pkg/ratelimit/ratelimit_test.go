@@ -0,0 +1,148 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_UnitLimiterLockout(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxFailures int
+		failures    int
+		success     bool
+		advance     time.Duration
+		wantAllowed bool
+	}{
+		{
+			name:        "below failure limit",
+			maxFailures: 3,
+			failures:    2,
+			wantAllowed: true,
+		},
+		{
+			name:        "at failure limit",
+			maxFailures: 3,
+			failures:    3,
+			wantAllowed: false,
+		},
+		{
+			name:        "success resets failure count",
+			maxFailures: 3,
+			failures:    3,
+			success:     true,
+			wantAllowed: true,
+		},
+		{
+			name:        "lockout expires",
+			maxFailures: 3,
+			failures:    3,
+			advance:     6 * time.Minute,
+			wantAllowed: true,
+		},
+		{
+			name:        "lockout disabled",
+			maxFailures: 0,
+			failures:    10,
+			wantAllowed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			l := New(0, 1, tt.maxFailures, 5*time.Minute)
+			l.now = func() time.Time { return now }
+
+			addr := "192.0.2.1"
+			for i := 0; i < tt.failures; i++ {
+				if tt.success && i == tt.failures-1 {
+					l.Success(addr)
+				}
+				l.Failure(addr)
+			}
+			now = now.Add(tt.advance)
+			if allowed, _ := l.Allow(addr); allowed != tt.wantAllowed {
+				t.Errorf("Allow() = %v, want %v", allowed, tt.wantAllowed)
+			}
+			if allowed, _ := l.Allow("192.0.2.2"); !allowed {
+				t.Errorf("Allow() for other address = false, want true")
+			}
+		})
+	}
+}
+
+func Test_UnitLimiterRate(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := New(1, 2, 0, 0)
+	l.now = func() time.Time { return now }
+
+	addr := "192.0.2.1"
+	for i, want := range []bool{true, true, false} {
+		if allowed, _ := l.Allow(addr); allowed != want {
+			t.Errorf("Allow() request %d = %v, want %v", i, allowed, want)
+		}
+	}
+	now = now.Add(time.Second)
+	if allowed, _ := l.Allow(addr); !allowed {
+		t.Errorf("Allow() after refill = false, want true")
+	}
+}
+
+func Test_UnitLimiterMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		status     int
+		requests   int
+		wantStatus int
+	}{
+		{
+			name:       "locked out after repeated failures",
+			remoteAddr: "192.0.2.1:12345",
+			status:     http.StatusUnauthorized,
+			requests:   3,
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "forbidden requests are not locked out",
+			remoteAddr: "192.0.2.1:12345",
+			status:     http.StatusForbidden,
+			requests:   3,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "successful requests are not locked out",
+			remoteAddr: "192.0.2.1:12345",
+			status:     http.StatusOK,
+			requests:   3,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "loopback is not locked out",
+			remoteAddr: "127.0.0.1:12345",
+			status:     http.StatusUnauthorized,
+			requests:   3,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(0, 1, 2, time.Minute)
+			handler := l.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(tt.status)
+			}))
+			var rec *httptest.ResponseRecorder
+			for i := 0; i < tt.requests; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/v1-k3s/config", nil)
+				req.RemoteAddr = tt.remoteAddr
+				rec = httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
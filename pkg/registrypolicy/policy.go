@@ -0,0 +1,226 @@
+package registrypolicy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/containerd/containerd/reference/docker"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ModeDisabled = "disabled"
+	ModeAudit    = "audit"
+	ModeEnforce  = "enforce"
+)
+
+// Policy restricts the registries and repositories that pod images may be pulled from.
+// An image is allowed if it does not match any deny entry, and either the allow list
+// is empty, or it matches an allow entry. Pods in exempt namespaces are not checked;
+// kube-system is always exempt, so that a misconfigured policy or an unavailable webhook
+// cannot prevent the system components needed to recover the cluster from starting.
+type Policy struct {
+	Mode             string
+	Allow            []string
+	Deny             []string
+	ExemptNamespaces []string
+}
+
+// New returns a validated Policy. Entries must start with a registry host, and may
+// be followed by a repository path, for example "registry.example.com" or
+// "docker.io/library". The registry host may start with a "*." wildcard, to match
+// all subdomains.
+func New(mode string, allow, deny, exemptNamespaces []string) (*Policy, error) {
+	switch mode {
+	case "":
+		mode = ModeDisabled
+	case ModeDisabled, ModeAudit, ModeEnforce:
+	default:
+		return nil, fmt.Errorf("invalid registry policy mode %q; must be one of %s, %s or %s", mode, ModeDisabled, ModeAudit, ModeEnforce)
+	}
+	p := &Policy{Mode: mode, ExemptNamespaces: []string{metav1.NamespaceSystem}}
+	for _, ns := range exemptNamespaces {
+		if ns != metav1.NamespaceSystem {
+			p.ExemptNamespaces = append(p.ExemptNamespaces, ns)
+		}
+	}
+	var err error
+	if p.Allow, err = normalizeEntries(allow); err != nil {
+		return nil, err
+	}
+	if p.Deny, err = normalizeEntries(deny); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Enabled returns true if the policy is in audit or enforce mode.
+func (p *Policy) Enabled() bool {
+	return p.Mode == ModeAudit || p.Mode == ModeEnforce
+}
+
+// Check returns an error describing the violation if the image is not allowed by the policy.
+func (p *Policy) Check(image string) error {
+	ref, err := docker.ParseDockerRef(image)
+	if err != nil {
+		return errors.Wrapf(err, "invalid image reference %q", image)
+	}
+	name := ref.Name()
+	for _, entry := range p.Deny {
+		if matches(name, entry) {
+			return fmt.Errorf("image %q is from denied registry or repository %q", image, entry)
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for _, entry := range p.Allow {
+		if matches(name, entry) {
+			return nil
+		}
+	}
+	return fmt.Errorf("image %q is not from an allowed registry or repository", image)
+}
+
+// Review returns the admission response for a pod create or update request. On update,
+// only images that were not already used by the pod are checked, so that pods created
+// before the policy was configured can still be updated.
+func (p *Policy) Review(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	resp := &admissionv1.AdmissionResponse{UID: req.UID, Allowed: true}
+	if !p.Enabled() || p.exempt(req.Namespace) {
+		return resp
+	}
+
+	pod := &corev1.Pod{}
+	if err := json.Unmarshal(req.Object.Raw, pod); err != nil {
+		resp.Allowed = false
+		resp.Result = &metav1.Status{Code: http.StatusBadRequest, Message: err.Error()}
+		return resp
+	}
+	existing := map[string]bool{}
+	if len(req.OldObject.Raw) > 0 {
+		oldPod := &corev1.Pod{}
+		if err := json.Unmarshal(req.OldObject.Raw, oldPod); err == nil {
+			for _, image := range podImages(oldPod) {
+				existing[image] = true
+			}
+		}
+	}
+
+	violations := []string{}
+	for _, image := range podImages(pod) {
+		if existing[image] {
+			continue
+		}
+		if err := p.Check(image); err != nil {
+			violations = append(violations, err.Error())
+		}
+	}
+	if len(violations) == 0 {
+		return resp
+	}
+
+	name := pod.Name
+	if name == "" {
+		name = pod.GenerateName
+	}
+	message := strings.Join(violations, "; ")
+	if p.Mode == ModeEnforce {
+		logrus.Infof("Registry policy denied pod %s/%s: %s", req.Namespace, name, message)
+		resp.Allowed = false
+		resp.Result = &metav1.Status{Code: http.StatusForbidden, Reason: metav1.StatusReasonForbidden, Message: "registry policy: " + message}
+		return resp
+	}
+	logrus.Infof("Registry policy violation by pod %s/%s (audit mode): %s", req.Namespace, name, message)
+	resp.Warnings = violations
+	resp.AuditAnnotations = map[string]string{"violation": message}
+	return resp
+}
+
+// ServeHTTP handles AdmissionReview requests from the apiserver.
+func (p *Policy) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		resp.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	review := &admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(req.Body).Decode(review); err != nil || review.Request == nil {
+		http.Error(resp, "invalid AdmissionReview", http.StatusBadRequest)
+		return
+	}
+	review.Response = p.Review(review.Request)
+	review.Request = nil
+	resp.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(resp).Encode(review); err != nil {
+		logrus.Errorf("Failed to write registry policy admission response: %v", err)
+	}
+}
+
+func (p *Policy) exempt(namespace string) bool {
+	for _, ns := range p.ExemptNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// podImages returns the images used by all containers in the pod.
+func podImages(pod *corev1.Pod) []string {
+	images := []string{}
+	for _, c := range pod.Spec.InitContainers {
+		images = append(images, c.Image)
+	}
+	for _, c := range pod.Spec.Containers {
+		images = append(images, c.Image)
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		images = append(images, c.Image)
+	}
+	return images
+}
+
+// matches returns true if the normalized image name is in the registry or repository
+// identified by the entry.
+func matches(name, entry string) bool {
+	if domain, ok := strings.CutPrefix(entry, "*."); ok {
+		domain, path, _ := strings.Cut(domain, "/")
+		imageDomain, imagePath, _ := strings.Cut(name, "/")
+		if !strings.HasSuffix(imageDomain, "."+domain) {
+			return false
+		}
+		return path == "" || imagePath == path || strings.HasPrefix(imagePath, path+"/")
+	}
+	return name == entry || strings.HasPrefix(name, entry+"/")
+}
+
+func normalizeEntries(entries []string) ([]string, error) {
+	result := []string{}
+	for _, entry := range entries {
+		entry = strings.TrimSuffix(strings.TrimSpace(entry), "/")
+		if entry == "" {
+			continue
+		}
+		domain, path, _ := strings.Cut(entry, "/")
+		domain = strings.ToLower(domain)
+		if domain != "localhost" && !strings.ContainsAny(domain, ".:") {
+			return nil, fmt.Errorf("invalid registry policy entry %q: must start with a registry host", entry)
+		}
+		if domain == "index.docker.io" {
+			domain = "docker.io"
+		}
+		if path != "" {
+			entry = domain + "/" + path
+		} else {
+			entry = domain
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
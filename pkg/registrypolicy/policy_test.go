@@ -0,0 +1,167 @@
+package registrypolicy
+
+import (
+	"encoding/json"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func Test_UnitNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		allow   []string
+		wantErr bool
+	}{
+		{
+			name: "default mode",
+		},
+		{
+			name:    "invalid mode",
+			mode:    "warn",
+			wantErr: true,
+		},
+		{
+			name:  "valid entries",
+			mode:  ModeEnforce,
+			allow: []string{"docker.io/library", "*.example.com", "localhost/app", "10.0.0.1:5000"},
+		},
+		{
+			name:    "entry without registry host",
+			mode:    ModeEnforce,
+			allow:   []string{"library/nginx"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.mode, tt.allow, nil, nil); (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_UnitCheck(t *testing.T) {
+	p, err := New(ModeEnforce,
+		[]string{"docker.io/library", "Registry.Example.com/team/", "*.mirror.example.net", "index.docker.io/rancher"},
+		[]string{"docker.io/library/busybox", "registry.example.com/team/untrusted"},
+		nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		image   string
+		allowed bool
+	}{
+		{image: "nginx", allowed: true},
+		{image: "nginx:1.25", allowed: true},
+		{image: "docker.io/library/nginx@sha256:0000000000000000000000000000000000000000000000000000000000000000", allowed: true},
+		{image: "rancher/mirrored-pause:3.6", allowed: true},
+		{image: "busybox", allowed: false},
+		{image: "attacker/nginx", allowed: false},
+		{image: "registry.example.com/team/app:v1", allowed: true},
+		{image: "registry.example.com/team/untrusted/app", allowed: false},
+		{image: "registry.example.com/teammate/app", allowed: false},
+		{image: "registry.example.com.evil.io/team/app", allowed: false},
+		{image: "eu.mirror.example.net/app", allowed: true},
+		{image: "mirror.example.net/app", allowed: false},
+		{image: "Invalid Image", allowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if err := p.Check(tt.image); (err == nil) != tt.allowed {
+				t.Errorf("Check(%q) error = %v, want allowed %v", tt.image, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func Test_UnitReview(t *testing.T) {
+	pod := func(images ...string) runtime.RawExtension {
+		p := &corev1.Pod{}
+		for _, image := range images {
+			p.Spec.Containers = append(p.Spec.Containers, corev1.Container{Image: image})
+		}
+		raw, _ := json.Marshal(p)
+		return runtime.RawExtension{Raw: raw}
+	}
+	tests := []struct {
+		name         string
+		mode         string
+		namespace    string
+		object       runtime.RawExtension
+		oldObject    runtime.RawExtension
+		wantAllowed  bool
+		wantWarnings int
+	}{
+		{
+			name:        "allowed image",
+			mode:        ModeEnforce,
+			object:      pod("registry.example.com/app"),
+			wantAllowed: true,
+		},
+		{
+			name:        "denied image",
+			mode:        ModeEnforce,
+			object:      pod("registry.example.com/app", "docker.io/app"),
+			wantAllowed: false,
+		},
+		{
+			name:         "audit mode",
+			mode:         ModeAudit,
+			object:       pod("docker.io/app", "quay.io/app"),
+			wantAllowed:  true,
+			wantWarnings: 2,
+		},
+		{
+			name:        "disabled",
+			mode:        ModeDisabled,
+			object:      pod("docker.io/app"),
+			wantAllowed: true,
+		},
+		{
+			name:        "exempt namespace",
+			mode:        ModeEnforce,
+			namespace:   "kube-system",
+			object:      pod("docker.io/app"),
+			wantAllowed: true,
+		},
+		{
+			name:        "configured exempt namespace",
+			mode:        ModeEnforce,
+			namespace:   "ci",
+			object:      pod("docker.io/app"),
+			wantAllowed: true,
+		},
+		{
+			name:        "update with existing image",
+			mode:        ModeEnforce,
+			object:      pod("docker.io/app"),
+			oldObject:   pod("docker.io/app"),
+			wantAllowed: true,
+		},
+		{
+			name:        "update with new image",
+			mode:        ModeEnforce,
+			object:      pod("docker.io/app:v2"),
+			oldObject:   pod("docker.io/app"),
+			wantAllowed: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.mode, []string{"registry.example.com"}, nil, []string{"ci"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp := p.Review(&admissionv1.AdmissionRequest{Namespace: tt.namespace, Object: tt.object, OldObject: tt.oldObject})
+			if resp.Allowed != tt.wantAllowed || len(resp.Warnings) != tt.wantWarnings {
+				t.Errorf("Review() allowed = %v warnings = %v, want %v with %d warnings", resp.Allowed, resp.Warnings, tt.wantAllowed, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -5,154 +5,257 @@ package rootlessports
 
 import (
 	"context"
+	"sync"
 	"time"
 
+	"github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/rootless"
+	"github.com/pkg/errors"
 	coreClients "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	"github.com/rootless-containers/rootlesskit/pkg/api/client"
 	"github.com/rootless-containers/rootlesskit/pkg/port"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 )
 
 var (
 	all = "_all_"
 )
 
-func Register(ctx context.Context, serviceController coreClients.ServiceController, enabled bool, httpsPort int) error {
-	var (
-		err            error
-		rootlessClient client.Client
-	)
+// portKey identifies a port bound in the parent network namespace.
+type portKey struct {
+	proto string
+	port  int
+}
 
+// Register forwards ports from the parent network namespace of a rootless server to the child namespace,
+// updating them as services change. The ports forwarded are the supervisor port, the ports used by flannel to
+// reach other nodes, the node ports of all services, and the ports of load-balancer services if enabled.
+func Register(ctx context.Context, serviceController coreClients.ServiceController, enabled bool, httpsPort int, flannelBackend string) error {
 	if rootless.Sock == "" {
 		return nil
 	}
 
-	for i := 0; i < 30; i++ {
-		rootlessClient, err = client.New(rootless.Sock)
-		if err == nil {
-			break
-		} else {
-			logrus.Infof("Waiting for rootless API socket %s: %v", rootless.Sock, err)
-			time.Sleep(1 * time.Second)
+	rootlessClient, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	staticPorts := flannelPorts(flannelBackend)
+	staticPorts[portKey{proto: "tcp", port: httpsPort}] = httpsPort
+	h := &handler{
+		enabled:        enabled,
+		rootlessClient: rootlessClient,
+		staticPorts:    staticPorts,
+		listServices: func() ([]*v1.Service, error) {
+			return serviceController.Cache().List("", labels.Everything())
+		},
+		ctx: ctx,
+	}
+	serviceController.OnChange(ctx, "rootlessports", func(key string, svc *v1.Service) (*v1.Service, error) {
+		if key != all {
+			serviceController.Enqueue("", all)
+			return svc, nil
 		}
+		return svc, h.sync()
+	})
+	serviceController.Enqueue("", all)
+
+	return nil
+}
+
+// RegisterAgent forwards ports from the parent network namespace of a rootless agent to the child namespace, so
+// that other nodes can reach the node's pods through flannel, and its node ports and load-balancer services
+// can be reached from outside the node.
+func RegisterAgent(ctx context.Context, k8s kubernetes.Interface, flannelBackend string) error {
+	if rootless.Sock == "" {
+		return nil
 	}
+
+	rootlessClient, err := newClient()
 	if err != nil {
 		return err
 	}
 
 	h := &handler{
-		enabled:        enabled,
+		enabled:        true,
 		rootlessClient: rootlessClient,
-		serviceClient:  serviceController,
-		serviceCache:   serviceController.Cache(),
-		httpsPort:      httpsPort,
+		staticPorts:    flannelPorts(flannelBackend),
 		ctx:            ctx,
 	}
-	serviceController.OnChange(ctx, "rootlessports", h.serviceChanged)
-	serviceController.Enqueue("", all)
 
+	// The informer resyncs periodically, so that ports that failed to bind are retried.
+	informerFactory := informers.NewSharedInformerFactory(k8s, time.Minute)
+	serviceInformer := informerFactory.Core().V1().Services()
+	h.listServices = func() ([]*v1.Service, error) {
+		return serviceInformer.Lister().List(labels.Everything())
+	}
+	onChange := func() {
+		if err := h.sync(); err != nil {
+			logrus.Errorf("Failed to update rootless port forwarding: %v", err)
+		}
+	}
+	serviceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { onChange() },
+		UpdateFunc: func(interface{}, interface{}) { onChange() },
+		DeleteFunc: func(interface{}) { onChange() },
+	})
+	informerFactory.Start(ctx.Done())
 	return nil
 }
 
+func newClient() (client.Client, error) {
+	var (
+		err            error
+		rootlessClient client.Client
+	)
+	for i := 0; i < 30; i++ {
+		rootlessClient, err = client.New(rootless.Sock)
+		if err == nil {
+			break
+		} else {
+			logrus.Infof("Waiting for rootless API socket %s: %v", rootless.Sock, err)
+			time.Sleep(1 * time.Second)
+		}
+	}
+	return rootlessClient, err
+}
+
+// flannelPorts returns the ports used by the flannel backend to send traffic between nodes. Other nodes reach
+// these ports at the address of the host, so nodes must use their host address as the flannel external address.
+func flannelPorts(backend string) map[portKey]int {
+	ports := map[portKey]int{}
+	switch backend {
+	case config.FlannelBackendVXLAN:
+		ports[portKey{proto: "udp", port: 8472}] = 8472
+	case config.FlannelBackendWireguardNative:
+		ports[portKey{proto: "udp", port: 51820}] = 51820
+		ports[portKey{proto: "udp", port: 51821}] = 51821
+	}
+	return ports
+}
+
 type handler struct {
+	mu             sync.Mutex
 	enabled        bool
 	rootlessClient client.Client
-	serviceClient  coreClients.ServiceController
-	serviceCache   coreClients.ServiceCache
-	httpsPort      int
+	staticPorts    map[portKey]int
+	listServices   func() ([]*v1.Service, error)
 	ctx            context.Context
 }
 
-func (h *handler) serviceChanged(key string, svc *v1.Service) (*v1.Service, error) {
-	if key != all {
-		h.serviceClient.Enqueue("", all)
-		return svc, nil
-	}
+// sync binds the ports that should be forwarded, and removes any other bound ports.
+func (h *handler) sync() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
 	ports, err := h.rootlessClient.PortManager().ListPorts(h.ctx)
 	if err != nil {
-		return svc, err
+		return err
 	}
 
-	boundPorts := map[int]int{}
+	boundPorts := map[portKey]int{}
 	for _, port := range ports {
-		boundPorts[port.Spec.ParentPort] = port.ID
+		boundPorts[portKey{proto: port.Spec.Proto, port: port.Spec.ParentPort}] = port.ID
 	}
 
-	toBindPort, err := h.toBindPorts()
+	svcs, err := h.listServices()
 	if err != nil {
-		return svc, err
+		return err
 	}
 
-	for bindPort, childBindPort := range toBindPort {
+	// A port that fails to bind, for example because it is in use on the host, does not prevent other ports
+	// from being bound.
+	var errs []error
+	for bindPort, childBindPort := range toBindPorts(svcs, h.staticPorts, h.enabled) {
 		if _, ok := boundPorts[bindPort]; ok {
-			logrus.Debugf("Parent port %d to child already bound", bindPort)
+			logrus.Debugf("Parent port %s/%d to child already bound", bindPort.proto, bindPort.port)
 			delete(boundPorts, bindPort)
 			continue
 		}
 
 		status, err := h.rootlessClient.PortManager().AddPort(h.ctx, port.Spec{
-			Proto:      "tcp",
-			ParentPort: bindPort,
+			Proto:      bindPort.proto,
+			ParentPort: bindPort.port,
 			ChildPort:  childBindPort,
 		})
 		if err != nil {
-			return svc, err
+			errs = append(errs, errors.Wrapf(err, "failed to bind parent port %d/%s", bindPort.port, bindPort.proto))
+			continue
 		}
 
-		logrus.Infof("Bound parent port %s:%d to child namespace port %d", status.Spec.ParentIP,
-			status.Spec.ParentPort, status.Spec.ChildPort)
+		logrus.Infof("Bound parent port %s:%d/%s to child namespace port %d", status.Spec.ParentIP,
+			status.Spec.ParentPort, status.Spec.Proto, status.Spec.ChildPort)
 	}
 
 	for bindPort, id := range boundPorts {
 		if err := h.rootlessClient.PortManager().RemovePort(h.ctx, id); err != nil {
-			return svc, err
+			return err
 		}
 
-		logrus.Infof("Removed parent port %d to child namespace", bindPort)
+		logrus.Infof("Removed parent port %d/%s to child namespace", bindPort.port, bindPort.proto)
 	}
 
-	return svc, nil
+	return utilerrors.NewAggregate(errs)
 }
 
-func (h *handler) toBindPorts() (map[int]int, error) {
-	svcs, err := h.serviceCache.List("", labels.Everything())
-	if err != nil {
-		return nil, err
+// toBindPorts returns the parent ports that should be bound, and the child port that each is forwarded to. The
+// node ports of all services are forwarded to the same port. The ports of load-balancer services with an ingress
+// address are forwarded if enabled; privileged ports cannot be bound by a rootless parent, and are instead
+// bound at 10000 above the port.
+func toBindPorts(svcs []*v1.Service, staticPorts map[portKey]int, enabled bool) map[portKey]int {
+	toBindPorts := map[portKey]int{}
+	for key, childPort := range staticPorts {
+		toBindPorts[key] = childPort
 	}
 
-	toBindPorts := map[int]int{
-		h.httpsPort: h.httpsPort,
-	}
+	for _, svc := range svcs {
+		for _, port := range svc.Spec.Ports {
+			if proto := protocol(port.Protocol); proto != "" && port.NodePort != 0 {
+				toBindPorts[portKey{proto: proto, port: int(port.NodePort)}] = int(port.NodePort)
+			}
+		}
 
-	if !h.enabled {
-		return toBindPorts, nil
-	}
+		if !enabled {
+			continue
+		}
 
-	for _, svc := range svcs {
 		for _, ingress := range svc.Status.LoadBalancer.Ingress {
 			if ingress.IP == "" {
 				continue
 			}
 
 			for _, port := range svc.Spec.Ports {
-				if port.Protocol != v1.ProtocolTCP {
+				proto := protocol(port.Protocol)
+				if proto == "" || port.Port == 0 {
 					continue
 				}
 
-				if port.Port != 0 {
-					if port.Port <= 1024 {
-						toBindPorts[10000+int(port.Port)] = int(port.Port)
-					} else {
-						toBindPorts[int(port.Port)] = int(port.Port)
-					}
+				if port.Port <= 1024 {
+					toBindPorts[portKey{proto: proto, port: 10000 + int(port.Port)}] = int(port.Port)
+				} else {
+					toBindPorts[portKey{proto: proto, port: int(port.Port)}] = int(port.Port)
 				}
 			}
 		}
 	}
 
-	return toBindPorts, nil
+	return toBindPorts
+}
+
+// protocol returns the rootlesskit protocol for a service port protocol, or an empty string if it cannot be
+// forwarded.
+func protocol(proto v1.Protocol) string {
+	switch proto {
+	case v1.ProtocolTCP, "":
+		return "tcp"
+	case v1.ProtocolUDP:
+		return "udp"
+	}
+	return ""
 }
@@ -4,8 +4,13 @@ import (
 	"context"
 
 	coreClients "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
-func Register(ctx context.Context, serviceController coreClients.ServiceController, enabled bool, httpsPort int) error {
+func Register(ctx context.Context, serviceController coreClients.ServiceController, enabled bool, httpsPort int, flannelBackend string) error {
+	panic("Rootless is not supported on windows")
+}
+
+func RegisterAgent(ctx context.Context, k8s kubernetes.Interface, flannelBackend string) error {
 	panic("Rootless is not supported on windows")
 }
@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/sirupsen/logrus"
@@ -27,7 +29,69 @@ const (
 
 var EncryptionHashAnnotation = version.Program + ".io/encryption-config-hash"
 
+// DefaultEncryptionResources is the list of resources that are encrypted at rest if no other resources are configured.
+var DefaultEncryptionResources = []string{"secrets"}
+
+// ParseEncryptionResources validates and normalizes a list of resources to encrypt at rest. Resources are specified
+// by plural name, optionally followed by the API group for resources outside the core group, for example
+// "configmaps" or "widgets.example.com". Secrets are always encrypted, and are added to the list if not present.
+func ParseEncryptionResources(resources []string) ([]string, error) {
+	result := append([]string{}, DefaultEncryptionResources...)
+	seen := map[string]bool{}
+	for _, r := range result {
+		seen[r] = true
+	}
+	for _, r := range resources {
+		for _, r := range strings.Split(r, ",") {
+			r = strings.ToLower(strings.TrimSpace(r))
+			if r == "" || seen[r] {
+				continue
+			}
+			if strings.Contains(r, "*") {
+				return nil, fmt.Errorf("invalid secrets encryption resource %q: wildcards are not supported", r)
+			}
+			if strings.ContainsAny(r, "/ ") || strings.HasPrefix(r, ".") || strings.HasSuffix(r, ".") {
+				return nil, fmt.Errorf("invalid secrets encryption resource %q: must be in the form resource or resource.group", r)
+			}
+			seen[r] = true
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
 func GetEncryptionProviders(runtime *config.ControlRuntime) ([]apiserverconfigv1.ProviderConfiguration, error) {
+	curEncryption, err := readEncryptionConfig(runtime)
+	if err != nil {
+		return nil, err
+	}
+	return curEncryption.Resources[0].Providers, nil
+}
+
+// GetEncryptionResources returns the list of resources that are encrypted at rest by the current encryption config.
+func GetEncryptionResources(runtime *config.ControlRuntime) ([]string, error) {
+	curEncryption, err := readEncryptionConfig(runtime)
+	if err != nil {
+		return nil, err
+	}
+	return curEncryption.Resources[0].Resources, nil
+}
+
+// GetDecryptOnlyResources returns the list of resources that were previously encrypted at rest, but have since been
+// removed from the list of encrypted resources. Existing data for these resources may still be encrypted, so the
+// encryption config retains the keys needed to read it until it has been reencrypted.
+func GetDecryptOnlyResources(runtime *config.ControlRuntime) ([]string, error) {
+	curEncryption, err := readEncryptionConfig(runtime)
+	if err != nil {
+		return nil, err
+	}
+	if len(curEncryption.Resources) < 2 {
+		return nil, nil
+	}
+	return curEncryption.Resources[1].Resources, nil
+}
+
+func readEncryptionConfig(runtime *config.ControlRuntime) (*apiserverconfigv1.EncryptionConfiguration, error) {
 	curEncryptionByte, err := os.ReadFile(runtime.EncryptionConfig)
 	if err != nil {
 		return nil, err
@@ -37,17 +101,22 @@ func GetEncryptionProviders(runtime *config.ControlRuntime) ([]apiserverconfigv1
 	if err = json.Unmarshal(curEncryptionByte, &curEncryption); err != nil {
 		return nil, err
 	}
-	return curEncryption.Resources[0].Providers, nil
+	if len(curEncryption.Resources) == 0 || len(curEncryption.Resources) > 2 {
+		return nil, fmt.Errorf("unknown secrets encryption configuration")
+	}
+	return &curEncryption, nil
 }
 
+// GetEncryptionKeys returns the locally stored AES-CBC keys from the current encryption config. The keys are
+// returned in order, with the key used to encrypt new data first.
 func GetEncryptionKeys(runtime *config.ControlRuntime) ([]apiserverconfigv1.Key, error) {
 
 	providers, err := GetEncryptionProviders(runtime)
 	if err != nil {
 		return nil, err
 	}
-	if len(providers) > 2 {
-		return nil, fmt.Errorf("more than 2 providers (%d) found in secrets encryption", len(providers))
+	if err := validateProviders(providers); err != nil {
+		return nil, err
 	}
 
 	var curKeys []apiserverconfigv1.Key
@@ -55,39 +124,123 @@ func GetEncryptionKeys(runtime *config.ControlRuntime) ([]apiserverconfigv1.Key,
 		if p.AESCBC != nil {
 			curKeys = append(curKeys, p.AESCBC.Keys...)
 		}
-		if p.AESGCM != nil || p.KMS != nil || p.Secretbox != nil {
-			return nil, fmt.Errorf("non-standard encryption keys found")
-		}
 	}
 	return curKeys, nil
 }
 
-func WriteEncryptionConfig(runtime *config.ControlRuntime, keys []apiserverconfigv1.Key, enable bool) error {
+// GetKMSProvider returns the KMS v2 provider from the current encryption config, or nil if the config only
+// contains locally stored keys.
+func GetKMSProvider(runtime *config.ControlRuntime) (*apiserverconfigv1.KMSConfiguration, error) {
+	providers, err := GetEncryptionProviders(runtime)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateProviders(providers); err != nil {
+		return nil, err
+	}
+	for _, p := range providers {
+		if p.KMS != nil {
+			return p.KMS, nil
+		}
+	}
+	return nil, nil
+}
+
+// IsEncryptionEnabled returns true if new data is encrypted by the providers, or false if the identity provider
+// is first and new data is stored unencrypted.
+func IsEncryptionEnabled(providers []apiserverconfigv1.ProviderConfiguration) (bool, error) {
+	if err := validateProviders(providers); err != nil {
+		return false, err
+	}
+	return providers[0].Identity == nil, nil
+}
+
+// validateProviders ensures that the providers are those written by WriteEncryptionConfig: an optional KMS v2
+// provider, optional local AES-CBC keys, and the identity provider, which must be either first or last.
+func validateProviders(providers []apiserverconfigv1.ProviderConfiguration) error {
+	if len(providers) > 3 {
+		return fmt.Errorf("more than 3 providers (%d) found in secrets encryption", len(providers))
+	}
+	var identity, aescbc, kms int
+	for i, p := range providers {
+		switch {
+		case p.Identity != nil:
+			if i != 0 && i != len(providers)-1 {
+				return fmt.Errorf("identity provider found at position %d in secrets encryption", i)
+			}
+			identity++
+		case p.AESCBC != nil:
+			aescbc++
+		case p.KMS != nil && p.KMS.APIVersion == KMSAPIVersion:
+			kms++
+		default:
+			return fmt.Errorf("non-standard encryption keys found")
+		}
+	}
+	if identity != 1 || aescbc > 1 || kms > 1 || aescbc+kms == 0 {
+		return fmt.Errorf("unknown secrets encryption configuration")
+	}
+	return nil
+}
+
+// WriteEncryptionConfig writes the encryption config, using the provided keys to encrypt the listed resources.
+// If a KMS provider is provided, it is used to encrypt new data, and the local keys are only used to decrypt
+// data that has not yet been reencrypted by the KMS provider. Resources that were encrypted by the current config
+// but are not listed are kept in the config as decrypt-only, so that data already stored in the datastore can still
+// be read; they are removed once all resources have been reencrypted.
+func WriteEncryptionConfig(runtime *config.ControlRuntime, resources []string, keys []apiserverconfigv1.Key, kms *apiserverconfigv1.KMSConfiguration, enable bool) error {
+	if len(resources) == 0 {
+		resources = DefaultEncryptionResources
+	}
+
+	var decryptOnly []string
+	if curEncryption, err := readEncryptionConfig(runtime); err == nil {
+		listed := map[string]bool{}
+		for _, r := range resources {
+			listed[r] = true
+		}
+		for _, rc := range curEncryption.Resources {
+			for _, r := range rc.Resources {
+				if !listed[r] {
+					listed[r] = true
+					decryptOnly = append(decryptOnly, r)
+				}
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return writeEncryptionConfig(runtime, resources, decryptOnly, keys, kms, enable)
+}
+
+// writeEncryptionConfig writes the encryption config, with the decrypt-only resources stored unencrypted but
+// readable with any of the provided keys.
+func writeEncryptionConfig(runtime *config.ControlRuntime, resources, decryptOnly []string, keys []apiserverconfigv1.Key, kms *apiserverconfigv1.KMSConfiguration, enable bool) error {
+	var encrypting []apiserverconfigv1.ProviderConfiguration
+	if kms != nil {
+		encrypting = append(encrypting, apiserverconfigv1.ProviderConfiguration{KMS: kms})
+	}
+	if len(keys) > 0 {
+		encrypting = append(encrypting, apiserverconfigv1.ProviderConfiguration{
+			AESCBC: &apiserverconfigv1.AESConfiguration{
+				Keys: keys,
+			},
+		})
+	}
+	if len(encrypting) == 0 {
+		return fmt.Errorf("no secrets encryption keys or KMS provider found")
+	}
+	identity := apiserverconfigv1.ProviderConfiguration{
+		Identity: &apiserverconfigv1.IdentityConfiguration{},
+	}
 
 	// Placing the identity provider first disables encryption
+	disabled := append([]apiserverconfigv1.ProviderConfiguration{identity}, encrypting...)
 	var providers []apiserverconfigv1.ProviderConfiguration
 	if enable {
-		providers = []apiserverconfigv1.ProviderConfiguration{
-			{
-				AESCBC: &apiserverconfigv1.AESConfiguration{
-					Keys: keys,
-				},
-			},
-			{
-				Identity: &apiserverconfigv1.IdentityConfiguration{},
-			},
-		}
+		providers = append(encrypting, identity)
 	} else {
-		providers = []apiserverconfigv1.ProviderConfiguration{
-			{
-				Identity: &apiserverconfigv1.IdentityConfiguration{},
-			},
-			{
-				AESCBC: &apiserverconfigv1.AESConfiguration{
-					Keys: keys,
-				},
-			},
-		}
+		providers = disabled
 	}
 
 	encConfig := apiserverconfigv1.EncryptionConfiguration{
@@ -97,11 +250,17 @@ func WriteEncryptionConfig(runtime *config.ControlRuntime, keys []apiserverconfi
 		},
 		Resources: []apiserverconfigv1.ResourceConfiguration{
 			{
-				Resources: []string{"secrets"},
+				Resources: resources,
 				Providers: providers,
 			},
 		},
 	}
+	if len(decryptOnly) > 0 {
+		encConfig.Resources = append(encConfig.Resources, apiserverconfigv1.ResourceConfiguration{
+			Resources: decryptOnly,
+			Providers: disabled,
+		})
+	}
 	jsonfile, err := json.Marshal(encConfig)
 	if err != nil {
 		return err
@@ -8,16 +8,22 @@ import (
 	"github.com/k3s-io/k3s/pkg/cluster"
 	"github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/util"
+	"github.com/pkg/errors"
 	coreclient "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/pager"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
@@ -36,7 +42,9 @@ type handler struct {
 	ctx           context.Context
 	controlConfig *config.Control
 	nodes         coreclient.NodeController
-	secrets       coreclient.SecretController
+	dynamic       dynamic.Interface
+	mapper        *restmapper.DeferredDiscoveryRESTMapper
+	configMaps    coreclient.ConfigMapClient
 	recorder      record.EventRecorder
 }
 
@@ -45,13 +53,16 @@ func Register(
 	k8s kubernetes.Interface,
 	controlConfig *config.Control,
 	nodes coreclient.NodeController,
-	secrets coreclient.SecretController,
+	dynamic dynamic.Interface,
+	configMaps coreclient.ConfigMapClient,
 ) error {
 	h := &handler{
 		ctx:           ctx,
 		controlConfig: controlConfig,
 		nodes:         nodes,
-		secrets:       secrets,
+		dynamic:       dynamic,
+		mapper:        restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(k8s.Discovery())),
+		configMaps:    configMaps,
 		recorder:      util.BuildControllerEventRecorder(k8s, controllerAgentName, metav1.NamespaceDefault),
 	}
 
@@ -107,7 +118,7 @@ func (h *handler) onChangeNode(nodeName string, node *corev1.Node) (*corev1.Node
 		return node, err
 	}
 
-	if err := h.updateSecrets(node); err != nil {
+	if err := h.updateResources(node); err != nil {
 		h.recorder.Event(nodeRef, corev1.EventTypeWarning, secretsUpdateErrorEvent, err.Error())
 		return node, err
 	}
@@ -133,12 +144,32 @@ func (h *handler) onChangeNode(nodeName string, node *corev1.Node) (*corev1.Node
 		return node, err
 	}
 
-	curKeys = curKeys[:len(curKeys)-1]
-	if err = WriteEncryptionConfig(h.controlConfig.Runtime, curKeys, true); err != nil {
+	// Only the resources that were reencrypted may be written without the last key.
+	curResources, err := GetEncryptionResources(h.controlConfig.Runtime)
+	if err != nil {
+		h.recorder.Event(nodeRef, corev1.EventTypeWarning, secretsUpdateErrorEvent, err.Error())
+		return node, err
+	}
+
+	// Once all resources have been reencrypted by a KMS provider, none of the local keys are needed.
+	curKMS, err := GetKMSProvider(h.controlConfig.Runtime)
+	if err != nil {
+		h.recorder.Event(nodeRef, corev1.EventTypeWarning, secretsUpdateErrorEvent, err.Error())
+		return node, err
+	}
+	removedKeys := curKeys
+	if curKMS == nil {
+		removedKeys = curKeys[len(curKeys)-1:]
+	}
+	curKeys = curKeys[:len(curKeys)-len(removedKeys)]
+	// Decrypt-only resources have been rewritten unencrypted, so they no longer need to be listed.
+	if err = writeEncryptionConfig(h.controlConfig.Runtime, curResources, nil, curKeys, curKMS, true); err != nil {
 		h.recorder.Event(nodeRef, corev1.EventTypeWarning, secretsUpdateErrorEvent, err.Error())
 		return node, err
 	}
-	logrus.Infoln("Removed key: ", curKeys[len(curKeys)-1])
+	for _, key := range removedKeys {
+		logrus.Infoln("Removed key: ", key.Name)
+	}
 	if err != nil {
 		h.recorder.Event(nodeRef, corev1.EventTypeWarning, secretsUpdateErrorEvent, err.Error())
 		return node, err
@@ -207,36 +238,82 @@ func (h *handler) validateReencryptStage(node *corev1.Node, annotation string) (
 	return true, nil
 }
 
-func (h *handler) updateSecrets(node *corev1.Node) error {
+// updateResources reencrypts all resources listed in the encryption config, by updating each resource without changes.
+// Decrypt-only resources are rewritten unencrypted. Resource types that are not served by the apiserver, such as custom resources whose definition has not been
+// installed, are skipped.
+func (h *handler) updateResources(node *corev1.Node) error {
 	nodeRef := &corev1.ObjectReference{
 		Kind:      "Node",
 		Name:      node.Name,
 		UID:       types.UID(node.Name),
 		Namespace: "",
 	}
-	secretPager := pager.New(pager.SimplePageFunc(func(opts metav1.ListOptions) (runtime.Object, error) {
-		return h.secrets.List("", opts)
-	}))
-	secretsList, _, err := secretPager.List(h.ctx, metav1.ListOptions{})
+	resources, err := GetEncryptionResources(h.controlConfig.Runtime)
 	if err != nil {
 		return err
 	}
+	decryptOnly, err := GetDecryptOnlyResources(h.controlConfig.Runtime)
+	if err != nil {
+		return err
+	}
+	resources = append(resources, decryptOnly...)
+	// Refresh discovery so that recently installed custom resources are found.
+	h.mapper.Reset()
+
+	var total, failed int
+	for _, resource := range resources {
+		count, failures, err := h.updateResource(nodeRef, node.Name, resource)
+		if err != nil {
+			return errors.Wrapf(err, "failed to reencrypt %s", resource)
+		}
+		total += count
+		failed += failures
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to reencrypt %d of %d resources", failed, total)
+	}
+	h.recorder.Eventf(nodeRef, corev1.EventTypeNormal, secretsUpdateCompleteEvent, "completed reencrypt of %d resources", total)
+	return nil
+}
+
+// updateResource reencrypts all resources of a single type, and returns the number of resources processed and the
+// number that could not be reencrypted.
+func (h *handler) updateResource(nodeRef *corev1.ObjectReference, nodeName, resource string) (int, int, error) {
+	gvr, err := h.mapper.ResourceFor(schema.ParseGroupResource(resource).WithVersion(""))
+	if meta.IsNoMatchError(err) {
+		logrus.Warnf("Skipping reencrypt of %s: resource is not served by the apiserver", resource)
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, err
+	}
+	client := h.dynamic.Resource(gvr)
+	resourcePager := pager.New(pager.SimplePageFunc(func(opts metav1.ListOptions) (runtime.Object, error) {
+		return client.List(h.ctx, opts)
+	}))
+	resourceList, _, err := resourcePager.List(h.ctx, metav1.ListOptions{})
+	if err != nil {
+		return 0, 0, err
+	}
+	// Failures are recorded and the remaining resources are still updated, so that all failures can be reported at once.
+	// The previous key is not removed if any resource could not be reencrypted.
+	tracker := newProgressTracker(h.configMaps, resource, nodeName, meta.LenList(resourceList))
 	i := 0
-	err = meta.EachListItem(secretsList, func(obj runtime.Object) error {
-		if secret, ok := obj.(*corev1.Secret); ok {
-			if _, err := h.secrets.Update(secret); err != nil && !apierrors.IsConflict(err) {
-				return fmt.Errorf("failed to update secret: %v", err)
+	err = meta.EachListItem(resourceList, func(obj runtime.Object) error {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			// A conflict indicates that the resource has been updated since it was listed, and has therefore already been reencrypted.
+			if _, err := client.Namespace(u.GetNamespace()).Update(h.ctx, u, metav1.UpdateOptions{}); err != nil && !apierrors.IsConflict(err) && !apierrors.IsNotFound(err) {
+				logrus.Warnf("Failed to reencrypt %s %s/%s: %v", resource, u.GetNamespace(), u.GetName(), err)
+				tracker.failed(u, err)
+			} else {
+				tracker.done()
 			}
 			if i != 0 && i%10 == 0 {
-				h.recorder.Eventf(nodeRef, corev1.EventTypeNormal, secretsProgressEvent, "reencrypted %d secrets", i)
+				h.recorder.Eventf(nodeRef, corev1.EventTypeNormal, secretsProgressEvent, "reencrypted %d %s", i, resource)
 			}
 			i++
 		}
 		return nil
 	})
-	if err != nil {
-		return err
-	}
-	h.recorder.Eventf(nodeRef, corev1.EventTypeNormal, secretsUpdateCompleteEvent, "completed reencrypt of %d secrets", i)
-	return nil
+	tracker.complete()
+	return i, tracker.progress.Failed, err
 }
@@ -0,0 +1,77 @@
+package secretsencrypt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/config/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// KMSAPIVersion is the only KMS plugin API version supported for secrets encryption. KMS v1 plugins are
+// deprecated, and cannot be used to encrypt data with a key that is not stored on disk.
+const KMSAPIVersion = "v2"
+
+// LoadKMSConfig reads the configuration of a KMS v2 plugin from a YAML or JSON file, for example:
+//
+//	name: vault
+//	endpoint: unix:///var/run/kms-plugin/vault.sock
+//	timeout: 5s
+//
+// An empty file path returns a nil configuration, and encryption keys are stored locally.
+func LoadKMSConfig(file string) (*apiserverconfigv1.KMSConfiguration, error) {
+	if file == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read KMS provider config")
+	}
+	kms := &apiserverconfigv1.KMSConfiguration{}
+	if err := yaml.UnmarshalStrict(b, kms); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse KMS provider config %s", file)
+	}
+	if kms.APIVersion == "" {
+		kms.APIVersion = KMSAPIVersion
+	}
+	if err := validateKMSConfig(kms); err != nil {
+		return nil, errors.Wrapf(err, "invalid KMS provider config %s", file)
+	}
+	return kms, nil
+}
+
+func validateKMSConfig(kms *apiserverconfigv1.KMSConfiguration) error {
+	if kms.APIVersion != KMSAPIVersion {
+		return fmt.Errorf("unsupported apiVersion %q; only %s plugins are supported", kms.APIVersion, KMSAPIVersion)
+	}
+	if kms.Name == "" {
+		return errors.New("name must be set")
+	}
+	// The name is stored in the prefix of each encrypted value, and is separated from the rest of the prefix by colons
+	if strings.Contains(kms.Name, ":") {
+		return fmt.Errorf("name %q must not contain ':'", kms.Name)
+	}
+	if !strings.HasPrefix(kms.Endpoint, "unix://") {
+		return fmt.Errorf("endpoint %q must be a unix socket, for example unix:///var/run/kms-plugin.sock", kms.Endpoint)
+	}
+	if kms.CacheSize != nil {
+		return errors.New("cachesize is not supported by KMS v2 plugins")
+	}
+	return nil
+}
+
+// SelectKMSProvider returns the KMS provider to use when secrets encryption is enabled. The provider in the
+// current encryption config is retained if none is configured on this server. Data encrypted by a KMS provider
+// can only be decrypted by a provider with the same name, so the name cannot be changed once it is in use,
+// although the endpoint and timeout may be.
+func SelectKMSProvider(current, configured *apiserverconfigv1.KMSConfiguration) (*apiserverconfigv1.KMSConfiguration, error) {
+	if configured == nil {
+		return current, nil
+	}
+	if current != nil && current.Name != configured.Name {
+		return nil, fmt.Errorf("KMS provider %s is in use, and cannot be replaced by KMS provider %s", current.Name, configured.Name)
+	}
+	return configured, nil
+}
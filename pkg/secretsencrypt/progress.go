@@ -0,0 +1,177 @@
+package secretsencrypt
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	coreclient "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	// maxRecordedFailures limits the number of failures recorded for each resource type, so that
+	// the progress ConfigMap does not grow unbounded if many resources fail.
+	maxRecordedFailures = 10
+
+	// progressSaveInterval is the number of resources processed between progress updates.
+	progressSaveInterval = 10
+)
+
+// ReencryptProgressConfigMapName is the name of the ConfigMap in the kube-system namespace that records
+// the progress of the most recent reencryption, so that it can be reported by any server.
+var ReencryptProgressConfigMapName = version.Program + "-reencrypt-progress"
+
+var reencryptResourcesGauge = metrics.NewGaugeVec(&metrics.GaugeOpts{
+	Name:           version.Program + "_secrets_reencrypt_resources",
+	StabilityLevel: metrics.ALPHA,
+	Help:           "Number of resources processed by the most recent secrets reencryption run on this server. 'resource' is the resource type, and 'state' is one of done, remaining, or failed.",
+}, []string{"resource", "state"})
+
+func init() {
+	legacyregistry.MustRegister(reencryptResourcesGauge)
+}
+
+// ReencryptProgress records the progress of reencrypting a resource type.
+type ReencryptProgress struct {
+	Resource  string             `json:"resource"`
+	Server    string             `json:"server"`
+	Done      int                `json:"done"`
+	Remaining int                `json:"remaining"`
+	Failed    int                `json:"failed"`
+	Failures  []ReencryptFailure `json:"failures,omitempty"`
+	Started   metav1.Time        `json:"started"`
+	Updated   metav1.Time        `json:"updated"`
+	Completed *metav1.Time       `json:"completed,omitempty"`
+}
+
+// ReencryptFailure records a resource that could not be reencrypted.
+type ReencryptFailure struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+}
+
+// GetReencryptProgress returns the progress of the most recent reencryption, sorted by resource type.
+func GetReencryptProgress(configMaps coreclient.ConfigMapClient) ([]ReencryptProgress, error) {
+	configMap, err := configMaps.Get(metav1.NamespaceSystem, ReencryptProgressConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	progress := []ReencryptProgress{}
+	for resource, data := range configMap.Data {
+		p := ReencryptProgress{}
+		if err := json.Unmarshal([]byte(data), &p); err != nil {
+			logrus.Warnf("Failed to unmarshal reencrypt progress for %s: %v", resource, err)
+			continue
+		}
+		progress = append(progress, p)
+	}
+	sort.Slice(progress, func(i, j int) bool {
+		return progress[i].Resource < progress[j].Resource
+	})
+	return progress, nil
+}
+
+// progressTracker tracks reencryption progress for a resource type, and periodically saves it to the
+// progress ConfigMap and updates the metrics. Failure to save progress is logged, but does not interrupt reencryption.
+type progressTracker struct {
+	configMaps coreclient.ConfigMapClient
+	progress   ReencryptProgress
+}
+
+func newProgressTracker(configMaps coreclient.ConfigMapClient, resource, server string, total int) *progressTracker {
+	now := metav1.Now()
+	t := &progressTracker{
+		configMaps: configMaps,
+		progress: ReencryptProgress{
+			Resource:  resource,
+			Server:    server,
+			Remaining: total,
+			Started:   now,
+			Updated:   now,
+		},
+	}
+	t.save()
+	return t
+}
+
+// done records a resource that was reencrypted.
+func (t *progressTracker) done() {
+	t.progress.Done++
+	t.progress.Remaining--
+	t.saveAtInterval()
+}
+
+// failed records a resource that could not be reencrypted.
+func (t *progressTracker) failed(obj metav1.Object, err error) {
+	t.progress.Failed++
+	t.progress.Remaining--
+	if len(t.progress.Failures) < maxRecordedFailures {
+		t.progress.Failures = append(t.progress.Failures, ReencryptFailure{
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			Reason:    err.Error(),
+		})
+	}
+	t.saveAtInterval()
+}
+
+// complete records the completion of the reencryption, and saves the final progress.
+func (t *progressTracker) complete() {
+	now := metav1.Now()
+	t.progress.Completed = &now
+	t.save()
+}
+
+func (t *progressTracker) saveAtInterval() {
+	if (t.progress.Done+t.progress.Failed)%progressSaveInterval == 0 {
+		t.save()
+	}
+}
+
+func (t *progressTracker) save() {
+	t.progress.Updated = metav1.NewTime(time.Now())
+	reencryptResourcesGauge.WithLabelValues(t.progress.Resource, "done").Set(float64(t.progress.Done))
+	reencryptResourcesGauge.WithLabelValues(t.progress.Resource, "remaining").Set(float64(t.progress.Remaining))
+	reencryptResourcesGauge.WithLabelValues(t.progress.Resource, "failed").Set(float64(t.progress.Failed))
+
+	data, err := json.Marshal(t.progress)
+	if err != nil {
+		logrus.Warnf("Failed to marshal reencrypt progress: %v", err)
+		return
+	}
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := t.configMaps.Get(metav1.NamespaceSystem, ReencryptProgressConfigMapName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = t.configMaps.Create(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ReencryptProgressConfigMapName,
+					Namespace: metav1.NamespaceSystem,
+				},
+				Data: map[string]string{t.progress.Resource: string(data)},
+			})
+			return err
+		} else if err != nil {
+			return err
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[t.progress.Resource] = string(data)
+		_, err = t.configMaps.Update(configMap)
+		return err
+	})
+	if err != nil {
+		logrus.Warnf("Failed to save reencrypt progress: %v", err)
+	}
+}
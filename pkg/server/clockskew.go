@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/sirupsen/logrus"
+)
+
+// checkClockSkew compares the time sent by a joining node with the local clock. Skew beyond the configured
+// threshold is logged, and if configured, the request is rejected, as a node with a skewed clock will fail to
+// validate certificates and will not renew leases correctly. Nodes that do not send their time are not checked.
+func checkClockSkew(control *config.Control, nodeName string, req *http.Request) error {
+	if control.ClockSkewThreshold <= 0 {
+		return nil
+	}
+	nodeTime, err := time.Parse(time.RFC3339Nano, req.Header.Get(version.Program+"-Node-Time"))
+	if err != nil {
+		return nil
+	}
+	skew := time.Since(nodeTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew <= control.ClockSkewThreshold {
+		return nil
+	}
+	err = fmt.Errorf("clock on node %s differs from server clock by approximately %v, which exceeds the threshold of %v", nodeName, skew.Round(time.Second), control.ClockSkewThreshold)
+	if control.ClockSkewReject {
+		return err
+	}
+	logrus.Warnf("%v; ensure that time is synchronized on all cluster members", err)
+	return nil
+}
@@ -22,20 +22,22 @@ import (
 	"github.com/rancher/wrangler/pkg/start"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
 type Context struct {
-	K3s   *k3s.Factory
-	Helm  *helm.Factory
-	Batch *batch.Factory
-	Apps  *apps.Factory
-	Auth  *rbac.Factory
-	Core  *core.Factory
-	K8s   kubernetes.Interface
-	Apply apply.Apply
+	K3s     *k3s.Factory
+	Helm    *helm.Factory
+	Batch   *batch.Factory
+	Apps    *apps.Factory
+	Auth    *rbac.Factory
+	Core    *core.Factory
+	K8s     kubernetes.Interface
+	Dynamic dynamic.Interface
+	Apply   apply.Apply
 }
 
 func (c *Context) Start(ctx context.Context) error {
@@ -66,15 +68,20 @@ func NewContext(ctx context.Context, cfg string) (*Context, error) {
 	if err != nil {
 		return nil, err
 	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
 	return &Context{
-		K3s:   k3s.NewFactoryFromConfigOrDie(restConfig),
-		Helm:  helm.NewFactoryFromConfigOrDie(restConfig),
-		K8s:   k8s,
-		Auth:  rbac.NewFactoryFromConfigOrDie(restConfig),
-		Apps:  apps.NewFactoryFromConfigOrDie(restConfig),
-		Batch: batch.NewFactoryFromConfigOrDie(restConfig),
-		Core:  core.NewFactoryFromConfigOrDie(restConfig),
-		Apply: apply.New(k8s, apply.NewClientFactory(restConfig)).WithDynamicLookup(),
+		K3s:     k3s.NewFactoryFromConfigOrDie(restConfig),
+		Helm:    helm.NewFactoryFromConfigOrDie(restConfig),
+		K8s:     k8s,
+		Dynamic: dynamicClient,
+		Auth:    rbac.NewFactoryFromConfigOrDie(restConfig),
+		Apps:    apps.NewFactoryFromConfigOrDie(restConfig),
+		Batch:   batch.NewFactoryFromConfigOrDie(restConfig),
+		Core:    core.NewFactoryFromConfigOrDie(restConfig),
+		Apply:   apply.New(k8s, apply.NewClientFactory(restConfig)).WithDynamicLookup(),
 	}, nil
 }
 
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/etcd"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// etcdMembersHandler lists the etcd cluster members, including the sync progress of learners.
+func etcdMembersHandler(ctx context.Context, server *config.Control) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method != http.MethodGet {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		members, err := etcd.ListMembers(ctx, server)
+		if err != nil {
+			genErrorMessage(resp, http.StatusInternalServerError, err, "etcd-members")
+			return
+		}
+		resp.Header().Set("content-type", "application/json")
+		if err := json.NewEncoder(resp).Encode(members); err != nil {
+			logrus.Errorf("Failed to encode etcd members: %v", err)
+			resp.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+}
+
+// etcdMemberActionHandler promotes or removes a single etcd cluster member.
+func etcdMemberActionHandler(ctx context.Context, server *config.Control) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method != http.MethodPut {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		vars := mux.Vars(req)
+		name, action := vars["name"], vars["action"]
+
+		var err error
+		switch action {
+		case "promote":
+			err = etcd.PromoteMember(ctx, server, name)
+		case "remove":
+			err = etcd.RemoveMember(ctx, server, name)
+		default:
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			var notFound *etcd.MemberNotFoundError
+			if errors.As(err, &notFound) {
+				status = http.StatusNotFound
+			}
+			genErrorMessage(resp, status, err, "etcd-members")
+			return
+		}
+		resp.WriteHeader(http.StatusOK)
+	})
+}
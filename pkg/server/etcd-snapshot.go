@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SnapshotStateNone      = "none"
+	SnapshotStateRunning   = "running"
+	SnapshotStateSucceeded = "succeeded"
+	SnapshotStateFailed    = "failed"
+)
+
+// SnapshotStatus describes the most recent on-demand snapshot requested through the supervisor API.
+type SnapshotStatus struct {
+	State      string     `json:"state"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+type snapshotTracker struct {
+	sync.Mutex
+	status SnapshotStatus
+}
+
+// snapshotHandler triggers an on-demand snapshot on PUT, and returns the status of the most recent
+// snapshot on GET. Snapshots are taken in the background using the server's snapshot configuration,
+// so that clients are not required to hold the connection open for the duration of the snapshot.
+func snapshotHandler(ctx context.Context, server *config.Control) http.Handler {
+	tracker := &snapshotTracker{status: SnapshotStatus{State: SnapshotStateNone}}
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch req.Method {
+		case http.MethodGet:
+			tracker.Lock()
+			status := tracker.status
+			tracker.Unlock()
+			writeSnapshotStatus(resp, http.StatusOK, status)
+		case http.MethodPut:
+			if server.Runtime.ClusterSnapshot == nil {
+				genErrorMessage(resp, http.StatusServiceUnavailable, errors.New("cluster not ready"), "etcd-snapshot")
+				return
+			}
+			tracker.Lock()
+			defer tracker.Unlock()
+			if tracker.status.State == SnapshotStateRunning {
+				genErrorMessage(resp, http.StatusConflict, errors.New("snapshot already in progress"), "etcd-snapshot")
+				return
+			}
+			now := time.Now()
+			tracker.status = SnapshotStatus{State: SnapshotStateRunning, StartedAt: &now}
+			go tracker.run(ctx, server)
+			writeSnapshotStatus(resp, http.StatusAccepted, tracker.status)
+		default:
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (t *snapshotTracker) run(ctx context.Context, server *config.Control) {
+	logrus.Info("Taking on-demand etcd snapshot requested via supervisor API")
+	err := server.Runtime.ClusterSnapshot(ctx, server)
+
+	t.Lock()
+	defer t.Unlock()
+	now := time.Now()
+	t.status.FinishedAt = &now
+	if err != nil {
+		logrus.Errorf("On-demand etcd snapshot failed: %v", err)
+		t.status.State = SnapshotStateFailed
+		t.status.Error = err.Error()
+	} else {
+		t.status.State = SnapshotStateSucceeded
+	}
+}
+
+func writeSnapshotStatus(resp http.ResponseWriter, code int, status SnapshotStatus) {
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(code)
+	if err := json.NewEncoder(resp).Encode(status); err != nil {
+		logrus.Errorf("Failed to encode snapshot status: %v", err)
+	}
+}
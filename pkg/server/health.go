@@ -0,0 +1,232 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+const (
+	healthStatusOK       = "ok"
+	healthStatusFailed   = "failed"
+	healthStatusDisabled = "disabled"
+
+	healthCheckTimeout = 5 * time.Second
+
+	// datastoreCheckInterval is the time for which the result of the datastore check is reused, so that
+	// frequent load balancer probes do not each cause a request to the apiserver and datastore.
+	datastoreCheckInterval = 10 * time.Second
+)
+
+// HealthResponse is the machine-readable body returned by the supervisor readyz and livez endpoints.
+// Components are only included for authenticated agents and servers.
+type HealthResponse struct {
+	Status     string            `json:"status"`
+	Components []ComponentHealth `json:"components,omitempty"`
+}
+
+// ComponentHealth contains the status of a single supervisor component.
+type ComponentHealth struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// healthCheck returns an error if the component is unhealthy, or errComponentDisabled
+// if the component is not in use on this server.
+type healthCheck struct {
+	name string
+	// live indicates that failure of this check means that the supervisor is not functional at all,
+	// and should be restarted. Checks that are not live only affect readiness.
+	live  bool
+	check func(ctx context.Context, server *config.Control) error
+}
+
+var errComponentDisabled = errors.New("disabled")
+
+// healthDetailRoles are the groups of users that may see the status of each component, which includes
+// internal error messages.
+var healthDetailRoles = []string{version.Program + ":agent", version.Program + ":server", user.NodesGroup, user.SystemPrivilegedGroup}
+
+// datastoreHealth caches the result of the last datastore check.
+var datastoreHealth struct {
+	sync.Mutex
+	checked time.Time
+	err     error
+}
+
+var healthChecks = []healthCheck{
+	{name: "tunnel", live: true, check: checkTunnel},
+	{name: "datastore", check: checkDatastore},
+	{name: "apiserver", check: checkAPIServer},
+	{name: "runtime-core", check: checkRuntimeCore},
+	{name: "deploy-controller", check: checkDeployController},
+}
+
+// readyzHandler reports the readiness of all supervisor components. A 500 status is returned if any
+// component is not ready; agents rely on this to wait for the server configuration to stabilize.
+func readyzHandler(server *config.Control) http.Handler {
+	return healthHandler(server, false)
+}
+
+// livezHandler reports the liveness of the supervisor. A 500 status is only returned if the
+// supervisor itself is not functional; components that are degraded are reported in the body
+// but do not cause the check to fail.
+func livezHandler(server *config.Control) http.Handler {
+	return healthHandler(server, true)
+}
+
+func healthHandler(server *config.Control, liveOnly bool) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), healthCheckTimeout)
+		defer cancel()
+
+		code := http.StatusOK
+		health := getHealth(ctx, server)
+		for i, check := range healthChecks {
+			if health.Components[i].Status == healthStatusFailed && (check.live || !liveOnly) {
+				code = http.StatusInternalServerError
+				health.Status = healthStatusFailed
+			}
+		}
+
+		if !healthDetailAllowed(server, req) {
+			health.Components = nil
+		}
+		data, err := json.Marshal(health)
+		if err != nil {
+			logrus.Errorf("Failed to encode health status: %v", err)
+			resp.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		resp.Header().Set("Content-Type", "application/json")
+		resp.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		resp.WriteHeader(code)
+		resp.Write(data)
+	})
+}
+
+// healthDetailAllowed returns true if the request is authenticated as an agent or server. The endpoints do not
+// require authentication, so that they can be used by load balancers, but unauthenticated clients only get the
+// overall status.
+func healthDetailAllowed(server *config.Control, req *http.Request) bool {
+	if server.Runtime.Authenticator == nil {
+		return false
+	}
+	resp, ok, err := server.Runtime.Authenticator.AuthenticateRequest(req)
+	return err == nil && ok && hasRole(healthDetailRoles, resp.User.GetGroups())
+}
+
+// getHealth runs all health checks, and returns the status of each component.
+// The overall status is left as ok; it is up to the caller to decide which failures are fatal.
+func getHealth(ctx context.Context, server *config.Control) HealthResponse {
+	health := HealthResponse{
+		Status:     healthStatusOK,
+		Components: make([]ComponentHealth, len(healthChecks)),
+	}
+	for i, check := range healthChecks {
+		component := ComponentHealth{Name: check.name, Status: healthStatusOK}
+		if err := check.check(ctx, server); err == errComponentDisabled {
+			component.Status = healthStatusDisabled
+		} else if err != nil {
+			component.Status = healthStatusFailed
+			component.Message = err.Error()
+		}
+		health.Components[i] = component
+	}
+	return health
+}
+
+func checkTunnel(ctx context.Context, server *config.Control) error {
+	if server.Runtime.Tunnel == nil {
+		return errors.New("tunnel server not ready")
+	}
+	return nil
+}
+
+// checkDatastore ensures that the datastore has started, and then asks the apiserver for the
+// status of its connection to the datastore, as the supervisor does not talk to it directly.
+// The result of asking the apiserver is reused for datastoreCheckInterval.
+func checkDatastore(ctx context.Context, server *config.Control) error {
+	if !isClosed(server.Runtime.ETCDReady) {
+		return errors.New("datastore not ready")
+	}
+	if server.DisableAPIServer || server.Runtime.K8s == nil {
+		return nil
+	}
+	datastoreHealth.Lock()
+	defer datastoreHealth.Unlock()
+	if time.Since(datastoreHealth.checked) < datastoreCheckInterval {
+		return datastoreHealth.err
+	}
+	datastoreHealth.err = checkAPIServerDatastore(ctx, server)
+	datastoreHealth.checked = time.Now()
+	return datastoreHealth.err
+}
+
+// checkAPIServerDatastore asks the apiserver for the status of its connection to the datastore.
+func checkAPIServerDatastore(ctx context.Context, server *config.Control) error {
+	var status int
+	result := server.Runtime.K8s.Discovery().RESTClient().Get().AbsPath("/readyz/etcd").Do(ctx).StatusCode(&status)
+	if err := result.Error(); err != nil {
+		return errors.Wrap(err, "datastore health check failed")
+	}
+	if status != http.StatusOK {
+		return errors.Errorf("datastore health check returned status %d", status)
+	}
+	return nil
+}
+
+func checkAPIServer(ctx context.Context, server *config.Control) error {
+	if server.DisableAPIServer {
+		return errComponentDisabled
+	}
+	if server.Runtime.APIServer == nil || !isClosed(server.Runtime.APIServerReady) {
+		return errors.New("apiserver not ready")
+	}
+	return nil
+}
+
+func checkRuntimeCore(ctx context.Context, server *config.Control) error {
+	if server.Runtime.Core == nil {
+		return errors.New("runtime core not ready")
+	}
+	return nil
+}
+
+func checkDeployController(ctx context.Context, server *config.Control) error {
+	if server.DisableAPIServer {
+		return errComponentDisabled
+	}
+	if !isClosed(server.Runtime.DeployReady) {
+		return errors.New("deploy controller not ready")
+	}
+	return nil
+}
+
+// isClosed returns true if the channel has been closed. Nil channels are never closed.
+func isClosed(c <-chan struct{}) bool {
+	if c == nil {
+		return false
+	}
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/version"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func Test_UnitHealthHandler(t *testing.T) {
+	ready := make(chan struct{})
+	close(ready)
+	controlConfig := &config.Control{DisableAPIServer: true}
+	controlConfig.Runtime = &config.ControlRuntime{
+		ETCDReady: ready,
+		Authenticator: authenticator.RequestFunc(func(req *http.Request) (*authenticator.Response, bool, error) {
+			username, _, ok := req.BasicAuth()
+			if !ok {
+				return nil, false, nil
+			}
+			groups := map[string][]string{"node": {version.Program + ":agent"}, "user": {"system:authenticated"}}[username]
+			return &authenticator.Response{User: &user.DefaultInfo{Name: username, Groups: groups}}, true, nil
+		}),
+	}
+
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		user           string
+		wantCode       int
+		wantComponents bool
+	}{
+		{name: "readyz unauthenticated", handler: readyzHandler(controlConfig), wantCode: http.StatusInternalServerError},
+		{name: "readyz as other user", handler: readyzHandler(controlConfig), user: "user", wantCode: http.StatusInternalServerError},
+		{name: "readyz as agent", handler: readyzHandler(controlConfig), user: "node", wantCode: http.StatusInternalServerError, wantComponents: true},
+		{name: "livez unauthenticated", handler: livezHandler(controlConfig), wantCode: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1-"+version.Program+"/readyz", nil)
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, "password")
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			health := &HealthResponse{}
+			if err := json.Unmarshal(rec.Body.Bytes(), health); err != nil {
+				t.Fatal(err)
+			}
+			if health.Status != healthStatusFailed {
+				t.Errorf("health status = %s, want %s", health.Status, healthStatusFailed)
+			}
+			if (len(health.Components) > 0) != tt.wantComponents {
+				t.Errorf("components = %+v, want components %v", health.Components, tt.wantComponents)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// kubeletSettingsHandler returns the kubelet settings from the kubelet settings ConfigMap, so that
+// they can be applied by agents. Agents poll this endpoint, and restart when the settings change;
+// servers only apply changed settings when they are next restarted.
+// If the ConfigMap does not exist, an empty set of settings is returned. Invalid settings are
+// rejected, so that agents continue to run with their current settings until the ConfigMap is fixed.
+func kubeletSettingsHandler(server *config.Control) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method != http.MethodGet {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if server.Runtime.Core == nil {
+			genErrorMessage(resp, http.StatusServiceUnavailable, errors.New("runtime core not ready"), "kubelet-settings")
+			return
+		}
+
+		settings := map[string]string{}
+		cm, err := server.Runtime.Core.Core().V1().ConfigMap().Get(metav1.NamespaceSystem, config.KubeletSettingsConfigMap, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			genErrorMessage(resp, http.StatusInternalServerError, err, "kubelet-settings")
+			return
+		}
+		if err == nil && cm.Data != nil {
+			settings = cm.Data
+		}
+		if err := config.ValidateKubeletSettings(settings); err != nil {
+			genErrorMessage(resp, http.StatusUnprocessableEntity, errors.Wrapf(err, "invalid ConfigMap %s/%s", metav1.NamespaceSystem, config.KubeletSettingsConfigMap), "kubelet-settings")
+			return
+		}
+
+		resp.Header().Set("content-type", "application/json")
+		if err := json.NewEncoder(resp).Encode(settings); err != nil {
+			logrus.Errorf("Failed to encode kubelet settings: %v", err)
+			resp.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/rancher/wrangler/pkg/leader"
+	"github.com/sirupsen/logrus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+
+	// for leader_election_master_status metric registration
+	_ "k8s.io/component-base/metrics/prometheus/clientgo/leaderelection"
+)
+
+const (
+	leaseDuration = 45 * time.Second
+	renewDeadline = 30 * time.Second
+	retryPeriod   = 2 * time.Second
+
+	// standbyGracePeriod is added to the lease duration on standby servers, so that preferred
+	// servers have a chance to take over an expired lease first.
+	standbyGracePeriod = 30 * time.Second
+
+	// candidateInterval is the interval at which preferred servers that are not leading renew their
+	// candidate lease, and at which standby servers that are leading check for preferred candidates.
+	candidateInterval = 10 * time.Second
+)
+
+var (
+	leaderHolderGauge = metrics.NewGaugeVec(&metrics.GaugeOpts{
+		Name:           version.Program + "_leader_election_holder",
+		StabilityLevel: metrics.ALPHA,
+		Help:           "Gauge set to 1 for the server observed to hold the lease for leader-elected controllers. 'name' is the name of the lease, and 'holder' is the identity of the server holding it.",
+	}, []string{"name", "holder"})
+
+	leaderHoldersMutex sync.Mutex
+	leaderHolders      = map[string]string{}
+)
+
+func init() {
+	legacyregistry.MustRegister(leaderHolderGauge)
+}
+
+// startLeaderElection starts leader election for all leader-elected controllers, unless this server's
+// leader election priority prevents it from running them.
+func startLeaderElection(ctx context.Context, controlConfig *config.Control, client kubernetes.Interface) error {
+	if controlConfig.LeaderElectionPriority == config.LeaderElectionNever {
+		logrus.Infof("Not running leader-elected controllers due to leader-election-priority %s", controlConfig.LeaderElectionPriority)
+		return nil
+	}
+	identity, err := leaderElectionIdentity(controlConfig)
+	if err != nil {
+		return err
+	}
+	for name, cb := range controlConfig.Runtime.LeaderElectedClusterControllerStarts {
+		go runLeaderElection(ctx, name, identity, controlConfig.LeaderElectionPriority, client, cb)
+	}
+	return nil
+}
+
+// runLeaderElection runs leader election for the named lease in the kube-system namespace, and runs
+// the callback when this server becomes leader. It is similar to leader.RunOrDie, except that the
+// election is named so that leadership metrics are reported, the lease holder observed by this
+// server is tracked, and the lease duration depends on this server's leader election priority.
+// Preferred servers that are not leading hold a candidate lease, and standby servers that are leading
+// release the lease when they see a preferred candidate, so that leadership returns to preferred servers.
+// The callback must stop the controllers that it started when its context is cancelled.
+func runLeaderElection(ctx context.Context, name, identity, priority string, client kubernetes.Interface, cb leader.Callback) {
+	rl, err := resourcelock.New(resourcelock.ConfigMapsLeasesResourceLock,
+		metav1.NamespaceSystem,
+		name,
+		client.CoreV1(),
+		client.CoordinationV1(),
+		resourcelock.ResourceLockConfig{
+			Identity: identity,
+		})
+	if err != nil {
+		logrus.Fatalf("Failed to create leader lock for %s: %v", name, err)
+	}
+
+	duration := leaseDuration
+	if priority == config.LeaderElectionStandby {
+		duration += standbyGracePeriod
+	}
+
+	var leading atomic.Bool
+	if priority == config.LeaderElectionPreferred {
+		go renewCandidateLease(ctx, name, identity, client, &leading)
+	}
+
+	// Standby servers rejoin the election after handing leadership over to a preferred server; the
+	// controllers are stopped by cancelling the leader context, so the server does not need to restart.
+	for {
+		if priority == config.LeaderElectionStandby {
+			// Give a waiting preferred server the chance to take a lease that was released by this server.
+			lease, err := client.CoordinationV1().Leases(metav1.NamespaceSystem).Get(ctx, candidateLeaseName(name), metav1.GetOptions{})
+			if err == nil && isPreferredCandidate(lease, identity, time.Now()) {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(standbyGracePeriod):
+				}
+			}
+		}
+
+		var handover atomic.Bool
+		electionCtx, cancelElection := context.WithCancel(ctx)
+		leaderelection.RunOrDie(electionCtx, leaderelection.LeaderElectionConfig{
+			Lock:          rl,
+			Name:          name,
+			LeaseDuration: duration,
+			RenewDeadline: renewDeadline,
+			RetryPeriod:   retryPeriod,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					logrus.Infof("Became leader for %s", name)
+					leading.Store(true)
+					if priority == config.LeaderElectionStandby {
+						go func() {
+							if waitForPreferredCandidate(ctx, name, identity, client) {
+								handover.Store(true)
+								cancelElection()
+							}
+						}()
+					}
+					go cb(ctx)
+				},
+				OnStoppedLeading: func() {
+					leading.Store(false)
+					if handover.Load() {
+						logrus.Infof("Released leadership of %s to a preferred server, rejoining election as a standby candidate", name)
+						return
+					}
+					select {
+					case <-ctx.Done():
+						// The context has been cancelled, so this is a request to terminate. Exit cleanly
+						// so that the service manager does not record it as exiting in error.
+						logrus.Info("Requested to terminate, exiting")
+						os.Exit(0)
+					default:
+						logrus.Fatalf("Leader election lost for %s", name)
+					}
+				},
+				OnNewLeader: func(holder string) {
+					setLeaderHolder(name, holder)
+					if holder != identity {
+						logrus.Infof("Leader for %s is %s", name, holder)
+					}
+				},
+			},
+			ReleaseOnCancel: true,
+		})
+		cancelElection()
+		if !handover.Load() {
+			break
+		}
+	}
+	panic("Failed to start leader election for " + name)
+}
+
+// candidateLeaseName returns the name of the lease held by preferred servers that are waiting to lead.
+func candidateLeaseName(name string) string {
+	return name + "-" + config.LeaderElectionPreferred
+}
+
+// renewCandidateLease periodically records this server as a preferred candidate for the named lease,
+// while it is not the leader.
+func renewCandidateLease(ctx context.Context, name, identity string, client kubernetes.Interface, leading *atomic.Bool) {
+	leases := client.CoordinationV1().Leases(metav1.NamespaceSystem)
+	leaseName := candidateLeaseName(name)
+	ticker := time.NewTicker(candidateInterval)
+	defer ticker.Stop()
+	for {
+		if !leading.Load() {
+			now := metav1.NewMicroTime(time.Now())
+			lease, err := leases.Get(ctx, leaseName, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				lease = &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: leaseName, Namespace: metav1.NamespaceSystem}}
+				lease.Spec.HolderIdentity = &identity
+				lease.Spec.RenewTime = &now
+				_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+			} else if err == nil {
+				lease.Spec.HolderIdentity = &identity
+				lease.Spec.RenewTime = &now
+				_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+			}
+			if err != nil && !apierrors.IsConflict(err) && !apierrors.IsAlreadyExists(err) {
+				logrus.Debugf("Failed to renew candidate lease for %s: %v", name, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForPreferredCandidate waits until a preferred server other than this one has recently renewed
+// the candidate lease for the named lease, and returns true. False is returned if the context is done first.
+func waitForPreferredCandidate(ctx context.Context, name, identity string, client kubernetes.Interface) bool {
+	leases := client.CoordinationV1().Leases(metav1.NamespaceSystem)
+	ticker := time.NewTicker(candidateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+		lease, err := leases.Get(ctx, candidateLeaseName(name), metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		if isPreferredCandidate(lease, identity, time.Now()) {
+			logrus.Infof("Preferred server %s is available to lead %s", *lease.Spec.HolderIdentity, name)
+			return true
+		}
+	}
+}
+
+// isPreferredCandidate returns true if the candidate lease is held by another server, and was renewed recently.
+func isPreferredCandidate(lease *coordinationv1.Lease, identity string, now time.Time) bool {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" || *lease.Spec.HolderIdentity == identity {
+		return false
+	}
+	return lease.Spec.RenewTime != nil && now.Sub(lease.Spec.RenewTime.Time) < leaseDuration
+}
+
+// setLeaderHolder updates the lease holder metric, removing the series for any previous holder.
+func setLeaderHolder(name, holder string) {
+	leaderHoldersMutex.Lock()
+	defer leaderHoldersMutex.Unlock()
+	if previous, ok := leaderHolders[name]; ok {
+		leaderHolderGauge.Delete(map[string]string{"name": name, "holder": previous})
+	}
+	leaderHolders[name] = holder
+	leaderHolderGauge.WithLabelValues(name, holder).Set(1)
+}
+
+// leaderElectionIdentity returns the identity used by this server when acquiring leases.
+// The node name is used if set, so that lease holders can be matched to nodes.
+func leaderElectionIdentity(controlConfig *config.Control) (string, error) {
+	if controlConfig.ServerNodeName != "" {
+		return controlConfig.ServerNodeName, nil
+	}
+	return os.Hostname()
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_UnitIsPreferredCandidate(t *testing.T) {
+	now := time.Now()
+	other := "server-2"
+	self := "server-1"
+	empty := ""
+	tests := []struct {
+		name      string
+		holder    *string
+		renewTime *time.Time
+		want      bool
+	}{
+		{
+			name:      "recently renewed by another server",
+			holder:    &other,
+			renewTime: &now,
+			want:      true,
+		},
+		{
+			name:      "expired",
+			holder:    &other,
+			renewTime: func() *time.Time { t := now.Add(-2 * leaseDuration); return &t }(),
+		},
+		{
+			name:      "held by this server",
+			holder:    &self,
+			renewTime: &now,
+		},
+		{
+			name:      "no holder",
+			holder:    &empty,
+			renewTime: &now,
+		},
+		{
+			name:   "never renewed",
+			holder: &other,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := &coordinationv1.Lease{}
+			lease.Spec.HolderIdentity = tt.holder
+			if tt.renewTime != nil {
+				renewTime := metav1.NewMicroTime(*tt.renewTime)
+				lease.Spec.RenewTime = &renewTime
+			}
+			if got := isPreferredCandidate(lease, self, now); got != tt.want {
+				t.Errorf("isPreferredCandidate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
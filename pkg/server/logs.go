@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"path/filepath"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/logs"
+	"github.com/pkg/errors"
+)
+
+// nodeDialer dials a port on the loopback address of a node through its tunnel.
+type nodeDialer interface {
+	DialNode(ctx context.Context, nodeName, port string) (net.Conn, error)
+}
+
+// logsHandler returns the recent logs of an embedded component, so that node diagnostics can be retrieved without
+// access to the node's journal. Logs of other nodes are retrieved from their agent through the tunnel.
+func logsHandler(server *config.Control) http.Handler {
+	local := logs.Handler(filepath.Join(filepath.Dir(server.DataDir), "agent", "containerd", "containerd.log"))
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		nodeName := req.URL.Query().Get("node")
+		if nodeName == "" || nodeName == server.ServerNodeName {
+			local.ServeHTTP(resp, req)
+			return
+		}
+		dialer, ok := server.Runtime.Tunnel.(nodeDialer)
+		if !ok {
+			genErrorMessage(resp, http.StatusServiceUnavailable, errors.New("tunnel server not ready"), "logs")
+			return
+		}
+		nodeLogsProxy(dialer, nodeName).ServeHTTP(resp, req)
+	})
+}
+
+// nodeLogsProxy returns a proxy to the logs served by the agent of a node through its tunnel.
+func nodeLogsProxy(dialer nodeDialer, nodeName string) http.Handler {
+	return &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			query := req.URL.Query()
+			query.Del("node")
+			req.URL.Scheme = "http"
+			req.URL.Host = nodeName
+			req.URL.Path = "/logs"
+			req.URL.RawQuery = query.Encode()
+			req.Header.Del("Authorization")
+		},
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialNode(ctx, nodeName, config.LogsServerPort)
+			},
+		},
+		ErrorHandler: func(resp http.ResponseWriter, req *http.Request, err error) {
+			genErrorMessage(resp, http.StatusBadGateway, errors.Wrapf(err, "failed to retrieve logs from node %s", nodeName), "logs")
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+)
+
+type testNodeDialer struct {
+	address  string
+	nodeName string
+	port     string
+}
+
+func (d *testNodeDialer) DialNode(ctx context.Context, nodeName, port string) (net.Conn, error) {
+	d.nodeName, d.port = nodeName, port
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", d.address)
+}
+
+func Test_UnitLogsHandlerNode(t *testing.T) {
+	var gotPath, gotQuery, gotAuth string
+	agent := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		gotPath, gotQuery, gotAuth = req.URL.Path, req.URL.RawQuery, req.Header.Get("Authorization")
+		resp.Write([]byte("agent logs\n"))
+	}))
+	defer agent.Close()
+
+	dialer := &testNodeDialer{address: agent.Listener.Addr().String()}
+	server := &config.Control{ServerNodeName: "server1", DataDir: t.TempDir()}
+	server.Runtime = &config.ControlRuntime{}
+	server.Runtime.Tunnel = struct {
+		http.Handler
+		nodeDialer
+	}{nodeDialer: dialer}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1-k3s/logs?component=kubelet&node=agent1&since=10m", nil)
+	req.TLS = &tls.ConnectionState{}
+	req.Header.Set("Authorization", "Basic c2VydmVyOnRva2Vu")
+	resp := httptest.NewRecorder()
+	logsHandler(server).ServeHTTP(resp, req)
+
+	body, _ := io.ReadAll(resp.Result().Body)
+	if resp.Code != http.StatusOK || string(body) != "agent logs\n" {
+		t.Fatalf("logsHandler() = %d %q, want %d %q", resp.Code, body, http.StatusOK, "agent logs\n")
+	}
+	if dialer.nodeName != "agent1" || dialer.port != config.LogsServerPort {
+		t.Errorf("DialNode() called with node=%s port=%s, want node=agent1 port=%s", dialer.nodeName, dialer.port, config.LogsServerPort)
+	}
+	if gotPath != "/logs" || gotQuery != "component=kubelet&since=10m" {
+		t.Errorf("agent request = %s?%s, want /logs?component=kubelet&since=10m", gotPath, gotQuery)
+	}
+	if gotAuth != "" {
+		t.Errorf("agent request Authorization = %q, want none", gotAuth)
+	}
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	v1 "github.com/k3s-io/k3s/pkg/apis/k3s.cattle.io/v1"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	k3scontrollers "github.com/k3s-io/k3s/pkg/generated/controllers/k3s.cattle.io/v1"
+	"github.com/k3s-io/k3s/pkg/nodepassword"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	coreclient "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// manualJoinApproval returns true if new nodes must be approved before they are issued kubelet certificates.
+func manualJoinApproval(control *config.Control) bool {
+	return control.NodeJoinApproval == config.NodeJoinApprovalManual
+}
+
+// checkNodeJoinApproval returns an error if a node may not be issued kubelet certificates because its join
+// request has not been approved. The join request is bound to the password of the node that created it, and the
+// node password secret is only created once the request is approved, so that approval applies only to the node
+// that created the request, and another node using the same name cannot claim it while the request is pending.
+// Nodes that joined the cluster before manual approval was enabled already have a node password secret, and do
+// not need to be approved. The node password secret is removed when a node is deleted, so a node that rejoins
+// with the same name after being deleted must be approved again.
+func checkNodeJoinApproval(runtime *config.ControlRuntime, secretClient coreclient.SecretClient, node *nodeInfo, req *http.Request) (int, error) {
+	if runtime.K3s == nil {
+		return http.StatusServiceUnavailable, errors.New("runtime core not ready")
+	}
+	return nodeJoinApproval(runtime.K3s.K3s().V1().NodeJoinRequest(), secretClient, node, remoteHost(req))
+}
+
+func nodeJoinApproval(joinRequests k3scontrollers.NodeJoinRequestClient, secretClient coreclient.SecretClient, node *nodeInfo, remoteAddress string) (int, error) {
+	hasPassword := true
+	if _, err := secretClient.Get(metav1.NamespaceSystem, nodepassword.SecretName(node.Name), metav1.GetOptions{}); apierrors.IsNotFound(err) {
+		hasPassword = false
+	} else if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	joinRequest, err := joinRequests.Get(metav1.NamespaceSystem, node.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		if hasPassword {
+			return http.StatusOK, nil
+		}
+		passwordHash, err := nodepassword.Hasher.CreateHash(node.Password)
+		if err != nil {
+			return http.StatusInternalServerError, errors.Wrapf(err, "unable to create hash for node '%s'", node.Name)
+		}
+		joinRequest = &v1.NodeJoinRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      node.Name,
+				Namespace: metav1.NamespaceSystem,
+			},
+			Spec: v1.NodeJoinRequestSpec{
+				NodeName:      node.Name,
+				RemoteAddress: remoteAddress,
+				PasswordHash:  passwordHash,
+			},
+		}
+		if _, err := joinRequests.Create(joinRequest); apierrors.IsAlreadyExists(err) {
+			return http.StatusConflict, fmt.Errorf("join request for node %s already exists", node.Name)
+		} else if err != nil {
+			return http.StatusInternalServerError, errors.Wrap(err, "failed to create node join request")
+		}
+		logrus.Infof("Node %s at %s requested to join the cluster; approve the request with '%s node approve %s'", node.Name, joinRequest.Spec.RemoteAddress, version.Program, node.Name)
+		return http.StatusAccepted, fmt.Errorf("join request for node %s is pending approval", node.Name)
+	case err != nil:
+		return http.StatusInternalServerError, err
+	case !hasPassword && joinRequest.Spec.PasswordHash == "":
+		// The join request is not bound to a node password, as it was approved and the node then deleted, or
+		// it was created without one. Bind it to this node, which must then be approved again.
+		passwordHash, err := nodepassword.Hasher.CreateHash(node.Password)
+		if err != nil {
+			return http.StatusInternalServerError, errors.Wrapf(err, "unable to create hash for node '%s'", node.Name)
+		}
+		joinRequest = joinRequest.DeepCopy()
+		joinRequest.Spec.Approved = false
+		joinRequest.Spec.RemoteAddress = remoteAddress
+		joinRequest.Spec.PasswordHash = passwordHash
+		if _, err := joinRequests.Update(joinRequest); err != nil {
+			return http.StatusInternalServerError, errors.Wrap(err, "failed to update node join request")
+		}
+		if joinRequest.Spec.Denied {
+			return http.StatusForbidden, fmt.Errorf("join request for node %s was denied", node.Name)
+		}
+		logrus.Infof("Node %s at %s requested to rejoin the cluster; approve the request with '%s node approve %s'", node.Name, joinRequest.Spec.RemoteAddress, version.Program, node.Name)
+		return http.StatusAccepted, fmt.Errorf("join request for node %s is pending approval", node.Name)
+	}
+
+	// Only the node that created the join request may use it; nodes that have already joined are verified
+	// against their node password secret.
+	if joinRequest.Spec.PasswordHash != "" {
+		if err := nodepassword.Hasher.VerifyHash(joinRequest.Spec.PasswordHash, node.Password); err != nil {
+			return http.StatusForbidden, errors.Wrapf(err, "unable to verify join request password for node '%s'", node.Name)
+		}
+	} else if err := nodepassword.Ensure(secretClient, node.Name, node.Password); err != nil {
+		return http.StatusForbidden, err
+	}
+	if joinRequest.Spec.Denied {
+		return http.StatusForbidden, fmt.Errorf("join request for node %s was denied", node.Name)
+	}
+	if !joinRequest.Spec.Approved {
+		return http.StatusAccepted, fmt.Errorf("join request for node %s is pending approval", node.Name)
+	}
+
+	// The join request has been approved; store the node password it was bound to.
+	if joinRequest.Spec.PasswordHash != "" {
+		if err := nodepassword.Ensure(secretClient, node.Name, node.Password); err != nil {
+			return http.StatusForbidden, err
+		}
+		joinRequest = joinRequest.DeepCopy()
+		joinRequest.Spec.PasswordHash = ""
+		if _, err := joinRequests.Update(joinRequest); err != nil {
+			return http.StatusInternalServerError, errors.Wrap(err, "failed to update node join request")
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// setNodeJoinApproval approves or denies the join request for a node.
+func setNodeJoinApproval(server *config.Control, name string, approved bool) error {
+	if server.Runtime.K3s == nil {
+		return errors.New("runtime core not ready")
+	}
+	return updateNodeJoinApproval(server.Runtime.K3s.K3s().V1().NodeJoinRequest(), name, approved)
+}
+
+func updateNodeJoinApproval(joinRequests k3scontrollers.NodeJoinRequestClient, name string, approved bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		joinRequest, err := joinRequests.Get(metav1.NamespaceSystem, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		joinRequest = joinRequest.DeepCopy()
+		joinRequest.Spec.Approved = approved
+		joinRequest.Spec.Denied = !approved
+		_, err = joinRequests.Update(joinRequest)
+		return err
+	})
+}
+
+// remoteHost returns the host portion of the remote address of a request.
+func remoteHost(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	v1 "github.com/k3s-io/k3s/pkg/apis/k3s.cattle.io/v1"
+	"github.com/k3s-io/k3s/pkg/nodepassword"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func Test_UnitNodeJoinApproval(t *testing.T) {
+	node := &nodeInfo{Name: "node1", Password: "password"}
+	tests := []struct {
+		name          string
+		hasPassword   bool
+		password      string
+		joinRequest   *v1.NodeJoinRequestSpec
+		boundPassword string
+		wantCode      int
+		wantErr       bool
+		wantRequest   *v1.NodeJoinRequestSpec
+		wantBound     bool
+		wantPassword  bool
+	}{
+		{
+			name:        "new node",
+			wantCode:    http.StatusAccepted,
+			wantErr:     true,
+			wantRequest: &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2"},
+			wantBound:   true,
+		},
+		{
+			name:         "pre-existing node",
+			hasPassword:  true,
+			wantCode:     http.StatusOK,
+			wantPassword: true,
+		},
+		{
+			name:          "pending",
+			joinRequest:   &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2"},
+			boundPassword: "password",
+			wantCode:      http.StatusAccepted,
+			wantErr:       true,
+			wantRequest:   &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2"},
+			wantBound:     true,
+		},
+		{
+			name:          "pending for another node",
+			joinRequest:   &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.3"},
+			boundPassword: "other",
+			wantCode:      http.StatusForbidden,
+			wantErr:       true,
+			wantRequest:   &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.3"},
+		},
+		{
+			name:          "approved",
+			joinRequest:   &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2", Approved: true},
+			boundPassword: "password",
+			wantCode:      http.StatusOK,
+			wantRequest:   &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2", Approved: true},
+			wantPassword:  true,
+		},
+		{
+			name:          "approved for another node",
+			joinRequest:   &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.3", Approved: true},
+			boundPassword: "other",
+			wantCode:      http.StatusForbidden,
+			wantErr:       true,
+			wantRequest:   &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.3", Approved: true},
+		},
+		{
+			name:         "approved and joined",
+			hasPassword:  true,
+			joinRequest:  &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2", Approved: true},
+			wantCode:     http.StatusOK,
+			wantRequest:  &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2", Approved: true},
+			wantPassword: true,
+		},
+		{
+			name:         "approved and joined with wrong password",
+			hasPassword:  true,
+			password:     "other",
+			joinRequest:  &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2", Approved: true},
+			wantCode:     http.StatusForbidden,
+			wantErr:      true,
+			wantRequest:  &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2", Approved: true},
+			wantPassword: true,
+		},
+		{
+			name:          "denied",
+			joinRequest:   &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2", Denied: true},
+			boundPassword: "password",
+			wantCode:      http.StatusForbidden,
+			wantErr:       true,
+			wantRequest:   &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2", Denied: true},
+			wantBound:     true,
+		},
+		{
+			name:        "rejoin after delete",
+			joinRequest: &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.1", Approved: true},
+			wantCode:    http.StatusAccepted,
+			wantErr:     true,
+			wantRequest: &v1.NodeJoinRequestSpec{NodeName: "node1", RemoteAddress: "10.0.0.2"},
+			wantBound:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secrets := &mockSecretClient{secrets: map[string]*corev1.Secret{}}
+			joinRequests := &mockNodeJoinRequestClient{requests: map[string]*v1.NodeJoinRequest{}}
+			if tt.hasPassword {
+				password := tt.password
+				if password == "" {
+					password = node.Password
+				}
+				if err := nodepassword.Ensure(secrets, node.Name, password); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.joinRequest != nil {
+				joinRequest := &v1.NodeJoinRequest{
+					ObjectMeta: metav1.ObjectMeta{Name: node.Name, Namespace: metav1.NamespaceSystem},
+					Spec:       *tt.joinRequest,
+				}
+				if tt.boundPassword != "" {
+					passwordHash, err := nodepassword.Hasher.CreateHash(tt.boundPassword)
+					if err != nil {
+						t.Fatal(err)
+					}
+					joinRequest.Spec.PasswordHash = passwordHash
+				}
+				joinRequests.requests[node.Name] = joinRequest
+			}
+
+			code, err := nodeJoinApproval(joinRequests, secrets, node, "10.0.0.2")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("nodeJoinApproval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if code != tt.wantCode {
+				t.Errorf("nodeJoinApproval() code = %d, want %d", code, tt.wantCode)
+			}
+
+			joinRequest := joinRequests.requests[node.Name]
+			switch {
+			case tt.wantRequest == nil && joinRequest != nil:
+				t.Errorf("nodeJoinApproval() created join request %+v", joinRequest.Spec)
+			case tt.wantRequest != nil && joinRequest == nil:
+				t.Errorf("nodeJoinApproval() did not create join request")
+			case tt.wantRequest != nil:
+				spec := joinRequest.Spec
+				spec.PasswordHash = ""
+				if spec != *tt.wantRequest {
+					t.Errorf("nodeJoinApproval() join request = %+v, want %+v", spec, *tt.wantRequest)
+				}
+				bound := joinRequest.Spec.PasswordHash != "" && nodepassword.Hasher.VerifyHash(joinRequest.Spec.PasswordHash, node.Password) == nil
+				if bound != tt.wantBound {
+					t.Errorf("nodeJoinApproval() join request bound to node password = %v, want %v", bound, tt.wantBound)
+				}
+			}
+			if _, ok := secrets.secrets[nodepassword.SecretName(node.Name)]; ok != tt.wantPassword {
+				t.Errorf("nodeJoinApproval() node password secret exists = %v, want %v", ok, tt.wantPassword)
+			}
+		})
+	}
+}
+
+func Test_UnitUpdateNodeJoinApproval(t *testing.T) {
+	tests := []struct {
+		name         string
+		joinRequest  *v1.NodeJoinRequestSpec
+		approved     bool
+		wantRequest  *v1.NodeJoinRequestSpec
+		wantNotFound bool
+	}{
+		{
+			name:        "approve pending",
+			joinRequest: &v1.NodeJoinRequestSpec{NodeName: "node1"},
+			approved:    true,
+			wantRequest: &v1.NodeJoinRequestSpec{NodeName: "node1", Approved: true},
+		},
+		{
+			name:        "deny pending",
+			joinRequest: &v1.NodeJoinRequestSpec{NodeName: "node1"},
+			wantRequest: &v1.NodeJoinRequestSpec{NodeName: "node1", Denied: true},
+		},
+		{
+			name:        "approve denied",
+			joinRequest: &v1.NodeJoinRequestSpec{NodeName: "node1", Denied: true},
+			approved:    true,
+			wantRequest: &v1.NodeJoinRequestSpec{NodeName: "node1", Approved: true},
+		},
+		{
+			name:        "deny approved",
+			joinRequest: &v1.NodeJoinRequestSpec{NodeName: "node1", Approved: true},
+			wantRequest: &v1.NodeJoinRequestSpec{NodeName: "node1", Denied: true},
+		},
+		{
+			name:         "missing",
+			approved:     true,
+			wantNotFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			joinRequests := &mockNodeJoinRequestClient{requests: map[string]*v1.NodeJoinRequest{}}
+			if tt.joinRequest != nil {
+				joinRequests.requests["node1"] = &v1.NodeJoinRequest{
+					ObjectMeta: metav1.ObjectMeta{Name: "node1", Namespace: metav1.NamespaceSystem},
+					Spec:       *tt.joinRequest,
+				}
+			}
+			err := updateNodeJoinApproval(joinRequests, "node1", tt.approved)
+			if apierrors.IsNotFound(err) != tt.wantNotFound {
+				t.Fatalf("updateNodeJoinApproval() error = %v, wantNotFound %v", err, tt.wantNotFound)
+			}
+			if tt.wantRequest != nil && joinRequests.requests["node1"].Spec != *tt.wantRequest {
+				t.Errorf("updateNodeJoinApproval() join request = %+v, want %+v", joinRequests.requests["node1"].Spec, *tt.wantRequest)
+			}
+		})
+	}
+}
+
+// mock secret client interface, storing secrets in the kube-system namespace
+
+type mockSecretClient struct {
+	secrets map[string]*corev1.Secret
+}
+
+func (m *mockSecretClient) Create(secret *corev1.Secret) (*corev1.Secret, error) {
+	if _, ok := m.secrets[secret.Name]; ok {
+		return nil, apierrors.NewAlreadyExists(schema.ParseGroupResource("secret"), secret.Name)
+	}
+	m.secrets[secret.Name] = secret.DeepCopy()
+	return secret, nil
+}
+
+func (m *mockSecretClient) Update(secret *corev1.Secret) (*corev1.Secret, error) {
+	return nil, apierrors.NewMethodNotSupported(schema.ParseGroupResource("secret"), "update")
+}
+
+func (m *mockSecretClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	if _, ok := m.secrets[name]; !ok {
+		return apierrors.NewNotFound(schema.ParseGroupResource("secret"), name)
+	}
+	delete(m.secrets, name)
+	return nil
+}
+
+func (m *mockSecretClient) Get(namespace, name string, options metav1.GetOptions) (*corev1.Secret, error) {
+	if secret, ok := m.secrets[name]; ok {
+		return secret.DeepCopy(), nil
+	}
+	return nil, apierrors.NewNotFound(schema.ParseGroupResource("secret"), name)
+}
+
+func (m *mockSecretClient) List(namespace string, opts metav1.ListOptions) (*corev1.SecretList, error) {
+	return nil, apierrors.NewMethodNotSupported(schema.ParseGroupResource("secret"), "list")
+}
+
+func (m *mockSecretClient) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return nil, apierrors.NewMethodNotSupported(schema.ParseGroupResource("secret"), "watch")
+}
+
+func (m *mockSecretClient) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*corev1.Secret, error) {
+	return nil, apierrors.NewMethodNotSupported(schema.ParseGroupResource("secret"), "patch")
+}
+
+// mock node join request client interface, storing join requests in the kube-system namespace
+
+type mockNodeJoinRequestClient struct {
+	requests map[string]*v1.NodeJoinRequest
+}
+
+func (m *mockNodeJoinRequestClient) Create(joinRequest *v1.NodeJoinRequest) (*v1.NodeJoinRequest, error) {
+	if _, ok := m.requests[joinRequest.Name]; ok {
+		return nil, apierrors.NewAlreadyExists(schema.ParseGroupResource("nodejoinrequest.k3s.cattle.io"), joinRequest.Name)
+	}
+	m.requests[joinRequest.Name] = joinRequest.DeepCopy()
+	return joinRequest, nil
+}
+
+func (m *mockNodeJoinRequestClient) Update(joinRequest *v1.NodeJoinRequest) (*v1.NodeJoinRequest, error) {
+	if _, ok := m.requests[joinRequest.Name]; !ok {
+		return nil, apierrors.NewNotFound(schema.ParseGroupResource("nodejoinrequest.k3s.cattle.io"), joinRequest.Name)
+	}
+	m.requests[joinRequest.Name] = joinRequest.DeepCopy()
+	return joinRequest, nil
+}
+
+func (m *mockNodeJoinRequestClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	if _, ok := m.requests[name]; !ok {
+		return apierrors.NewNotFound(schema.ParseGroupResource("nodejoinrequest.k3s.cattle.io"), name)
+	}
+	delete(m.requests, name)
+	return nil
+}
+
+func (m *mockNodeJoinRequestClient) Get(namespace, name string, options metav1.GetOptions) (*v1.NodeJoinRequest, error) {
+	if joinRequest, ok := m.requests[name]; ok {
+		return joinRequest.DeepCopy(), nil
+	}
+	return nil, apierrors.NewNotFound(schema.ParseGroupResource("nodejoinrequest.k3s.cattle.io"), name)
+}
+
+func (m *mockNodeJoinRequestClient) List(namespace string, opts metav1.ListOptions) (*v1.NodeJoinRequestList, error) {
+	return nil, apierrors.NewMethodNotSupported(schema.ParseGroupResource("nodejoinrequest.k3s.cattle.io"), "list")
+}
+
+func (m *mockNodeJoinRequestClient) Watch(namespace string, opts metav1.ListOptions) (watch.Interface, error) {
+	return nil, apierrors.NewMethodNotSupported(schema.ParseGroupResource("nodejoinrequest.k3s.cattle.io"), "watch")
+}
+
+func (m *mockNodeJoinRequestClient) Patch(namespace, name string, pt types.PatchType, data []byte, subresources ...string) (*v1.NodeJoinRequest, error) {
+	return nil, apierrors.NewMethodNotSupported(schema.ParseGroupResource("nodejoinrequest.k3s.cattle.io"), "patch")
+}
@@ -0,0 +1,378 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/k3s-io/k3s/pkg/agent/reload"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	nodeutil "github.com/k3s-io/k3s/pkg/node"
+	"github.com/k3s-io/k3s/pkg/nodeconfig"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+const (
+	defaultDrainTimeout = 5 * time.Minute
+)
+
+// NodeInfo contains details about a cluster member, as returned by the node management API.
+type NodeInfo struct {
+	Name                    string    `json:"name"`
+	Roles                   []string  `json:"roles"`
+	Ready                   bool      `json:"ready"`
+	Unschedulable           bool      `json:"unschedulable"`
+	InternalIPs             []string  `json:"internalIPs,omitempty"`
+	ExternalIPs             []string  `json:"externalIPs,omitempty"`
+	KubeletVersion          string    `json:"kubeletVersion"`
+	ContainerRuntimeVersion string    `json:"containerRuntimeVersion"`
+	OSImage                 string    `json:"osImage"`
+	KernelVersion           string    `json:"kernelVersion"`
+	Architecture            string    `json:"architecture"`
+	ConfigHash              string    `json:"configHash,omitempty"`
+	CertRotationRequested   string    `json:"certRotationRequested,omitempty"`
+	CreatedAt               time.Time `json:"createdAt"`
+}
+
+// NodeRequest contains options for node management operations.
+type NodeRequest struct {
+	Force              bool          `json:"force"`
+	IgnoreDaemonSets   *bool         `json:"ignoreDaemonSets,omitempty"`
+	DeleteEmptyDirData bool          `json:"deleteEmptyDirData"`
+	Timeout            time.Duration `json:"timeout,omitempty"`
+}
+
+func getNodeRequest(req *http.Request) (NodeRequest, error) {
+	result := NodeRequest{}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return result, err
+	}
+	if len(b) == 0 {
+		return result, nil
+	}
+	err = json.Unmarshal(b, &result)
+	return result, err
+}
+
+// nodesHandler lists nodes, or returns a single node if a name is provided.
+func nodesHandler(server *config.Control) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method != http.MethodGet {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if server.Runtime.Core == nil {
+			genErrorMessage(resp, http.StatusServiceUnavailable, errors.New("runtime core not ready"), "nodes")
+			return
+		}
+
+		var data interface{}
+		nodes := server.Runtime.Core.Core().V1().Node()
+		if name := mux.Vars(req)["name"]; name != "" {
+			node, err := nodes.Get(name, metav1.GetOptions{})
+			if err != nil {
+				genErrorMessage(resp, nodeErrorStatus(err), err, "nodes")
+				return
+			}
+			data = toNodeInfo(node)
+		} else {
+			nodeList, err := nodes.List(metav1.ListOptions{})
+			if err != nil {
+				genErrorMessage(resp, http.StatusInternalServerError, err, "nodes")
+				return
+			}
+			infos := []NodeInfo{}
+			for i := range nodeList.Items {
+				infos = append(infos, toNodeInfo(&nodeList.Items[i]))
+			}
+			data = infos
+		}
+
+		resp.Header().Set("content-type", "application/json")
+		if err := json.NewEncoder(resp).Encode(data); err != nil {
+			logrus.Errorf("Failed to encode node info: %v", err)
+			resp.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+}
+
+// nodeActionHandler performs a lifecycle operation against a single node.
+// Supported actions are cordon, uncordon, drain, rotate-certs, and delete, as well as approve and deny,
+// which act on the join request for a node that is not yet a member of the cluster.
+func nodeActionHandler(ctx context.Context, server *config.Control) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		vars := mux.Vars(req)
+		name, action := vars["name"], vars["action"]
+		if (action == "delete" && req.Method != http.MethodDelete && req.Method != http.MethodPost) || (action != "delete" && req.Method != http.MethodPost) {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if server.Runtime.Core == nil || server.Runtime.K8s == nil {
+			genErrorMessage(resp, http.StatusServiceUnavailable, errors.New("runtime core not ready"), "nodes")
+			return
+		}
+		nodeReq, err := getNodeRequest(req)
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			resp.Write([]byte(err.Error()))
+			return
+		}
+
+		switch action {
+		case "cordon":
+			err = cordonNode(ctx, server, name, true)
+		case "uncordon":
+			err = cordonNode(ctx, server, name, false)
+		case "drain":
+			err = drainNode(ctx, server, name, nodeReq)
+		case "rotate-certs":
+			err = requestNodeCertRotation(server, name)
+		case "delete":
+			err = deleteNode(ctx, server, name, nodeReq)
+		case "approve":
+			err = setNodeJoinApproval(server, name, true)
+		case "deny":
+			err = setNodeJoinApproval(server, name, false)
+		default:
+			resp.WriteHeader(http.StatusBadRequest)
+			resp.Write([]byte(fmt.Sprintf("unknown node action %s", action)))
+			return
+		}
+		if err != nil {
+			genErrorMessage(resp, nodeErrorStatus(err), err, "nodes")
+			return
+		}
+		logrus.Infof("Node management: %s completed for node %s", action, name)
+		resp.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func nodeErrorStatus(err error) int {
+	switch {
+	case apierrors.IsNotFound(err):
+		return http.StatusNotFound
+	case apierrors.IsConflict(err):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// toNodeInfo converts a node object into a NodeInfo
+func toNodeInfo(node *corev1.Node) NodeInfo {
+	info := NodeInfo{
+		Name:                    node.Name,
+		Roles:                   nodeutil.Roles(node),
+		Unschedulable:           node.Spec.Unschedulable,
+		KubeletVersion:          node.Status.NodeInfo.KubeletVersion,
+		ContainerRuntimeVersion: node.Status.NodeInfo.ContainerRuntimeVersion,
+		OSImage:                 node.Status.NodeInfo.OSImage,
+		KernelVersion:           node.Status.NodeInfo.KernelVersion,
+		Architecture:            node.Status.NodeInfo.Architecture,
+		ConfigHash:              node.Annotations[nodeconfig.NodeConfigHashAnnotation],
+		CertRotationRequested:   node.Annotations[nodeconfig.CertRotationAnnotation],
+		CreatedAt:               node.CreationTimestamp.Time,
+	}
+	for _, address := range node.Status.Addresses {
+		switch address.Type {
+		case corev1.NodeInternalIP:
+			info.InternalIPs = append(info.InternalIPs, address.Address)
+		case corev1.NodeExternalIP:
+			info.ExternalIPs = append(info.ExternalIPs, address.Address)
+		}
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			info.Ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+	return info
+}
+
+func newDrainHelper(ctx context.Context, server *config.Control, nodeReq NodeRequest) *drain.Helper {
+	timeout := nodeReq.Timeout
+	if timeout == 0 {
+		timeout = defaultDrainTimeout
+	}
+	ignoreDaemonSets := true
+	if nodeReq.IgnoreDaemonSets != nil {
+		ignoreDaemonSets = *nodeReq.IgnoreDaemonSets
+	}
+	return &drain.Helper{
+		Ctx:                 ctx,
+		Client:              server.Runtime.K8s,
+		Force:               nodeReq.Force,
+		GracePeriodSeconds:  -1,
+		IgnoreAllDaemonSets: ignoreDaemonSets,
+		DeleteEmptyDirData:  nodeReq.DeleteEmptyDirData,
+		Timeout:             timeout,
+		Out:                 logrus.StandardLogger().WriterLevel(logrus.InfoLevel),
+		ErrOut:              logrus.StandardLogger().WriterLevel(logrus.WarnLevel),
+	}
+}
+
+func cordonNode(ctx context.Context, server *config.Control, name string, desired bool) error {
+	node, err := server.Runtime.K8s.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return drain.RunCordonOrUncordon(newDrainHelper(ctx, server, NodeRequest{}), node, desired)
+}
+
+func drainNode(ctx context.Context, server *config.Control, name string, nodeReq NodeRequest) error {
+	node, err := server.Runtime.K8s.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	helper := newDrainHelper(ctx, server, nodeReq)
+	if err := drain.RunCordonOrUncordon(helper, node, true); err != nil {
+		return errors.Wrapf(err, "failed to cordon node %s", name)
+	}
+	return drain.RunNodeDrain(helper, name)
+}
+
+// requestNodeCertRotation annotates the node with the current time. Agents watch for this annotation
+// and restart in order to request new certificates from the supervisor.
+func requestNodeCertRotation(server *config.Control, name string) error {
+	nodes := server.Runtime.Core.Core().V1().Node()
+	node, err := nodes.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	node = node.DeepCopy()
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[nodeconfig.CertRotationAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	_, err = nodes.Update(node)
+	return err
+}
+
+// deleteNode drains the node unless forced, and then deletes it from the cluster.
+// The node password secret is cleaned up by the node controller, and etcd
+// members are removed by the etcd member controller.
+func deleteNode(ctx context.Context, server *config.Control, name string, nodeReq NodeRequest) error {
+	if !nodeReq.Force {
+		if err := drainNode(ctx, server, name, nodeReq); err != nil {
+			return errors.Wrapf(err, "failed to drain node %s before removal; use force to skip", name)
+		}
+	}
+	return server.Runtime.K8s.CoreV1().Nodes().Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+// nodeTaintsHandler adds taints to the requesting node. Nodes are not allowed to modify their own taints, so
+// taints added to a node's configuration are applied by the supervisor on its behalf. Only taints that are not
+// already present are added; existing taints are never modified or removed, so a node cannot use this to
+// attract workloads that its taints would otherwise repel.
+func nodeTaintsHandler(server *config.Control) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method != http.MethodPut {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if server.Runtime.Core == nil {
+			genErrorMessage(resp, http.StatusServiceUnavailable, errors.New("runtime core not ready"), "node-taints")
+			return
+		}
+		user, ok := request.UserFrom(req.Context())
+		if !ok {
+			resp.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		name, ok := strings.CutPrefix(user.GetName(), "system:node:")
+		if !ok || name == "" {
+			genErrorMessage(resp, http.StatusForbidden, fmt.Errorf("user %s is not a node", user.GetName()), "node-taints")
+			return
+		}
+		taints := []corev1.Taint{}
+		if err := json.NewDecoder(req.Body).Decode(&taints); err != nil {
+			genErrorMessage(resp, http.StatusBadRequest, err, "node-taints")
+			return
+		}
+
+		nodes := server.Runtime.Core.Core().V1().Node()
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			node, err := nodes.Get(name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			node = node.DeepCopy()
+			added := false
+			for _, taint := range taints {
+				if !hasTaint(node.Spec.Taints, taint) {
+					node.Spec.Taints = append(node.Spec.Taints, taint)
+					added = true
+				}
+			}
+			if !added {
+				return nil
+			}
+			if _, err := nodes.Update(node); err != nil {
+				return err
+			}
+			logrus.Infof("Added taints to node %s", name)
+			return nil
+		})
+		if err != nil {
+			genErrorMessage(resp, http.StatusInternalServerError, err, "node-taints")
+			return
+		}
+		resp.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// hasTaint returns true if a taint with the same key and effect is present.
+func hasTaint(taints []corev1.Taint, taint corev1.Taint) bool {
+	for _, t := range taints {
+		if t.Key == taint.Key && t.Effect == taint.Effect {
+			return true
+		}
+	}
+	return false
+}
+
+// agentReloadHandler requests that the agent running on this server reloads its configuration, in the same way
+// as when the process receives SIGHUP.
+func agentReloadHandler(config *Config) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method != http.MethodPut {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if config.DisableAgent {
+			genErrorMessage(resp, http.StatusConflict, errors.New("agent is disabled on this server"), "agent-reload")
+			return
+		}
+		reload.Trigger()
+		resp.WriteHeader(http.StatusAccepted)
+	})
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	nvidiaGPUResource = "nvidia.com/gpu"
+
+	// NvidiaMIGConfig is the name of the MIG configuration rendered from the MIG profiles. The NVIDIA MIG manager
+	// applies it to nodes that are labeled with nvidia.com/mig.config set to this value.
+	NvidiaMIGConfig = "k3s"
+)
+
+// nvidiaMIGProfile matches MIG profile names such as 1g.5gb, 1g.10gb+me, or 1c.3g.20gb.
+var nvidiaMIGProfile = regexp.MustCompile(`^([0-9]+c\.)?[0-9]+g\.[0-9]+gb(\+me)?$`)
+
+// nvidiaDevicePluginConfig is the subset of the NVIDIA device plugin config file that is rendered from
+// the server configuration.
+type nvidiaDevicePluginConfig struct {
+	Version string `json:"version"`
+	Flags   struct {
+		MigStrategy string `json:"migStrategy"`
+	} `json:"flags"`
+	Sharing *nvidiaSharing `json:"sharing,omitempty"`
+}
+
+type nvidiaSharing struct {
+	TimeSlicing nvidiaTimeSlicing `json:"timeSlicing"`
+}
+
+type nvidiaTimeSlicing struct {
+	Resources []nvidiaSharedResource `json:"resources"`
+}
+
+type nvidiaSharedResource struct {
+	Name     string `json:"name"`
+	Replicas int    `json:"replicas"`
+}
+
+// nvidiaMIGPartedConfig is the MIG partitioning config file used by the NVIDIA MIG manager.
+type nvidiaMIGPartedConfig struct {
+	Version    string                       `json:"version"`
+	MigConfigs map[string][]nvidiaMIGDevice `json:"mig-configs"`
+}
+
+type nvidiaMIGDevice struct {
+	Devices    string         `json:"devices"`
+	MigEnabled bool           `json:"mig-enabled"`
+	MigDevices map[string]int `json:"mig-devices,omitempty"`
+}
+
+// NvidiaDevicePluginConfig returns the config file for the packaged NVIDIA device plugin, with the given
+// MIG strategy and time-slicing replicas. Time-slicing entries are in the form [<resource>=]<replicas>;
+// if the resource is omitted, the replicas apply to full GPUs. If MIG profiles are set, the MIG strategy
+// defaults to mixed, so that each profile is advertised as a separate nvidia.com/mig-<profile> resource.
+// The config is returned as single-line JSON, so that it can be substituted into the packaged manifest.
+func NvidiaDevicePluginConfig(migStrategy string, migProfiles, timeSlicing []string) (string, error) {
+	profiles, err := parseNvidiaMIGProfiles(migProfiles)
+	if err != nil {
+		return "", err
+	}
+
+	config := nvidiaDevicePluginConfig{Version: "v1"}
+	switch migStrategy {
+	case "":
+		config.Flags.MigStrategy = "none"
+		if len(profiles) > 0 {
+			config.Flags.MigStrategy = "mixed"
+		}
+	case "none":
+		if len(profiles) > 0 {
+			return "", fmt.Errorf("nvidia-mig-strategy %s cannot be used with nvidia-mig-profile", migStrategy)
+		}
+		config.Flags.MigStrategy = migStrategy
+	case "single":
+		if len(profiles) > 1 {
+			return "", fmt.Errorf("nvidia-mig-strategy %s requires all MIG devices to use the same nvidia-mig-profile", migStrategy)
+		}
+		config.Flags.MigStrategy = migStrategy
+	case "mixed":
+		config.Flags.MigStrategy = migStrategy
+	default:
+		return "", fmt.Errorf("invalid nvidia-mig-strategy %s", migStrategy)
+	}
+
+	seen := map[string]bool{}
+	for _, entry := range timeSlicing {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, replicas, ok := strings.Cut(entry, "=")
+		if !ok {
+			name, replicas = nvidiaGPUResource, entry
+		}
+		if !strings.Contains(name, "/") {
+			name = "nvidia.com/" + name
+		}
+		count, err := strconv.Atoi(replicas)
+		if err != nil || count < 2 {
+			return "", fmt.Errorf("invalid nvidia-time-slicing %s: replicas must be an integer greater than 1", entry)
+		}
+		if seen[name] {
+			return "", fmt.Errorf("invalid nvidia-time-slicing %s: replicas for %s already set", entry, name)
+		}
+		if profile, ok := strings.CutPrefix(name, "nvidia.com/mig-"); ok && len(profiles) > 0 && profiles[profile] == 0 {
+			return "", fmt.Errorf("invalid nvidia-time-slicing %s: %s is not a configured nvidia-mig-profile", entry, profile)
+		}
+		seen[name] = true
+		if config.Sharing == nil {
+			config.Sharing = &nvidiaSharing{}
+		}
+		config.Sharing.TimeSlicing.Resources = append(config.Sharing.TimeSlicing.Resources, nvidiaSharedResource{Name: name, Replicas: count})
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// NvidiaMIGPartedConfig returns the config file for the packaged NVIDIA MIG manager. MIG profile entries are
+// in the form <profile>=<count>, and set the number of MIG devices of each profile that every MIG-capable GPU
+// on the node is partitioned into. The all-disabled config is always included, so that MIG can be disabled by
+// relabeling the node. The config is returned as single-line JSON, so that it can be substituted into the
+// packaged manifest.
+func NvidiaMIGPartedConfig(migProfiles []string) (string, error) {
+	profiles, err := parseNvidiaMIGProfiles(migProfiles)
+	if err != nil {
+		return "", err
+	}
+	config := nvidiaMIGPartedConfig{
+		Version: "v1",
+		MigConfigs: map[string][]nvidiaMIGDevice{
+			"all-disabled": {{Devices: "all", MigEnabled: false}},
+		},
+	}
+	if len(profiles) > 0 {
+		config.MigConfigs[NvidiaMIGConfig] = []nvidiaMIGDevice{{Devices: "all", MigEnabled: true, MigDevices: profiles}}
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// parseNvidiaMIGProfiles returns the number of MIG devices of each profile.
+func parseNvidiaMIGProfiles(migProfiles []string) (map[string]int, error) {
+	profiles := map[string]int{}
+	for _, entry := range migProfiles {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		profile, devices, ok := strings.Cut(entry, "=")
+		profile = strings.TrimPrefix(profile, "mig-")
+		if !ok || !nvidiaMIGProfile.MatchString(profile) {
+			return nil, fmt.Errorf("invalid nvidia-mig-profile %s: must be in the form <profile>=<count>, for example 1g.5gb=7", entry)
+		}
+		count, err := strconv.Atoi(devices)
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("invalid nvidia-mig-profile %s: count must be a positive integer", entry)
+		}
+		if _, ok := profiles[profile]; ok {
+			return nil, fmt.Errorf("invalid nvidia-mig-profile %s: count for %s already set", entry, profile)
+		}
+		profiles[profile] = count
+	}
+	return profiles, nil
+}
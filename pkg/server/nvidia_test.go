@@ -0,0 +1,163 @@
+package server
+
+import (
+	"testing"
+)
+
+func Test_UnitNvidiaDevicePluginConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		migStrategy string
+		migProfiles []string
+		timeSlicing []string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name: "defaults",
+			want: `{"version":"v1","flags":{"migStrategy":"none"}}`,
+		},
+		{
+			name:        "mig strategy",
+			migStrategy: "single",
+			want:        `{"version":"v1","flags":{"migStrategy":"single"}}`,
+		},
+		{
+			name:        "invalid mig strategy",
+			migStrategy: "all",
+			wantErr:     true,
+		},
+		{
+			name:        "time-slicing full GPUs",
+			timeSlicing: []string{"4"},
+			want:        `{"version":"v1","flags":{"migStrategy":"none"},"sharing":{"timeSlicing":{"resources":[{"name":"nvidia.com/gpu","replicas":4}]}}}`,
+		},
+		{
+			name:        "time-slicing resources",
+			migStrategy: "mixed",
+			timeSlicing: []string{"gpu=2", " nvidia.com/mig-1g.5gb=3 ", ""},
+			want:        `{"version":"v1","flags":{"migStrategy":"mixed"},"sharing":{"timeSlicing":{"resources":[{"name":"nvidia.com/gpu","replicas":2},{"name":"nvidia.com/mig-1g.5gb","replicas":3}]}}}`,
+		},
+		{
+			name:        "time-slicing single replica",
+			timeSlicing: []string{"1"},
+			wantErr:     true,
+		},
+		{
+			name:        "time-slicing invalid replicas",
+			timeSlicing: []string{"nvidia.com/gpu=many"},
+			wantErr:     true,
+		},
+		{
+			name:        "time-slicing duplicate resource",
+			timeSlicing: []string{"4", "nvidia.com/gpu=2"},
+			wantErr:     true,
+		},
+		{
+			name:        "mig profiles default to mixed strategy",
+			migProfiles: []string{"1g.5gb=3", "2g.10gb=2"},
+			want:        `{"version":"v1","flags":{"migStrategy":"mixed"}}`,
+		},
+		{
+			name:        "mig profile with single strategy",
+			migStrategy: "single",
+			migProfiles: []string{"1g.5gb=7"},
+			want:        `{"version":"v1","flags":{"migStrategy":"single"}}`,
+		},
+		{
+			name:        "mig profiles with single strategy",
+			migStrategy: "single",
+			migProfiles: []string{"1g.5gb=3", "2g.10gb=2"},
+			wantErr:     true,
+		},
+		{
+			name:        "mig profiles with none strategy",
+			migStrategy: "none",
+			migProfiles: []string{"1g.5gb=7"},
+			wantErr:     true,
+		},
+		{
+			name:        "time-slicing mig profile",
+			migProfiles: []string{"1g.5gb=7"},
+			timeSlicing: []string{"mig-1g.5gb=2"},
+			want:        `{"version":"v1","flags":{"migStrategy":"mixed"},"sharing":{"timeSlicing":{"resources":[{"name":"nvidia.com/mig-1g.5gb","replicas":2}]}}}`,
+		},
+		{
+			name:        "time-slicing unconfigured mig profile",
+			migProfiles: []string{"1g.5gb=7"},
+			timeSlicing: []string{"mig-2g.10gb=2"},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid mig profile",
+			migProfiles: []string{"1g.5gb"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NvidiaDevicePluginConfig(tt.migStrategy, tt.migProfiles, tt.timeSlicing)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NvidiaDevicePluginConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NvidiaDevicePluginConfig() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitNvidiaMIGPartedConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		migProfiles []string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name: "no profiles",
+			want: `{"version":"v1","mig-configs":{"all-disabled":[{"devices":"all","mig-enabled":false}]}}`,
+		},
+		{
+			name:        "single profile",
+			migProfiles: []string{"1g.5gb=7"},
+			want:        `{"version":"v1","mig-configs":{"all-disabled":[{"devices":"all","mig-enabled":false}],"k3s":[{"devices":"all","mig-enabled":true,"mig-devices":{"1g.5gb":7}}]}}`,
+		},
+		{
+			name:        "mixed profiles",
+			migProfiles: []string{"mig-3g.20gb=1", " 1g.10gb+me=1 ", "1c.2g.10gb=2", ""},
+			want:        `{"version":"v1","mig-configs":{"all-disabled":[{"devices":"all","mig-enabled":false}],"k3s":[{"devices":"all","mig-enabled":true,"mig-devices":{"1c.2g.10gb":2,"1g.10gb+me":1,"3g.20gb":1}}]}}`,
+		},
+		{
+			name:        "invalid profile name",
+			migProfiles: []string{"small=2"},
+			wantErr:     true,
+		},
+		{
+			name:        "missing count",
+			migProfiles: []string{"1g.5gb"},
+			wantErr:     true,
+		},
+		{
+			name:        "zero count",
+			migProfiles: []string{"1g.5gb=0"},
+			wantErr:     true,
+		},
+		{
+			name:        "duplicate profile",
+			migProfiles: []string{"1g.5gb=3", "mig-1g.5gb=4"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NvidiaMIGPartedConfig(tt.migProfiles)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NvidiaMIGPartedConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NvidiaMIGPartedConfig() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
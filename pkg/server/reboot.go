@@ -0,0 +1,68 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/agent/reboot"
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// rebootLeaseHandler acquires the reboot lease for the requesting node with POST, and releases it with DELETE.
+// Nodes are not allowed to access leases outside the kube-node-lease namespace, so the lease that ensures that
+// only one node is drained and rebooted at a time is managed by the server.
+func rebootLeaseHandler(server *config.Control) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if req.Method != http.MethodPost && req.Method != http.MethodDelete {
+			resp.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if server.Runtime.K8s == nil {
+			genErrorMessage(resp, http.StatusServiceUnavailable, errors.New("runtime core not ready"), "reboot-lease")
+			return
+		}
+		user, ok := request.UserFrom(req.Context())
+		if !ok {
+			resp.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		name, ok := strings.CutPrefix(user.GetName(), "system:node:")
+		if !ok || name == "" {
+			genErrorMessage(resp, http.StatusForbidden, fmt.Errorf("user %s is not a node", user.GetName()), "reboot-lease")
+			return
+		}
+
+		leases := server.Runtime.K8s.CoordinationV1().Leases(metav1.NamespaceSystem)
+		if req.Method == http.MethodDelete {
+			if err := reboot.ReleaseLease(req.Context(), leases, name); err != nil {
+				genErrorMessage(resp, http.StatusInternalServerError, err, "reboot-lease")
+				return
+			}
+			resp.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		holder, err := reboot.AcquireLease(req.Context(), leases, name, time.Now())
+		if err != nil {
+			genErrorMessage(resp, http.StatusInternalServerError, err, "reboot-lease")
+			return
+		}
+		if holder != name {
+			resp.WriteHeader(http.StatusConflict)
+			resp.Write([]byte("reboot lease is held by node " + holder))
+			return
+		}
+		logrus.Infof("Node %s acquired the reboot lease", name)
+		resp.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/registrypolicy"
+	"github.com/k3s-io/k3s/pkg/version"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var (
+	registryPolicyWebhookName = "registry-policy." + version.Program + ".io"
+	registryPolicyPath        = "/v1-" + version.Program + "/admission/registry-policy"
+)
+
+// registryPolicyHandler serves the registry policy admission webhook. The webhook is called by
+// the apiserver, which does not present a client certificate, so requests are not authenticated.
+func registryPolicyHandler(control *config.Control) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		policy := control.RegistryPolicy
+		if policy == nil {
+			policy = &registrypolicy.Policy{Mode: registrypolicy.ModeDisabled}
+		}
+		policy.ServeHTTP(resp, req)
+	})
+}
+
+// setRegistryPolicyWebhook creates or updates the ValidatingWebhookConfiguration that sends pod
+// create and update requests to the registry policy webhook, or deletes it if the policy is disabled.
+// The webhook configuration is shared by all servers, so the URL uses the loopback address, which
+// every apiserver resolves to the supervisor running alongside it. This ensures that losing the
+// server that configured the webhook does not block pod creation on the remaining servers.
+// This is only run on the leader; the mode is a critical config arg that must match on all servers,
+// so a leader never removes a webhook that other servers expect to exist. kube-system is never sent
+// to the webhook, so that enforce mode cannot block the system components needed to recover.
+func setRegistryPolicyWebhook(ctx context.Context, sc *Context, control *config.Control) error {
+	client := sc.K8s.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+	name := version.Program + "-registry-policy"
+	policy := control.RegistryPolicy
+	if policy == nil || !policy.Enabled() {
+		if err := client.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	caBundle, err := os.ReadFile(control.Runtime.ServerCA)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("https://%s:%d%s", control.Loopback(true), control.SupervisorPort, registryPolicyPath)
+	failurePolicy := admissionregistrationv1.Ignore
+	if policy.Mode == registrypolicy.ModeEnforce {
+		failurePolicy = admissionregistrationv1.Fail
+	}
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	matchPolicy := admissionregistrationv1.Equivalent
+	namespaceSelector := &metav1.LabelSelector{}
+	if len(policy.ExemptNamespaces) > 0 {
+		namespaceSelector.MatchExpressions = []metav1.LabelSelectorRequirement{{
+			Key:      corev1.LabelMetadataName,
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   policy.ExemptNamespaces,
+		}}
+	}
+
+	webhookConfig := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"app.kubernetes.io/managed-by": version.Program},
+		},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+			Name: registryPolicyWebhookName,
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				URL:      &url,
+				CABundle: caBundle,
+			},
+			Rules: []admissionregistrationv1.RuleWithOperations{{
+				Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+				Rule: admissionregistrationv1.Rule{
+					APIGroups:   []string{""},
+					APIVersions: []string{"v1"},
+					Resources:   []string{"pods", "pods/ephemeralcontainers"},
+				},
+			}},
+			FailurePolicy:           &failurePolicy,
+			MatchPolicy:             &matchPolicy,
+			NamespaceSelector:       namespaceSelector,
+			SideEffects:             &sideEffects,
+			TimeoutSeconds:          pointer.Int32(5),
+			AdmissionReviewVersions: []string{"v1"},
+		}},
+	}
+
+	existing, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, webhookConfig, metav1.CreateOptions{})
+	} else if err == nil {
+		webhookConfig.ResourceVersion = existing.ResourceVersion
+		_, err = client.Update(ctx, webhookConfig, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to configure registry policy webhook")
+	}
+	logrus.Infof("Registry policy admission webhook configured in %s mode", policy.Mode)
+	return nil
+}
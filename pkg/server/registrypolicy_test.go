@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k3s-io/k3s/pkg/daemons/config"
+	"github.com/k3s-io/k3s/pkg/registrypolicy"
+	"github.com/k3s-io/k3s/pkg/version"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_UnitSetRegistryPolicyWebhook(t *testing.T) {
+	serverCA := filepath.Join(t.TempDir(), "server-ca.crt")
+	if err := os.WriteFile(serverCA, []byte("ca"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name              string
+		mode              string
+		bindAddress       string
+		wantWebhook       bool
+		wantURL           string
+		wantFailurePolicy admissionregistrationv1.FailurePolicyType
+	}{
+		{
+			name: "disabled",
+			mode: registrypolicy.ModeDisabled,
+		},
+		{
+			name:              "audit",
+			mode:              registrypolicy.ModeAudit,
+			wantWebhook:       true,
+			wantURL:           "https://127.0.0.1:9345" + registryPolicyPath,
+			wantFailurePolicy: admissionregistrationv1.Ignore,
+		},
+		{
+			name:              "enforce with unspecified bind address",
+			mode:              registrypolicy.ModeEnforce,
+			bindAddress:       "0.0.0.0",
+			wantWebhook:       true,
+			wantURL:           "https://127.0.0.1:9345" + registryPolicyPath,
+			wantFailurePolicy: admissionregistrationv1.Fail,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := registrypolicy.New(tt.mode, nil, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			control := &config.Control{
+				SupervisorPort:        9345,
+				SupervisorBindAddress: tt.bindAddress,
+				RegistryPolicy:        policy,
+				Runtime:               &config.ControlRuntime{},
+			}
+			control.Runtime.ServerCA = serverCA
+			sc := &Context{K8s: fake.NewSimpleClientset(&admissionregistrationv1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: version.Program + "-registry-policy"},
+			})}
+
+			if err := setRegistryPolicyWebhook(context.Background(), sc, control); err != nil {
+				t.Fatalf("setRegistryPolicyWebhook() error = %v", err)
+			}
+			webhookConfig, err := sc.K8s.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(context.Background(), version.Program+"-registry-policy", metav1.GetOptions{})
+			if !tt.wantWebhook {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("setRegistryPolicyWebhook() did not delete webhook: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setRegistryPolicyWebhook() did not configure webhook: %v", err)
+			}
+			webhook := webhookConfig.Webhooks[0]
+			if url := *webhook.ClientConfig.URL; url != tt.wantURL {
+				t.Errorf("setRegistryPolicyWebhook() URL = %s, want %s", url, tt.wantURL)
+			}
+			if *webhook.FailurePolicy != tt.wantFailurePolicy {
+				t.Errorf("setRegistryPolicyWebhook() FailurePolicy = %s, want %s", *webhook.FailurePolicy, tt.wantFailurePolicy)
+			}
+		})
+	}
+}
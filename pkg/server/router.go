@@ -20,6 +20,7 @@ import (
 	"github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/generated/clientset/versioned/scheme"
 	"github.com/k3s-io/k3s/pkg/nodepassword"
+	"github.com/k3s-io/k3s/pkg/ratelimit"
 	"github.com/k3s-io/k3s/pkg/util"
 	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/pkg/errors"
@@ -49,8 +50,14 @@ func router(ctx context.Context, config *Config, cfg *cmds.Server) http.Handler
 	serverConfig := &config.ControlConfig
 	nodeAuth := passwordBootstrap(ctx, config)
 
+	// Requests to the registration and bootstrap endpoints, which are authenticated by token, are rate
+	// limited per source address, and addresses that repeatedly fail to authenticate are locked out if a
+	// failure limit is set.
+	limiter := ratelimit.New(serverConfig.SupervisorRateLimit, serverConfig.SupervisorRateBurst, serverConfig.AuthFailureLimit, serverConfig.AuthLockoutDuration)
+
 	prefix := "/v1-" + version.Program
 	authed := mux.NewRouter().SkipClean(true)
+	authed.Use(limiter.Middleware)
 	authed.Use(authMiddleware(serverConfig, version.Program+":agent", user.NodesGroup, bootstrapapi.BootstrapDefaultGroup))
 	authed.Path(prefix + "/serving-kubelet.crt").Handler(servingKubeletCert(serverConfig, serverConfig.Runtime.ServingKubeletKey, nodeAuth))
 	authed.Path(prefix + "/client-kubelet.crt").Handler(clientKubeletCert(serverConfig, serverConfig.Runtime.ClientKubeletKey, nodeAuth))
@@ -60,7 +67,7 @@ func router(ctx context.Context, config *Config, cfg *cmds.Server) http.Handler
 	authed.Path(prefix + "/server-ca.crt").Handler(fileHandler(serverConfig.Runtime.ServerCA))
 	authed.Path(prefix + "/apiservers").Handler(apiserversHandler(serverConfig))
 	authed.Path(prefix + "/config").Handler(configHandler(serverConfig, cfg))
-	authed.Path(prefix + "/readyz").Handler(readyzHandler(serverConfig))
+	authed.Path(prefix + "/kubelet-settings").Handler(kubeletSettingsHandler(serverConfig))
 
 	if cfg.DisableAPIServer {
 		authed.NotFoundHandler = apiserverDisabled()
@@ -72,13 +79,24 @@ func router(ctx context.Context, config *Config, cfg *cmds.Server) http.Handler
 	nodeAuthed.NotFoundHandler = authed
 	nodeAuthed.Use(authMiddleware(serverConfig, user.NodesGroup))
 	nodeAuthed.Path(prefix + "/connect").Handler(serverConfig.Runtime.Tunnel)
+	nodeAuthed.Path(prefix + "/node-taints").Handler(nodeTaintsHandler(serverConfig))
+	nodeAuthed.Path(prefix + "/reboot-lease").Handler(rebootLeaseHandler(serverConfig))
 
 	serverAuthed := mux.NewRouter().SkipClean(true)
 	serverAuthed.NotFoundHandler = nodeAuthed
+	serverAuthed.Use(limiter.Middleware)
 	serverAuthed.Use(authMiddleware(serverConfig, version.Program+":server"))
 	serverAuthed.Path(prefix + "/encrypt/status").Handler(encryptionStatusHandler(serverConfig))
 	serverAuthed.Path(prefix + "/encrypt/config").Handler(encryptionConfigHandler(ctx, serverConfig))
 	serverAuthed.Path(prefix + "/cert/cacerts").Handler(caCertReplaceHandler(serverConfig))
+	serverAuthed.Path(prefix + "/nodes").Handler(nodesHandler(serverConfig))
+	serverAuthed.Path(prefix + "/nodes/{name}").Handler(nodesHandler(serverConfig))
+	serverAuthed.Path(prefix + "/nodes/{name}/{action}").Handler(nodeActionHandler(ctx, serverConfig))
+	serverAuthed.Path(prefix + "/etcd/snapshot").Handler(snapshotHandler(ctx, serverConfig))
+	serverAuthed.Path(prefix + "/etcd/members").Handler(etcdMembersHandler(ctx, serverConfig))
+	serverAuthed.Path(prefix + "/etcd/members/{name}/{action}").Handler(etcdMemberActionHandler(ctx, serverConfig))
+	serverAuthed.Path(prefix + "/logs").Handler(logsHandler(serverConfig))
+	serverAuthed.Path(prefix + "/agent/reload").Handler(agentReloadHandler(config))
 	serverAuthed.Path("/db/info").Handler(nodeAuthed)
 	serverAuthed.Path(prefix + "/server-bootstrap").Handler(bootstrapHandler(serverConfig.Runtime))
 
@@ -92,8 +110,11 @@ func router(ctx context.Context, config *Config, cfg *cmds.Server) http.Handler
 	router := mux.NewRouter().SkipClean(true)
 	router.NotFoundHandler = systemAuthed
 	router.PathPrefix(staticURL).Handler(serveStatic(staticURL, staticDir))
-	router.Path("/cacerts").Handler(cacerts(serverConfig.Runtime.ServerCA))
+	router.Path("/cacerts").Handler(limiter.Middleware(cacerts(serverConfig.Runtime.ServerCA)))
 	router.Path("/ping").Handler(ping())
+	router.Path(prefix + "/readyz").Handler(readyzHandler(serverConfig))
+	router.Path(prefix + "/livez").Handler(livezHandler(serverConfig))
+	router.Path(registryPolicyPath).Handler(registryPolicyHandler(serverConfig))
 
 	return router
 }
@@ -369,21 +390,6 @@ func configHandler(server *config.Control, cfg *cmds.Server) http.Handler {
 	})
 }
 
-func readyzHandler(server *config.Control) http.Handler {
-	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
-		code := http.StatusOK
-		data := []byte("ok")
-		if server.Runtime.Core == nil {
-			code = http.StatusInternalServerError
-			data = []byte("runtime core not ready")
-		}
-		resp.WriteHeader(code)
-		resp.Header().Set("Content-Type", "text/plain")
-		resp.Header().Set("Content-length", strconv.Itoa(len(data)))
-		resp.Write(data)
-	})
-}
-
 func ping() http.Handler {
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
 		data := []byte("pong")
@@ -439,6 +445,10 @@ func passwordBootstrap(ctx context.Context, config *Config) nodePassBootstrapper
 			return "", http.StatusBadRequest, errors.New("header node name does not match auth node name")
 		}
 
+		if err := checkClockSkew(&config.ControlConfig, node.Name, req); err != nil {
+			return "", http.StatusPreconditionFailed, err
+		}
+
 		if secretClient == nil || nodeClient == nil {
 			if runtime.Core != nil {
 				// initialize the client if we can
@@ -447,7 +457,7 @@ func passwordBootstrap(ctx context.Context, config *Config) nodePassBootstrapper
 			} else if node.Name == os.Getenv("NODE_NAME") {
 				// If we're verifying our own password, verify it locally and ensure a secret later.
 				return verifyLocalPassword(ctx, config, &mu, deferredNodes, node)
-			} else if config.ControlConfig.DisableAPIServer && !isNodeAuth {
+			} else if config.ControlConfig.DisableAPIServer && !isNodeAuth && !manualJoinApproval(&config.ControlConfig) {
 				// If we're running on an etcd-only node, and the request didn't use Node Identity auth,
 				// defer node password verification until an apiserver joins the cluster.
 				return verifyRemotePassword(ctx, config, &mu, deferredNodes, node)
@@ -461,6 +471,12 @@ func passwordBootstrap(ctx context.Context, config *Config) nodePassBootstrapper
 			return "", http.StatusUnauthorized, err
 		}
 
+		if manualJoinApproval(&config.ControlConfig) && !isNodeAuth && node.Name != os.Getenv("NODE_NAME") {
+			if code, err := checkNodeJoinApproval(runtime, secretClient, node, req); err != nil {
+				return "", code, err
+			}
+		}
+
 		if err := nodepassword.Ensure(secretClient, node.Name, node.Password); err != nil {
 			return "", http.StatusForbidden, err
 		}
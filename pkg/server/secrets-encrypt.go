@@ -16,8 +16,10 @@ import (
 	"github.com/k3s-io/k3s/pkg/cluster"
 	"github.com/k3s-io/k3s/pkg/daemons/config"
 	"github.com/k3s-io/k3s/pkg/secretsencrypt"
+	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/rancher/wrangler/pkg/generated/controllers/core"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/config/v1"
@@ -33,6 +35,11 @@ type EncryptionState struct {
 	HashMatch    bool     `json:"hashmatch,omitempty"`
 	HashError    string   `json:"hasherror,omitempty"`
 	InactiveKeys []string `json:"inactivekeys,omitempty"`
+	Resources    []string `json:"resources,omitempty"`
+	DecryptOnly  []string `json:"decryptonly,omitempty"`
+	KMSProvider  string   `json:"kmsprovider,omitempty"`
+
+	ReencryptProgress []secretsencrypt.ReencryptProgress `json:"reencryptprogress,omitempty"`
 }
 
 type EncryptionRequest struct {
@@ -80,9 +87,9 @@ func encryptionStatus(server *config.Control) (EncryptionState, error) {
 	} else if err != nil {
 		return state, err
 	}
-	if providers[1].Identity != nil && providers[0].AESCBC != nil {
-		state.Enable = pointer.Bool(true)
-	} else if providers[0].Identity != nil && providers[1].AESCBC != nil || !server.EncryptSecrets {
+	if enabled, err := secretsencrypt.IsEncryptionEnabled(providers); err == nil {
+		state.Enable = pointer.Bool(enabled)
+	} else if !server.EncryptSecrets {
 		state.Enable = pointer.Bool(false)
 	}
 
@@ -97,8 +104,29 @@ func encryptionStatus(server *config.Control) (EncryptionState, error) {
 		return state, err
 	}
 	state.Stage = stage
+	state.Resources, err = secretsencrypt.GetEncryptionResources(server.Runtime)
+	if err != nil {
+		return state, err
+	}
+	state.DecryptOnly, err = secretsencrypt.GetDecryptOnlyResources(server.Runtime)
+	if err != nil {
+		return state, err
+	}
+	state.ReencryptProgress, err = secretsencrypt.GetReencryptProgress(server.Runtime.Core.Core().V1().ConfigMap())
+	if err != nil {
+		return state, err
+	}
 	active := true
 	for _, p := range providers {
+		if p.KMS != nil {
+			state.KMSProvider = p.KMS.Name
+			if active {
+				active = false
+				state.ActiveKey = p.KMS.Name
+			} else {
+				state.InactiveKeys = append(state.InactiveKeys, p.KMS.Name)
+			}
+		}
 		if p.AESCBC != nil {
 			for _, aesKey := range p.AESCBC.Keys {
 				if active {
@@ -122,35 +150,73 @@ func encryptionEnable(ctx context.Context, server *config.Control, enable bool)
 	if err != nil {
 		return err
 	}
-	if len(providers) > 2 {
-		return fmt.Errorf("more than 2 providers (%d) found in secrets encryption", len(providers))
+	enabled, err := secretsencrypt.IsEncryptionEnabled(providers)
+	if err != nil {
+		return fmt.Errorf("unable to enable/disable secrets encryption: %v", err)
 	}
 	curKeys, err := secretsencrypt.GetEncryptionKeys(server.Runtime)
 	if err != nil {
 		return err
 	}
-	if providers[1].Identity != nil && providers[0].AESCBC != nil && !enable {
+	curKMS, err := secretsencrypt.GetKMSProvider(server.Runtime)
+	if err != nil {
+		return err
+	}
+	if !enable {
+		if !enabled {
+			logrus.Infoln("Secrets encryption already disabled")
+			return nil
+		}
 		logrus.Infoln("Disabling secrets encryption")
-		if err := secretsencrypt.WriteEncryptionConfig(server.Runtime, curKeys, enable); err != nil {
+		if err := secretsencrypt.WriteEncryptionConfig(server.Runtime, server.EncryptResources, curKeys, curKMS, enable); err != nil {
 			return err
 		}
-	} else if !enable {
-		logrus.Infoln("Secrets encryption already disabled")
-		return nil
-	} else if providers[0].Identity != nil && providers[1].AESCBC != nil && enable {
+		return cluster.Save(ctx, server, true)
+	}
+
+	kms, err := secretsencrypt.SelectKMSProvider(curKMS, server.EncryptKMSProvider)
+	if err != nil {
+		return err
+	}
+	if !enabled {
 		logrus.Infoln("Enabling secrets encryption")
-		if err := secretsencrypt.WriteEncryptionConfig(server.Runtime, curKeys, enable); err != nil {
+	} else {
+		// Enabling encryption when it is already enabled applies any change to the list of encrypted resources,
+		// or to the KMS provider.
+		curResources, err := secretsencrypt.GetEncryptionResources(server.Runtime)
+		if err != nil {
 			return err
 		}
-	} else if enable {
-		logrus.Infoln("Secrets encryption already enabled")
-		return nil
-	} else {
-		return fmt.Errorf("unable to enable/disable secrets encryption, unknown configuration")
+		if equality.Semantic.DeepEqual(curResources, server.EncryptResources) && equality.Semantic.DeepEqual(curKMS, kms) {
+			logrus.Infoln("Secrets encryption already enabled")
+			return nil
+		}
+		if !equality.Semantic.DeepEqual(curResources, server.EncryptResources) {
+			logrus.Infof("Updating encrypted resources to %v; resources that are no longer listed remain readable until '%s secrets-encrypt reencrypt' completes", server.EncryptResources, version.Program)
+		}
+	}
+	if kms != nil && !equality.Semantic.DeepEqual(curKMS, kms) {
+		logrus.Infof("Encrypting new data with KMS provider %s at %s", kms.Name, kms.Endpoint)
+	}
+	if err := secretsencrypt.WriteEncryptionConfig(server.Runtime, server.EncryptResources, curKeys, kms, enable); err != nil {
+		return err
 	}
 	return cluster.Save(ctx, server, true)
 }
 
+// errKMSManagedKeys returns an error if new data is encrypted by a KMS provider, as its keys are rotated by the
+// KMS rather than by preparing and rotating local keys.
+func errKMSManagedKeys(server *config.Control) error {
+	kms, err := secretsencrypt.GetKMSProvider(server.Runtime)
+	if err != nil {
+		return err
+	}
+	if kms != nil {
+		return fmt.Errorf("secrets encryption keys are managed by KMS provider %s; rotate the key in the KMS, then run '%s secrets-encrypt reencrypt' to reencrypt existing data", kms.Name, version.Program)
+	}
+	return nil
+}
+
 func encryptionConfigHandler(ctx context.Context, server *config.Control) http.Handler {
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
 		if req.TLS == nil {
@@ -199,6 +265,9 @@ func encryptionPrepare(ctx context.Context, server *config.Control, force bool)
 	if err := verifyEncryptionHashAnnotation(server.Runtime, server.Runtime.Core.Core(), states); err != nil && !force {
 		return err
 	}
+	if err := errKMSManagedKeys(server); err != nil {
+		return err
+	}
 
 	curKeys, err := secretsencrypt.GetEncryptionKeys(server.Runtime)
 	if err != nil {
@@ -210,7 +279,7 @@ func encryptionPrepare(ctx context.Context, server *config.Control, force bool)
 	}
 	logrus.Infoln("Adding secrets-encryption key: ", curKeys[len(curKeys)-1])
 
-	if err := secretsencrypt.WriteEncryptionConfig(server.Runtime, curKeys, true); err != nil {
+	if err := secretsencrypt.WriteEncryptionConfig(server.Runtime, server.EncryptResources, curKeys, nil, true); err != nil {
 		return err
 	}
 	nodeName := os.Getenv("NODE_NAME")
@@ -229,6 +298,9 @@ func encryptionRotate(ctx context.Context, server *config.Control, force bool) e
 	if err := verifyEncryptionHashAnnotation(server.Runtime, server.Runtime.Core.Core(), secretsencrypt.EncryptionPrepare); err != nil && !force {
 		return err
 	}
+	if err := errKMSManagedKeys(server); err != nil {
+		return err
+	}
 
 	curKeys, err := secretsencrypt.GetEncryptionKeys(server.Runtime)
 	if err != nil {
@@ -238,7 +310,7 @@ func encryptionRotate(ctx context.Context, server *config.Control, force bool) e
 	// Right rotate elements
 	rotatedKeys := append(curKeys[len(curKeys)-1:], curKeys[:len(curKeys)-1]...)
 
-	if err = secretsencrypt.WriteEncryptionConfig(server.Runtime, rotatedKeys, true); err != nil {
+	if err = secretsencrypt.WriteEncryptionConfig(server.Runtime, server.EncryptResources, rotatedKeys, nil, true); err != nil {
 		return err
 	}
 	logrus.Infoln("Encryption keys right rotated")
@@ -254,8 +326,15 @@ func encryptionRotate(ctx context.Context, server *config.Control, force bool) e
 }
 
 func encryptionReencrypt(ctx context.Context, server *config.Control, force bool, skip bool) error {
-
-	if err := verifyEncryptionHashAnnotation(server.Runtime, server.Runtime.Core.Core(), secretsencrypt.EncryptionRotate); err != nil && !force {
+	// Keys managed by a KMS provider are rotated without preparing and rotating local keys, so existing data may be
+	// reencrypted at any time.
+	states := secretsencrypt.EncryptionRotate
+	if kms, err := secretsencrypt.GetKMSProvider(server.Runtime); err != nil {
+		return err
+	} else if kms != nil {
+		states = secretsencrypt.EncryptionStart + "-" + secretsencrypt.EncryptionRotate + "-" + secretsencrypt.EncryptionReencryptFinished
+	}
+	if err := verifyEncryptionHashAnnotation(server.Runtime, server.Runtime.Core.Core(), states); err != nil && !force {
 		return err
 	}
 	server.EncryptForce = force
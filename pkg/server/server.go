@@ -22,6 +22,7 @@ import (
 	"github.com/k3s-io/k3s/pkg/deploy"
 	"github.com/k3s-io/k3s/pkg/node"
 	"github.com/k3s-io/k3s/pkg/nodepassword"
+	"github.com/k3s-io/k3s/pkg/nsdefaults"
 	"github.com/k3s-io/k3s/pkg/rootlessports"
 	"github.com/k3s-io/k3s/pkg/secretsencrypt"
 	"github.com/k3s-io/k3s/pkg/static"
@@ -40,6 +41,10 @@ const (
 	MasterRoleLabelKey       = "node-role.kubernetes.io/master"
 	ControlPlaneRoleLabelKey = "node-role.kubernetes.io/control-plane"
 	ETCDRoleLabelKey         = "node-role.kubernetes.io/etcd"
+
+	// gatewayAPIStaticFile is the path of the Gateway API CRDs within the static files, as downloaded at build time.
+	gatewayAPIStaticFile = "gateway-api/crds.yaml"
+	gatewayAPIManifest   = "gateway-api-crds.yaml"
 )
 
 func ResolveDataDir(dataDir string) (string, error) {
@@ -118,7 +123,9 @@ func runControllers(ctx context.Context, config *Config) error {
 		controlConfig.Runtime.NodePasswdFile); err != nil {
 		logrus.Warn(errors.Wrap(err, "error migrating node-password file"))
 	}
+	controlConfig.Runtime.K3s = sc.K3s
 	controlConfig.Runtime.Core = sc.Core
+	controlConfig.Runtime.K8s = sc.K8s
 
 	for name, cb := range controlConfig.Runtime.ClusterControllerStarts {
 		go runOrDie(ctx, name, cb)
@@ -134,9 +141,15 @@ func runControllers(ctx context.Context, config *Config) error {
 		return errors.Wrap(err, "failed to start wranger controllers")
 	}
 
+	// apply SystemDefaultRegistry setting to Helm before starting controllers. This is done once, as the
+	// leader-elected controllers are started again each time this server becomes leader.
+	if controlConfig.SystemDefaultRegistry != "" {
+		helm.DefaultJobImage = controlConfig.SystemDefaultRegistry + "/" + helm.DefaultJobImage
+	}
+
 	if !controlConfig.DisableAPIServer {
-		controlConfig.Runtime.LeaderElectedClusterControllerStarts[version.Program] = func(ctx context.Context) {
-			apiserverControllers(ctx, sc, config)
+		controlConfig.Runtime.LeaderElectedClusterControllerStarts[version.Program] = func(leaderCtx context.Context) {
+			apiserverControllers(ctx, leaderCtx, sc, config)
 		}
 	}
 
@@ -144,33 +157,35 @@ func runControllers(ctx context.Context, config *Config) error {
 
 	go setClusterDNSConfig(ctx, config, sc.Core.Core().V1().ConfigMap())
 
+	go setSupervisorAdvertiseAddress(ctx, sc.Core.Core().V1().Endpoints(), config)
+
 	if controlConfig.NoLeaderElect {
 		for name, cb := range controlConfig.Runtime.LeaderElectedClusterControllerStarts {
 			go runOrDie(ctx, name, cb)
 		}
 	} else {
-		for name, cb := range controlConfig.Runtime.LeaderElectedClusterControllerStarts {
-			go leader.RunOrDie(ctx, "", name, sc.K8s, cb)
-		}
+		return startLeaderElection(ctx, controlConfig, sc.K8s)
 	}
 
 	return nil
 }
 
 // apiServerControllers starts the core controllers, as well as the leader-elected controllers
-// that should only run on a control-plane node.
-func apiserverControllers(ctx context.Context, sc *Context, config *Config) {
-	if err := coreControllers(ctx, sc, config); err != nil {
+// that should only run on a control-plane node. The controllers are stopped when the leader
+// context is cancelled, so that they can be started again if this server regains leadership.
+func apiserverControllers(ctx, leaderCtx context.Context, sc *Context, config *Config) {
+	if err := coreControllers(leaderCtx, sc, config); err != nil {
 		panic(err)
 	}
 	for _, controller := range config.LeaderControllers {
-		if err := controller(ctx, sc); err != nil {
+		if err := controller(leaderCtx, sc); err != nil {
 			panic(errors.Wrapf(err, "failed to start %s leader controller", util.GetFunctionName(controller)))
 		}
 	}
 
 	// Re-run context startup after core and leader-elected controllers have started. Additional
-	// informer caches may need to start for the newly added OnChange callbacks.
+	// informer caches may need to start for the newly added OnChange callbacks. The caches are
+	// shared, and cannot be restarted once stopped, so they run until the server is stopped.
 	if err := sc.Start(ctx); err != nil {
 		panic(errors.Wrap(err, "failed to start wranger controllers"))
 	}
@@ -190,6 +205,10 @@ func runOrDie(ctx context.Context, name string, cb leader.Callback) {
 
 // coreControllers starts the following controllers, if they are enabled:
 // * Node controller (manages nodes passwords and coredns hosts file)
+// * Node webhooks
+// * Stale node password cleanup
+// * Node clock skew monitor
+// * Registry policy admission webhook
 // * Helm controller
 // * Secrets encryption
 // * Rootless ports
@@ -203,9 +222,40 @@ func coreControllers(ctx context.Context, sc *Context, config *Config) error {
 		return err
 	}
 
-	// apply SystemDefaultRegistry setting to Helm before starting controllers
-	if config.ControlConfig.SystemDefaultRegistry != "" {
-		helm.DefaultJobImage = config.ControlConfig.SystemDefaultRegistry + "/" + helm.DefaultJobImage
+	if len(config.ControlConfig.NodeWebhookURLs) > 0 {
+		if err := node.RegisterWebhooks(ctx,
+			config.ControlConfig.NodeWebhookURLs,
+			config.ControlConfig.NodeWebhookNotReady,
+			sc.Core.Core().V1().Secret(),
+			sc.Core.Core().V1().Node()); err != nil {
+			return err
+		}
+	}
+
+	if config.ControlConfig.StaleNodeCleanupDays > 0 {
+		if err := node.RegisterCleanup(ctx,
+			time.Duration(config.ControlConfig.StaleNodeCleanupDays)*24*time.Hour,
+			sc.Core.Core().V1().Secret(),
+			sc.Core.Core().V1().Node(),
+			sc.K3s.K3s().V1().NodeJoinRequest()); err != nil {
+			return err
+		}
+	}
+
+	if config.ControlConfig.ClockSkewThreshold > 0 {
+		if err := node.RegisterClockSkewMonitor(ctx, config.ControlConfig.ClockSkewThreshold, sc.K8s); err != nil {
+			return err
+		}
+	}
+
+	if err := setRegistryPolicyWebhook(ctx, sc, &config.ControlConfig); err != nil {
+		return err
+	}
+
+	if config.ControlConfig.NamespaceDefaults != nil {
+		if err := nsdefaults.Register(ctx, config.ControlConfig.NamespaceDefaults, sc.K8s, sc.Core.Core().V1().Namespace()); err != nil {
+			return err
+		}
 	}
 
 	if !config.ControlConfig.DisableHelmController {
@@ -232,7 +282,8 @@ func coreControllers(ctx context.Context, sc *Context, config *Config) error {
 			sc.K8s,
 			&config.ControlConfig,
 			sc.Core.Core().V1().Node(),
-			sc.Core.Core().V1().Secret()); err != nil {
+			sc.Dynamic,
+			sc.Core.Core().V1().ConfigMap()); err != nil {
 			return err
 		}
 	}
@@ -241,7 +292,8 @@ func coreControllers(ctx context.Context, sc *Context, config *Config) error {
 		return rootlessports.Register(ctx,
 			sc.Core.Core().V1().Service(),
 			!config.ControlConfig.DisableServiceLB,
-			config.ControlConfig.HTTPSPort)
+			config.ControlConfig.HTTPSPort,
+			config.ControlConfig.FlannelBackend)
 	}
 
 	return nil
@@ -256,14 +308,7 @@ func stageFiles(ctx context.Context, sc *Context, controlConfig *config.Control)
 		return err
 	}
 	dataDir = filepath.Join(controlConfig.DataDir, "manifests")
-	templateVars := map[string]string{
-		"%{CLUSTER_DNS}%":                 controlConfig.ClusterDNS.String(),
-		"%{CLUSTER_DOMAIN}%":              controlConfig.ClusterDomain,
-		"%{DEFAULT_LOCAL_STORAGE_PATH}%":  controlConfig.DefaultLocalStoragePath,
-		"%{SYSTEM_DEFAULT_REGISTRY}%":     registryTemplate(controlConfig.SystemDefaultRegistry),
-		"%{SYSTEM_DEFAULT_REGISTRY_RAW}%": controlConfig.SystemDefaultRegistry,
-		"%{PREFERRED_ADDRESS_TYPES}%":     addrTypesPrioTemplate(controlConfig.FlannelExternalIP),
-	}
+	templateVars := ManifestTemplateVars(controlConfig)
 
 	skip := controlConfig.Skips
 	if !skip["traefik"] && isHelmChartTraefikV1(sc) {
@@ -273,8 +318,14 @@ func stageFiles(ctx context.Context, sc *Context, controlConfig *config.Control)
 	if err := deploy.Stage(dataDir, templateVars, skip); err != nil {
 		return err
 	}
+	if err := stageGatewayAPICRDs(controlConfig, dataDir); err != nil {
+		return err
+	}
 
+	ready := make(chan struct{})
+	controlConfig.Runtime.DeployReady = ready
 	return deploy.WatchFiles(ctx,
+		ready,
 		sc.K8s,
 		sc.Apply,
 		sc.K3s.K3s().V1().Addon(),
@@ -282,6 +333,52 @@ func stageFiles(ctx context.Context, sc *Context, controlConfig *config.Control)
 		dataDir)
 }
 
+// GatewayAPICRDs returns the name and content of the Gateway API CRDs manifest that is staged when Gateway API is
+// enabled. The content is nil if the CRDs are not included in this build.
+func GatewayAPICRDs() (string, []byte) {
+	return gatewayAPIManifest, static.Read(gatewayAPIStaticFile)
+}
+
+// stageGatewayAPICRDs copies the Gateway API CRDs from the static files to the manifests directory if Gateway
+// API is enabled, so that they are applied by the deploy controller. If Gateway API is not enabled, the manifest
+// is removed, but the CRDs are left in place, as deleting them would delete any Gateway API resources.
+func stageGatewayAPICRDs(controlConfig *config.Control, manifestsDir string) error {
+	p := filepath.Join(manifestsDir, gatewayAPIManifest)
+	if !controlConfig.EnableGatewayAPI {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	content, err := os.ReadFile(filepath.Join(controlConfig.DataDir, "static", gatewayAPIStaticFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			logrus.Warnf("Gateway API is enabled, but the Gateway API CRDs are not included in this build; install them manually")
+			return nil
+		}
+		return err
+	}
+	logrus.Info("Writing manifest: ", p)
+	return os.WriteFile(p, content, 0600)
+}
+
+// ManifestTemplateVars returns the values that template variables in packaged manifests are replaced with.
+func ManifestTemplateVars(controlConfig *config.Control) map[string]string {
+	return map[string]string{
+		"%{CLUSTER_CIDR}%":                controlConfig.ClusterIPRange.String(),
+		"%{CLUSTER_DNS}%":                 controlConfig.ClusterDNS.String(),
+		"%{CLUSTER_DOMAIN}%":              controlConfig.ClusterDomain,
+		"%{DEFAULT_LOCAL_STORAGE_PATH}%":  controlConfig.DefaultLocalStoragePath,
+		"%{SYSTEM_DEFAULT_REGISTRY}%":     registryTemplate(controlConfig.SystemDefaultRegistry),
+		"%{SYSTEM_DEFAULT_REGISTRY_RAW}%": controlConfig.SystemDefaultRegistry,
+		"%{PREFERRED_ADDRESS_TYPES}%":     addrTypesPrioTemplate(controlConfig.FlannelExternalIP),
+		"%{NVIDIA_DEVICE_PLUGIN_CONFIG}%": controlConfig.NvidiaDevicePluginConfig,
+		"%{NVIDIA_MIG_PARTED_CONFIG}%":    controlConfig.NvidiaMIGPartedConfig,
+		"%{NODE_LOCAL_DNS}%":              config.NodeLocalDNSAddress,
+		"%{ENABLE_GATEWAY_API}%":          strconv.FormatBool(controlConfig.EnableGatewayAPI),
+	}
+}
+
 // registryTemplate behaves like the system_default_registry template in Rancher helm charts,
 // and returns the registry value with a trailing forward slash if the registry string is not empty.
 // If it is empty, it is passed through as a no-op.
@@ -354,7 +451,7 @@ func printTokens(config *config.Control) error {
 		}
 
 		logrus.Infof("Server node token is available at %s", serverTokenFile)
-		printToken(config.SupervisorPort, config.BindAddressOrLoopback(true, true), "To join server node to cluster:", "server", "SERVER_NODE_TOKEN")
+		printToken(config.SupervisorPort, config.SupervisorAdvertiseAddressOrLoopback(true, true), "To join server node to cluster:", "server", "SERVER_NODE_TOKEN")
 	}
 
 	var agentTokenFile string
@@ -384,7 +481,7 @@ func printTokens(config *config.Control) error {
 
 	if agentTokenFile != "" {
 		logrus.Infof("Agent node token is available at %s", agentTokenFile)
-		printToken(config.SupervisorPort, config.BindAddressOrLoopback(true, true), "To join agent node to cluster:", "agent", "AGENT_NODE_TOKEN")
+		printToken(config.SupervisorPort, config.SupervisorAdvertiseAddressOrLoopback(true, true), "To join agent node to cluster:", "agent", "AGENT_NODE_TOKEN")
 	}
 
 	return nil
@@ -488,20 +585,17 @@ func writeToken(token, file, certs string) error {
 }
 
 func setNoProxyEnv(config *config.Control) error {
-	splitter := func(c rune) bool {
-		return c == ','
-	}
-	envList := []string{}
-	envList = append(envList, strings.FieldsFunc(os.Getenv("NO_PROXY"), splitter)...)
-	envList = append(envList, strings.FieldsFunc(os.Getenv("no_proxy"), splitter)...)
-	envList = append(envList,
+	// The server's own names and addresses are included, so that connections between
+	// servers and to the local apiserver and supervisor are not sent through the proxy.
+	noProxy := util.AppendNoProxy(os.Getenv("NO_PROXY"), os.Getenv("no_proxy"),
 		".svc",
 		"."+config.ClusterDomain,
 		util.JoinIPNets(config.ClusterIPRanges),
 		util.JoinIPNets(config.ServiceIPRanges),
+		strings.Join(config.SANs, ","),
 	)
 	os.Unsetenv("no_proxy")
-	return os.Setenv("NO_PROXY", strings.Join(envList, ","))
+	return os.Setenv("NO_PROXY", noProxy)
 }
 
 func writeConfigSymlink(kubeconfig, kubeconfigSymlink string) error {
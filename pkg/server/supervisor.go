@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/k3s-io/k3s/pkg/util"
+	v1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// setSupervisorAdvertiseAddress publishes the supervisor advertise address of this server in an annotation
+// on the kubernetes service endpoints, so that agents connect to the supervisor at this address instead of
+// the apiserver address. If no supervisor advertise address is set, any address previously published
+// by this server is removed.
+func setSupervisorAdvertiseAddress(ctx context.Context, endpoints v1.EndpointsClient, config *Config) error {
+	if config.ControlConfig.DisableAPIServer {
+		return nil
+	}
+	apiServerAddress := apiServerAdvertiseAddress(config)
+	if apiServerAddress == "" {
+		logrus.Warn("Unable to determine apiserver advertise address; supervisor advertise address will not be published")
+		return nil
+	}
+	var supervisorAddress string
+	if config.ControlConfig.SupervisorAdvertiseAddress != "" {
+		supervisorAddress = net.JoinHostPort(config.ControlConfig.SupervisorAdvertiseAddress, strconv.Itoa(config.ControlConfig.SupervisorPort))
+	}
+
+	for {
+		endpoint, err := endpoints.Get(metav1.NamespaceDefault, "kubernetes", metav1.GetOptions{})
+		if err == nil {
+			if !updateSupervisorAddresses(endpoint, apiServerAddress, supervisorAddress) {
+				return nil
+			}
+			if _, err = endpoints.Update(endpoint); err == nil {
+				logrus.Infof("Published supervisor advertise address %q for apiserver %s", supervisorAddress, apiServerAddress)
+				return nil
+			}
+		}
+		if !apierrors.IsConflict(err) {
+			logrus.Infof("Waiting to publish supervisor advertise address: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// updateSupervisorAddresses sets the supervisor address for the given apiserver address in the
+// supervisor addresses annotation, or removes it if the supervisor address is empty. It returns
+// true if the annotation was changed.
+func updateSupervisorAddresses(endpoint *corev1.Endpoints, apiServerAddress, supervisorAddress string) bool {
+	addresses := map[string]string{}
+	if value := endpoint.Annotations[util.SupervisorAddressesAnnotation]; value != "" {
+		if err := json.Unmarshal([]byte(value), &addresses); err != nil {
+			logrus.Warnf("Replacing invalid %s annotation: %v", util.SupervisorAddressesAnnotation, err)
+			addresses = map[string]string{}
+		}
+	}
+	if addresses[apiServerAddress] == supervisorAddress {
+		return false
+	}
+	if supervisorAddress == "" {
+		delete(addresses, apiServerAddress)
+	} else {
+		addresses[apiServerAddress] = supervisorAddress
+	}
+
+	if endpoint.Annotations == nil {
+		endpoint.Annotations = map[string]string{}
+	}
+	if len(addresses) == 0 {
+		delete(endpoint.Annotations, util.SupervisorAddressesAnnotation)
+		return true
+	}
+	b, _ := json.Marshal(addresses)
+	endpoint.Annotations[util.SupervisorAddressesAnnotation] = string(b)
+	return true
+}
+
+// apiServerAdvertiseAddress returns the address that the apiserver lists in the kubernetes service endpoints.
+// As with the apiserver, this is the advertise address if one was set, or the apiserver bind address if it is
+// a specific address, or the address of the host interface.
+func apiServerAdvertiseAddress(config *Config) string {
+	if config.ControlConfig.AdvertiseIP != "" {
+		return config.ControlConfig.AdvertiseIP
+	}
+	if ip := net.ParseIP(config.ControlConfig.APIServerBindAddress); ip != nil && !ip.IsUnspecified() && !ip.IsLoopback() {
+		return ip.String()
+	}
+	if hostIP, err := utilnet.ChooseHostInterface(); err == nil {
+		return hostIP.String()
+	}
+	return ""
+}
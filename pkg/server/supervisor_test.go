@@ -0,0 +1,81 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/k3s-io/k3s/pkg/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_UnitUpdateSupervisorAddresses(t *testing.T) {
+	tests := []struct {
+		name              string
+		annotation        string
+		supervisorAddress string
+		want              string
+		wantChanged       bool
+	}{
+		{
+			name:              "add first address",
+			supervisorAddress: "192.168.1.10:9345",
+			want:              `{"10.0.0.1":"192.168.1.10:9345"}`,
+			wantChanged:       true,
+		},
+		{
+			name:              "add to existing addresses",
+			annotation:        `{"10.0.0.2":"192.168.1.20:9345"}`,
+			supervisorAddress: "192.168.1.10:9345",
+			want:              `{"10.0.0.1":"192.168.1.10:9345","10.0.0.2":"192.168.1.20:9345"}`,
+			wantChanged:       true,
+		},
+		{
+			name:              "unchanged address",
+			annotation:        `{"10.0.0.1":"192.168.1.10:9345"}`,
+			supervisorAddress: "192.168.1.10:9345",
+			want:              `{"10.0.0.1":"192.168.1.10:9345"}`,
+		},
+		{
+			name:              "replace address",
+			annotation:        `{"10.0.0.1":"192.168.1.11:9345"}`,
+			supervisorAddress: "192.168.1.10:9345",
+			want:              `{"10.0.0.1":"192.168.1.10:9345"}`,
+			wantChanged:       true,
+		},
+		{
+			name:        "remove address",
+			annotation:  `{"10.0.0.1":"192.168.1.10:9345","10.0.0.2":"192.168.1.20:9345"}`,
+			want:        `{"10.0.0.2":"192.168.1.20:9345"}`,
+			wantChanged: true,
+		},
+		{
+			name:        "remove last address",
+			annotation:  `{"10.0.0.1":"192.168.1.10:9345"}`,
+			wantChanged: true,
+		},
+		{
+			name: "nothing to remove",
+		},
+		{
+			name:              "invalid annotation",
+			annotation:        "192.168.1.10:9345",
+			supervisorAddress: "192.168.1.10:9345",
+			want:              `{"10.0.0.1":"192.168.1.10:9345"}`,
+			wantChanged:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := &corev1.Endpoints{}
+			if tt.annotation != "" {
+				endpoint.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{util.SupervisorAddressesAnnotation: tt.annotation}}
+			}
+			if got := updateSupervisorAddresses(endpoint, "10.0.0.1", tt.supervisorAddress); got != tt.wantChanged {
+				t.Errorf("updateSupervisorAddresses() = %v, want %v", got, tt.wantChanged)
+			}
+			if got := endpoint.Annotations[util.SupervisorAddressesAnnotation]; got != tt.want {
+				t.Errorf("annotation = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,3 +6,7 @@ package static
 func Stage(dataDir string) error {
 	return nil
 }
+
+func Read(name string) []byte {
+	return nil
+}
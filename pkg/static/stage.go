@@ -26,3 +26,12 @@ func Stage(dataDir string) error {
 
 	return nil
 }
+
+// Read returns the content of the named static file, or nil if the file is not included in this build.
+func Read(name string) []byte {
+	content, err := Asset(name)
+	if err != nil {
+		return nil
+	}
+	return content
+}
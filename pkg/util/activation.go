@@ -0,0 +1,60 @@
+//go:build !windows
+
+package util
+
+import (
+	"net"
+	"sync"
+
+	"github.com/coreos/go-systemd/activation"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	activatedListenersOnce sync.Once
+	activatedListenersMu   sync.Mutex
+	activatedListeners     = map[int]net.Listener{}
+)
+
+// loadActivatedListeners collects the TCP listeners passed to this process by systemd socket activation,
+// keyed by port. The socket activation environment variables are unset, so that the sockets are not
+// claimed by child processes.
+func loadActivatedListeners() {
+	activatedListenersOnce.Do(func() {
+		listeners, err := activation.Listeners()
+		if err != nil {
+			logrus.Warnf("Failed to get listeners from systemd socket activation: %v", err)
+			return
+		}
+		for _, listener := range listeners {
+			if listener == nil {
+				continue
+			}
+			if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+				logrus.Infof("Found listener for %s from systemd socket activation", addr)
+				activatedListeners[addr.Port] = listener
+			}
+		}
+	})
+}
+
+// HasActivatedListener returns true if a listener for the port was passed by systemd socket activation.
+func HasActivatedListener(port int) bool {
+	loadActivatedListeners()
+	activatedListenersMu.Lock()
+	defer activatedListenersMu.Unlock()
+	_, ok := activatedListeners[port]
+	return ok
+}
+
+// ActivatedListener returns the listener for the port that was passed by systemd socket activation, or nil
+// if there is none. Each listener is only returned once. As the socket is held open by systemd while the
+// service is restarted, connections made while the service is stopped are queued instead of refused.
+func ActivatedListener(port int) net.Listener {
+	loadActivatedListeners()
+	activatedListenersMu.Lock()
+	defer activatedListenersMu.Unlock()
+	listener := activatedListeners[port]
+	delete(activatedListeners, port)
+	return listener
+}
@@ -0,0 +1,13 @@
+package util
+
+import "net"
+
+// HasActivatedListener always returns false, as systemd socket activation is not available on windows.
+func HasActivatedListener(port int) bool {
+	return false
+}
+
+// ActivatedListener always returns nil, as systemd socket activation is not available on windows.
+func ActivatedListener(port int) net.Listener {
+	return nil
+}
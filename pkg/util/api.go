@@ -2,6 +2,7 @@ package util
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/k3s-io/k3s/pkg/version"
 	"github.com/pkg/errors"
 	"github.com/rancher/wrangler/pkg/merr"
 	"github.com/rancher/wrangler/pkg/schemes"
@@ -33,6 +35,12 @@ import (
 // be at least long enough for downstream projects like RKE2 to start the apiserver in the background.
 const DefaultAPIServerReadyTimeout = 15 * time.Minute
 
+// SupervisorAddressesAnnotation is set on the kubernetes service endpoints, and maps the address of each
+// apiserver to the supervisor address advertised by the same server, for servers that advertise a
+// supervisor address that differs from the apiserver address. The endpoints are used because they are
+// already watched by agents to discover servers, and only list servers with a running apiserver.
+var SupervisorAddressesAnnotation = version.Program + ".io/supervisor-addresses"
+
 func GetAddresses(endpoint *v1.Endpoints) []string {
 	serverAddresses := []string{}
 	if endpoint == nil {
@@ -53,6 +61,28 @@ func GetAddresses(endpoint *v1.Endpoints) []string {
 	return serverAddresses
 }
 
+// GetSupervisorAddresses returns the advertised supervisor addresses from the kubernetes service endpoints,
+// keyed by apiserver IP. Entries for apiservers that are not currently listed in the endpoints are omitted.
+func GetSupervisorAddresses(endpoint *v1.Endpoints) map[string]string {
+	addresses := map[string]string{}
+	if endpoint == nil || endpoint.Annotations[SupervisorAddressesAnnotation] == "" {
+		return addresses
+	}
+	advertised := map[string]string{}
+	if err := json.Unmarshal([]byte(endpoint.Annotations[SupervisorAddressesAnnotation]), &advertised); err != nil {
+		logrus.Errorf("Failed to decode %s annotation: %v", SupervisorAddressesAnnotation, err)
+		return addresses
+	}
+	for _, subset := range endpoint.Subsets {
+		for _, address := range subset.Addresses {
+			if supervisorAddress, ok := advertised[address.IP]; ok {
+				addresses[address.IP] = supervisorAddress
+			}
+		}
+	}
+	return addresses
+}
+
 // WaitForAPIServerReady waits for the API Server's /readyz endpoint to report "ok" with timeout.
 // This is modified from WaitForAPIServer from the Kubernetes controller-manager app, but checks the
 // readyz endpoint instead of the deprecated healthz endpoint, and supports context.
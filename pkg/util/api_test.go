@@ -0,0 +1,55 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_UnitGetSupervisorAddresses(t *testing.T) {
+	subsets := []v1.EndpointSubset{{
+		Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+		Ports:     []v1.EndpointPort{{Port: 6443}},
+	}}
+	tests := []struct {
+		name       string
+		annotation string
+		want       map[string]string
+	}{
+		{
+			name: "no annotation",
+			want: map[string]string{},
+		},
+		{
+			name:       "advertised addresses",
+			annotation: `{"10.0.0.1":"192.168.1.10:9345","10.0.0.2":"192.168.1.20:9345"}`,
+			want:       map[string]string{"10.0.0.1": "192.168.1.10:9345", "10.0.0.2": "192.168.1.20:9345"},
+		},
+		{
+			name:       "stale address",
+			annotation: `{"10.0.0.1":"192.168.1.10:9345","10.0.0.3":"192.168.1.30:9345"}`,
+			want:       map[string]string{"10.0.0.1": "192.168.1.10:9345"},
+		},
+		{
+			name:       "invalid annotation",
+			annotation: "192.168.1.10:9345",
+			want:       map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint := &v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+				Subsets:    subsets,
+			}
+			if tt.annotation != "" {
+				endpoint.Annotations[SupervisorAddressesAnnotation] = tt.annotation
+			}
+			if got := GetSupervisorAddresses(endpoint); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSupervisorAddresses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
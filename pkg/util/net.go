@@ -179,7 +179,7 @@ func ParseStringSliceToIPs(s cli.StringSlice) ([]net.IP, error) {
 	var ips []net.IP
 	for _, unparsedIP := range s {
 		for _, v := range strings.Split(unparsedIP, ",") {
-			ip := net.ParseIP(v)
+			ip := ParseIP(v)
 			if ip == nil {
 				return nil, fmt.Errorf("invalid ip format '%s'", v)
 			}
@@ -190,13 +190,40 @@ func ParseStringSliceToIPs(s cli.StringSlice) ([]net.IP, error) {
 	return ips, nil
 }
 
+// ParseIP parses an IP address, ignoring surrounding whitespace and the square brackets
+// that enclose IPv6 addresses in URLs. If the address is not valid, nil is returned.
+func ParseIP(s string) net.IP {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+	}
+	return net.ParseIP(s)
+}
+
+// NormalizeSANs returns the list of certificate SANs with IP addresses in canonical form,
+// so that bracketed or non-canonical IPv6 addresses are not treated as DNS names.
+// Empty entries are omitted.
+func NormalizeSANs(sans []string) []string {
+	result := []string{}
+	for _, san := range sans {
+		san = strings.TrimSpace(san)
+		if ip := ParseIP(san); ip != nil {
+			san = ip.String()
+		}
+		if san != "" {
+			result = append(result, san)
+		}
+	}
+	return result
+}
+
 // GetFirstValidIPString returns the first valid address from a list of IP address strings,
 // without preference for IP family. If no address are found, an empty string is returned.
 func GetFirstValidIPString(s cli.StringSlice) string {
 	for _, unparsedIP := range s {
 		for _, v := range strings.Split(unparsedIP, ",") {
-			if ip := net.ParseIP(v); ip != nil {
-				return v
+			if ip := ParseIP(v); ip != nil {
+				return ip.String()
 			}
 		}
 	}
@@ -48,6 +48,14 @@ func Test_UnitParseStringSliceToIPs(t *testing.T) {
 				net.ParseIP("10.10.10.13"),
 			},
 		},
+		{
+			name: "bracketed IPv6 address must succeed",
+			arg:  cli.StringSlice{"10.10.10.10,[fd00::1]"},
+			want: []net.IP{
+				net.ParseIP("10.10.10.10"),
+				net.ParseIP("fd00::1"),
+			},
+		},
 		{
 			name:    "single element slice with correct IP list with trailing comma must fail",
 			arg:     cli.StringSlice{"10.10.10.10,"},
@@ -82,3 +90,39 @@ func Test_UnitParseStringSliceToIPs(t *testing.T) {
 		)
 	}
 }
+
+func Test_UnitNormalizeSANs(t *testing.T) {
+	tests := []struct {
+		name string
+		arg  []string
+		want []string
+	}{
+		{
+			name: "empty list",
+			arg:  nil,
+			want: []string{},
+		},
+		{
+			name: "names and IPv4 addresses are unchanged",
+			arg:  []string{"example.com", "10.10.10.10"},
+			want: []string{"example.com", "10.10.10.10"},
+		},
+		{
+			name: "IPv6 addresses are unbracketed and canonicalized",
+			arg:  []string{"[fd00::1]", "FD00:0:0::2", " ::1 "},
+			want: []string{"fd00::1", "fd00::2", "::1"},
+		},
+		{
+			name: "empty entries are omitted",
+			arg:  []string{"", "example.com", " "},
+			want: []string{"example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeSANs(tt.arg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeSANs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
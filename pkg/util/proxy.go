@@ -0,0 +1,94 @@
+package util
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/k3s-io/k3s/pkg/version"
+	"golang.org/x/net/http/httpproxy"
+)
+
+var (
+	// ProxySupervisorPrefix is the prefix of proxy environment variables that apply to connections from agents to the
+	// supervisor, for example K3S_SUPERVISOR_HTTPS_PROXY. Connections to the supervisor do not use the global proxy variables.
+	ProxySupervisorPrefix = version.ProgramUpper + "_SUPERVISOR_"
+	// ProxyWebhookPrefix is the prefix of proxy environment variables that apply to outgoing notification webhooks,
+	// for example K3S_WEBHOOK_HTTPS_PROXY. If none are set, the global proxy variables are used.
+	ProxyWebhookPrefix = version.ProgramUpper + "_WEBHOOK_"
+)
+
+// GetProxyConfig returns the proxy configuration for a component, from the environment variables with the given prefix.
+// Variables are checked in upper and lower case, as with the global HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables. If
+// inherit is true, the global variables are used for any that are not set for the component. The component's NO_PROXY
+// is combined with the global NO_PROXY, so that automatically computed entries are retained.
+func GetProxyConfig(prefix string, inherit bool) *httpproxy.Config {
+	global := httpproxy.FromEnvironment()
+	config := &httpproxy.Config{
+		HTTPProxy:  getEnvAny(prefix+"HTTP_PROXY", prefix+"http_proxy"),
+		HTTPSProxy: getEnvAny(prefix+"HTTPS_PROXY", prefix+"https_proxy"),
+		NoProxy:    AppendNoProxy(global.NoProxy, getEnvAny(prefix+"NO_PROXY", prefix+"no_proxy")),
+		CGI:        global.CGI,
+	}
+	if inherit {
+		if config.HTTPProxy == "" {
+			config.HTTPProxy = global.HTTPProxy
+		}
+		if config.HTTPSProxy == "" {
+			config.HTTPSProxy = global.HTTPSProxy
+		}
+	}
+	return config
+}
+
+// ProxyFunc returns a function suitable for use as the Proxy field of a http.Transport, using the proxy configuration for
+// a component. The configuration is read from the environment on each request, so that changes made to the environment
+// after the transport is created, such as automatically computed NO_PROXY entries, are used.
+func ProxyFunc(prefix string, inherit bool) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		return GetProxyConfig(prefix, inherit).ProxyFunc()(req.URL)
+	}
+}
+
+// IsProxyConfigured returns true if a HTTP or HTTPS proxy is set in the environment, either globally or for any component.
+func IsProxyConfigured() bool {
+	for _, e := range os.Environ() {
+		pair := strings.SplitN(e, "=", 2)
+		if len(pair) != 2 || pair[1] == "" {
+			continue
+		}
+		name := strings.ToUpper(pair[0])
+		if strings.HasSuffix(name, "HTTP_PROXY") || strings.HasSuffix(name, "HTTPS_PROXY") {
+			return true
+		}
+	}
+	return false
+}
+
+// AppendNoProxy returns the comma-separated NO_PROXY value with the given entries appended.
+// Empty entries, and entries that are already present, are omitted.
+func AppendNoProxy(noProxy string, entries ...string) string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, list := range append([]string{noProxy}, entries...) {
+		for _, entry := range strings.Split(list, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" || seen[entry] {
+				continue
+			}
+			seen[entry] = true
+			result = append(result, entry)
+		}
+	}
+	return strings.Join(result, ",")
+}
+
+func getEnvAny(names ...string) string {
+	for _, n := range names {
+		if val := os.Getenv(n); val != "" {
+			return val
+		}
+	}
+	return ""
+}
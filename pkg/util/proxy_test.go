@@ -0,0 +1,100 @@
+package util
+
+import (
+	"net/url"
+	"testing"
+)
+
+func Test_UnitAppendNoProxy(t *testing.T) {
+	tests := []struct {
+		name    string
+		noProxy string
+		entries []string
+		want    string
+	}{
+		{
+			name: "empty",
+			want: "",
+		},
+		{
+			name:    "append to existing",
+			noProxy: "example.com,10.0.0.0/8",
+			entries: []string{".svc", "10.42.0.0/16,10.43.0.0/16"},
+			want:    "example.com,10.0.0.0/8,.svc,10.42.0.0/16,10.43.0.0/16",
+		},
+		{
+			name:    "duplicates and empty entries are omitted",
+			noProxy: "example.com,,.svc",
+			entries: []string{".svc", "", " example.com ", "127.0.0.1"},
+			want:    "example.com,.svc,127.0.0.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AppendNoProxy(tt.noProxy, tt.entries...); got != tt.want {
+				t.Errorf("AppendNoProxy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UnitGetProxyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		inherit bool
+		target  string
+		want    string
+	}{
+		{
+			name:    "component proxy takes precedence",
+			env:     map[string]string{"HTTPS_PROXY": "http://global:3128", "TEST_HTTPS_PROXY": "http://component:3128"},
+			inherit: true,
+			target:  "https://example.com",
+			want:    "http://component:3128",
+		},
+		{
+			name:    "global proxy inherited",
+			env:     map[string]string{"HTTPS_PROXY": "http://global:3128"},
+			inherit: true,
+			target:  "https://example.com",
+			want:    "http://global:3128",
+		},
+		{
+			name:    "global proxy not inherited",
+			env:     map[string]string{"HTTPS_PROXY": "http://global:3128"},
+			inherit: false,
+			target:  "https://example.com",
+		},
+		{
+			name:    "global no proxy applies to component",
+			env:     map[string]string{"NO_PROXY": "10.0.0.0/8", "TEST_https_proxy": "http://component:3128"},
+			inherit: false,
+			target:  "https://10.1.2.3:6443",
+		},
+		{
+			name:    "component no proxy",
+			env:     map[string]string{"NO_PROXY": "10.0.0.0/8", "TEST_HTTPS_PROXY": "http://component:3128", "TEST_NO_PROXY": "example.com"},
+			inherit: false,
+			target:  "https://example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy", "REQUEST_METHOD"} {
+				t.Setenv(name, "")
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			target, _ := url.Parse(tt.target)
+			got, err := GetProxyConfig("TEST_", tt.inherit).ProxyFunc()(target)
+			if err != nil {
+				t.Fatalf("ProxyFunc() error = %v", err)
+			}
+			if (got == nil && tt.want != "") || (got != nil && got.String() != tt.want) {
+				t.Errorf("ProxyFunc() = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
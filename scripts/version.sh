@@ -62,6 +62,8 @@ fi
 
 VERSION_ROOT="v0.12.2"
 
+VERSION_GATEWAY_API="v0.4.3"
+
 if [[ -n "$GIT_TAG" ]]; then
     if [[ ! "$GIT_TAG" =~ ^"$VERSION_K8S"[+-] ]]; then
         echo "Tagged version '$GIT_TAG' does not match expected version '$VERSION_K8S[+-]*'" >&2